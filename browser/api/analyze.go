@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/sego"
+)
+
+// maxAnalyzeTextLength 分词预览的文本长度上限（字节）
+const maxAnalyzeTextLength = 64 * 1024
+
+// analyzeText 返回文本的 sego 分词结果、被过滤的停用词和写入 content_tokens 的词，
+// 用于排查全文搜索查询为何没有命中文档
+func analyzeText(c *gin.Context) {
+	text := c.Query("text")
+	if strings.TrimSpace(text) == "" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "text is required"})
+		return
+	}
+	if len(text) > maxAnalyzeTextLength {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "text is too long"})
+		return
+	}
+
+	opts := sego.DefaultTokenizeOptions()
+	contentTokens := tokenizeWithSego(text)
+	resp := AnalyzeResponse{
+		Text:          text,
+		Normalized:    sego.Normalize(text, opts.Normalize),
+		Tokens:        []string{},
+		Stopwords:     []string{},
+		IndexedTokens: strings.Fields(contentTokens),
+		ContentTokens: contentTokens,
+	}
+
+	// 保留停用词和标点，得到过滤前的完整分词结果
+	opts.RemoveStopwords = false
+	opts.RemovePunctuation = false
+	for _, token := range sego.TokenizeWithOptions(text, opts) {
+		resp.Tokens = append(resp.Tokens, token)
+		if sego.IsStopword(token) {
+			resp.Stopwords = append(resp.Stopwords, token)
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// maxAttachmentSize 单个附件的大小上限（字节）
+const maxAttachmentSize = 100 << 20
+
+// blobMu 串行化 blob 文件的写入和清理，避免清理掉刚上传、尚未登记的 blob
+var blobMu sync.Mutex
+
+// sqlQueryExecer 抽象 *sql.DB 与 *sql.Tx 的读写操作
+type sqlQueryExecer interface {
+	sqlExecer
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// ensureAttachmentsTable 创建附件元数据表，附件内容按 SHA-256 存放在 blob 目录中，相同内容只存一份
+func ensureAttachmentsTable(db *sql.DB) error {
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS attachments (
+		collection_name VARCHAR NOT NULL,
+		document_id VARCHAR NOT NULL,
+		name VARCHAR NOT NULL,
+		content_type VARCHAR,
+		size BIGINT,
+		sha256 VARCHAR NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (collection_name, document_id, name)
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create attachments table: %w", err)
+	}
+	return nil
+}
+
+// blobDir 返回附件内容的存放目录，默认为数据目录下的 blobs
+func blobDir() string {
+	if dir := os.Getenv("BLOB_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(dataDir(), "blobs")
+}
+
+// blobPath 返回 blob 的存放路径，按摘要前两位分目录
+func blobPath(sum string) string {
+	return filepath.Join(blobDir(), sum[:2], sum)
+}
+
+// validateAttachmentName 校验附件名称，名称会出现在 URL 路径中
+func validateAttachmentName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("attachment name is required")
+	}
+	if len(name) > 255 {
+		return fmt.Errorf("attachment name must be at most 255 bytes")
+	}
+	if strings.ContainsAny(name, `/\`) || strings.IndexFunc(name, func(r rune) bool { return r < 0x20 }) >= 0 {
+		return fmt.Errorf("attachment name must not contain '/', '\\' or control characters")
+	}
+	return nil
+}
+
+// liveDocumentExists 判断文档存在且不在回收站中
+func liveDocumentExists(name, id string) (bool, error) {
+	var count int
+	err := sqlDB.QueryRow(`SELECT COUNT(*) FROM documents WHERE collection_name = ? AND id = ? AND `+liveDocumentsSQL(""), name, id).Scan(&count)
+	return count > 0, err
+}
+
+// uploadAttachment 以流式方式上传附件，请求体为附件内容，Content-Type 请求头为附件类型
+// 同名附件会被覆盖
+func uploadAttachment(c *gin.Context) {
+	name := c.Param("name")
+	id := c.Param("id")
+	attachmentName := c.Param("attachment")
+	if err := validateAttachmentName(attachmentName); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	exists, err := liveDocumentExists(name, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Document not found"})
+		return
+	}
+
+	if err := os.MkdirAll(blobDir(), 0o755); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	tmp, err := os.CreateTemp(blobDir(), ".upload-*")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxAttachmentSize)
+	size, err := io.Copy(io.MultiWriter(tmp, hash), body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{Error: fmt.Sprintf("attachment exceeds %d bytes", maxAttachmentSize)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("failed to read attachment: %v", err)})
+		return
+	}
+
+	sum := hex.EncodeToString(hash.Sum(nil))
+	contentType := c.GetHeader("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	blobMu.Lock()
+	defer blobMu.Unlock()
+
+	path := blobPath(sum)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		if err := os.Rename(tmp.Name(), path); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	var previous sql.NullString
+	sqlDB.QueryRow(`SELECT sha256 FROM attachments WHERE collection_name = ? AND document_id = ? AND name = ?`,
+		name, id, attachmentName).Scan(&previous)
+	if _, err := sqlDB.Exec(`INSERT OR REPLACE INTO attachments (collection_name, document_id, name, content_type, size, sha256, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`, name, id, attachmentName, contentType, size, sum); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if previous.Valid && previous.String != sum {
+		removeUnreferencedBlobsLocked([]string{previous.String})
+	}
+
+	attachment, err := loadAttachment(name, id, attachmentName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"collection": name,
+		"id":         id,
+		"attachment": attachmentName,
+		"size":       size,
+	}).Info("📎 Attachment uploaded")
+
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// loadAttachment 读取附件元数据，文档不存在或在回收站中时返回 sql.ErrNoRows
+func loadAttachment(name, id, attachmentName string) (Attachment, error) {
+	var a Attachment
+	var contentType sql.NullString
+	err := sqlDB.QueryRow(`SELECT a.name, a.content_type, a.size, a.sha256, a.created_at
+		FROM attachments a JOIN documents d ON d.collection_name = a.collection_name AND d.id = a.document_id
+		WHERE a.collection_name = ? AND a.document_id = ? AND a.name = ? AND `+liveDocumentsSQL("d."),
+		name, id, attachmentName).Scan(&a.Name, &contentType, &a.Size, &a.SHA256, &a.CreatedAt)
+	a.ContentType = contentType.String
+	return a, err
+}
+
+// listAttachments 列出文档的附件
+func listAttachments(c *gin.Context) {
+	name := c.Param("name")
+	id := c.Param("id")
+
+	exists, err := liveDocumentExists(name, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Document not found"})
+		return
+	}
+
+	rows, err := sqlDB.Query(`SELECT name, content_type, size, sha256, created_at FROM attachments
+		WHERE collection_name = ? AND document_id = ? ORDER BY name`, name, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	attachments := make([]Attachment, 0)
+	for rows.Next() {
+		var a Attachment
+		var contentType sql.NullString
+		if err := rows.Scan(&a.Name, &contentType, &a.Size, &a.SHA256, &a.CreatedAt); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		a.ContentType = contentType.String
+		attachments = append(attachments, a)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"attachments": attachments})
+}
+
+// downloadAttachment 以流式方式下载附件，支持 Range 和 If-None-Match 请求
+func downloadAttachment(c *gin.Context) {
+	attachment, err := loadAttachment(c.Param("name"), c.Param("id"), c.Param("attachment"))
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Attachment not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	f, err := os.Open(blobPath(attachment.SHA256))
+	if err != nil {
+		logrus.WithError(err).WithField("sha256", attachment.SHA256).Error("Attachment blob missing")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "attachment content is missing"})
+		return
+	}
+	defer f.Close()
+
+	c.Header("Content-Type", attachment.ContentType)
+	c.Header("ETag", `"`+attachment.SHA256+`"`)
+	c.Header("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": attachment.Name}))
+	http.ServeContent(c.Writer, c.Request, attachment.Name, attachment.CreatedAt, f)
+}
+
+// deleteAttachment 删除文档的一个附件
+func deleteAttachment(c *gin.Context) {
+	name := c.Param("name")
+	id := c.Param("id")
+	attachmentName := c.Param("attachment")
+
+	if _, err := loadAttachment(name, id, attachmentName); err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Attachment not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	sums, err := deleteAttachmentRows(sqlDB, `collection_name = ? AND document_id = ? AND name = ?`, name, id, attachmentName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	removeUnreferencedBlobs(sums)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Attachment deleted"})
+}
+
+// deleteAttachmentRows 删除满足条件的附件记录，返回它们引用的 blob 摘要
+// 在事务中调用时，应在提交后再用 removeUnreferencedBlobs 清理 blob 文件；附件表不存在时不做任何事
+func deleteAttachmentRows(q sqlQueryExecer, where string, args ...interface{}) ([]string, error) {
+	if ok, err := attachmentsTableExists(q); err != nil || !ok {
+		return nil, err
+	}
+
+	rows, err := q.Query(`SELECT DISTINCT sha256 FROM attachments WHERE `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	var sums []string
+	for rows.Next() {
+		var sum string
+		if err := rows.Scan(&sum); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		sums = append(sums, sum)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(sums) == 0 {
+		return nil, nil
+	}
+
+	if _, err := q.Exec(`DELETE FROM attachments WHERE `+where, args...); err != nil {
+		return nil, err
+	}
+	return sums, nil
+}
+
+// attachmentsTableExists 判断附件表是否存在（旧数据库中可能没有）
+func attachmentsTableExists(q sqlQueryer) (bool, error) {
+	var exists int
+	err := q.QueryRow(`SELECT COUNT(*) FROM information_schema.tables WHERE table_name = 'attachments'`).Scan(&exists)
+	return exists > 0, err
+}
+
+// renameCollectionAttachments 重命名集合时迁移附件记录
+func renameCollectionAttachments(q sqlQueryExecer, name, newName string) error {
+	if ok, err := attachmentsTableExists(q); err != nil || !ok {
+		return err
+	}
+	_, err := q.Exec(`UPDATE attachments SET collection_name = ? WHERE collection_name = ?`, newName, name)
+	return err
+}
+
+// deleteDocumentAttachments 删除文档的所有附件记录
+func deleteDocumentAttachments(q sqlQueryExecer, name, id string) ([]string, error) {
+	return deleteAttachmentRows(q, `collection_name = ? AND document_id = ?`, name, id)
+}
+
+// deleteOrphanAttachments 删除集合中文档已被永久删除的附件记录（回收站中文档的附件会保留）
+func deleteOrphanAttachments(q sqlQueryExecer, name string) ([]string, error) {
+	return deleteAttachmentRows(q, `collection_name = ? AND NOT EXISTS (
+		SELECT 1 FROM documents d WHERE d.collection_name = attachments.collection_name AND d.id = attachments.document_id)`, name)
+}
+
+// removeUnreferencedBlobs 删除不再被任何附件引用的 blob 文件
+func removeUnreferencedBlobs(sums []string) {
+	if len(sums) == 0 {
+		return
+	}
+	blobMu.Lock()
+	defer blobMu.Unlock()
+	removeUnreferencedBlobsLocked(sums)
+}
+
+// removeUnreferencedBlobsLocked 同 removeUnreferencedBlobs，调用方需持有 blobMu
+func removeUnreferencedBlobsLocked(sums []string) {
+	for _, sum := range sums {
+		var count int
+		if err := sqlDB.QueryRow(`SELECT COUNT(*) FROM attachments WHERE sha256 = ?`, sum).Scan(&count); err != nil {
+			logrus.WithError(err).WithField("sha256", sum).Warn("Failed to check blob references")
+			continue
+		}
+		if count > 0 {
+			continue
+		}
+		if err := os.Remove(blobPath(sum)); err != nil && !os.IsNotExist(err) {
+			logrus.WithError(err).WithField("sha256", sum).Warn("Failed to remove blob")
+		}
+	}
+}
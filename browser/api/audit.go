@@ -0,0 +1,269 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// requestIDHeader 请求 ID 的请求头和响应头，客户端提供时沿用，否则由服务端生成
+	requestIDHeader = "X-Request-ID"
+	// requestIDContextKey 请求 ID 在 gin.Context 中的键
+	requestIDContextKey = "request.id"
+	// touchedDocumentsContextKey 请求涉及的文档在 gin.Context 中的键
+	touchedDocumentsContextKey = "request.documents"
+	// maxLoggedDocumentIDs 请求日志中最多列出的文档 ID 数，批量导入时只记录总数
+	maxLoggedDocumentIDs = 20
+)
+
+// auditEnabled 是否把写操作记录到 audit_log 表，由环境变量 AUDIT_LOG=true 开启（测试中可替换）
+var auditEnabled bool
+
+// touchedDocument 请求修改的一个文档，before 为修改前的快照
+type touchedDocument struct {
+	collection string
+	id         string
+	before     sql.NullString
+}
+
+// requestLogMiddleware 为每个请求分配请求 ID，并在请求结束后输出结构化日志：
+// 路由、状态码、耗时、客户端、API Key、集合和涉及的文档 ID
+func requestLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		requestID := c.GetHeader(requestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = generateID()
+		}
+		c.Set(requestIDContextKey, requestID)
+		c.Header(requestIDHeader, requestID)
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		status := c.Writer.Status()
+		fields := logrus.Fields{
+			"request_id": requestID,
+			"method":     c.Request.Method,
+			"route":      route,
+			"status":     status,
+			"latency_ms": time.Since(start).Milliseconds(),
+			"client_ip":  c.ClientIP(),
+			"bytes":      c.Writer.Size(),
+		}
+		if key := requestAPIKeyName(c); key != "" {
+			fields["api_key"] = key
+		}
+		if name := c.Param("name"); name != "" {
+			fields["collection"] = name
+		}
+		if docs := touchedDocuments(c); len(docs) > 0 {
+			ids := make([]string, 0, min(len(docs), maxLoggedDocumentIDs))
+			for _, doc := range docs[:min(len(docs), maxLoggedDocumentIDs)] {
+				ids = append(ids, doc.id)
+			}
+			fields["doc_ids"] = ids
+			fields["doc_count"] = len(docs)
+		}
+		if len(c.Errors) > 0 {
+			fields["errors"] = c.Errors.String()
+		}
+
+		entry := logrus.WithFields(fields)
+		switch {
+		case status >= http.StatusInternalServerError:
+			entry.Error("Request failed")
+		case status >= http.StatusBadRequest:
+			entry.Warn("Request rejected")
+		default:
+			entry.Info("Request handled")
+		}
+	}
+}
+
+// requestAPIKeyName 返回认证通过的 API Key 名称，未启用认证时为空
+func requestAPIKeyName(c *gin.Context) string {
+	if value, ok := c.Get(authKeyContextKey); ok {
+		return value.(*APIKey).Name
+	}
+	return ""
+}
+
+// touchedDocumentSet 请求中登记的文档，按登记顺序保存
+type touchedDocumentSet struct {
+	docs  []*touchedDocument
+	index map[[2]string]struct{}
+}
+
+// touchedDocuments 返回请求中通过 noteDocument 登记的文档
+func touchedDocuments(c *gin.Context) []*touchedDocument {
+	if value, ok := c.Get(touchedDocumentsContextKey); ok {
+		return value.(*touchedDocumentSet).docs
+	}
+	return nil
+}
+
+// noteDocument 登记请求将要修改的文档，用于请求日志和审计记录
+// 启用审计时在修改前调用，通过 q（*sql.DB 或批量导入的事务）读取修改前的快照；同一文档只登记一次
+func noteDocument(c *gin.Context, q sqlQueryer, collection, id string) {
+	var set *touchedDocumentSet
+	if value, ok := c.Get(touchedDocumentsContextKey); ok {
+		set = value.(*touchedDocumentSet)
+	} else {
+		set = &touchedDocumentSet{index: make(map[[2]string]struct{})}
+		c.Set(touchedDocumentsContextKey, set)
+	}
+	key := [2]string{collection, id}
+	if _, ok := set.index[key]; ok {
+		return
+	}
+	set.index[key] = struct{}{}
+
+	doc := &touchedDocument{collection: collection, id: id}
+	if auditEnabled {
+		snapshot, err := documentSnapshot(q, collection, id)
+		if err != nil {
+			logrus.WithError(err).WithField("id", id).Warn("Failed to read document snapshot for audit")
+		}
+		doc.before = snapshot
+	}
+	set.docs = append(set.docs, doc)
+}
+
+// auditMiddleware 启用审计时把写操作记录到 audit_log 表
+// 路由包含文档 ID 时自动登记该文档；新建和批量导入的文档由处理函数调用 noteDocument 登记。
+// 每个登记的文档记录一行修改前后的快照，不涉及具体文档的写操作（如删除集合、图操作）记录一行不带快照的记录
+func auditMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !auditEnabled || c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead || c.Request.Method == http.MethodOptions {
+			c.Next()
+			return
+		}
+
+		if name, id := c.Param("name"), c.Param("id"); name != "" && id != "" {
+			noteDocument(c, sqlDB, name, id)
+		}
+
+		c.Next()
+
+		docs := touchedDocuments(c)
+		if len(docs) == 0 {
+			docs = []*touchedDocument{{collection: c.Param("name")}}
+		}
+		for _, doc := range docs {
+			var after sql.NullString
+			if doc.id != "" {
+				var err error
+				if after, err = documentSnapshot(sqlDB, doc.collection, doc.id); err != nil {
+					logrus.WithError(err).WithField("id", doc.id).Warn("Failed to read document snapshot for audit")
+				}
+			}
+			if err := insertAuditRecord(c, doc, after); err != nil {
+				logrus.WithError(err).Error("Failed to write audit record")
+				return
+			}
+		}
+	}
+}
+
+// documentSnapshot 读取文档数据作为审计快照，包含回收站中的文档（带 _deleted_at 字段），文档不存在时返回 NULL
+func documentSnapshot(q sqlQueryer, collection, id string) (sql.NullString, error) {
+	var snapshot sql.NullString
+	deletedAt := "NULL"
+	if hasDeletedAtColumn() {
+		deletedAt = "deleted_at"
+	}
+	var dataJSON string
+	var deleted sql.NullTime
+	query := fmt.Sprintf(`SELECT data, %s FROM documents WHERE collection_name = ? AND id = ?`, deletedAt)
+	err := q.QueryRow(query, collection, id).Scan(&dataJSON, &deleted)
+	if err == sql.ErrNoRows {
+		return snapshot, nil
+	}
+	if err != nil {
+		return snapshot, err
+	}
+	if deleted.Valid {
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(dataJSON), &data); err == nil {
+			data["_deleted_at"] = deleted.Time
+			if raw, err := json.Marshal(data); err == nil {
+				dataJSON = string(raw)
+			}
+		}
+	}
+	return sql.NullString{String: dataJSON, Valid: true}, nil
+}
+
+// insertAuditRecord 写入一条审计记录
+func insertAuditRecord(c *gin.Context, doc *touchedDocument, after sql.NullString) error {
+	route := c.FullPath()
+	if route == "" {
+		route = c.Request.URL.Path
+	}
+	_, err := sqlDB.Exec(`INSERT INTO audit_log (id, request_id, api_key, client_ip, method, route, status, collection_name, document_id, before_data, after_data)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		generateID(), c.GetString(requestIDContextKey), requestAPIKeyName(c), c.ClientIP(), c.Request.Method, route,
+		c.Writer.Status(), nullIfEmpty(doc.collection), nullIfEmpty(doc.id), doc.before, after)
+	return err
+}
+
+// nullIfEmpty 空字符串写入 NULL
+func nullIfEmpty(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// ensureAuditTable 创建审计表
+func ensureAuditTable(db *sql.DB) error {
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS audit_log (
+		id VARCHAR PRIMARY KEY,
+		request_id VARCHAR,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		api_key VARCHAR,
+		client_ip VARCHAR,
+		method VARCHAR,
+		route VARCHAR,
+		status INTEGER,
+		collection_name VARCHAR,
+		document_id VARCHAR,
+		before_data TEXT,
+		after_data TEXT
+	);
+	CREATE INDEX IF NOT EXISTS idx_audit_log_document ON audit_log(collection_name, document_id);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create audit_log table: %w", err)
+	}
+	return nil
+}
+
+// initAudit 读取 AUDIT_LOG，开启时创建审计表
+func initAudit() error {
+	if v := os.Getenv("AUDIT_LOG"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid AUDIT_LOG: %q", v)
+		}
+		auditEnabled = enabled
+	}
+	if !auditEnabled {
+		return nil
+	}
+	if err := ensureAuditTable(sqlDB); err != nil {
+		return err
+	}
+	logrus.Info("📝 Audit log enabled")
+	return nil
+}
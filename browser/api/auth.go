@@ -0,0 +1,251 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// API Key 权限范围，admin 包含 write，write 包含 read
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeAdmin = "admin"
+
+	// authKeyContextKey 认证通过的 API Key 在 gin.Context 中的键
+	authKeyContextKey = "auth.api_key"
+)
+
+// scopeLevels 权限范围的级别，用于判断包含关系
+var scopeLevels = map[string]int{
+	ScopeRead:  1,
+	ScopeWrite: 2,
+	ScopeAdmin: 3,
+}
+
+// routeScopes 需要特殊权限的路由，其余路由 GET 需要 read，其他方法需要 write
+// 键为 "METHOD 路由模板"（gin 的 FullPath）
+var routeScopes = map[string]string{
+	// 只读的 POST 查询
+	"POST /api/collections/:name/query":           ScopeRead,
+	"POST /api/collections/:name/fulltext/search": ScopeRead,
+	"POST /api/collections/:name/vector/search":   ScopeRead,
+	"POST /api/collections/:name/vector/msearch":  ScopeRead,
+	"POST /api/collections/:name/search":          ScopeRead,
+	"POST /api/graph/path":                        ScopeRead,
+	"POST /api/graph/query":                       ScopeRead,
+
+	// 集合管理和后台任务
+	"POST /api/collections":               ScopeAdmin,
+	"PATCH /api/collections/:name":        ScopeAdmin,
+	"DELETE /api/collections/:name":       ScopeAdmin,
+	"POST /api/collections/:name/reembed": ScopeAdmin,
+	"DELETE /api/collections/:name/trash": ScopeAdmin,
+
+	// 备份和恢复
+	"POST /api/admin/backup":       ScopeAdmin,
+	"GET /api/admin/backups":       ScopeAdmin,
+	"GET /api/admin/backups/:file": ScopeAdmin,
+	"POST /api/admin/restore":      ScopeAdmin,
+}
+
+// sharedRoutePrefixes 访问所有集合共享的数据的路由（如知识图谱），只允许可以访问所有集合的 API Key
+var sharedRoutePrefixes = []string{
+	"/api/graph/",
+}
+
+// APIKey 一个 API Key 及其权限
+type APIKey struct {
+	Name        string   `json:"name"`
+	Key         string   `json:"key"`
+	Scopes      []string `json:"scopes"`                // read / write / admin
+	Collections []string `json:"collections,omitempty"` // 允许访问的集合，支持通配符（如 logs_*），为空表示全部
+}
+
+// apiKeys 已配置的 API Key，为空时不启用认证（测试中可替换）
+var apiKeys []APIKey
+
+// loadAPIKeys 从环境变量 API_KEYS（JSON 数组）或 API_KEYS_FILE 指向的文件加载 API Key
+func loadAPIKeys() ([]APIKey, error) {
+	raw := os.Getenv("API_KEYS")
+	if file := os.Getenv("API_KEYS_FILE"); file != "" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read API_KEYS_FILE: %w", err)
+		}
+		raw = string(content)
+	}
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+
+	var keys []APIKey
+	if err := json.Unmarshal([]byte(raw), &keys); err != nil {
+		return nil, fmt.Errorf("invalid API_KEYS: %w", err)
+	}
+	for i, key := range keys {
+		if key.Key == "" {
+			return nil, fmt.Errorf("API key %d has an empty key", i)
+		}
+		if len(key.Scopes) == 0 {
+			return nil, fmt.Errorf("API key %q has no scopes", key.Name)
+		}
+		for _, scope := range key.Scopes {
+			if _, ok := scopeLevels[scope]; !ok {
+				return nil, fmt.Errorf("API key %q has unknown scope %q", key.Name, scope)
+			}
+		}
+		for _, pattern := range key.Collections {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("API key %q has invalid collection pattern %q", key.Name, pattern)
+			}
+		}
+	}
+	return keys, nil
+}
+
+// authMiddleware 校验请求中的 API Key（Authorization: Bearer <key> 或 X-API-Key），
+// 并按路由所需权限和集合 ACL 授权；未配置任何 API Key 时直接放行
+func authMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(apiKeys) == 0 || c.Request.Method == http.MethodOptions {
+			c.Next()
+			return
+		}
+
+		token := requestToken(c.Request)
+		if token == "" {
+			c.Header("WWW-Authenticate", `Bearer realm="browser-api"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: "missing API key"})
+			return
+		}
+		key := findAPIKey(token)
+		if key == nil {
+			c.Header("WWW-Authenticate", `Bearer realm="browser-api", error="invalid_token"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse{Error: "invalid API key"})
+			return
+		}
+
+		scope := requiredScope(c.Request.Method, c.FullPath())
+		if !key.hasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{Error: fmt.Sprintf("API key requires %s scope", scope)})
+			return
+		}
+		if name := c.Param("name"); name != "" && !key.allowsCollection(name) {
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{Error: fmt.Sprintf("API key is not allowed to access collection %s", name)})
+			return
+		}
+		if len(key.Collections) > 0 && isSharedRoute(c.FullPath()) {
+			c.AbortWithStatusJSON(http.StatusForbidden, ErrorResponse{Error: "API key must be allowed to access all collections"})
+			return
+		}
+
+		c.Set(authKeyContextKey, key)
+		c.Next()
+	}
+}
+
+// requestToken 读取请求中的 API Key
+func requestToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if scheme, token, ok := strings.Cut(auth, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
+
+// findAPIKey 查找与 token 匹配的 API Key，使用常量时间比较
+func findAPIKey(token string) *APIKey {
+	var found *APIKey
+	for i := range apiKeys {
+		if subtle.ConstantTimeCompare([]byte(apiKeys[i].Key), []byte(token)) == 1 {
+			found = &apiKeys[i]
+		}
+	}
+	return found
+}
+
+// isSharedRoute 判断路由是否访问所有集合共享的数据
+func isSharedRoute(fullPath string) bool {
+	for _, prefix := range sharedRoutePrefixes {
+		if strings.HasPrefix(fullPath, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// requiredScope 返回路由需要的权限范围
+func requiredScope(method, fullPath string) string {
+	if scope, ok := routeScopes[method+" "+fullPath]; ok {
+		return scope
+	}
+	if method == http.MethodGet || method == http.MethodHead {
+		return ScopeRead
+	}
+	return ScopeWrite
+}
+
+// hasScope 判断 API Key 是否具有指定权限（高级别权限包含低级别权限）
+func (k *APIKey) hasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if scopeLevels[s] >= scopeLevels[scope] {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsCollection 判断 API Key 是否可以访问集合
+func (k *APIKey) allowsCollection(name string) bool {
+	if len(k.Collections) == 0 {
+		return true
+	}
+	for _, pattern := range k.Collections {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// collectionAllowed 判断当前请求的 API Key 是否可以访问集合，未启用认证时总是允许
+func collectionAllowed(c *gin.Context, name string) bool {
+	value, ok := c.Get(authKeyContextKey)
+	if !ok {
+		return true
+	}
+	return value.(*APIKey).allowsCollection(name)
+}
+
+// allCollectionsAllowed 判断当前请求的 API Key 是否可以访问所有集合，用于备份、恢复等涉及全部数据的操作
+func allCollectionsAllowed(c *gin.Context) bool {
+	value, ok := c.Get(authKeyContextKey)
+	if !ok {
+		return true
+	}
+	return len(value.(*APIKey).Collections) == 0
+}
+
+// initAuth 加载 API Key 配置，未配置时给出提示
+func initAuth() error {
+	keys, err := loadAPIKeys()
+	if err != nil {
+		return err
+	}
+	apiKeys = keys
+	if len(apiKeys) == 0 {
+		logrus.Warn("⚠️ API_KEYS is not set, authentication is disabled; do not expose the server beyond localhost")
+	} else {
+		logrus.WithField("keys", len(apiKeys)).Info("🔐 API key authentication enabled")
+	}
+	return nil
+}
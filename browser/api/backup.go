@@ -0,0 +1,749 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// backupFormatVersion 备份归档格式版本，恢复时拒绝不认识的版本
+	backupFormatVersion = 1
+
+	backupManifestFile = "manifest.json"
+	backupGraphFile    = "graph.nq"
+	// backupBlobDir 归档中存放附件内容的目录，文件名为 SHA-256 摘要
+	backupBlobDir = "blobs"
+)
+
+// backupTables 备份中包含的 DuckDB 表，按恢复顺序排列
+var backupTables = []string{"collections", "documents", "graph_nodes", "attachments"}
+
+// adminJobMu 保证同一时间只有一个备份或恢复任务在运行
+var adminJobMu sync.Mutex
+
+// BackupManifest 备份归档中的 manifest.json
+type BackupManifest struct {
+	Version            int            `json:"version"`
+	CreatedAt          time.Time      `json:"created_at"`
+	EmbeddingDimension int            `json:"embedding_dimension"`
+	Tables             map[string]int `json:"tables"`  // 表名 -> 行数
+	Triples            int            `json:"triples"` // 图中的边数
+	Blobs              int            `json:"blobs"`   // 附件内容的个数
+}
+
+// BackupInfo 已生成的备份文件
+type BackupInfo struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// backupDir 返回备份文件目录：BACKUP_DIR，默认为数据目录下的 backups
+func backupDir() string {
+	if dir := os.Getenv("BACKUP_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(dataDir(), "backups")
+}
+
+// backupPath 返回备份文件的路径，只允许备份目录下的 .tar.gz 文件名
+func backupPath(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || !strings.HasSuffix(name, ".tar.gz") {
+		return "", fmt.Errorf("invalid backup name: %s", name)
+	}
+	return filepath.Join(backupDir(), name), nil
+}
+
+// createBackup 启动后台备份任务，完成后可通过 GET /api/admin/backups/:file 下载
+func createBackup(c *gin.Context) {
+	if !allCollectionsAllowed(c) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "API key must be allowed to access all collections"})
+		return
+	}
+	if sqlDB == nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Database not available"})
+		return
+	}
+	if !adminJobMu.TryLock() {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "a backup or restore job is already running"})
+		return
+	}
+	job, err := startJob("backup", "")
+	if err != nil {
+		adminJobMu.Unlock()
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	name := fmt.Sprintf("backup-%s-%s.tar.gz", job.StartedAt.UTC().Format("20060102T150405Z"), job.ID)
+	updateJob(job, func(j *Job) { j.File = name })
+	logrus.WithFields(logrus.Fields{"job": job.ID, "file": name}).Info("💾 Backup job started")
+
+	goJob(func() {
+		defer adminJobMu.Unlock()
+		err := runBackup(job, name)
+		finishJob(job, err)
+		if err != nil {
+			logrus.WithError(err).WithField("job", job.ID).Error("❌ Backup job failed")
+		} else {
+			logrus.WithFields(logrus.Fields{"job": job.ID, "file": name}).Info("✅ Backup job finished")
+		}
+	})
+
+	c.JSON(http.StatusAccepted, snapshotJob(job))
+}
+
+// runBackup 在一个 DuckDB 事务中把各表导出为 Parquet，导出图的 N-Quads，再打包为 tar.gz
+func runBackup(job *Job, name string) error {
+	dir := backupDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	workDir, err := os.MkdirTemp(dir, ".backup-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+
+	manifest := BackupManifest{
+		Version:            backupFormatVersion,
+		CreatedAt:          time.Now().UTC(),
+		EmbeddingDimension: getEmbeddingDimension(),
+		Tables:             make(map[string]int),
+	}
+	files := []string{backupManifestFile}
+
+	sums, err := exportTables(job, workDir, &manifest)
+	if err != nil {
+		return err
+	}
+	for _, table := range backupTables {
+		if _, ok := manifest.Tables[table]; ok {
+			files = append(files, table+".parquet")
+		}
+	}
+
+	if graphDB != nil {
+		updateJob(job, func(j *Job) { j.Stage = "exporting graph" })
+		f, err := os.Create(filepath.Join(workDir, backupGraphFile))
+		if err != nil {
+			return err
+		}
+		count, err := graphDB.ExportNQuads(dbContext, f)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to export graph: %w", err)
+		}
+		manifest.Triples = count
+		files = append(files, backupGraphFile)
+		updateJob(job, func(j *Job) { j.Processed += count })
+	}
+
+	// 附件内容直接从 blob 目录打包；导出后附件被删除时 blob 可能已经不存在，跳过并记录日志
+	var blobs []string
+	for _, sum := range sums {
+		if _, err := os.Stat(blobPath(sum)); err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{"job": job.ID, "sha256": sum}).Warn("Attachment blob is missing, not included in backup")
+			continue
+		}
+		blobs = append(blobs, sum)
+	}
+	manifest.Blobs = len(blobs)
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(workDir, backupManifestFile), data, 0644); err != nil {
+		return err
+	}
+
+	updateJob(job, func(j *Job) { j.Stage = "archiving" })
+	// 先写临时文件再重命名，下载时不会读到未完成的归档
+	tmpArchive := filepath.Join(workDir, name)
+	if err := writeTarGz(tmpArchive, workDir, files, blobs); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	return os.Rename(tmpArchive, filepath.Join(dir, name))
+}
+
+// exportTables 在同一个事务中导出各表，保证各表数据来自同一时刻，返回导出的附件引用的 blob 摘要
+func exportTables(job *Job, workDir string, manifest *BackupManifest) ([]string, error) {
+	conn, err := sqlDB.Conn(dbContext)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	tx, err := conn.BeginTx(dbContext, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for _, table := range backupTables {
+		var exists int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM information_schema.tables WHERE table_name = ?`, table).Scan(&exists); err != nil {
+			return nil, err
+		}
+		if exists == 0 {
+			continue
+		}
+
+		updateJob(job, func(j *Job) { j.Stage = "exporting " + table })
+		var count int
+		if err := tx.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM %s`, table)).Scan(&count); err != nil {
+			return nil, fmt.Errorf("failed to count %s: %w", table, err)
+		}
+		file := filepath.Join(workDir, table+".parquet")
+		if _, err := tx.Exec(fmt.Sprintf(`COPY %s TO %s (FORMAT PARQUET)`, table, sqlStringLiteral(file))); err != nil {
+			return nil, fmt.Errorf("failed to export %s: %w", table, err)
+		}
+		manifest.Tables[table] = count
+		updateJob(job, func(j *Job) { j.Processed += count })
+	}
+
+	var sums []string
+	if _, ok := manifest.Tables["attachments"]; ok {
+		rows, err := tx.Query(`SELECT DISTINCT sha256 FROM attachments ORDER BY sha256`)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list attachment blobs: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var sum string
+			if err := rows.Scan(&sum); err != nil {
+				return nil, err
+			}
+			sums = append(sums, sum)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return sums, tx.Commit()
+}
+
+// writeTarGz 把 dir 下的 files 和 blob 目录中的 blobs 打包为 tar.gz，blob 放在归档的 blobs 目录下
+func writeTarGz(archive, dir string, files, blobs []string) error {
+	out, err := os.Create(archive)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	for _, name := range files {
+		if err := addTarFile(tw, filepath.Join(dir, name), name); err != nil {
+			return err
+		}
+	}
+	for _, sum := range blobs {
+		if err := addTarFile(tw, blobPath(sum), backupBlobDir+"/"+sum); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// addTarFile 把单个文件写入 tar
+func addTarFile(tw *tar.Writer, path, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// extractTarGz 解压备份归档，只接受归档根目录和 blobs 目录下的普通文件
+func extractTarGz(archive, dir string) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("invalid backup archive: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid backup archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg || !validArchiveEntry(header.Name) {
+			return fmt.Errorf("invalid backup archive: unexpected entry %s", header.Name)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(header.Name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		out, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(out, tr)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// validArchiveEntry 判断归档中的文件名：根目录下的文件，或 blobs 目录下以 SHA-256 摘要命名的文件
+func validArchiveEntry(name string) bool {
+	if name == filepath.Base(name) && name != "." && name != ".." {
+		return true
+	}
+	sum, ok := strings.CutPrefix(name, backupBlobDir+"/")
+	return ok && isSHA256Hex(sum)
+}
+
+// isSHA256Hex 判断字符串是否为小写十六进制的 SHA-256 摘要
+func isSHA256Hex(s string) bool {
+	if len(s) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil && strings.ToLower(s) == s
+}
+
+// listBackups 列出备份目录中的备份文件，按时间倒序
+func listBackups(c *gin.Context) {
+	if !allCollectionsAllowed(c) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "API key must be allowed to access all collections"})
+		return
+	}
+	entries, err := os.ReadDir(backupDir())
+	if err != nil && !os.IsNotExist(err) {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	backups := []BackupInfo{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".tar.gz") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, BackupInfo{Name: entry.Name(), Size: info.Size(), CreatedAt: info.ModTime()})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt.After(backups[j].CreatedAt) })
+
+	c.JSON(http.StatusOK, gin.H{"backups": backups})
+}
+
+// downloadBackup 下载备份文件
+func downloadBackup(c *gin.Context) {
+	if !allCollectionsAllowed(c) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "API key must be allowed to access all collections"})
+		return
+	}
+	path, err := backupPath(c.Param("file"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if _, err := os.Stat(path); err != nil {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Backup not found"})
+		return
+	}
+	c.FileAttachment(path, filepath.Base(path))
+}
+
+// restoreBackup 启动后台恢复任务，用备份替换当前的全部数据
+// 可以上传归档（multipart 字段 file），或通过 {"backup": "<file>"} 指定备份目录中已有的备份
+func restoreBackup(c *gin.Context) {
+	if !allCollectionsAllowed(c) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "API key must be allowed to access all collections"})
+		return
+	}
+	if sqlDB == nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "Database not available"})
+		return
+	}
+	if err := os.MkdirAll(backupDir(), 0755); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	var archive string
+	removeArchive := false
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "file is required"})
+			return
+		}
+		tmp, err := os.CreateTemp(backupDir(), ".restore-*.tar.gz")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		tmp.Close()
+		if err := c.SaveUploadedFile(file, tmp.Name()); err != nil {
+			os.Remove(tmp.Name())
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		archive = tmp.Name()
+		removeArchive = true
+	} else {
+		var req RestoreRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		path, err := backupPath(req.Backup)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		if _, err := os.Stat(path); err != nil {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Backup not found"})
+			return
+		}
+		archive = path
+	}
+
+	if !adminJobMu.TryLock() {
+		if removeArchive {
+			os.Remove(archive)
+		}
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "a backup or restore job is already running"})
+		return
+	}
+	job, err := startJob("restore", "")
+	if err != nil {
+		adminJobMu.Unlock()
+		if removeArchive {
+			os.Remove(archive)
+		}
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		return
+	}
+	updateJob(job, func(j *Job) { j.File = filepath.Base(archive) })
+	logrus.WithFields(logrus.Fields{"job": job.ID, "file": filepath.Base(archive)}).Info("♻️ Restore job started")
+
+	goJob(func() {
+		defer adminJobMu.Unlock()
+		if removeArchive {
+			defer os.Remove(archive)
+		}
+		err := runRestore(job, archive)
+		searchCache.invalidate("")
+		finishJob(job, err)
+		if err != nil {
+			logrus.WithError(err).WithField("job", job.ID).Error("❌ Restore job failed")
+		} else {
+			logrus.WithField("job", job.ID).Info("✅ Restore job finished")
+		}
+	})
+
+	c.JSON(http.StatusAccepted, snapshotJob(job))
+}
+
+// runRestore 解压归档，在一个 DuckDB 事务中替换各表，再替换图中的所有边并重建全文索引
+func runRestore(job *Job, archive string) error {
+	updateJob(job, func(j *Job) { j.Stage = "extracting" })
+	workDir, err := os.MkdirTemp(backupDir(), ".restore-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+	if err := extractTarGz(archive, workDir); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(filepath.Join(workDir, backupManifestFile))
+	if err != nil {
+		return fmt.Errorf("invalid backup archive: missing %s", backupManifestFile)
+	}
+	var manifest BackupManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("invalid backup manifest: %w", err)
+	}
+	if manifest.Version != backupFormatVersion {
+		return fmt.Errorf("unsupported backup version %d", manifest.Version)
+	}
+
+	// 先恢复附件内容，导入后的附件记录不会引用不存在的 blob
+	if err := restoreBlobs(job, filepath.Join(workDir, backupBlobDir)); err != nil {
+		return err
+	}
+	staleSums, err := importTables(job, workDir, &manifest)
+	if err != nil {
+		return err
+	}
+	// 被替换的附件记录引用的 blob 不再需要时删除
+	removeUnreferencedBlobs(staleSums)
+
+	if graphDB != nil {
+		if err := restoreGraph(job, filepath.Join(workDir, backupGraphFile)); err != nil {
+			return err
+		}
+	}
+
+	updateJob(job, func(j *Job) { j.Stage = "rebuilding indexes" })
+	if _, err := sqlDB.Exec(`PRAGMA create_fts_index('documents', 'id', 'content', 'content_tokens', overwrite = 1)`); err != nil {
+		logrus.WithError(err).WithField("job", job.ID).Warn("Failed to rebuild FTS index after restore")
+	} else {
+		markFTSIndexBuilt(sqlDB)
+	}
+	return nil
+}
+
+// importTables 在同一个事务中清空并导入各表，只导入备份和当前表都有的列；
+// 导入后删除文档已不存在的附件记录（如从没有附件表的旧备份恢复时），返回被替换或删除的附件记录引用的 blob 摘要
+func importTables(job *Job, workDir string, manifest *BackupManifest) ([]string, error) {
+	tx, err := sqlDB.BeginTx(dbContext, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var staleSums []string
+	if _, ok := manifest.Tables["attachments"]; ok {
+		rows, err := tx.Query(`SELECT DISTINCT sha256 FROM attachments`)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list attachment blobs: %w", err)
+		}
+		for rows.Next() {
+			var sum string
+			if err := rows.Scan(&sum); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			staleSums = append(staleSums, sum)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, table := range backupTables {
+		if _, ok := manifest.Tables[table]; !ok {
+			continue
+		}
+		file := filepath.Join(workDir, table+".parquet")
+		if _, err := os.Stat(file); err != nil {
+			return nil, fmt.Errorf("invalid backup archive: missing %s.parquet", table)
+		}
+
+		updateJob(job, func(j *Job) { j.Stage = "restoring " + table })
+		source := fmt.Sprintf(`read_parquet(%s)`, sqlStringLiteral(file))
+		columns, err := commonColumns(tx, table, source)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`DELETE FROM %s`, table)); err != nil {
+			return nil, fmt.Errorf("failed to clear %s: %w", table, err)
+		}
+		if len(columns) == 0 {
+			continue
+		}
+		cols := strings.Join(columns, ", ")
+		result, err := tx.Exec(fmt.Sprintf(`INSERT INTO %s (%s) SELECT %s FROM %s`, table, cols, cols, source))
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore %s: %w", table, err)
+		}
+		count, _ := result.RowsAffected()
+		updateJob(job, func(j *Job) { j.Processed += int(count) })
+	}
+
+	orphanSums, err := deleteAttachmentRows(tx, `NOT EXISTS (
+		SELECT 1 FROM documents d WHERE d.collection_name = attachments.collection_name AND d.id = attachments.document_id)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete orphaned attachments: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return append(staleSums, orphanSums...), nil
+}
+
+// restoreBlobs 把归档中的附件内容复制到 blob 目录，已存在的跳过；内容与文件名中的摘要不一致时返回错误
+func restoreBlobs(job *Job, dir string) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	updateJob(job, func(j *Job) { j.Stage = "restoring attachments" })
+	blobMu.Lock()
+	defer blobMu.Unlock()
+	for _, entry := range entries {
+		sum := entry.Name()
+		if _, err := os.Stat(blobPath(sum)); err == nil {
+			continue
+		}
+		if err := copyBlob(filepath.Join(dir, sum), sum); err != nil {
+			return fmt.Errorf("failed to restore attachment blob %s: %w", sum, err)
+		}
+	}
+	return nil
+}
+
+// copyBlob 校验摘要后把文件写入 blob 目录，先写临时文件再重命名
+func copyBlob(src, sum string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	target := blobPath(sum)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".restore-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, hash), in)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != sum {
+		return fmt.Errorf("checksum mismatch")
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+// commonColumns 返回当前表和备份文件中都存在的列
+func commonColumns(tx *sql.Tx, table, source string) ([]string, error) {
+	rows, err := tx.Query(fmt.Sprintf(`SELECT name FROM pragma_table_info('%s')`, table))
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		current[name] = true
+	}
+	rows.Close()
+	if len(current) == 0 {
+		return nil, fmt.Errorf("table %s does not exist", table)
+	}
+
+	rows, err = tx.Query(fmt.Sprintf(`SELECT column_name FROM (DESCRIBE SELECT * FROM %s)`, source))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s from backup: %w", table, err)
+	}
+	defer rows.Close()
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		if current[name] {
+			columns = append(columns, name)
+		}
+	}
+	return columns, rows.Err()
+}
+
+// restoreGraph 删除图中现有的边后导入备份中的边
+// 图数据库与 DuckDB 不在同一个事务中，导入失败时图可能为空，需要重新恢复
+func restoreGraph(job *Job, file string) error {
+	updateJob(job, func(j *Job) { j.Stage = "restoring graph" })
+	ctx := context.Background()
+
+	triples, err := graphDB.AllTriples(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read graph: %w", err)
+	}
+	tx, err := graphDB.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	for _, t := range triples {
+		if err := tx.Unlink(ctx, t.Subject, t.Predicate, t.Object); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to clear graph: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to clear graph: %w", err)
+	}
+
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	count, err := graphDB.ImportNQuads(ctx, f)
+	if err != nil {
+		return fmt.Errorf("failed to restore graph: %w", err)
+	}
+	updateJob(job, func(j *Job) { j.Processed += count })
+	return nil
+}
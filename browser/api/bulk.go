@@ -0,0 +1,349 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// importBatchSize 批量导入时每个事务提交的文档数
+	importBatchSize = 500
+	// maxImportErrors 批量导入响应中最多返回的错误条数
+	maxImportErrors = 100
+	// exportFlushEvery 导出 NDJSON 时每写多少行刷新一次响应
+	exportFlushEvery = 500
+)
+
+// importConflictVerbs on_conflict 参数到 INSERT 语句的映射
+var importConflictVerbs = map[string]string{
+	"error":   "INSERT",
+	"skip":    "INSERT OR IGNORE",
+	"replace": "INSERT OR REPLACE",
+}
+
+// collectionAction 处理 POST /collections/:name/:action 形式的自定义动作
+// gin 的路由无法在静态段中包含冒号，因此 documents:batch 通过参数段分发
+func collectionAction(c *gin.Context) {
+	switch c.Param("action") {
+	case "documents:batch":
+		batchImportDocuments(c)
+	default:
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: fmt.Sprintf("unknown action: %s", c.Param("action"))})
+	}
+}
+
+// batchImportDocuments 批量导入文档，请求体可以是 JSON 数组或 NDJSON 流
+// 请求体按流式解码，每 importBatchSize 条文档提交一次事务，因此导入不是整体原子的：
+// 出错时已提交的批次会保留，响应中的 inserted 为已提交的文档数
+func batchImportDocuments(c *gin.Context) {
+	name := c.Param("name")
+	onConflict := c.DefaultQuery("on_conflict", "error")
+	verb, ok := importConflictVerbs[onConflict]
+	if !ok {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid on_conflict: %s (expected error, skip or replace)", onConflict)})
+		return
+	}
+
+	reader := bufio.NewReaderSize(c.Request.Body, 64*1024)
+	first, err := peekNonSpace(reader)
+	if err == io.EOF {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "request body is empty"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	decoder := json.NewDecoder(reader)
+	isArray := first == '['
+	if isArray {
+		// 消费开头的 '['
+		if _, err := decoder.Token(); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	cols := detectDocumentColumns()
+	settings := getCollectionSettings(name)
+	resp := BatchImportResponse{Errors: []BatchImportError{}}
+
+	var tx *sql.Tx
+	pendingInserted, pendingSkipped := 0, 0
+	// pendingBlobs 本批次中被取代的回收站文档的附件 blob，提交后清理
+	var pendingBlobs []string
+	commit := func() error {
+		if tx == nil {
+			return nil
+		}
+		err := tx.Commit()
+		tx = nil
+		if err != nil {
+			return err
+		}
+		resp.Inserted += pendingInserted
+		resp.Skipped += pendingSkipped
+		pendingInserted, pendingSkipped = 0, 0
+		removeUnreferencedBlobs(pendingBlobs)
+		pendingBlobs = nil
+		return nil
+	}
+	abort := func(status int, err error) {
+		if tx != nil {
+			tx.Rollback()
+		}
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"collection": name,
+			"inserted":   resp.Inserted,
+		}).Error("❌ Batch import aborted")
+		c.JSON(status, gin.H{
+			"error":    err.Error(),
+			"inserted": resp.Inserted,
+			"skipped":  resp.Skipped,
+			"failed":   resp.Failed,
+			"errors":   resp.Errors,
+		})
+	}
+
+	for index := 0; ; index++ {
+		if isArray && !decoder.More() {
+			break
+		}
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil {
+			if err == io.EOF && !isArray {
+				break
+			}
+			abort(http.StatusBadRequest, fmt.Errorf("document %d: %w", index, err))
+			return
+		}
+
+		data, ok := value.(map[string]interface{})
+		if !ok {
+			resp.addError(index, "", fmt.Sprintf("expected JSON object, got %T", value))
+			continue
+		}
+
+		id, ok := data["id"].(string)
+		if !ok || id == "" {
+			id = generateID()
+			data["id"] = id
+		}
+		// 保留导入数据中的版本号（例如从导出文件恢复），没有时作为新文档
+		if _, ok, err := parseRevision(data[revField]); err != nil || !ok {
+			data[revField] = 1
+		}
+		embeddingVector := extractEmbeddingVector(data["embedding"])
+		if err := checkVectorDimension(settings, embeddingVector); err != nil {
+			resp.addError(index, id, err.Error())
+			continue
+		}
+		dataJSON, err := json.Marshal(data)
+		if err != nil {
+			resp.addError(index, id, err.Error())
+			continue
+		}
+
+		if tx == nil {
+			if tx, err = sqlDB.Begin(); err != nil {
+				abort(http.StatusInternalServerError, err)
+				return
+			}
+		}
+		noteDocument(c, tx, name, id)
+		// 回收站中同 ID 的文档视为不存在，会被导入的文档取代
+		purgedBlobs, err := purgeTrashedDocument(tx, name, id, cols)
+		if err != nil {
+			abort(http.StatusInternalServerError, fmt.Errorf("document %d (%s): %w", index, id, err))
+			return
+		}
+		pendingBlobs = append(pendingBlobs, purgedBlobs...)
+		affected, err := insertDocument(tx, verb, name, id, string(dataJSON), embeddingVector, settings.FTSFields, cols)
+		if err != nil {
+			abort(http.StatusInternalServerError, fmt.Errorf("document %d (%s): %w", index, id, err))
+			return
+		}
+		if affected == 0 {
+			pendingSkipped++
+		} else {
+			pendingInserted++
+		}
+
+		if pendingInserted+pendingSkipped >= importBatchSize {
+			if err := commit(); err != nil {
+				abort(http.StatusInternalServerError, err)
+				return
+			}
+		}
+	}
+
+	if isArray {
+		// 消费结尾的 ']'
+		if _, err := decoder.Token(); err != nil {
+			abort(http.StatusBadRequest, err)
+			return
+		}
+	}
+	if err := commit(); err != nil {
+		abort(http.StatusInternalServerError, err)
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"collection": name,
+		"inserted":   resp.Inserted,
+		"skipped":    resp.Skipped,
+		"failed":     resp.Failed,
+	}).Info("📥 Batch import finished")
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// addError 记录一条导入失败的文档，超过 maxImportErrors 后只计数
+func (r *BatchImportResponse) addError(index int, id, msg string) {
+	r.Failed++
+	if len(r.Errors) < maxImportErrors {
+		r.Errors = append(r.Errors, BatchImportError{Index: index, ID: id, Error: msg})
+	}
+}
+
+// peekNonSpace 跳过开头的空白字符（以及 UTF-8 BOM），返回第一个有效字节但不消费它
+func peekNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case 0xEF:
+			// UTF-8 BOM: EF BB BF
+			if bom, err := r.Peek(2); err == nil && bom[0] == 0xBB && bom[1] == 0xBF {
+				r.Discard(2)
+				continue
+			}
+		}
+		return b, r.UnreadByte()
+	}
+}
+
+// exportDocuments 导出集合中的所有文档，format 支持 ndjson（默认）和 parquet
+func exportDocuments(c *gin.Context) {
+	name := c.Param("name")
+	format := c.DefaultQuery("format", "ndjson")
+
+	switch format {
+	case "ndjson", "jsonl":
+		exportNDJSON(c, name)
+	case "parquet":
+		exportParquet(c, name)
+	default:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("unsupported export format: %s (expected ndjson or parquet)", format)})
+	}
+}
+
+// exportNDJSON 逐行流式输出文档的 data 字段，输出可直接用于 documents:batch 导入
+func exportNDJSON(c *gin.Context, name string) {
+	rows, err := sqlDB.Query(`SELECT data FROM documents WHERE collection_name = ? AND `+liveDocumentsSQL("")+` ORDER BY created_at, id`, name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".ndjson"))
+	c.Status(http.StatusOK)
+
+	w := bufio.NewWriter(c.Writer)
+	var line bytes.Buffer
+	count := 0
+	for rows.Next() {
+		var data sql.NullString
+		if err := rows.Scan(&data); err != nil {
+			logrus.WithError(err).Warn("Failed to scan document")
+			continue
+		}
+		line.Reset()
+		if err := json.Compact(&line, []byte(data.String)); err != nil {
+			logrus.WithError(err).Warn("Skipping document with invalid data")
+			continue
+		}
+		line.WriteByte('\n')
+		if _, err := w.Write(line.Bytes()); err != nil {
+			logrus.WithError(err).Warn("Client disconnected during export")
+			return
+		}
+		count++
+		if count%exportFlushEvery == 0 {
+			w.Flush()
+			c.Writer.Flush()
+		}
+	}
+	if err := rows.Err(); err != nil {
+		// 响应头已发送，只能记录日志
+		logrus.WithError(err).Error("❌ Export interrupted")
+	}
+	w.Flush()
+
+	logrus.WithFields(logrus.Fields{
+		"collection": name,
+		"count":      count,
+	}).Info("📤 Exported documents as NDJSON")
+}
+
+// exportParquet 使用 DuckDB COPY 将文档写入临时 Parquet 文件后流式返回
+func exportParquet(c *gin.Context, name string) {
+	tmpFile, err := os.CreateTemp("", "export-*.parquet")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	tmpPath := tmpFile.Name()
+	tmpFile.Close()
+	defer os.Remove(tmpPath)
+
+	cols := detectDocumentColumns()
+	columns := []string{"id", "collection_name", "data"}
+	if cols.embedding {
+		columns = append(columns, "embedding")
+	}
+	if cols.content {
+		columns = append(columns, "content")
+	}
+	columns = append(columns, "created_at", "updated_at")
+
+	// COPY 语句不支持参数绑定，使用字面量转义
+	copyQuery := fmt.Sprintf("COPY (SELECT %s FROM documents WHERE collection_name = %s AND %s ORDER BY created_at, id) TO %s (FORMAT PARQUET)",
+		strings.Join(columns, ", "),
+		sqlStringLiteral(name),
+		liveDocumentsSQL(""),
+		sqlStringLiteral(tmpPath))
+	if _, err := sqlDB.Exec(copyQuery); err != nil {
+		logrus.WithError(err).Error("❌ Failed to export parquet")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	logrus.WithField("collection", name).Info("📤 Exported documents as Parquet")
+
+	c.Header("Content-Type", "application/vnd.apache.parquet")
+	c.FileAttachment(tmpPath, name+".parquet")
+}
+
+// sqlStringLiteral 将字符串转义为 SQL 字符串字面量
+func sqlStringLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
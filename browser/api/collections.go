@@ -0,0 +1,412 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ensureCollectionsTable 创建集合注册表，保存每个集合的配置
+func ensureCollectionsTable(db *sql.DB) error {
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS collections (
+		name VARCHAR(255) PRIMARY KEY,
+		embed_fields TEXT,
+		fts_fields TEXT,
+		vector_dimension INTEGER,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create collections table: %w", err)
+	}
+	return nil
+}
+
+// loadCollectionSettings 读取已注册集合的配置，未注册时 ok 为 false
+func loadCollectionSettings(q sqlQueryer, name string) (settings CollectionSettings, ok bool, err error) {
+	var embedFields, ftsFields sql.NullString
+	var dimension sql.NullInt64
+	err = q.QueryRow(`SELECT embed_fields, fts_fields, vector_dimension FROM collections WHERE name = ?`, name).
+		Scan(&embedFields, &ftsFields, &dimension)
+	if err == sql.ErrNoRows {
+		return settings, false, nil
+	}
+	if err != nil {
+		return settings, false, err
+	}
+
+	if embedFields.Valid && embedFields.String != "" {
+		if err := json.Unmarshal([]byte(embedFields.String), &settings.EmbedFields); err != nil {
+			return settings, false, fmt.Errorf("invalid embed_fields of collection %s: %w", name, err)
+		}
+	}
+	if ftsFields.Valid && ftsFields.String != "" {
+		if err := json.Unmarshal([]byte(ftsFields.String), &settings.FTSFields); err != nil {
+			return settings, false, fmt.Errorf("invalid fts_fields of collection %s: %w", name, err)
+		}
+	}
+	settings.VectorDimension = int(dimension.Int64)
+	return settings, true, nil
+}
+
+// sqlQueryer 抽象 *sql.DB 与 *sql.Tx 的 QueryRow
+type sqlQueryer interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// getCollectionSettings 返回集合配置，未注册或读取失败时返回零值（注册表缺失时同样视为未注册）
+func getCollectionSettings(name string) CollectionSettings {
+	settings, _, err := loadCollectionSettings(sqlDB, name)
+	if err != nil {
+		logrus.WithError(err).WithField("collection", name).Debug("Failed to load collection settings")
+	}
+	return settings
+}
+
+// collectionNameExists 判断集合是否存在（已注册或已有文档，包括回收站中的文档）
+func collectionNameExists(q sqlQueryer, name string) (bool, error) {
+	var count int64
+	err := q.QueryRow(`SELECT (SELECT COUNT(*) FROM collections WHERE name = ?) + (SELECT COUNT(*) FROM documents WHERE collection_name = ?)`,
+		name, name).Scan(&count)
+	return count > 0, err
+}
+
+// validateCollectionName 校验集合名称，名称会出现在 URL 路径中
+func validateCollectionName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("collection name is required")
+	}
+	if len(name) > 255 {
+		return fmt.Errorf("collection name must be at most 255 bytes")
+	}
+	if strings.ContainsAny(name, "/:") || strings.IndexFunc(name, func(r rune) bool { return r < 0x20 }) >= 0 {
+		return fmt.Errorf("collection name must not contain '/', ':' or control characters")
+	}
+	return nil
+}
+
+// validateCollectionSettings 校验集合配置；vector_dimension 需要与 embedding 列的固定维度一致
+func validateCollectionSettings(settings CollectionSettings) error {
+	for _, fields := range [][]string{settings.EmbedFields, settings.FTSFields} {
+		for _, field := range fields {
+			if _, err := jsonPath(field); err != nil {
+				return err
+			}
+		}
+	}
+	if settings.VectorDimension < 0 {
+		return fmt.Errorf("vector_dimension must be positive")
+	}
+	if settings.VectorDimension > 0 {
+		colType, err := getColumnType(sqlDB, "documents", "embedding")
+		if err == nil {
+			if m := fixedFloatArrayPattern.FindStringSubmatch(strings.ToUpper(colType)); m != nil {
+				if dim, _ := strconv.Atoi(m[1]); dim != settings.VectorDimension {
+					return fmt.Errorf("vector_dimension %d does not match embedding column dimension %d", settings.VectorDimension, dim)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// marshalFields 将字段列表序列化为 JSON，空列表存为 NULL
+func marshalFields(fields []string) interface{} {
+	if len(fields) == 0 {
+		return nil
+	}
+	return mustMarshalJSON(fields)
+}
+
+// createCollection 创建（注册）集合；已有文档但未注册的集合也可以注册
+func createCollection(c *gin.Context) {
+	var req CreateCollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := validateCollectionName(req.Name); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if !collectionAllowed(c, req.Name) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: fmt.Sprintf("API key is not allowed to access collection %s", req.Name)})
+		return
+	}
+	if err := validateCollectionSettings(req.CollectionSettings); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if _, ok, err := loadCollectionSettings(sqlDB, req.Name); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	} else if ok {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: fmt.Sprintf("collection %s already exists", req.Name)})
+		return
+	}
+
+	var dimension interface{}
+	if req.VectorDimension > 0 {
+		dimension = req.VectorDimension
+	}
+	_, err := sqlDB.Exec(`INSERT INTO collections (name, embed_fields, fts_fields, vector_dimension) VALUES (?, ?, ?, ?)`,
+		req.Name, marshalFields(req.EmbedFields), marshalFields(req.FTSFields), dimension)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	logrus.WithField("collection", req.Name).Info("📁 Collection created")
+	c.JSON(http.StatusCreated, CollectionInfo{
+		Name:     req.Name,
+		Schema:   make(map[string]interface{}),
+		Settings: &req.CollectionSettings,
+	})
+}
+
+// updateCollection 修改集合配置或重命名集合，只更新请求中出现的字段
+func updateCollection(c *gin.Context) {
+	name := c.Param("name")
+
+	var req UpdateCollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	exists, err := collectionNameExists(sqlDB, name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Collection not found"})
+		return
+	}
+
+	settings, registered, err := loadCollectionSettings(sqlDB, name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if req.EmbedFields != nil {
+		settings.EmbedFields = *req.EmbedFields
+	}
+	if req.FTSFields != nil {
+		settings.FTSFields = *req.FTSFields
+	}
+	if req.VectorDimension != nil {
+		settings.VectorDimension = *req.VectorDimension
+	}
+	if err := validateCollectionSettings(settings); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	newName := name
+	if req.Name != nil && *req.Name != name {
+		newName = *req.Name
+		if err := validateCollectionName(newName); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+		if !collectionAllowed(c, newName) {
+			c.JSON(http.StatusForbidden, ErrorResponse{Error: fmt.Sprintf("API key is not allowed to access collection %s", newName)})
+			return
+		}
+		taken, err := collectionNameExists(sqlDB, newName)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		if taken {
+			c.JSON(http.StatusConflict, ErrorResponse{Error: fmt.Sprintf("collection %s already exists", newName)})
+			return
+		}
+	}
+
+	tx, err := sqlDB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	defer tx.Rollback()
+
+	var moved int64
+	if newName != name {
+		result, err := tx.Exec(`UPDATE documents SET collection_name = ? WHERE collection_name = ?`, newName, name)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		moved, _ = result.RowsAffected()
+		if err := renameCollectionAttachments(tx, name, newName); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	// 注册表以名称为主键，重命名和首次注册都按删除后插入处理
+	var createdAt interface{}
+	if registered {
+		var ts sql.NullTime
+		if err := tx.QueryRow(`SELECT created_at FROM collections WHERE name = ?`, name).Scan(&ts); err == nil && ts.Valid {
+			createdAt = ts.Time
+		}
+		if _, err := tx.Exec(`DELETE FROM collections WHERE name = ?`, name); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+	var dimension interface{}
+	if settings.VectorDimension > 0 {
+		dimension = settings.VectorDimension
+	}
+	_, err = tx.Exec(`INSERT INTO collections (name, embed_fields, fts_fields, vector_dimension, created_at, updated_at)
+		VALUES (?, ?, ?, ?, COALESCE(?, CURRENT_TIMESTAMP), CURRENT_TIMESTAMP)`,
+		newName, marshalFields(settings.EmbedFields), marshalFields(settings.FTSFields), dimension, createdAt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"collection": name,
+		"new_name":   newName,
+		"moved":      moved,
+	}).Info("📁 Collection updated")
+
+	c.JSON(http.StatusOK, CollectionInfo{
+		Name:     newName,
+		Schema:   make(map[string]interface{}),
+		Settings: &settings,
+	})
+}
+
+// deleteCollection 删除集合及其所有文档
+func deleteCollection(c *gin.Context) {
+	name := c.Param("name")
+
+	exists, err := collectionNameExists(sqlDB, name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Collection not found"})
+		return
+	}
+
+	tx, err := sqlDB.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM documents WHERE collection_name = ?`, name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	deleted, _ := result.RowsAffected()
+	purgedBlobs, err := deleteOrphanAttachments(tx, name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if _, err := tx.Exec(`DELETE FROM collections WHERE name = ?`, name); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	removeUnreferencedBlobs(purgedBlobs)
+
+	logrus.WithFields(logrus.Fields{
+		"collection": name,
+		"deleted":    deleted,
+	}).Info("🗑️ Collection deleted")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Collection deleted",
+		"deleted": deleted,
+	})
+}
+
+// listCollectionNames 返回已注册集合和已有文档的集合名称，按名称排序
+// 只有回收站中文档的集合也会列出，以便恢复
+func listCollectionNames() ([]string, error) {
+	rows, err := sqlDB.Query(`SELECT DISTINCT collection_name FROM documents`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seen := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			continue
+		}
+		seen[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 注册表可能尚未创建（例如旧数据库），此时只返回有文档的集合
+	if registered, err := sqlDB.Query(`SELECT name FROM collections`); err == nil {
+		defer registered.Close()
+		for registered.Next() {
+			var name string
+			if err := registered.Scan(&name); err == nil {
+				seen[name] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// documentContent 计算写入 content 列（全文检索使用）的文本
+// 集合配置了 fts_fields 时只索引这些字段，否则索引文档中的所有字段
+func documentContent(dataJSON string, ftsFields []string) string {
+	if len(ftsFields) == 0 {
+		return extractTextFromData(dataJSON)
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return ""
+	}
+	return strings.Join(fieldTexts(data, ftsFields), " ")
+}
+
+// checkVectorDimension 校验向量维度是否与集合配置的 vector_dimension 一致，未配置时不校验
+func checkVectorDimension(settings CollectionSettings, vector []float64) error {
+	if settings.VectorDimension > 0 && len(vector) > 0 && len(vector) != settings.VectorDimension {
+		return fmt.Errorf("embedding dimension %d does not match collection vector_dimension %d", len(vector), settings.VectorDimension)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/config"
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/embedding"
+	"github.com/sirupsen/logrus"
+)
+
+// serverConfig 启动时加载的配置，为 nil 时（如测试中）每次按 CONFIG_FILE 和环境变量重新读取
+var serverConfig *config.Config
+
+// defaultConfig browser API 的默认配置，配置文件和环境变量的说明见 pkg/config
+func defaultConfig() config.Config {
+	return config.Config{
+		Server:    config.ServerConfig{Port: 40121},
+		Database:  config.DatabaseConfig{Path: "./testdata/"},
+		Embedding: config.EmbeddingConfig{Provider: embedding.ProviderDashScope},
+		RateLimit: config.RateLimitConfig{
+			MaxBodySize:  defaultMaxBodySize,
+			QueryTimeout: config.Duration(defaultQueryTimeout),
+		},
+	}
+}
+
+// loadConfig 读取 CONFIG_FILE 指向的配置文件和环境变量
+func loadConfig() (*config.Config, error) {
+	return config.Load("", defaultConfig())
+}
+
+// currentConfig 返回当前配置，未加载且配置无效时使用默认值
+func currentConfig() *config.Config {
+	if serverConfig != nil {
+		return serverConfig
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		logrus.WithError(err).Warn("Invalid config, using defaults")
+		defaults := defaultConfig()
+		return &defaults
+	}
+	return cfg
+}
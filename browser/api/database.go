@@ -3,39 +3,39 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"time"
 
 	cayley_driver "github.com/mozhou-tech/sqlite-ai-driver/pkg/cayley-driver"
 	_ "github.com/mozhou-tech/sqlite-ai-driver/pkg/duckdb-driver"
 	"github.com/sirupsen/logrus"
-	"gorm.io/gorm"
 )
 
 var (
-	gormDB       *gorm.DB
 	sqlDB        *sql.DB
 	graphDB      cayley_driver.Graph
 	dbContext    context.Context
 	embeddingDim int // embedding 向量维度
 )
 
+// dataDir 返回数据目录：database.path（DB_PATH），默认为 ./testdata/
+func dataDir() string {
+	return currentConfig().Database.Path
+}
+
 // getEmbeddingDimension 获取 embedding 向量维度
 func getEmbeddingDimension() int {
 	if embeddingDim > 0 {
 		return embeddingDim
 	}
-	// 从环境变量读取，默认为 1024 text-embedding-v4 的维度）
-	dimStr := os.Getenv("EMBEDDING_DIMENSION")
-	if dimStr != "" {
-		if dim, err := strconv.Atoi(dimStr); err == nil && dim > 0 {
-			embeddingDim = dim
-			return embeddingDim
-		}
+	// 从配置读取（embedding.dimension 或 EMBEDDING_DIMENSION），默认为 1024（text-embedding-v4 的维度）
+	if dim := currentConfig().Embedding.Dimension; dim > 0 {
+		embeddingDim = dim
+		return embeddingDim
 	}
 	// 默认维度为 1536
 	embeddingDim = 1024
@@ -45,10 +45,7 @@ func getEmbeddingDimension() int {
 // initDatabase 初始化数据库
 func initDatabase() error {
 
-	dbPath := os.Getenv("DB_PATH")
-	if dbPath == "" {
-		dbPath = "./testdata/"
-	}
+	dbPath := dataDir()
 
 	// 确保数据目录存在
 	if err := os.MkdirAll(dbPath, 0755); err != nil {
@@ -107,7 +104,8 @@ func initDatabase() error {
 		content TEXT,
 		content_tokens TEXT,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		deleted_at TIMESTAMP
 	);
 	CREATE INDEX IF NOT EXISTS idx_documents_collection ON documents(collection_name);
 	`, dim)
@@ -115,11 +113,31 @@ func initDatabase() error {
 		return fmt.Errorf("failed to create documents table: %w", err)
 	}
 
+	// 集合注册表
+	if err := ensureCollectionsTable(sqlDB); err != nil {
+		return err
+	}
+
+	// 图节点属性表
+	if err := ensureGraphNodesTable(sqlDB); err != nil {
+		return err
+	}
+
+	// 文档附件表
+	if err := ensureAttachmentsTable(sqlDB); err != nil {
+		return err
+	}
+
 	// 确保必要的列存在
 	if err := ensureTableColumns(sqlDB); err != nil {
 		logrus.WithError(err).Warn("Failed to ensure table columns, some features may not work")
 	}
 
+	// 分词规则变化后重新生成已有文档的 content_tokens，需要在创建全文索引之前执行
+	if err := retokenizeContent(sqlDB); err != nil {
+		logrus.WithError(err).Warn("Failed to retokenize documents, fulltext search may miss existing documents")
+	}
+
 	// 创建全文搜索索引
 	if err := createDuckDBFTSIndex(sqlDB); err != nil {
 		logrus.WithError(err).Error("Failed to create FTS index, fulltext search may not work")
@@ -152,6 +170,26 @@ func initDatabase() error {
 	return nil
 }
 
+// closeDatabase 合并 DuckDB 的 WAL 后关闭 DuckDB 和图数据库
+// 关闭图数据库（SQLite）时会执行 checkpoint，外部的 Litestream 等复制进程可以在之后完成同步
+func closeDatabase() error {
+	var errs []error
+	if sqlDB != nil {
+		if _, err := sqlDB.Exec(`CHECKPOINT`); err != nil {
+			errs = append(errs, fmt.Errorf("failed to checkpoint DuckDB: %w", err))
+		}
+		if err := sqlDB.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close DuckDB: %w", err))
+		}
+	}
+	if graphDB != nil {
+		if err := graphDB.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close graph database: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // columnExists 检查表中是否存在指定列
 func columnExists(db *sql.DB, tableName, columnName string) (bool, error) {
 	query := `SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`
@@ -172,6 +210,7 @@ func ensureTableColumns(db *sql.DB) error {
 		{"content", "TEXT"},
 		{"content_tokens", "TEXT"},
 		{"embedding", "FLOAT[1024]"},
+		{"deleted_at", "TIMESTAMP"},
 	}
 
 	for _, col := range requiredColumns {
@@ -198,6 +237,69 @@ func ensureTableColumns(db *sql.DB) error {
 	return nil
 }
 
+// retokenizeContent 已有文档的 content_tokens 由旧的分词规则生成时重新分词，并重建全文索引
+// 分词规则版本记录在 content_tokens 列的注释中，没有注释的数据库视为旧版本
+func retokenizeContent(db *sql.DB) error {
+	var version sql.NullString
+	err := db.QueryRow(`
+		SELECT comment FROM duckdb_columns()
+		WHERE table_name = 'documents' AND column_name = 'content_tokens'
+	`).Scan(&version)
+	if err != nil {
+		return fmt.Errorf("failed to read tokenizer version: %w", err)
+	}
+	if version.String == tokenizerVersion {
+		return nil
+	}
+
+	rows, err := db.Query(`SELECT id, content FROM documents WHERE content IS NOT NULL`)
+	if err != nil {
+		return fmt.Errorf("failed to query documents: %w", err)
+	}
+	docs := make(map[string]string)
+	for rows.Next() {
+		var id, content string
+		if err := rows.Scan(&id, &content); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan document: %w", err)
+		}
+		docs[id] = content
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	for id, content := range docs {
+		if _, err := tx.Exec(`UPDATE documents SET content_tokens = ? WHERE id = ?`, tokenizeWithSego(content), id); err != nil {
+			return fmt.Errorf("failed to update content_tokens: %w", err)
+		}
+	}
+	if _, err := tx.Exec(fmt.Sprintf(`COMMENT ON COLUMN documents.content_tokens IS '%s'`, tokenizerVersion)); err != nil {
+		return fmt.Errorf("failed to record tokenizer version: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit retokenized documents: %w", err)
+	}
+	logrus.WithFields(logrus.Fields{
+		"documents": len(docs),
+		"version":   tokenizerVersion,
+	}).Info("Documents retokenized")
+
+	// 已有的全文索引基于旧的分词结果，删除后由 createDuckDBFTSIndex 重建
+	if len(docs) > 0 {
+		if _, err := db.Exec(`PRAGMA drop_fts_index('documents')`); err != nil {
+			logrus.WithError(err).Warn("Failed to drop FTS index built from old tokens")
+		}
+	}
+	return nil
+}
+
 // ensureDuckDBExtensions 确保 DuckDB 扩展已加载
 func ensureDuckDBExtensions(db *sql.DB) error {
 	var count int
@@ -230,6 +332,7 @@ func createDuckDBFTSIndex(db *sql.DB) error {
 		}
 		return fmt.Errorf("failed to create FTS index: %w", err)
 	}
+	markFTSIndexBuilt(db)
 
 	logrus.Info("DuckDB FTS index created successfully with sego tokenization support")
 	return nil
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 
@@ -17,35 +16,32 @@ import (
 func getDBInfo(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"name": "browser-db",
-		"path": os.Getenv("DB_PATH"),
+		"path": dataDir(),
 	})
 }
 
-// getCollections 获取所有集合
+// getCollections 获取所有集合（已注册的集合和已有文档的集合）
 func getCollections(c *gin.Context) {
-	query := `SELECT DISTINCT collection_name FROM documents`
-	rows, err := sqlDB.Query(query)
+	collections, err := listCollectionNames()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 		return
 	}
-	defer rows.Close()
 
-	var collections []string
-	for rows.Next() {
-		var name string
-		if err := rows.Scan(&name); err != nil {
+	collectionInfos := make([]CollectionInfo, 0, len(collections))
+	for _, name := range collections {
+		// 只列出当前 API Key 可以访问的集合
+		if !collectionAllowed(c, name) {
 			continue
 		}
-		collections = append(collections, name)
-	}
-
-	collectionInfos := make([]CollectionInfo, len(collections))
-	for i, name := range collections {
-		collectionInfos[i] = CollectionInfo{
+		info := CollectionInfo{
 			Name:   name,
 			Schema: make(map[string]interface{}),
 		}
+		if settings, ok, err := loadCollectionSettings(sqlDB, name); err == nil && ok {
+			info.Settings = &settings
+		}
+		collectionInfos = append(collectionInfos, info)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -58,16 +54,23 @@ func getCollection(c *gin.Context) {
 	name := c.Param("name")
 
 	var count int64
-	query := `SELECT COUNT(*) FROM documents WHERE collection_name = ?`
+	query := `SELECT COUNT(*) FROM documents WHERE collection_name = ? AND ` + liveDocumentsSQL("")
 	if err := sqlDB.QueryRow(query, name).Scan(&count); err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 		return
 	}
 
+	settings, registered, err := loadCollectionSettings(sqlDB, name)
+	if err != nil {
+		logrus.WithError(err).WithField("collection", name).Warn("Failed to load collection settings")
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"name":   name,
-		"exists": count > 0,
-		"count":  count,
+		"name":       name,
+		"exists":     count > 0 || registered,
+		"count":      count,
+		"registered": registered,
+		"settings":   settings,
 	})
 }
 
@@ -110,34 +113,37 @@ func getDocuments(c *gin.Context) {
 	} else {
 		baseQuery = `SELECT id, collection_name, data, NULL as embedding, NULL as content, created_at, updated_at FROM documents WHERE collection_name = ?`
 	}
-	args := []interface{}{name}
-
+	// tag 参数等价于 selector {"tags": tag}：tags 为数组时包含该标签即可匹配
+	var selector map[string]interface{}
 	if tagFilter != "" {
-		baseQuery += ` AND json_extract(data, '$.tags') LIKE ?`
-		args = append(args, "%"+tagFilter+"%")
+		selector = map[string]interface{}{"tags": tagFilter}
 	}
-
-	countQuery := `SELECT COUNT(*) FROM documents WHERE collection_name = ?`
-	countArgs := []interface{}{name}
-	if tagFilter != "" {
-		countQuery += ` AND json_extract(data, '$.tags') LIKE ?`
-		countArgs = append(countArgs, "%"+tagFilter+"%")
+	where, whereArgs, err := compileSelector(selector)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
 	}
+	where = liveDocumentsSQL("") + ` AND ` + where
+	baseQuery += ` AND ` + where
+	args := append([]interface{}{name}, whereArgs...)
+
+	countQuery := `SELECT COUNT(*) FROM documents WHERE collection_name = ? AND ` + where
+	countArgs := append([]interface{}{name}, whereArgs...)
 
 	var total int64
-	if err := sqlDB.QueryRow(countQuery, countArgs...).Scan(&total); err != nil {
+	if err := sqlDB.QueryRowContext(c.Request.Context(), countQuery, countArgs...).Scan(&total); err != nil {
 		logrus.WithError(err).Error("❌ Failed to count documents")
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		c.JSON(queryErrorStatus(c), ErrorResponse{Error: err.Error()})
 		return
 	}
 
 	query := baseQuery + ` ORDER BY created_at DESC LIMIT ? OFFSET ?`
 	args = append(args, limit, skip)
 
-	rows, err := sqlDB.Query(query, args...)
+	rows, err := sqlDB.QueryContext(c.Request.Context(), query, args...)
 	if err != nil {
 		logrus.WithError(err).Error("❌ Failed to get documents")
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		c.JSON(queryErrorStatus(c), ErrorResponse{Error: err.Error()})
 		return
 	}
 	defer rows.Close()
@@ -218,6 +224,7 @@ func getDocument(c *gin.Context) {
 	} else {
 		query = `SELECT id, collection_name, data, NULL as embedding, NULL as content, created_at, updated_at FROM documents WHERE collection_name = ? AND id = ?`
 	}
+	query += ` AND ` + liveDocumentsSQL("")
 	err = sqlDB.QueryRow(query, name, id).Scan(&doc.ID, &doc.CollectionName, &doc.Data, &embeddingNull, &contentNull, &doc.CreatedAt, &doc.UpdatedAt)
 	if contentNull.Valid {
 		doc.Content = contentNull.String
@@ -240,6 +247,7 @@ func getDocument(c *gin.Context) {
 		return
 	}
 
+	setRevisionHeader(c, data)
 	c.JSON(http.StatusOK, DocumentResponse{
 		ID:   doc.ID,
 		Data: data,
@@ -261,6 +269,7 @@ func createDocument(c *gin.Context) {
 		id = generateID()
 		data["id"] = id
 	}
+	data[revField] = 1
 
 	dataJSON, err := json.Marshal(data)
 	if err != nil {
@@ -268,47 +277,111 @@ func createDocument(c *gin.Context) {
 		return
 	}
 
-	content := extractTextFromData(string(dataJSON))
-	contentTokens := tokenizeWithSego(content)
+	settings := getCollectionSettings(name)
+	var warnings []string
+	embeddingVector := extractEmbeddingVector(data["embedding"])
+	if err := checkVectorDimension(settings, embeddingVector); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if embeddingVector == nil {
+		// 客户端未提供 embedding 时，按集合配置的 embed 字段在服务端生成；失败不影响写入，可以稍后通过 reembed 补齐
+		embeddingVector, err = embedDocument(name, data)
+		if err == nil {
+			err = checkVectorDimension(settings, embeddingVector)
+		}
+		if err != nil {
+			logrus.WithError(err).WithField("id", id).Warn("Failed to generate document embedding")
+			warnings = append(warnings, fmt.Sprintf("embedding not generated: %v", err))
+			embeddingVector = nil
+		}
+	}
+
+	noteDocument(c, sqlDB, name, id)
 
-	var embeddingVector []float64
-	if embeddingField, ok := data["embedding"]; ok {
-		embeddingVector = extractEmbeddingVector(embeddingField)
+	// 回收站中同 ID 的文档会被新文档取代
+	cols := detectDocumentColumns()
+	purgedBlobs, err := purgeTrashedDocument(sqlDB, name, id, cols)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	removeUnreferencedBlobs(purgedBlobs)
+	if _, err := insertDocument(sqlDB, "INSERT", name, id, string(dataJSON), embeddingVector, settings.FTSFields, cols); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
 	}
 
-	hasEmbedding, err := columnExists(sqlDB, "documents", "embedding")
+	setRevisionHeader(c, data)
+	c.JSON(http.StatusCreated, DocumentResponse{
+		ID:       id,
+		Data:     data,
+		Warnings: warnings,
+	})
+}
+
+// sqlExecer 抽象 *sql.DB 与 *sql.Tx 的 Exec，便于在事务中复用插入逻辑
+type sqlExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// documentColumns 记录 documents 表中可选列是否存在
+type documentColumns struct {
+	embedding     bool
+	content       bool
+	contentTokens bool
+	deletedAt     bool
+}
+
+// detectDocumentColumns 检查 documents 表的可选列，检查失败时假定列存在
+func detectDocumentColumns() documentColumns {
+	var cols documentColumns
+	var err error
+
+	cols.embedding, err = columnExists(sqlDB, "documents", "embedding")
 	if err != nil {
 		logrus.WithError(err).Warn("Failed to check embedding column, assuming it exists")
-		hasEmbedding = true
+		cols.embedding = true
 	}
 
-	hasContent, err := columnExists(sqlDB, "documents", "content")
+	cols.content, err = columnExists(sqlDB, "documents", "content")
 	if err != nil {
 		logrus.WithError(err).Warn("Failed to check content column, assuming it exists")
-		hasContent = true
+		cols.content = true
 	}
 
-	hasContentTokens, err := columnExists(sqlDB, "documents", "content_tokens")
+	cols.contentTokens, err = columnExists(sqlDB, "documents", "content_tokens")
 	if err != nil {
 		logrus.WithError(err).Warn("Failed to check content_tokens column, assuming it exists")
-		hasContentTokens = true
+		cols.contentTokens = true
 	}
 
+	cols.deletedAt = hasDeletedAtColumn()
+
+	return cols
+}
+
+// insertDocument 插入一条文档，verb 为 INSERT / INSERT OR REPLACE / INSERT OR IGNORE，返回受影响的行数
+// ftsFields 为集合配置的全文检索字段，为空时索引所有字段
+func insertDocument(exec sqlExecer, verb, name, id, dataJSON string, embeddingVector []float64, ftsFields []string, cols documentColumns) (int64, error) {
+	content := documentContent(dataJSON, ftsFields)
+	contentTokens := tokenizeWithSego(content)
+
 	columns := []string{"id", "collection_name", "data"}
-	values := []interface{}{id, name, string(dataJSON)}
+	values := []interface{}{id, name, dataJSON}
 	placeholders := []string{"?", "?", "?"}
 
-	if hasEmbedding && len(embeddingVector) > 0 {
+	if cols.embedding && len(embeddingVector) > 0 {
 		columns = append(columns, "embedding")
-		values = append(values, embeddingVector)
+		values = append(values, formatVectorLiteral(embeddingVector))
 		placeholders = append(placeholders, "?")
 	}
-	if hasContent {
+	if cols.content {
 		columns = append(columns, "content")
 		values = append(values, content)
 		placeholders = append(placeholders, "?")
 	}
-	if hasContentTokens {
+	if cols.contentTokens {
 		columns = append(columns, "content_tokens")
 		values = append(values, contentTokens)
 		placeholders = append(placeholders, "?")
@@ -317,20 +390,16 @@ func createDocument(c *gin.Context) {
 	columns = append(columns, "created_at", "updated_at")
 	placeholders = append(placeholders, "CURRENT_TIMESTAMP", "CURRENT_TIMESTAMP")
 
-	insertQuery := fmt.Sprintf("INSERT INTO documents (%s) VALUES (%s)",
+	insertQuery := fmt.Sprintf("%s INTO documents (%s) VALUES (%s)",
+		verb,
 		strings.Join(columns, ", "),
 		strings.Join(placeholders, ", "))
 
-	_, err = sqlDB.Exec(insertQuery, values...)
+	result, err := exec.Exec(insertQuery, values...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
-		return
+		return 0, err
 	}
-
-	c.JSON(http.StatusCreated, DocumentResponse{
-		ID:   id,
-		Data: data,
-	})
+	return result.RowsAffected()
 }
 
 // updateDocument 更新文档
@@ -363,6 +432,7 @@ func updateDocument(c *gin.Context) {
 	} else {
 		query = `SELECT id, collection_name, data, NULL as embedding, NULL as content FROM documents WHERE collection_name = ? AND id = ?`
 	}
+	query += ` AND ` + liveDocumentsSQL("")
 	err = sqlDB.QueryRow(query, name, id).Scan(&doc.ID, &doc.CollectionName, &doc.Data, &embeddingNull, &contentNull)
 	if contentNull.Valid {
 		doc.Content = contentNull.String
@@ -391,11 +461,28 @@ func updateDocument(c *gin.Context) {
 		return
 	}
 
+	// 客户端通过 _rev 或 If-Match 指定期望的版本号时，版本不一致说明文档已被其他请求修改
+	currentRev := documentRevision(data)
+	expectedRev, checkRev, err := expectedRevision(c, updates)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if checkRev && expectedRev != currentRev {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": fmt.Sprintf("document revision conflict: expected %d, current %d", expectedRev, currentRev),
+			"_rev":  currentRev,
+		})
+		return
+	}
+	delete(updates, revField)
+
 	for k, v := range updates {
 		data[k] = v
 	}
 
 	data["id"] = id
+	data[revField] = currentRev + 1
 
 	dataJSON, err := json.Marshal(data)
 	if err != nil {
@@ -403,12 +490,33 @@ func updateDocument(c *gin.Context) {
 		return
 	}
 
-	content := extractTextFromData(string(dataJSON))
+	settings := getCollectionSettings(name)
+	content := documentContent(string(dataJSON), settings.FTSFields)
 	contentTokens := tokenizeWithSego(content)
 
-	var embeddingVector []float64
-	if embeddingField, ok := data["embedding"]; ok {
-		embeddingVector = extractEmbeddingVector(embeddingField)
+	var warnings []string
+	embeddingVector := extractEmbeddingVector(data["embedding"])
+	if err := checkVectorDimension(settings, embeddingVector); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	// 配置了 embed 字段时，只有这些字段被修改或文档还没有 embedding 时才重新生成，否则保留原有向量
+	embedFields := collectionEmbedFields(name)
+	keepEmbedding := false
+	if embeddingVector == nil && len(embedFields) > 0 {
+		keepEmbedding = true
+		if !embeddingNull.Valid || updatesTouchFields(updates, embedFields) {
+			vector, err := embedDocument(name, data)
+			if err == nil {
+				err = checkVectorDimension(settings, vector)
+			}
+			if err != nil {
+				logrus.WithError(err).WithField("id", id).Warn("Failed to generate document embedding")
+				warnings = append(warnings, fmt.Sprintf("embedding not updated: %v", err))
+			} else if vector != nil {
+				embeddingVector = vector
+			}
+		}
 	}
 
 	hasContentTokens, err := columnExists(sqlDB, "documents", "content_tokens")
@@ -422,8 +530,8 @@ func updateDocument(c *gin.Context) {
 
 	if hasEmbedding && len(embeddingVector) > 0 {
 		setParts = append(setParts, "embedding = ?")
-		values = append(values, embeddingVector)
-	} else if hasEmbedding {
+		values = append(values, formatVectorLiteral(embeddingVector))
+	} else if hasEmbedding && !keepEmbedding {
 		setParts = append(setParts, "embedding = NULL")
 	}
 	if hasContent {
@@ -438,32 +546,64 @@ func updateDocument(c *gin.Context) {
 	setParts = append(setParts, "updated_at = CURRENT_TIMESTAMP")
 	values = append(values, name, id)
 
-	updateQuery := fmt.Sprintf("UPDATE documents SET %s WHERE collection_name = ? AND id = ?",
-		strings.Join(setParts, ", "))
+	values = append(values, currentRev)
+
+	// 版本号条件保证读取和写入之间没有其他更新（包括被移入回收站）
+	updateQuery := fmt.Sprintf("UPDATE documents SET %s WHERE collection_name = ? AND id = ? AND %s = ? AND %s",
+		strings.Join(setParts, ", "), revisionSQL, liveDocumentsSQL(""))
 
-	_, err = sqlDB.Exec(updateQuery, values...)
+	result, err := sqlDB.Exec(updateQuery, values...)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 		return
 	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: "document was modified concurrently, reload and retry"})
+		return
+	}
 
+	setRevisionHeader(c, data)
 	c.JSON(http.StatusOK, DocumentResponse{
-		ID:   doc.ID,
-		Data: data,
+		ID:       doc.ID,
+		Data:     data,
+		Warnings: warnings,
 	})
 }
 
-// deleteDocument 删除文档
+// updatesTouchFields 判断本次更新是否涉及指定字段（按顶层字段名比较）
+func updatesTouchFields(updates map[string]interface{}, fields []string) bool {
+	for _, field := range fields {
+		if _, ok := updates[strings.SplitN(field, ".", 2)[0]]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteDocument 删除文档：默认移入回收站，permanent=true 时永久删除
 func deleteDocument(c *gin.Context) {
 	name := c.Param("name")
 	id := c.Param("id")
 
-	deleteQuery := `DELETE FROM documents WHERE collection_name = ? AND id = ?`
-	_, err := sqlDB.Exec(deleteQuery, name, id)
-	if err != nil {
+	if c.Query("permanent") == "true" {
+		deleteQuery := `DELETE FROM documents WHERE collection_name = ? AND id = ?`
+		if _, err := sqlDB.Exec(deleteQuery, name, id); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		sums, err := deleteDocumentAttachments(sqlDB, name, id)
+		if err != nil {
+			logrus.WithError(err).WithField("id", id).Warn("Failed to delete document attachments")
+		}
+		removeUnreferencedBlobs(sums)
+		c.JSON(http.StatusOK, gin.H{"message": "Document deleted"})
+		return
+	}
+
+	if _, err := softDeleteDocument(name, id); err != nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Document deleted"})
+	c.JSON(http.StatusOK, gin.H{"message": "Document moved to trash"})
 }
@@ -1,100 +1,126 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
-	"time"
+	"strings"
+
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/config"
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/embedding"
+	"github.com/sirupsen/logrus"
 )
 
-// DashScope API 结构
-type DashScopeEmbeddingRequest struct {
-	Model string         `json:"model"`
-	Input DashScopeInput `json:"input"`
-}
+// Embedder 文本向量化接口，与 chatbot 后端共用 pkg/embedding 的实现，
+// 通过 embedding.provider（EMBEDDING_PROVIDER）在 DashScope、OpenAI 兼容服务、Ollama 和自定义 HTTP 服务之间切换
+type Embedder = embedding.Provider
 
-type DashScopeInput struct {
-	Texts []string `json:"texts"`
-}
+// embedder 当前使用的 Embedder，为 nil 时按配置创建（测试中可替换）
+var embedder Embedder
 
-type DashScopeEmbeddingResponse struct {
-	Output DashScopeOutput `json:"output"`
-}
+// embedRequestTimeout 单次向量化请求的超时时间
+const embedRequestTimeout = embedding.DefaultTimeout
 
-type DashScopeOutput struct {
-	Embeddings []DashScopeEmbedding `json:"embeddings"`
+// currentEmbedder 返回当前的 Embedder
+func currentEmbedder() (Embedder, error) {
+	if embedder != nil {
+		return embedder, nil
+	}
+	return newEmbedderFromConfig(currentConfig().Embedding)
 }
 
-type DashScopeEmbedding struct {
-	Embedding []float32 `json:"embedding"`
+// newEmbedderFromConfig 根据配置创建 Embedder，未配置服务时使用 DashScope
+func newEmbedderFromConfig(cfg config.EmbeddingConfig) (Embedder, error) {
+	return embedding.New(embedding.Config{
+		Provider:  cfg.Provider,
+		APIKey:    cfg.APIKey,
+		BaseURL:   cfg.BaseURL,
+		Model:     cfg.Model,
+		Dimension: cfg.Dimension,
+	})
 }
 
-// generateEmbeddingFromText 使用 DashScope API 从文本生成 embedding
+// generateEmbeddingFromText 使用当前的 Embedder 从文本生成 embedding
 func generateEmbeddingFromText(text string) ([]float64, error) {
-	apiKey := os.Getenv("DASHSCOPE_API_KEY")
-	if apiKey == "" {
-		return nil, fmt.Errorf("DASHSCOPE_API_KEY environment variable is not set")
-	}
-
-	url := "https://dashscope.aliyuncs.com/api/v1/services/embeddings/text-embedding/text-embedding"
-
-	reqBody := DashScopeEmbeddingRequest{
-		Model: "text-embedding-v4",
-		Input: DashScopeInput{
-			Texts: []string{text},
-		},
-	}
-
-	jsonData, err := json.Marshal(reqBody)
+	e, err := currentEmbedder()
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, err
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), embedRequestTimeout)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	vectors, err := e.Embed(ctx, []string{text})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiKey))
-
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+	if len(vectors) == 0 {
+		return nil, fmt.Errorf("no embedding returned")
 	}
-	defer resp.Body.Close()
+	return vectors[0], nil
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+// collectionEmbedFields 返回集合配置的需要服务端生成 embedding 的字段
+// 优先使用集合注册表中的 embed_fields，未配置时回退到环境变量 EMBED_FIELDS，
+// 格式为 JSON：{"articles": ["title", "content"]}
+func collectionEmbedFields(name string) []string {
+	if settings := getCollectionSettings(name); len(settings.EmbedFields) > 0 {
+		return settings.EmbedFields
 	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	raw := os.Getenv("EMBED_FIELDS")
+	if raw == "" {
+		return nil
 	}
-
-	var apiResp DashScopeEmbeddingResponse
-	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	var config map[string][]string
+	if err := json.Unmarshal([]byte(raw), &config); err != nil {
+		logrus.WithError(err).Warn("Invalid EMBED_FIELDS, server-side embedding disabled")
+		return nil
 	}
+	return config[name]
+}
 
-	if len(apiResp.Output.Embeddings) == 0 {
-		return nil, fmt.Errorf("no embedding returned")
-	}
+// embeddingTextFromData 拼接 embed 字段的文本，字段支持点号路径
+func embeddingTextFromData(data map[string]interface{}, fields []string) string {
+	return strings.Join(fieldTexts(data, fields), "\n")
+}
 
-	embedding := apiResp.Output.Embeddings[0].Embedding
-	result := make([]float64, len(embedding))
-	for i, v := range embedding {
-		result[i] = float64(v)
+// fieldTexts 按顺序取出字段中的文本，数组字段只取其中的字符串元素
+func fieldTexts(data map[string]interface{}, fields []string) []string {
+	var parts []string
+	for _, field := range fields {
+		value, ok := lookupField(data, strings.Split(field, "."))
+		if !ok || value == nil {
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			if v != "" {
+				parts = append(parts, v)
+			}
+		case []interface{}:
+			for _, item := range v {
+				if s, ok := item.(string); ok && s != "" {
+					parts = append(parts, s)
+				}
+			}
+		default:
+			parts = append(parts, fmt.Sprint(v))
+		}
 	}
+	return parts
+}
 
-	return result, nil
+// embedDocument 按集合的 embed 字段为文档生成 embedding
+// 返回 nil 向量表示集合未配置 embed 字段或文档没有可向量化的文本
+func embedDocument(name string, data map[string]interface{}) ([]float64, error) {
+	fields := collectionEmbedFields(name)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	text := embeddingTextFromData(data, fields)
+	if text == "" {
+		return nil, nil
+	}
+	return generateEmbeddingFromText(text)
 }
@@ -6,7 +6,9 @@ require (
 	github.com/gin-contrib/cors v1.7.0
 	github.com/gin-gonic/gin v1.10.0
 	github.com/mozhou-tech/sqlite-ai-driver/pkg/cayley-driver v0.0.0
+	github.com/mozhou-tech/sqlite-ai-driver/pkg/config v0.0.0
 	github.com/mozhou-tech/sqlite-ai-driver/pkg/duckdb-driver v0.0.0
+	github.com/mozhou-tech/sqlite-ai-driver/pkg/embedding v0.0.0
 	github.com/mozhou-tech/sqlite-ai-driver/pkg/sego v0.0.0
 	github.com/mozhou-tech/sqlite-ai-driver/pkg/sqlite3-driver v0.0.0
 	github.com/sirupsen/logrus v1.9.3
@@ -17,7 +19,9 @@ require (
 replace (
 	github.com/mozhou-tech/sqlite-ai-driver => ../../
 	github.com/mozhou-tech/sqlite-ai-driver/pkg/cayley-driver => ../../pkg/cayley-driver
+	github.com/mozhou-tech/sqlite-ai-driver/pkg/config => ../../pkg/config
 	github.com/mozhou-tech/sqlite-ai-driver/pkg/duckdb-driver => ../../pkg/duckdb-driver
+	github.com/mozhou-tech/sqlite-ai-driver/pkg/embedding => ../../pkg/embedding
 	github.com/mozhou-tech/sqlite-ai-driver/pkg/graphstore => ../../pkg/graphstore
 	github.com/mozhou-tech/sqlite-ai-driver/pkg/lightrag => ../../pkg/lightrag
 	github.com/mozhou-tech/sqlite-ai-driver/pkg/sego => ../../pkg/sego
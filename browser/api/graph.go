@@ -1,10 +1,11 @@
 package main
 
 import (
+	"fmt"
 	"net/http"
-	"strings"
 
 	"github.com/gin-gonic/gin"
+	cayley_driver "github.com/mozhou-tech/sqlite-ai-driver/pkg/cayley-driver"
 	"github.com/sirupsen/logrus"
 )
 
@@ -64,11 +65,17 @@ func graphUnlink(c *gin.Context) {
 	})
 }
 
-// graphNeighbors 获取节点的邻居
+// graphNeighbors 获取节点的邻居，按节点 ID 排序分页
 func graphNeighbors(c *gin.Context) {
 	nodeID := c.Param("nodeId")
 	relation := c.DefaultQuery("relation", "")
 
+	page, err := parseGraphPageQuery(c.Query("limit"), c.Query("offset"), c.Query("cursor"), c.Query("order"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
 	if graphDB == nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error: "Graph database not available",
@@ -82,10 +89,25 @@ func graphNeighbors(c *gin.Context) {
 		return
 	}
 
+	total := len(neighbors)
+	neighbors, nextCursor, err := paginateGraphResults(neighbors, func(n string) []string { return []string{n} }, page)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if neighbors == nil {
+		neighbors = []string{}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"node_id":   nodeID,
-		"relation":  relation,
-		"neighbors": neighbors,
+		"node_id":     nodeID,
+		"relation":    relation,
+		"neighbors":   neighbors,
+		"total":       total,
+		"limit":       page.Limit,
+		"offset":      page.Offset,
+		"has_more":    nextCursor != "",
+		"next_cursor": nextCursor,
 	})
 }
 
@@ -137,146 +159,65 @@ func graphQuery(c *gin.Context) {
 		return
 	}
 
-	if graphDB == nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error: "Graph database not available",
-		})
+	page, err := parseGraphPage(req.Limit, req.Offset, req.Cursor, req.Order)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
-
-	query := graphDB.Query()
-	if query == nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error: "Query builder not available",
-		})
+	sortKey, err := tripleSortKey(req.Sort)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 		return
 	}
 
-	logrus.WithField("query", req.Query).Info("🔍 解析查询字符串")
-
-	if !strings.HasPrefix(req.Query, "V(") {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error: "查询必须以 V('nodeId') 开始",
-		})
+	traversal := req.Traversal
+	switch {
+	case req.Query != "" && traversal != nil:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "query and traversal cannot be used together"})
 		return
-	}
-
-	var nodeID string
-	var vEndIndex int
-
-	nodeStart := strings.Index(req.Query, "('")
-	if nodeStart == -1 {
-		nodeStart = strings.Index(req.Query, "(\"")
-		if nodeStart == -1 {
-			c.JSON(http.StatusBadRequest, ErrorResponse{
-				Error: "无法解析节点ID，格式应为 V('nodeId') 或 V(\"nodeId\")",
-			})
-			return
-		}
-		relEnd := strings.Index(req.Query[nodeStart+2:], "\")")
-		if relEnd == -1 {
-			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "节点ID格式错误"})
+	case req.Query != "":
+		if traversal, err = parseTraversal(req.Query); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 			return
 		}
-		nodeID = req.Query[nodeStart+2 : nodeStart+2+relEnd]
-		vEndIndex = nodeStart + 2 + relEnd + 2
-	} else {
-		relEnd := strings.Index(req.Query[nodeStart+2:], "')")
-		if relEnd == -1 {
-			c.JSON(http.StatusBadRequest, ErrorResponse{Error: "节点ID格式错误"})
+	case traversal != nil:
+		if err := traversal.validate(); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 			return
 		}
-		nodeID = req.Query[nodeStart+2 : nodeStart+2+relEnd]
-		vEndIndex = nodeStart + 2 + relEnd + 2
-	}
-
-	logrus.WithField("node_id", nodeID).Info("📌 提取节点ID")
-
-	queryImpl := query.V(nodeID)
-	if queryImpl == nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error: "创建查询失败",
-		})
+	default:
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "query or traversal is required"})
 		return
 	}
 
-	remainingQuery := ""
-	if vEndIndex < len(req.Query) {
-		remainingQuery = req.Query[vEndIndex:]
-	}
-	logrus.WithField("remaining_query", remainingQuery).Info("📋 剩余查询部分")
-
-	if strings.HasPrefix(remainingQuery, ".Out(") {
-		relStart := strings.Index(remainingQuery, "('")
-		if relStart == -1 {
-			relStart = strings.Index(remainingQuery, "(\"")
-			if relStart == -1 {
-				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "无法解析关系名称"})
-				return
-			}
-			relEnd := strings.Index(remainingQuery[relStart+2:], "\")")
-			if relEnd == -1 {
-				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "关系名称格式错误"})
-				return
-			}
-			relation := remainingQuery[relStart+2 : relStart+2+relEnd]
-			logrus.WithField("relation", relation).Info("🔗 提取关系 (Out)")
-			queryImpl = queryImpl.Out(relation)
-		} else {
-			relEnd := strings.Index(remainingQuery[relStart+2:], "')")
-			if relEnd == -1 {
-				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "关系名称格式错误"})
-				return
-			}
-			relation := remainingQuery[relStart+2 : relStart+2+relEnd]
-			logrus.WithField("relation", relation).Info("🔗 提取关系 (Out)")
-			queryImpl = queryImpl.Out(relation)
-		}
-	} else if strings.HasPrefix(remainingQuery, ".In(") {
-		relStart := strings.Index(remainingQuery, "('")
-		if relStart == -1 {
-			relStart = strings.Index(remainingQuery, "(\"")
-			if relStart == -1 {
-				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "无法解析关系名称"})
-				return
-			}
-			relEnd := strings.Index(remainingQuery[relStart+2:], "\")")
-			if relEnd == -1 {
-				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "关系名称格式错误"})
-				return
-			}
-			relation := remainingQuery[relStart+2 : relStart+2+relEnd]
-			logrus.WithField("relation", relation).Info("🔗 提取关系 (In)")
-			queryImpl = queryImpl.In(relation)
-		} else {
-			relEnd := strings.Index(remainingQuery[relStart+2:], "')")
-			if relEnd == -1 {
-				c.JSON(http.StatusBadRequest, ErrorResponse{Error: "关系名称格式错误"})
-				return
-			}
-			relation := remainingQuery[relStart+2 : relStart+2+relEnd]
-			logrus.WithField("relation", relation).Info("🔗 提取关系 (In)")
-			queryImpl = queryImpl.In(relation)
-		}
-	}
-
-	if queryImpl == nil {
+	if graphDB == nil {
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error: "构建查询失败",
+			Error: "Graph database not available",
 		})
 		return
 	}
 
-	logrus.Info("🚀 执行图查询...")
-	queryResults, err := queryImpl.All(dbContext)
+	logrus.WithFields(logrus.Fields{
+		"start": traversal.Start,
+		"steps": len(traversal.Steps),
+	}).Info("🚀 执行图查询...")
+	result, err := executeTraversal(dbContext, graphDB, traversal)
 	if err != nil {
 		logrus.WithError(err).Info("❌ 查询执行失败")
 		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
 		return
 	}
+	queryResults := result.Triples
 
 	logrus.WithField("count", len(queryResults)).Info("✅ 查询成功，找到结果")
 
+	total := len(queryResults)
+	queryResults, nextCursor, err := paginateGraphResults(queryResults, sortKey, page)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
 	results := make([]gin.H, len(queryResults))
 	for i, r := range queryResults {
 		results[i] = gin.H{
@@ -287,7 +228,28 @@ func graphQuery(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"query":   req.Query,
-		"results": results,
+		"query":       req.Query,
+		"traversal":   traversal,
+		"nodes":       result.Nodes,
+		"results":     results,
+		"total":       total,
+		"limit":       page.Limit,
+		"offset":      page.Offset,
+		"has_more":    nextCursor != "",
+		"next_cursor": nextCursor,
 	})
 }
+
+// tripleSortKey 返回三元组的排序键，先按 sort 指定的字段，再按其余字段排序保证顺序稳定
+func tripleSortKey(field string) (func(cayley_driver.Triple) []string, error) {
+	switch field {
+	case "", "subject":
+		return func(t cayley_driver.Triple) []string { return []string{t.Subject, t.Predicate, t.Object} }, nil
+	case "predicate":
+		return func(t cayley_driver.Triple) []string { return []string{t.Predicate, t.Subject, t.Object} }, nil
+	case "object":
+		return func(t cayley_driver.Triple) []string { return []string{t.Object, t.Subject, t.Predicate} }, nil
+	default:
+		return nil, fmt.Errorf("invalid sort: %s (expected subject, predicate or object)", field)
+	}
+}
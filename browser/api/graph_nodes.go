@@ -0,0 +1,430 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	cayley_driver "github.com/mozhou-tech/sqlite-ai-driver/pkg/cayley-driver"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultSubgraphDepth 子图默认展开的层数
+	defaultSubgraphDepth = 1
+	// maxSubgraphDepth 子图最多展开的层数
+	maxSubgraphDepth = 5
+	// defaultSubgraphNodes 子图默认最多返回的节点数
+	defaultSubgraphNodes = 500
+	// maxBulkLinks 单次批量创建边的上限
+	maxBulkLinks = 10000
+)
+
+// ensureGraphNodesTable 创建图节点属性表
+// 图数据库只保存三元组，节点的标签和属性保存在 DuckDB 中
+func ensureGraphNodesTable(db *sql.DB) error {
+	_, err := db.Exec(`
+	CREATE TABLE IF NOT EXISTS graph_nodes (
+		id VARCHAR PRIMARY KEY,
+		label VARCHAR,
+		properties TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create graph_nodes table: %w", err)
+	}
+	return nil
+}
+
+// graphAvailable 检查图数据库是否可用，不可用时写入错误响应
+func graphAvailable(c *gin.Context) bool {
+	if graphDB == nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: "Graph database not available",
+		})
+		return false
+	}
+	return true
+}
+
+// createGraphNode 创建或更新图节点，已存在时替换其标签和属性
+func createGraphNode(c *gin.Context) {
+	var req GraphNodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if req.Properties == nil {
+		req.Properties = make(map[string]interface{})
+	}
+
+	properties, err := json.Marshal(req.Properties)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	_, exists, err := loadGraphNode(req.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if exists {
+		_, err = sqlDB.Exec(`UPDATE graph_nodes SET label = ?, properties = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+			req.Label, string(properties), req.ID)
+	} else {
+		_, err = sqlDB.Exec(`INSERT INTO graph_nodes (id, label, properties) VALUES (?, ?, ?)`,
+			req.ID, req.Label, string(properties))
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	status := http.StatusCreated
+	if exists {
+		status = http.StatusOK
+	}
+	c.JSON(status, GraphNode{
+		ID:         req.ID,
+		Label:      req.Label,
+		Properties: req.Properties,
+	})
+}
+
+// getGraphNode 获取图节点的属性和出入度
+func getGraphNode(c *gin.Context) {
+	nodeID := c.Param("id")
+	if !graphAvailable(c) {
+		return
+	}
+
+	node, exists, err := loadGraphNode(nodeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	edges, err := nodeEdges(nodeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if !exists && len(edges) == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Node not found"})
+		return
+	}
+
+	outDegree, inDegree := 0, 0
+	for _, edge := range edges {
+		if edge.From == nodeID {
+			outDegree++
+		}
+		if edge.To == nodeID {
+			inDegree++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":         node.ID,
+		"label":      node.Label,
+		"properties": node.Properties,
+		"out_degree": outDegree,
+		"in_degree":  inDegree,
+	})
+}
+
+// deleteGraphNode 删除图节点；节点还有边时需要 cascade=true 才会连同边一起删除
+func deleteGraphNode(c *gin.Context) {
+	nodeID := c.Param("id")
+	cascade := c.DefaultQuery("cascade", "false") == "true"
+	if !graphAvailable(c) {
+		return
+	}
+
+	_, exists, err := loadGraphNode(nodeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	edges, err := nodeEdges(nodeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if !exists && len(edges) == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Node not found"})
+		return
+	}
+	if len(edges) > 0 && !cascade {
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Error: fmt.Sprintf("node %s still has %d edges, use cascade=true to delete them", nodeID, len(edges)),
+		})
+		return
+	}
+
+	if len(edges) > 0 {
+		tx, err := graphDB.BeginTx(dbContext)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+		for _, edge := range edges {
+			if err := tx.Unlink(dbContext, edge.From, edge.Relation, edge.To); err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+				return
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	if _, err := sqlDB.Exec(`DELETE FROM graph_nodes WHERE id = ?`, nodeID); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"node_id": nodeID,
+		"edges":   len(edges),
+	}).Info("🗑️ Graph node deleted")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Node deleted successfully",
+		"id":            nodeID,
+		"deleted_edges": len(edges),
+	})
+}
+
+// graphBulkLink 在一个事务中批量创建边，任意一条失败时全部回滚
+func graphBulkLink(c *gin.Context) {
+	var req GraphBulkLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if len(req.Links) > maxBulkLinks {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("too many links: %d (max %d)", len(req.Links), maxBulkLinks)})
+		return
+	}
+	for i, link := range req.Links {
+		if link.From == "" || link.Relation == "" || link.To == "" {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("link %d: from, relation and to are required", i)})
+			return
+		}
+	}
+	if !graphAvailable(c) {
+		return
+	}
+
+	tx, err := graphDB.BeginTx(dbContext)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	for i, link := range req.Links {
+		if err := tx.Link(dbContext, link.From, link.Relation, link.To); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("link %d: %v", i, err)})
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	logrus.WithField("count", len(req.Links)).Info("🔗 Bulk links created")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Links created successfully",
+		"count":   len(req.Links),
+	})
+}
+
+// graphSubgraph 从节点出发按广度优先展开 depth 层，返回途经的节点（含属性）和边
+// direction 为 out、in 或 both（默认），relation 限定边的类型，limit 限制节点数
+func graphSubgraph(c *gin.Context) {
+	rootID := c.Param("nodeId")
+	relation := c.Query("relation")
+	direction := c.DefaultQuery("direction", "both")
+	if direction != "out" && direction != "in" && direction != "both" {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid direction: %s (expected out, in or both)", direction)})
+		return
+	}
+	depth, err := strconv.Atoi(c.DefaultQuery("depth", strconv.Itoa(defaultSubgraphDepth)))
+	if err != nil || depth < 0 || depth > maxSubgraphDepth {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("depth must be between 0 and %d", maxSubgraphDepth)})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultSubgraphNodes)))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "limit must be a positive integer"})
+		return
+	}
+	if !graphAvailable(c) {
+		return
+	}
+
+	var filter cayley_driver.TripleFilter
+	if relation != "" {
+		filter.Predicates = []string{relation}
+	}
+
+	depths := map[string]int{rootID: 0}
+	order := []string{rootID}
+	var edges []GraphEdge
+	seenEdges := make(map[GraphEdge]bool)
+	truncated := false
+
+	frontier := []string{rootID}
+	for level := 1; level <= depth && len(frontier) > 0 && !truncated; level++ {
+		var next []string
+		for _, nodeID := range frontier {
+			visit := func(t cayley_driver.Triple) error {
+				neighbor := t.Object
+				if t.Object == nodeID {
+					neighbor = t.Subject
+				}
+				if _, seen := depths[neighbor]; !seen {
+					if len(order) >= limit {
+						truncated = true
+						return cayley_driver.ErrStopIteration
+					}
+					depths[neighbor] = level
+					order = append(order, neighbor)
+					next = append(next, neighbor)
+				}
+				edge := GraphEdge{From: t.Subject, Relation: t.Predicate, To: t.Object}
+				if !seenEdges[edge] {
+					seenEdges[edge] = true
+					edges = append(edges, edge)
+				}
+				return nil
+			}
+			if direction != "in" {
+				outFilter := filter
+				outFilter.Subject = nodeID
+				if err := graphDB.TriplesIter(dbContext, outFilter, visit); err != nil {
+					c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+					return
+				}
+			}
+			if direction != "out" && !truncated {
+				inFilter := filter
+				inFilter.Object = nodeID
+				if err := graphDB.TriplesIter(dbContext, inFilter, visit); err != nil {
+					c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+					return
+				}
+			}
+			if truncated {
+				break
+			}
+		}
+		frontier = next
+	}
+
+	properties, err := loadGraphNodes(order)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	nodes := make([]GraphNode, len(order))
+	for i, id := range order {
+		node, ok := properties[id]
+		if !ok {
+			node = GraphNode{ID: id, Properties: make(map[string]interface{})}
+		}
+		node.Depth = depths[id]
+		nodes[i] = node
+	}
+	if edges == nil {
+		edges = []GraphEdge{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"root":      rootID,
+		"depth":     depth,
+		"nodes":     nodes,
+		"edges":     edges,
+		"truncated": truncated,
+	})
+}
+
+// nodeEdges 返回与节点相连的所有边（出边和入边）
+func nodeEdges(nodeID string) ([]GraphEdge, error) {
+	var edges []GraphEdge
+	collect := func(t cayley_driver.Triple) error {
+		edges = append(edges, GraphEdge{From: t.Subject, Relation: t.Predicate, To: t.Object})
+		return nil
+	}
+	if err := graphDB.TriplesIter(dbContext, cayley_driver.TripleFilter{Subject: nodeID}, collect); err != nil {
+		return nil, err
+	}
+	// 自环在出边中已经收集过
+	err := graphDB.TriplesIter(dbContext, cayley_driver.TripleFilter{Object: nodeID}, func(t cayley_driver.Triple) error {
+		if t.Subject == nodeID {
+			return nil
+		}
+		return collect(t)
+	})
+	return edges, err
+}
+
+// loadGraphNode 读取节点属性，节点未登记时 exists 为 false，返回的节点属性为空
+func loadGraphNode(nodeID string) (node GraphNode, exists bool, err error) {
+	nodes, err := loadGraphNodes([]string{nodeID})
+	if err != nil {
+		return node, false, err
+	}
+	node, exists = nodes[nodeID]
+	if !exists {
+		node = GraphNode{ID: nodeID, Properties: make(map[string]interface{})}
+	}
+	return node, exists, nil
+}
+
+// loadGraphNodes 批量读取节点属性，只返回已登记的节点
+func loadGraphNodes(ids []string) (map[string]GraphNode, error) {
+	nodes := make(map[string]GraphNode, len(ids))
+	if len(ids) == 0 {
+		return nodes, nil
+	}
+
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	rows, err := sqlDB.Query(`SELECT id, label, properties FROM graph_nodes WHERE id IN (`+strings.Join(placeholders, ", ")+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var label, properties sql.NullString
+		if err := rows.Scan(&id, &label, &properties); err != nil {
+			return nil, err
+		}
+		node := GraphNode{ID: id, Label: label.String, Properties: make(map[string]interface{})}
+		if properties.Valid && properties.String != "" {
+			if err := json.Unmarshal([]byte(properties.String), &node.Properties); err != nil {
+				logrus.WithError(err).WithField("node_id", id).Warn("Invalid graph node properties")
+			}
+		}
+		nodes[id] = node
+	}
+	return nodes, rows.Err()
+}
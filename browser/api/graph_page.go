@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+const (
+	// defaultGraphPageLimit 图查询结果默认每页条数
+	defaultGraphPageLimit = 100
+	// maxGraphPageLimit 图查询结果每页最多条数
+	maxGraphPageLimit = 1000
+)
+
+// graphPage 图查询结果的分页参数
+// cursor 为上一页响应中的 next_cursor，与 offset 不能同时使用
+type graphPage struct {
+	Limit      int
+	Offset     int
+	Cursor     string
+	Descending bool
+}
+
+// parseGraphPage 校验分页参数，limit 为 0 时使用默认值
+func parseGraphPage(limit, offset int, cursor, order string) (graphPage, error) {
+	page := graphPage{Limit: limit, Offset: offset, Cursor: cursor}
+	if page.Limit == 0 {
+		page.Limit = defaultGraphPageLimit
+	}
+	if page.Limit < 0 || page.Limit > maxGraphPageLimit {
+		return page, fmt.Errorf("limit must be between 1 and %d", maxGraphPageLimit)
+	}
+	if page.Offset < 0 {
+		return page, fmt.Errorf("offset must be non-negative")
+	}
+	if page.Cursor != "" && page.Offset > 0 {
+		return page, fmt.Errorf("cursor and offset cannot be used together")
+	}
+	switch order {
+	case "", "asc":
+	case "desc":
+		page.Descending = true
+	default:
+		return page, fmt.Errorf("invalid order: %s (expected asc or desc)", order)
+	}
+	return page, nil
+}
+
+// parseGraphPageQuery 从查询参数读取分页参数
+func parseGraphPageQuery(limit, offset, cursor, order string) (graphPage, error) {
+	var l, o int
+	var err error
+	if limit != "" {
+		if l, err = strconv.Atoi(limit); err != nil {
+			return graphPage{}, fmt.Errorf("invalid limit: %s", limit)
+		}
+	}
+	if offset != "" {
+		if o, err = strconv.Atoi(offset); err != nil {
+			return graphPage{}, fmt.Errorf("invalid offset: %s", offset)
+		}
+	}
+	return parseGraphPage(l, o, cursor, order)
+}
+
+// paginateGraphResults 按排序键排序后取出一页，返回该页结果和下一页的 cursor（没有下一页时为空）
+// 图数据库的查询接口返回完整结果，这里在内存中分页，避免一次响应返回所有边
+func paginateGraphResults[T any](items []T, key func(T) []string, page graphPage) ([]T, string, error) {
+	less := func(a, b []string) bool {
+		for i := range a {
+			if a[i] != b[i] {
+				return a[i] < b[i]
+			}
+		}
+		return false
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if page.Descending {
+			return less(key(items[j]), key(items[i]))
+		}
+		return less(key(items[i]), key(items[j]))
+	})
+
+	start := page.Offset
+	if page.Cursor != "" {
+		after, err := decodeGraphCursor(page.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(items) > 0 && len(key(items[0])) != len(after) {
+			return nil, "", fmt.Errorf("invalid cursor")
+		}
+		// 第一个排在 cursor 之后的位置
+		start = sort.Search(len(items), func(i int) bool {
+			k := key(items[i])
+			if page.Descending {
+				return less(k, after)
+			}
+			return less(after, k)
+		})
+	}
+	if start > len(items) {
+		start = len(items)
+	}
+	end := start + page.Limit
+	if end > len(items) {
+		end = len(items)
+	}
+
+	result := items[start:end]
+	nextCursor := ""
+	if end < len(items) && len(result) > 0 {
+		nextCursor = encodeGraphCursor(key(result[len(result)-1]))
+	}
+	return result, nextCursor, nil
+}
+
+// encodeGraphCursor 将排序键编码为不透明的 cursor
+func encodeGraphCursor(key []string) string {
+	data, _ := json.Marshal(key)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeGraphCursor 解码 cursor
+func decodeGraphCursor(cursor string) ([]string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	var key []string
+	if err := json.Unmarshal(data, &key); err != nil || len(key) == 0 {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return key, nil
+}
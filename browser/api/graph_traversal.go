@@ -0,0 +1,436 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	cayley_driver "github.com/mozhou-tech/sqlite-ai-driver/pkg/cayley-driver"
+)
+
+const (
+	// maxTraversalSteps 单个遍历最多包含的步骤数
+	maxTraversalSteps = 20
+	// maxTraversalFrontier 遍历过程中每一步最多保留的节点数，超出时报错，避免稠密节点拖垮服务
+	maxTraversalFrontier = 10000
+)
+
+// GraphTraversal 图遍历的 JSON 形式，也是遍历语言解析后的结果
+//
+//	V('alice').Out('follows').Has('type', 'person').Limit(10)
+//
+// 等价于
+//
+//	{"start": ["alice"], "steps": [
+//	  {"op": "out", "predicate": "follows"},
+//	  {"op": "has", "predicate": "type", "object": "person"},
+//	  {"op": "limit", "limit": 10}]}
+type GraphTraversal struct {
+	Start []string             `json:"start"`
+	Steps []GraphTraversalStep `json:"steps"`
+}
+
+// GraphTraversalStep 遍历中的一步
+// out / in / both 沿边移动，predicate 为空表示任意类型的边（both 为出边和入边之和）；
+// has 只保留存在 predicate -> object 出边的节点；limit 只保留前 limit 个节点
+type GraphTraversalStep struct {
+	Op        string `json:"op"`
+	Predicate string `json:"predicate,omitempty"`
+	Object    string `json:"object,omitempty"`
+	Limit     int    `json:"limit,omitempty"`
+}
+
+// validate 校验遍历结构
+func (t *GraphTraversal) validate() error {
+	if len(t.Start) == 0 {
+		return fmt.Errorf("traversal must start with at least one node")
+	}
+	if len(t.Steps) > maxTraversalSteps {
+		return fmt.Errorf("traversal has too many steps: %d (max %d)", len(t.Steps), maxTraversalSteps)
+	}
+	for i, step := range t.Steps {
+		switch step.Op {
+		case "out", "in", "both":
+		case "has":
+			if step.Predicate == "" {
+				return fmt.Errorf("step %d: has requires a predicate", i)
+			}
+		case "limit":
+			if step.Limit <= 0 {
+				return fmt.Errorf("step %d: limit must be positive", i)
+			}
+		default:
+			return fmt.Errorf("step %d: unknown op %q (expected out, in, both, has or limit)", i, step.Op)
+		}
+	}
+	return nil
+}
+
+// traversalResult 遍历结果：最后一次沿边移动经过的边，以及最终的节点集合
+type traversalResult struct {
+	Triples []cayley_driver.Triple
+	Nodes   []string
+}
+
+// traversalEdge 沿边移动时经过的一条边及其到达的节点
+type traversalEdge struct {
+	triple cayley_driver.Triple
+	target string
+}
+
+// executeTraversal 逐步执行遍历，每一跳通过 cayley 查询构建器完成
+// 节点集合在每一步之后去重并保持发现顺序；has 和 limit 作用于当前节点集合，
+// 同时过滤掉到达节点已被过滤的边
+func executeTraversal(ctx context.Context, graph cayley_driver.Graph, t *GraphTraversal) (*traversalResult, error) {
+	if err := t.validate(); err != nil {
+		return nil, err
+	}
+
+	nodes := uniqueStrings(t.Start)
+	var edges []traversalEdge
+	for i, step := range t.Steps {
+		switch step.Op {
+		case "out", "in", "both":
+			edges = nil
+			var next []string
+			seen := make(map[string]bool)
+			for _, node := range nodes {
+				hop, err := traverseHop(ctx, graph, node, step)
+				if err != nil {
+					return nil, fmt.Errorf("step %d: %w", i, err)
+				}
+				for _, edge := range hop {
+					edges = append(edges, edge)
+					if !seen[edge.target] {
+						seen[edge.target] = true
+						next = append(next, edge.target)
+					}
+				}
+				if len(next) > maxTraversalFrontier {
+					return nil, fmt.Errorf("step %d: traversal reached more than %d nodes, add filters or a limit", i, maxTraversalFrontier)
+				}
+			}
+			nodes = next
+		case "has":
+			var kept []string
+			for _, node := range nodes {
+				objects, err := graph.Query().V(node).Out(step.Predicate).Values(ctx)
+				if err != nil {
+					return nil, fmt.Errorf("step %d: %w", i, err)
+				}
+				if (step.Object == "" && len(objects) > 0) || containsString(objects, step.Object) {
+					kept = append(kept, node)
+				}
+			}
+			nodes = kept
+			edges = filterEdgesByTarget(edges, nodes)
+		case "limit":
+			if len(nodes) > step.Limit {
+				nodes = nodes[:step.Limit]
+			}
+			edges = filterEdgesByTarget(edges, nodes)
+		}
+	}
+
+	result := &traversalResult{Triples: make([]cayley_driver.Triple, len(edges)), Nodes: nodes}
+	for i, edge := range edges {
+		result.Triples[i] = edge.triple
+	}
+	if result.Nodes == nil {
+		result.Nodes = []string{}
+	}
+	return result, nil
+}
+
+// traverseHop 通过查询构建器从单个节点沿边移动一步
+// both 分别沿出边和入边移动，以便区分每条边到达的节点
+func traverseHop(ctx context.Context, graph cayley_driver.Graph, node string, step GraphTraversalStep) ([]traversalEdge, error) {
+	var edges []traversalEdge
+	if step.Op == "out" || step.Op == "both" {
+		triples, err := graph.Query().V(node).Out(step.Predicate).All(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range triples {
+			edges = append(edges, traversalEdge{triple: t, target: t.Object})
+		}
+	}
+	if step.Op == "in" || step.Op == "both" {
+		triples, err := graph.Query().V(node).In(step.Predicate).All(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range triples {
+			edges = append(edges, traversalEdge{triple: t, target: t.Subject})
+		}
+	}
+	return edges, nil
+}
+
+// filterEdgesByTarget 只保留到达节点仍在节点集合中的边
+func filterEdgesByTarget(edges []traversalEdge, nodes []string) []traversalEdge {
+	keep := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		keep[node] = true
+	}
+	var filtered []traversalEdge
+	for _, edge := range edges {
+		if keep[edge.target] {
+			filtered = append(filtered, edge)
+		}
+	}
+	return filtered
+}
+
+// uniqueStrings 去重并保持原有顺序
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// containsString 判断切片中是否包含指定字符串
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTraversal 解析遍历语言：
+//
+//	[g.]V('a'[, 'b'...]){.Out(['p']) | .In(['p']) | .Both(['p']) | .Has('p'[, 'o']) | .Limit(n)}[.All()]
+//
+// 字符串可以使用单引号或双引号，支持反斜杠转义
+func parseTraversal(input string) (*GraphTraversal, error) {
+	p := &traversalParser{input: input}
+	t, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	return t, t.validate()
+}
+
+// traversalParser 遍历语言的递归下降解析器
+type traversalParser struct {
+	input string
+	pos   int
+}
+
+func (p *traversalParser) parse() (*GraphTraversal, error) {
+	p.skipSpace()
+	if strings.HasPrefix(p.input[p.pos:], "g.") {
+		p.pos += 2
+	}
+
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	if name != "V" {
+		return nil, p.errorf("查询必须以 V('nodeId') 开始")
+	}
+	start, err := p.stringArgs()
+	if err != nil {
+		return nil, err
+	}
+
+	t := &GraphTraversal{Start: start, Steps: []GraphTraversalStep{}}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.input) {
+			return t, nil
+		}
+		if p.input[p.pos] != '.' {
+			return nil, p.errorf("期望 '.'")
+		}
+		p.pos++
+
+		stepPos := p.pos
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case "Out", "In", "Both":
+			args, err := p.stringArgs()
+			if err != nil {
+				return nil, err
+			}
+			if len(args) > 1 {
+				return nil, p.errorAt(stepPos, "%s 最多接受一个关系名称", name)
+			}
+			step := GraphTraversalStep{Op: strings.ToLower(name)}
+			if len(args) == 1 {
+				step.Predicate = args[0]
+			}
+			t.Steps = append(t.Steps, step)
+		case "Has":
+			args, err := p.stringArgs()
+			if err != nil {
+				return nil, err
+			}
+			if len(args) < 1 || len(args) > 2 {
+				return nil, p.errorAt(stepPos, "Has 需要关系名称和可选的目标节点")
+			}
+			step := GraphTraversalStep{Op: "has", Predicate: args[0]}
+			if len(args) == 2 {
+				step.Object = args[1]
+			}
+			t.Steps = append(t.Steps, step)
+		case "Limit":
+			n, err := p.intArg()
+			if err != nil {
+				return nil, err
+			}
+			t.Steps = append(t.Steps, GraphTraversalStep{Op: "limit", Limit: n})
+		case "All":
+			// 兼容 Gizmo 风格的结尾 .All()
+			if args, err := p.stringArgs(); err != nil {
+				return nil, err
+			} else if len(args) > 0 {
+				return nil, p.errorAt(stepPos, "All 不接受参数")
+			}
+			p.skipSpace()
+			if p.pos < len(p.input) {
+				return nil, p.errorf("All() 之后不能再有其他步骤")
+			}
+			return t, nil
+		default:
+			return nil, p.errorAt(stepPos, "未知的步骤 %s（支持 Out、In、Both、Has、Limit）", name)
+		}
+	}
+}
+
+// ident 读取一个标识符
+func (p *traversalParser) ident() (string, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.input) && (unicode.IsLetter(rune(p.input[p.pos])) || p.input[p.pos] == '_') {
+		p.pos++
+	}
+	if start == p.pos {
+		return "", p.errorf("期望步骤名称")
+	}
+	return p.input[start:p.pos], nil
+}
+
+// stringArgs 读取括号中以逗号分隔的字符串参数
+func (p *traversalParser) stringArgs() ([]string, error) {
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	args := []string{}
+	p.skipSpace()
+	if p.peek() == ')' {
+		p.pos++
+		return args, nil
+	}
+	for {
+		s, err := p.quoted()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, s)
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ')':
+			p.pos++
+			return args, nil
+		default:
+			return nil, p.errorf("期望 ',' 或 ')'")
+		}
+	}
+}
+
+// intArg 读取括号中的一个正整数参数
+func (p *traversalParser) intArg() (int, error) {
+	if err := p.expect('('); err != nil {
+		return 0, err
+	}
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.input) && p.input[p.pos] >= '0' && p.input[p.pos] <= '9' {
+		p.pos++
+	}
+	n, err := strconv.Atoi(p.input[start:p.pos])
+	if err != nil {
+		return 0, p.errorAt(start, "期望整数")
+	}
+	if err := p.expect(')'); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// quoted 读取单引号或双引号字符串
+func (p *traversalParser) quoted() (string, error) {
+	p.skipSpace()
+	quote := p.peek()
+	if quote != '\'' && quote != '"' {
+		return "", p.errorf("期望字符串")
+	}
+	start := p.pos
+	p.pos++
+
+	var b strings.Builder
+	for p.pos < len(p.input) {
+		ch := p.input[p.pos]
+		switch {
+		case ch == '\\' && p.pos+1 < len(p.input):
+			b.WriteByte(p.input[p.pos+1])
+			p.pos += 2
+		case ch == quote:
+			p.pos++
+			return b.String(), nil
+		default:
+			b.WriteByte(ch)
+			p.pos++
+		}
+	}
+	return "", p.errorAt(start, "字符串没有结束")
+}
+
+// expect 读取指定字符
+func (p *traversalParser) expect(ch byte) error {
+	p.skipSpace()
+	if p.peek() != ch {
+		return p.errorf("期望 '%c'", ch)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *traversalParser) peek() byte {
+	if p.pos < len(p.input) {
+		return p.input[p.pos]
+	}
+	return 0
+}
+
+func (p *traversalParser) skipSpace() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *traversalParser) errorf(format string, args ...interface{}) error {
+	return p.errorAt(p.pos, format, args...)
+}
+
+// errorAt 生成带位置信息的解析错误
+func (p *traversalParser) errorAt(pos int, format string, args ...interface{}) error {
+	return fmt.Errorf("查询语法错误（位置 %d）：%s", pos, fmt.Sprintf(format, args...))
+}
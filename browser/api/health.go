@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 健康检查状态
+const (
+	HealthStatusOK       = "ok"
+	HealthStatusDegraded = "degraded"
+	HealthStatusDown     = "down"
+	HealthStatusDisabled = "disabled"
+)
+
+const (
+	// healthDBTimeout 数据库检查的超时时间
+	healthDBTimeout = 2 * time.Second
+	// healthEmbedderTimeout 探测 embedding 服务的超时时间
+	healthEmbedderTimeout = 5 * time.Second
+	// healthEmbedderCacheTTL embedding 服务探测结果的缓存时间，避免探针频繁调用计费接口
+	healthEmbedderCacheTTL = 30 * time.Second
+)
+
+// startedAt 服务启动时间
+var startedAt = time.Now()
+
+// HealthCheck 单项检查的结果
+type HealthCheck struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ReadinessResponse /readyz 的响应
+// 只有数据库不可用时返回 503；扩展或 embedding 服务不可用时服务仍能处理大部分请求，status 为 degraded
+type ReadinessResponse struct {
+	Status     string                 `json:"status"`
+	Checks     map[string]HealthCheck `json:"checks"`
+	Extensions map[string]bool        `json:"extensions"`
+	Queues     map[string]int         `json:"queues"`
+}
+
+// embedderProbe 缓存的 embedding 服务探测结果
+var (
+	embedderProbeMu sync.Mutex
+	embedderProbe   HealthCheck
+	embedderProbeAt time.Time
+)
+
+// registerHealthRoutes 注册 /healthz 和 /readyz，挂在根路径下、不经过鉴权和请求限制，供容器编排探针使用
+func registerHealthRoutes(r *gin.Engine) {
+	r.GET("/healthz", healthz)
+	r.GET("/readyz", readyz)
+}
+
+// healthz 存活检查，进程能处理请求即返回 200
+func healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":         HealthStatusOK,
+		"uptime_seconds": int64(time.Since(startedAt).Seconds()),
+	})
+}
+
+// readyz 就绪检查：数据库连通性、fts/vss 扩展加载状态、embedding 服务可达性和待处理的任务数
+func readyz(c *gin.Context) {
+	ctx := c.Request.Context()
+	resp := ReadinessResponse{
+		Status: HealthStatusOK,
+		Checks: make(map[string]HealthCheck),
+		Queues: make(map[string]int),
+	}
+
+	resp.Checks["database"] = checkDatabase(ctx)
+	if resp.Checks["database"].Status != HealthStatusOK {
+		resp.Status = HealthStatusDown
+		c.JSON(http.StatusServiceUnavailable, resp)
+		return
+	}
+
+	if graphDB != nil {
+		resp.Checks["graph"] = HealthCheck{Status: HealthStatusOK}
+	} else {
+		resp.Checks["graph"] = HealthCheck{Status: HealthStatusDown, Error: "graph database not initialized"}
+	}
+
+	extensions, err := loadedExtensions(ctx)
+	if err != nil {
+		resp.Checks["extensions"] = HealthCheck{Status: HealthStatusDown, Error: err.Error()}
+	} else {
+		resp.Extensions = extensions
+		check := HealthCheck{Status: HealthStatusOK}
+		for _, name := range []string{"fts", "vss"} {
+			if !extensions[name] {
+				check = HealthCheck{Status: HealthStatusDown, Error: fmt.Sprintf("extension %s not loaded", name)}
+				break
+			}
+		}
+		resp.Checks["extensions"] = check
+	}
+
+	resp.Checks["embedder"] = checkEmbedder(ctx)
+
+	if err := loadQueueSizes(ctx, resp.Queues); err != nil {
+		resp.Checks["queues"] = HealthCheck{Status: HealthStatusDown, Error: err.Error()}
+	}
+
+	for _, check := range resp.Checks {
+		if check.Status == HealthStatusDown {
+			resp.Status = HealthStatusDegraded
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// checkDatabase 检查 DuckDB 连接是否可用
+func checkDatabase(ctx context.Context) HealthCheck {
+	if sqlDB == nil {
+		return HealthCheck{Status: HealthStatusDown, Error: "database not initialized"}
+	}
+	ctx, cancel := context.WithTimeout(ctx, healthDBTimeout)
+	defer cancel()
+
+	start := time.Now()
+	var one int
+	if err := sqlDB.QueryRowContext(ctx, `SELECT 1`).Scan(&one); err != nil {
+		return HealthCheck{Status: HealthStatusDown, Error: err.Error()}
+	}
+	return HealthCheck{Status: HealthStatusOK, LatencyMs: time.Since(start).Milliseconds()}
+}
+
+// loadedExtensions 返回 fts 和 vss 扩展是否已加载
+func loadedExtensions(ctx context.Context) (map[string]bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, healthDBTimeout)
+	defer cancel()
+
+	extensions := map[string]bool{"fts": false, "vss": false}
+	rows, err := sqlDB.QueryContext(ctx, `SELECT extension_name, loaded FROM duckdb_extensions() WHERE extension_name IN ('fts', 'vss')`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query extensions: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		var loaded bool
+		if err := rows.Scan(&name, &loaded); err != nil {
+			return nil, fmt.Errorf("failed to scan extension: %w", err)
+		}
+		extensions[name] = loaded
+	}
+	return extensions, rows.Err()
+}
+
+// checkEmbedder 向 embedding 服务发送一次请求，结果缓存 healthEmbedderCacheTTL
+// 未配置 embedding 服务时返回 disabled
+func checkEmbedder(ctx context.Context) HealthCheck {
+	embedderProbeMu.Lock()
+	defer embedderProbeMu.Unlock()
+	if !embedderProbeAt.IsZero() && time.Since(embedderProbeAt) < healthEmbedderCacheTTL {
+		return embedderProbe
+	}
+
+	e, err := currentEmbedder()
+	if err != nil {
+		embedderProbe = HealthCheck{Status: HealthStatusDisabled, Error: err.Error()}
+	} else {
+		ctx, cancel := context.WithTimeout(ctx, healthEmbedderTimeout)
+		defer cancel()
+		start := time.Now()
+		if _, err := e.Embed(ctx, []string{"ping"}); err != nil {
+			embedderProbe = HealthCheck{Status: HealthStatusDown, Error: err.Error()}
+		} else {
+			embedderProbe = HealthCheck{Status: HealthStatusOK, LatencyMs: time.Since(start).Milliseconds()}
+		}
+	}
+	embedderProbeAt = time.Now()
+	return embedderProbe
+}
+
+// resetEmbedderProbe 清除缓存的探测结果（更换 Embedder 后调用）
+func resetEmbedderProbe() {
+	embedderProbeMu.Lock()
+	defer embedderProbeMu.Unlock()
+	embedderProbeAt = time.Time{}
+}
+
+// loadQueueSizes 统计待处理的工作：按类型统计运行中的后台任务，以及尚未进入全文索引的文档数
+func loadQueueSizes(ctx context.Context, queues map[string]int) error {
+	jobsMu.Lock()
+	for _, job := range jobs {
+		if job.Status == JobStatusRunning {
+			queues["jobs_"+job.Type]++
+		}
+	}
+	jobsMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, healthDBTimeout)
+	defer cancel()
+
+	var exists int
+	if err := sqlDB.QueryRowContext(ctx, `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = ? AND table_name = 'docs'`, ftsIndexSchema).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check FTS index: %w", err)
+	}
+	if exists == 0 {
+		return nil
+	}
+	var unindexed int
+	query := fmt.Sprintf(`SELECT COUNT(*) FROM documents d LEFT JOIN %s.docs f ON f.name = d.id WHERE f.name IS NULL AND %s`,
+		ftsIndexSchema, liveDocumentsSQL("d."))
+	if err := sqlDB.QueryRowContext(ctx, query).Scan(&unindexed); err != nil {
+		return fmt.Errorf("failed to count unindexed documents: %w", err)
+	}
+	queues["fts_unindexed"] = unindexed
+	return nil
+}
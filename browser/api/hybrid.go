@@ -0,0 +1,399 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// FusionRRF 倒数排名融合：score = Σ weight / (k + rank)
+	FusionRRF = "rrf"
+	// FusionWeighted 加权融合：各路分数归一化到 [0, 1] 后按权重加权平均
+	FusionWeighted = "weighted"
+
+	// defaultRRFK RRF 的平滑常数
+	defaultRRFK = 60
+	// hybridMinCandidates 每一路检索的候选数量下限
+	hybridMinCandidates = 50
+)
+
+// rankedDoc 单路检索的一条结果，rank 从 1 开始
+type rankedDoc struct {
+	id    string
+	data  map[string]interface{}
+	score float64
+	rank  int
+}
+
+// hybridHit 融合后的一条结果
+type hybridHit struct {
+	id      string
+	data    map[string]interface{}
+	score   float64
+	keyword *rankedDoc
+	vector  *rankedDoc
+}
+
+// hybridSearch 混合检索：分别执行关键词检索和向量检索，再用 RRF 或加权方式融合排序
+func hybridSearch(c *gin.Context) {
+	name := c.Param("name")
+
+	var req HybridSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Query) == "" && len(req.Vector) == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "Either 'query' (text) or 'vector' must be provided"})
+		return
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = 10
+	}
+	if req.Fusion == "" {
+		req.Fusion = FusionRRF
+	}
+	if req.Fusion != FusionRRF && req.Fusion != FusionWeighted {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid fusion: %s (expected rrf or weighted)", req.Fusion)})
+		return
+	}
+	if req.RRFK <= 0 {
+		req.RRFK = defaultRRFK
+	}
+	if req.KeywordWeight == nil {
+		w := 1.0
+		req.KeywordWeight = &w
+	}
+	if req.VectorWeight == nil {
+		w := 1.0
+		req.VectorWeight = &w
+	}
+	if *req.KeywordWeight < 0 || *req.VectorWeight < 0 || *req.KeywordWeight+*req.VectorWeight == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "keyword_weight and vector_weight must be non-negative and not both zero"})
+		return
+	}
+	if req.Candidates <= 0 {
+		req.Candidates = req.Limit * 5
+		if req.Candidates < hybridMinCandidates {
+			req.Candidates = hybridMinCandidates
+		}
+	}
+
+	where, whereArgs, err := compileSelector(req.Filter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid filter: %v", err)})
+		return
+	}
+	where = liveDocumentsSQL("") + ` AND ` + where
+
+	start := time.Now()
+	var warnings []string
+	var modes []string
+
+	var keywordDocs []rankedDoc
+	if strings.TrimSpace(req.Query) != "" && *req.KeywordWeight > 0 {
+		keywordDocs, err = keywordCandidates(c.Request.Context(), name, req.Query, where, whereArgs, req.Candidates)
+		if err != nil {
+			logrus.WithError(err).Error("❌ Keyword search failed")
+			c.JSON(queryErrorStatus(c), ErrorResponse{Error: fmt.Sprintf("keyword search failed: %v", err)})
+			return
+		}
+		modes = append(modes, "keyword")
+	}
+
+	var vectorDocs []rankedDoc
+	if *req.VectorWeight > 0 {
+		queryVector := req.Vector
+		if len(queryVector) == 0 {
+			// 没有提供向量时用 query 生成 embedding，失败则退化为纯关键词检索
+			queryVector, err = generateEmbeddingFromText(req.Query)
+			if err != nil {
+				logrus.WithError(err).Warn("Failed to generate embedding, falling back to keyword search only")
+				warnings = append(warnings, fmt.Sprintf("vector search skipped: %v", err))
+			}
+		}
+		if len(queryVector) > 0 {
+			vectorDocs, err = vectorRankCandidates(c.Request.Context(), name, queryVector, where, whereArgs, req.Candidates)
+			if err != nil {
+				if _, ok := err.(vectorDimensionError); ok {
+					c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+					return
+				}
+				logrus.WithError(err).Warn("Vector search failed, falling back to keyword search only")
+				warnings = append(warnings, fmt.Sprintf("vector search skipped: %v", err))
+			} else {
+				modes = append(modes, "vector")
+			}
+		}
+	}
+
+	if len(modes) == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "no search mode available: " + strings.Join(warnings, "; ")})
+		return
+	}
+
+	var hits []*hybridHit
+	if req.Fusion == FusionWeighted {
+		hits = fuseWeighted(keywordDocs, vectorDocs, *req.KeywordWeight, *req.VectorWeight)
+	} else {
+		hits = fuseRRF(keywordDocs, vectorDocs, *req.KeywordWeight, *req.VectorWeight, req.RRFK)
+	}
+	if len(hits) > req.Limit {
+		hits = hits[:req.Limit]
+	}
+
+	results := make([]gin.H, 0, len(hits))
+	for _, hit := range hits {
+		result := gin.H{
+			"document": DocumentResponse{
+				ID:   hit.id,
+				Data: hit.data,
+			},
+			"score": hit.score,
+		}
+		if hit.keyword != nil {
+			result["keyword_score"] = hit.keyword.score
+			result["keyword_rank"] = hit.keyword.rank
+		}
+		if hit.vector != nil {
+			result["vector_score"] = hit.vector.score
+			result["vector_rank"] = hit.vector.rank
+		}
+		results = append(results, result)
+	}
+
+	response := gin.H{
+		"results": results,
+		"query":   req.Query,
+		"fusion":  req.Fusion,
+		"modes":   modes,
+		"took":    time.Since(start).Milliseconds(),
+	}
+	if len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// keywordCandidates 关键词检索：优先使用 DuckDB FTS 索引的 BM25 分数，
+// 索引不可用时退化为按命中的查询词比例打分
+func keywordCandidates(ctx context.Context, name, query, where string, whereArgs []interface{}, limit int) ([]rankedDoc, error) {
+	tokens := tokenizeWithSego(query)
+	if tokens == "" {
+		tokens = query
+	}
+
+	bm25Query := `
+		SELECT id, data, score FROM (
+			SELECT id, data, fts_main_documents.match_bm25(id, ?) AS score
+			FROM documents
+			WHERE collection_name = ? AND ` + where + `
+		)
+		WHERE score IS NOT NULL
+		ORDER BY score DESC, id
+		LIMIT ?`
+	args := append([]interface{}{tokens, name}, whereArgs...)
+	docs, err := queryRankedDocs(ctx, bm25Query, append(args, limit)...)
+	if err == nil {
+		return docs, nil
+	}
+	logrus.WithError(err).Debug("BM25 unavailable, using token match scoring")
+
+	column := "data"
+	if ok, _ := columnExists(sqlDB, "documents", "content_tokens"); ok {
+		column = "COALESCE(content_tokens, content, data)"
+	} else if ok, _ := columnExists(sqlDB, "documents", "content"); ok {
+		column = "COALESCE(content, data)"
+	}
+
+	terms := uniqueTerms(tokens)
+	parts := make([]string, len(terms))
+	args = make([]interface{}, 0, len(terms)+len(whereArgs)+2)
+	for i, term := range terms {
+		parts[i] = fmt.Sprintf("CASE WHEN contains(lower(%s), ?) THEN 1 ELSE 0 END", column)
+		args = append(args, term)
+	}
+	args = append(args, name)
+	args = append(args, whereArgs...)
+	args = append(args, limit)
+
+	matchQuery := fmt.Sprintf(`
+		SELECT id, data, score FROM (
+			SELECT id, data, CAST((%s) AS DOUBLE) / %d AS score
+			FROM documents
+			WHERE collection_name = ? AND %s
+		)
+		WHERE score > 0
+		ORDER BY score DESC, id
+		LIMIT ?`, strings.Join(parts, " + "), len(terms), where)
+	return queryRankedDocs(ctx, matchQuery, args...)
+}
+
+// vectorDimensionError 查询向量维度与 embedding 列不一致
+type vectorDimensionError struct{ error }
+
+// vectorRankCandidates 向量检索，分数为余弦相似度
+func vectorRankCandidates(ctx context.Context, name string, queryVector []float64, where string, whereArgs []interface{}, limit int) ([]rankedDoc, error) {
+	hasEmbedding, err := columnExists(sqlDB, "documents", "embedding")
+	if err != nil {
+		return nil, err
+	}
+	if !hasEmbedding {
+		return nil, fmt.Errorf("embedding column does not exist")
+	}
+	colType, err := getColumnType(sqlDB, "documents", "embedding")
+	if err != nil {
+		return nil, err
+	}
+	distanceExpr, err := vectorDistanceExpr(colType, len(queryVector))
+	if err != nil {
+		return nil, vectorDimensionError{err}
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, data, 1 - distance AS score FROM (
+			SELECT id, data, %s AS distance
+			FROM documents
+			WHERE collection_name = ? AND embedding IS NOT NULL AND %s
+		)
+		WHERE distance IS NOT NULL
+		ORDER BY distance ASC, id
+		LIMIT ?`, distanceExpr, where)
+	args := append([]interface{}{formatVectorLiteral(queryVector), name}, whereArgs...)
+	return queryRankedDocs(ctx, query, append(args, limit)...)
+}
+
+// queryRankedDocs 执行返回 id, data, score 的查询，按返回顺序编号 rank
+func queryRankedDocs(ctx context.Context, query string, args ...interface{}) ([]rankedDoc, error) {
+	rows, err := sqlDB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var docs []rankedDoc
+	for rows.Next() {
+		var id, dataJSON string
+		var score sql.NullFloat64
+		if err := rows.Scan(&id, &dataJSON, &score); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+			logrus.WithError(err).WithField("id", id).Warn("Failed to unmarshal document data")
+			continue
+		}
+		docs = append(docs, rankedDoc{id: id, data: data, score: score.Float64, rank: len(docs) + 1})
+	}
+	return docs, rows.Err()
+}
+
+// fuseRRF 倒数排名融合，只依赖名次，不受两路分数量纲不同的影响
+func fuseRRF(keywordDocs, vectorDocs []rankedDoc, keywordWeight, vectorWeight float64, k int) []*hybridHit {
+	hits := make(map[string]*hybridHit)
+	add := func(docs []rankedDoc, weight float64, isVector bool) {
+		for i := range docs {
+			doc := &docs[i]
+			hit := hits[doc.id]
+			if hit == nil {
+				hit = &hybridHit{id: doc.id, data: doc.data}
+				hits[doc.id] = hit
+			}
+			hit.score += weight / float64(k+doc.rank)
+			if isVector {
+				hit.vector = doc
+			} else {
+				hit.keyword = doc
+			}
+		}
+	}
+	add(keywordDocs, keywordWeight, false)
+	add(vectorDocs, vectorWeight, true)
+	return sortHits(hits)
+}
+
+// fuseWeighted 加权融合：每一路分数按 min-max 归一化到 [0, 1]，缺失的一路记 0
+func fuseWeighted(keywordDocs, vectorDocs []rankedDoc, keywordWeight, vectorWeight float64) []*hybridHit {
+	hits := make(map[string]*hybridHit)
+	total := keywordWeight + vectorWeight
+	add := func(docs []rankedDoc, weight float64, isVector bool) {
+		normalized := normalizeScores(docs)
+		for i := range docs {
+			doc := &docs[i]
+			hit := hits[doc.id]
+			if hit == nil {
+				hit = &hybridHit{id: doc.id, data: doc.data}
+				hits[doc.id] = hit
+			}
+			hit.score += weight / total * normalized[i]
+			if isVector {
+				hit.vector = doc
+			} else {
+				hit.keyword = doc
+			}
+		}
+	}
+	add(keywordDocs, keywordWeight, false)
+	add(vectorDocs, vectorWeight, true)
+	return sortHits(hits)
+}
+
+// normalizeScores min-max 归一化；所有分数相同时都记为 1
+func normalizeScores(docs []rankedDoc) []float64 {
+	normalized := make([]float64, len(docs))
+	if len(docs) == 0 {
+		return normalized
+	}
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, doc := range docs {
+		lo = math.Min(lo, doc.score)
+		hi = math.Max(hi, doc.score)
+	}
+	for i, doc := range docs {
+		if hi == lo {
+			normalized[i] = 1
+		} else {
+			normalized[i] = (doc.score - lo) / (hi - lo)
+		}
+	}
+	return normalized
+}
+
+// sortHits 按融合分数降序排列，分数相同时按 ID 排序保证结果稳定
+func sortHits(hits map[string]*hybridHit) []*hybridHit {
+	result := make([]*hybridHit, 0, len(hits))
+	for _, hit := range hits {
+		result = append(result, hit)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].score != result[j].score {
+			return result[i].score > result[j].score
+		}
+		return result[i].id < result[j].id
+	})
+	return result
+}
+
+// uniqueTerms 将分词结果去重并转为小写
+func uniqueTerms(tokens string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, term := range strings.Fields(strings.ToLower(tokens)) {
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// 后台任务状态
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+
+	// defaultReembedBatchSize 每次向量化请求包含的文档数（DashScope 单次最多 10 条）
+	defaultReembedBatchSize = 10
+)
+
+// Job 后台任务的状态快照
+type Job struct {
+	ID         string     `json:"id"`
+	Type       string     `json:"type"`
+	Collection string     `json:"collection"`
+	Status     string     `json:"status"`
+	Stage      string     `json:"stage,omitempty"` // 当前阶段，如备份任务的 exporting documents
+	File       string     `json:"file,omitempty"`  // 备份或恢复任务使用的归档文件
+	Processed  int        `json:"processed"`       // 已成功处理的文档数（备份和恢复任务为行数和边数之和）
+	Skipped    int        `json:"skipped"`         // 没有可向量化文本而跳过的文档数
+	Failed     int        `json:"failed"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+var (
+	jobsMu sync.Mutex
+	jobs   = make(map[string]*Job)
+
+	// jobWorkers 运行中的后台任务协程，关闭服务时等待它们结束
+	jobWorkers sync.WaitGroup
+	// jobsCtx 关闭服务时取消，reembed 任务在批次之间检查并提前结束
+	jobsCtx, stopJobs = context.WithCancel(context.Background())
+)
+
+// goJob 在登记到 jobWorkers 的协程中运行后台任务
+func goJob(fn func()) {
+	jobWorkers.Add(1)
+	go func() {
+		defer jobWorkers.Done()
+		fn()
+	}()
+}
+
+// waitJobs 等待后台任务结束，ctx 到期时返回错误
+func waitJobs(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		jobWorkers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("background jobs still running: %w", ctx.Err())
+	}
+}
+
+// startJob 登记一个后台任务，同一集合同一类型的任务同时只能运行一个
+func startJob(jobType, collection string) (*Job, error) {
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+
+	for _, job := range jobs {
+		if job.Type == jobType && job.Collection == collection && job.Status == JobStatusRunning {
+			return nil, fmt.Errorf("a %s job is already running for collection %s: %s", jobType, collection, job.ID)
+		}
+	}
+
+	job := &Job{
+		ID:         generateID(),
+		Type:       jobType,
+		Collection: collection,
+		Status:     JobStatusRunning,
+		StartedAt:  time.Now(),
+	}
+	jobs[job.ID] = job
+	return job, nil
+}
+
+// updateJob 在锁内修改任务状态
+func updateJob(job *Job, fn func(*Job)) {
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+	fn(job)
+}
+
+// finishJob 标记任务结束
+func finishJob(job *Job, err error) {
+	updateJob(job, func(j *Job) {
+		now := time.Now()
+		j.FinishedAt = &now
+		if err != nil {
+			j.Status = JobStatusFailed
+			j.Error = err.Error()
+		} else {
+			j.Status = JobStatusCompleted
+		}
+	})
+}
+
+// snapshotJob 复制任务状态，避免序列化时与后台协程竞争
+func snapshotJob(job *Job) Job {
+	jobsMu.Lock()
+	defer jobsMu.Unlock()
+	return *job
+}
+
+// getJob 查询后台任务状态
+func getJob(c *gin.Context) {
+	jobsMu.Lock()
+	job, ok := jobs[c.Param("id")]
+	jobsMu.Unlock()
+	if !ok {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Job not found"})
+		return
+	}
+	// 集合任务受集合 ACL 限制，备份、恢复等全局任务需要可以访问所有集合
+	snapshot := snapshotJob(job)
+	if (snapshot.Collection != "" && !collectionAllowed(c, snapshot.Collection)) ||
+		(snapshot.Collection == "" && !allCollectionsAllowed(c)) {
+		c.JSON(http.StatusForbidden, ErrorResponse{Error: "API key is not allowed to access this job"})
+		return
+	}
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// reembedCollection 启动后台任务，按集合的 embed 字段重新生成 embedding
+// only_missing（默认 true）时只处理还没有 embedding 的文档
+func reembedCollection(c *gin.Context) {
+	name := c.Param("name")
+
+	var req ReembedRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+	onlyMissing := req.OnlyMissing == nil || *req.OnlyMissing
+	batchSize := req.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultReembedBatchSize
+	}
+
+	fields := collectionEmbedFields(name)
+	if len(fields) == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("collection %s has no embed_fields configured", name)})
+		return
+	}
+	hasEmbedding, err := columnExists(sqlDB, "documents", "embedding")
+	if err != nil || !hasEmbedding {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "embedding column does not exist"})
+		return
+	}
+	e, err := currentEmbedder()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	job, err := startJob("reembed", name)
+	if err != nil {
+		c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"job":          job.ID,
+		"collection":   name,
+		"only_missing": onlyMissing,
+	}).Info("🔄 Re-embed job started")
+
+	goJob(func() {
+		err := runReembed(job, e, name, fields, onlyMissing, batchSize)
+		finishJob(job, err)
+		final := snapshotJob(job)
+		entry := logrus.WithFields(logrus.Fields{
+			"job":       final.ID,
+			"processed": final.Processed,
+			"skipped":   final.Skipped,
+			"failed":    final.Failed,
+		})
+		if err != nil {
+			entry.WithError(err).Error("❌ Re-embed job failed")
+		} else {
+			entry.Info("✅ Re-embed job finished")
+		}
+	})
+
+	c.JSON(http.StatusAccepted, snapshotJob(job))
+}
+
+// runReembed 按 id 顺序分批读取文档并写回 embedding
+// 单批向量化失败只计入 failed 并继续，数据库错误会中止任务
+func runReembed(job *Job, e Embedder, name string, fields []string, onlyMissing bool, batchSize int) error {
+	query := `SELECT id, data FROM documents WHERE collection_name = ? AND id > ? AND ` + liveDocumentsSQL("")
+	if onlyMissing {
+		query += ` AND embedding IS NULL`
+	}
+	query += ` ORDER BY id LIMIT ?`
+
+	lastID := ""
+	for {
+		// 服务关闭时中断，only_missing 的任务可以在重启后重新提交继续处理
+		if err := jobsCtx.Err(); err != nil {
+			return fmt.Errorf("interrupted by shutdown: %w", err)
+		}
+		items, err := loadReembedBatch(query, name, lastID, fields, batchSize)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		lastID = items[len(items)-1].id
+
+		var ids, texts []string
+		skipped := 0
+		for _, item := range items {
+			if item.text == "" {
+				skipped++
+				continue
+			}
+			ids = append(ids, item.id)
+			texts = append(texts, item.text)
+		}
+		updateJob(job, func(j *Job) { j.Skipped += skipped })
+
+		if len(texts) > 0 {
+			ctx, cancel := context.WithTimeout(jobsCtx, embedRequestTimeout)
+			vectors, err := e.Embed(ctx, texts)
+			cancel()
+			if err != nil {
+				logrus.WithError(err).WithField("job", job.ID).Warn("Failed to embed batch")
+				updateJob(job, func(j *Job) {
+					j.Failed += len(texts)
+					j.Error = err.Error()
+				})
+			} else {
+				for i, id := range ids {
+					if _, err := sqlDB.Exec(`UPDATE documents SET embedding = ?, updated_at = CURRENT_TIMESTAMP WHERE collection_name = ? AND id = ?`,
+						formatVectorLiteral(vectors[i]), name, id); err != nil {
+						return fmt.Errorf("failed to update embedding of %s: %w", id, err)
+					}
+				}
+				updateJob(job, func(j *Job) { j.Processed += len(ids) })
+				searchCache.invalidate(name)
+			}
+		}
+
+		if len(items) < batchSize {
+			return nil
+		}
+	}
+}
+
+// reembedItem 待向量化的文档，text 为空表示没有可向量化的内容
+type reembedItem struct {
+	id   string
+	text string
+}
+
+// loadReembedBatch 读取 afterID 之后的一批文档并拼接向量化文本
+func loadReembedBatch(query, name, afterID string, fields []string, batchSize int) ([]reembedItem, error) {
+	rows, err := sqlDB.Query(query, name, afterID, batchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []reembedItem
+	for rows.Next() {
+		var id, dataJSON string
+		if err := rows.Scan(&id, &dataJSON); err != nil {
+			return nil, err
+		}
+		item := reembedItem{id: id}
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(dataJSON), &data); err == nil {
+			item.text = strings.TrimSpace(embeddingTextFromData(data, fields))
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
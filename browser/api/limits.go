@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/config"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultMaxBodySize 默认的请求体大小上限
+	defaultMaxBodySize = 32 << 20
+	// defaultQueryTimeout 默认的查询超时
+	defaultQueryTimeout = 30 * time.Second
+)
+
+// streamingRoutes 自行处理大请求体的流式路由，不受请求体大小上限限制
+// 键为 "METHOD 路由模板"（gin 的 FullPath）
+var streamingRoutes = map[string]bool{
+	"POST /api/collections/:name/:action":                              true, // documents:batch 批量导入
+	"PUT /api/collections/:name/documents/:id/attachments/:attachment": true, // 附件有单独的大小上限
+	"POST /api/admin/restore":                                          true,
+}
+
+// RequestLimits 请求限制配置，零值表示不限制
+type RequestLimits struct {
+	RateLimit    float64       // 每个客户端每秒允许的请求数
+	RateBurst    int           // 允许的突发请求数
+	MaxBodySize  int64         // 请求体大小上限（字节）
+	QueryTimeout time.Duration // 查询和搜索的超时
+}
+
+// requestLimits 当前的请求限制（测试中可替换）
+var requestLimits RequestLimits
+
+// requestLimitsFromConfig 转换配置中的 rate_limit：
+// RATE_LIMIT（每秒请求数，默认不限流）、RATE_LIMIT_BURST（默认为 RATE_LIMIT 向上取整）、
+// MAX_BODY_SIZE（字节，默认 32MB，0 表示不限制）、QUERY_TIMEOUT（如 30s，默认 30 秒，0 表示不限制）
+func requestLimitsFromConfig(cfg *config.Config) RequestLimits {
+	return RequestLimits{
+		RateLimit:    cfg.RateLimit.RequestsPerSecond,
+		RateBurst:    cfg.RateLimit.Burst,
+		MaxBodySize:  cfg.RateLimit.MaxBodySize,
+		QueryTimeout: time.Duration(cfg.RateLimit.QueryTimeout),
+	}
+}
+
+// loadRequestLimits 从配置文件和环境变量读取请求限制
+func loadRequestLimits() (RequestLimits, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return RequestLimits{}, err
+	}
+	return requestLimitsFromConfig(cfg), nil
+}
+
+// initRequestLimits 加载请求限制配置
+func initRequestLimits(cfg *config.Config) {
+	limits := requestLimitsFromConfig(cfg)
+	requestLimits = limits
+	logrus.WithFields(logrus.Fields{
+		"rate_limit":    limits.RateLimit,
+		"rate_burst":    limits.RateBurst,
+		"max_body_size": limits.MaxBodySize,
+		"query_timeout": limits.QueryTimeout,
+	}).Info("🚦 Request limits configured")
+}
+
+// limitsMiddleware 按客户端限流，限制请求体大小，并为请求上下文设置查询超时
+// 客户端按 API Key 区分，未认证的请求按 IP 区分；需要放在 authMiddleware 之后
+func limitsMiddleware() gin.HandlerFunc {
+	limits := requestLimits
+	var limiter *rateLimiter
+	if limits.RateLimit > 0 {
+		limiter = newRateLimiter(limits.RateLimit, limits.RateBurst)
+	}
+
+	return func(c *gin.Context) {
+		if c.Request.Method == http.MethodOptions {
+			c.Next()
+			return
+		}
+
+		if limiter != nil {
+			if ok, retryAfter := limiter.allow(clientKey(c), time.Now()); !ok {
+				c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, ErrorResponse{Error: "rate limit exceeded"})
+				return
+			}
+		}
+
+		if limits.MaxBodySize > 0 && c.Request.Body != nil && !streamingRoutes[c.Request.Method+" "+c.FullPath()] {
+			if c.Request.ContentLength > limits.MaxBodySize {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, ErrorResponse{Error: fmt.Sprintf("request body exceeds %d bytes", limits.MaxBodySize)})
+				return
+			}
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limits.MaxBodySize)
+		}
+
+		if limits.QueryTimeout > 0 {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), limits.QueryTimeout)
+			defer cancel()
+			c.Request = c.Request.WithContext(ctx)
+		}
+
+		c.Next()
+	}
+}
+
+// clientKey 返回限流使用的客户端标识
+func clientKey(c *gin.Context) string {
+	if value, ok := c.Get(authKeyContextKey); ok {
+		return "key:" + value.(*APIKey).Key
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// queryErrorStatus 返回查询失败时的状态码：超过 QUERY_TIMEOUT 时为 504，否则为 500
+func queryErrorStatus(c *gin.Context) int {
+	if c.Request.Context().Err() == context.DeadlineExceeded {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+// rateLimiter 按客户端的令牌桶限流器
+type rateLimiter struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     float64
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// tokenBucket 一个客户端的令牌桶
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{rate: rate, burst: float64(burst), buckets: make(map[string]*tokenBucket)}
+}
+
+// allow 尝试消耗一个令牌，失败时返回需要等待的时间
+func (l *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// 定期清理已经回满的桶，避免大量客户端占用内存
+	if now.Sub(l.lastSweep) > time.Minute {
+		for k, b := range l.buckets {
+			if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
@@ -1,7 +1,13 @@
 package main
 
 import (
+	"context"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -11,7 +17,17 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// shutdownTimeout 关闭服务时等待处理中的请求和后台任务的最长时间
+const shutdownTimeout = 30 * time.Second
+
 func main() {
+	// 加载配置（CONFIG_FILE 指向的配置文件，环境变量优先）
+	cfg, err := loadConfig()
+	if err != nil {
+		logrus.WithError(err).Fatal("Failed to load config")
+	}
+	serverConfig = cfg
+
 	// 预加载 sego 词典
 	if err := sego.Init(); err != nil {
 		logrus.WithError(err).Warn("Failed to initialize sego dictionary")
@@ -21,57 +37,156 @@ func main() {
 	if err := initDatabase(); err != nil {
 		logrus.WithError(err).Fatal("Failed to initialize database")
 	}
-	defer sqlDB.Close()
-	if graphDB != nil {
-		defer graphDB.Close()
+	// 加载 API Key
+	if err := initAuth(); err != nil {
+		logrus.WithError(err).Fatal("Failed to load API keys")
+	}
+
+	// 审计日志（AUDIT_LOG=true 时开启）
+	if err := initAudit(); err != nil {
+		logrus.WithError(err).Fatal("Failed to initialize audit log")
+	}
+
+	// 搜索结果缓存（QUERY_CACHE_SIZE 大于 0 时开启）
+	if err := initSearchCache(); err != nil {
+		logrus.WithError(err).Fatal("Failed to initialize search cache")
 	}
 
+	// 加载请求限制
+	initRequestLimits(cfg)
+
 	// 设置 Gin 路由
-	r := gin.Default()
+	r := gin.New()
+	r.Use(gin.Recovery(), requestLogMiddleware())
 
 	// 配置 CORS
 	config := cors.DefaultConfig()
-	config.AllowAllOrigins = true
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+	if origins := cfg.CORS.AllowedOrigins; len(origins) > 0 {
+		config.AllowOrigins = origins
+	} else {
+		config.AllowAllOrigins = true
+	}
+	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "X-API-Key"}
 	r.Use(cors.New(config))
 
+	// 健康检查（不需要鉴权）
+	registerHealthRoutes(r)
+
 	// API 路由
 	api := r.Group("/api")
+	api.Use(authMiddleware(), limitsMiddleware(), auditMiddleware(), searchCacheMiddleware())
 	{
+		// API 文档
+		api.GET("/openapi.json", getOpenAPISpec)
+
 		// 数据库信息
 		api.GET("/db/info", getDBInfo)
 		api.GET("/db/collections", getCollections)
 
 		// 集合操作
+		api.GET("/collections", getCollections)
+		api.POST("/collections", createCollection)
 		api.GET("/collections/:name", getCollection)
+		api.PATCH("/collections/:name", updateCollection)
+		api.DELETE("/collections/:name", deleteCollection)
+		api.GET("/collections/:name/stats", getCollectionStats)
 		api.GET("/collections/:name/documents", getDocuments)
 		api.GET("/collections/:name/documents/:id", getDocument)
 		api.POST("/collections/:name/documents", createDocument)
 		api.PUT("/collections/:name/documents/:id", updateDocument)
 		api.DELETE("/collections/:name/documents/:id", deleteDocument)
 
+		// 文档附件
+		api.GET("/collections/:name/documents/:id/attachments", listAttachments)
+		api.PUT("/collections/:name/documents/:id/attachments/:attachment", uploadAttachment)
+		api.GET("/collections/:name/documents/:id/attachments/:attachment", downloadAttachment)
+		api.DELETE("/collections/:name/documents/:id/attachments/:attachment", deleteAttachment)
+
+		// 回收站
+		api.GET("/collections/:name/trash", getTrash)
+		api.DELETE("/collections/:name/trash", purgeTrash)
+		api.POST("/collections/:name/trash/:id/restore", restoreTrashedDocument)
+		api.DELETE("/collections/:name/trash/:id", purgeTrash)
+
+		// 条件查询
+		api.POST("/collections/:name/query", queryDocuments)
+
+		// 服务端 embedding 回填
+		api.POST("/collections/:name/reembed", reembedCollection)
+		api.GET("/jobs/:id", getJob)
+
+		// 批量导入导出
+		api.POST("/collections/:name/:action", collectionAction) // documents:batch
+		api.GET("/collections/:name/export", exportDocuments)
+
 		// 全文搜索
 		api.POST("/collections/:name/fulltext/search", fulltextSearch)
+		api.GET("/analyze", analyzeText)
 
 		// 向量搜索
 		api.POST("/collections/:name/vector/search", vectorSearch)
+		api.POST("/collections/:name/vector/msearch", vectorMultiSearch)
+
+		// 混合检索
+		api.POST("/collections/:name/search", hybridSearch)
 
 		// 图数据库操作
 		api.POST("/graph/link", graphLink)
 		api.DELETE("/graph/link", graphUnlink)
+		api.POST("/graph/links/bulk", graphBulkLink)
 		api.GET("/graph/neighbors/:nodeId", graphNeighbors)
+		api.GET("/graph/subgraph/:nodeId", graphSubgraph)
+		api.POST("/graph/nodes", createGraphNode)
+		api.GET("/graph/nodes/:id", getGraphNode)
+		api.DELETE("/graph/nodes/:id", deleteGraphNode)
 		api.POST("/graph/path", graphPath)
 		api.POST("/graph/query", graphQuery)
-	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "40121"
+		// 备份和恢复
+		api.POST("/admin/backup", createBackup)
+		api.GET("/admin/backups", listBackups)
+		api.GET("/admin/backups/:file", downloadBackup)
+		api.POST("/admin/restore", restoreBackup)
 	}
 
+	port := strconv.Itoa(cfg.Server.Port)
 	logrus.WithField("port", port).Info("Server starting")
-	if err := r.Run(":" + port); err != nil {
-		logrus.WithError(err).Fatal("Failed to start server")
+
+	// 优雅关闭
+	srv := &http.Server{
+		Addr:    ":" + port,
+		Handler: r,
+	}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logrus.WithError(err).Fatal("Failed to start server")
+		}
+	}()
+
+	// 等待中断信号
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	logrus.Info("Shutting down server...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// 停止接收新请求，等待处理中的请求（包括写入）完成
+	if err := srv.Shutdown(ctx); err != nil {
+		logrus.WithError(err).Warn("Server forced to shutdown")
+	}
+
+	// 中断 reembed 任务，等待备份和恢复任务完成
+	stopJobs()
+	if err := waitJobs(ctx); err != nil {
+		logrus.WithError(err).Warn("Background jobs did not finish before shutdown")
+	}
+
+	if err := closeDatabase(); err != nil {
+		logrus.WithError(err).Error("Failed to close database")
 	}
+	logrus.Info("Server exiting")
 }
@@ -5,12 +5,17 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	cayley_driver "github.com/mozhou-tech/sqlite-ai-driver/pkg/cayley-driver"
@@ -46,12 +51,16 @@ func setupTestDB(t *testing.T) (*sql.DB, cayley_driver.Graph, func()) {
 		embedding TEXT,
 		content TEXT,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		deleted_at TIMESTAMP
 	);
 	CREATE INDEX IF NOT EXISTS idx_documents_collection ON documents(collection_name);
 	`
 	_, err = testSQLDB.Exec(createTableSQL)
 	require.NoError(t, err)
+	require.NoError(t, ensureCollectionsTable(testSQLDB))
+	require.NoError(t, ensureGraphNodesTable(testSQLDB))
+	require.NoError(t, ensureAttachmentsTable(testSQLDB))
 
 	// 初始化图数据库
 	// 使用 tmpDir 作为 workingDir，相对路径会构建到 {tmpDir}/graph/ 目录
@@ -85,23 +94,58 @@ func setupTestDB(t *testing.T) (*sql.DB, cayley_driver.Graph, func()) {
 func setupRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
+	r.Use(requestLogMiddleware())
 	api := r.Group("/api")
+	api.Use(authMiddleware(), limitsMiddleware(), auditMiddleware(), searchCacheMiddleware())
 	{
+		api.GET("/openapi.json", getOpenAPISpec)
 		api.GET("/db/info", getDBInfo)
 		api.GET("/db/collections", getCollections)
+		api.GET("/collections", getCollections)
+		api.POST("/collections", createCollection)
 		api.GET("/collections/:name", getCollection)
+		api.PATCH("/collections/:name", updateCollection)
+		api.DELETE("/collections/:name", deleteCollection)
+		api.GET("/collections/:name/stats", getCollectionStats)
 		api.GET("/collections/:name/documents", getDocuments)
 		api.GET("/collections/:name/documents/:id", getDocument)
 		api.POST("/collections/:name/documents", createDocument)
 		api.PUT("/collections/:name/documents/:id", updateDocument)
 		api.DELETE("/collections/:name/documents/:id", deleteDocument)
+		api.GET("/collections/:name/documents/:id/attachments", listAttachments)
+		api.PUT("/collections/:name/documents/:id/attachments/:attachment", uploadAttachment)
+		api.GET("/collections/:name/documents/:id/attachments/:attachment", downloadAttachment)
+		api.DELETE("/collections/:name/documents/:id/attachments/:attachment", deleteAttachment)
+		api.GET("/collections/:name/trash", getTrash)
+		api.DELETE("/collections/:name/trash", purgeTrash)
+		api.POST("/collections/:name/trash/:id/restore", restoreTrashedDocument)
+		api.DELETE("/collections/:name/trash/:id", purgeTrash)
+		api.POST("/collections/:name/query", queryDocuments)
+		api.POST("/collections/:name/reembed", reembedCollection)
+		api.GET("/jobs/:id", getJob)
+		api.POST("/collections/:name/:action", collectionAction)
+		api.GET("/collections/:name/export", exportDocuments)
 		api.POST("/collections/:name/fulltext/search", fulltextSearch)
+		api.GET("/analyze", analyzeText)
 		api.POST("/collections/:name/vector/search", vectorSearch)
+		api.POST("/collections/:name/vector/msearch", vectorMultiSearch)
+		api.POST("/collections/:name/search", hybridSearch)
 		api.POST("/graph/link", graphLink)
 		api.DELETE("/graph/link", graphUnlink)
+		api.POST("/graph/links/bulk", graphBulkLink)
 		api.GET("/graph/neighbors/:nodeId", graphNeighbors)
+		api.GET("/graph/subgraph/:nodeId", graphSubgraph)
+		api.POST("/graph/nodes", createGraphNode)
+		api.GET("/graph/nodes/:id", getGraphNode)
+		api.DELETE("/graph/nodes/:id", deleteGraphNode)
 		api.POST("/graph/path", graphPath)
 		api.POST("/graph/query", graphQuery)
+
+		// 备份和恢复
+		api.POST("/admin/backup", createBackup)
+		api.GET("/admin/backups", listBackups)
+		api.GET("/admin/backups/:file", downloadBackup)
+		api.POST("/admin/restore", restoreBackup)
 	}
 	return r
 }
@@ -435,8 +479,24 @@ func TestDeleteDocument(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 
-	// 验证文档已删除
+	// 默认移入回收站：记录保留并标记删除时间，读取时视为不存在
 	var count int
+	err = sqlDB.QueryRow(`SELECT COUNT(*) FROM documents WHERE id = ? AND deleted_at IS NOT NULL`, docID).Scan(&count)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	req, _ = http.NewRequest("GET", fmt.Sprintf("/api/collections/test_collection/documents/%s", docID), nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// permanent=true 永久删除
+	req, _ = http.NewRequest("DELETE", fmt.Sprintf("/api/collections/test_collection/documents/%s?permanent=true", docID), nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// 验证文档已删除
 	err = sqlDB.QueryRow(`SELECT COUNT(*) FROM documents WHERE id = ?`, docID).Scan(&count)
 	require.NoError(t, err)
 	assert.Equal(t, 0, count)
@@ -523,6 +583,33 @@ func TestFulltextSearch(t *testing.T) {
 	}
 }
 
+// TestRetokenizeContent 测试分词规则变化后重新生成已有文档的 content_tokens
+func TestRetokenizeContent(t *testing.T) {
+	testDB, _, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	// 旧版本写入的分词结果没有规范化和停用词过滤
+	_, err := testDB.Exec(`ALTER TABLE documents ADD COLUMN content_tokens TEXT`)
+	require.NoError(t, err)
+	_, err = testDB.Exec(
+		`INSERT INTO documents (id, collection_name, data, content, content_tokens) VALUES (?, ?, ?, ?, ?)`,
+		"doc1", "test_collection", `{}`, "這是一個ＡＰＩ測試", "這是 一個 ＡＰＩ 測試",
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, retokenizeContent(testDB))
+	var tokens string
+	require.NoError(t, testDB.QueryRow(`SELECT content_tokens FROM documents WHERE id = 'doc1'`).Scan(&tokens))
+	assert.Equal(t, tokenizeWithSego("這是一個ＡＰＩ測試"), tokens)
+
+	// 版本已记录，再次启动不会重新分词
+	_, err = testDB.Exec(`UPDATE documents SET content_tokens = 'unchanged' WHERE id = 'doc1'`)
+	require.NoError(t, err)
+	require.NoError(t, retokenizeContent(testDB))
+	require.NoError(t, testDB.QueryRow(`SELECT content_tokens FROM documents WHERE id = 'doc1'`).Scan(&tokens))
+	assert.Equal(t, "unchanged", tokens)
+}
+
 // TestGraphLink 测试创建图链接
 func TestGraphLink(t *testing.T) {
 	testDB, testGraph, cleanup := setupTestDB(t)
@@ -763,6 +850,100 @@ func TestVectorSearchFallback(t *testing.T) {
 	assert.InDelta(t, 1.0, similarity, 0.001)
 }
 
+// postVectorSearch 发送向量搜索请求并解析响应
+func postVectorSearch(t *testing.T, r *gin.Engine, searchReq VectorSearchRequest) (int, map[string]interface{}) {
+	jsonData, _ := json.Marshal(searchReq)
+	req, _ := http.NewRequest("POST", "/api/collections/test_collection/vector/search", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return w.Code, response
+}
+
+// resultIDs 提取向量搜索结果中的文档 ID
+func resultIDs(response map[string]interface{}) []string {
+	var ids []string
+	results, _ := response["results"].([]interface{})
+	for _, r := range results {
+		doc := r.(map[string]interface{})["document"].(map[string]interface{})
+		ids = append(ids, doc["id"].(string))
+	}
+	return ids
+}
+
+// TestVectorSearch 测试固定维度 FLOAT[N] 列上的向量搜索、分页和阈值
+func TestVectorSearch(t *testing.T) {
+	_, _, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, err := sqlDB.Exec(`DROP TABLE documents; CREATE TABLE documents (
+		id VARCHAR(255) PRIMARY KEY,
+		collection_name VARCHAR(255) NOT NULL,
+		data TEXT,
+		embedding FLOAT[3],
+		content TEXT
+	)`)
+	require.NoError(t, err)
+
+	vectors := map[string]string{
+		"a": "[1, 0, 0]",
+		"b": "[0.9, 0.1, 0]",
+		"c": "[0.5, 0.5, 0]",
+		"d": "[0, 1, 0]",
+		// 与 d 的距离不能相同，否则分页顺序不确定（ORDER BY 只按距离排序以便命中 HNSW 索引）
+		"e": "[-0.1, 0, 1]",
+	}
+	for id, vec := range vectors {
+		_, err := sqlDB.Exec(`INSERT INTO documents (id, collection_name, data, embedding) VALUES (?, 'test_collection', ?, ?::FLOAT[3])`,
+			id, fmt.Sprintf(`{"name": %q}`, id), vec)
+		require.NoError(t, err)
+	}
+	_, err = sqlDB.Exec(`INSERT INTO documents (id, collection_name, data, embedding) VALUES ('x', 'other', '{}', [1, 0, 0]::FLOAT[3])`)
+	require.NoError(t, err)
+
+	r := setupRouter()
+
+	code, response := postVectorSearch(t, r, VectorSearchRequest{Query: []float64{1, 0, 0}, Limit: 2})
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, []string{"a", "b"}, resultIDs(response))
+	assert.Equal(t, true, response["has_more"])
+	first := response["results"].([]interface{})[0].(map[string]interface{})
+	assert.InDelta(t, 1.0, first["score"], 0.0001)
+
+	// 第二页
+	code, response = postVectorSearch(t, r, VectorSearchRequest{Query: []float64{1, 0, 0}, Limit: 2, Offset: 2})
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, []string{"c", "d"}, resultIDs(response))
+
+	// 阈值截断候选
+	code, response = postVectorSearch(t, r, VectorSearchRequest{Query: []float64{1, 0, 0}, Limit: 10, Threshold: 0.6})
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, []string{"a", "b", "c"}, resultIDs(response))
+	assert.Equal(t, false, response["has_more"])
+
+	// 维度不匹配
+	code, _ = postVectorSearch(t, r, VectorSearchRequest{Query: []float64{1, 0}})
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+// TestVectorSearchTextEmbedding 测试以文本存储的 embedding 列上的向量搜索
+func TestVectorSearchTextEmbedding(t *testing.T) {
+	_, _, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	_, err := sqlDB.Exec(`INSERT INTO documents (id, collection_name, data, embedding) VALUES
+		('near', 'test_collection', '{}', '[0.1, 0.2, 0.3]'),
+		('far', 'test_collection', '{}', '[-0.3, 0.2, -0.1]')`)
+	require.NoError(t, err)
+
+	code, response := postVectorSearch(t, setupRouter(), VectorSearchRequest{Query: []float64{0.1, 0.2, 0.3}, Limit: 10})
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, []string{"near", "far"}, resultIDs(response))
+}
+
 // setupTestDBWithoutEmbedding 设置没有 embedding 列的测试数据库
 func setupTestDBWithoutEmbedding(t *testing.T) (*sql.DB, cayley_driver.Graph, func()) {
 	// 创建临时目录
@@ -796,6 +977,9 @@ func setupTestDBWithoutEmbedding(t *testing.T) (*sql.DB, cayley_driver.Graph, fu
 	`
 	_, err = testSQLDB.Exec(createTableSQL)
 	require.NoError(t, err)
+	require.NoError(t, ensureCollectionsTable(testSQLDB))
+	require.NoError(t, ensureGraphNodesTable(testSQLDB))
+	require.NoError(t, ensureAttachmentsTable(testSQLDB))
 
 	// 初始化图数据库
 	// 使用 tmpDir 作为 workingDir，相对路径会构建到 {tmpDir}/graph/ 目录
@@ -1025,3 +1209,1758 @@ func TestCosineSimilarityEdgeCases(t *testing.T) {
 	result = cosineSimilarity([]float64{-1.0, 0.0}, []float64{1.0, 0.0})
 	assert.InDelta(t, -1.0, result, 0.001)
 }
+
+// postBatchImport 发送批量导入请求并解析响应
+func postBatchImport(t *testing.T, r *gin.Engine, query, body string) (int, BatchImportResponse) {
+	req, _ := http.NewRequest("POST", "/api/collections/bulk/documents:batch"+query, bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var response BatchImportResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return w.Code, response
+}
+
+// TestBatchImportDocuments 测试 JSON 数组和 NDJSON 批量导入
+func TestBatchImportDocuments(t *testing.T) {
+	_, _, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	r := setupRouter()
+
+	code, resp := postBatchImport(t, r, "", `[{"id":"a1","title":"文档一"},{"title":"无 ID 文档"},42]`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 2, resp.Inserted)
+	assert.Equal(t, 1, resp.Failed)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, 2, resp.Errors[0].Index)
+
+	ndjson := "{\"id\":\"a1\",\"title\":\"重复\"}\n\n{\"id\":\"a2\",\"title\":\"文档二\"}\n"
+	code, resp = postBatchImport(t, r, "?on_conflict=skip", ndjson)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 1, resp.Inserted)
+	assert.Equal(t, 1, resp.Skipped)
+
+	code, resp = postBatchImport(t, r, "?on_conflict=replace", `{"id":"a1","title":"替换后"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 1, resp.Inserted)
+
+	var data string
+	require.NoError(t, sqlDB.QueryRow(`SELECT data FROM documents WHERE id = 'a1'`).Scan(&data))
+	assert.Contains(t, data, "替换后")
+
+	var count int
+	require.NoError(t, sqlDB.QueryRow(`SELECT COUNT(*) FROM documents WHERE collection_name = 'bulk'`).Scan(&count))
+	assert.Equal(t, 3, count)
+
+	// 默认 on_conflict=error 时 ID 冲突会中止导入
+	code, _ = postBatchImport(t, r, "", `[{"id":"a2","title":"冲突"}]`)
+	assert.Equal(t, http.StatusInternalServerError, code)
+
+	code, _ = postBatchImport(t, r, "?on_conflict=bogus", `[]`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	req, _ := http.NewRequest("POST", "/api/collections/bulk/unknown", bytes.NewBufferString(`[]`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// TestExportDocuments 测试 NDJSON 和 Parquet 导出
+func TestExportDocuments(t *testing.T) {
+	_, _, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	r := setupRouter()
+
+	code, resp := postBatchImport(t, r, "", "{\"id\":\"e1\",\"title\":\"导出一\"}\n{\"id\":\"e2\",\"title\":\"导出二\"}")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, 2, resp.Inserted)
+
+	req, _ := http.NewRequest("GET", "/api/collections/bulk/export", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+
+	lines := bytes.Split(bytes.TrimSpace(w.Body.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	var first map[string]interface{}
+	require.NoError(t, json.Unmarshal(lines[0], &first))
+	assert.Equal(t, "e1", first["id"])
+
+	req, _ = http.NewRequest("GET", "/api/collections/bulk/export?format=parquet", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "bulk.parquet")
+
+	// 将下载内容写回文件，用 DuckDB 读取验证
+	parquetPath := filepath.Join(t.TempDir(), "bulk.parquet")
+	require.NoError(t, os.WriteFile(parquetPath, w.Body.Bytes(), 0o644))
+	var count int
+	require.NoError(t, sqlDB.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM read_parquet('%s')", parquetPath)).Scan(&count))
+	assert.Equal(t, 2, count)
+
+	req, _ = http.NewRequest("GET", "/api/collections/bulk/export?format=csv", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// postQuery 发送条件查询请求并返回文档 ID 列表
+func postQuery(t *testing.T, r *gin.Engine, body string) (int, map[string]interface{}) {
+	req, _ := http.NewRequest("POST", "/api/collections/books/query", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return w.Code, response
+}
+
+// queryIDs 提取查询结果中的文档 ID
+func queryIDs(response map[string]interface{}) []string {
+	docs, _ := response["documents"].([]interface{})
+	ids := make([]string, 0, len(docs))
+	for _, d := range docs {
+		ids = append(ids, d.(map[string]interface{})["id"].(string))
+	}
+	return ids
+}
+
+// TestQueryDocuments 测试 selector 查询、排序和投影
+func TestQueryDocuments(t *testing.T) {
+	_, _, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	books := []string{
+		`{"id":"b1","title":"Go 语言","price":59,"tags":["go","programming"],"author":{"name":"Alan"}}`,
+		`{"id":"b2","title":"DuckDB 入门","price":45.5,"tags":["database"],"author":{"name":"Mark"}}`,
+		`{"id":"b3","title":"Rust 编程","price":80,"tags":["rust","programming"],"author":{"name":"Steve"},"draft":true}`,
+		`{"id":"b4","title":"随笔","price":"free"}`,
+	}
+	for _, b := range books {
+		_, err := sqlDB.Exec(`INSERT INTO documents (id, collection_name, data) VALUES (json_extract_string(?, '$.id'), 'books', ?)`, b, b)
+		require.NoError(t, err)
+	}
+
+	r := setupRouter()
+
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"数组包含", `{"selector":{"tags":"programming"},"sort":["id"]}`, []string{"b1", "b3"}},
+		{"数值比较", `{"selector":{"price":{"$gte":45.5,"$lt":80}},"sort":["price"]}`, []string{"b2", "b1"}},
+		{"数值相等", `{"selector":{"price":59.0}}`, []string{"b1"}},
+		{"嵌套字段", `{"selector":{"author.name":{"$in":["Mark","Steve"]}},"sort":[{"price":"desc"}]}`, []string{"b3", "b2"}},
+		{"存在性", `{"selector":{"draft":{"$exists":false},"price":{"$type":"number"}},"sort":["-price"]}`, []string{"b1", "b2"}},
+		{"逻辑组合", `{"selector":{"$or":[{"tags":{"$all":["rust","programming"]}},{"title":{"$regex":"^Duck"}}]},"sort":["id"]}`, []string{"b2", "b3"}},
+		{"取反", `{"selector":{"tags":{"$ne":"programming"}},"sort":["id"]}`, []string{"b2", "b4"}},
+		{"数组长度", `{"selector":{"tags":{"$size":1}}}`, []string{"b2"}},
+		{"分页", `{"sort":["id"],"limit":2,"skip":1}`, []string{"b2", "b3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, response := postQuery(t, r, tt.body)
+			require.Equal(t, http.StatusOK, code, response)
+			assert.Equal(t, tt.want, queryIDs(response))
+		})
+	}
+
+	code, response := postQuery(t, r, `{"selector":{"id":"b1"},"fields":["title","author.name"]}`)
+	require.Equal(t, http.StatusOK, code)
+	doc := response["documents"].([]interface{})[0].(map[string]interface{})["data"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"id":     "b1",
+		"title":  "Go 语言",
+		"author": map[string]interface{}{"name": "Alan"},
+	}, doc)
+
+	for _, body := range []string{
+		`{"selector":{"price":{"$bogus":1}}}`,
+		`{"selector":{"$or":{}}}`,
+		`{"selector":{"title":{"$regex":"("}}}`,
+		`{"sort":[{"price":"sideways"}]}`,
+	} {
+		code, _ := postQuery(t, r, body)
+		assert.Equal(t, http.StatusBadRequest, code, body)
+	}
+}
+
+// TestGetDocumentsTagFilter 测试文档列表的 tag 过滤按数组元素精确匹配
+func TestGetDocumentsTagFilter(t *testing.T) {
+	_, _, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	for i, tags := range []string{`["go","db"]`, `["golang"]`, `[]`} {
+		_, err := sqlDB.Exec(`INSERT INTO documents (id, collection_name, data) VALUES (?, 'tagged', ?)`,
+			fmt.Sprintf("t%d", i), fmt.Sprintf(`{"tags": %s}`, tags))
+		require.NoError(t, err)
+	}
+
+	r := setupRouter()
+	req, _ := http.NewRequest("GET", "/api/collections/tagged/documents?tag=go", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, float64(1), response["total"])
+	assert.Equal(t, []string{"t0"}, queryIDs(response))
+}
+
+// postHybridSearch 发送混合检索请求
+func postHybridSearch(t *testing.T, r *gin.Engine, body string) (int, map[string]interface{}) {
+	req, _ := http.NewRequest("POST", "/api/collections/hybrid/search", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return w.Code, response
+}
+
+// TestHybridSearch 测试关键词与向量检索的 RRF / 加权融合
+func TestHybridSearch(t *testing.T) {
+	_, _, cleanup := setupTestDB(t)
+	defer cleanup()
+	t.Setenv("DASHSCOPE_API_KEY", "")
+
+	_, err := sqlDB.Exec(`DROP TABLE documents; CREATE TABLE documents (
+		id VARCHAR(255) PRIMARY KEY,
+		collection_name VARCHAR(255) NOT NULL,
+		data TEXT,
+		embedding FLOAT[3],
+		content TEXT
+	)`)
+	require.NoError(t, err)
+
+	docs := []struct {
+		id, content, category, vec string
+	}{
+		{"a", "golang database", "db", "[1, 0, 0]"},
+		{"b", "golang web", "web", "[0, 1, 0]"},
+		{"c", "python database", "db", "[0.9, 0.1, 0]"},
+		{"d", "cooking recipes", "food", "[0, 0, 1]"},
+	}
+	for _, d := range docs {
+		_, err := sqlDB.Exec(`INSERT INTO documents (id, collection_name, data, embedding, content) VALUES (?, 'hybrid', ?, ?::FLOAT[3], ?)`,
+			d.id, fmt.Sprintf(`{"category": %q}`, d.category), d.vec, d.content)
+		require.NoError(t, err)
+	}
+
+	r := setupRouter()
+
+	// RRF：两路都排第一的 a 居首，只在向量侧命中的 d 垫底
+	code, response := postHybridSearch(t, r, `{"query":"golang database","vector":[1,0,0]}`)
+	require.Equal(t, http.StatusOK, code, response)
+	ids := resultIDs(response)
+	require.Len(t, ids, 4)
+	assert.Equal(t, "a", ids[0])
+	assert.ElementsMatch(t, []string{"b", "c"}, ids[1:3])
+	assert.Equal(t, "d", ids[3])
+	assert.Equal(t, []interface{}{"keyword", "vector"}, response["modes"])
+	first := response["results"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, float64(1), first["keyword_rank"])
+	assert.Equal(t, float64(1), first["vector_rank"])
+
+	// 加权融合，只看向量
+	code, response = postHybridSearch(t, r, `{"query":"golang database","vector":[1,0,0],"fusion":"weighted","keyword_weight":0,"limit":2}`)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, []string{"a", "c"}, resultIDs(response))
+	assert.Equal(t, []interface{}{"vector"}, response["modes"])
+
+	// 预过滤
+	code, response = postHybridSearch(t, r, `{"query":"golang","vector":[0,1,0],"filter":{"category":"db"}}`)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, []string{"a", "c"}, resultIDs(response))
+
+	// 无法生成 embedding 时退化为纯关键词检索
+	code, response = postHybridSearch(t, r, `{"query":"database"}`)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, []string{"a", "c"}, resultIDs(response))
+	assert.Equal(t, []interface{}{"keyword"}, response["modes"])
+	assert.NotEmpty(t, response["warnings"])
+
+	for _, body := range []string{
+		`{}`,
+		`{"query":"x","fusion":"max"}`,
+		`{"vector":[1,0]}`,
+		`{"query":"x","keyword_weight":0,"vector_weight":0}`,
+	} {
+		code, _ := postHybridSearch(t, r, body)
+		assert.Equal(t, http.StatusBadRequest, code, body)
+	}
+}
+
+// fakeEmbedder 测试用 Embedder：向量为 [文本长度, 1, 0]
+type fakeEmbedder struct {
+	calls int
+	err   error
+}
+
+func (f *fakeEmbedder) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vectors[i] = []float64{float64(len([]rune(text))), 1, 0}
+	}
+	return vectors, nil
+}
+
+// useFakeEmbedder 替换全局 Embedder 并为 notes 集合配置 embed 字段
+func useFakeEmbedder(t *testing.T) *fakeEmbedder {
+	fake := &fakeEmbedder{}
+	old := embedder
+	embedder = fake
+	t.Cleanup(func() { embedder = old })
+	t.Setenv("EMBED_FIELDS", `{"notes": ["title", "meta.summary"]}`)
+	return fake
+}
+
+// storedEmbedding 读取文档 embedding 列的文本形式
+func storedEmbedding(t *testing.T, id string) sql.NullString {
+	var embedding sql.NullString
+	require.NoError(t, sqlDB.QueryRow(`SELECT CAST(embedding AS VARCHAR) FROM documents WHERE id = ?`, id).Scan(&embedding))
+	return embedding
+}
+
+// TestServerSideEmbedding 测试创建和更新文档时按 embed 字段生成 embedding
+func TestServerSideEmbedding(t *testing.T) {
+	_, _, cleanup := setupTestDB(t)
+	defer cleanup()
+	fake := useFakeEmbedder(t)
+
+	r := setupRouter()
+	doRequest := func(method, url, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := doRequest("POST", "/api/collections/notes/documents", `{"id":"n1","title":"abc","meta":{"summary":"de"},"other":"x"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, 1, fake.calls)
+	// "abc\nde" 共 6 个字符
+	assert.Contains(t, storedEmbedding(t, "n1").String, "6")
+
+	// 修改无关字段不重新生成，也不清空原有 embedding
+	w = doRequest("PUT", "/api/collections/notes/documents/n1", `{"other":"y"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, fake.calls)
+	assert.True(t, storedEmbedding(t, "n1").Valid)
+
+	w = doRequest("PUT", "/api/collections/notes/documents/n1", `{"title":"abcdefgh"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, fake.calls)
+	assert.Contains(t, storedEmbedding(t, "n1").String, "11")
+
+	// 未配置 embed 字段的集合不调用 Embedder
+	w = doRequest("POST", "/api/collections/plain/documents", `{"id":"p1","title":"abc"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, 2, fake.calls)
+	assert.False(t, storedEmbedding(t, "p1").Valid)
+
+	// 生成失败时文档照常写入，响应中带有 warnings
+	fake.err = fmt.Errorf("provider down")
+	w = doRequest("POST", "/api/collections/notes/documents", `{"id":"n2","title":"xyz"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var response DocumentResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Warnings, 1)
+	assert.Contains(t, response.Warnings[0], "provider down")
+	assert.False(t, storedEmbedding(t, "n2").Valid)
+}
+
+// TestReembedCollection 测试后台回填 embedding 任务
+func TestReembedCollection(t *testing.T) {
+	_, _, cleanup := setupTestDB(t)
+	defer cleanup()
+	fake := useFakeEmbedder(t)
+
+	for i := 0; i < 5; i++ {
+		_, err := sqlDB.Exec(`INSERT INTO documents (id, collection_name, data) VALUES (?, 'notes', ?)`,
+			fmt.Sprintf("r%d", i), fmt.Sprintf(`{"title": "title %d"}`, i))
+		require.NoError(t, err)
+	}
+	_, err := sqlDB.Exec(`INSERT INTO documents (id, collection_name, data) VALUES ('r9', 'notes', '{"other": "no text"}')`)
+	require.NoError(t, err)
+
+	r := setupRouter()
+	req, _ := http.NewRequest("POST", "/api/collections/notes/reembed", bytes.NewBufferString(`{"batch_size": 2}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	var job Job
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &job))
+
+	require.Eventually(t, func() bool {
+		req, _ := http.NewRequest("GET", "/api/jobs/"+job.ID, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &job))
+		return job.Status != JobStatusRunning
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, JobStatusCompleted, job.Status)
+	assert.Equal(t, 5, job.Processed)
+	assert.Equal(t, 1, job.Skipped)
+	assert.Equal(t, 3, fake.calls)
+	assert.True(t, storedEmbedding(t, "r4").Valid)
+
+	// 未配置 embed 字段的集合
+	req, _ = http.NewRequest("POST", "/api/collections/plain/reembed", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// TestJobShutdown 关闭服务时 reembed 任务在批次之间中断，waitJobs 等待后台任务结束
+func TestJobShutdown(t *testing.T) {
+	_, _, cleanup := setupTestDB(t)
+	defer cleanup()
+	fake := useFakeEmbedder(t)
+
+	_, err := sqlDB.Exec(`INSERT INTO documents (id, collection_name, data) VALUES ('s1', 'notes', '{"title": "title"}')`)
+	require.NoError(t, err)
+
+	oldCtx, oldStop := jobsCtx, stopJobs
+	jobsCtx, stopJobs = context.WithCancel(context.Background())
+	t.Cleanup(func() { jobsCtx, stopJobs = oldCtx, oldStop })
+	stopJobs()
+
+	job, err := startJob("reembed", "shutdown")
+	require.NoError(t, err)
+	err = runReembed(job, fake, "notes", []string{"title"}, false, 10)
+	finishJob(job, err)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, fake.calls)
+
+	release := make(chan struct{})
+	goJob(func() { <-release })
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.Error(t, waitJobs(ctx))
+	close(release)
+	assert.NoError(t, waitJobs(context.Background()))
+}
+
+// TestCollectionManagement 测试集合的创建、配置、重命名和删除
+func TestCollectionManagement(t *testing.T) {
+	_, _, cleanup := setupTestDB(t)
+	defer cleanup()
+	fake := &fakeEmbedder{}
+	oldEmbedder := embedder
+	embedder = fake
+	defer func() { embedder = oldEmbedder }()
+
+	r := setupRouter()
+	doRequest := func(method, url, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := doRequest("POST", "/api/collections", `{"name":"articles","embed_fields":["title"],"fts_fields":["body"],"vector_dimension":3}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = doRequest("POST", "/api/collections", `{"name":"articles"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = doRequest("POST", "/api/collections", `{"name":"a/b"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = doRequest("POST", "/api/collections", `{"name":"bad","embed_fields":["a..b"]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 空集合也出现在列表中并带有配置
+	w = doRequest("GET", "/api/collections/articles", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var info map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+	assert.Equal(t, true, info["exists"])
+	assert.Equal(t, true, info["registered"])
+	assert.Equal(t, []interface{}{"title"}, info["settings"].(map[string]interface{})["embed_fields"])
+
+	// 注册表中的 embed_fields 用于服务端 embedding，fts_fields 决定 content 列
+	w = doRequest("POST", "/api/collections/articles/documents", `{"id":"a1","title":"hello","body":"full text"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, 1, fake.calls)
+	assert.Contains(t, storedEmbedding(t, "a1").String, "5")
+	var content string
+	require.NoError(t, sqlDB.QueryRow(`SELECT content FROM documents WHERE id = 'a1'`).Scan(&content))
+	assert.Equal(t, "full text", content)
+
+	// 维度不符的 embedding 被拒绝
+	w = doRequest("POST", "/api/collections/articles/documents", `{"id":"a2","embedding":[1,2]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = doRequest("GET", "/api/collections", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"articles"`)
+
+	// 修改配置只影响请求中出现的字段
+	w = doRequest("PATCH", "/api/collections/articles", `{"fts_fields":[]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	settings := getCollectionSettings("articles")
+	assert.Equal(t, []string{"title"}, settings.EmbedFields)
+	assert.Empty(t, settings.FTSFields)
+	assert.Equal(t, 3, settings.VectorDimension)
+
+	// 重命名会迁移文档和配置
+	_, err := sqlDB.Exec(`INSERT INTO documents (id, collection_name, data) VALUES ('o1', 'other', '{}')`)
+	require.NoError(t, err)
+	w = doRequest("PATCH", "/api/collections/articles", `{"name":"other"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = doRequest("PATCH", "/api/collections/articles", `{"name":"posts"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var collection string
+	require.NoError(t, sqlDB.QueryRow(`SELECT collection_name FROM documents WHERE id = 'a1'`).Scan(&collection))
+	assert.Equal(t, "posts", collection)
+	assert.Equal(t, 3, getCollectionSettings("posts").VectorDimension)
+	assert.Zero(t, getCollectionSettings("articles").VectorDimension)
+
+	// 未注册但有文档的集合也可以修改配置和删除
+	w = doRequest("PATCH", "/api/collections/other", `{"vector_dimension":4}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, 4, getCollectionSettings("other").VectorDimension)
+	w = doRequest("PATCH", "/api/collections/missing", `{"vector_dimension":4}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = doRequest("DELETE", "/api/collections/posts", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var count int
+	require.NoError(t, sqlDB.QueryRow(`SELECT COUNT(*) FROM documents WHERE collection_name = 'posts'`).Scan(&count))
+	assert.Zero(t, count)
+	w = doRequest("DELETE", "/api/collections/posts", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// TestAuthMiddleware 测试 API Key 认证、权限范围和集合 ACL
+func TestAuthMiddleware(t *testing.T) {
+	_, _, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	t.Setenv("API_KEYS", `[
+		{"name": "reader", "key": "r-key", "scopes": ["read"], "collections": ["pub_*"]},
+		{"name": "writer", "key": "w-key", "scopes": ["write"]},
+		{"name": "admin", "key": "a-key", "scopes": ["admin"], "collections": ["pub_*"]},
+		{"name": "scoped", "key": "s-key", "scopes": ["write"], "collections": ["pub_*"]}
+	]`)
+	keys, err := loadAPIKeys()
+	require.NoError(t, err)
+	require.Len(t, keys, 4)
+	oldKeys := apiKeys
+	apiKeys = keys
+	defer func() { apiKeys = oldKeys }()
+
+	_, err = sqlDB.Exec(`INSERT INTO documents (id, collection_name, data) VALUES ('d1', 'pub_docs', '{"title": "a"}'), ('d2', 'private', '{"title": "b"}')`)
+	require.NoError(t, err)
+
+	r := setupRouter()
+	doRequest := func(method, url, body string, header ...string) int {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	bearer := func(key string) []string { return []string{"Authorization", "Bearer " + key} }
+
+	assert.Equal(t, http.StatusUnauthorized, doRequest("GET", "/api/collections/pub_docs/documents", ""))
+	assert.Equal(t, http.StatusUnauthorized, doRequest("GET", "/api/collections/pub_docs/documents", "", bearer("wrong")...))
+	assert.Equal(t, http.StatusOK, doRequest("GET", "/api/collections/pub_docs/documents", "", bearer("r-key")...))
+	assert.Equal(t, http.StatusOK, doRequest("GET", "/api/collections/pub_docs/documents", "", "X-API-Key", "r-key"))
+
+	// 只读的 POST 查询只需要 read 权限，写操作需要 write
+	assert.Equal(t, http.StatusOK, doRequest("POST", "/api/collections/pub_docs/query", `{"selector": {}}`, bearer("r-key")...))
+	assert.Equal(t, http.StatusForbidden, doRequest("POST", "/api/collections/pub_docs/documents", `{"title": "c"}`, bearer("r-key")...))
+	assert.Equal(t, http.StatusCreated, doRequest("POST", "/api/collections/pub_docs/documents", `{"title": "c"}`, bearer("w-key")...))
+
+	// 集合 ACL
+	assert.Equal(t, http.StatusForbidden, doRequest("GET", "/api/collections/private/documents/d2", "", bearer("r-key")...))
+	assert.Equal(t, http.StatusOK, doRequest("GET", "/api/collections/private/documents/d2", "", bearer("w-key")...))
+
+	// 集合管理需要 admin 权限，且受集合 ACL 限制
+	assert.Equal(t, http.StatusForbidden, doRequest("POST", "/api/collections", `{"name": "pub_new"}`, bearer("w-key")...))
+	assert.Equal(t, http.StatusForbidden, doRequest("POST", "/api/collections", `{"name": "secret"}`, bearer("a-key")...))
+	assert.Equal(t, http.StatusCreated, doRequest("POST", "/api/collections", `{"name": "pub_new"}`, bearer("a-key")...))
+	assert.Equal(t, http.StatusForbidden, doRequest("PATCH", "/api/collections/pub_new", `{"name": "secret"}`, bearer("a-key")...))
+
+	// 清空回收站需要 admin 权限，单个文档的恢复只需要 write
+	assert.Equal(t, http.StatusForbidden, doRequest("DELETE", "/api/collections/pub_docs/trash", "", bearer("w-key")...))
+	assert.Equal(t, http.StatusOK, doRequest("DELETE", "/api/collections/pub_docs/trash", "", bearer("a-key")...))
+	assert.Equal(t, http.StatusNotFound, doRequest("POST", "/api/collections/pub_docs/trash/none/restore", "", bearer("w-key")...))
+
+	// 备份涉及全部数据，需要 admin 权限且不受集合 ACL 限制
+	assert.Equal(t, http.StatusForbidden, doRequest("POST", "/api/admin/backup", "", bearer("w-key")...))
+	assert.Equal(t, http.StatusForbidden, doRequest("POST", "/api/admin/backup", "", bearer("a-key")...))
+	assert.Equal(t, http.StatusForbidden, doRequest("GET", "/api/admin/backups", "", bearer("a-key")...))
+
+	// 集合列表只包含可访问的集合
+	req, _ := http.NewRequest("GET", "/api/db/collections", nil)
+	req.Header.Set("Authorization", "Bearer r-key")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "pub_docs")
+	assert.NotContains(t, w.Body.String(), "private")
+	req, _ = http.NewRequest("GET", "/api/collections", nil)
+	req.Header.Set("Authorization", "Bearer s-key")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "private")
+
+	// 知识图谱由所有集合共享，限定了集合的 API Key 不能读写
+	link := `{"subject": "a", "predicate": "knows", "object": "b"}`
+	for _, route := range [][3]string{
+		{"POST", "/api/graph/link", link},
+		{"DELETE", "/api/graph/link", link},
+		{"POST", "/api/graph/links/bulk", `{"links": [` + link + `]}`},
+		{"POST", "/api/graph/nodes", `{"id": "a"}`},
+		{"DELETE", "/api/graph/nodes/a?cascade=true", ""},
+		{"GET", "/api/graph/nodes/a", ""},
+		{"GET", "/api/graph/neighbors/a", ""},
+		{"POST", "/api/graph/query", `{}`},
+	} {
+		assert.Equal(t, http.StatusForbidden, doRequest(route[0], route[1], route[2], bearer("s-key")...), route[1])
+	}
+	assert.NotEqual(t, http.StatusForbidden, doRequest("POST", "/api/graph/link", link, bearer("w-key")...))
+
+	// 任务受所属集合的 ACL 限制，全局任务需要可以访问所有集合
+	pubJob, err := startJob("reembed", "pub_docs")
+	require.NoError(t, err)
+	privateJob, err := startJob("reembed", "private")
+	require.NoError(t, err)
+	globalJob, err := startJob("backup", "")
+	require.NoError(t, err)
+	for _, job := range []*Job{pubJob, privateJob, globalJob} {
+		finishJob(job, nil)
+	}
+	assert.Equal(t, http.StatusOK, doRequest("GET", "/api/jobs/"+pubJob.ID, "", bearer("s-key")...))
+	assert.Equal(t, http.StatusForbidden, doRequest("GET", "/api/jobs/"+privateJob.ID, "", bearer("s-key")...))
+	assert.Equal(t, http.StatusForbidden, doRequest("GET", "/api/jobs/"+globalJob.ID, "", bearer("s-key")...))
+	assert.Equal(t, http.StatusOK, doRequest("GET", "/api/jobs/"+privateJob.ID, "", bearer("w-key")...))
+	assert.Equal(t, http.StatusOK, doRequest("GET", "/api/jobs/"+globalJob.ID, "", bearer("w-key")...))
+
+	t.Setenv("API_KEYS", `[{"name": "bad", "key": "k", "scopes": ["root"]}]`)
+	_, err = loadAPIKeys()
+	assert.Error(t, err)
+}
+
+// TestDocumentRevisions 测试 _rev 版本号和更新时的乐观并发控制
+func TestDocumentRevisions(t *testing.T) {
+	_, _, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	r := setupRouter()
+	doRequest := func(method, url, body string, header ...string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+	rev := func(response map[string]interface{}) interface{} {
+		data, _ := response["data"].(map[string]interface{})
+		return data["_rev"]
+	}
+
+	// 新文档的版本号为 1，客户端传入的 _rev 被忽略
+	w, response := doRequest("POST", "/api/collections/test/documents", `{"id":"doc1","title":"a","_rev":7}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, float64(1), rev(response))
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+
+	w, response = doRequest("GET", "/api/collections/test/documents/doc1", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, float64(1), rev(response))
+
+	// 版本一致时更新成功并递增版本号
+	w, response = doRequest("PUT", "/api/collections/test/documents/doc1", `{"title":"b","_rev":1}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, float64(2), rev(response))
+
+	// 使用过期的版本号更新返回 409，文档不变
+	w, response = doRequest("PUT", "/api/collections/test/documents/doc1", `{"title":"c","_rev":1}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, float64(2), response["_rev"])
+	w, _ = doRequest("PUT", "/api/collections/test/documents/doc1", `{"title":"c"}`, "If-Match", `"1"`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	_, response = doRequest("GET", "/api/collections/test/documents/doc1", "")
+	assert.Equal(t, "b", response["data"].(map[string]interface{})["title"])
+
+	w, response = doRequest("PUT", "/api/collections/test/documents/doc1", `{"title":"c"}`, "If-Match", `"2"`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, float64(3), rev(response))
+
+	// 不指定版本号时直接更新
+	w, response = doRequest("PUT", "/api/collections/test/documents/doc1", `{"title":"d"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, float64(4), rev(response))
+
+	w, _ = doRequest("PUT", "/api/collections/test/documents/doc1", `{"_rev":"abc"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// 没有版本号的旧文档视为版本 0
+	_, err := sqlDB.Exec(`INSERT INTO documents (id, collection_name, data) VALUES ('legacy', 'test', '{"id": "legacy"}')`)
+	require.NoError(t, err)
+	w, response = doRequest("PUT", "/api/collections/test/documents/legacy", `{"title":"x","_rev":0}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, float64(1), rev(response))
+}
+
+// TestGraphNodes 测试图节点属性、批量建边、子图和级联删除
+func TestGraphNodes(t *testing.T) {
+	_, _, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	r := setupRouter()
+	doRequest := func(method, url, body string) (int, map[string]interface{}) {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+
+	code, _ := doRequest("POST", "/api/graph/nodes", `{"id":"alice","label":"person","properties":{"age":30}}`)
+	assert.Equal(t, http.StatusCreated, code)
+	code, _ = doRequest("POST", "/api/graph/nodes", `{"id":"alice","label":"person","properties":{"age":31}}`)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = doRequest("POST", "/api/graph/nodes", `{"label":"person"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// 批量建边：任意一条无效时整体拒绝
+	code, _ = doRequest("POST", "/api/graph/links/bulk", `{"links":[{"from":"alice","relation":"knows","to":"bob"},{"from":"bob","relation":"","to":"x"}]}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, response := doRequest("POST", "/api/graph/links/bulk", `{"links":[
+		{"from":"alice","relation":"knows","to":"bob"},
+		{"from":"bob","relation":"knows","to":"carol"},
+		{"from":"carol","relation":"knows","to":"dave"},
+		{"from":"erin","relation":"likes","to":"alice"}
+	]}`)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, float64(4), response["count"])
+
+	code, response = doRequest("GET", "/api/graph/nodes/alice", "")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(31), response["properties"].(map[string]interface{})["age"])
+	assert.Equal(t, float64(1), response["out_degree"])
+	assert.Equal(t, float64(1), response["in_degree"])
+	code, _ = doRequest("GET", "/api/graph/nodes/nobody", "")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	subgraphNodes := func(response map[string]interface{}) map[string]float64 {
+		result := make(map[string]float64)
+		for _, n := range response["nodes"].([]interface{}) {
+			node := n.(map[string]interface{})
+			result[node["id"].(string)] = node["depth"].(float64)
+		}
+		return result
+	}
+
+	code, response = doRequest("GET", "/api/graph/subgraph/alice?depth=2", "")
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, map[string]float64{"alice": 0, "bob": 1, "erin": 1, "carol": 2}, subgraphNodes(response))
+	assert.Len(t, response["edges"], 3)
+	assert.Equal(t, "person", response["nodes"].([]interface{})[0].(map[string]interface{})["label"])
+
+	code, response = doRequest("GET", "/api/graph/subgraph/alice?depth=3&direction=out&relation=knows", "")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]float64{"alice": 0, "bob": 1, "carol": 2, "dave": 3}, subgraphNodes(response))
+
+	code, response = doRequest("GET", "/api/graph/subgraph/alice?depth=3&limit=2", "")
+	require.Equal(t, http.StatusOK, code)
+	assert.Len(t, response["nodes"], 2)
+	assert.Equal(t, true, response["truncated"])
+
+	code, _ = doRequest("GET", "/api/graph/subgraph/alice?depth=9", "")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// 删除仍有边的节点需要 cascade
+	code, _ = doRequest("DELETE", "/api/graph/nodes/alice", "")
+	assert.Equal(t, http.StatusConflict, code)
+	code, response = doRequest("DELETE", "/api/graph/nodes/alice?cascade=true", "")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(2), response["deleted_edges"])
+	code, _ = doRequest("GET", "/api/graph/nodes/alice", "")
+	assert.Equal(t, http.StatusNotFound, code)
+	neighbors, err := graphDB.GetNeighbors(dbContext, "erin", "")
+	require.NoError(t, err)
+	assert.Empty(t, neighbors)
+}
+
+// TestGraphPagination 测试邻居和图查询结果的分页、排序和总数
+func TestGraphPagination(t *testing.T) {
+	_, _, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	for _, n := range []string{"n3", "n1", "n5", "n2", "n4"} {
+		require.NoError(t, graphDB.Link(dbContext, "hub", "links", n))
+	}
+
+	r := setupRouter()
+	doRequest := func(method, url, body string) (int, map[string]interface{}) {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+
+	code, response := doRequest("GET", "/api/graph/neighbors/hub?limit=2", "")
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, []interface{}{"n1", "n2"}, response["neighbors"])
+	assert.Equal(t, float64(5), response["total"])
+	assert.Equal(t, true, response["has_more"])
+
+	// 按 cursor 翻页直到结束
+	cursor := response["next_cursor"].(string)
+	code, response = doRequest("GET", "/api/graph/neighbors/hub?limit=2&cursor="+cursor, "")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []interface{}{"n3", "n4"}, response["neighbors"])
+	cursor = response["next_cursor"].(string)
+	code, response = doRequest("GET", "/api/graph/neighbors/hub?limit=2&cursor="+cursor, "")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []interface{}{"n5"}, response["neighbors"])
+	assert.Equal(t, false, response["has_more"])
+	assert.Equal(t, "", response["next_cursor"])
+
+	code, response = doRequest("GET", "/api/graph/neighbors/hub?limit=2&offset=1&order=desc", "")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []interface{}{"n4", "n3"}, response["neighbors"])
+
+	code, _ = doRequest("GET", "/api/graph/neighbors/hub?limit=5000", "")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = doRequest("GET", "/api/graph/neighbors/hub?cursor=abc&offset=1", "")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = doRequest("GET", "/api/graph/neighbors/hub?cursor=!!", "")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, response = doRequest("POST", "/api/graph/query", `{"query":"V('hub').Out('links')","limit":3,"sort":"object","order":"desc"}`)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, float64(5), response["total"])
+	results := response["results"].([]interface{})
+	require.Len(t, results, 3)
+	assert.Equal(t, "n5", results[0].(map[string]interface{})["object"])
+	assert.Equal(t, "n3", results[2].(map[string]interface{})["object"])
+
+	code, response = doRequest("POST", "/api/graph/query", `{"query":"V('hub').Out('links')","limit":3,"sort":"object","order":"desc","cursor":"`+response["next_cursor"].(string)+`"}`)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Len(t, response["results"], 2)
+	assert.Equal(t, false, response["has_more"])
+
+	code, _ = doRequest("POST", "/api/graph/query", `{"query":"V('hub').Out('links')","sort":"weight"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestGraphTraversal(t *testing.T) {
+	_, _, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	links := [][3]string{
+		{"alice", "follows", "bob"},
+		{"alice", "follows", "carol"},
+		{"bob", "follows", "dave"},
+		{"carol", "follows", "dave"},
+		{"carol", "follows", "erin"},
+		{"dave", "type", "person"},
+		{"erin", "type", "bot"},
+		{"frank", "likes", "carol"},
+	}
+	for _, l := range links {
+		require.NoError(t, graphDB.Link(dbContext, l[0], l[1], l[2]))
+	}
+
+	r := setupRouter()
+	doQuery := func(body string) (int, map[string]interface{}) {
+		req, _ := http.NewRequest("POST", "/api/graph/query", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+
+	// 多跳遍历，去重后的节点集合与最后一跳的边
+	code, response := doQuery(`{"query":"g.V('alice').Out('follows').Out('follows')"}`)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, []interface{}{"dave", "erin"}, response["nodes"])
+	assert.Equal(t, float64(3), response["total"])
+
+	// Has 过滤节点，同时过滤到达这些节点的边
+	code, response = doQuery(`{"query":"V(\"alice\") .Out(\"follows\").Out(\"follows\").Has('type', 'person')"}`)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, []interface{}{"dave"}, response["nodes"])
+	assert.Equal(t, float64(2), response["total"])
+
+	// Limit 截断节点集合
+	code, response = doQuery(`{"query":"V('alice').Out().Limit(1).All()"}`)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, []interface{}{"bob"}, response["nodes"])
+	results := response["results"].([]interface{})
+	require.Len(t, results, 1)
+	assert.Equal(t, "bob", results[0].(map[string]interface{})["object"])
+
+	// Both 不限边类型，In 返回真实的边类型
+	code, response = doQuery(`{"query":"V('carol').Both()"}`)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.ElementsMatch(t, []interface{}{"dave", "erin", "alice", "frank"}, response["nodes"])
+	code, response = doQuery(`{"query":"V('carol').In()","sort":"predicate"}`)
+	require.Equal(t, http.StatusOK, code, response)
+	results = response["results"].([]interface{})
+	require.Len(t, results, 2)
+	assert.Equal(t, "follows", results[0].(map[string]interface{})["predicate"])
+	assert.Equal(t, "likes", results[1].(map[string]interface{})["predicate"])
+
+	// JSON 形式的遍历，支持多个起点
+	code, response = doQuery(`{"traversal":{"start":["bob","carol"],"steps":[{"op":"out","predicate":"follows"},{"op":"has","predicate":"type"}]}}`)
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Equal(t, []interface{}{"dave", "erin"}, response["nodes"])
+	assert.Equal(t, float64(3), response["total"])
+
+	for _, body := range []string{
+		`{}`,
+		`{"query":"Out('follows')"}`,
+		`{"query":"V('alice').Out('follows'"}`,
+		`{"query":"V('alice).Out('follows')"}`,
+		`{"query":"V('alice').Sideways('follows')"}`,
+		`{"query":"V('alice').Limit(0)"}`,
+		`{"query":"V('alice').Has()"}`,
+		`{"query":"V('alice').All().Out()"}`,
+		`{"query":"V('alice')","traversal":{"start":["alice"]}}`,
+		`{"traversal":{"start":[],"steps":[]}}`,
+		`{"traversal":{"start":["alice"],"steps":[{"op":"jump"}]}}`,
+	} {
+		code, response = doQuery(body)
+		assert.Equal(t, http.StatusBadRequest, code, body)
+		assert.NotEmpty(t, response["error"], body)
+	}
+}
+
+func TestBackupRestore(t *testing.T) {
+	_, _, cleanup := setupTestDB(t)
+	defer cleanup()
+	t.Setenv("BACKUP_DIR", t.TempDir())
+	t.Setenv("BLOB_DIR", t.TempDir())
+
+	r := setupRouter()
+	doRequest := func(method, url, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	waitJob := func(w *httptest.ResponseRecorder) Job {
+		require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+		var job Job
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &job))
+		require.Eventually(t, func() bool {
+			w := doRequest("GET", "/api/jobs/"+job.ID, "")
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &job))
+			return job.Status != JobStatusRunning
+		}, 10*time.Second, 10*time.Millisecond)
+		require.Equal(t, JobStatusCompleted, job.Status, job.Error)
+		return job
+	}
+	documentIDs := func() []string {
+		rows, err := sqlDB.Query(`SELECT id FROM documents ORDER BY id`)
+		require.NoError(t, err)
+		defer rows.Close()
+		ids := []string{}
+		for rows.Next() {
+			var id string
+			require.NoError(t, rows.Scan(&id))
+			ids = append(ids, id)
+		}
+		return ids
+	}
+
+	require.Equal(t, http.StatusCreated, doRequest("POST", "/api/collections", `{"name":"notes","embed_fields":["title"]}`).Code)
+	require.Equal(t, http.StatusCreated, doRequest("POST", "/api/collections/notes/documents", `{"id":"a","title":"first"}`).Code)
+	require.Equal(t, http.StatusCreated, doRequest("POST", "/api/collections/notes/documents", `{"id":"b","title":"second"}`).Code)
+	require.NoError(t, graphDB.Link(dbContext, "a", "cites", "b"))
+	require.Equal(t, http.StatusCreated, doRequest("POST", "/api/graph/nodes", `{"id":"a","label":"note"}`).Code)
+	require.Equal(t, http.StatusCreated, doRequest("PUT", "/api/collections/notes/documents/b/attachments/scan.txt", "scanned page").Code)
+	attachmentCount := func() int {
+		var count int
+		require.NoError(t, sqlDB.QueryRow(`SELECT COUNT(*) FROM attachments`).Scan(&count))
+		return count
+	}
+
+	job := waitJob(doRequest("POST", "/api/admin/backup", ""))
+	assert.Equal(t, 6, job.Processed) // 1 个集合 + 2 个文档 + 1 个节点 + 1 个附件 + 1 条边
+	require.NotEmpty(t, job.File)
+
+	w := doRequest("GET", "/api/admin/backups", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Backups []BackupInfo `json:"backups"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Backups, 1)
+	assert.Equal(t, job.File, list.Backups[0].Name)
+
+	w = doRequest("GET", "/api/admin/backups/"+job.File, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	archive := w.Body.Bytes()
+	assert.Equal(t, []byte{0x1f, 0x8b}, archive[:2])
+
+	// 修改数据后从备份恢复
+	require.Equal(t, http.StatusOK, doRequest("DELETE", "/api/collections/notes/documents/a", "").Code)
+	require.Equal(t, http.StatusCreated, doRequest("POST", "/api/collections/notes/documents", `{"id":"c","title":"third"}`).Code)
+	require.NoError(t, graphDB.Link(dbContext, "c", "cites", "a"))
+	require.NoError(t, graphDB.Unlink(dbContext, "a", "cites", "b"))
+	w = doRequest("PUT", "/api/collections/notes/documents/c/attachments/draft.txt", "draft")
+	require.Equal(t, http.StatusCreated, w.Code)
+	var draft Attachment
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &draft))
+
+	waitJob(doRequest("POST", "/api/admin/restore", `{"backup":"`+job.File+`"}`))
+	assert.Equal(t, []string{"a", "b"}, documentIDs())
+	// 被替换的附件记录和不再引用的 blob 已删除
+	assert.Equal(t, 1, attachmentCount())
+	_, err := os.Stat(blobPath(draft.SHA256))
+	assert.True(t, os.IsNotExist(err))
+	triples, err := graphDB.AllTriples(dbContext)
+	require.NoError(t, err)
+	assert.Equal(t, []cayley_driver.Triple{{Subject: "a", Predicate: "cites", Object: "b"}}, triples)
+	w = doRequest("GET", "/api/graph/nodes/a", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"label":"note"`)
+	w = doRequest("GET", "/api/collections/notes", "")
+	assert.Contains(t, w.Body.String(), `"embed_fields":["title"]`)
+
+	// 上传归档恢复
+	require.Equal(t, http.StatusOK, doRequest("DELETE", "/api/collections/notes/documents/b", "").Code)
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", "backup.tar.gz")
+	require.NoError(t, err)
+	part.Write(archive)
+	require.NoError(t, mw.Close())
+	req, _ := http.NewRequest("POST", "/api/admin/restore", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w = httptest.NewRecorder()
+	// 在另一台机器上恢复：blob 目录为空，附件内容从归档中恢复
+	t.Setenv("BLOB_DIR", t.TempDir())
+	r.ServeHTTP(w, req)
+	waitJob(w)
+	assert.Equal(t, []string{"a", "b"}, documentIDs())
+	w = doRequest("GET", "/api/collections/notes/documents/b/attachments/scan.txt", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "scanned page", w.Body.String())
+
+	assert.Equal(t, http.StatusBadRequest, doRequest("GET", "/api/admin/backups/secret.txt", "").Code)
+	assert.Equal(t, http.StatusNotFound, doRequest("GET", "/api/admin/backups/missing.tar.gz", "").Code)
+	assert.Equal(t, http.StatusBadRequest, doRequest("POST", "/api/admin/restore", `{"backup":"notes.txt"}`).Code)
+	assert.Equal(t, http.StatusNotFound, doRequest("POST", "/api/admin/restore", `{"backup":"missing.tar.gz"}`).Code)
+}
+
+func TestCollectionStats(t *testing.T) {
+	_, _, cleanup := setupTestDB(t)
+	defer cleanup()
+	defer func() { ftsBuiltAt = time.Time{} }()
+
+	_, err := sqlDB.Exec(`INSERT INTO documents (id, collection_name, data, embedding, content) VALUES
+		('a', 'notes', '{"title": "first"}', '[0.1, 0.2]', 'first'),
+		('b', 'notes', '{"title": "second"}', NULL, 'second'),
+		('x', 'other', '{"title": "other"}', NULL, 'other')`)
+	require.NoError(t, err)
+
+	r := setupRouter()
+	getStats := func(name string) (int, CollectionStats) {
+		req, _ := http.NewRequest("GET", "/api/collections/"+name+"/stats", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var stats CollectionStats
+		json.Unmarshal(w.Body.Bytes(), &stats)
+		return w.Code, stats
+	}
+
+	code, stats := getStats("notes")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, int64(2), stats.DocumentCount)
+	assert.Equal(t, int64(1), stats.Embedded)
+	assert.Equal(t, int64(1), stats.Unembedded)
+	assert.Equal(t, int64(len(`{"title": "first"}`)+len(`{"title": "second"}`)), stats.Storage.DataBytes)
+	assert.Equal(t, int64(len("first")+len("second")), stats.Storage.ContentBytes)
+	assert.Equal(t, stats.Storage.DataBytes+stats.Storage.ContentBytes+stats.Storage.EmbeddingBytes, stats.Storage.TotalBytes)
+	assert.NotNil(t, stats.LastUpdatedAt)
+	assert.False(t, stats.FTS.Present)
+	assert.False(t, stats.VectorIndex.Present)
+
+	// 模拟 FTS 扩展创建的索引表：索引构建后修改的文档为 stale，新增的文档为 missing
+	_, err = sqlDB.Exec(`CREATE SCHEMA fts_main_documents;
+		CREATE TABLE fts_main_documents.docs (docid BIGINT, name VARCHAR, len BIGINT);
+		INSERT INTO fts_main_documents.docs VALUES (0, 'a', 1), (1, 'b', 1), (2, 'x', 1)`)
+	require.NoError(t, err)
+	markFTSIndexBuilt(sqlDB)
+
+	code, stats = getStats("notes")
+	require.Equal(t, http.StatusOK, code)
+	assert.True(t, stats.FTS.Present)
+	assert.True(t, stats.FTS.Fresh)
+	assert.Equal(t, int64(2), stats.FTS.Indexed)
+	require.NotNil(t, stats.FTS.BuiltAt)
+
+	time.Sleep(5 * time.Millisecond)
+	_, err = sqlDB.Exec(`UPDATE documents SET data = '{"title": "changed"}', updated_at = CURRENT_TIMESTAMP WHERE id = 'b'`)
+	require.NoError(t, err)
+	_, err = sqlDB.Exec(`INSERT INTO documents (id, collection_name, data) VALUES ('c', 'notes', '{"title": "third"}')`)
+	require.NoError(t, err)
+
+	code, stats = getStats("notes")
+	require.Equal(t, http.StatusOK, code)
+	assert.False(t, stats.FTS.Fresh)
+	assert.Equal(t, int64(2), stats.FTS.Indexed)
+	assert.Equal(t, int64(1), stats.FTS.Missing)
+	assert.Equal(t, int64(1), stats.FTS.Stale)
+	assert.Equal(t, int64(2), stats.Unembedded)
+
+	// 已注册但没有文档的集合
+	_, err = sqlDB.Exec(`INSERT INTO collections (name) VALUES ('empty')`)
+	require.NoError(t, err)
+	code, stats = getStats("empty")
+	require.Equal(t, http.StatusOK, code)
+	assert.True(t, stats.Registered)
+	assert.Equal(t, int64(0), stats.DocumentCount)
+
+	code, _ = getStats("missing")
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestVectorMultiSearch(t *testing.T) {
+	_, _, cleanup := setupTestDB(t)
+	defer cleanup()
+	fake := useFakeEmbedder(t)
+
+	_, err := sqlDB.Exec(`DROP TABLE documents; CREATE TABLE documents (
+		id VARCHAR(255) PRIMARY KEY,
+		collection_name VARCHAR(255) NOT NULL,
+		data TEXT,
+		embedding FLOAT[3],
+		content TEXT
+	)`)
+	require.NoError(t, err)
+	vectors := map[string]string{
+		"a": "[1, 0, 0]",
+		"b": "[0.9, 0.1, 0]",
+		"c": "[0.5, 0.5, 0]",
+		"d": "[0, 1, 0]",
+		"e": "[-0.1, 0, 1]",
+	}
+	for id, vec := range vectors {
+		_, err := sqlDB.Exec(`INSERT INTO documents (id, collection_name, data, embedding) VALUES (?, 'test_collection', ?, ?::FLOAT[3])`,
+			id, fmt.Sprintf(`{"name": %q}`, id), vec)
+		require.NoError(t, err)
+	}
+
+	r := setupRouter()
+	doSearch := func(body string) (int, map[string]interface{}) {
+		req, _ := http.NewRequest("POST", "/api/collections/test_collection/vector/msearch", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+
+	// 文本 "x" 的 fake embedding 为 [1, 1, 0]
+	code, response := doSearch(`{"queries": [{"query": [1, 0, 0]}, {"query_text": "x"}, {"query": [0, 0, 1]}], "limit": 2, "threshold": 0.5}`)
+	require.Equal(t, http.StatusOK, code, response)
+	responses := response["responses"].([]interface{})
+	require.Len(t, responses, 3)
+	first := responses[0].(map[string]interface{})
+	assert.Equal(t, []string{"a", "b"}, resultIDs(first))
+	assert.Equal(t, true, first["has_more"])
+	second := responses[1].(map[string]interface{})
+	assert.Equal(t, "x", second["query"])
+	assert.Equal(t, []string{"c", "b"}, resultIDs(second))
+	third := responses[2].(map[string]interface{})
+	assert.Equal(t, []string{"e"}, resultIDs(third))
+	assert.Equal(t, false, third["has_more"])
+	assert.Equal(t, 1, fake.calls)
+
+	// 查询文本按批量上限分批生成 embedding
+	fake.calls = 0
+	texts := make([]string, 12)
+	for i := range texts {
+		texts[i] = `{"query_text": "q"}`
+	}
+	code, response = doSearch(fmt.Sprintf(`{"queries": [%s], "limit": 1}`, strings.Join(texts, ",")))
+	require.Equal(t, http.StatusOK, code, response)
+	assert.Len(t, response["responses"], 12)
+	assert.Equal(t, 2, fake.calls)
+
+	for _, body := range []string{
+		`{"queries": []}`,
+		`{"queries": [{}]}`,
+		`{"queries": [{"query": [1, 0, 0], "query_text": "x"}]}`,
+		`{"queries": [{"query": [1, 0]}]}`,
+		fmt.Sprintf(`{"queries": [%s]}`, strings.Repeat(`{"query": [1, 0, 0]},`, maxVectorMultiSearchQueries)+`{"query": [1, 0, 0]}`),
+	} {
+		code, response = doSearch(body)
+		assert.Equal(t, http.StatusBadRequest, code, body)
+		assert.NotEmpty(t, response["error"], body)
+	}
+}
+
+// TestAnalyze 测试分词预览接口
+func TestAnalyze(t *testing.T) {
+	r := setupRouter()
+	analyze := func(text string) (int, AnalyzeResponse) {
+		req, _ := http.NewRequest("GET", "/api/analyze?text="+url.QueryEscape(text), nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var resp AnalyzeResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	code, resp := analyze("我们在ＨＥＬＬＯ北京大学学习。")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "我们在hello北京大学学习。", resp.Normalized)
+	assert.Contains(t, resp.Tokens, "我们")
+	assert.Contains(t, resp.Tokens, "。")
+	assert.Contains(t, resp.Stopwords, "我们")
+	assert.NotContains(t, resp.IndexedTokens, "我们")
+	assert.NotContains(t, resp.IndexedTokens, "。")
+	assert.Contains(t, resp.IndexedTokens, "hello")
+	assert.Equal(t, tokenizeWithSego("我们在ＨＥＬＬＯ北京大学学习。"), resp.ContentTokens)
+	assert.Equal(t, strings.Join(resp.IndexedTokens, " "), resp.ContentTokens)
+
+	// 全部是停用词时不会写入任何词
+	code, resp = analyze("我们")
+	require.Equal(t, http.StatusOK, code)
+	assert.Empty(t, resp.ContentTokens)
+	assert.Empty(t, resp.IndexedTokens)
+
+	code, _ = analyze("  ")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+// TestTrash 测试软删除、回收站列表、恢复和永久删除
+func TestTrash(t *testing.T) {
+	_, _, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	r := setupRouter()
+	doRequest := func(method, url, body string) (int, map[string]interface{}) {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response
+	}
+
+	for _, doc := range []string{`{"id":"a","title":"apple"}`, `{"id":"b","title":"banana"}`, `{"id":"c","title":"cherry"}`} {
+		code, _ := doRequest("POST", "/api/collections/fruits/documents", doc)
+		require.Equal(t, http.StatusCreated, code)
+	}
+
+	code, _ := doRequest("DELETE", "/api/collections/fruits/documents/a", "")
+	require.Equal(t, http.StatusOK, code)
+	code, _ = doRequest("DELETE", "/api/collections/fruits/documents/b", "")
+	require.Equal(t, http.StatusOK, code)
+
+	// 回收站中的文档不出现在列表、查询、导出和统计中，也不能被更新
+	_, response := doRequest("GET", "/api/collections/fruits/documents", "")
+	assert.Equal(t, float64(1), response["total"])
+	_, response = doRequest("POST", "/api/collections/fruits/query", `{"selector": {}}`)
+	assert.Equal(t, float64(1), response["total"])
+	_, response = doRequest("GET", "/api/collections/fruits", "")
+	assert.Equal(t, float64(1), response["count"])
+	code, _ = doRequest("PUT", "/api/collections/fruits/documents/a", `{"title":"apricot"}`)
+	assert.Equal(t, http.StatusNotFound, code)
+	_, response = doRequest("GET", "/api/collections/fruits/stats", "")
+	assert.Equal(t, float64(1), response["document_count"])
+	assert.Equal(t, float64(2), response["trashed"])
+
+	req, _ := http.NewRequest("GET", "/api/collections/fruits/export", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 1, strings.Count(strings.TrimSpace(w.Body.String()), "\n")+1)
+	assert.Contains(t, w.Body.String(), "cherry")
+
+	code, response = doRequest("GET", "/api/collections/fruits/trash", "")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(2), response["total"])
+	trashed := response["documents"].([]interface{})
+	require.Len(t, trashed, 2)
+	assert.NotEmpty(t, trashed[0].(map[string]interface{})["deleted_at"])
+
+	// 恢复后重新可见
+	code, _ = doRequest("POST", "/api/collections/fruits/trash/a/restore", "")
+	require.Equal(t, http.StatusOK, code)
+	code, response = doRequest("GET", "/api/collections/fruits/documents/a", "")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "apple", response["data"].(map[string]interface{})["title"])
+	code, _ = doRequest("POST", "/api/collections/fruits/trash/a/restore", "")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	// 以回收站中文档的 ID 创建新文档会取代它
+	code, _ = doRequest("POST", "/api/collections/fruits/documents", `{"id":"b","title":"blueberry"}`)
+	require.Equal(t, http.StatusCreated, code)
+	_, response = doRequest("GET", "/api/collections/fruits/documents/b", "")
+	assert.Equal(t, "blueberry", response["data"].(map[string]interface{})["title"])
+	_, response = doRequest("GET", "/api/collections/fruits/trash", "")
+	assert.Equal(t, float64(0), response["total"])
+
+	// 永久删除单个文档和清空回收站
+	doRequest("DELETE", "/api/collections/fruits/documents/a", "")
+	doRequest("DELETE", "/api/collections/fruits/documents/b", "")
+	code, _ = doRequest("DELETE", "/api/collections/fruits/trash/a", "")
+	require.Equal(t, http.StatusOK, code)
+	code, _ = doRequest("DELETE", "/api/collections/fruits/trash/a", "")
+	assert.Equal(t, http.StatusNotFound, code)
+	code, response = doRequest("DELETE", "/api/collections/fruits/trash", "")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(1), response["purged"])
+
+	var count int
+	require.NoError(t, sqlDB.QueryRow(`SELECT COUNT(*) FROM documents WHERE collection_name = 'fruits'`).Scan(&count))
+	assert.Equal(t, 1, count)
+}
+
+func TestAttachments(t *testing.T) {
+	_, _, cleanup := setupTestDB(t)
+	defer cleanup()
+	t.Setenv("BLOB_DIR", t.TempDir())
+
+	r := setupRouter()
+	doRequest := func(method, url, contentType, body string, headers ...string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	blobCount := func() int {
+		count := 0
+		filepath.Walk(blobDir(), func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				count++
+			}
+			return nil
+		})
+		return count
+	}
+
+	for _, id := range []string{"a", "b"} {
+		w := doRequest("POST", "/api/collections/files/documents", "application/json", fmt.Sprintf(`{"id":%q}`, id))
+		require.Equal(t, http.StatusCreated, w.Code)
+	}
+
+	// 上传、列出和下载
+	w := doRequest("PUT", "/api/collections/files/documents/a/attachments/note.txt", "text/plain", "hello attachment")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var attachment Attachment
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &attachment))
+	assert.Equal(t, "note.txt", attachment.Name)
+	assert.Equal(t, "text/plain", attachment.ContentType)
+	assert.Equal(t, int64(16), attachment.Size)
+	assert.Len(t, attachment.SHA256, 64)
+
+	w = doRequest("GET", "/api/collections/files/documents/a/attachments", "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Attachments []Attachment `json:"attachments"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Attachments, 1)
+
+	w = doRequest("GET", "/api/collections/files/documents/a/attachments/note.txt", "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "hello attachment", w.Body.String())
+	assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+	w = doRequest("GET", "/api/collections/files/documents/a/attachments/note.txt", "", "", "Range", "bytes=6-15")
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "attachment", w.Body.String())
+	w = doRequest("GET", "/api/collections/files/documents/a/attachments/missing.txt", "", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doRequest("PUT", "/api/collections/files/documents/missing/attachments/note.txt", "text/plain", "x")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// 相同内容只存一份，覆盖后旧内容被清理
+	w = doRequest("PUT", "/api/collections/files/documents/b/attachments/copy.txt", "text/plain", "hello attachment")
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, 1, blobCount())
+	w = doRequest("PUT", "/api/collections/files/documents/b/attachments/other.bin", "", "other")
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, 2, blobCount())
+	w = doRequest("PUT", "/api/collections/files/documents/b/attachments/other.bin", "", "replaced")
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, 2, blobCount())
+	w = doRequest("DELETE", "/api/collections/files/documents/b/attachments/other.bin", "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, blobCount())
+
+	// 回收站中文档的附件不可访问，永久删除后附件和 blob 被清理
+	w = doRequest("DELETE", "/api/collections/files/documents/a", "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	w = doRequest("GET", "/api/collections/files/documents/a/attachments/note.txt", "", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doRequest("DELETE", "/api/collections/files/trash", "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, blobCount(), "blob still referenced by b/copy.txt")
+
+	w = doRequest("DELETE", "/api/collections/files/documents/b?permanent=true", "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 0, blobCount())
+	var count int
+	require.NoError(t, sqlDB.QueryRow(`SELECT COUNT(*) FROM attachments`).Scan(&count))
+	assert.Equal(t, 0, count)
+}
+
+func TestRequestLimits(t *testing.T) {
+	_, _, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	original := requestLimits
+	t.Cleanup(func() { requestLimits = original })
+	doRequest := func(r *gin.Engine, method, url, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// 按客户端限流，超出后返回 429 和 Retry-After
+	requestLimits = RequestLimits{RateLimit: 0.001, RateBurst: 2}
+	r := setupRouter()
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, http.StatusOK, doRequest(r, "GET", "/api/collections", "").Code)
+	}
+	w := doRequest(r, "GET", "/api/collections", "")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	req, _ := http.NewRequest("GET", "/api/collections", nil)
+	req.RemoteAddr = "10.0.0.2:1234"
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code, "other clients have their own bucket")
+
+	// 请求体超过上限时返回 413，流式路由不受限制
+	requestLimits = RequestLimits{MaxBodySize: 64}
+	r = setupRouter()
+	large := fmt.Sprintf(`{"id":"a","text":%q}`, strings.Repeat("x", 100))
+	w = doRequest(r, "POST", "/api/collections/limits/documents", large)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	w = doRequest(r, "POST", "/api/collections/limits/documents:batch", large)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// 查询超时返回 504
+	requestLimits = RequestLimits{QueryTimeout: time.Nanosecond}
+	r = setupRouter()
+	w = doRequest(r, "POST", "/api/collections/limits/query", `{"selector": {}}`)
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code, w.Body.String())
+}
+
+func TestLoadRequestLimits(t *testing.T) {
+	limits, err := loadRequestLimits()
+	require.NoError(t, err)
+	assert.Equal(t, RequestLimits{MaxBodySize: defaultMaxBodySize, QueryTimeout: defaultQueryTimeout}, limits)
+
+	t.Setenv("RATE_LIMIT", "2.5")
+	t.Setenv("MAX_BODY_SIZE", "0")
+	t.Setenv("QUERY_TIMEOUT", "5s")
+	limits, err = loadRequestLimits()
+	require.NoError(t, err)
+	assert.Equal(t, RequestLimits{RateLimit: 2.5, RateBurst: 3, QueryTimeout: 5 * time.Second}, limits)
+
+	t.Setenv("QUERY_TIMEOUT", "soon")
+	_, err = loadRequestLimits()
+	assert.Error(t, err)
+}
+
+var updateGolden = flag.Bool("update", false, "重新生成 openapi.json 和前端的 api.gen.ts")
+
+func TestOpenAPIRoutes(t *testing.T) {
+	documented := make(map[string]bool)
+	for _, op := range apiOperations {
+		key := op.Method + " /api" + op.Path
+		assert.False(t, documented[key], "duplicate operation %s", key)
+		documented[key] = true
+	}
+
+	routes := make(map[string]bool)
+	for _, route := range setupRouter().Routes() {
+		key := route.Method + " " + route.Path
+		routes[key] = true
+		assert.True(t, documented[key], "route %s is missing from apiOperations", key)
+	}
+	for key := range documented {
+		assert.True(t, routes[key], "apiOperations documents unknown route %s", key)
+	}
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	spec, err := json.MarshalIndent(buildOpenAPISpec(), "", "  ")
+	require.NoError(t, err)
+	spec = append(spec, '\n')
+	types := []byte(renderTypeScript(buildOpenAPISpec()))
+
+	golden := map[string][]byte{
+		"openapi.json":            spec,
+		"../src/utils/api.gen.ts": types,
+	}
+	for file, content := range golden {
+		if *updateGolden {
+			require.NoError(t, os.WriteFile(file, content, 0o644))
+			continue
+		}
+		expected, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), string(content), "%s is out of date, run go test -run TestOpenAPISpec -update", file)
+	}
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(spec, &doc))
+	paths := doc["paths"].(map[string]interface{})
+	batch := paths["/collections/{name}/documents:batch"].(map[string]interface{})["post"].(map[string]interface{})
+	assert.Equal(t, "ImportDocuments", batch["operationId"])
+	assert.Equal(t, ScopeWrite, batch["x-required-scope"])
+	schemas := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	assert.Contains(t, schemas, "CollectionStats")
+	assert.Contains(t, schemas, "StorageStats")
+	// 嵌入的结构体字段展开到外层
+	create := schemas["CreateCollectionRequest"].(map[string]interface{})["properties"].(map[string]interface{})
+	assert.Contains(t, create, "embed_fields")
+
+	// 接口返回的文档与生成的一致
+	_, _, cleanup := setupTestDB(t)
+	defer cleanup()
+	req, _ := http.NewRequest("GET", "/api/openapi.json", nil)
+	w := httptest.NewRecorder()
+	setupRouter().ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, string(spec), w.Body.String())
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	_, _, cleanup := setupTestDB(t)
+	defer cleanup()
+	fake := useFakeEmbedder(t)
+	resetEmbedderProbe()
+	t.Cleanup(resetEmbedderProbe)
+
+	// 健康检查挂在根路径下，不经过 /api 的鉴权
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	registerHealthRoutes(r)
+	get := func(path string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return w, body
+	}
+
+	w, body := get("/healthz")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, HealthStatusOK, body["status"])
+
+	w, body = get("/readyz")
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	checks := body["checks"].(map[string]interface{})
+	assert.Equal(t, HealthStatusOK, checks["database"].(map[string]interface{})["status"])
+	assert.Equal(t, HealthStatusOK, checks["graph"].(map[string]interface{})["status"])
+	assert.Equal(t, HealthStatusOK, checks["embedder"].(map[string]interface{})["status"])
+	assert.Contains(t, body["extensions"], "fts")
+	assert.Contains(t, body["extensions"], "vss")
+	assert.Equal(t, 1, fake.calls)
+
+	// embedding 服务的探测结果会被缓存
+	get("/readyz")
+	assert.Equal(t, 1, fake.calls)
+
+	// embedding 服务不可用时仍然就绪，但状态为 degraded
+	fake.err = fmt.Errorf("connection refused")
+	resetEmbedderProbe()
+	w, body = get("/readyz")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, HealthStatusDegraded, body["status"])
+
+	// 数据库不可用时返回 503
+	sqlDB.Close()
+	w, body = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, HealthStatusDown, body["status"])
+}
+
+func TestAuditLog(t *testing.T) {
+	_, _, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	auditEnabled = true
+	t.Cleanup(func() { auditEnabled = false })
+	require.NoError(t, ensureAuditTable(sqlDB))
+
+	r := setupRouter()
+	doRequest := func(method, url, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(requestIDHeader, "req-"+method)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := doRequest("POST", "/api/collections/audited/documents", `{"id":"a1","title":"v1"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, "req-POST", w.Header().Get(requestIDHeader))
+	w = doRequest("PUT", "/api/collections/audited/documents/a1", `{"title":"v2"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doRequest("DELETE", "/api/collections/audited/documents/a1", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = doRequest("POST", "/api/collections/audited/documents:batch", `[{"id":"b1"},{"id":"b2"}]`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	// 读请求不记录
+	doRequest("GET", "/api/collections/audited/documents", "")
+
+	type auditRow struct {
+		requestID, method, documentID string
+		status                        int
+		before, after                 sql.NullString
+	}
+	rows, err := sqlDB.Query(`SELECT request_id, method, status, COALESCE(document_id, ''), before_data, after_data FROM audit_log ORDER BY id`)
+	require.NoError(t, err)
+	defer rows.Close()
+	var records []auditRow
+	for rows.Next() {
+		var row auditRow
+		require.NoError(t, rows.Scan(&row.requestID, &row.method, &row.status, &row.documentID, &row.before, &row.after))
+		records = append(records, row)
+	}
+	require.Len(t, records, 5)
+
+	// 新建：没有修改前的快照
+	assert.Equal(t, "req-POST", records[0].requestID)
+	assert.Equal(t, "a1", records[0].documentID)
+	assert.False(t, records[0].before.Valid)
+	assert.Contains(t, records[0].after.String, `"v1"`)
+
+	// 更新：修改前后的快照
+	assert.Equal(t, "PUT", records[1].method)
+	assert.Contains(t, records[1].before.String, `"v1"`)
+	assert.Contains(t, records[1].after.String, `"v2"`)
+
+	// 移入回收站：修改后的快照带删除时间
+	assert.Equal(t, "DELETE", records[2].method)
+	assert.NotContains(t, records[2].before.String, "_deleted_at")
+	assert.Contains(t, records[2].after.String, "_deleted_at")
+
+	// 批量导入：每个文档一行
+	assert.Equal(t, "b1", records[3].documentID)
+	assert.Equal(t, "b2", records[4].documentID)
+	assert.True(t, records[4].after.Valid)
+}
+
+// TestSearchCache 测试搜索结果缓存的命中、规范化的键以及写入后的失效
+func TestSearchCache(t *testing.T) {
+	_, _, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	searchCache = newResponseCache(10, 0)
+	t.Cleanup(func() { searchCache = nil })
+
+	_, err := sqlDB.Exec(`DROP TABLE documents; CREATE TABLE documents (
+		id VARCHAR(255) PRIMARY KEY,
+		collection_name VARCHAR(255) NOT NULL,
+		data TEXT,
+		embedding FLOAT[3],
+		content TEXT
+	)`)
+	require.NoError(t, err)
+	for id, vec := range map[string]string{"a": "[1, 0, 0]", "b": "[0.9, 0.1, 0]"} {
+		_, err := sqlDB.Exec(`INSERT INTO documents (id, collection_name, data, embedding) VALUES (?, 'test_collection', '{}', ?::FLOAT[3])`, id, vec)
+		require.NoError(t, err)
+	}
+
+	r := setupRouter()
+	search := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/collections/test_collection/vector/search", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := search(`{"query": [1, 0, 0], "limit": 5}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "MISS", w.Header().Get(searchCacheHeader))
+	first := w.Body.String()
+
+	// 字段顺序不同的相同请求命中缓存；绕过 API 的写入不会使缓存失效
+	_, err = sqlDB.Exec(`DELETE FROM documents WHERE id = 'b'`)
+	require.NoError(t, err)
+	w = search(`{"limit": 5, "query": [1, 0, 0]}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "HIT", w.Header().Get(searchCacheHeader))
+	assert.Equal(t, first, w.Body.String())
+
+	// 其他集合的写入不影响，本集合的写入使缓存失效
+	req, _ := http.NewRequest("POST", "/api/collections/other/documents", bytes.NewBufferString(`{"id": "x"}`))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "HIT", search(`{"query": [1, 0, 0], "limit": 5}`).Header().Get(searchCacheHeader))
+
+	req, _ = http.NewRequest("DELETE", "/api/collections/test_collection/documents/a", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = search(`{"query": [1, 0, 0], "limit": 5}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "MISS", w.Header().Get(searchCacheHeader))
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Empty(t, resultIDs(response))
+
+	// 失败的请求不缓存
+	assert.Equal(t, http.StatusBadRequest, search(`{"query": [1, 0]}`).Code)
+	assert.Equal(t, "MISS", search(`{"query": [1, 0]}`).Header().Get(searchCacheHeader))
+
+	// 查询文本忽略大小写和多余的空白
+	key1, _ := searchCacheKey("route", "c", []byte(`{"query": " Smart  Phone ", "limit": 1}`))
+	key2, _ := searchCacheKey("route", "c", []byte(`{"limit": 1, "query": "smart phone"}`))
+	assert.Equal(t, key1, key2)
+}
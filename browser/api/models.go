@@ -26,14 +26,47 @@ type DatabaseConfig struct {
 
 // CollectionInfo 集合信息
 type CollectionInfo struct {
-	Name   string                 `json:"name"`
-	Schema map[string]interface{} `json:"schema"`
+	Name     string                 `json:"name"`
+	Schema   map[string]interface{} `json:"schema"`
+	Settings *CollectionSettings    `json:"settings,omitempty"`
+}
+
+// CollectionSettings 集合注册表中保存的配置
+type CollectionSettings struct {
+	EmbedFields     []string `json:"embed_fields,omitempty"`     // 服务端生成 embedding 使用的字段
+	FTSFields       []string `json:"fts_fields,omitempty"`       // 写入全文检索 content 列的字段，为空时索引所有字段
+	VectorDimension int      `json:"vector_dimension,omitempty"` // embedding 维度，为 0 时不校验
+}
+
+// CreateCollectionRequest 创建集合请求
+type CreateCollectionRequest struct {
+	Name string `json:"name"`
+	CollectionSettings
+}
+
+// UpdateCollectionRequest 修改集合请求，未出现的字段保持不变
+type UpdateCollectionRequest struct {
+	Name            *string   `json:"name,omitempty"` // 新名称，用于重命名
+	EmbedFields     *[]string `json:"embed_fields,omitempty"`
+	FTSFields       *[]string `json:"fts_fields,omitempty"`
+	VectorDimension *int      `json:"vector_dimension,omitempty"`
 }
 
 // DocumentResponse 文档响应
 type DocumentResponse struct {
-	ID   string                 `json:"id"`
-	Data map[string]interface{} `json:"data"`
+	ID        string                 `json:"id"`
+	Data      map[string]interface{} `json:"data"`
+	Warnings  []string               `json:"warnings,omitempty"`   // 不影响写入的问题，如服务端 embedding 生成失败
+	DeletedAt *time.Time             `json:"deleted_at,omitempty"` // 回收站中的文档被删除的时间
+}
+
+// Attachment 文档附件的元数据，内容按 SHA-256 存放在 blob 目录中
+type Attachment struct {
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 // FulltextSearchRequest 全文搜索请求
@@ -44,16 +77,128 @@ type FulltextSearchRequest struct {
 	Threshold  float64 `json:"threshold"`
 }
 
+// AnalyzeResponse 分词预览结果，与写入 content_tokens 和全文搜索查询时使用的分词方式一致
+type AnalyzeResponse struct {
+	Text          string   `json:"text"`
+	Normalized    string   `json:"normalized"`     // 小写化、全角转半角、繁体转简体后的文本
+	Tokens        []string `json:"tokens"`         // sego 分词结果（含停用词和标点）
+	Stopwords     []string `json:"stopwords"`      // 被过滤掉的停用词
+	IndexedTokens []string `json:"indexed_tokens"` // 过滤停用词和标点后保留的词
+	ContentTokens string   `json:"content_tokens"` // 写入 content_tokens 列的值
+}
+
 // VectorSearchRequest 向量搜索请求
 type VectorSearchRequest struct {
 	Collection string    `json:"collection,omitempty"`
 	Query      []float64 `json:"query,omitempty"`
 	QueryText  string    `json:"query_text,omitempty"`
 	Limit      int       `json:"limit,omitempty"`
+	Offset     int       `json:"offset,omitempty"` // 分页偏移，与 limit 配合使用
 	Field      string    `json:"field,omitempty"`
 	Threshold  float64   `json:"threshold,omitempty"`
 }
 
+// VectorSearchQuery 批量向量搜索中的一个查询，query 和 query_text 二选一
+type VectorSearchQuery struct {
+	Query     []float64 `json:"query,omitempty"`
+	QueryText string    `json:"query_text,omitempty"`
+}
+
+// VectorMultiSearchRequest 批量向量搜索请求，所有查询共享 limit 和 threshold
+type VectorMultiSearchRequest struct {
+	Queries   []VectorSearchQuery `json:"queries"`
+	Limit     int                 `json:"limit,omitempty"`
+	Threshold float64             `json:"threshold,omitempty"`
+}
+
+// HybridSearchRequest 混合检索请求
+type HybridSearchRequest struct {
+	Query         string                 `json:"query,omitempty"`          // 关键词检索文本；未提供 vector 时也用于生成 embedding
+	Vector        []float64              `json:"vector,omitempty"`         // 查询向量
+	Limit         int                    `json:"limit,omitempty"`          // 返回条数，默认 10
+	Fusion        string                 `json:"fusion,omitempty"`         // 融合方式：rrf（默认）或 weighted
+	RRFK          int                    `json:"rrf_k,omitempty"`          // RRF 平滑常数，默认 60
+	KeywordWeight *float64               `json:"keyword_weight,omitempty"` // 关键词检索权重，默认 1
+	VectorWeight  *float64               `json:"vector_weight,omitempty"`  // 向量检索权重，默认 1
+	Candidates    int                    `json:"candidates,omitempty"`     // 每一路检索的候选数，默认 max(limit*5, 50)
+	Filter        map[string]interface{} `json:"filter,omitempty"`         // 预过滤条件，语法同 /query 的 selector
+}
+
+// QueryRequest 文档查询请求（Mango 风格）
+type QueryRequest struct {
+	Selector map[string]interface{} `json:"selector"`         // 查询条件，如 {"price": {"$gt": 10}, "tags": "go"}
+	Fields   []string               `json:"fields,omitempty"` // 返回的字段，为空时返回完整文档
+	Sort     []interface{}          `json:"sort,omitempty"`   // 排序，如 ["-price"] 或 [{"price": "desc"}]
+	Limit    int                    `json:"limit,omitempty"`
+	Skip     int                    `json:"skip,omitempty"`
+}
+
+// ReembedRequest 重新生成 embedding 的请求
+type ReembedRequest struct {
+	OnlyMissing *bool `json:"only_missing,omitempty"` // 只处理没有 embedding 的文档，默认 true
+	BatchSize   int   `json:"batch_size,omitempty"`   // 每次向量化请求的文档数，默认 10
+}
+
+// CollectionStats 集合统计和索引状态
+type CollectionStats struct {
+	Name          string           `json:"name"`
+	Registered    bool             `json:"registered"`
+	DocumentCount int64            `json:"document_count"`
+	Trashed       int64            `json:"trashed"`    // 回收站中的文档数，不计入其他统计
+	Embedded      int64            `json:"embedded"`   // 有 embedding 的文档数
+	Unembedded    int64            `json:"unembedded"` // 没有 embedding 的文档数
+	LastUpdatedAt *time.Time       `json:"last_updated_at,omitempty"`
+	Storage       StorageStats     `json:"storage"`
+	FTS           FTSIndexStats    `json:"fts"`
+	VectorIndex   VectorIndexStats `json:"vector_index"`
+}
+
+// StorageStats 集合占用的存储空间（字节，按字段内容估算，不含索引和数据库页开销）
+type StorageStats struct {
+	DataBytes      int64 `json:"data_bytes"`
+	ContentBytes   int64 `json:"content_bytes"`
+	EmbeddingBytes int64 `json:"embedding_bytes"`
+	TotalBytes     int64 `json:"total_bytes"`
+}
+
+// FTSIndexStats 全文索引状态
+type FTSIndexStats struct {
+	Present bool       `json:"present"`
+	Fresh   bool       `json:"fresh"`   // 集合中所有文档都已索引且索引构建后没有修改
+	Indexed int64      `json:"indexed"` // 已在索引中的文档数
+	Missing int64      `json:"missing"` // 索引构建后新增、尚未索引的文档数
+	Stale   int64      `json:"stale"`   // 已索引但在索引构建后被修改的文档数
+	BuiltAt *time.Time `json:"built_at,omitempty"`
+}
+
+// VectorIndexStats 向量索引状态（HNSW 索引建在 documents 表上，所有集合共用）
+type VectorIndexStats struct {
+	Present    bool   `json:"present"`
+	Name       string `json:"name,omitempty"`
+	ColumnType string `json:"column_type,omitempty"`
+	Dimension  int    `json:"dimension,omitempty"`
+}
+
+// RestoreRequest 从备份目录中已有的备份恢复数据的请求
+type RestoreRequest struct {
+	Backup string `json:"backup" binding:"required"` // 备份文件名，如 backup-20240101T000000Z-xxx.tar.gz
+}
+
+// BatchImportError 批量导入中单条文档的错误
+type BatchImportError struct {
+	Index int    `json:"index"` // 文档在请求体中的序号（从 0 开始）
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error"`
+}
+
+// BatchImportResponse 批量导入响应
+type BatchImportResponse struct {
+	Inserted int                `json:"inserted"`
+	Skipped  int                `json:"skipped"` // on_conflict=skip 时因 ID 冲突跳过的文档数
+	Failed   int                `json:"failed"`
+	Errors   []BatchImportError `json:"errors"`
+}
+
 // ErrorResponse 错误响应
 type ErrorResponse struct {
 	Error string `json:"error"`
@@ -75,6 +220,40 @@ type GraphPathRequest struct {
 }
 
 // GraphQueryRequest 图查询请求
+// query 和 traversal 二选一：query 为遍历语言字符串，traversal 为解析后的 JSON 形式
 type GraphQueryRequest struct {
-	Query string `json:"query" binding:"required"`
+	Query     string          `json:"query,omitempty"`     // 如 V('a').Out('knows').Has('type', 'person').Limit(10)
+	Traversal *GraphTraversal `json:"traversal,omitempty"` // JSON 形式的遍历
+	Limit     int             `json:"limit,omitempty"`     // 每页条数，默认 100，最大 1000
+	Offset    int             `json:"offset,omitempty"`    // 分页偏移，不能与 cursor 同时使用
+	Cursor    string          `json:"cursor,omitempty"`    // 上一页响应中的 next_cursor
+	Sort      string          `json:"sort,omitempty"`      // 排序字段：subject（默认）、predicate 或 object
+	Order     string          `json:"order,omitempty"`     // asc（默认）或 desc
+}
+
+// GraphNodeRequest 创建或更新图节点请求
+type GraphNodeRequest struct {
+	ID         string                 `json:"id" binding:"required"`
+	Label      string                 `json:"label,omitempty"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+// GraphNode 图节点及其属性；只出现在边中、没有登记属性的节点 Properties 为空
+type GraphNode struct {
+	ID         string                 `json:"id"`
+	Label      string                 `json:"label,omitempty"`
+	Properties map[string]interface{} `json:"properties"`
+	Depth      int                    `json:"depth"` // 子图中与起点的距离
+}
+
+// GraphEdge 图中的一条边
+type GraphEdge struct {
+	From     string `json:"from"`
+	Relation string `json:"relation"`
+	To       string `json:"to"`
+}
+
+// GraphBulkLinkRequest 批量创建边请求
+type GraphBulkLinkRequest struct {
+	Links []GraphLinkRequest `json:"links" binding:"required"`
 }
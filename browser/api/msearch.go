@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// maxVectorMultiSearchQueries 单次批量向量搜索最多包含的查询数
+const maxVectorMultiSearchQueries = 100
+
+// vectorMultiSearch 批量向量搜索：一次请求包含多个查询向量或查询文本，共享 limit 和 threshold
+// 查询文本合并成批生成 embedding，每个查询的结果按请求中的顺序返回
+func vectorMultiSearch(c *gin.Context) {
+	start := time.Now()
+	name := c.Param("name")
+
+	var req VectorMultiSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("Invalid request format: %v", err)})
+		return
+	}
+	if len(req.Queries) == 0 {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: "queries must not be empty"})
+		return
+	}
+	if len(req.Queries) > maxVectorMultiSearchQueries {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("too many queries: %d (max %d)", len(req.Queries), maxVectorMultiSearchQueries)})
+		return
+	}
+	if req.Limit <= 0 {
+		req.Limit = 10
+	}
+
+	vectors, err := multiSearchVectors(req.Queries)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	hasEmbedding, err := columnExists(sqlDB, "documents", "embedding")
+	if err != nil || !hasEmbedding {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "embedding column does not exist"})
+		return
+	}
+	colType, err := getColumnType(sqlDB, "documents", "embedding")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: fmt.Sprintf("Failed to get embedding column type: %v", err)})
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"collection": name,
+		"queries":    len(req.Queries),
+		"limit":      req.Limit,
+	}).Info("Vector multi-search request")
+
+	responses := make([]gin.H, len(vectors))
+	for i, vector := range vectors {
+		distanceExpr, err := vectorDistanceExpr(colType, len(vector))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("queries[%d]: %v", i, err)})
+			return
+		}
+		results, hasMore, err := searchVectorMatches(c.Request.Context(), name, distanceExpr, vector, 0, req.Limit, req.Threshold)
+		if err != nil {
+			logrus.WithError(err).WithField("index", i).Error("Vector multi-search query failed")
+			c.JSON(queryErrorStatus(c), ErrorResponse{Error: fmt.Sprintf("向量搜索失败: %v", err)})
+			return
+		}
+		responses[i] = gin.H{
+			"index":    i,
+			"query":    req.Queries[i].QueryText,
+			"results":  results,
+			"has_more": hasMore,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"responses": responses,
+		"limit":     req.Limit,
+		"took":      time.Since(start).Milliseconds(),
+	})
+}
+
+// multiSearchVectors 返回每个查询的向量，查询文本按 embedding 服务的批量上限分批生成
+func multiSearchVectors(queries []VectorSearchQuery) ([][]float64, error) {
+	vectors := make([][]float64, len(queries))
+	var textIndexes []int
+	var texts []string
+	for i, q := range queries {
+		switch {
+		case len(q.Query) > 0 && q.QueryText != "":
+			return nil, fmt.Errorf("queries[%d]: 'query' and 'query_text' cannot be used together", i)
+		case len(q.Query) > 0:
+			vectors[i] = q.Query
+		case q.QueryText != "":
+			textIndexes = append(textIndexes, i)
+			texts = append(texts, q.QueryText)
+		default:
+			return nil, fmt.Errorf("queries[%d]: either 'query' (vector) or 'query_text' (text) must be provided", i)
+		}
+	}
+	if len(texts) == 0 {
+		return vectors, nil
+	}
+
+	e, err := currentEmbedder()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate embedding from text: %w", err)
+	}
+	for start := 0; start < len(texts); start += defaultReembedBatchSize {
+		end := min(start+defaultReembedBatchSize, len(texts))
+		ctx, cancel := context.WithTimeout(context.Background(), embedRequestTimeout)
+		batch, err := e.Embed(ctx, texts[start:end])
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate embedding from text: %w", err)
+		}
+		if len(batch) != end-start {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", end-start, len(batch))
+		}
+		for j, vector := range batch {
+			vectors[textIndexes[start+j]] = vector
+		}
+	}
+	return vectors, nil
+}
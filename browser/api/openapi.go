@@ -0,0 +1,497 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// openAPIVersion API 文档的版本号，接口有不兼容变更时递增
+const openAPIVersion = "1.0.0"
+
+// jsonSchema OpenAPI 中的 schema 片段；字段值可以是 Go 类型的值，生成文档时转换为 schema
+type jsonSchema map[string]interface{}
+
+var (
+	stringSchema  = jsonSchema{"type": "string"}
+	integerSchema = jsonSchema{"type": "integer"}
+	numberSchema  = jsonSchema{"type": "number"}
+	booleanSchema = jsonSchema{"type": "boolean"}
+	binarySchema  = jsonSchema{"type": "string", "format": "binary"}
+	anyObject     = jsonSchema{"type": "object", "additionalProperties": true}
+)
+
+// object 按 name, schema 成对的参数构造对象 schema
+func object(props ...interface{}) jsonSchema {
+	properties := jsonSchema{}
+	for i := 0; i+1 < len(props); i += 2 {
+		properties[props[i].(string)] = props[i+1]
+	}
+	return jsonSchema{"type": "object", "properties": properties}
+}
+
+// arrayOf 构造数组 schema
+func arrayOf(item interface{}) jsonSchema {
+	return jsonSchema{"type": "array", "items": item}
+}
+
+// apiParam 查询参数
+type apiParam struct {
+	Name        string
+	Schema      jsonSchema
+	Description string
+}
+
+// apiOperation 一个路由的 OpenAPI 描述
+type apiOperation struct {
+	Method       string
+	Path         string // gin 路由模板，不含 /api 前缀
+	DocPath      string // 文档中的路径，为空时由 Path 转换（:name -> {name}）
+	ID           string // operationId，pkg/browserclient 中对应的方法使用相同的名称
+	Tag          string
+	Summary      string
+	Query        []apiParam
+	Body         interface{} // 请求体：Go 类型的值或 jsonSchema
+	BodyType     string      // 请求体的 Content-Type，默认 application/json
+	Status       int         // 成功时的状态码，默认 200
+	Response     interface{} // 响应体：Go 类型的值或 jsonSchema
+	ResponseType string      // 响应的 Content-Type，默认 application/json
+}
+
+// 常用的响应结构
+var (
+	messageResponse      = object("message", stringSchema)
+	documentListResponse = object("documents", arrayOf(DocumentResponse{}), "total", integerSchema, "skip", integerSchema, "limit", integerSchema)
+	searchResult         = object("document", DocumentResponse{}, "score", numberSchema)
+	graphLinkResponse    = object("message", stringSchema, "from", stringSchema, "relation", stringSchema, "to", stringSchema)
+	paginationParams     = []apiParam{
+		{"skip", integerSchema, "跳过的文档数，默认 0"},
+		{"limit", integerSchema, "返回的文档数，默认 100"},
+	}
+	graphPageParams = []apiParam{
+		{"limit", integerSchema, "每页条数，默认 100，最大 1000"},
+		{"offset", integerSchema, "分页偏移，不能与 cursor 同时使用"},
+		{"cursor", stringSchema, "上一页响应中的 next_cursor"},
+		{"order", stringSchema, "asc（默认）或 desc"},
+	}
+)
+
+// apiOperations 所有 API 路由的描述，新增路由时需要同步添加（TestOpenAPIRoutes 会检查）
+var apiOperations = []apiOperation{
+	{Method: "GET", Path: "/openapi.json", ID: "GetOpenAPISpec", Tag: "meta", Summary: "获取 OpenAPI 文档", Response: anyObject},
+	{Method: "GET", Path: "/db/info", ID: "GetDBInfo", Tag: "meta", Summary: "获取数据库信息",
+		Response: object("name", stringSchema, "path", stringSchema)},
+
+	// 集合
+	{Method: "GET", Path: "/db/collections", ID: "ListDBCollections", Tag: "collections", Summary: "列出集合（同 GET /collections）",
+		Response: object("collections", arrayOf(CollectionInfo{}))},
+	{Method: "GET", Path: "/collections", ID: "ListCollections", Tag: "collections", Summary: "列出当前 API Key 可以访问的集合",
+		Response: object("collections", arrayOf(CollectionInfo{}))},
+	{Method: "POST", Path: "/collections", ID: "CreateCollection", Tag: "collections", Summary: "创建集合",
+		Body: CreateCollectionRequest{}, Status: http.StatusCreated, Response: CollectionInfo{}},
+	{Method: "GET", Path: "/collections/:name", ID: "GetCollection", Tag: "collections", Summary: "获取集合信息",
+		Response: object("name", stringSchema, "exists", booleanSchema, "count", integerSchema, "registered", booleanSchema, "settings", CollectionSettings{})},
+	{Method: "PATCH", Path: "/collections/:name", ID: "UpdateCollection", Tag: "collections", Summary: "修改集合配置或重命名集合",
+		Body: UpdateCollectionRequest{}, Response: CollectionInfo{}},
+	{Method: "DELETE", Path: "/collections/:name", ID: "DeleteCollection", Tag: "collections", Summary: "删除集合及其所有文档",
+		Response: object("message", stringSchema, "deleted", integerSchema)},
+	{Method: "GET", Path: "/collections/:name/stats", ID: "GetCollectionStats", Tag: "collections", Summary: "获取集合统计和索引状态",
+		Response: CollectionStats{}},
+
+	// 文档
+	{Method: "GET", Path: "/collections/:name/documents", ID: "ListDocuments", Tag: "documents", Summary: "分页列出文档",
+		Query: append(append([]apiParam{}, paginationParams...), apiParam{"tag", stringSchema, "只返回 tags 包含该值的文档"}), Response: documentListResponse},
+	{Method: "GET", Path: "/collections/:name/documents/:id", ID: "GetDocument", Tag: "documents", Summary: "获取文档",
+		Response: DocumentResponse{}},
+	{Method: "POST", Path: "/collections/:name/documents", ID: "CreateDocument", Tag: "documents", Summary: "创建文档，id 为空时自动生成，embedding 可选",
+		Body: anyObject, Status: http.StatusCreated, Response: DocumentResponse{}},
+	{Method: "PUT", Path: "/collections/:name/documents/:id", ID: "UpdateDocument", Tag: "documents", Summary: "更新文档，请求中的字段合并到现有文档",
+		Body: anyObject, Response: DocumentResponse{}},
+	{Method: "DELETE", Path: "/collections/:name/documents/:id", ID: "DeleteDocument", Tag: "documents", Summary: "删除文档（默认移入回收站）",
+		Query: []apiParam{{"permanent", booleanSchema, "为 true 时永久删除"}}, Response: messageResponse},
+	{Method: "POST", Path: "/collections/:name/query", ID: "QueryDocuments", Tag: "documents", Summary: "按 Mango 风格的 selector 查询文档",
+		Body: QueryRequest{}, Response: object("documents", arrayOf(DocumentResponse{}), "total", integerSchema, "skip", integerSchema, "limit", integerSchema, "took", integerSchema)},
+
+	// 附件
+	{Method: "GET", Path: "/collections/:name/documents/:id/attachments", ID: "ListAttachments", Tag: "attachments", Summary: "列出文档的附件",
+		Response: object("attachments", arrayOf(Attachment{}))},
+	{Method: "PUT", Path: "/collections/:name/documents/:id/attachments/:attachment", ID: "UploadAttachment", Tag: "attachments", Summary: "上传附件，同名附件会被覆盖",
+		Body: binarySchema, BodyType: "application/octet-stream", Status: http.StatusCreated, Response: Attachment{}},
+	{Method: "GET", Path: "/collections/:name/documents/:id/attachments/:attachment", ID: "DownloadAttachment", Tag: "attachments", Summary: "下载附件，支持 Range",
+		Response: binarySchema, ResponseType: "application/octet-stream"},
+	{Method: "DELETE", Path: "/collections/:name/documents/:id/attachments/:attachment", ID: "DeleteAttachment", Tag: "attachments", Summary: "删除附件",
+		Response: messageResponse},
+
+	// 回收站
+	{Method: "GET", Path: "/collections/:name/trash", ID: "ListTrash", Tag: "trash", Summary: "列出回收站中的文档",
+		Query: paginationParams, Response: documentListResponse},
+	{Method: "DELETE", Path: "/collections/:name/trash", ID: "EmptyTrash", Tag: "trash", Summary: "清空集合的回收站",
+		Response: object("message", stringSchema, "purged", integerSchema)},
+	{Method: "POST", Path: "/collections/:name/trash/:id/restore", ID: "RestoreDocument", Tag: "trash", Summary: "从回收站恢复文档",
+		Response: object("message", stringSchema, "id", stringSchema)},
+	{Method: "DELETE", Path: "/collections/:name/trash/:id", ID: "PurgeDocument", Tag: "trash", Summary: "永久删除回收站中的文档",
+		Response: object("message", stringSchema, "id", stringSchema)},
+
+	// 后台任务
+	{Method: "POST", Path: "/collections/:name/reembed", ID: "ReembedCollection", Tag: "jobs", Summary: "启动后台任务重新生成 embedding",
+		Body: ReembedRequest{}, Status: http.StatusAccepted, Response: Job{}},
+	{Method: "GET", Path: "/jobs/:id", ID: "GetJob", Tag: "jobs", Summary: "查询后台任务状态",
+		Response: Job{}},
+
+	// 批量导入导出
+	{Method: "POST", Path: "/collections/:name/:action", DocPath: "/collections/{name}/documents:batch", ID: "ImportDocuments", Tag: "documents", Summary: "批量导入文档，请求体为 JSON 数组或 NDJSON 流",
+		Query: []apiParam{{"on_conflict", stringSchema, "ID 冲突时的处理方式：error（默认）、skip 或 replace"}},
+		Body:  arrayOf(anyObject), Response: BatchImportResponse{}},
+	{Method: "GET", Path: "/collections/:name/export", ID: "ExportDocuments", Tag: "documents", Summary: "导出集合中的所有文档",
+		Query:    []apiParam{{"format", stringSchema, "ndjson（默认）或 parquet"}},
+		Response: binarySchema, ResponseType: "application/x-ndjson"},
+
+	// 检索
+	{Method: "POST", Path: "/collections/:name/fulltext/search", ID: "FulltextSearch", Tag: "search", Summary: "全文搜索",
+		Body: FulltextSearchRequest{}, Response: object("results", arrayOf(searchResult), "query", stringSchema, "took", integerSchema)},
+	{Method: "GET", Path: "/analyze", ID: "Analyze", Tag: "search", Summary: "预览文本的分词结果",
+		Query: []apiParam{{"text", stringSchema, "要分词的文本"}}, Response: AnalyzeResponse{}},
+	{Method: "POST", Path: "/collections/:name/vector/search", ID: "VectorSearch", Tag: "search", Summary: "向量搜索",
+		Body: VectorSearchRequest{}, Response: object("results", arrayOf(searchResult), "query", stringSchema, "offset", integerSchema, "limit", integerSchema, "has_more", booleanSchema, "took", integerSchema)},
+	{Method: "POST", Path: "/collections/:name/vector/msearch", ID: "VectorMultiSearch", Tag: "search", Summary: "批量向量搜索",
+		Body: VectorMultiSearchRequest{}, Response: object(
+			"responses", arrayOf(object("index", integerSchema, "query", stringSchema, "results", arrayOf(searchResult), "has_more", booleanSchema)),
+			"limit", integerSchema, "took", integerSchema)},
+	{Method: "POST", Path: "/collections/:name/search", ID: "HybridSearch", Tag: "search", Summary: "混合检索（关键词 + 向量）",
+		Body: HybridSearchRequest{}, Response: object(
+			"results", arrayOf(object("document", DocumentResponse{}, "score", numberSchema,
+				"keyword_score", numberSchema, "keyword_rank", integerSchema, "vector_score", numberSchema, "vector_rank", integerSchema)),
+			"query", stringSchema, "fusion", stringSchema, "modes", arrayOf(stringSchema), "warnings", arrayOf(stringSchema), "took", integerSchema)},
+
+	// 图数据库
+	{Method: "POST", Path: "/graph/link", ID: "GraphLink", Tag: "graph", Summary: "创建边",
+		Body: GraphLinkRequest{}, Response: graphLinkResponse},
+	{Method: "DELETE", Path: "/graph/link", ID: "GraphUnlink", Tag: "graph", Summary: "删除边",
+		Body: GraphLinkRequest{}, Response: graphLinkResponse},
+	{Method: "POST", Path: "/graph/links/bulk", ID: "GraphBulkLink", Tag: "graph", Summary: "在一个事务中批量创建边",
+		Body: GraphBulkLinkRequest{}, Response: object("message", stringSchema, "count", integerSchema)},
+	{Method: "GET", Path: "/graph/neighbors/:nodeId", ID: "GraphNeighbors", Tag: "graph", Summary: "分页获取节点的邻居",
+		Query: append([]apiParam{{"relation", stringSchema, "只返回该类型的边"}}, graphPageParams...),
+		Response: object("node_id", stringSchema, "relation", stringSchema, "neighbors", arrayOf(stringSchema), "total", integerSchema,
+			"limit", integerSchema, "offset", integerSchema, "has_more", booleanSchema, "next_cursor", stringSchema)},
+	{Method: "GET", Path: "/graph/subgraph/:nodeId", ID: "GraphSubgraph", Tag: "graph", Summary: "从节点出发按广度优先展开子图",
+		Query: []apiParam{
+			{"depth", integerSchema, "展开的层数"},
+			{"direction", stringSchema, "out、in 或 both（默认）"},
+			{"relation", stringSchema, "只沿该类型的边展开"},
+			{"limit", integerSchema, "最多返回的节点数"},
+		},
+		Response: object("root", stringSchema, "depth", integerSchema, "nodes", arrayOf(GraphNode{}), "edges", arrayOf(GraphEdge{}), "truncated", booleanSchema)},
+	{Method: "POST", Path: "/graph/nodes", ID: "PutGraphNode", Tag: "graph", Summary: "创建或更新图节点",
+		Body: GraphNodeRequest{}, Response: GraphNode{}},
+	{Method: "GET", Path: "/graph/nodes/:id", ID: "GetGraphNode", Tag: "graph", Summary: "获取图节点及其出入度",
+		Response: object("id", stringSchema, "label", stringSchema, "properties", anyObject, "out_degree", integerSchema, "in_degree", integerSchema)},
+	{Method: "DELETE", Path: "/graph/nodes/:id", ID: "DeleteGraphNode", Tag: "graph", Summary: "删除图节点",
+		Query:    []apiParam{{"cascade", booleanSchema, "为 true 时同时删除节点的边"}},
+		Response: object("message", stringSchema, "id", stringSchema, "deleted_edges", integerSchema)},
+	{Method: "POST", Path: "/graph/path", ID: "GraphPath", Tag: "graph", Summary: "查找两个节点之间的路径",
+		Body: GraphPathRequest{}, Response: object("from", stringSchema, "to", stringSchema, "paths", arrayOf(arrayOf(stringSchema)))},
+	{Method: "POST", Path: "/graph/query", ID: "GraphQuery", Tag: "graph", Summary: "执行图遍历查询",
+		Body: GraphQueryRequest{}, Response: object("query", stringSchema, "traversal", GraphTraversal{}, "nodes", arrayOf(stringSchema),
+			"results", arrayOf(object("subject", stringSchema, "predicate", stringSchema, "object", stringSchema)),
+			"total", integerSchema, "limit", integerSchema, "offset", integerSchema, "has_more", booleanSchema, "next_cursor", stringSchema)},
+
+	// 备份与恢复
+	{Method: "POST", Path: "/admin/backup", ID: "CreateBackup", Tag: "admin", Summary: "启动后台备份任务",
+		Status: http.StatusAccepted, Response: Job{}},
+	{Method: "GET", Path: "/admin/backups", ID: "ListBackups", Tag: "admin", Summary: "列出备份文件",
+		Response: object("backups", arrayOf(BackupInfo{}))},
+	{Method: "GET", Path: "/admin/backups/:file", ID: "DownloadBackup", Tag: "admin", Summary: "下载备份文件",
+		Response: binarySchema, ResponseType: "application/gzip"},
+	{Method: "POST", Path: "/admin/restore", ID: "RestoreBackup", Tag: "admin", Summary: "启动后台恢复任务（也可以上传 multipart 字段 file）",
+		Body: RestoreRequest{}, Status: http.StatusAccepted, Response: Job{}},
+}
+
+var (
+	// ginParamPattern 匹配 gin 路由模板中的参数段
+	ginParamPattern = regexp.MustCompile(`:([A-Za-z]+)`)
+	// docParamPattern 匹配文档路径中的参数段
+	docParamPattern = regexp.MustCompile(`\{([A-Za-z]+)\}`)
+)
+
+// docPath 返回文档中的路径
+func (op apiOperation) docPath() string {
+	if op.DocPath != "" {
+		return op.DocPath
+	}
+	return ginParamPattern.ReplaceAllString(op.Path, "{$1}")
+}
+
+// schemaBuilder 将 Go 类型转换为 schema，结构体登记到 components 中
+type schemaBuilder struct {
+	components jsonSchema
+}
+
+// schema 转换 schema 片段，Go 类型的值按类型生成 schema
+func (b *schemaBuilder) schema(v interface{}) interface{} {
+	if v == nil {
+		return jsonSchema{}
+	}
+	s, ok := v.(jsonSchema)
+	if !ok {
+		return b.typeSchema(reflect.TypeOf(v))
+	}
+	out := jsonSchema{}
+	for key, value := range s {
+		switch key {
+		case "properties":
+			properties := jsonSchema{}
+			for name, property := range value.(jsonSchema) {
+				properties[name] = b.schema(property)
+			}
+			out[key] = properties
+		case "items", "additionalProperties":
+			if _, isBool := value.(bool); isBool {
+				out[key] = value
+			} else {
+				out[key] = b.schema(value)
+			}
+		default:
+			out[key] = value
+		}
+	}
+	return out
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// typeSchema 按 Go 类型生成 schema，结构体按 json 标签生成属性并以 $ref 引用
+func (b *schemaBuilder) typeSchema(t reflect.Type) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return jsonSchema{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if _, ok := b.components[t.Name()]; !ok {
+			b.components[t.Name()] = jsonSchema{} // 占位，避免递归类型无限展开
+			properties := jsonSchema{}
+			var required []string
+			b.structFields(t, properties, &required)
+			schema := jsonSchema{"type": "object", "properties": properties}
+			if len(required) > 0 {
+				schema["required"] = required
+			}
+			b.components[t.Name()] = schema
+		}
+		return jsonSchema{"$ref": "#/components/schemas/" + t.Name()}
+	case reflect.Map:
+		if t.Elem().Kind() == reflect.Interface {
+			return anyObject
+		}
+		return jsonSchema{"type": "object", "additionalProperties": b.typeSchema(t.Elem())}
+	case reflect.Slice, reflect.Array:
+		return jsonSchema{"type": "array", "items": b.typeSchema(t.Elem())}
+	case reflect.Interface:
+		return jsonSchema{}
+	case reflect.String:
+		return stringSchema
+	case reflect.Bool:
+		return booleanSchema
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return integerSchema
+	case reflect.Float32, reflect.Float64:
+		return numberSchema
+	}
+	return jsonSchema{}
+}
+
+// structFields 收集结构体的 JSON 字段，匿名嵌入的结构体字段展开到外层
+// 没有 omitempty 的字段总会出现在 JSON 中，标记为 required
+func (b *schemaBuilder) structFields(t reflect.Type, properties jsonSchema, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			b.structFields(field.Type, properties, required)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = b.typeSchema(field.Type)
+		if !strings.Contains(options, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// buildOpenAPISpec 根据 apiOperations 生成 OpenAPI 3 文档
+func buildOpenAPISpec() jsonSchema {
+	b := &schemaBuilder{components: jsonSchema{}}
+	errorResponse := jsonSchema{
+		"description": "错误",
+		"content":     jsonSchema{"application/json": jsonSchema{"schema": b.schema(ErrorResponse{})}},
+	}
+
+	paths := jsonSchema{}
+	for _, op := range apiOperations {
+		path := op.docPath()
+		item, ok := paths[path].(jsonSchema)
+		if !ok {
+			item = jsonSchema{}
+			paths[path] = item
+		}
+
+		var parameters []jsonSchema
+		for _, match := range docParamPattern.FindAllStringSubmatch(path, -1) {
+			parameters = append(parameters, jsonSchema{"name": match[1], "in": "path", "required": true, "schema": stringSchema})
+		}
+		for _, param := range op.Query {
+			parameters = append(parameters, jsonSchema{"name": param.Name, "in": "query", "description": param.Description, "schema": param.Schema})
+		}
+
+		status, responseType := op.Status, op.ResponseType
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if responseType == "" {
+			responseType = "application/json"
+		}
+		operation := jsonSchema{
+			"operationId":      op.ID,
+			"summary":          op.Summary,
+			"tags":             []string{op.Tag},
+			"x-required-scope": requiredScope(op.Method, "/api"+op.Path),
+			"responses": jsonSchema{
+				strconv.Itoa(status): jsonSchema{
+					"description": http.StatusText(status),
+					"content":     jsonSchema{responseType: jsonSchema{"schema": b.schema(op.Response)}},
+				},
+				"default": errorResponse,
+			},
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+		if op.Body != nil {
+			bodyType := op.BodyType
+			if bodyType == "" {
+				bodyType = "application/json"
+			}
+			operation["requestBody"] = jsonSchema{
+				"required": true,
+				"content":  jsonSchema{bodyType: jsonSchema{"schema": b.schema(op.Body)}},
+			}
+		}
+		item[strings.ToLower(op.Method)] = operation
+	}
+
+	return jsonSchema{
+		"openapi": "3.0.3",
+		"info": jsonSchema{
+			"title":       "sqlite-ai-driver browser API",
+			"version":     openAPIVersion,
+			"description": "文档、全文/向量检索、图数据库和备份管理接口",
+		},
+		"servers": []jsonSchema{{"url": "/api"}},
+		"paths":   paths,
+		"components": jsonSchema{
+			"schemas": b.components,
+			"securitySchemes": jsonSchema{
+				"bearerAuth": jsonSchema{"type": "http", "scheme": "bearer"},
+				"apiKey":     jsonSchema{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+			},
+		},
+		"security": []jsonSchema{{"bearerAuth": []string{}}, {"apiKey": []string{}}},
+	}
+}
+
+var (
+	openAPISpecOnce sync.Once
+	openAPISpec     jsonSchema
+)
+
+// getOpenAPISpec 返回 OpenAPI 文档
+func getOpenAPISpec(c *gin.Context) {
+	openAPISpecOnce.Do(func() { openAPISpec = buildOpenAPISpec() })
+	c.JSON(http.StatusOK, openAPISpec)
+}
+
+// renderTypeScript 将文档中的 schema 渲染为 TypeScript 类型声明，供前端使用
+func renderTypeScript(spec jsonSchema) string {
+	schemas := spec["components"].(jsonSchema)["schemas"].(jsonSchema)
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	sb.WriteString("// 由 browser/api 根据 OpenAPI 文档生成，请勿手动修改\n")
+	sb.WriteString("// 重新生成：cd api && go test -run TestOpenAPISpec -update\n")
+	for _, name := range names {
+		fmt.Fprintf(&sb, "\nexport interface %s %s\n", name, tsType(schemas[name], ""))
+	}
+	return sb.String()
+}
+
+// tsType 返回 schema 对应的 TypeScript 类型，indent 为当前缩进
+func tsType(v interface{}, indent string) string {
+	s, _ := v.(jsonSchema)
+	if ref, ok := s["$ref"].(string); ok {
+		return strings.TrimPrefix(ref, "#/components/schemas/")
+	}
+	switch s["type"] {
+	case "string":
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "array":
+		return tsType(s["items"], indent) + "[]"
+	case "object":
+		properties, _ := s["properties"].(jsonSchema)
+		if len(properties) == 0 {
+			if additional, ok := s["additionalProperties"].(jsonSchema); ok {
+				return "Record<string, " + tsType(additional, indent) + ">"
+			}
+			return "Record<string, any>"
+		}
+		required := map[string]bool{}
+		names, _ := s["required"].([]string)
+		for _, name := range names {
+			required[name] = true
+		}
+		keys := make([]string, 0, len(properties))
+		for name := range properties {
+			keys = append(keys, name)
+		}
+		sort.Strings(keys)
+
+		var sb strings.Builder
+		sb.WriteString("{\n")
+		for _, name := range keys {
+			optional := "?"
+			if required[name] {
+				optional = ""
+			}
+			fmt.Fprintf(&sb, "%s  %s%s: %s\n", indent, name, optional, tsType(properties[name], indent+"  "))
+		}
+		sb.WriteString(indent + "}")
+		return sb.String()
+	}
+	return "any"
+}
@@ -0,0 +1,514 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// jsonNumberTypes json_type 对数值返回的类型名
+const jsonNumberTypes = "('BIGINT', 'UBIGINT', 'DOUBLE')"
+
+// defaultQueryLimit 查询未指定 limit 时的默认返回条数
+const defaultQueryLimit = 100
+
+// selectorTypeNames $type 操作符的类型名到 json_type 结果的映射
+var selectorTypeNames = map[string]string{
+	"null":    "= 'NULL'",
+	"boolean": "= 'BOOLEAN'",
+	"number":  "IN " + jsonNumberTypes,
+	"string":  "= 'VARCHAR'",
+	"array":   "= 'ARRAY'",
+	"object":  "= 'OBJECT'",
+}
+
+// selectorCompareOps 比较操作符到 SQL 运算符的映射
+var selectorCompareOps = map[string]string{
+	"$gt":  ">",
+	"$gte": ">=",
+	"$lt":  "<",
+	"$lte": "<=",
+}
+
+// queryDocuments 使用 Mango 风格的 selector 查询文档，支持排序、分页和字段投影
+func queryDocuments(c *gin.Context) {
+	name := c.Param("name")
+
+	var req QueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = defaultQueryLimit
+	}
+	if req.Skip < 0 {
+		req.Skip = 0
+	}
+
+	where, whereArgs, err := compileSelector(req.Selector)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid selector: %v", err)})
+		return
+	}
+	where = liveDocumentsSQL("") + ` AND ` + where
+	orderBy, err := compileSort(req.Sort)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid sort: %v", err)})
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"collection": name,
+		"where":      where,
+		"order_by":   orderBy,
+		"limit":      req.Limit,
+		"skip":       req.Skip,
+	}).Info("🔎 queryDocuments")
+
+	start := time.Now()
+	args := append([]interface{}{name}, whereArgs...)
+
+	var total int64
+	countQuery := `SELECT COUNT(*) FROM documents WHERE collection_name = ? AND ` + where
+	if err := sqlDB.QueryRowContext(c.Request.Context(), countQuery, args...).Scan(&total); err != nil {
+		logrus.WithError(err).Error("❌ Failed to count documents")
+		c.JSON(queryErrorStatus(c), ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	query := `SELECT id, data FROM documents WHERE collection_name = ? AND ` + where +
+		` ORDER BY ` + orderBy + ` LIMIT ? OFFSET ?`
+	rows, err := sqlDB.QueryContext(c.Request.Context(), query, append(args, req.Limit, req.Skip)...)
+	if err != nil {
+		logrus.WithError(err).Error("❌ Failed to query documents")
+		c.JSON(queryErrorStatus(c), ErrorResponse{Error: err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	docs := make([]DocumentResponse, 0)
+	for rows.Next() {
+		var id, dataJSON string
+		if err := rows.Scan(&id, &dataJSON); err != nil {
+			logrus.WithError(err).Warn("Failed to scan document")
+			continue
+		}
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+			logrus.WithError(err).Warn("Failed to unmarshal document data")
+			data = make(map[string]interface{})
+		}
+		if len(req.Fields) > 0 {
+			data = projectFields(data, req.Fields)
+		}
+		docs = append(docs, DocumentResponse{ID: id, Data: data})
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"documents": docs,
+		"total":     total,
+		"skip":      req.Skip,
+		"limit":     req.Limit,
+		"took":      time.Since(start).Milliseconds(),
+	})
+}
+
+// selectorCompiler 将 selector 编译为 SQL 条件，参数按占位符出现的顺序收集
+type selectorCompiler struct {
+	args []interface{}
+}
+
+// compileSelector 将 Mango 风格的 selector 编译为 DuckDB 的 WHERE 条件
+// 字段路径使用点号分隔（如 "author.name"、"tags.0"），对 data 列执行 json_extract；
+// 空 selector 匹配所有文档
+func compileSelector(selector map[string]interface{}) (string, []interface{}, error) {
+	sc := &selectorCompiler{}
+	where, err := sc.selector(selector)
+	if err != nil {
+		return "", nil, err
+	}
+	return where, sc.args, nil
+}
+
+// selector 编译一个 selector 对象，各键之间为 AND 关系
+func (sc *selectorCompiler) selector(selector map[string]interface{}) (string, error) {
+	if len(selector) == 0 {
+		return "TRUE", nil
+	}
+
+	keys := make([]string, 0, len(selector))
+	for k := range selector {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value := selector[key]
+		var part string
+		var err error
+		switch key {
+		case "$and", "$or", "$nor":
+			part, err = sc.combinator(key, value)
+		case "$not":
+			sub, ok := value.(map[string]interface{})
+			if !ok {
+				return "", fmt.Errorf("$not expects an object")
+			}
+			part, err = sc.selector(sub)
+			part = "NOT (" + part + ")"
+		default:
+			if strings.HasPrefix(key, "$") {
+				return "", fmt.Errorf("unknown top-level operator %s", key)
+			}
+			part, err = sc.field(key, value)
+		}
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, part)
+	}
+	return joinConditions(parts, " AND "), nil
+}
+
+// combinator 编译 $and / $or / $nor
+func (sc *selectorCompiler) combinator(op string, value interface{}) (string, error) {
+	items, ok := value.([]interface{})
+	if !ok || len(items) == 0 {
+		return "", fmt.Errorf("%s expects a non-empty array", op)
+	}
+
+	parts := make([]string, 0, len(items))
+	for i, item := range items {
+		sub, ok := item.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("%s[%d] must be an object", op, i)
+		}
+		part, err := sc.selector(sub)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, part)
+	}
+
+	switch op {
+	case "$and":
+		return joinConditions(parts, " AND "), nil
+	case "$or":
+		return joinConditions(parts, " OR "), nil
+	default:
+		return "NOT " + joinConditions(parts, " OR "), nil
+	}
+}
+
+// field 编译单个字段的条件：值为操作符对象时逐个编译，否则按相等比较
+func (sc *selectorCompiler) field(field string, cond interface{}) (string, error) {
+	path, err := jsonPath(field)
+	if err != nil {
+		return "", err
+	}
+
+	ops, ok := cond.(map[string]interface{})
+	if !ok || !isOperatorObject(ops) {
+		return sc.eq(path, cond), nil
+	}
+
+	keys := make([]string, 0, len(ops))
+	for k := range ops {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, op := range keys {
+		part, err := sc.operator(field, path, op, ops[op])
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, part)
+	}
+	return joinConditions(parts, " AND "), nil
+}
+
+// operator 编译字段上的单个操作符
+func (sc *selectorCompiler) operator(field, path, op string, operand interface{}) (string, error) {
+	p := sqlStringLiteral(path)
+
+	switch op {
+	case "$eq":
+		return sc.eq(path, operand), nil
+	case "$ne":
+		return "NOT " + sc.eq(path, operand), nil
+	case "$gt", "$gte", "$lt", "$lte":
+		sqlOp := selectorCompareOps[op]
+		switch v := operand.(type) {
+		case float64:
+			sc.args = append(sc.args, v)
+			return fmt.Sprintf("COALESCE(json_type(data, %s) IN %s AND TRY_CAST(json_extract(data, %s) AS DOUBLE) %s ?, FALSE)",
+				p, jsonNumberTypes, p, sqlOp), nil
+		case string:
+			sc.args = append(sc.args, v)
+			return fmt.Sprintf("COALESCE(json_type(data, %s) = 'VARCHAR' AND json_extract_string(data, %s) %s ?, FALSE)",
+				p, p, sqlOp), nil
+		default:
+			return "", fmt.Errorf("%s on %q expects a number or string", op, field)
+		}
+	case "$in", "$nin", "$all":
+		values, ok := operand.([]interface{})
+		if !ok {
+			return "", fmt.Errorf("%s on %q expects an array", op, field)
+		}
+		if len(values) == 0 {
+			// 空的 $in 不匹配任何文档，空的 $nin / $all 匹配所有文档
+			if op == "$in" {
+				return "FALSE", nil
+			}
+			return "TRUE", nil
+		}
+		parts := make([]string, 0, len(values))
+		for _, v := range values {
+			if op == "$all" {
+				parts = append(parts, sc.contains(path, v))
+			} else {
+				parts = append(parts, sc.eq(path, v))
+			}
+		}
+		switch op {
+		case "$in":
+			return joinConditions(parts, " OR "), nil
+		case "$nin":
+			return "NOT " + joinConditions(parts, " OR "), nil
+		default:
+			return joinConditions(parts, " AND "), nil
+		}
+	case "$exists":
+		exists, ok := operand.(bool)
+		if !ok {
+			return "", fmt.Errorf("$exists on %q expects a boolean", field)
+		}
+		if exists {
+			return fmt.Sprintf("COALESCE(json_exists(data, %s), FALSE)", p), nil
+		}
+		return fmt.Sprintf("NOT COALESCE(json_exists(data, %s), FALSE)", p), nil
+	case "$type":
+		name, _ := operand.(string)
+		check, ok := selectorTypeNames[name]
+		if !ok {
+			return "", fmt.Errorf("$type on %q expects one of null, boolean, number, string, array, object", field)
+		}
+		return fmt.Sprintf("COALESCE(json_type(data, %s) %s, FALSE)", p, check), nil
+	case "$size":
+		size, ok := operand.(float64)
+		if !ok || size < 0 || size != float64(int64(size)) {
+			return "", fmt.Errorf("$size on %q expects a non-negative integer", field)
+		}
+		sc.args = append(sc.args, int64(size))
+		return fmt.Sprintf("COALESCE(json_type(data, %s) = 'ARRAY' AND json_array_length(data, %s) = ?, FALSE)", p, p), nil
+	case "$regex":
+		pattern, ok := operand.(string)
+		if !ok {
+			return "", fmt.Errorf("$regex on %q expects a string", field)
+		}
+		// DuckDB 与 Go 都使用 RE2 语法，提前校验以返回 400 而不是执行期错误
+		if _, err := regexp.Compile(pattern); err != nil {
+			return "", fmt.Errorf("$regex on %q: %w", field, err)
+		}
+		sc.args = append(sc.args, pattern)
+		return fmt.Sprintf("COALESCE(json_type(data, %s) = 'VARCHAR' AND regexp_matches(json_extract_string(data, %s), ?), FALSE)", p, p), nil
+	case "$not":
+		sub, err := sc.field(field, operand)
+		if err != nil {
+			return "", err
+		}
+		return "NOT (" + sub + ")", nil
+	default:
+		return "", fmt.Errorf("unknown operator %s on %q", op, field)
+	}
+}
+
+// eq 编译相等比较；字段为数组时，数组中包含该值也视为相等
+func (sc *selectorCompiler) eq(path string, value interface{}) string {
+	p := sqlStringLiteral(path)
+
+	switch value.(type) {
+	case []interface{}, map[string]interface{}:
+		sc.args = append(sc.args, mustMarshalJSON(value))
+		return fmt.Sprintf("COALESCE(json_extract(data, %s) = json(?), FALSE)", p)
+	case float64:
+		// 数值按 DOUBLE 比较，避免 5 与 5.0 的文本差异
+		sc.args = append(sc.args, value)
+		return fmt.Sprintf("COALESCE((json_type(data, %s) IN %s AND TRY_CAST(json_extract(data, %s) AS DOUBLE) = ?) OR %s, FALSE)",
+			p, jsonNumberTypes, p, sc.contains(path, value))
+	default:
+		sc.args = append(sc.args, mustMarshalJSON(value))
+		return fmt.Sprintf("COALESCE(json_extract(data, %s) = json(?) OR %s, FALSE)", p, sc.contains(path, value))
+	}
+}
+
+// contains 编译数组包含判断
+func (sc *selectorCompiler) contains(path string, value interface{}) string {
+	elems := sqlStringLiteral(path + "[*]")
+	if _, ok := value.(float64); ok {
+		sc.args = append(sc.args, value)
+		return fmt.Sprintf("COALESCE(list_contains(list_transform(list_filter(json_extract(data, %s), x -> json_type(x) IN %s), x -> TRY_CAST(x AS DOUBLE)), ?), FALSE)",
+			elems, jsonNumberTypes)
+	}
+	sc.args = append(sc.args, mustMarshalJSON(value))
+	return fmt.Sprintf("COALESCE(list_contains(json_extract(data, %s), json(?)), FALSE)", elems)
+}
+
+// compileSort 编译排序规则，支持 "field"、"-field" 和 {"field": "asc"|"desc"} 三种写法
+// 未指定排序时与文档列表保持一致，按创建时间倒序
+func compileSort(items []interface{}) (string, error) {
+	if len(items) == 0 {
+		return "created_at DESC, id", nil
+	}
+
+	parts := make([]string, 0, len(items)*2+1)
+	for i, item := range items {
+		var field, direction string
+		switch v := item.(type) {
+		case string:
+			field, direction = v, "ASC"
+			if strings.HasPrefix(v, "-") {
+				field, direction = v[1:], "DESC"
+			}
+		case map[string]interface{}:
+			if len(v) != 1 {
+				return "", fmt.Errorf("sort[%d] must have exactly one field", i)
+			}
+			for k, d := range v {
+				field = k
+				switch strings.ToLower(fmt.Sprint(d)) {
+				case "asc", "1":
+					direction = "ASC"
+				case "desc", "-1":
+					direction = "DESC"
+				default:
+					return "", fmt.Errorf("sort[%d]: direction must be asc or desc", i)
+				}
+			}
+		default:
+			return "", fmt.Errorf("sort[%d] must be a string or object", i)
+		}
+
+		path, err := jsonPath(field)
+		if err != nil {
+			return "", err
+		}
+		p := sqlStringLiteral(path)
+		// 先按数值排序，非数值（TRY_CAST 为 NULL）再按字符串排序
+		parts = append(parts,
+			fmt.Sprintf("TRY_CAST(json_extract_string(data, %s) AS DOUBLE) %s NULLS LAST", p, direction),
+			fmt.Sprintf("json_extract_string(data, %s) %s NULLS LAST", p, direction))
+	}
+	parts = append(parts, "id")
+	return strings.Join(parts, ", "), nil
+}
+
+// jsonPath 将点号分隔的字段名转换为 JSON 路径，纯数字的段视为数组下标
+func jsonPath(field string) (string, error) {
+	if field == "" {
+		return "", fmt.Errorf("empty field name")
+	}
+
+	var b strings.Builder
+	b.WriteString("$")
+	for _, seg := range strings.Split(field, ".") {
+		if seg == "" {
+			return "", fmt.Errorf("invalid field name %q", field)
+		}
+		if strings.ContainsAny(seg, "\"\\") {
+			return "", fmt.Errorf("field name %q must not contain quotes or backslashes", field)
+		}
+		if _, err := strconv.ParseUint(seg, 10, 32); err == nil {
+			b.WriteString("[" + seg + "]")
+			continue
+		}
+		b.WriteString(`."` + seg + `"`)
+	}
+	return b.String(), nil
+}
+
+// projectFields 只保留指定字段（支持点号路径），id 始终保留
+func projectFields(data map[string]interface{}, fields []string) map[string]interface{} {
+	result := make(map[string]interface{})
+	if id, ok := data["id"]; ok {
+		result["id"] = id
+	}
+
+	for _, field := range fields {
+		segs := strings.Split(field, ".")
+		value, ok := lookupField(data, segs)
+		if !ok {
+			continue
+		}
+		dst := result
+		for _, seg := range segs[:len(segs)-1] {
+			child, ok := dst[seg].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				dst[seg] = child
+			}
+			dst = child
+		}
+		dst[segs[len(segs)-1]] = value
+	}
+	return result
+}
+
+// lookupField 按路径查找嵌套字段
+func lookupField(data map[string]interface{}, segs []string) (interface{}, bool) {
+	var value interface{} = data
+	for _, seg := range segs {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = m[seg]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// isOperatorObject 判断对象是否为操作符对象（所有键都以 $ 开头）
+func isOperatorObject(m map[string]interface{}) bool {
+	if len(m) == 0 {
+		return false
+	}
+	for k := range m {
+		if !strings.HasPrefix(k, "$") {
+			return false
+		}
+	}
+	return true
+}
+
+// joinConditions 用括号包裹并连接多个条件
+func joinConditions(parts []string, sep string) string {
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return "(" + strings.Join(parts, sep) + ")"
+}
+
+// mustMarshalJSON 序列化由 encoding/json 解码得到的值，这类值总能成功序列化
+func mustMarshalJSON(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
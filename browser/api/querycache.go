@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// searchCacheHeader 响应头，标记搜索结果是否来自缓存（HIT 或 MISS）
+const searchCacheHeader = "X-Cache"
+
+// cachedSearchRoutes 结果可以缓存的搜索路由，键为 "METHOD 路由模板"（gin 的 FullPath）
+var cachedSearchRoutes = map[string]bool{
+	"POST /api/collections/:name/fulltext/search": true,
+	"POST /api/collections/:name/vector/search":   true,
+	"POST /api/collections/:name/vector/msearch":  true,
+	"POST /api/collections/:name/search":          true,
+}
+
+// readOnlyRoutes 不修改数据的 POST 路由，不会使缓存失效
+var readOnlyRoutes = map[string]bool{
+	"POST /api/collections/:name/query": true,
+	"POST /api/graph/path":              true,
+	"POST /api/graph/query":             true,
+	"POST /api/admin/backup":            true,
+}
+
+// searchCache 搜索结果缓存，由环境变量 QUERY_CACHE_SIZE 开启（测试中可替换），为 nil 时不缓存
+var searchCache *responseCache
+
+// cachedResponse 缓存的响应，generation 为写入缓存时集合的写入代数
+type cachedResponse struct {
+	key         string
+	collection  string
+	generation  uint64
+	storedAt    time.Time
+	contentType string
+	body        []byte
+}
+
+// responseCache 按最近使用淘汰的搜索响应缓存
+// 每个集合有一个写入代数，集合有写入时加一，代数不同的缓存视为失效
+type responseCache struct {
+	maxEntries int
+	ttl        time.Duration
+
+	mu          sync.Mutex
+	entries     map[string]*list.Element
+	order       *list.List // 最近使用的在前
+	generations map[string]uint64
+	global      uint64 // 恢复备份等影响所有集合的写入
+}
+
+func newResponseCache(maxEntries int, ttl time.Duration) *responseCache {
+	return &responseCache{
+		maxEntries:  maxEntries,
+		ttl:         ttl,
+		entries:     make(map[string]*list.Element),
+		order:       list.New(),
+		generations: make(map[string]uint64),
+	}
+}
+
+// generation 返回集合当前的写入代数
+func (rc *responseCache) generation(collection string) uint64 {
+	return rc.global + rc.generations[collection]
+}
+
+func (rc *responseCache) get(key, collection string) (*cachedResponse, bool) {
+	if rc == nil {
+		return nil, false
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	elem, ok := rc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cachedResponse)
+	if entry.generation != rc.generation(collection) || (rc.ttl > 0 && time.Since(entry.storedAt) >= rc.ttl) {
+		rc.order.Remove(elem)
+		delete(rc.entries, key)
+		return nil, false
+	}
+	rc.order.MoveToFront(elem)
+	return entry, true
+}
+
+// put 保存响应，generation 为执行搜索之前的写入代数，搜索期间集合有写入时不保存
+func (rc *responseCache) put(entry *cachedResponse) {
+	if rc == nil {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if entry.generation != rc.generation(entry.collection) {
+		return
+	}
+	if elem, ok := rc.entries[entry.key]; ok {
+		elem.Value = entry
+		rc.order.MoveToFront(elem)
+		return
+	}
+	rc.entries[entry.key] = rc.order.PushFront(entry)
+	for rc.order.Len() > rc.maxEntries {
+		oldest := rc.order.Back()
+		rc.order.Remove(oldest)
+		delete(rc.entries, oldest.Value.(*cachedResponse).key)
+	}
+}
+
+// currentGeneration 返回集合当前的写入代数
+func (rc *responseCache) currentGeneration(collection string) uint64 {
+	if rc == nil {
+		return 0
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.generation(collection)
+}
+
+// invalidate 使集合的缓存失效，collection 为空时使所有集合的缓存失效
+func (rc *responseCache) invalidate(collection string) {
+	if rc == nil {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if collection == "" {
+		rc.global++
+		return
+	}
+	rc.generations[collection]++
+}
+
+// searchCacheKey 缓存的键：路由、集合和规范化的请求体
+// 请求体按 JSON 重新编码（字段顺序无关），query 字段转小写并合并空白
+func searchCacheKey(route, collection string, body []byte) (string, bool) {
+	var req map[string]any
+	if err := json.Unmarshal(body, &req); err != nil {
+		return "", false
+	}
+	if query, ok := req["query"].(string); ok {
+		req["query"] = strings.Join(strings.Fields(strings.ToLower(query)), " ")
+	}
+	canonical, err := json.Marshal(req)
+	if err != nil {
+		return "", false
+	}
+	return route + "\x00" + collection + "\x00" + string(canonical), true
+}
+
+// cacheRecorder 记录写出的响应体，用于保存到缓存
+type cacheRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *cacheRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *cacheRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// searchCacheMiddleware 缓存搜索路由的结果，并在写请求之后使相关集合的缓存失效
+// 写请求（GET 以外的方法，只读的 POST 除外）使 :name 集合的缓存失效，没有 :name 的写请求（如图操作）使所有缓存失效；
+// 需要放在 authMiddleware 之后，未通过认证的请求不会读取缓存
+func searchCacheMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rc := searchCache
+		if rc == nil {
+			c.Next()
+			return
+		}
+		route := c.Request.Method + " " + c.FullPath()
+		collection := c.Param("name")
+
+		if !cachedSearchRoutes[route] {
+			c.Next()
+			if c.Request.Method != http.MethodGet && !readOnlyRoutes[route] {
+				rc.invalidate(collection)
+			}
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("Failed to read request body: %v", err)})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		key, ok := searchCacheKey(route, collection, body)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		if entry, ok := rc.get(key, collection); ok {
+			c.Header(searchCacheHeader, "HIT")
+			c.Data(http.StatusOK, entry.contentType, entry.body)
+			c.Abort()
+			return
+		}
+
+		generation := rc.currentGeneration(collection)
+		recorder := &cacheRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Header(searchCacheHeader, "MISS")
+		c.Next()
+
+		if recorder.Status() == http.StatusOK {
+			rc.put(&cachedResponse{
+				key:         key,
+				collection:  collection,
+				generation:  generation,
+				storedAt:    time.Now(),
+				contentType: recorder.Header().Get("Content-Type"),
+				body:        recorder.body.Bytes(),
+			})
+		}
+	}
+}
+
+// initSearchCache 读取 QUERY_CACHE_SIZE（最多缓存的搜索数，默认不缓存）和 QUERY_CACHE_TTL（如 5m，默认只在写入时失效）
+func initSearchCache() error {
+	v := os.Getenv("QUERY_CACHE_SIZE")
+	if v == "" {
+		return nil
+	}
+	size, err := strconv.Atoi(v)
+	if err != nil || size < 0 {
+		return fmt.Errorf("invalid QUERY_CACHE_SIZE: %q", v)
+	}
+	if size == 0 {
+		return nil
+	}
+	var ttl time.Duration
+	if v := os.Getenv("QUERY_CACHE_TTL"); v != "" {
+		ttl, err = time.ParseDuration(v)
+		if err != nil || ttl < 0 {
+			return fmt.Errorf("invalid QUERY_CACHE_TTL: %q", v)
+		}
+	}
+	searchCache = newResponseCache(size, ttl)
+	logrus.WithFields(logrus.Fields{
+		"size": size,
+		"ttl":  ttl,
+	}).Info("🗃️ Search result cache enabled")
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// revField 文档版本号字段，与 lightrag Schema 的 RevField 一致：新文档为 1，每次更新加 1
+const revField = "_rev"
+
+// revisionSQL 在 SQL 中读取文档版本号，没有版本号的旧文档视为 0
+const revisionSQL = `COALESCE(TRY_CAST(json_extract_string(data, '$._rev') AS BIGINT), 0)`
+
+// documentRevision 读取文档数据中的版本号，没有版本号时返回 0
+func documentRevision(data map[string]interface{}) int64 {
+	rev, ok, err := parseRevision(data[revField])
+	if err != nil || !ok {
+		return 0
+	}
+	return rev
+}
+
+// parseRevision 解析版本号，支持数字和数字字符串；value 为 nil 时 ok 为 false
+func parseRevision(value interface{}) (rev int64, ok bool, err error) {
+	switch v := value.(type) {
+	case nil:
+		return 0, false, nil
+	case int:
+		rev = int64(v)
+	case int64:
+		rev = v
+	case float64:
+		if v != float64(int64(v)) || v < 0 {
+			return 0, false, fmt.Errorf("invalid _rev: %v", v)
+		}
+		return int64(v), true, nil
+	case json.Number:
+		rev, err = v.Int64()
+	case string:
+		rev, err = strconv.ParseInt(strings.Trim(strings.TrimSpace(v), `"`), 10, 64)
+	default:
+		return 0, false, fmt.Errorf("invalid _rev: %v", v)
+	}
+	if err != nil || rev < 0 {
+		return 0, false, fmt.Errorf("invalid _rev: %v", value)
+	}
+	return rev, true, nil
+}
+
+// expectedRevision 读取客户端期望的版本号：If-Match 请求头优先，其次为请求体中的 _rev
+// 两者都没有时 ok 为 false，表示不做版本检查
+func expectedRevision(c *gin.Context, updates map[string]interface{}) (rev int64, ok bool, err error) {
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" && ifMatch != "*" {
+		return parseRevision(strings.TrimPrefix(ifMatch, "W/"))
+	}
+	return parseRevision(updates[revField])
+}
+
+// setRevisionHeader 通过 ETag 返回文档版本号，便于客户端使用 If-Match
+func setRevisionHeader(c *gin.Context, data map[string]interface{}) {
+	if rev := documentRevision(data); rev > 0 {
+		c.Header("ETag", strconv.Quote(strconv.FormatInt(rev, 10)))
+	}
+}
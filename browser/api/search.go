@@ -2,11 +2,16 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io"
 	"math"
 	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -28,6 +33,7 @@ func fulltextSearch(c *gin.Context) {
 	}
 
 	start := time.Now()
+	live := liveDocumentsSQL("")
 
 	hasContent, err := columnExists(sqlDB, "documents", "content")
 	if err != nil {
@@ -40,15 +46,16 @@ func fulltextSearch(c *gin.Context) {
 		query := `
 		SELECT id, collection_name, data, CAST(1.0 AS DOUBLE) as score
 		FROM documents
-		WHERE collection_name = ? 
+		WHERE collection_name = ?
+		  AND ` + live + `
 		  AND data LIKE ?
 		LIMIT ?
 		`
 		searchPattern := "%" + req.Query + "%"
-		rows, err := sqlDB.Query(query, name, searchPattern, req.Limit)
+		rows, err := sqlDB.QueryContext(c.Request.Context(), query, name, searchPattern, req.Limit)
 		if err != nil {
 			logrus.WithError(err).Error("Fulltext search failed")
-			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			c.JSON(queryErrorStatus(c), ErrorResponse{Error: err.Error()})
 			return
 		}
 		defer rows.Close()
@@ -118,7 +125,8 @@ func fulltextSearch(c *gin.Context) {
 		query = `
 		SELECT id, collection_name, data, CAST(1.0 AS DOUBLE) as score
 		FROM documents
-		WHERE collection_name = ? 
+		WHERE collection_name = ?
+		  AND ` + live + `
 		  AND content_tokens MATCH ?
 		LIMIT ?
 		`
@@ -127,40 +135,43 @@ func fulltextSearch(c *gin.Context) {
 		query = `
 		SELECT id, collection_name, data, CAST(1.0 AS DOUBLE) as score
 		FROM documents
-		WHERE collection_name = ? 
+		WHERE collection_name = ?
+		  AND ` + live + `
 		  AND content MATCH ?
 		LIMIT ?
 		`
 		searchText = req.Query
 	}
 
-	rows, err := sqlDB.Query(query, name, searchText, req.Limit)
+	rows, err := sqlDB.QueryContext(c.Request.Context(), query, name, searchText, req.Limit)
 	if err != nil {
 		logrus.WithError(err).Warn("FTS query failed, using LIKE query as fallback")
 		if hasContentTokens && queryTokens != "" {
 			query = `
 			SELECT id, collection_name, data, CAST(1.0 AS DOUBLE) as score
 			FROM documents
-			WHERE collection_name = ? 
+			WHERE collection_name = ?
+			  AND ` + live + `
 			  AND content_tokens LIKE ?
 			LIMIT ?
 			`
 			searchPattern := "%" + queryTokens + "%"
-			rows, err = sqlDB.Query(query, name, searchPattern, req.Limit)
+			rows, err = sqlDB.QueryContext(c.Request.Context(), query, name, searchPattern, req.Limit)
 		} else {
 			query = `
 			SELECT id, collection_name, data, CAST(1.0 AS DOUBLE) as score
 			FROM documents
-			WHERE collection_name = ? 
+			WHERE collection_name = ?
+			  AND ` + live + `
 			  AND content LIKE ?
 			LIMIT ?
 			`
 			searchPattern := "%" + req.Query + "%"
-			rows, err = sqlDB.Query(query, name, searchPattern, req.Limit)
+			rows, err = sqlDB.QueryContext(c.Request.Context(), query, name, searchPattern, req.Limit)
 		}
 		if err != nil {
 			logrus.WithError(err).Error("Fulltext search failed")
-			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			c.JSON(queryErrorStatus(c), ErrorResponse{Error: err.Error()})
 			return
 		}
 	}
@@ -256,6 +267,9 @@ func vectorSearch(c *gin.Context) {
 	if req.Limit <= 0 {
 		req.Limit = 10
 	}
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
 
 	if req.Field == "" {
 		req.Field = "embedding"
@@ -265,7 +279,32 @@ func vectorSearch(c *gin.Context) {
 	vectorSearchDB(c, name, req, queryVector)
 }
 
+// vectorCandidatePageSize 向量搜索每次从数据库取出的候选数量下限
+const vectorCandidatePageSize = 50
+
+// vectorDistanceExpr 根据 embedding 列的类型生成余弦距离表达式（距离越小越相似）
+// 固定维度的 FLOAT[N] 使用 array_cosine_distance，可以命中 HNSW 索引；
+// 变长 FLOAT[] 和以文本存储的向量使用 list_cosine_similarity 兜底
+func vectorDistanceExpr(colType string, queryDim int) (string, error) {
+	colType = strings.ToUpper(strings.TrimSpace(colType))
+	if m := fixedFloatArrayPattern.FindStringSubmatch(colType); m != nil {
+		dim, _ := strconv.Atoi(m[1])
+		if dim != queryDim {
+			return "", fmt.Errorf("query vector dimension %d does not match embedding dimension %d", queryDim, dim)
+		}
+		return fmt.Sprintf("array_cosine_distance(embedding, ?::FLOAT[%d])", dim), nil
+	}
+	if colType == "FLOAT[]" || colType == "DOUBLE[]" {
+		return "1 - list_cosine_similarity(embedding, ?::FLOAT[])", nil
+	}
+	return "1 - list_cosine_similarity(CAST(embedding AS FLOAT[]), ?::FLOAT[])", nil
+}
+
+var fixedFloatArrayPattern = regexp.MustCompile(`^(?:FLOAT|DOUBLE)\[(\d+)\]$`)
+
 // vectorSearchDB 使用数据库进行向量搜索
+// 按距离升序分页读取候选（每页都是 ORDER BY 距离 LIMIT/OFFSET，可以命中 HNSW 索引），
+// 直到凑够 offset+limit 条满足阈值的结果，或者候选的相似度已低于阈值
 func vectorSearchDB(c *gin.Context, name string, req VectorSearchRequest, queryVector []float64) {
 	start := time.Now()
 
@@ -287,92 +326,158 @@ func vectorSearchDB(c *gin.Context, name string, req VectorSearchRequest, queryV
 		return
 	}
 
-	// 将查询向量转换为 DuckDB 可以接受的格式
-	// DuckDB 需要 FLOAT[] 类型
-	vectorStr := "["
-	for i, v := range queryVector {
-		if i > 0 {
-			vectorStr += ", "
-		}
-		vectorStr += fmt.Sprintf("%g", v)
-	}
-	vectorStr += "]"
-
-	// 使用 DuckDB 的 list_cosine_similarity 进行向量搜索
-	// list_cosine_similarity 返回距离（distance），距离越小相似度越高
-	// 相似度 = 1 - 距离，所以按距离升序排列（相似度降序）
-	query := `
-		SELECT 
-			id,
-			collection_name,
-			data,
-			1 - list_cosine_similarity(embedding, ?::FLOAT[]) as similarity
-		FROM documents
-		WHERE collection_name = ? 
-		  AND embedding IS NOT NULL
-		ORDER BY list_cosine_similarity(embedding, ?::FLOAT[]) ASC
-		LIMIT ?
-	`
+	colType, err := getColumnType(sqlDB, "documents", "embedding")
+	if err != nil {
+		logrus.WithError(err).Error("Failed to get embedding column type")
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error: fmt.Sprintf("Failed to get embedding column type: %v", err),
+		})
+		return
+	}
+	distanceExpr, err := vectorDistanceExpr(colType, len(queryVector))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		return
+	}
 
-	rows, err := sqlDB.Query(query, vectorStr, name, vectorStr, req.Limit*2) // 获取更多结果以便过滤
+	results, hasMore, err := searchVectorMatches(c.Request.Context(), name, distanceExpr, queryVector, req.Offset, req.Limit, req.Threshold)
 	if err != nil {
 		logrus.WithError(err).Error("Vector search query failed")
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
+		c.JSON(queryErrorStatus(c), ErrorResponse{
 			Error: fmt.Sprintf("向量搜索失败: %v", err),
 		})
 		return
 	}
-	defer rows.Close()
 
-	var results []gin.H
-	for rows.Next() {
-		var docID, collectionName, dataJSON string
-		var similarity float64
-		if err := rows.Scan(&docID, &collectionName, &dataJSON, &similarity); err != nil {
-			logrus.WithError(err).Error("Failed to scan row")
-			continue
-		}
+	took := time.Since(start).Milliseconds()
 
-		// 应用阈值过滤
-		if req.Threshold > 0 && similarity < req.Threshold {
-			continue
-		}
+	c.JSON(http.StatusOK, gin.H{
+		"results":  results,
+		"query":    req.QueryText,
+		"offset":   req.Offset,
+		"limit":    req.Limit,
+		"has_more": hasMore,
+		"took":     took,
+	})
+}
 
-		var data map[string]interface{}
-		if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
-			logrus.WithError(err).Warn("Failed to unmarshal document data")
-			continue
-		}
+// searchVectorMatches 按距离升序分页读取候选，返回第 offset 条起最多 limit 条满足阈值的结果，以及是否还有更多结果
+func searchVectorMatches(ctx context.Context, name, distanceExpr string, queryVector []float64, offset, limit int, threshold float64) ([]gin.H, bool, error) {
+	// 将查询向量转换为 DuckDB 可以接受的格式
+	vectorStr := formatVectorLiteral(queryVector)
 
-		results = append(results, gin.H{
-			"document": DocumentResponse{
-				ID:   docID,
-				Data: data,
-			},
-			"score": similarity,
-		})
+	query := fmt.Sprintf(`
+		SELECT id, collection_name, data, distance
+		FROM (
+			SELECT id, collection_name, data, %s AS distance
+			FROM documents
+			WHERE collection_name = ?
+			  AND embedding IS NOT NULL
+			  AND %s
+		)
+		ORDER BY distance ASC
+		LIMIT ? OFFSET ?
+	`, distanceExpr, liveDocumentsSQL(""))
+
+	want := offset + limit
+	pageSize := limit * 2
+	if pageSize < vectorCandidatePageSize {
+		pageSize = vectorCandidatePageSize
+	}
 
-		// 达到限制数量后停止
-		if len(results) >= req.Limit {
+	var matched []gin.H
+	hasMore := false
+	for candidateOffset := 0; ; candidateOffset += pageSize {
+		page, err := queryVectorCandidates(ctx, query, vectorStr, name, pageSize, candidateOffset)
+		if err != nil {
+			return nil, false, err
+		}
+
+		belowThreshold := false
+		for _, candidate := range page {
+			// 候选按相似度降序排列，低于阈值后后面的都不满足
+			if threshold > 0 && candidate.similarity < threshold {
+				belowThreshold = true
+				break
+			}
+			if candidate.data == nil {
+				continue
+			}
+			if len(matched) == want {
+				hasMore = true
+				break
+			}
+			matched = append(matched, gin.H{
+				"document": DocumentResponse{
+					ID:   candidate.id,
+					Data: candidate.data,
+				},
+				"score": candidate.similarity,
+			})
+		}
+		if belowThreshold || hasMore || len(page) < pageSize {
 			break
 		}
 	}
 
-	if err := rows.Err(); err != nil {
-		logrus.WithError(err).Error("Error iterating rows")
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error: fmt.Sprintf("向量搜索处理失败: %v", err),
-		})
-		return
+	results := []gin.H{}
+	if offset < len(matched) {
+		results = matched[offset:]
 	}
+	return results, hasMore, nil
+}
 
-	took := time.Since(start).Milliseconds()
+// vectorCandidate 向量搜索的一条候选结果
+type vectorCandidate struct {
+	id         string
+	data       map[string]interface{}
+	similarity float64
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"results": results,
-		"query":   req.QueryText,
-		"took":    took,
-	})
+// queryVectorCandidates 读取一页候选，data 无法解析的文档会被跳过
+func queryVectorCandidates(ctx context.Context, query, vectorStr, name string, limit, offset int) ([]vectorCandidate, error) {
+	rows, err := sqlDB.QueryContext(ctx, query, vectorStr, name, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// 返回的页长度以数据库行数为准，用于判断是否还有下一页
+	page := make([]vectorCandidate, 0, limit)
+	for rows.Next() {
+		var docID, collectionName, dataJSON string
+		var distance sql.NullFloat64
+		if err := rows.Scan(&docID, &collectionName, &dataJSON, &distance); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		candidate := vectorCandidate{id: docID}
+		if distance.Valid {
+			candidate.similarity = 1 - distance.Float64
+		}
+		if err := json.Unmarshal([]byte(dataJSON), &candidate.data); err != nil {
+			logrus.WithError(err).WithField("id", docID).Warn("Failed to unmarshal document data")
+		}
+		page = append(page, candidate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("向量搜索处理失败: %w", err)
+	}
+	return page, nil
+}
+
+// formatVectorLiteral 将向量转换为 DuckDB 列表字面量，如 [0.1, 0.2]
+func formatVectorLiteral(vector []float64) string {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for i, v := range vector {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+	}
+	sb.WriteByte(']')
+	return sb.String()
 }
 
 // cosineSimilarity 计算两个向量的余弦相似度
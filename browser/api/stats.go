@@ -0,0 +1,173 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ftsIndexSchema DuckDB FTS 扩展为 documents 表创建的 schema
+const ftsIndexSchema = "fts_main_documents"
+
+var (
+	ftsBuiltMu sync.Mutex
+	// ftsBuiltAt 本进程最近一次构建全文索引的时间；索引在启动前已存在时为零值
+	ftsBuiltAt time.Time
+)
+
+// markFTSIndexBuilt 记录全文索引的构建时间
+// DuckDB 的全文索引是构建时的快照，之后写入或更新的文档需要重建索引才能被检索到；
+// 使用数据库的时间，与 updated_at 的时区保持一致
+func markFTSIndexBuilt(db *sql.DB) {
+	builtAt := time.Now()
+	if err := db.QueryRow(`SELECT CAST(CURRENT_TIMESTAMP AS TIMESTAMP)`).Scan(&builtAt); err != nil {
+		logrus.WithError(err).Warn("Failed to read database time for FTS index")
+	}
+	ftsBuiltMu.Lock()
+	defer ftsBuiltMu.Unlock()
+	ftsBuiltAt = builtAt
+}
+
+// ftsIndexBuiltAt 返回全文索引的构建时间，未知时为零值
+func ftsIndexBuiltAt() time.Time {
+	ftsBuiltMu.Lock()
+	defer ftsBuiltMu.Unlock()
+	return ftsBuiltAt
+}
+
+// getCollectionStats 返回集合的文档数、存储大小、embedding 覆盖情况以及全文索引和向量索引的状态
+func getCollectionStats(c *gin.Context) {
+	name := c.Param("name")
+
+	stats := CollectionStats{Name: name}
+	var lastUpdated sql.NullTime
+	var dataBytes, contentBytes sql.NullInt64
+	contentExpr := "0"
+	if ok, _ := columnExists(sqlDB, "documents", "content"); ok {
+		contentExpr = "strlen(content)"
+	}
+	query := fmt.Sprintf(`SELECT COUNT(*), SUM(strlen(data)), SUM(%s), MAX(updated_at) FROM documents WHERE collection_name = ? AND %s`,
+		contentExpr, liveDocumentsSQL(""))
+	if err := sqlDB.QueryRow(query, name).Scan(&stats.DocumentCount, &dataBytes, &contentBytes, &lastUpdated); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if hasDeletedAtColumn() {
+		if err := sqlDB.QueryRow(`SELECT COUNT(*) FROM documents WHERE collection_name = ? AND deleted_at IS NOT NULL`, name).Scan(&stats.Trashed); err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+
+	_, registered, err := loadCollectionSettings(sqlDB, name)
+	if err != nil {
+		logrus.WithError(err).WithField("collection", name).Warn("Failed to load collection settings")
+	}
+	if stats.DocumentCount == 0 && stats.Trashed == 0 && !registered {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Collection not found"})
+		return
+	}
+	stats.Registered = registered
+	if lastUpdated.Valid {
+		stats.LastUpdatedAt = &lastUpdated.Time
+	}
+	stats.Storage.DataBytes = dataBytes.Int64
+	stats.Storage.ContentBytes = contentBytes.Int64
+
+	if err := loadEmbeddingStats(name, &stats); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	stats.Storage.TotalBytes = stats.Storage.DataBytes + stats.Storage.ContentBytes + stats.Storage.EmbeddingBytes
+
+	if err := loadFTSStats(name, &stats.FTS); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// loadEmbeddingStats 统计有无 embedding 的文档数和 embedding 占用的空间，并检查向量索引
+func loadEmbeddingStats(name string, stats *CollectionStats) error {
+	hasEmbedding, err := columnExists(sqlDB, "documents", "embedding")
+	if err != nil {
+		return err
+	}
+	if !hasEmbedding {
+		stats.Unembedded = stats.DocumentCount
+		return nil
+	}
+
+	colType, err := getColumnType(sqlDB, "documents", "embedding")
+	if err != nil {
+		return err
+	}
+	stats.VectorIndex.ColumnType = colType
+
+	// 固定维度的向量按 4 字节一个分量估算，其他类型按文本长度估算
+	sizeExpr := "strlen(CAST(embedding AS VARCHAR))"
+	if m := fixedFloatArrayPattern.FindStringSubmatch(strings.ToUpper(colType)); m != nil {
+		stats.VectorIndex.Dimension, _ = strconv.Atoi(m[1])
+		sizeExpr = strconv.Itoa(stats.VectorIndex.Dimension * 4)
+	}
+	var embeddingBytes sql.NullInt64
+	query := fmt.Sprintf(`SELECT COUNT(embedding), SUM(CASE WHEN embedding IS NULL THEN 0 ELSE %s END) FROM documents WHERE collection_name = ? AND %s`,
+		sizeExpr, liveDocumentsSQL(""))
+	if err := sqlDB.QueryRow(query, name).Scan(&stats.Embedded, &embeddingBytes); err != nil {
+		return err
+	}
+	stats.Unembedded = stats.DocumentCount - stats.Embedded
+	stats.Storage.EmbeddingBytes = embeddingBytes.Int64
+
+	// HNSW 索引建在整个 documents 表上，所有集合共用
+	rows, err := sqlDB.Query(`SELECT index_name, sql FROM duckdb_indexes() WHERE table_name = 'documents'`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var indexName string
+		var indexSQL sql.NullString
+		if err := rows.Scan(&indexName, &indexSQL); err != nil {
+			return err
+		}
+		if strings.Contains(strings.ToUpper(indexSQL.String), "USING HNSW") {
+			stats.VectorIndex.Present = true
+			stats.VectorIndex.Name = indexName
+		}
+	}
+	return rows.Err()
+}
+
+// loadFTSStats 检查全文索引是否存在，以及集合中有多少文档不在索引中或在索引构建后被修改过
+func loadFTSStats(name string, fts *FTSIndexStats) error {
+	var exists int
+	if err := sqlDB.QueryRow(`SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = ? AND table_name = 'docs'`, ftsIndexSchema).Scan(&exists); err != nil {
+		return err
+	}
+	if exists == 0 {
+		return nil
+	}
+	fts.Present = true
+
+	// 构建时间未知（索引在启动前已存在）时无法判断已索引的文档是否过期
+	builtAt := ftsIndexBuiltAt()
+	if !builtAt.IsZero() {
+		fts.BuiltAt = &builtAt
+	}
+	query := fmt.Sprintf(`SELECT COUNT(f.name), COUNT(*) - COUNT(f.name), COUNT(CASE WHEN f.name IS NOT NULL AND ? AND d.updated_at > ? THEN 1 END)
+		FROM documents d LEFT JOIN %s.docs f ON f.name = d.id WHERE d.collection_name = ? AND %s`, ftsIndexSchema, liveDocumentsSQL("d."))
+	if err := sqlDB.QueryRow(query, !builtAt.IsZero(), builtAt, name).Scan(&fts.Indexed, &fts.Missing, &fts.Stale); err != nil {
+		return err
+	}
+	fts.Fresh = fts.Missing == 0 && fts.Stale == 0
+	return nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// hasDeletedAtColumn 检查 documents 表是否有 deleted_at 列，检查失败时假定列存在
+func hasDeletedAtColumn() bool {
+	ok, err := columnExists(sqlDB, "documents", "deleted_at")
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to check deleted_at column, assuming it exists")
+		return true
+	}
+	return ok
+}
+
+// liveDocumentsSQL 返回排除回收站中文档的条件，prefix 为列名前缀（如 "d."）
+// 旧表结构没有 deleted_at 列时所有文档都视为未删除
+func liveDocumentsSQL(prefix string) string {
+	if !hasDeletedAtColumn() {
+		return "TRUE"
+	}
+	return prefix + "deleted_at IS NULL"
+}
+
+// softDeleteDocument 将文档移入回收站，返回是否有文档被删除
+// 没有 deleted_at 列时直接删除
+func softDeleteDocument(name, id string) (bool, error) {
+	query := `UPDATE documents SET deleted_at = CURRENT_TIMESTAMP WHERE collection_name = ? AND id = ? AND deleted_at IS NULL`
+	if !hasDeletedAtColumn() {
+		query = `DELETE FROM documents WHERE collection_name = ? AND id = ?`
+	}
+	result, err := sqlDB.Exec(query, name, id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// purgeTrashedDocument 从回收站中永久删除同 ID 的文档及其附件，使新文档可以复用该 ID
+// 返回被删除附件引用的 blob 摘要，由调用方在提交后清理
+func purgeTrashedDocument(q sqlQueryExecer, name, id string, cols documentColumns) ([]string, error) {
+	if !cols.deletedAt {
+		return nil, nil
+	}
+	result, err := q.Exec(`DELETE FROM documents WHERE collection_name = ? AND id = ? AND deleted_at IS NOT NULL`, name, id)
+	if err != nil {
+		return nil, err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return nil, err
+	}
+	return deleteDocumentAttachments(q, name, id)
+}
+
+// getTrash 列出集合回收站中的文档，按删除时间倒序
+func getTrash(c *gin.Context) {
+	name := c.Param("name")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	skip, _ := strconv.Atoi(c.DefaultQuery("skip", "0"))
+
+	docs := make([]DocumentResponse, 0)
+	if !hasDeletedAtColumn() {
+		c.JSON(http.StatusOK, gin.H{"documents": docs, "total": 0, "skip": skip, "limit": limit})
+		return
+	}
+
+	var total int64
+	if err := sqlDB.QueryRow(`SELECT COUNT(*) FROM documents WHERE collection_name = ? AND deleted_at IS NOT NULL`, name).Scan(&total); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	rows, err := sqlDB.Query(`SELECT id, data, deleted_at FROM documents WHERE collection_name = ? AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id LIMIT ? OFFSET ?`, name, limit, skip)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, dataJSON string
+		var deletedAt time.Time
+		if err := rows.Scan(&id, &dataJSON, &deletedAt); err != nil {
+			logrus.WithError(err).Warn("Failed to scan trashed document")
+			continue
+		}
+		var data map[string]interface{}
+		if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+			data = make(map[string]interface{})
+		}
+		docs = append(docs, DocumentResponse{ID: id, Data: data, DeletedAt: &deletedAt})
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"documents": docs,
+		"total":     total,
+		"skip":      skip,
+		"limit":     limit,
+	})
+}
+
+// restoreTrashedDocument 将文档从回收站恢复
+func restoreTrashedDocument(c *gin.Context) {
+	name := c.Param("name")
+	id := c.Param("id")
+
+	if !hasDeletedAtColumn() {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Document not found in trash"})
+		return
+	}
+	result, err := sqlDB.Exec(`UPDATE documents SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP
+		WHERE collection_name = ? AND id = ? AND deleted_at IS NOT NULL`, name, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		c.JSON(http.StatusNotFound, ErrorResponse{Error: "Document not found in trash"})
+		return
+	}
+
+	logrus.WithFields(logrus.Fields{"collection": name, "id": id}).Info("♻️ Document restored from trash")
+	c.JSON(http.StatusOK, gin.H{"message": "Document restored", "id": id})
+}
+
+// purgeTrash 永久删除回收站中的文档：带 :id 时只删除该文档，否则清空集合的回收站
+func purgeTrash(c *gin.Context) {
+	name := c.Param("name")
+	id := c.Param("id")
+
+	if !hasDeletedAtColumn() {
+		if id != "" {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Document not found in trash"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Trash emptied", "purged": 0})
+		return
+	}
+
+	query := `DELETE FROM documents WHERE collection_name = ? AND deleted_at IS NOT NULL`
+	args := []interface{}{name}
+	if id != "" {
+		query += ` AND id = ?`
+		args = append(args, id)
+	}
+	result, err := sqlDB.Exec(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	purged, _ := result.RowsAffected()
+
+	sums, err := deleteOrphanAttachments(sqlDB, name)
+	if err != nil {
+		logrus.WithError(err).WithField("collection", name).Warn("Failed to delete attachments of purged documents")
+	}
+	removeUnreferencedBlobs(sums)
+
+	logrus.WithFields(logrus.Fields{
+		"collection": name,
+		"id":         id,
+		"purged":     purged,
+	}).Info("🗑️ Trash purged")
+
+	if id != "" {
+		if purged == 0 {
+			c.JSON(http.StatusNotFound, ErrorResponse{Error: "Document not found in trash"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Document purged", "id": id})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Trash emptied", "purged": purged})
+}
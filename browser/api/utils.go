@@ -4,14 +4,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/mozhou-tech/sqlite-ai-driver/pkg/sego"
 )
 
-// tokenizeWithSego 使用 sego 对文本进行分词，返回用空格分隔的词
+// tokenizerVersion tokenizeWithSego 的分词规则版本
+// 规则变化（如过滤停用词、规范化）后需要更新，启动时 retokenizeContent 会重新生成已有文档的 content_tokens
+const tokenizerVersion = "sego-filtered-1"
+
+// tokenizeWithSego 使用 sego 对文本进行分词，返回用空格分隔的词（已过滤停用词和标点）
 func tokenizeWithSego(text string) string {
-	return sego.Tokenize(text)
+	return sego.TokenizeFiltered(text)
 }
 
 // extractEmbeddingVector 从 embedding 字段中提取 []float64 向量
@@ -42,9 +47,21 @@ func extractEmbeddingVector(embeddingField interface{}) []float64 {
 	}
 }
 
+// lastGeneratedID 记录上一次生成的 ID，保证批量导入时同一纳秒内生成的 ID 也不重复
+var lastGeneratedID int64
+
 // generateID 生成文档 ID
 func generateID() string {
-	return fmt.Sprintf("%d", time.Now().UnixNano())
+	for {
+		last := atomic.LoadInt64(&lastGeneratedID)
+		next := time.Now().UnixNano()
+		if next <= last {
+			next = last + 1
+		}
+		if atomic.CompareAndSwapInt64(&lastGeneratedID, last, next) {
+			return fmt.Sprintf("%d", next)
+		}
+	}
 }
 
 // extractTextFromData 从 JSON 数据中提取文本内容
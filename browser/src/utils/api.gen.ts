@@ -0,0 +1,241 @@
+// 由 browser/api 根据 OpenAPI 文档生成，请勿手动修改
+// 重新生成：cd api && go test -run TestOpenAPISpec -update
+
+export interface AnalyzeResponse {
+  content_tokens: string
+  indexed_tokens: string[]
+  normalized: string
+  stopwords: string[]
+  text: string
+  tokens: string[]
+}
+
+export interface Attachment {
+  content_type: string
+  created_at: string
+  name: string
+  sha256: string
+  size: number
+}
+
+export interface BackupInfo {
+  created_at: string
+  name: string
+  size: number
+}
+
+export interface BatchImportError {
+  error: string
+  id?: string
+  index: number
+}
+
+export interface BatchImportResponse {
+  errors: BatchImportError[]
+  failed: number
+  inserted: number
+  skipped: number
+}
+
+export interface CollectionInfo {
+  name: string
+  schema: Record<string, any>
+  settings?: CollectionSettings
+}
+
+export interface CollectionSettings {
+  embed_fields?: string[]
+  fts_fields?: string[]
+  vector_dimension?: number
+}
+
+export interface CollectionStats {
+  document_count: number
+  embedded: number
+  fts: FTSIndexStats
+  last_updated_at?: string
+  name: string
+  registered: boolean
+  storage: StorageStats
+  trashed: number
+  unembedded: number
+  vector_index: VectorIndexStats
+}
+
+export interface CreateCollectionRequest {
+  embed_fields?: string[]
+  fts_fields?: string[]
+  name: string
+  vector_dimension?: number
+}
+
+export interface DocumentResponse {
+  data: Record<string, any>
+  deleted_at?: string
+  id: string
+  warnings?: string[]
+}
+
+export interface ErrorResponse {
+  error: string
+}
+
+export interface FTSIndexStats {
+  built_at?: string
+  fresh: boolean
+  indexed: number
+  missing: number
+  present: boolean
+  stale: number
+}
+
+export interface FulltextSearchRequest {
+  collection: string
+  limit: number
+  query: string
+  threshold: number
+}
+
+export interface GraphBulkLinkRequest {
+  links: GraphLinkRequest[]
+}
+
+export interface GraphEdge {
+  from: string
+  relation: string
+  to: string
+}
+
+export interface GraphLinkRequest {
+  from: string
+  relation: string
+  to: string
+}
+
+export interface GraphNode {
+  depth: number
+  id: string
+  label?: string
+  properties: Record<string, any>
+}
+
+export interface GraphNodeRequest {
+  id: string
+  label?: string
+  properties?: Record<string, any>
+}
+
+export interface GraphPathRequest {
+  from: string
+  max_depth: number
+  relations?: string[]
+  to: string
+}
+
+export interface GraphQueryRequest {
+  cursor?: string
+  limit?: number
+  offset?: number
+  order?: string
+  query?: string
+  sort?: string
+  traversal?: GraphTraversal
+}
+
+export interface GraphTraversal {
+  start: string[]
+  steps: GraphTraversalStep[]
+}
+
+export interface GraphTraversalStep {
+  limit?: number
+  object?: string
+  op: string
+  predicate?: string
+}
+
+export interface HybridSearchRequest {
+  candidates?: number
+  filter?: Record<string, any>
+  fusion?: string
+  keyword_weight?: number
+  limit?: number
+  query?: string
+  rrf_k?: number
+  vector?: number[]
+  vector_weight?: number
+}
+
+export interface Job {
+  collection: string
+  error?: string
+  failed: number
+  file?: string
+  finished_at?: string
+  id: string
+  processed: number
+  skipped: number
+  stage?: string
+  started_at: string
+  status: string
+  type: string
+}
+
+export interface QueryRequest {
+  fields?: string[]
+  limit?: number
+  selector: Record<string, any>
+  skip?: number
+  sort?: any[]
+}
+
+export interface ReembedRequest {
+  batch_size?: number
+  only_missing?: boolean
+}
+
+export interface RestoreRequest {
+  backup: string
+}
+
+export interface StorageStats {
+  content_bytes: number
+  data_bytes: number
+  embedding_bytes: number
+  total_bytes: number
+}
+
+export interface UpdateCollectionRequest {
+  embed_fields?: string[]
+  fts_fields?: string[]
+  name?: string
+  vector_dimension?: number
+}
+
+export interface VectorIndexStats {
+  column_type?: string
+  dimension?: number
+  name?: string
+  present: boolean
+}
+
+export interface VectorMultiSearchRequest {
+  limit?: number
+  queries: VectorSearchQuery[]
+  threshold?: number
+}
+
+export interface VectorSearchQuery {
+  query?: number[]
+  query_text?: string
+}
+
+export interface VectorSearchRequest {
+  collection?: string
+  field?: string
+  limit?: number
+  offset?: number
+  query?: number[]
+  query_text?: string
+  threshold?: number
+}
@@ -2,16 +2,29 @@ import axios from 'axios'
 
 const API_URL = import.meta.env.VITE_API_URL || '/api'
 
+const API_KEY = import.meta.env.VITE_API_KEY
+
 const api = axios.create({
   baseURL: API_URL,
   headers: {
     'Content-Type': 'application/json',
+    ...(API_KEY ? { Authorization: `Bearer ${API_KEY}` } : {}),
   },
 })
 
 export interface Document {
   id: string
   data: Record<string, any>
+  warnings?: string[]
+  deleted_at?: string
+}
+
+export interface Attachment {
+  name: string
+  content_type: string
+  size: number
+  sha256: string
+  created_at: string
 }
 
 export interface DocumentListResponse {
@@ -38,7 +51,9 @@ export interface VectorSearchRequest {
   query?: number[]      // 向量查询（可选）
   query_text?: string  // 文本查询（可选，将自动生成 embedding）
   limit?: number
+  offset?: number      // 分页偏移（可选）
   field?: string
+  threshold?: number
 }
 
 export interface VectorSearchResult {
@@ -52,7 +67,22 @@ export interface GraphLinkRequest {
   to: string
 }
 
-export interface GraphNeighborsResponse {
+export interface GraphPageParams {
+  limit?: number
+  offset?: number
+  cursor?: string
+  order?: 'asc' | 'desc'
+}
+
+export interface GraphPageInfo {
+  total: number
+  limit: number
+  offset: number
+  has_more: boolean
+  next_cursor: string
+}
+
+export interface GraphNeighborsResponse extends GraphPageInfo {
   node_id: string
   relation: string
   neighbors: string[]
@@ -71,8 +101,23 @@ export interface GraphPathResponse {
   paths: string[][]
 }
 
-export interface GraphQueryRequest {
-  query: string
+export interface GraphTraversalStep {
+  op: 'out' | 'in' | 'both' | 'has' | 'limit'
+  predicate?: string
+  object?: string
+  limit?: number
+}
+
+export interface GraphTraversal {
+  start: string[]
+  steps: GraphTraversalStep[]
+}
+
+export interface GraphQueryRequest extends GraphPageParams {
+  // query 和 traversal 二选一
+  query?: string
+  traversal?: GraphTraversal
+  sort?: 'subject' | 'predicate' | 'object'
 }
 
 export interface GraphQueryResult {
@@ -81,21 +126,180 @@ export interface GraphQueryResult {
   object: string
 }
 
-export interface GraphQueryResponse {
+export interface GraphQueryResponse extends GraphPageInfo {
   query: string
+  traversal: GraphTraversal
+  nodes: string[]
   results: GraphQueryResult[]
 }
 
+export interface GraphNode {
+  id: string
+  label?: string
+  properties: Record<string, any>
+  depth: number
+}
+
+export interface GraphEdge {
+  from: string
+  relation: string
+  to: string
+}
+
+export interface GraphSubgraphResponse {
+  root: string
+  depth: number
+  nodes: GraphNode[]
+  edges: GraphEdge[]
+  truncated: boolean
+}
+
 export interface FulltextSearchResponse {
   results: FulltextSearchResult[]
   took: number
 }
 
+export interface AnalyzeResponse {
+  text: string
+  normalized: string
+  tokens: string[]
+  stopwords: string[]
+  indexed_tokens: string[]
+  content_tokens: string
+}
+
 export interface VectorSearchResponse {
   results: VectorSearchResult[]
+  offset?: number
+  limit?: number
+  has_more?: boolean
+  took: number
+}
+
+export interface VectorMultiSearchRequest {
+  // 每个查询的 query 和 query_text 二选一
+  queries: Pick<VectorSearchRequest, 'query' | 'query_text'>[]
+  limit?: number
+  threshold?: number
+}
+
+export interface VectorMultiSearchResponse {
+  responses: {
+    index: number
+    query?: string
+    results: VectorSearchResult[]
+    has_more: boolean
+  }[]
+  limit: number
   took: number
 }
 
+export interface HybridSearchRequest {
+  query?: string
+  vector?: number[]
+  limit?: number
+  fusion?: 'rrf' | 'weighted'
+  rrf_k?: number
+  keyword_weight?: number
+  vector_weight?: number
+  candidates?: number
+  filter?: Record<string, any>
+}
+
+export interface HybridSearchResult {
+  document: Document
+  score: number
+  keyword_score?: number
+  keyword_rank?: number
+  vector_score?: number
+  vector_rank?: number
+}
+
+export interface HybridSearchResponse {
+  results: HybridSearchResult[]
+  modes: ('keyword' | 'vector')[]
+  warnings?: string[]
+  took: number
+}
+
+export interface CollectionSettings {
+  embed_fields?: string[]
+  fts_fields?: string[]
+  vector_dimension?: number
+}
+
+export interface CollectionInfo {
+  name: string
+  schema: Record<string, any>
+  settings?: CollectionSettings
+}
+
+export interface CollectionStats {
+  name: string
+  registered: boolean
+  document_count: number
+  trashed: number
+  embedded: number
+  unembedded: number
+  last_updated_at?: string
+  storage: {
+    data_bytes: number
+    content_bytes: number
+    embedding_bytes: number
+    total_bytes: number
+  }
+  fts: {
+    present: boolean
+    fresh: boolean
+    indexed: number
+    missing: number
+    stale: number
+    built_at?: string
+  }
+  vector_index: {
+    present: boolean
+    name?: string
+    column_type?: string
+    dimension?: number
+  }
+}
+
+export interface BackupInfo {
+  name: string
+  size: number
+  created_at: string
+}
+
+export interface Job {
+  id: string
+  type: string
+  collection: string
+  status: 'running' | 'completed' | 'failed'
+  stage?: string
+  file?: string
+  processed: number
+  skipped: number
+  failed: number
+  error?: string
+  started_at: string
+  finished_at?: string
+}
+
+export interface QueryRequest {
+  selector?: Record<string, any>
+  fields?: string[]
+  sort?: (string | Record<string, 'asc' | 'desc'>)[]
+  limit?: number
+  skip?: number
+}
+
+export interface BatchImportResponse {
+  inserted: number
+  skipped: number
+  failed: number
+  errors: { index: number; id?: string; error: string }[]
+}
+
 export const apiClient = {
   // 获取集合列表
   getCollections: async (): Promise<string[]> => {
@@ -103,6 +307,35 @@ export const apiClient = {
     return response.data.collections || []
   },
 
+  // 创建集合
+  createCollection: async (
+    name: string,
+    settings: CollectionSettings = {}
+  ): Promise<CollectionInfo> => {
+    const response = await api.post('/collections', { name, ...settings })
+    return response.data
+  },
+
+  // 修改集合配置或重命名集合
+  updateCollection: async (
+    name: string,
+    updates: CollectionSettings & { name?: string }
+  ): Promise<CollectionInfo> => {
+    const response = await api.patch(`/collections/${name}`, updates)
+    return response.data
+  },
+
+  // 删除集合及其所有文档
+  deleteCollection: async (name: string): Promise<void> => {
+    await api.delete(`/collections/${name}`)
+  },
+
+  // 获取集合统计和索引状态
+  getCollectionStats: async (name: string): Promise<CollectionStats> => {
+    const response = await api.get(`/collections/${name}/stats`)
+    return response.data
+  },
+
   // 获取文档列表
   getDocuments: async (
     collection: string,
@@ -135,7 +368,7 @@ export const apiClient = {
     return response.data
   },
 
-  // 更新文档
+  // 更新文档（updates 中带有 _rev 时，版本不一致会返回 409）
   updateDocument: async (
     collection: string,
     id: string,
@@ -149,8 +382,93 @@ export const apiClient = {
   },
 
   // 删除文档
-  deleteDocument: async (collection: string, id: string): Promise<void> => {
-    await api.delete(`/collections/${collection}/documents/${id}`)
+  deleteDocument: async (collection: string, id: string, permanent = false): Promise<void> => {
+    await api.delete(`/collections/${collection}/documents/${id}`, {
+      params: permanent ? { permanent: true } : undefined,
+    })
+  },
+
+  // 回收站
+  getTrash: async (collection: string, skip = 0, limit = 100): Promise<DocumentListResponse> => {
+    const response = await api.get(`/collections/${collection}/trash`, {
+      params: { skip, limit },
+    })
+    return response.data
+  },
+
+  restoreDocument: async (collection: string, id: string): Promise<void> => {
+    await api.post(`/collections/${collection}/trash/${id}/restore`)
+  },
+
+  purgeTrash: async (collection: string, id?: string): Promise<{ purged?: number }> => {
+    const url = id ? `/collections/${collection}/trash/${id}` : `/collections/${collection}/trash`
+    const response = await api.delete(url)
+    return response.data
+  },
+
+  // 文档附件
+  listAttachments: async (collection: string, id: string): Promise<Attachment[]> => {
+    const response = await api.get(`/collections/${collection}/documents/${id}/attachments`)
+    return response.data.attachments
+  },
+
+  uploadAttachment: async (collection: string, id: string, file: File): Promise<Attachment> => {
+    const response = await api.put(
+      `/collections/${collection}/documents/${id}/attachments/${encodeURIComponent(file.name)}`,
+      file,
+      { headers: { 'Content-Type': file.type || 'application/octet-stream' } }
+    )
+    return response.data
+  },
+
+  attachmentUrl: (collection: string, id: string, name: string): string => {
+    return `${api.defaults.baseURL}/collections/${encodeURIComponent(collection)}/documents/${encodeURIComponent(id)}/attachments/${encodeURIComponent(name)}`
+  },
+
+  deleteAttachment: async (collection: string, id: string, name: string): Promise<void> => {
+    await api.delete(`/collections/${collection}/documents/${id}/attachments/${encodeURIComponent(name)}`)
+  },
+
+  // 条件查询文档
+  queryDocuments: async (
+    collection: string,
+    query: QueryRequest
+  ): Promise<DocumentListResponse> => {
+    const response = await api.post(`/collections/${collection}/query`, query)
+    return response.data
+  },
+
+  // 启动 embedding 回填任务
+  reembedCollection: async (collection: string, onlyMissing = true): Promise<Job> => {
+    const response = await api.post(`/collections/${collection}/reembed`, {
+      only_missing: onlyMissing,
+    })
+    return response.data
+  },
+
+  // 查询后台任务状态
+  getJob: async (id: string): Promise<Job> => {
+    const response = await api.get(`/jobs/${id}`)
+    return response.data
+  },
+
+  // 批量导入文档（JSON 数组）
+  importDocuments: async (
+    collection: string,
+    documents: Record<string, any>[],
+    onConflict: 'error' | 'skip' | 'replace' = 'error'
+  ): Promise<BatchImportResponse> => {
+    const response = await api.post(
+      `/collections/${collection}/documents:batch`,
+      documents,
+      { params: { on_conflict: onConflict } }
+    )
+    return response.data
+  },
+
+  // 导出文档的下载地址
+  exportDocumentsUrl: (collection: string, format: 'ndjson' | 'parquet' = 'ndjson'): string => {
+    return `${api.defaults.baseURL}/collections/${encodeURIComponent(collection)}/export?format=${format}`
   },
 
   // 全文搜索
@@ -175,6 +493,12 @@ export const apiClient = {
     }
   },
 
+  // 分词预览
+  analyze: async (text: string): Promise<AnalyzeResponse> => {
+    const response = await api.get('/analyze', { params: { text } })
+    return response.data
+  },
+
   // 向量搜索
   vectorSearch: async (
     collection: string,
@@ -207,6 +531,28 @@ export const apiClient = {
     }
   },
 
+  // 批量向量搜索
+  vectorMultiSearch: async (
+    collection: string,
+    request: VectorMultiSearchRequest
+  ): Promise<VectorMultiSearchResponse> => {
+    const response = await api.post(`/collections/${collection}/vector/msearch`, request)
+    return response.data
+  },
+
+  // 混合检索
+  hybridSearch: async (
+    collection: string,
+    request: HybridSearchRequest
+  ): Promise<HybridSearchResponse> => {
+    const response = await api.post(`/collections/${collection}/search`, request)
+    return {
+      ...response.data,
+      results: response.data.results || [],
+      took: response.data.took || 0,
+    }
+  },
+
   // 图数据库操作
   // 创建图关系链接
   graphLink: async (from: string, relation: string, to: string): Promise<void> => {
@@ -221,9 +567,10 @@ export const apiClient = {
   // 获取节点的邻居
   graphNeighbors: async (
     nodeId: string,
-    relation?: string
+    relation?: string,
+    page: GraphPageParams = {}
   ): Promise<GraphNeighborsResponse> => {
-    const params = relation ? { relation } : {}
+    const params = relation ? { relation, ...page } : page
     const response = await api.get(`/graph/neighbors/${nodeId}`, { params })
     return response.data
   },
@@ -245,11 +592,87 @@ export const apiClient = {
   },
 
   // 执行图查询
-  graphQuery: async (query: string): Promise<GraphQueryResponse> => {
-    const response = await api.post('/graph/query', { query })
+  graphQuery: async (
+    query: string,
+    options: Omit<GraphQueryRequest, 'query' | 'traversal'> = {}
+  ): Promise<GraphQueryResponse> => {
+    const response = await api.post('/graph/query', { query, ...options })
+    return response.data
+  },
+
+  // 执行 JSON 形式的图遍历
+  graphTraverse: async (
+    traversal: GraphTraversal,
+    options: Omit<GraphQueryRequest, 'query' | 'traversal'> = {}
+  ): Promise<GraphQueryResponse> => {
+    const response = await api.post('/graph/query', { traversal, ...options })
+    return response.data
+  },
+
+  // 批量创建边
+  graphBulkLink: async (links: GraphEdge[]): Promise<void> => {
+    await api.post('/graph/links/bulk', { links })
+  },
+
+  // 创建或更新节点
+  graphSaveNode: async (
+    id: string,
+    properties: Record<string, any> = {},
+    label?: string
+  ): Promise<GraphNode> => {
+    const response = await api.post('/graph/nodes', { id, label, properties })
+    return response.data
+  },
+
+  // 删除节点（cascade 为 true 时连同边一起删除）
+  graphDeleteNode: async (id: string, cascade = false): Promise<void> => {
+    await api.delete(`/graph/nodes/${id}`, { params: { cascade } })
+  },
+
+  // 获取以节点为中心的子图
+  graphSubgraph: async (
+    nodeId: string,
+    depth = 1,
+    options: { relation?: string; direction?: 'out' | 'in' | 'both'; limit?: number } = {}
+  ): Promise<GraphSubgraphResponse> => {
+    const response = await api.get(`/graph/subgraph/${nodeId}`, {
+      params: { depth, ...options },
+    })
+    return response.data
+  },
+
+  // 启动备份任务
+  createBackup: async (): Promise<Job> => {
+    const response = await api.post('/admin/backup')
+    return response.data
+  },
+
+  // 列出备份文件
+  listBackups: async (): Promise<BackupInfo[]> => {
+    const response = await api.get('/admin/backups')
+    return response.data.backups
+  },
+
+  // 备份文件下载地址
+  backupDownloadUrl: (file: string): string => {
+    return `${api.defaults.baseURL}/admin/backups/${encodeURIComponent(file)}`
+  },
+
+  // 从备份目录中已有的备份恢复
+  restoreBackup: async (backup: string): Promise<Job> => {
+    const response = await api.post('/admin/restore', { backup })
     return response.data
   },
-}
 
-export default api
+  // 上传备份归档并恢复
+  uploadRestore: async (file: File): Promise<Job> => {
+    const form = new FormData()
+    form.append('file', file)
+    const response = await api.post('/admin/restore', form, {
+      headers: { 'Content-Type': 'multipart/form-data' },
+    })
+    return response.data
+  },
+}
 
+export default api
\ No newline at end of file
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/components/tool"
+	"github.com/cloudwego/eino/components/tool/utils"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/flow/agent/react"
+	"github.com/cloudwego/eino/schema"
+	"github.com/gin-gonic/gin"
+	ragflow "github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/flow/rag"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// agentMaxStep Agent 图的最大步数，每轮工具调用占两步
+	agentMaxStep = 20
+	// agentSQLMaxRows run_sql 返回的最大行数
+	agentSQLMaxRows = 50
+	// agentSearchMaxTopK search_documents 的最大 top_k
+	agentSearchMaxTopK = 20
+	// agentToolResultRunes 记录到 steps 中的工具结果最大字符数
+	agentToolResultRunes = 2000
+	// agentSQLView run_sql 可以查询的视图名，对应当前知识库的分块
+	agentSQLView = "documents"
+)
+
+// AgentStep Agent 的一次工具调用
+type AgentStep struct {
+	Tool      string `json:"tool"`
+	Arguments string `json:"arguments"`
+	Result    string `json:"result"`
+	Error     string `json:"error,omitempty"`
+	TookMs    int64  `json:"took_ms"`
+}
+
+type agentStepsKey struct{}
+
+// agentStepRecorder 收集一次 Agent 调用中的工具调用
+type agentStepRecorder struct {
+	mu    sync.Mutex
+	steps []AgentStep
+}
+
+// recordingTool 记录工具调用，并将工具错误作为结果返回给模型，让模型可以修正参数后重试
+type recordingTool struct {
+	tool.InvokableTool
+}
+
+func (t *recordingTool) InvokableRun(ctx context.Context, argumentsInJSON string, opts ...tool.Option) (string, error) {
+	info, err := t.Info(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	start := time.Now()
+	result, err := t.InvokableTool.InvokableRun(ctx, argumentsInJSON, opts...)
+	step := AgentStep{
+		Tool:      info.Name,
+		Arguments: argumentsInJSON,
+		Result:    truncateRunes(result, agentToolResultRunes),
+		TookMs:    time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		step.Error = err.Error()
+		result = "error: " + err.Error()
+	}
+	if recorder, ok := ctx.Value(agentStepsKey{}).(*agentStepRecorder); ok {
+		recorder.mu.Lock()
+		recorder.steps = append(recorder.steps, step)
+		recorder.mu.Unlock()
+	}
+	return result, nil
+}
+
+type SearchDocumentsInput struct {
+	Query string `json:"query" jsonschema:"description=the search query"`
+	TopK  int    `json:"top_k,omitempty" jsonschema:"description=number of chunks to return (default 5\\, max 20)"`
+}
+
+// SearchDocumentsResult search_documents 返回的一个分块
+type SearchDocumentsResult struct {
+	ID       string  `json:"id"`
+	Score    float64 `json:"score"`
+	Filename string  `json:"filename,omitempty"`
+	URL      string  `json:"url,omitempty"`
+	Content  string  `json:"content"`
+}
+
+type RunSQLInput struct {
+	Query string `json:"query" jsonschema:"description=a single read-only SELECT statement over the documents view"`
+}
+
+// RunSQLResult run_sql 的查询结果，超过 agentSQLMaxRows 行时截断
+type RunSQLResult struct {
+	Columns   []string `json:"columns"`
+	Rows      [][]any  `json:"rows"`
+	Truncated bool     `json:"truncated,omitempty"`
+}
+
+// newAgentTools 创建绑定到知识库的工具
+func newAgentTools(kb *KnowledgeBase) ([]tool.BaseTool, error) {
+	searchTool, err := utils.InferTool("search_documents",
+		"Semantic search over the knowledge base. Returns the most relevant document chunks with their scores.",
+		func(ctx context.Context, input *SearchDocumentsInput) ([]SearchDocumentsResult, error) {
+			return searchDocuments(ctx, kb, input)
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlTool, err := utils.InferTool("run_sql",
+		"Run a read-only DuckDB SELECT over the documents view (one row per chunk) for counting, grouping and other aggregations over document metadata.",
+		func(ctx context.Context, input *RunSQLInput) (*RunSQLResult, error) {
+			return runAgentSQL(ctx, kb, input.Query)
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	return []tool.BaseTool{
+		&recordingTool{InvokableTool: searchTool},
+		&recordingTool{InvokableTool: sqlTool},
+	}, nil
+}
+
+func searchDocuments(ctx context.Context, kb *KnowledgeBase, input *SearchDocumentsInput) ([]SearchDocumentsResult, error) {
+	if strings.TrimSpace(input.Query) == "" {
+		return nil, errors.New("query is required")
+	}
+	topK := input.TopK
+	if topK <= 0 {
+		topK = 5
+	}
+	topK = min(topK, agentSearchMaxTopK)
+
+	docs, err := kb.ragConfig.Retriever.Retrieve(ctx, input.Query, retriever.WithTopK(topK))
+	if err != nil {
+		return nil, err
+	}
+	results := make([]SearchDocumentsResult, 0, len(docs))
+	for _, doc := range docs {
+		r := SearchDocumentsResult{ID: doc.ID, Content: doc.Content}
+		r.Score, _ = ragflow.DocScore(doc)
+		r.Filename, _ = doc.MetaData["filename"].(string)
+		r.URL, _ = doc.MetaData["url"].(string)
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// runAgentSQL 在当前知识库的 documents 视图上执行只读查询
+// documents 是连接级的临时视图；语句先经 json_serialize_sql 解析，只允许单条 SELECT 且只能引用 documents，
+// 并在回滚的事务中执行
+func runAgentSQL(ctx context.Context, kb *KnowledgeBase, query string) (*RunSQLResult, error) {
+	query = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(query), ";"))
+	if query == "" {
+		return nil, errors.New("query is required")
+	}
+
+	conn, err := kb.store.GetDB().Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := checkAgentSQL(ctx, conn, query); err != nil {
+		return nil, err
+	}
+
+	_, err = conn.ExecContext(ctx, fmt.Sprintf(`CREATE OR REPLACE TEMP VIEW %s AS
+		SELECT id,
+			CASE WHEN json_valid(content) THEN json_extract_string(content, '$.content') ELSE content END AS content,
+			metadata->>'filename' AS filename,
+			metadata->>'filetype' AS filetype,
+			metadata->>'source_id' AS source_id,
+			metadata->>'url' AS url,
+			TRY_CAST(metadata->>'page' AS INTEGER) AS page,
+			metadata,
+			created_at
+		FROM %s`, agentSQLView, kb.store.GetTableName()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create documents view: %w", err)
+	}
+	// 连接会放回连接池，用完删除临时视图
+	defer conn.ExecContext(context.WithoutCancel(ctx), "DROP VIEW IF EXISTS temp."+agentSQLView)
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	result := &RunSQLResult{Columns: columns, Rows: [][]any{}}
+	for rows.Next() {
+		if len(result.Rows) == agentSQLMaxRows {
+			result.Truncated = true
+			break
+		}
+		values := make([]any, len(columns))
+		ptrs := make([]any, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	return result, rows.Err()
+}
+
+// checkAgentSQL 解析语句并检查引用的表
+func checkAgentSQL(ctx context.Context, conn *sql.Conn, query string) error {
+	var astJSON string
+	if err := conn.QueryRowContext(ctx, "SELECT CAST(json_serialize_sql(?::VARCHAR) AS VARCHAR)", query).Scan(&astJSON); err != nil {
+		return fmt.Errorf("failed to parse query: %w", err)
+	}
+	var ast struct {
+		Error        bool   `json:"error"`
+		ErrorMessage string `json:"error_message"`
+		Statements   []any  `json:"statements"`
+	}
+	if err := json.Unmarshal([]byte(astJSON), &ast); err != nil {
+		return fmt.Errorf("failed to parse query: %w", err)
+	}
+	if ast.Error {
+		return fmt.Errorf("only SELECT statements are allowed: %s", ast.ErrorMessage)
+	}
+	if len(ast.Statements) != 1 {
+		return errors.New("exactly one statement is allowed")
+	}
+	return checkAgentSQLNode(ast.Statements[0])
+}
+
+// checkAgentSQLNode 遍历语法树，只允许引用 documents 视图，禁止 CTE 和表函数（如 read_csv）
+func checkAgentSQLNode(node any) error {
+	switch n := node.(type) {
+	case map[string]any:
+		if cte, ok := n["cte_map"].(map[string]any); ok {
+			if entries, _ := cte["map"].([]any); len(entries) > 0 {
+				return errors.New("WITH clauses are not supported, use subqueries instead")
+			}
+		}
+		switch n["type"] {
+		case "TABLE_FUNCTION":
+			return errors.New("table functions are not allowed")
+		case "BASE_TABLE":
+			schemaName, _ := n["schema_name"].(string)
+			catalogName, _ := n["catalog_name"].(string)
+			tableName, _ := n["table_name"].(string)
+			if tableName != agentSQLView || (schemaName != "" && schemaName != "temp") || catalogName != "" {
+				return fmt.Errorf("only the %s view can be queried", agentSQLView)
+			}
+		}
+		for _, v := range n {
+			if err := checkAgentSQLNode(v); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, v := range n {
+			if err := checkAgentSQLNode(v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type AgentRequest struct {
+	Message string `json:"message"`
+	// SessionID 会话 ID，与 /api/chat 共用会话
+	SessionID string `json:"session_id,omitempty"`
+}
+
+type AgentResponse struct {
+	SessionID string      `json:"session_id"`
+	Answer    string      `json:"answer"`
+	Steps     []AgentStep `json:"steps"`
+	TookMs    int64       `json:"took_ms"`
+}
+
+// handleAgent 使用工具调用的 Agent 回答问题，模型自行决定检索文档或执行 SQL，适合多步和聚合类问题
+func handleAgent(c *gin.Context) {
+	var req AgentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Message) == "" {
+		c.JSON(400, gin.H{"error": "Message is required"})
+		return
+	}
+	if sharedRAG == nil || sharedRAG.toolCallingModel == nil {
+		c.JSON(500, gin.H{"error": "Tool calling model not initialized"})
+		return
+	}
+	kb, ok := resolveKnowledgeBase(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	db := vecStoreInstance.GetDB()
+
+	tools, err := newAgentTools(kb)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to create tools: %v", err)})
+		return
+	}
+	agent, err := react.NewAgent(ctx, &react.AgentConfig{
+		ToolCallingModel: sharedRAG.toolCallingModel,
+		ToolsConfig:      compose.ToolsNodeConfig{Tools: tools},
+		MaxStep:          agentMaxStep,
+	})
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to create agent: %v", err)})
+		return
+	}
+
+	sessionID, err := ensureSession(ctx, db, req.SessionID, req.Message)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	history, err := loadHistory(ctx, db, sessionID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+
+	messages := make([]*schema.Message, 0, len(history)+2)
+	messages = append(messages, schema.SystemMessage(getPrompt(PromptAgentSystem)))
+	messages = append(messages, history...)
+	messages = append(messages, schema.UserMessage(req.Message))
+
+	log := logrus.WithFields(logrus.Fields{"kb": kb.Name, "session_id": sessionID})
+	log.WithField("message", req.Message).Info("Starting agent query")
+
+	recorder := &agentStepRecorder{}
+	start := time.Now()
+	answer, err := agent.Generate(context.WithValue(ctx, agentStepsKey{}, recorder), messages)
+	if err != nil {
+		log.WithError(err).Error("Agent query failed")
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Agent failed: %v", err), "steps": recorder.steps})
+		return
+	}
+
+	if err := appendSessionMessages(context.WithoutCancel(ctx), db, sessionID,
+		schema.UserMessage(req.Message), schema.AssistantMessage(answer.Content, nil)); err != nil {
+		log.WithError(err).Error("Failed to save chat history")
+	}
+
+	steps := recorder.steps
+	if steps == nil {
+		steps = []AgentStep{}
+	}
+	log.WithField("steps", len(steps)).Info("Agent query completed")
+
+	c.Header("X-Session-ID", sessionID)
+	c.JSON(200, AgentResponse{
+		SessionID: sessionID,
+		Answer:    answer.Content,
+		Steps:     steps,
+		TookMs:    time.Since(start).Milliseconds(),
+	})
+}
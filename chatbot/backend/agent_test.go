@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/vecstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckAgentSQL(t *testing.T) {
+	db, _ := setupTestKB(t)
+	ctx := context.Background()
+
+	// 另一个知识库的表
+	other := vecstore.New(vecstore.Options{TableName: kbTableName("other"), DB: db})
+	require.NoError(t, other.Initialize(ctx))
+
+	conn, err := db.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	tests := []struct {
+		name    string
+		query   string
+		allowed bool
+	}{
+		{name: "select documents", query: "SELECT filename, COUNT(*) FROM documents GROUP BY filename", allowed: true},
+		{name: "temp schema", query: "SELECT id FROM temp.documents", allowed: true},
+		{name: "subquery", query: "SELECT * FROM (SELECT id, page FROM documents WHERE page > 1) t", allowed: true},
+		{name: "scalar subquery", query: "SELECT id FROM documents WHERE page = (SELECT MAX(page) FROM documents)", allowed: true},
+		{name: "own table", query: "SELECT * FROM vecstore_documents"},
+		{name: "other kb table", query: "SELECT * FROM vecstore_kb_other"},
+		{name: "sessions table", query: "SELECT * FROM chat_sessions"},
+		{name: "join other table", query: "SELECT * FROM documents JOIN chat_answers ON true"},
+		{name: "table in subquery", query: "SELECT * FROM documents WHERE id IN (SELECT id FROM vecstore_kb_other)"},
+		{name: "read_csv", query: "SELECT * FROM read_csv('/etc/passwd')"},
+		{name: "read_text", query: "SELECT * FROM read_text('/etc/passwd')"},
+		{name: "file path", query: "SELECT * FROM '/etc/passwd'"},
+		{name: "cte", query: "WITH d AS (SELECT * FROM documents) SELECT * FROM d"},
+		{name: "cte shadowing documents", query: "WITH documents AS (SELECT * FROM chat_sessions) SELECT * FROM documents"},
+		{name: "attach", query: "ATTACH 'other.db' AS other"},
+		{name: "copy", query: "COPY documents TO 'out.csv'"},
+		{name: "pragma", query: "PRAGMA database_list"},
+		{name: "delete", query: "DELETE FROM documents"},
+		{name: "two statements", query: "SELECT * FROM documents; DROP TABLE vecstore_documents"},
+		{name: "main schema", query: "SELECT * FROM main.documents"},
+		{name: "catalog", query: "SELECT * FROM memory.temp.documents"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkAgentSQL(ctx, conn, tt.query)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestRunAgentSQL(t *testing.T) {
+	_, kb := setupTestKB(t)
+	ctx := context.Background()
+
+	_, err := kb.indexer.indexer.Store(ctx, []*schema.Document{
+		{ID: "a_chunk_0", Content: "first", MetaData: map[string]any{"filename": "a.txt", "page": 1}},
+		{ID: "a_chunk_1", Content: "second", MetaData: map[string]any{"filename": "a.txt", "page": 2}},
+		{ID: "b_chunk_0", Content: "third", MetaData: map[string]any{"filename": "b.txt"}},
+	})
+	require.NoError(t, err)
+
+	result, err := runAgentSQL(ctx, kb, "SELECT filename, COUNT(*) AS n FROM documents GROUP BY filename ORDER BY filename;")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"filename", "n"}, result.Columns)
+	require.Len(t, result.Rows, 2)
+	assert.Equal(t, "a.txt", result.Rows[0][0])
+	assert.EqualValues(t, 2, result.Rows[0][1])
+
+	_, err = runAgentSQL(ctx, kb, "SELECT * FROM vecstore_documents")
+	assert.Error(t, err)
+	_, err = runAgentSQL(ctx, kb, "  ")
+	assert.Error(t, err)
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/gin-gonic/gin"
+	ragflow "github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/flow/rag"
+)
+
+// citationSnippetRunes 引用片段的最大字符数
+const citationSnippetRunes = 200
+
+// Citation 回答中 [n] 对应的检索分块
+type Citation struct {
+	Index      int     `json:"index"`
+	DocumentID string  `json:"document_id"`
+	SourceID   string  `json:"source_id,omitempty"`
+	Filename   string  `json:"filename,omitempty"`
+	URL        string  `json:"url,omitempty"`
+	Page       int     `json:"page,omitempty"`
+	Section    string  `json:"section,omitempty"`
+	Score      float64 `json:"score"`
+	Snippet    string  `json:"snippet"`
+	// SourceURL 下载原始文件的接口路径，仅上传的文件有原始文件
+	SourceURL string `json:"source_url,omitempty"`
+}
+
+type citationsKey struct{}
+
+// citationCollector 收集一次对话中 RAG Chain 检索到的文档
+type citationCollector struct {
+	docs []*schema.Document
+}
+
+// withCitationCollector 在 context 中附加收集器，由 collectingContextFormatter 填充
+func withCitationCollector(ctx context.Context) (context.Context, *citationCollector) {
+	collector := &citationCollector{}
+	return context.WithValue(ctx, citationsKey{}, collector), collector
+}
+
+// collectingContextFormatter 记录检索到的文档后按提示词模板格式化，文档顺序与 prompt 中的 [n] 一致
+func collectingContextFormatter(ctx context.Context, input *ragflow.ContextInput) (string, error) {
+	if collector, ok := ctx.Value(citationsKey{}).(*citationCollector); ok && input != nil {
+		collector.docs = input.Docs
+	}
+	return promptContextFormatter(ctx, input)
+}
+
+// buildCitations 将检索到的文档转换为引用信息，kb 为文档所属的知识库
+func buildCitations(docs []*schema.Document, kb string) []Citation {
+	citations := make([]Citation, 0, len(docs))
+	for i, doc := range docs {
+		citation := Citation{
+			Index:      i + 1,
+			DocumentID: doc.ID,
+			Snippet:    truncateRunes(doc.Content, citationSnippetRunes),
+		}
+		citation.Score, _ = ragflow.DocScore(doc)
+
+		meta := doc.MetaData
+		citation.SourceID, _ = meta["source_id"].(string)
+		citation.Filename, _ = meta["filename"].(string)
+		citation.URL, _ = meta["url"].(string)
+		// 元数据经过 JSON 存储后数字为 float64
+		if page, ok := meta["page"].(float64); ok {
+			citation.Page = int(page)
+		}
+		citation.Section = citationSection(meta)
+		if citation.SourceID != "" {
+			citation.SourceURL = "/api/documents/" + url.PathEscape(doc.ID) + "/source"
+			if kb != defaultKB {
+				citation.SourceURL += "?kb=" + url.QueryEscape(kb)
+			}
+		}
+		citations = append(citations, citation)
+	}
+	return citations
+}
+
+// citationSection 根据解析器写入的元数据描述分块所在的位置
+func citationSection(meta map[string]any) string {
+	if sheet, ok := meta["sheet"].(string); ok && sheet != "" {
+		return sheet
+	}
+	if start, ok := meta["start"].(float64); ok {
+		if end, ok := meta["end"].(float64); ok {
+			return formatTimestamp(start) + "-" + formatTimestamp(end)
+		}
+		return formatTimestamp(start)
+	}
+	section, _ := meta["sectionType"].(string)
+	return section
+}
+
+// formatTimestamp 将秒数格式化为 hh:mm:ss
+func formatTimestamp(seconds float64) string {
+	s := int(seconds)
+	return fmt.Sprintf("%02d:%02d:%02d", s/3600, s/60%60, s%60)
+}
+
+// truncateRunes 截断到 n 个字符
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "…"
+}
+
+// handleDownloadSource 下载文档分块所属的原始文件
+func handleDownloadSource(c *gin.Context) {
+	if vecStoreInstance == nil {
+		c.JSON(500, gin.H{"error": "VecStore not initialized"})
+		return
+	}
+	kb, ok := resolveKnowledgeBase(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	db := kb.store.GetDB()
+
+	var metadataRaw interface{}
+	query := fmt.Sprintf("SELECT metadata FROM %s WHERE id = ?", kb.store.GetTableName())
+	err := db.QueryRowContext(ctx, query, c.Param("id")).Scan(&metadataRaw)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(404, gin.H{"error": "Document not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to get document: %v", err)})
+		return
+	}
+
+	var metadata map[string]any
+	switch v := metadataRaw.(type) {
+	case string:
+		json.Unmarshal([]byte(v), &metadata)
+	case []byte:
+		json.Unmarshal(v, &metadata)
+	case map[string]any:
+		metadata = v
+	}
+	sourceID, _ := metadata["source_id"].(string)
+	if sourceID == "" {
+		c.JSON(404, gin.H{"error": "Document has no source file"})
+		return
+	}
+
+	job, err := getIngestJob(ctx, db, sourceID)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(404, gin.H{"error": "Source file not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to get source: %v", err)})
+		return
+	}
+	if _, err := os.Stat(job.filePath); err != nil {
+		c.JSON(404, gin.H{"error": "Source file not found"})
+		return
+	}
+
+	c.FileAttachment(job.filePath, job.Filename)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/config"
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/embedding"
+)
+
+// appConfig 启动时加载的配置（CONFIG_FILE 指向的配置文件，环境变量优先），说明见 pkg/config
+var appConfig *config.Config
+
+// defaultConfig chatbot 后端的默认配置
+// 向量化默认沿用 LLM 的 OpenAI 兼容服务，数据库固定为 duckdb-driver 的共享数据库，不使用 database.path
+func defaultConfig() config.Config {
+	return config.Config{
+		Server:    config.ServerConfig{Port: 45111},
+		Embedding: config.EmbeddingConfig{Provider: embedding.ProviderOpenAI},
+		LLM: config.LLMConfig{
+			Provider: "openai",
+			BaseURL:  "https://api.openai.com/v1",
+			Model:    "gpt-4o-mini",
+		},
+	}
+}
+
+// originAllowed 判断请求来源是否在 cors.allowed_origins 中，未配置时允许所有来源
+func originAllowed(origin string) bool {
+	if len(appConfig.CORS.AllowedOrigins) == 0 {
+		return true
+	}
+	for _, allowed := range appConfig.CORS.AllowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/gin-gonic/gin"
+	ragflow "github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/flow/rag"
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/sego"
+)
+
+// debugKeywordCount 从问题中提取的关键词数
+const debugKeywordCount = 10
+
+type DebugRetrieveRequest struct {
+	Query string `json:"query"`
+	// SessionID 可选，带上会话历史渲染 prompt，与 /api/chat 看到的一致（不会触发摘要）
+	SessionID string `json:"session_id,omitempty"`
+}
+
+// DebugDocument 一个检索结果
+type DebugDocument struct {
+	Rank            int            `json:"rank"`
+	ID              string         `json:"id"`
+	Score           float64        `json:"score"`
+	Content         string         `json:"content"`
+	Metadata        map[string]any `json:"metadata,omitempty"`
+	MatchedKeywords []string       `json:"matched_keywords"`
+}
+
+// DebugTriple 图谱三元组
+type DebugTriple struct {
+	Subject   string `json:"subject"`
+	Predicate string `json:"predicate"`
+	Object    string `json:"object"`
+}
+
+// DebugMessage prompt 中的一条消息
+type DebugMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type DebugRetrieveResponse struct {
+	Query string `json:"query"`
+	// Keywords 问题的关键词（sego 提取），用于判断召回的分块是否包含问题中的关键信息
+	Keywords  []string        `json:"keywords"`
+	Documents []DebugDocument `json:"documents"`
+	Triples   []DebugTriple   `json:"triples"`
+	// Context 注入 prompt 的背景信息
+	Context string         `json:"context"`
+	Prompt  []DebugMessage `json:"prompt"`
+	TookMs  int64          `json:"took_ms"`
+}
+
+// handleDebugRetrieve 执行与 /api/chat 相同的检索和 prompt 渲染，但不调用模型，返回中间结果
+// 使用所选知识库构建 RAG Chain 时的配置复现检索过程
+func handleDebugRetrieve(c *gin.Context) {
+	var req DebugRetrieveRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.Query) == "" {
+		c.JSON(400, gin.H{"error": "query is required"})
+		return
+	}
+	kb, ok := resolveKnowledgeBase(c)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	if req.SessionID != "" {
+		history, err := loadRecentHistory(ctx, vecStoreInstance.GetDB(), req.SessionID)
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(404, gin.H{"error": "Session not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(500, gin.H{"error": err.Error()})
+			return
+		}
+		ctx = ragflow.WithHistory(ctx, history)
+	}
+
+	start := time.Now()
+	exp, err := ragflow.Explain(ctx, kb.ragConfig, req.Query)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to retrieve: %v", err)})
+		return
+	}
+
+	resp := DebugRetrieveResponse{
+		Query:     req.Query,
+		Keywords:  sego.ExtractKeywords(req.Query, debugKeywordCount),
+		Documents: make([]DebugDocument, 0, len(exp.Input.Docs)),
+		Triples:   make([]DebugTriple, 0, len(exp.Input.Triples)),
+		Context:   exp.Context,
+		Prompt:    make([]DebugMessage, 0, len(exp.Messages)),
+		TookMs:    time.Since(start).Milliseconds(),
+	}
+	if resp.Keywords == nil {
+		resp.Keywords = []string{}
+	}
+	for _, t := range exp.Input.Triples {
+		resp.Triples = append(resp.Triples, DebugTriple{Subject: t.Subject, Predicate: t.Predicate, Object: t.Object})
+	}
+	for i, doc := range exp.Input.Docs {
+		resp.Documents = append(resp.Documents, debugDocument(i+1, doc, resp.Keywords))
+	}
+	for _, msg := range exp.Messages {
+		resp.Prompt = append(resp.Prompt, DebugMessage{Role: string(msg.Role), Content: msg.Content})
+	}
+
+	c.JSON(200, resp)
+}
+
+// debugDocument 转换检索结果，并标出其中出现的关键词
+func debugDocument(rank int, doc *schema.Document, keywords []string) DebugDocument {
+	score, _ := ragflow.DocScore(doc)
+	matched := []string{}
+	content := strings.ToLower(doc.Content)
+	for _, keyword := range keywords {
+		if strings.Contains(content, strings.ToLower(keyword)) {
+			matched = append(matched, keyword)
+		}
+	}
+	return DebugDocument{
+		Rank:            rank,
+		ID:              doc.ID,
+		Score:           score,
+		Content:         doc.Content,
+		Metadata:        doc.MetaData,
+		MatchedKeywords: matched,
+	}
+}
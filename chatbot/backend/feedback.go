@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	ragflow "github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/flow/rag"
+)
+
+// 反馈评分
+const (
+	RatingUp   = "up"
+	RatingDown = "down"
+)
+
+// AnswerChunk 生成回答时检索到的分块
+type AnswerChunk struct {
+	DocumentID string  `json:"document_id"`
+	Score      float64 `json:"score"`
+	Content    string  `json:"content"`
+}
+
+// initFeedback 创建回答记录表和反馈表
+// chat_answers 记录每次回答的问题、检索到的分块和回答，反馈通过 answer_id 关联
+func initFeedback(ctx context.Context, db *sql.DB) error {
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS chat_answers (
+			id VARCHAR PRIMARY KEY,
+			session_id VARCHAR,
+			kb VARCHAR,
+			question VARCHAR,
+			answer VARCHAR,
+			chunks JSON,
+			created_at TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS answer_feedback (
+			answer_id VARCHAR PRIMARY KEY,
+			rating VARCHAR,
+			correction VARCHAR DEFAULT '',
+			comment VARCHAR DEFAULT '',
+			created_at TIMESTAMP,
+			updated_at TIMESTAMP
+		)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to create feedback tables: %w", err)
+		}
+	}
+	return nil
+}
+
+// recordAnswer 保存一次回答及其检索到的分块，返回 answer ID
+func recordAnswer(ctx context.Context, db *sql.DB, sessionID, kb, question, answer string, docs []*schema.Document) (string, error) {
+	chunks := make([]AnswerChunk, 0, len(docs))
+	for _, doc := range docs {
+		score, _ := ragflow.DocScore(doc)
+		chunks = append(chunks, AnswerChunk{DocumentID: doc.ID, Score: score, Content: doc.Content})
+	}
+	chunksJSON, err := json.Marshal(chunks)
+	if err != nil {
+		return "", err
+	}
+
+	id := uuid.NewString()
+	if _, err := db.ExecContext(ctx, `
+		INSERT INTO chat_answers (id, session_id, kb, question, answer, chunks, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, id, sessionID, kb, question, answer, string(chunksJSON), time.Now()); err != nil {
+		return "", fmt.Errorf("failed to record answer: %w", err)
+	}
+	return id, nil
+}
+
+type FeedbackRequest struct {
+	AnswerID string `json:"answer_id"`
+	// Rating up 或 down
+	Rating string `json:"rating"`
+	// Correction 可选，用户给出的正确答案，导出时作为 ground_truth
+	Correction string `json:"correction,omitempty"`
+	Comment    string `json:"comment,omitempty"`
+}
+
+// handleFeedback 记录对回答的评价，同一回答重复提交时覆盖之前的反馈
+func handleFeedback(c *gin.Context) {
+	var req FeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if req.AnswerID == "" {
+		c.JSON(400, gin.H{"error": "answer_id is required"})
+		return
+	}
+	if req.Rating != RatingUp && req.Rating != RatingDown {
+		c.JSON(400, gin.H{"error": "rating must be up or down"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	db := vecStoreInstance.GetDB()
+
+	var exists bool
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) > 0 FROM chat_answers WHERE id = ?`, req.AnswerID).Scan(&exists); err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to get answer: %v", err)})
+		return
+	}
+	if !exists {
+		c.JSON(404, gin.H{"error": "Answer not found"})
+		return
+	}
+
+	now := time.Now()
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO answer_feedback (answer_id, rating, correction, comment, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (answer_id) DO UPDATE SET
+			rating = excluded.rating, correction = excluded.correction,
+			comment = excluded.comment, updated_at = excluded.updated_at
+	`, req.AnswerID, req.Rating, strings.TrimSpace(req.Correction), req.Comment, now, now)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to save feedback: %v", err)})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Feedback saved successfully"})
+}
+
+// EvalRecord 评估数据集中的一条样本，字段名与常见的 RAG 评估工具（如 ragas）一致
+type EvalRecord struct {
+	AnswerID   string   `json:"answer_id"`
+	SessionID  string   `json:"session_id"`
+	KB         string   `json:"kb"`
+	Question   string   `json:"question"`
+	Answer     string   `json:"answer"`
+	Contexts   []string `json:"contexts"`
+	ContextIDs []string `json:"context_ids"`
+	// GroundTruth 用户的更正；好评且没有更正时为原回答，差评且没有更正时为空
+	GroundTruth string    `json:"ground_truth"`
+	Rating      string    `json:"rating"`
+	Comment     string    `json:"comment,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// handleExportFeedback 导出有反馈的回答作为离线评估数据集
+// 查询参数：rating 只导出 up 或 down，kb 只导出指定知识库，format 为 jsonl（默认）或 json
+func handleExportFeedback(c *gin.Context) {
+	rating := c.Query("rating")
+	if rating != "" && rating != RatingUp && rating != RatingDown {
+		c.JSON(400, gin.H{"error": "rating must be up or down"})
+		return
+	}
+	format := c.DefaultQuery("format", "jsonl")
+	if format != "jsonl" && format != "json" {
+		c.JSON(400, gin.H{"error": "format must be jsonl or json"})
+		return
+	}
+
+	query := `
+		SELECT a.id, a.session_id, a.kb, a.question, a.answer, CAST(a.chunks AS VARCHAR),
+			f.rating, f.correction, f.comment, a.created_at
+		FROM chat_answers a
+		JOIN answer_feedback f ON f.answer_id = a.id
+		WHERE (? = '' OR f.rating = ?) AND (? = '' OR a.kb = ?)
+		ORDER BY a.created_at
+	`
+	kb := c.Query("kb")
+	rows, err := vecStoreInstance.GetDB().QueryContext(c.Request.Context(), query, rating, rating, kb, kb)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to export feedback: %v", err)})
+		return
+	}
+	defer rows.Close()
+
+	records := []EvalRecord{}
+	for rows.Next() {
+		var r EvalRecord
+		var chunksJSON, correction string
+		if err := rows.Scan(&r.AnswerID, &r.SessionID, &r.KB, &r.Question, &r.Answer, &chunksJSON,
+			&r.Rating, &correction, &r.Comment, &r.CreatedAt); err != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to export feedback: %v", err)})
+			return
+		}
+		var chunks []AnswerChunk
+		json.Unmarshal([]byte(chunksJSON), &chunks)
+		r.Contexts = make([]string, 0, len(chunks))
+		r.ContextIDs = make([]string, 0, len(chunks))
+		for _, chunk := range chunks {
+			r.Contexts = append(r.Contexts, chunk.Content)
+			r.ContextIDs = append(r.ContextIDs, chunk.DocumentID)
+		}
+		r.GroundTruth = correction
+		if r.GroundTruth == "" && r.Rating == RatingUp {
+			r.GroundTruth = r.Answer
+		}
+		records = append(records, r)
+	}
+	if err := rows.Err(); err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to export feedback: %v", err)})
+		return
+	}
+
+	if format == "json" {
+		c.JSON(200, gin.H{"records": records})
+		return
+	}
+
+	var body strings.Builder
+	enc := json.NewEncoder(&body)
+	enc.SetEscapeHTML(false)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to encode record: %v", err)})
+			return
+		}
+	}
+	c.Header("Content-Disposition", `attachment; filename="rag_eval_dataset.jsonl"`)
+	c.Data(200, "application/x-ndjson", []byte(body.String()))
+}
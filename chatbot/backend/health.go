@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// healthDBTimeout 数据库检查的超时时间
+	healthDBTimeout = 2 * time.Second
+	// healthEmbedderTimeout 探测 embedding 服务的超时时间
+	healthEmbedderTimeout = 5 * time.Second
+	// healthEmbedderCacheTTL embedding 服务探测结果的缓存时间，避免探针频繁调用计费接口
+	healthEmbedderCacheTTL = 30 * time.Second
+)
+
+// startedAt 服务启动时间
+var startedAt = time.Now()
+
+// healthCheck 单项检查的结果，status 为 ok、down 或 disabled
+type healthCheck struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+var (
+	embedderProbeMu sync.Mutex
+	embedderProbe   healthCheck
+	embedderProbeAt time.Time
+)
+
+// handleHealthz 存活检查，进程能处理请求即返回 200
+func handleHealthz(c *gin.Context) {
+	c.JSON(200, gin.H{
+		"status":         "ok",
+		"uptime_seconds": int64(time.Since(startedAt).Seconds()),
+	})
+}
+
+// handleReadyz 就绪检查：数据库连通性、fts/vss 扩展加载状态、embedding 服务可达性和待处理的任务数
+// 只有数据库不可用时返回 503；扩展或 embedding 服务不可用时 status 为 degraded
+func handleReadyz(c *gin.Context) {
+	ctx := c.Request.Context()
+	checks := make(map[string]healthCheck)
+
+	checks["database"] = checkDatabase(ctx)
+	if checks["database"].Status != "ok" {
+		c.JSON(503, gin.H{"status": "down", "checks": checks})
+		return
+	}
+
+	extensions, err := loadedExtensions(ctx)
+	if err != nil {
+		checks["extensions"] = healthCheck{Status: "down", Error: err.Error()}
+	} else {
+		checks["extensions"] = healthCheck{Status: "ok"}
+		for _, name := range []string{"fts", "vss"} {
+			if !extensions[name] {
+				checks["extensions"] = healthCheck{Status: "down", Error: fmt.Sprintf("extension %s not loaded", name)}
+				break
+			}
+		}
+	}
+
+	checks["embedder"] = checkEmbedder(ctx)
+
+	queues, err := loadQueueSizes(ctx)
+	if err != nil {
+		checks["queues"] = healthCheck{Status: "down", Error: err.Error()}
+	}
+
+	status := "ok"
+	for _, check := range checks {
+		if check.Status == "down" {
+			status = "degraded"
+		}
+	}
+	c.JSON(200, gin.H{
+		"status":     status,
+		"checks":     checks,
+		"extensions": extensions,
+		"queues":     queues,
+	})
+}
+
+// checkDatabase 检查 DuckDB 连接是否可用
+func checkDatabase(ctx context.Context) healthCheck {
+	if vecStoreInstance == nil {
+		return healthCheck{Status: "down", Error: "VecStore not initialized"}
+	}
+	ctx, cancel := context.WithTimeout(ctx, healthDBTimeout)
+	defer cancel()
+
+	start := time.Now()
+	var one int
+	if err := vecStoreInstance.GetDB().QueryRowContext(ctx, `SELECT 1`).Scan(&one); err != nil {
+		return healthCheck{Status: "down", Error: err.Error()}
+	}
+	return healthCheck{Status: "ok", LatencyMs: time.Since(start).Milliseconds()}
+}
+
+// loadedExtensions 返回 fts 和 vss 扩展是否已加载
+func loadedExtensions(ctx context.Context) (map[string]bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, healthDBTimeout)
+	defer cancel()
+
+	extensions := map[string]bool{"fts": false, "vss": false}
+	rows, err := vecStoreInstance.GetDB().QueryContext(ctx,
+		`SELECT extension_name, loaded FROM duckdb_extensions() WHERE extension_name IN ('fts', 'vss')`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query extensions: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		var loaded bool
+		if err := rows.Scan(&name, &loaded); err != nil {
+			return nil, fmt.Errorf("failed to scan extension: %w", err)
+		}
+		extensions[name] = loaded
+	}
+	return extensions, rows.Err()
+}
+
+// checkEmbedder 向 embedding 服务发送一次请求，结果缓存 healthEmbedderCacheTTL
+func checkEmbedder(ctx context.Context) healthCheck {
+	if sharedRAG == nil || sharedRAG.embedder == nil {
+		return healthCheck{Status: "disabled"}
+	}
+
+	embedderProbeMu.Lock()
+	defer embedderProbeMu.Unlock()
+	if !embedderProbeAt.IsZero() && time.Since(embedderProbeAt) < healthEmbedderCacheTTL {
+		return embedderProbe
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthEmbedderTimeout)
+	defer cancel()
+	start := time.Now()
+	if _, err := sharedRAG.embedder.EmbedStrings(ctx, []string{"ping"}); err != nil {
+		embedderProbe = healthCheck{Status: "down", Error: err.Error()}
+	} else {
+		embedderProbe = healthCheck{Status: "ok", LatencyMs: time.Since(start).Milliseconds()}
+	}
+	embedderProbeAt = time.Now()
+	return embedderProbe
+}
+
+// loadQueueSizes 统计待处理的工作：队列中的导入任务、排队和运行中的任务数，以及各知识库等待生成向量的分块数
+func loadQueueSizes(ctx context.Context) (map[string]int, error) {
+	ctx, cancel := context.WithTimeout(ctx, healthDBTimeout)
+	defer cancel()
+	db := vecStoreInstance.GetDB()
+
+	queues := map[string]int{"job_queue": len(jobQueue)}
+	var queued, running int
+	err := db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FILTER (WHERE status = ?), COUNT(*) FILTER (WHERE status = ?) FROM ingest_jobs
+	`, JobQueued, JobRunning).Scan(&queued, &running)
+	if err != nil {
+		return queues, fmt.Errorf("failed to count ingest jobs: %w", err)
+	}
+	queues["jobs_queued"] = queued
+	queues["jobs_running"] = running
+
+	kbMu.RLock()
+	names := make([]string, 0, len(knowledgeBases))
+	for name := range knowledgeBases {
+		names = append(names, name)
+	}
+	kbMu.RUnlock()
+
+	var pending int
+	for _, name := range names {
+		var n int
+		query := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE embedding_status IN ('pending', 'processing')`, kbTableName(name))
+		if err := db.QueryRowContext(ctx, query).Scan(&n); err != nil {
+			return queues, fmt.Errorf("failed to count pending embeddings in %s: %w", name, err)
+		}
+		pending += n
+	}
+	queues["pending_embeddings"] = pending
+	return queues, nil
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// 任务状态
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// 任务阶段
+const (
+	StageQueued    = "queued"
+	StageParsing   = "parsing"
+	StageSplitting = "splitting"
+	StageEmbedding = "embedding"
+	StageDone      = "done"
+)
+
+const (
+	// ingestJobTimeout 单个导入任务的超时
+	ingestJobTimeout = 30 * time.Minute
+	// ingestBatchSize 每批写入的分块数，每批完成后更新一次进度
+	ingestBatchSize = 20
+)
+
+var (
+	// jobQueue 待处理的任务 ID
+	jobQueue chan string
+	// uploadDir 上传的原始文件目录，文件名为 source ID（即任务 ID）加扩展名
+	// 导入成功后保留，用于下载引用的原始文件；导入失败时删除
+	uploadDir string
+	// jobWorkers 等待 worker 退出
+	jobWorkers sync.WaitGroup
+)
+
+// IngestJob 文档导入任务
+type IngestJob struct {
+	ID           string    `json:"id"`
+	KB           string    `json:"kb"`
+	Status       string    `json:"status"`
+	Stage        string    `json:"stage"`
+	Progress     float64   `json:"progress"`
+	Filename     string    `json:"filename"`
+	Filetype     string    `json:"filetype"`
+	DocCount     int       `json:"doc_count"`
+	ChunkCount   int       `json:"chunk_count"`
+	IndexedCount int       `json:"indexed_count"`
+	Error        string    `json:"error,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	filePath string
+}
+
+// initJobs 创建任务表并启动 worker
+// 上次退出时未完成的任务（queued 或 running）会重新排队，从头开始处理
+// INGEST_WORKERS 为并发处理的任务数（默认 2），UPLOAD_DIR 为原始文件目录（默认 ./data/uploads）
+func initJobs(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS ingest_jobs (
+		id VARCHAR PRIMARY KEY,
+		kb VARCHAR DEFAULT 'default',
+		status VARCHAR,
+		stage VARCHAR,
+		progress DOUBLE DEFAULT 0,
+		filename VARCHAR,
+		filetype VARCHAR,
+		file_path VARCHAR,
+		doc_count INTEGER DEFAULT 0,
+		chunk_count INTEGER DEFAULT 0,
+		indexed_count INTEGER DEFAULT 0,
+		error VARCHAR DEFAULT '',
+		created_at TIMESTAMP,
+		updated_at TIMESTAMP
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create ingest_jobs table: %w", err)
+	}
+	// 旧版本的任务表没有 kb 列，这些任务都属于默认知识库
+	if _, err := db.ExecContext(ctx, `ALTER TABLE ingest_jobs ADD COLUMN IF NOT EXISTS kb VARCHAR DEFAULT 'default'`); err != nil {
+		return fmt.Errorf("failed to migrate ingest_jobs table: %w", err)
+	}
+
+	uploadDir = os.Getenv("UPLOAD_DIR")
+	if uploadDir == "" {
+		uploadDir = "./data/uploads"
+	}
+	if err := os.MkdirAll(uploadDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create upload dir: %w", err)
+	}
+
+	workers := 2
+	if v := os.Getenv("INGEST_WORKERS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid INGEST_WORKERS: %q", v)
+		}
+		workers = n
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT id FROM ingest_jobs WHERE status IN (?, ?) ORDER BY created_at`, JobQueued, JobRunning)
+	if err != nil {
+		return fmt.Errorf("failed to load pending jobs: %w", err)
+	}
+	var pending []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		pending = append(pending, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if _, err := db.ExecContext(ctx, `UPDATE ingest_jobs SET status = ?, stage = ?, progress = 0 WHERE status = ?`,
+		JobQueued, StageQueued, JobRunning); err != nil {
+		return fmt.Errorf("failed to requeue jobs: %w", err)
+	}
+
+	// 队列容量足够容纳恢复的任务，避免启动时阻塞
+	jobQueue = make(chan string, len(pending)+1024)
+	for _, id := range pending {
+		jobQueue <- id
+	}
+	if len(pending) > 0 {
+		logrus.WithField("count", len(pending)).Info("Requeued unfinished ingest jobs")
+	}
+
+	for i := 0; i < workers; i++ {
+		jobWorkers.Add(1)
+		go func() {
+			defer jobWorkers.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case id := <-jobQueue:
+					runIngestJob(ctx, db, id)
+				}
+			}
+		}()
+	}
+	return nil
+}
+
+// createIngestJob 将上传的文件保存到 uploadDir 并创建排队中的任务，导入到知识库 kb
+func createIngestJob(ctx context.Context, file *multipart.FileHeader, kb string) (*IngestJob, error) {
+	now := time.Now()
+	job := &IngestJob{
+		ID:        uuid.NewString(),
+		KB:        kb,
+		Status:    JobQueued,
+		Stage:     StageQueued,
+		Filename:  file.Filename,
+		Filetype:  strings.ToLower(filepath.Ext(file.Filename)),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	job.filePath = filepath.Join(uploadDir, job.ID+job.Filetype)
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer src.Close()
+	dst, err := os.Create(job.filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save file: %w", err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(job.filePath)
+		return nil, fmt.Errorf("failed to save file: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(job.filePath)
+		return nil, fmt.Errorf("failed to save file: %w", err)
+	}
+
+	_, err = vecStoreInstance.GetDB().ExecContext(ctx, `
+		INSERT INTO ingest_jobs (id, kb, status, stage, progress, filename, filetype, file_path, created_at, updated_at)
+		VALUES (?, ?, ?, ?, 0, ?, ?, ?, ?, ?)
+	`, job.ID, job.KB, job.Status, job.Stage, job.Filename, job.Filetype, job.filePath, now, now)
+	if err != nil {
+		os.Remove(job.filePath)
+		return nil, err
+	}
+
+	jobQueue <- job.ID
+	return job, nil
+}
+
+// getIngestJob 读取任务，不存在时返回 sql.ErrNoRows
+func getIngestJob(ctx context.Context, db *sql.DB, id string) (*IngestJob, error) {
+	var job IngestJob
+	err := db.QueryRowContext(ctx, `
+		SELECT id, kb, status, stage, progress, filename, filetype, file_path,
+			doc_count, chunk_count, indexed_count, error, created_at, updated_at
+		FROM ingest_jobs WHERE id = ?
+	`, id).Scan(&job.ID, &job.KB, &job.Status, &job.Stage, &job.Progress, &job.Filename, &job.Filetype, &job.filePath,
+		&job.DocCount, &job.ChunkCount, &job.IndexedCount, &job.Error, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// saveIngestJob 保存任务的状态和进度
+func saveIngestJob(ctx context.Context, db *sql.DB, job *IngestJob) error {
+	job.UpdatedAt = time.Now()
+	_, err := db.ExecContext(ctx, `
+		UPDATE ingest_jobs SET status = ?, stage = ?, progress = ?, doc_count = ?, chunk_count = ?,
+			indexed_count = ?, error = ?, updated_at = ?
+		WHERE id = ?
+	`, job.Status, job.Stage, job.Progress, job.DocCount, job.ChunkCount, job.IndexedCount, job.Error, job.UpdatedAt, job.ID)
+	if err != nil {
+		logrus.WithError(err).WithField("job_id", job.ID).Error("Failed to save ingest job")
+	}
+	return err
+}
+
+// runIngestJob 解析、分块并写入文档，每个阶段更新任务进度
+// 服务关闭导致的中断不标记为失败，任务保持 running，下次启动时重新排队
+func runIngestJob(ctx context.Context, db *sql.DB, id string) {
+	job, err := getIngestJob(ctx, db, id)
+	if err != nil {
+		logrus.WithError(err).WithField("job_id", id).Error("Failed to load ingest job")
+		return
+	}
+	if job.Status != JobQueued {
+		return
+	}
+
+	log := logrus.WithFields(logrus.Fields{"job_id": job.ID, "kb": job.KB, "filename": job.Filename})
+	log.Info("Starting ingest job")
+
+	jobCtx, cancel := context.WithTimeout(ctx, ingestJobTimeout)
+	defer cancel()
+
+	err = processIngestJob(jobCtx, db, job)
+	if err != nil && ctx.Err() != nil {
+		log.Warn("Ingest job interrupted by shutdown, will resume on next start")
+		return
+	}
+
+	if err != nil {
+		log.WithError(err).Error("Ingest job failed")
+		job.Status = JobFailed
+		job.Error = err.Error()
+		if errors.Is(err, context.DeadlineExceeded) {
+			job.Error = fmt.Sprintf("timed out after %s: %v", ingestJobTimeout, err)
+		}
+	} else {
+		log.WithFields(logrus.Fields{
+			"doc_count":     job.DocCount,
+			"indexed_count": job.IndexedCount,
+		}).Info("Ingest job completed")
+		job.Status = JobSucceeded
+		job.Stage = StageDone
+		job.Progress = 1
+	}
+	// 使用独立的 context，保证任务超时后也能保存最终状态
+	saveIngestJob(context.WithoutCancel(ctx), db, job)
+	if job.Status == JobFailed {
+		if err := os.Remove(job.filePath); err != nil && !os.IsNotExist(err) {
+			log.WithError(err).Warn("Failed to remove uploaded file")
+		}
+	}
+}
+
+// processIngestJob 执行导入的各个阶段
+func processIngestJob(ctx context.Context, db *sql.DB, job *IngestJob) error {
+	// 知识库可能在任务排队期间被删除
+	kb, ok := getKnowledgeBase(job.KB)
+	if !ok {
+		return fmt.Errorf("knowledge base %q not found", job.KB)
+	}
+
+	job.Status = JobRunning
+	job.Stage = StageParsing
+	job.Progress = 0
+	saveIngestJob(ctx, db, job)
+
+	f, err := os.Open(job.filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open uploaded file: %w", err)
+	}
+	defer f.Close()
+
+	docs, err := parseDocuments(ctx, f, job.Filename)
+	if err != nil {
+		return err
+	}
+	// 分块继承 source_id，引用时据此找到原始文件
+	// 解析器没有设置 ID 时用任务 ID 生成，否则不同文件的分块 ID 相同，后导入的会覆盖之前的
+	for i, doc := range docs {
+		if doc.ID == "" {
+			doc.ID = fmt.Sprintf("%s_%d", job.ID, i)
+		}
+		doc.MetaData["source_id"] = job.ID
+	}
+	job.DocCount = len(docs)
+	job.Stage = StageSplitting
+	job.Progress = 0.1
+	saveIngestJob(ctx, db, job)
+
+	chunks, err := kb.indexer.Split(ctx, docs)
+	if err != nil {
+		return err
+	}
+	job.ChunkCount = len(chunks)
+	job.Stage = StageEmbedding
+	job.Progress = 0.2
+	saveIngestJob(ctx, db, job)
+
+	// embedding 阶段占 20%~100% 的进度
+	for start := 0; start < len(chunks); start += ingestBatchSize {
+		end := min(start+ingestBatchSize, len(chunks))
+		ids, err := kb.indexer.indexer.Store(ctx, chunks[start:end])
+		if err != nil {
+			return fmt.Errorf("failed to index documents: %w", err)
+		}
+		job.IndexedCount += len(ids)
+		job.Progress = 0.2 + 0.8*float64(end)/float64(len(chunks))
+		saveIngestJob(ctx, db, job)
+	}
+	return nil
+}
+
+// deleteKnowledgeBaseJobs 删除知识库的导入任务，返回需要清理的上传文件路径
+func deleteKnowledgeBaseJobs(ctx context.Context, db *sql.DB, kb string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT file_path FROM ingest_jobs WHERE kb = ?`, kb)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := db.ExecContext(ctx, `DELETE FROM ingest_jobs WHERE kb = ?`, kb); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+func handleGetJob(c *gin.Context) {
+	if vecStoreInstance == nil {
+		c.JSON(500, gin.H{"error": "VecStore not initialized"})
+		return
+	}
+
+	job, err := getIngestJob(c.Request.Context(), vecStoreInstance.GetDB(), c.Param("id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(404, gin.H{"error": "Job not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to get job: %v", err)})
+		return
+	}
+
+	c.JSON(200, job)
+}
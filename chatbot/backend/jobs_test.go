@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startTestJobs 启动任务 worker，测试结束时停止
+func startTestJobs(t *testing.T, db *sql.DB) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, initJobs(ctx, db))
+	t.Cleanup(func() {
+		cancel()
+		jobWorkers.Wait()
+	})
+}
+
+// waitForJob 等待任务结束（成功或失败）
+func waitForJob(t *testing.T, db *sql.DB, id string) *IngestJob {
+	t.Helper()
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		job, err := getIngestJob(context.Background(), db, id)
+		require.NoError(t, err)
+		if job.Status == JobSucceeded || job.Status == JobFailed {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s did not finish, status %s", id, job.Status)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func setupTestJobs(t *testing.T) *sql.DB {
+	t.Helper()
+	db, _ := setupTestKB(t)
+	cfg := defaultConfig()
+	appConfig = &cfg
+	t.Setenv("UPLOAD_DIR", filepath.Join(t.TempDir(), "uploads"))
+	t.Setenv("INGEST_WORKERS", "1")
+	return db
+}
+
+func TestInitJobsRequeue(t *testing.T) {
+	db := setupTestJobs(t)
+	ctx := context.Background()
+
+	// 第一次启动只创建任务表，队列为空，随即停止 worker
+	first, stop := context.WithCancel(ctx)
+	require.NoError(t, initJobs(first, db))
+	stop()
+	jobWorkers.Wait()
+
+	// 模拟上次退出时的任务：运行中、排队中、已完成，以及知识库已被删除的任务
+	insert := func(id, kb, status string) string {
+		path := filepath.Join(uploadDir, id+".txt")
+		require.NoError(t, os.WriteFile(path, []byte("content of "+id), 0o644))
+		now := time.Now()
+		_, err := db.ExecContext(ctx, `
+			INSERT INTO ingest_jobs (id, kb, status, stage, progress, filename, filetype, file_path, created_at, updated_at)
+			VALUES (?, ?, ?, ?, 0.5, ?, '.txt', ?, ?, ?)
+		`, id, kb, status, StageEmbedding, id+".txt", path, now, now)
+		require.NoError(t, err)
+		return path
+	}
+	runningPath := insert("running", defaultKB, JobRunning)
+	insert("queued", defaultKB, JobQueued)
+	insert("done", defaultKB, JobSucceeded)
+	deletedPath := insert("deleted", "removed", JobQueued)
+
+	startTestJobs(t, db)
+
+	for _, id := range []string{"running", "queued"} {
+		job := waitForJob(t, db, id)
+		assert.Equal(t, JobSucceeded, job.Status, id)
+		assert.Equal(t, StageDone, job.Stage, id)
+		assert.Equal(t, 1.0, job.Progress, id)
+		assert.Equal(t, 1, job.DocCount, id)
+		assert.Equal(t, 1, job.IndexedCount, id)
+
+		var count int
+		require.NoError(t, db.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM vecstore_documents WHERE metadata->>'source_id' = ?`, id).Scan(&count))
+		assert.Equal(t, 1, count, id)
+	}
+	// 成功的任务保留原始文件，供引用下载
+	assert.FileExists(t, runningPath)
+
+	job := waitForJob(t, db, "deleted")
+	assert.Equal(t, JobFailed, job.Status)
+	assert.Contains(t, job.Error, "not found")
+	assert.NoFileExists(t, deletedPath)
+
+	// 已完成的任务不会重新执行
+	job, err := getIngestJob(ctx, db, "done")
+	require.NoError(t, err)
+	assert.Equal(t, JobSucceeded, job.Status)
+	assert.Equal(t, StageEmbedding, job.Stage)
+	assert.Equal(t, 0, job.IndexedCount)
+}
+
+func TestUploadJob(t *testing.T) {
+	db := setupTestJobs(t)
+	startTestJobs(t, db)
+
+	r := gin.New()
+	r.POST("/api/upload", handleUploadDocument)
+	r.GET("/api/jobs/:id", handleGetJob)
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", "Notes.TXT")
+	require.NoError(t, err)
+	_, err = fw.Write([]byte("uploaded notes"))
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+	payload := body.Bytes()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/upload", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	var resp struct {
+		JobID    string `json:"job_id"`
+		KB       string `json:"kb"`
+		Status   string `json:"status"`
+		Filetype string `json:"filetype"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, defaultKB, resp.KB)
+	assert.Equal(t, JobQueued, resp.Status)
+	assert.Equal(t, ".txt", resp.Filetype)
+
+	waitForJob(t, db, resp.JobID)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/jobs/"+resp.JobID, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var job IngestJob
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &job))
+	assert.Equal(t, JobSucceeded, job.Status, job.Error)
+	assert.Equal(t, 1, job.IndexedCount)
+	assert.FileExists(t, filepath.Join(uploadDir, resp.JobID+".txt"))
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/jobs/missing", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// 不存在的知识库
+	req = httptest.NewRequest(http.MethodPost, "/api/upload?kb=missing", bytes.NewReader(payload))
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/components/document"
+	einoembedding "github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	"github.com/gin-gonic/gin"
+	ragflow "github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/flow/rag"
+	vssindexer "github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/indexer/vec"
+	duckdbretriever "github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/retriever/vec"
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/vecstore"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultKB 默认知识库，使用原有的 vecstore_documents 表，不能删除
+const defaultKB = "default"
+
+// kbHeader 选择知识库的请求头，与 kb 查询参数等价
+const kbHeader = "X-Knowledge-Base"
+
+// kbNamePattern 知识库名称只能包含小写字母、数字和下划线，会用作表名的一部分
+var kbNamePattern = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+
+// ragComponents 各知识库共享的组件
+type ragComponents struct {
+	embedder         einoembedding.Embedder
+	splitter         document.Transformer
+	chatModel        model.BaseChatModel
+	vectorDimensions int
+	// toolCallingModel Agent 模式使用的模型，需要支持工具调用
+	toolCallingModel model.ToolCallingChatModel
+}
+
+var (
+	sharedRAG *ragComponents
+
+	kbMu           sync.RWMutex
+	knowledgeBases map[string]*KnowledgeBase
+)
+
+// KnowledgeBase 一个独立的文档集合，拥有自己的向量表、Indexer 和 RAG Chain
+type KnowledgeBase struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	store     *vecstore.VecStore
+	indexer   *VecIndexerWrapper
+	ragConfig *ragflow.Config
+	chain     compose.Runnable[string, *schema.Message]
+}
+
+// kbTableName 返回知识库的向量表名
+func kbTableName(name string) string {
+	if name == defaultKB {
+		return "vecstore_documents"
+	}
+	return "vecstore_kb_" + name
+}
+
+// newKnowledgeBase 创建知识库的存储、Indexer、Retriever 和 RAG Chain，与默认知识库共享数据库连接
+func newKnowledgeBase(ctx context.Context, name, description string, createdAt time.Time) (*KnowledgeBase, error) {
+	store := vecStoreInstance
+	if name != defaultKB {
+		store = vecstore.New(vecstore.Options{
+			TableName: kbTableName(name),
+			DB:        vecStoreInstance.GetDB(),
+		})
+		if err := store.Initialize(ctx); err != nil {
+			return nil, fmt.Errorf("failed to initialize vecstore: %w", err)
+		}
+	}
+
+	vecIndexer, err := vssindexer.NewIndexer(ctx, &vssindexer.IndexerConfig{
+		VecStore:         store,
+		VectorDimensions: sharedRAG.vectorDimensions,
+		Embedding:        sharedRAG.embedder,
+		BatchSize:        10,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vec indexer: %w", err)
+	}
+
+	vecRetriever, err := duckdbretriever.NewRetriever(ctx, &duckdbretriever.RetrieverConfig{
+		VecStore:  store,
+		Embedding: sharedRAG.embedder,
+		TopK:      5,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create vec retriever: %w", err)
+	}
+
+	// 构建 RAG Chain（检索 -> 格式化上下文 -> Prompt -> 模型）
+	// ContextFormatter 同时记录检索到的文档，用于在回答后返回引用
+	// Prompt 和上下文格式来自可配置的提示词模板
+	config := &ragflow.Config{
+		Retriever:        vecRetriever,
+		ChatModel:        sharedRAG.chatModel,
+		PromptTemplate:   promptTemplate{},
+		ContextFormatter: collectingContextFormatter,
+	}
+	chain, err := ragflow.NewRAGChain(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build rag chain: %w", err)
+	}
+
+	return &KnowledgeBase{
+		Name:        name,
+		Description: description,
+		CreatedAt:   createdAt,
+		store:       store,
+		indexer:     &VecIndexerWrapper{indexer: vecIndexer, splitter: sharedRAG.splitter},
+		ragConfig:   config,
+		chain:       chain,
+	}, nil
+}
+
+// initKnowledgeBases 创建知识库注册表并加载所有知识库，默认知识库总是存在
+func initKnowledgeBases(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS knowledge_bases (
+		name VARCHAR PRIMARY KEY,
+		description VARCHAR DEFAULT '',
+		created_at TIMESTAMP
+	)`); err != nil {
+		return fmt.Errorf("failed to create knowledge_bases table: %w", err)
+	}
+	if _, err := db.ExecContext(ctx, `INSERT INTO knowledge_bases (name, description, created_at) VALUES (?, ?, ?) ON CONFLICT (name) DO NOTHING`,
+		defaultKB, "默认知识库", time.Now()); err != nil {
+		return fmt.Errorf("failed to create default knowledge base: %w", err)
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT name, description, created_at FROM knowledge_bases`)
+	if err != nil {
+		return fmt.Errorf("failed to load knowledge bases: %w", err)
+	}
+	type kbRow struct {
+		name, description string
+		createdAt         time.Time
+	}
+	var kbRows []kbRow
+	for rows.Next() {
+		var r kbRow
+		if err := rows.Scan(&r.name, &r.description, &r.createdAt); err != nil {
+			rows.Close()
+			return err
+		}
+		kbRows = append(kbRows, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	knowledgeBases = make(map[string]*KnowledgeBase, len(kbRows))
+	for _, r := range kbRows {
+		kb, err := newKnowledgeBase(ctx, r.name, r.description, r.createdAt)
+		if err != nil {
+			return fmt.Errorf("failed to load knowledge base %s: %w", r.name, err)
+		}
+		knowledgeBases[r.name] = kb
+	}
+	logrus.WithField("count", len(knowledgeBases)).Info("Knowledge bases loaded")
+	return nil
+}
+
+// getKnowledgeBase 按名称查找知识库
+func getKnowledgeBase(name string) (*KnowledgeBase, bool) {
+	kbMu.RLock()
+	defer kbMu.RUnlock()
+	kb, ok := knowledgeBases[name]
+	return kb, ok
+}
+
+// resolveKnowledgeBase 返回请求选择的知识库（kb 查询参数或 X-Knowledge-Base 请求头，默认 default）
+// 知识库不存在时写入 404 并返回 false
+func resolveKnowledgeBase(c *gin.Context) (*KnowledgeBase, bool) {
+	name := c.Query("kb")
+	if name == "" {
+		name = c.GetHeader(kbHeader)
+	}
+	if name == "" {
+		name = defaultKB
+	}
+	kb, ok := getKnowledgeBase(name)
+	if !ok {
+		c.JSON(404, gin.H{"error": fmt.Sprintf("Knowledge base %q not found", name)})
+		return nil, false
+	}
+	return kb, true
+}
+
+type CreateKnowledgeBaseRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+func handleListKnowledgeBases(c *gin.Context) {
+	kbMu.RLock()
+	kbs := make([]*KnowledgeBase, 0, len(knowledgeBases))
+	for _, kb := range knowledgeBases {
+		kbs = append(kbs, kb)
+	}
+	kbMu.RUnlock()
+	sort.Slice(kbs, func(i, j int) bool { return kbs[i].CreatedAt.Before(kbs[j].CreatedAt) })
+
+	ctx := c.Request.Context()
+	db := vecStoreInstance.GetDB()
+	result := make([]gin.H, 0, len(kbs))
+	for _, kb := range kbs {
+		var count int
+		if err := db.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s", kb.store.GetTableName())).Scan(&count); err != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to count documents: %v", err)})
+			return
+		}
+		result = append(result, gin.H{
+			"name":           kb.Name,
+			"description":    kb.Description,
+			"created_at":     kb.CreatedAt,
+			"document_count": count,
+		})
+	}
+
+	c.JSON(200, gin.H{"knowledge_bases": result})
+}
+
+func handleCreateKnowledgeBase(c *gin.Context) {
+	var req CreateKnowledgeBaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if !kbNamePattern.MatchString(req.Name) {
+		c.JSON(400, gin.H{"error": "name must be 1-32 lowercase letters, digits or underscores"})
+		return
+	}
+
+	kbMu.Lock()
+	defer kbMu.Unlock()
+	if _, ok := knowledgeBases[req.Name]; ok {
+		c.JSON(409, gin.H{"error": fmt.Sprintf("Knowledge base %q already exists", req.Name)})
+		return
+	}
+
+	ctx := c.Request.Context()
+	now := time.Now()
+	kb, err := newKnowledgeBase(ctx, req.Name, req.Description, now)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to create knowledge base: %v", err)})
+		return
+	}
+	if _, err := vecStoreInstance.GetDB().ExecContext(ctx, `INSERT INTO knowledge_bases (name, description, created_at) VALUES (?, ?, ?)`,
+		req.Name, req.Description, now); err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to create knowledge base: %v", err)})
+		return
+	}
+	knowledgeBases[req.Name] = kb
+
+	c.JSON(201, kb)
+}
+
+// handleDeleteKnowledgeBase 删除知识库的向量表、导入任务和上传的原始文件
+func handleDeleteKnowledgeBase(c *gin.Context) {
+	name := c.Param("name")
+	if name == defaultKB {
+		c.JSON(400, gin.H{"error": "The default knowledge base cannot be deleted"})
+		return
+	}
+
+	kbMu.Lock()
+	defer kbMu.Unlock()
+	kb, ok := knowledgeBases[name]
+	if !ok {
+		c.JSON(404, gin.H{"error": fmt.Sprintf("Knowledge base %q not found", name)})
+		return
+	}
+
+	ctx := c.Request.Context()
+	db := vecStoreInstance.GetDB()
+
+	// 正在运行的任务找不到知识库后会失败
+	delete(knowledgeBases, name)
+
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("DROP TABLE IF EXISTS %s", kb.store.GetTableName())); err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to drop documents: %v", err)})
+		return
+	}
+	if _, err := db.ExecContext(ctx, `DELETE FROM knowledge_bases WHERE name = ?`, name); err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to delete knowledge base: %v", err)})
+		return
+	}
+
+	paths, err := deleteKnowledgeBaseJobs(ctx, db, name)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to delete ingest jobs: %v", err)})
+		return
+	}
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			logrus.WithError(err).WithField("path", path).Warn("Failed to remove uploaded file")
+		}
+	}
+
+	c.JSON(200, gin.H{"message": "Knowledge base deleted successfully"})
+}
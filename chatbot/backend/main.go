@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -10,6 +11,7 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -17,62 +19,100 @@ import (
 
 	docxparser "github.com/cloudwego/eino-ext/components/document/parser/docx"
 	htmlparser "github.com/cloudwego/eino-ext/components/document/parser/html"
-	openaiembedding "github.com/cloudwego/eino-ext/components/embedding/openai"
 	openaimodel "github.com/cloudwego/eino-ext/components/model/openai"
 	"github.com/cloudwego/eino/components/document"
+	einoembedding "github.com/cloudwego/eino/components/embedding"
 	"github.com/cloudwego/eino/components/indexer"
-	"github.com/cloudwego/eino/components/prompt"
-	"github.com/cloudwego/eino/components/retriever"
-	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/schema"
 	"github.com/gin-gonic/gin"
+	webloader "github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/document/loader/web"
+	audioparser "github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/document/parser/audio"
+	csvparser "github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/document/parser/csv"
 	pdfparser "github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/document/parser/pdf"
+	xlsxparser "github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/document/parser/xlsx"
 	"github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/document/transformer/splitter/tfidf"
+	ragflow "github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/flow/rag"
 	vssindexer "github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/indexer/vec"
-	duckdbretriever "github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/retriever/vec"
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/config"
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/embedding"
 	"github.com/mozhou-tech/sqlite-ai-driver/pkg/sego"
 	"github.com/mozhou-tech/sqlite-ai-driver/pkg/vecstore"
 	"github.com/sirupsen/logrus"
 )
 
 var (
+	// vecStoreInstance 默认知识库的存储，其他知识库和会话、任务等表共享它的数据库连接
 	vecStoreInstance *vecstore.VecStore
-	ragGraph         compose.Runnable[string, *schema.Message]
-	einoIndexer      indexer.Indexer
-	einoRetriever    retriever.Retriever
 
 	// 文档解析器
 	parsers     map[string]interface{}
 	parsersOnce sync.Once
+	// 网页加载器（抓取 URL 并提取正文）
+	urlLoader *webloader.Loader
 )
 
 func main() {
+	// 加载配置
+	cfg, err := config.Load("", defaultConfig())
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	appConfig = cfg
+
 	// 初始化 VecStore 和 RAG 组件
-	if err := initRAG(); err != nil {
+	if err := initRAG(cfg); err != nil {
 		log.Fatalf("Failed to initialize RAG: %v", err)
 	}
 
+	if err := initFeedback(context.Background(), vecStoreInstance.GetDB()); err != nil {
+		log.Fatalf("Failed to initialize feedback: %v", err)
+	}
+
+	// 启动文档导入任务的 worker
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	if err := initJobs(jobsCtx, vecStoreInstance.GetDB()); err != nil {
+		log.Fatalf("Failed to initialize ingest jobs: %v", err)
+	}
+
 	// 创建 Gin 路由
 	r := gin.Default()
 
 	// CORS 中间件
 	r.Use(corsMiddleware())
 
+	// 健康检查，供容器编排探针使用
+	r.GET("/healthz", handleHealthz)
+	r.GET("/readyz", handleReadyz)
+
 	// API 路由
 	api := r.Group("/api")
 	{
 		api.POST("/chat", handleChat)
 		api.POST("/documents", handleAddDocument)
 		api.POST("/upload", handleUploadDocument)
+		api.POST("/documents/url", handleImportURL)
 		api.GET("/documents", handleListDocuments)
+		api.GET("/documents/:id/source", handleDownloadSource)
 		api.DELETE("/documents/:id", handleDeleteDocument)
+		api.GET("/jobs/:id", handleGetJob)
+		api.POST("/agent", handleAgent)
+		api.POST("/debug/retrieve", handleDebugRetrieve)
+		api.GET("/sessions", handleListSessions)
+		api.GET("/sessions/:id", handleGetSession)
+		api.DELETE("/sessions/:id", handleDeleteSession)
+		api.POST("/feedback", handleFeedback)
+		api.GET("/feedback/export", handleExportFeedback)
+		api.GET("/prompts", handleListPrompts)
+		api.PUT("/prompts/:name", handleUpdatePrompt)
+		api.DELETE("/prompts/:name", handleDeletePrompt)
+		api.POST("/prompts/reload", handleReloadPrompts)
+		api.GET("/kbs", handleListKnowledgeBases)
+		api.POST("/kbs", handleCreateKnowledgeBase)
+		api.DELETE("/kbs/:name", handleDeleteKnowledgeBase)
 	}
 
 	// 启动服务器
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "45111"
-	}
+	port := strconv.Itoa(cfg.Server.Port)
 	log.Printf("Server starting on port %s", port)
 
 	// 优雅关闭
@@ -100,6 +140,10 @@ func main() {
 		log.Fatal("Server forced to shutdown:", err)
 	}
 
+	// 停止 worker，未完成的任务在下次启动时重新排队
+	stopJobs()
+	jobWorkers.Wait()
+
 	if vecStoreInstance != nil {
 		if err := vecStoreInstance.Close(); err != nil {
 			log.Printf("Failed to close vecstore: %v", err)
@@ -109,7 +153,7 @@ func main() {
 	log.Println("Server exiting")
 }
 
-func initRAG() error {
+func initRAG(cfg *config.Config) error {
 	ctx := context.Background()
 
 	// 预加载 sego 词典
@@ -123,37 +167,33 @@ func initRAG() error {
 		FullTimestamp: true,
 	})
 
-	// 获取环境变量
-	openaiAPIKey := os.Getenv("OPENAI_API_KEY")
-	if openaiAPIKey == "" {
-		return fmt.Errorf("OPENAI_API_KEY environment variable is required")
-	}
-
-	openaiModel := os.Getenv("OPENAI_MODEL")
-	if openaiModel == "" {
-		openaiModel = "gpt-4o-mini"
-	}
-
-	openaiBaseURL := os.Getenv("OPENAI_BASE_URL")
-	if openaiBaseURL == "" {
-		openaiBaseURL = "https://api.openai.com/v1"
+	if cfg.LLM.APIKey == "" {
+		return fmt.Errorf("llm.api_key (OPENAI_API_KEY) is required")
 	}
 
 	// 初始化 Embedder (用于 eino)
-	embedderConfig := &openaiembedding.EmbeddingConfig{
-		APIKey:  openaiAPIKey,
-		BaseURL: openaiBaseURL,
-		Model:   "text-embedding-v4",
-	}
-	einoEmbedder, err := openaiembedding.NewEmbedder(ctx, embedderConfig)
+	// 默认沿用 LLM 的密钥和地址调用 OpenAI 兼容的 text-embedding-v4，
+	// 可通过 embedding.provider（EMBEDDING_PROVIDER）切换到 dashscope、ollama 或 custom
+	embeddingConfig := embedding.Config{
+		Provider:  cfg.Embedding.Provider,
+		APIKey:    cfg.Embedding.APIKey,
+		BaseURL:   cfg.Embedding.BaseURL,
+		Model:     cfg.Embedding.Model,
+		Dimension: cfg.Embedding.Dimension,
+	}
+	if embeddingConfig.Provider == embedding.ProviderOpenAI && embeddingConfig.Model == "" {
+		embeddingConfig.Model = "text-embedding-v4"
+	}
+	embeddingProvider, err := embedding.New(embeddingConfig)
 	if err != nil {
-		return fmt.Errorf("failed to create eino embedder: %w", err)
+		return fmt.Errorf("failed to create embedder: %w", err)
 	}
+	einoEmbedder := &einoEmbedderAdapter{provider: embeddingProvider}
 
 	// 确定向量维度
 	vectorDimensions := 1024 // text-embedding-v4 默认维度为 1024
-	if embedderConfig.Dimensions != nil {
-		vectorDimensions = *embedderConfig.Dimensions
+	if embeddingConfig.Dimension > 0 {
+		vectorDimensions = embeddingConfig.Dimension
 	}
 
 	// 创建 VecStore 实例
@@ -168,14 +208,19 @@ func initRAG() error {
 
 	// 初始化 Eino 组件
 	cm, err := openaimodel.NewChatModel(ctx, &openaimodel.ChatModelConfig{
-		APIKey:  openaiAPIKey,
-		BaseURL: openaiBaseURL,
-		Model:   openaiModel,
+		APIKey:  cfg.LLM.APIKey,
+		BaseURL: cfg.LLM.BaseURL,
+		Model:   cfg.LLM.Model,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create eino chat model: %w", err)
 	}
 
+	// 初始化会话存储，旧对话由同一模型压缩为摘要
+	if err := initSessions(ctx, vecStoreInstance.GetDB(), cm); err != nil {
+		return err
+	}
+
 	// 创建 TFIDF Splitter
 	splitter, err := tfidf.NewTFIDFSplitter(ctx, &tfidf.Config{
 		SimilarityThreshold: 0.2,
@@ -191,128 +236,45 @@ func initRAG() error {
 		return fmt.Errorf("failed to create TFIDF splitter: %w", err)
 	}
 
-	// 创建 Vec Indexer
-	vecIndexer, err := vssindexer.NewIndexer(ctx, &vssindexer.IndexerConfig{
-		VecStore:         vecStoreInstance,
-		TableName:        "documents",
-		VectorDimensions: vectorDimensions,
-		Embedding:        einoEmbedder,
-		BatchSize:        10,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to create vec indexer: %w", err)
-	}
-
-	// 创建包装 Indexer，集成 TFIDF Splitter
-	einoIndexer = &VecIndexerWrapper{
-		indexer:  vecIndexer,
-		splitter: splitter,
+	// 加载提示词模板（PROMPTS_FILE 和 prompt_templates 表），修改后自动重新加载
+	if err := initPrompts(ctx, vecStoreInstance.GetDB()); err != nil {
+		return err
 	}
 
-	// 创建 Vec Retriever
-	einoRetriever, err = duckdbretriever.NewRetriever(ctx, &duckdbretriever.RetrieverConfig{
-		VecStore:  vecStoreInstance,
-		TableName: "documents",
-		Embedding: einoEmbedder,
-		TopK:      5,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to create vec retriever: %w", err)
-	}
-
-	// 构建 RAG Graph
-	chatTemplate := prompt.FromMessages(
-		schema.FString,
-		schema.SystemMessage("你是一个专业的知识库助手。请根据提供的背景信息回答问题。\n\n"+
-			"要求：\n"+
-			"1. 回答内容必须严格基于背景信息。\n"+
-			"2. 在引用背景信息的内容处，必须在行内使用 [n] 格式标注引用来源（例如 [1], [2]）。\n"+
-			"3. 如果背景信息中没有相关内容，请说明你不知道。\n\n"+
-			"背景信息：\n{format_docs}"),
-		schema.UserMessage("{input}"),
-	)
-
-	formatDocs := func(ctx context.Context, docs []*schema.Document) (map[string]any, error) {
-		if len(docs) == 0 {
-			logrus.Warn("No documents retrieved for context")
-			return map[string]any{"format_docs": "未找到相关知识内容。"}, nil
-		}
-
-		var contextText string
-		contextText += "### 相关参考文档 (Reference Documents):\n"
-		for i, doc := range docs {
-			// 尝试获取相似度分数（从 distance 转换）
-			score := 0.0
-			if distance, ok := doc.MetaData["distance"].(float64); ok {
-				// distance = 1 - similarity，所以 similarity = 1 - distance
-				score = 1.0 - distance
-			}
-			contextText += fmt.Sprintf("[%d] (Score: %.4f) %s\n", i+1, score, doc.Content)
-		}
-
-		return map[string]any{"format_docs": contextText}, nil
+	// Embedder、Splitter 和模型由所有知识库共享，每个知识库有独立的向量表和 RAG Chain
+	sharedRAG = &ragComponents{
+		embedder:         einoEmbedder,
+		splitter:         splitter,
+		chatModel:        cm,
+		vectorDimensions: vectorDimensions,
+		toolCallingModel: cm,
 	}
-
-	chain, err := compose.NewChain[string, *schema.Message]().
-		AppendLambda(compose.InvokableLambda(func(ctx context.Context, input string) (map[string]any, error) {
-			// 1. 检索文档
-			docs, err := einoRetriever.Retrieve(ctx, input)
-			if err != nil {
-				return nil, err
-			}
-
-			// 打印召回的chunk
-			logrus.WithFields(logrus.Fields{
-				"query":       input,
-				"chunk_count": len(docs),
-			}).Info("召回的chunk信息")
-			for i, doc := range docs {
-				score := 0.0
-				if distance, ok := doc.MetaData["distance"].(float64); ok {
-					score = 1.0 - distance
-				}
-				logrus.WithFields(logrus.Fields{
-					"index":          i + 1,
-					"id":             doc.ID,
-					"score":          score,
-					"content":        doc.Content,
-					"metadata":       doc.MetaData,
-					"content_length": len(doc.Content),
-				}).Info("召回的chunk详情")
-			}
-
-			// 2. 格式化文档
-			formatted, err := formatDocs(ctx, docs)
-			if err != nil {
-				return nil, err
-			}
-
-			// 3. 将原始输入放入 map
-			formatted["input"] = input
-			return formatted, nil
-		})).
-		AppendChatTemplate(chatTemplate).
-		AppendChatModel(cm).
-		Compile(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to compile eino graph: %w", err)
+	if err := initKnowledgeBases(ctx, vecStoreInstance.GetDB()); err != nil {
+		return err
 	}
 
-	ragGraph = chain
-
 	log.Println("VecStore and Eino initialized successfully")
 	return nil
 }
 
+// einoEmbedderAdapter 将 embedding.Provider 适配为 eino 的 Embedder
+type einoEmbedderAdapter struct {
+	provider embedding.Provider
+}
+
+// EmbedStrings 实现 einoembedding.Embedder
+func (a *einoEmbedderAdapter) EmbedStrings(ctx context.Context, texts []string, opts ...einoembedding.Option) ([][]float64, error) {
+	return a.provider.Embed(ctx, texts)
+}
+
 // Vec Indexer 包装，集成 TFIDF Splitter
 type VecIndexerWrapper struct {
 	indexer  *vssindexer.Indexer
 	splitter document.Transformer
 }
 
-func (i *VecIndexerWrapper) Store(ctx context.Context, docs []*schema.Document, opts ...indexer.Option) ([]string, error) {
-	logrus.WithField("count", len(docs)).Info("Indexing documents into VecStore")
-
+// Split 使用 TFIDF Splitter 分割文档，并过滤掉空内容的分块
+func (i *VecIndexerWrapper) Split(ctx context.Context, docs []*schema.Document) ([]*schema.Document, error) {
 	// 使用 TFIDF Splitter 分割文档
 	var transformedDocs []*schema.Document
 	var err error
@@ -353,15 +315,23 @@ func (i *VecIndexerWrapper) Store(ctx context.Context, docs []*schema.Document,
 		logrus.WithField("skipped_empty_count", skippedEmpty).Info("Filtered out documents with empty content")
 	}
 
+	return validDocs, nil
+}
+
+func (i *VecIndexerWrapper) Store(ctx context.Context, docs []*schema.Document, opts ...indexer.Option) ([]string, error) {
+	logrus.WithField("count", len(docs)).Info("Indexing documents into VecStore")
+
+	validDocs, err := i.Split(ctx, docs)
+	if err != nil {
+		return nil, err
+	}
+
 	if len(validDocs) == 0 {
 		logrus.Warn("No valid documents to index after filtering")
 		return []string{}, nil
 	}
 
-	logrus.WithFields(logrus.Fields{
-		"total_docs":    len(validDocs),
-		"skipped_empty": skippedEmpty,
-	}).Info("Starting batch insertion with embedding generation")
+	logrus.WithField("total_docs", len(validDocs)).Info("Starting batch insertion with embedding generation")
 
 	// 使用 Vec Indexer 存储文档
 	ids, err := i.indexer.Store(ctx, validDocs, opts...)
@@ -387,10 +357,17 @@ func (i *VecIndexerWrapper) IsCallbacksEnabled() bool {
 
 func corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		// 配置了 cors.allowed_origins 时只回显允许的来源
+		if len(appConfig.CORS.AllowedOrigins) == 0 {
+			c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		} else if origin := c.GetHeader("Origin"); origin != "" && originAllowed(origin) {
+			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+			c.Writer.Header().Add("Vary", "Origin")
+		}
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Knowledge-Base")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Session-ID")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -402,8 +379,11 @@ func corsMiddleware() gin.HandlerFunc {
 }
 
 type ChatRequest struct {
-	Message string   `json:"message"`
-	History []string `json:"history,omitempty"`
+	Message string `json:"message"`
+	// SessionID 会话 ID，为空时创建新会话；新会话的 ID 通过响应头 X-Session-ID 返回
+	SessionID string `json:"session_id,omitempty"`
+	// SSEVersion 流式协议版本，默认 1（原始文本）；2 为结构化事件
+	SSEVersion int `json:"sse_version,omitempty"`
 }
 
 type ChatResponse struct {
@@ -416,27 +396,74 @@ func handleChat(c *gin.Context) {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
+	if strings.TrimSpace(req.Message) == "" {
+		c.JSON(400, gin.H{"error": "Message is required"})
+		return
+	}
+	if req.SSEVersion != 0 && req.SSEVersion != SSEVersionText && req.SSEVersion != SSEVersionEvents {
+		c.JSON(400, gin.H{"error": fmt.Sprintf("Unsupported sse_version %d", req.SSEVersion)})
+		return
+	}
+	structured := req.SSEVersion == SSEVersionEvents
+	kb, ok := resolveKnowledgeBase(c)
+	if !ok {
+		return
+	}
 
 	ctx := c.Request.Context()
+	db := vecStoreInstance.GetDB()
+
+	sessionID, err := ensureSession(ctx, db, req.SessionID, req.Message)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	history, err := loadHistory(ctx, db, sessionID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
 
 	// 使用 Eino Graph 进行查询
 	logrus.WithFields(logrus.Fields{
-		"message": req.Message,
+		"message":       req.Message,
+		"kb":            kb.Name,
+		"session_id":    sessionID,
+		"history_count": len(history),
+		"sse_version":   req.SSEVersion,
 	}).Info("Starting chat query via Eino Graph (streaming)")
 
-	sr, err := ragGraph.Stream(ctx, req.Message)
+	// 结构化协议在检索前就开始响应，之后的错误通过 error 事件返回
+	if structured {
+		setSSEHeaders(c, sessionID)
+		sendEvent(c, EventRetrievalStarted, RetrievalStartedEvent{Query: req.Message, KB: kb.Name, SessionID: sessionID})
+	}
+
+	// Stream 返回时检索和上下文格式化已经完成，collector 中是检索到的文档
+	chainCtx, collector := withCitationCollector(ragflow.WithHistory(ctx, history))
+	sr, err := kb.chain.Stream(chainCtx, req.Message)
 	if err != nil {
 		logrus.WithError(err).Error("Chat query failed")
+		if structured {
+			sendEvent(c, EventError, ErrorEvent{Error: fmt.Sprintf("Failed to query via Eino: %v", err)})
+			return
+		}
 		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to query via Eino: %v", err)})
 		return
 	}
 	defer sr.Close()
 
-	c.Header("Content-Type", "text/event-stream")
-	c.Header("Cache-Control", "no-cache")
-	c.Header("Connection", "keep-alive")
-	c.Header("X-Accel-Buffering", "no") // 禁用 Nginx 缓存
+	citations := buildCitations(collector.docs, kb.Name)
+	if structured {
+		sendEvent(c, EventSources, citations)
+	} else {
+		setSSEHeaders(c, sessionID)
+	}
 
+	var answer strings.Builder
+	var usage *schema.TokenUsage
+	var finishReason string
+	streamFailed := false
 	c.Stream(func(w io.Writer) bool {
 		chunk, err := sr.Recv()
 		if err == io.EOF {
@@ -444,16 +471,64 @@ func handleChat(c *gin.Context) {
 		}
 		if err != nil {
 			logrus.WithError(err).Error("Stream receive failed")
+			streamFailed = true
+			if structured {
+				sendEvent(c, EventError, ErrorEvent{Error: fmt.Sprintf("Stream receive failed: %v", err)})
+			}
 			return false
 		}
 
 		if chunk != nil {
-			c.SSEvent("message", chunk.Content)
-			c.Writer.Flush()
+			if meta := chunk.ResponseMeta; meta != nil {
+				if meta.Usage != nil {
+					usage = meta.Usage
+				}
+				if meta.FinishReason != "" {
+					finishReason = meta.FinishReason
+				}
+			}
+			answer.WriteString(chunk.Content)
+			if !structured {
+				sendEvent(c, "message", chunk.Content)
+			} else if chunk.Content != "" {
+				sendEvent(c, EventToken, TokenEvent{Content: chunk.Content})
+			}
 		}
 		return true
 	})
 
+	if ctx.Err() == nil {
+		// 默认协议在回答结束后发送引用的分块，index 对应回答中的 [n]
+		if !structured && len(citations) > 0 {
+			sendEvent(c, "citations", citations)
+		}
+		if structured && usage != nil {
+			sendEvent(c, EventUsage, usageEvent(usage))
+		}
+	}
+
+	// 客户端断开时请求上下文已取消，仍保存已生成的回答
+	var answerID string
+	if answer.Len() > 0 {
+		saveCtx := context.WithoutCancel(ctx)
+		if err := appendSessionMessages(saveCtx, db, sessionID,
+			schema.UserMessage(req.Message), schema.AssistantMessage(answer.String(), nil)); err != nil {
+			logrus.WithError(err).WithField("session_id", sessionID).Error("Failed to save chat history")
+		}
+
+		// 记录问题、检索到的分块和回答，客户端用 answer_id 提交反馈
+		answerID, err = recordAnswer(saveCtx, db, sessionID, kb.Name, req.Message, answer.String(), collector.docs)
+		if err != nil {
+			logrus.WithError(err).WithField("session_id", sessionID).Error("Failed to record answer")
+		} else if ctx.Err() == nil && !structured {
+			sendEvent(c, "answer", gin.H{"answer_id": answerID})
+		}
+	}
+
+	if structured && !streamFailed && ctx.Err() == nil {
+		sendEvent(c, EventDone, DoneEvent{SessionID: sessionID, AnswerID: answerID, FinishReason: finishReason})
+	}
+
 	logrus.Info("Chat query completed successfully")
 }
 
@@ -471,11 +546,15 @@ func handleAddDocument(c *gin.Context) {
 		c.JSON(400, gin.H{"error": err.Error()})
 		return
 	}
+	kb, ok := resolveKnowledgeBase(c)
+	if !ok {
+		return
+	}
 
 	ctx := c.Request.Context()
 
 	// 使用 Eino Indexer 插入文档
-	_, err := einoIndexer.Store(ctx, []*schema.Document{
+	_, err := kb.indexer.Store(ctx, []*schema.Document{
 		{
 			Content: req.Content,
 		},
@@ -497,7 +576,8 @@ func initParsers(ctx context.Context) error {
 
 		// 初始化 PDF 解析器
 		pdfParser, err := pdfparser.NewPDFParser(ctx, &pdfparser.Config{
-			ToPages: true, // 按页面分割文档
+			ToPages:       true, // 按页面分割文档
+			ExtractLayout: true, // 记录页码（引用中显示），表格输出为 Markdown
 		})
 		if err != nil {
 			logrus.WithError(err).Warn("Failed to initialize PDF parser")
@@ -519,8 +599,45 @@ func initParsers(ctx context.Context) error {
 			parsers[".docx"] = docxParser
 		}
 
-		// 注意：XLSX 解析器暂时不可用，XLSX 文件将作为普通文本处理
-		// TODO: 添加 XLSX 解析器支持
+		// 初始化 XLSX 解析器（每个工作表输出一个 Markdown 表格）
+		xlsxParser, err := xlsxparser.NewXlsxParser(ctx, &xlsxparser.Config{
+			Mode: xlsxparser.ModeTable,
+		})
+		if err != nil {
+			logrus.WithError(err).Warn("Failed to initialize XLSX parser")
+		} else {
+			parsers[".xlsx"] = xlsxParser
+		}
+
+		// 初始化 CSV 解析器
+		csvParser, err := csvparser.NewCsvParser(ctx, &csvparser.Config{
+			Mode: csvparser.ModeTable,
+		})
+		if err != nil {
+			logrus.WithError(err).Warn("Failed to initialize CSV parser")
+		} else {
+			parsers[".csv"] = csvParser
+			parsers[".tsv"] = csvParser
+		}
+
+		// 初始化音频解析器（调用 Whisper 兼容接口转写录音，每 5 分钟输出一个带时间戳的文档）
+		whisperBaseURL := os.Getenv("WHISPER_BASE_URL")
+		if whisperBaseURL == "" {
+			whisperBaseURL = appConfig.LLM.BaseURL
+		}
+		audioParser, err := audioparser.NewWhisperParser(ctx, &audioparser.Config{
+			BaseURL:       whisperBaseURL,
+			APIKey:        appConfig.LLM.APIKey,
+			Model:         os.Getenv("WHISPER_MODEL"),
+			ChunkDuration: 5 * time.Minute,
+		})
+		if err != nil {
+			logrus.WithError(err).Warn("Failed to initialize audio parser")
+		} else {
+			for _, ext := range []string{".mp3", ".wav", ".m4a", ".mp4", ".mpeg", ".mpga", ".webm", ".ogg", ".flac"} {
+				parsers[ext] = audioParser
+			}
+		}
 
 		// 初始化 HTML 解析器
 		htmlParser, err := htmlparser.NewParser(ctx, &htmlparser.Config{
@@ -533,41 +650,35 @@ func initParsers(ctx context.Context) error {
 			parsers[".htm"] = htmlParser
 		}
 
+		// 初始化网页加载器，链接指向 PDF 时使用 PDF 解析器
+		// 默认拒绝抓取内网和回环地址，URL_IMPORT_ALLOW_PRIVATE=true 时允许导入内网页面
+		loaderConfig := &webloader.Config{AllowPrivateNetworks: os.Getenv("URL_IMPORT_ALLOW_PRIVATE") == "true"}
+		if pdfParser != nil {
+			loaderConfig.Parser = pdfParser
+		}
+		urlLoader, err = webloader.NewLoader(ctx, loaderConfig)
+		if err != nil {
+			logrus.WithError(err).Warn("Failed to initialize URL loader")
+		}
+
 		logrus.WithField("parsers", len(parsers)).Info("Document parsers initialized")
 	})
 	return nil
 }
 
-func handleUploadDocument(c *gin.Context) {
-	file, err := c.FormFile("file")
-	if err != nil {
-		c.JSON(400, gin.H{"error": "No file uploaded"})
-		return
-	}
-
-	// 为上传操作创建带更长超时的 context（10分钟，足够处理大文件和 embedding）
-	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Minute)
-	defer cancel()
-
+// parseDocuments 按文件扩展名选择解析器解析文件，未支持的格式按纯文本处理
+func parseDocuments(ctx context.Context, f io.Reader, filename string) ([]*schema.Document, error) {
 	// 初始化解析器
 	if err := initParsers(ctx); err != nil {
-		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to initialize parsers: %v", err)})
-		return
-	}
-
-	// 打开文件
-	f, err := file.Open()
-	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to open file"})
-		return
+		return nil, fmt.Errorf("failed to initialize parsers: %w", err)
 	}
-	defer f.Close()
 
 	// 根据文件扩展名判断文件类型
-	ext := strings.ToLower(filepath.Ext(file.Filename))
+	ext := strings.ToLower(filepath.Ext(filename))
 
 	// 尝试使用 eino-ext 解析器解析文档
 	var docs []*schema.Document
+	var err error
 
 	if parser, ok := parsers[ext]; ok {
 		// 根据文件类型调用对应的解析器
@@ -586,6 +697,25 @@ func handleUploadDocument(c *gin.Context) {
 			} else {
 				err = fmt.Errorf("DOCX parser type assertion failed")
 			}
+		case ".xlsx":
+			if xlsxParser, ok := parser.(*xlsxparser.XlsxParser); ok {
+				docs, err = xlsxParser.Parse(ctx, f)
+			} else {
+				err = fmt.Errorf("XLSX parser type assertion failed")
+			}
+		case ".csv", ".tsv":
+			if csvParser, ok := parser.(*csvparser.CsvParser); ok {
+				docs, err = csvParser.Parse(ctx, f)
+			} else {
+				err = fmt.Errorf("CSV parser type assertion failed")
+			}
+		case ".mp3", ".wav", ".m4a", ".mp4", ".mpeg", ".mpga", ".webm", ".ogg", ".flac":
+			if audioParser, ok := parser.(*audioparser.WhisperParser); ok {
+				// 接口依靠文件扩展名识别音频格式
+				docs, err = audioParser.Parse(ctx, f, audioparser.WithFileName(filename))
+			} else {
+				err = fmt.Errorf("audio parser type assertion failed")
+			}
 		case ".html", ".htm":
 			if htmlParser, ok := parser.(*htmlparser.Parser); ok {
 				// HTML 解析器的 Parse 方法签名: Parse(ctx context.Context, reader io.Reader, opts ...parser.Option)
@@ -599,12 +729,11 @@ func handleUploadDocument(c *gin.Context) {
 
 		if err != nil {
 			logrus.WithError(err).WithField("extension", ext).Error("Failed to parse document")
-			c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to parse document: %v", err)})
-			return
+			return nil, fmt.Errorf("failed to parse document: %w", err)
 		}
 
 		logrus.WithFields(logrus.Fields{
-			"filename":  file.Filename,
+			"filename":  filename,
 			"extension": ext,
 			"doc_count": len(docs),
 		}).Info("Successfully parsed document")
@@ -612,31 +741,28 @@ func handleUploadDocument(c *gin.Context) {
 		// 对于文本文件或其他未支持的格式，直接读取内容
 		content, readErr := io.ReadAll(f)
 		if readErr != nil {
-			c.JSON(500, gin.H{"error": "Failed to read file"})
-			return
+			return nil, fmt.Errorf("failed to read file: %w", readErr)
 		}
 
 		textContent := string(content)
 		if strings.TrimSpace(textContent) == "" {
-			c.JSON(400, gin.H{"error": "No text content extracted from file"})
-			return
+			return nil, fmt.Errorf("no text content extracted from file")
 		}
 
 		docs = []*schema.Document{
 			{
 				Content: textContent,
 				MetaData: map[string]any{
-					"filename": file.Filename,
+					"filename": filename,
 					"filetype": ext,
 				},
 			},
 		}
-		logrus.WithField("filename", file.Filename).Info("Treated as plain text file")
+		logrus.WithField("filename", filename).Info("Treated as plain text file")
 	}
 
 	if len(docs) == 0 {
-		c.JSON(400, gin.H{"error": "No content extracted from file"})
-		return
+		return nil, fmt.Errorf("no content extracted from file")
 	}
 
 	// 为每个文档添加文件名元数据
@@ -644,54 +770,105 @@ func handleUploadDocument(c *gin.Context) {
 		if doc.MetaData == nil {
 			doc.MetaData = make(map[string]any)
 		}
-		doc.MetaData["filename"] = file.Filename
+		doc.MetaData["filename"] = filename
 		doc.MetaData["filetype"] = ext
 	}
+	return docs, nil
+}
 
-	// 使用 Eino Indexer 插入文档（包含 embedding 操作）
-	logrus.WithFields(logrus.Fields{
-		"filename":  file.Filename,
-		"filetype":  ext,
-		"doc_count": len(docs),
-	}).Info("Starting document indexing with embedding")
+// handleUploadDocument 保存上传的文件并创建后台导入任务，立即返回任务 ID
+// 解析、分块和 embedding 在任务中完成，进度通过 GET /api/jobs/:id 查询
+func handleUploadDocument(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(400, gin.H{"error": "No file uploaded"})
+		return
+	}
+	kb, ok := resolveKnowledgeBase(c)
+	if !ok {
+		return
+	}
 
-	ids, err := einoIndexer.Store(ctx, docs)
+	job, err := createIngestJob(c.Request.Context(), file, kb.Name)
 	if err != nil {
-		logrus.WithError(err).Error("Failed to index documents")
-		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to insert document via Eino: %v", err)})
+		logrus.WithError(err).WithField("filename", file.Filename).Error("Failed to create ingest job")
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to create ingest job: %v", err)})
+		return
+	}
+
+	c.JSON(202, gin.H{
+		"message":  "File uploaded, indexing in background",
+		"job_id":   job.ID,
+		"kb":       job.KB,
+		"status":   job.Status,
+		"filename": job.Filename,
+		"filetype": job.Filetype,
+	})
+}
+
+type ImportURLRequest struct {
+	URL string `json:"url"`
+}
+
+// handleImportURL 抓取网页正文并索引（遵守 robots.txt）
+func handleImportURL(c *gin.Context) {
+	var req ImportURLRequest
+	if err := c.ShouldBindJSON(&req); err != nil || strings.TrimSpace(req.URL) == "" {
+		c.JSON(400, gin.H{"error": "url is required"})
 		return
 	}
+	kb, ok := resolveKnowledgeBase(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Minute)
+	defer cancel()
 
-	// 检查 context 是否被取消（可能表示超时）
-	contextErr := ctx.Err()
-	var warning string
-	if contextErr == context.DeadlineExceeded {
-		warning = "Upload timeout: Some embeddings may not have completed. Please check logs for details."
-		logrus.WithError(contextErr).Warn("Upload context deadline exceeded")
-	} else if contextErr == context.Canceled {
-		warning = "Upload canceled: Some embeddings may not have completed. Please check logs for details."
-		logrus.WithError(contextErr).Warn("Upload context canceled")
+	if err := initParsers(ctx); err != nil || urlLoader == nil {
+		c.JSON(500, gin.H{"error": "URL loader is not available"})
+		return
+	}
+
+	docs, err := urlLoader.Load(ctx, document.Source{URI: req.URL})
+	if err != nil {
+		logrus.WithError(err).WithField("url", req.URL).Error("Failed to load URL")
+		status := 500
+		if errors.Is(err, webloader.ErrDisallowedByRobots) {
+			status = 403
+		} else if errors.Is(err, webloader.ErrPrivateAddress) {
+			status = 400
+		}
+		c.JSON(status, gin.H{"error": fmt.Sprintf("Failed to load URL: %v", err)})
+		return
+	}
+	if len(docs) == 0 {
+		c.JSON(400, gin.H{"error": "No content extracted from URL"})
+		return
+	}
+
+	ids, err := kb.indexer.Store(ctx, docs)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to index documents")
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to insert document via Eino: %v", err)})
+		return
 	}
 
 	logrus.WithFields(logrus.Fields{
-		"filename":    file.Filename,
-		"filetype":    ext,
+		"url":         req.URL,
+		"kb":          kb.Name,
 		"doc_count":   len(docs),
 		"indexed_ids": len(ids),
-		"context_err": contextErr,
-	}).Info("Documents indexed with embeddings")
+	}).Info("URL indexed with embeddings")
 
-	response := gin.H{
-		"message":       "File uploaded and indexed successfully",
-		"filename":      file.Filename,
-		"filetype":      ext,
+	c.JSON(200, gin.H{
+		"message":       "URL imported and indexed successfully",
+		"url":           req.URL,
+		"canonical_url": docs[0].MetaData[webloader.MetaKeyCanonicalURL],
+		"title":         docs[0].MetaData[webloader.MetaKeyTitle],
 		"doc_count":     len(docs),
 		"indexed_count": len(ids),
-	}
-	if warning != "" {
-		response["warning"] = warning
-	}
-	c.JSON(200, response)
+	})
 }
 
 func handleListDocuments(c *gin.Context) {
@@ -701,9 +878,13 @@ func handleListDocuments(c *gin.Context) {
 		c.JSON(500, gin.H{"error": "VecStore not initialized"})
 		return
 	}
+	kb, ok := resolveKnowledgeBase(c)
+	if !ok {
+		return
+	}
 
-	db := vecStoreInstance.GetDB()
-	tableName := vecStoreInstance.GetTableName()
+	db := kb.store.GetDB()
+	tableName := kb.store.GetTableName()
 
 	// 查询文档列表
 	query := fmt.Sprintf(`
@@ -773,9 +954,13 @@ func handleDeleteDocument(c *gin.Context) {
 		c.JSON(500, gin.H{"error": "VecStore not initialized"})
 		return
 	}
+	kb, ok := resolveKnowledgeBase(c)
+	if !ok {
+		return
+	}
 
-	db := vecStoreInstance.GetDB()
-	tableName := vecStoreInstance.GetTableName()
+	db := kb.store.GetDB()
+	tableName := kb.store.GetTableName()
 
 	// 删除文档
 	query := fmt.Sprintf("DELETE FROM %s WHERE id = ?", tableName)
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	einoembedding "github.com/cloudwego/eino/components/embedding"
+	"github.com/gin-gonic/gin"
+	vssindexer "github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/indexer/vec"
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/vecstore"
+	"github.com/stretchr/testify/require"
+)
+
+// testEmbedder 返回固定的向量，测试不调用 embedding 服务
+type testEmbedder struct{}
+
+func (testEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...einoembedding.Option) ([][]float64, error) {
+	vectors := make([][]float64, len(texts))
+	for i := range texts {
+		vectors[i] = make([]float64, 1024)
+		vectors[i][0] = 1
+	}
+	return vectors, nil
+}
+
+// setupTestKB 在临时目录中初始化数据库、会话和反馈表，并注册默认知识库
+// duckdb-driver 把所有路径映射到工作目录下的 ./data/indexing/index.db，因此切换工作目录
+func setupTestKB(t *testing.T) (*sql.DB, *KnowledgeBase) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	t.Chdir(t.TempDir())
+	ctx := context.Background()
+
+	db, err := sql.Open("duckdb", "test.db")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	vecStoreInstance = vecstore.New(vecstore.Options{DB: db})
+	require.NoError(t, vecStoreInstance.Initialize(ctx))
+	t.Cleanup(func() { vecStoreInstance = nil })
+	require.NoError(t, initSessions(ctx, db, nil))
+	require.NoError(t, initFeedback(ctx, db))
+
+	idx, err := vssindexer.NewIndexer(ctx, &vssindexer.IndexerConfig{
+		VecStore:         vecStoreInstance,
+		VectorDimensions: 1024,
+		Embedding:        testEmbedder{},
+	})
+	require.NoError(t, err)
+	kb := &KnowledgeBase{
+		Name:    defaultKB,
+		store:   vecStoreInstance,
+		indexer: &VecIndexerWrapper{indexer: idx},
+	}
+	kbMu.Lock()
+	knowledgeBases = map[string]*KnowledgeBase{defaultKB: kb}
+	kbMu.Unlock()
+	t.Cleanup(func() {
+		kbMu.Lock()
+		knowledgeBases = nil
+		kbMu.Unlock()
+	})
+	return db, kb
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/schema"
+	"github.com/gin-gonic/gin"
+	ragflow "github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/flow/rag"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// 提示词模板名称，模板中的 {name} 占位符在使用时替换
+const (
+	// PromptSystem RAG 对话的系统提示词，占位符 {context}（背景信息）和 {citation_policy}
+	PromptSystem = "system"
+	// PromptCitationPolicy 引用要求，插入系统提示词的 {citation_policy}
+	PromptCitationPolicy = "citation_policy"
+	// PromptNoContext 没有检索到内容时的背景信息
+	PromptNoContext = "no_context"
+	// PromptTriplesHeader 图谱三元组的标题
+	PromptTriplesHeader = "triples_header"
+	// PromptTripleItem 一个三元组，占位符 {subject} {predicate} {object}
+	PromptTripleItem = "triple_item"
+	// PromptDocumentsHeader 参考文档的标题
+	PromptDocumentsHeader = "documents_header"
+	// PromptDocumentItem 一个参考文档，占位符 {index} {score} {content} {source}
+	PromptDocumentItem = "document_item"
+	// PromptAgentSystem Agent 模式的系统提示词
+	PromptAgentSystem = "agent_system"
+)
+
+// 模板来源，优先级从低到高
+const (
+	PromptSourceDefault = "default"
+	PromptSourceFile    = "file"
+	PromptSourceDB      = "db"
+)
+
+// defaultPrompts 内置模板
+var defaultPrompts = map[string]string{
+	PromptSystem: "你是一个专业的知识库助手。请根据提供的背景信息回答问题。\n\n" +
+		"要求：\n" +
+		"1. 回答内容必须严格基于背景信息。\n" +
+		"2. {citation_policy}\n" +
+		"3. 如果背景信息中没有相关内容，请说明你不知道。\n\n" +
+		"背景信息：\n{context}",
+	PromptCitationPolicy:  "在引用背景信息的内容处，必须在行内使用 [n] 格式标注引用来源（例如 [1], [2]）。",
+	PromptNoContext:       ragflow.NoContextText,
+	PromptTriplesHeader:   "### 核心知识关联（三元组）(Knowledge Graph):",
+	PromptTripleItem:      "- {subject} --({predicate})--> {object}",
+	PromptDocumentsHeader: "### 相关参考文档 (Reference Documents):",
+	PromptDocumentItem:    "[{index}] (Score: {score}) {content}",
+	PromptAgentSystem: `你是一个知识库助手，可以调用工具回答问题。
+- search_documents：按语义检索知识库中的文档分块，适合查找具体内容。
+- run_sql：对 documents 视图执行只读 SQL（DuckDB 方言），适合统计、计数、按文件分组等聚合问题。
+  documents 的列：id, content, filename, filetype, source_id, url, page, metadata (JSON), created_at。
+  一个文件会被切分为多个分块（多行），统计文件数时使用 COUNT(DISTINCT filename)。
+需要时可以多次调用工具，根据工具结果回答；工具结果中没有的信息不要编造。`,
+}
+
+// promptSet 一组生效的模板及其来源
+type promptSet struct {
+	templates map[string]string
+	sources   map[string]string
+}
+
+var (
+	// currentPrompts 当前生效的模板，重新加载时整体替换
+	currentPrompts atomic.Pointer[promptSet]
+	// promptsFile PROMPTS_FILE 指定的模板文件（YAML 或 JSON），为空时不使用文件
+	promptsFile string
+)
+
+// getPrompt 返回模板，并替换 vars 中的占位符
+func getPrompt(name string, vars ...string) string {
+	tpl := defaultPrompts[name]
+	if set := currentPrompts.Load(); set != nil {
+		tpl = set.templates[name]
+	}
+	if len(vars) == 0 {
+		return tpl
+	}
+	return strings.NewReplacer(vars...).Replace(tpl)
+}
+
+// initPrompts 创建模板表并加载模板，之后按 PROMPTS_RELOAD_INTERVAL（默认 30s，0 表示不自动重新加载）
+// 重新读取模板文件和模板表，修改后无需重启
+func initPrompts(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS prompt_templates (
+		name VARCHAR PRIMARY KEY,
+		content VARCHAR,
+		updated_at TIMESTAMP
+	)`); err != nil {
+		return fmt.Errorf("failed to create prompt_templates table: %w", err)
+	}
+
+	promptsFile = os.Getenv("PROMPTS_FILE")
+	if err := reloadPrompts(ctx, db); err != nil {
+		return err
+	}
+
+	interval := 30 * time.Second
+	if v := os.Getenv("PROMPTS_RELOAD_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid PROMPTS_RELOAD_INTERVAL: %q", v)
+		}
+		interval = d
+	}
+	if interval > 0 {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if err := reloadPrompts(ctx, db); err != nil {
+						logrus.WithError(err).Warn("Failed to reload prompts, keeping previous templates")
+					}
+				}
+			}
+		}()
+	}
+	return nil
+}
+
+// reloadPrompts 依次合并内置模板、模板文件和模板表，出错时保留当前模板
+func reloadPrompts(ctx context.Context, db *sql.DB) error {
+	set := &promptSet{
+		templates: maps.Clone(defaultPrompts),
+		sources:   make(map[string]string, len(defaultPrompts)),
+	}
+	for name := range defaultPrompts {
+		set.sources[name] = PromptSourceDefault
+	}
+
+	if promptsFile != "" {
+		data, err := os.ReadFile(promptsFile)
+		if err != nil {
+			return fmt.Errorf("failed to read prompts file: %w", err)
+		}
+		var fileTemplates map[string]string
+		if err := yaml.Unmarshal(data, &fileTemplates); err != nil {
+			return fmt.Errorf("failed to parse prompts file %s: %w", promptsFile, err)
+		}
+		for name, content := range fileTemplates {
+			if _, ok := defaultPrompts[name]; !ok {
+				return fmt.Errorf("unknown prompt %q in %s", name, promptsFile)
+			}
+			set.templates[name] = content
+			set.sources[name] = PromptSourceFile
+		}
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT name, content FROM prompt_templates`)
+	if err != nil {
+		return fmt.Errorf("failed to load prompt templates: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name, content string
+		if err := rows.Scan(&name, &content); err != nil {
+			return err
+		}
+		// 表中的旧模板名可能已被移除，忽略
+		if _, ok := defaultPrompts[name]; ok {
+			set.templates[name] = content
+			set.sources[name] = PromptSourceDB
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if !strings.Contains(set.templates[PromptSystem], "{context}") {
+		logrus.Warn("System prompt has no {context} placeholder, retrieved documents will not be sent to the model")
+	}
+
+	if prev := currentPrompts.Swap(set); prev != nil && !maps.Equal(prev.templates, set.templates) {
+		logrus.Info("Prompt templates reloaded")
+	}
+	return nil
+}
+
+// promptTemplate 使用当前的系统提示词渲染 RAG Chain 的消息，每次调用时读取，模板重新加载后立即生效
+type promptTemplate struct{}
+
+func (promptTemplate) Format(ctx context.Context, vars map[string]any, opts ...prompt.Option) ([]*schema.Message, error) {
+	contextText, _ := vars[ragflow.VarContext].(string)
+	input, _ := vars[ragflow.VarInput].(string)
+	history, _ := vars[ragflow.VarHistory].([]*schema.Message)
+
+	// 直接替换占位符，模板中的其他花括号原样保留
+	system := getPrompt(PromptSystem,
+		"{citation_policy}", getPrompt(PromptCitationPolicy),
+		"{context}", contextText)
+
+	messages := make([]*schema.Message, 0, len(history)+2)
+	messages = append(messages, schema.SystemMessage(system))
+	messages = append(messages, history...)
+	messages = append(messages, schema.UserMessage(input))
+	return messages, nil
+}
+
+// promptContextFormatter 按当前模板格式化检索结果，文档按检索顺序编号为 [1], [2], ...
+func promptContextFormatter(ctx context.Context, input *ragflow.ContextInput) (string, error) {
+	if input == nil || (len(input.Docs) == 0 && len(input.Triples) == 0) {
+		return getPrompt(PromptNoContext), nil
+	}
+
+	var sb strings.Builder
+	if len(input.Triples) > 0 {
+		sb.WriteString(getPrompt(PromptTriplesHeader))
+		sb.WriteString("\n")
+		for _, t := range input.Triples {
+			sb.WriteString(getPrompt(PromptTripleItem,
+				"{subject}", t.Subject, "{predicate}", t.Predicate, "{object}", t.Object))
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(input.Docs) > 0 {
+		sb.WriteString(getPrompt(PromptDocumentsHeader))
+		sb.WriteString("\n")
+		for i, doc := range input.Docs {
+			score := "-"
+			if s, ok := ragflow.DocScore(doc); ok {
+				score = fmt.Sprintf("%.4f", s)
+			}
+			source, _ := doc.MetaData["filename"].(string)
+			if source == "" {
+				source, _ = doc.MetaData["url"].(string)
+			}
+			sb.WriteString(getPrompt(PromptDocumentItem,
+				"{index}", fmt.Sprint(i+1), "{score}", score, "{source}", source, "{content}", doc.Content))
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String(), nil
+}
+
+// handleListPrompts 返回当前生效的模板及其来源
+func handleListPrompts(c *gin.Context) {
+	set := currentPrompts.Load()
+	names := slices.Sorted(maps.Keys(defaultPrompts))
+	result := make([]gin.H, 0, len(names))
+	for _, name := range names {
+		result = append(result, gin.H{
+			"name":    name,
+			"content": set.templates[name],
+			"source":  set.sources[name],
+		})
+	}
+	c.JSON(200, gin.H{"prompts": result, "file": promptsFile})
+}
+
+type UpdatePromptRequest struct {
+	Content string `json:"content"`
+}
+
+// handleUpdatePrompt 在模板表中覆盖模板，立即生效
+func handleUpdatePrompt(c *gin.Context) {
+	name := c.Param("name")
+	if _, ok := defaultPrompts[name]; !ok {
+		c.JSON(404, gin.H{"error": fmt.Sprintf("Unknown prompt %q", name)})
+		return
+	}
+	var req UpdatePromptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	db := vecStoreInstance.GetDB()
+	if _, err := db.ExecContext(ctx, `
+		INSERT INTO prompt_templates (name, content, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET content = excluded.content, updated_at = excluded.updated_at
+	`, name, req.Content, time.Now()); err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to save prompt: %v", err)})
+		return
+	}
+	if err := reloadPrompts(ctx, db); err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to reload prompts: %v", err)})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Prompt updated successfully"})
+}
+
+// handleDeletePrompt 删除模板表中的覆盖，恢复为模板文件或内置模板
+func handleDeletePrompt(c *gin.Context) {
+	ctx := c.Request.Context()
+	db := vecStoreInstance.GetDB()
+	if _, err := db.ExecContext(ctx, `DELETE FROM prompt_templates WHERE name = ?`, c.Param("name")); err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to delete prompt: %v", err)})
+		return
+	}
+	if err := reloadPrompts(ctx, db); err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to reload prompts: %v", err)})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Prompt reset successfully"})
+}
+
+// handleReloadPrompts 立即重新读取模板文件和模板表
+func handleReloadPrompts(c *gin.Context) {
+	if err := reloadPrompts(c.Request.Context(), vecStoreInstance.GetDB()); err != nil {
+		c.JSON(500, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(200, gin.H{"message": "Prompts reloaded successfully"})
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// historyKeepMessages 原样注入 RAG Chain 的最近消息数（6 轮对话）
+	historyKeepMessages = 12
+	// historySummarizeBatch 超出保留窗口的消息累积到该数量后才合并进摘要，减少模型调用
+	historySummarizeBatch = 8
+	// sessionTitleRunes 会话标题取首条消息的前若干个字符
+	sessionTitleRunes = 30
+)
+
+var (
+	// summaryModel 用于压缩旧对话的模型
+	summaryModel model.BaseChatModel
+	// sessionsMu 串行化会话写入，保证消息序号连续
+	sessionsMu sync.Mutex
+)
+
+// Session 会话概况
+type Session struct {
+	ID           string    `json:"id"`
+	Title        string    `json:"title"`
+	Summary      string    `json:"summary,omitempty"`
+	MessageCount int       `json:"message_count"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// SessionMessage 会话中的一条消息
+type SessionMessage struct {
+	Role      string    `json:"role"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// initSessions 创建会话表
+// chat_sessions.summarized_count 记录已合并进 summary 的消息数
+func initSessions(ctx context.Context, db *sql.DB, cm model.BaseChatModel) error {
+	summaryModel = cm
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS chat_sessions (
+			id VARCHAR PRIMARY KEY,
+			title VARCHAR,
+			summary VARCHAR DEFAULT '',
+			summarized_count INTEGER DEFAULT 0,
+			created_at TIMESTAMP,
+			updated_at TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS chat_messages (
+			session_id VARCHAR,
+			seq INTEGER,
+			role VARCHAR,
+			content VARCHAR,
+			created_at TIMESTAMP,
+			PRIMARY KEY (session_id, seq)
+		)`,
+	}
+	for _, stmt := range stmts {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to create session tables: %w", err)
+		}
+	}
+	return nil
+}
+
+// ensureSession 返回会话 ID，id 为空时生成新 ID；会话不存在时以 message 作为标题创建
+func ensureSession(ctx context.Context, db *sql.DB, id, message string) (string, error) {
+	if id == "" {
+		id = uuid.NewString()
+	}
+	title := []rune(strings.TrimSpace(message))
+	if len(title) > sessionTitleRunes {
+		title = append(title[:sessionTitleRunes], '…')
+	}
+	now := time.Now()
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO chat_sessions (id, title, summary, summarized_count, created_at, updated_at)
+		VALUES (?, ?, '', 0, ?, ?)
+		ON CONFLICT (id) DO NOTHING
+	`, id, string(title), now, now)
+	if err != nil {
+		return "", fmt.Errorf("failed to create session: %w", err)
+	}
+	return id, nil
+}
+
+// appendSessionMessages 追加消息并更新会话时间
+func appendSessionMessages(ctx context.Context, db *sql.DB, sessionID string, messages ...*schema.Message) error {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var next int
+	if err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(seq), -1) + 1 FROM chat_messages WHERE session_id = ?`, sessionID).Scan(&next); err != nil {
+		return fmt.Errorf("failed to get message seq: %w", err)
+	}
+	now := time.Now()
+	for i, msg := range messages {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO chat_messages (session_id, seq, role, content, created_at) VALUES (?, ?, ?, ?, ?)`,
+			sessionID, next+i, string(msg.Role), msg.Content, now); err != nil {
+			return fmt.Errorf("failed to save message: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE chat_sessions SET updated_at = ? WHERE id = ?`, now, sessionID); err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	return tx.Commit()
+}
+
+// loadSessionMessages 按顺序读取会话中 seq >= from 的消息
+func loadSessionMessages(ctx context.Context, db *sql.DB, sessionID string, from int) ([]SessionMessage, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT role, content, created_at FROM chat_messages
+		WHERE session_id = ? AND seq >= ?
+		ORDER BY seq
+	`, sessionID, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []SessionMessage
+	for rows.Next() {
+		var msg SessionMessage
+		if err := rows.Scan(&msg.Role, &msg.Content, &msg.CreatedAt); err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+	return messages, rows.Err()
+}
+
+// loadHistory 返回注入 RAG Chain 的历史消息：旧对话的摘要（作为 system 消息）加上最近的消息
+// 未摘要的旧消息超过 historySummarizeBatch 条时，先调用模型把它们合并进摘要
+func loadHistory(ctx context.Context, db *sql.DB, sessionID string) ([]*schema.Message, error) {
+	summary, summarized, messages, err := readSessionHistory(ctx, db, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if overflow := len(messages) - historyKeepMessages; overflow >= historySummarizeBatch && summaryModel != nil {
+		newSummary, err := summarizeHistory(ctx, summary, messages[:overflow])
+		if err != nil {
+			// 摘要失败不影响对话，本轮带上全部未摘要的消息
+			logrus.WithError(err).WithField("session_id", sessionID).Warn("Failed to summarize chat history")
+		} else {
+			if _, err := db.ExecContext(ctx, `UPDATE chat_sessions SET summary = ?, summarized_count = ? WHERE id = ?`,
+				newSummary, summarized+overflow, sessionID); err != nil {
+				return nil, fmt.Errorf("failed to save summary: %w", err)
+			}
+			summary = newSummary
+			messages = messages[overflow:]
+		}
+	}
+
+	return buildHistory(summary, messages), nil
+}
+
+// loadRecentHistory 与 loadHistory 相同但不触发摘要，会话不存在时返回 sql.ErrNoRows
+func loadRecentHistory(ctx context.Context, db *sql.DB, sessionID string) ([]*schema.Message, error) {
+	summary, _, messages, err := readSessionHistory(ctx, db, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return buildHistory(summary, messages), nil
+}
+
+// readSessionHistory 读取会话摘要、已摘要的消息数和之后的消息
+func readSessionHistory(ctx context.Context, db *sql.DB, sessionID string) (string, int, []SessionMessage, error) {
+	var summary string
+	var summarized int
+	err := db.QueryRowContext(ctx, `SELECT summary, summarized_count FROM chat_sessions WHERE id = ?`, sessionID).Scan(&summary, &summarized)
+	if err != nil {
+		return "", 0, nil, fmt.Errorf("failed to load session: %w", err)
+	}
+
+	messages, err := loadSessionMessages(ctx, db, sessionID, summarized)
+	if err != nil {
+		return "", 0, nil, fmt.Errorf("failed to load messages: %w", err)
+	}
+	return summary, summarized, messages, nil
+}
+
+// buildHistory 将摘要（作为 system 消息）和消息转换为注入 RAG Chain 的历史
+func buildHistory(summary string, messages []SessionMessage) []*schema.Message {
+	var history []*schema.Message
+	if summary != "" {
+		history = append(history, schema.SystemMessage("之前对话的摘要：\n"+summary))
+	}
+	for _, msg := range messages {
+		history = append(history, &schema.Message{Role: schema.RoleType(msg.Role), Content: msg.Content})
+	}
+	return history
+}
+
+// summarizeHistory 将旧消息合并进已有摘要
+func summarizeHistory(ctx context.Context, summary string, messages []SessionMessage) (string, error) {
+	var sb strings.Builder
+	if summary != "" {
+		sb.WriteString("已有摘要：\n")
+		sb.WriteString(summary)
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString("新的对话：\n")
+	for _, msg := range messages {
+		role := "用户"
+		if msg.Role == string(schema.Assistant) {
+			role = "助手"
+		}
+		fmt.Fprintf(&sb, "%s：%s\n", role, msg.Content)
+	}
+
+	resp, err := summaryModel.Generate(ctx, []*schema.Message{
+		schema.SystemMessage("你负责压缩对话历史。请将已有摘要和新的对话合并为一段简洁的摘要，" +
+			"保留用户的问题、关注点以及回答中的关键事实和结论，不超过 300 字。只输出摘要内容。"),
+		schema.UserMessage(sb.String()),
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(resp.Content), nil
+}
+
+func handleListSessions(c *gin.Context) {
+	if vecStoreInstance == nil {
+		c.JSON(500, gin.H{"error": "VecStore not initialized"})
+		return
+	}
+
+	rows, err := vecStoreInstance.GetDB().QueryContext(c.Request.Context(), `
+		SELECT s.id, s.title, s.created_at, s.updated_at,
+			(SELECT COUNT(*) FROM chat_messages m WHERE m.session_id = s.id)
+		FROM chat_sessions s
+		ORDER BY s.updated_at DESC
+		LIMIT 100
+	`)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to list sessions: %v", err)})
+		return
+	}
+	defer rows.Close()
+
+	sessions := []Session{}
+	for rows.Next() {
+		var s Session
+		if err := rows.Scan(&s.ID, &s.Title, &s.CreatedAt, &s.UpdatedAt, &s.MessageCount); err != nil {
+			continue
+		}
+		sessions = append(sessions, s)
+	}
+
+	c.JSON(200, gin.H{"sessions": sessions})
+}
+
+func handleGetSession(c *gin.Context) {
+	if vecStoreInstance == nil {
+		c.JSON(500, gin.H{"error": "VecStore not initialized"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	db := vecStoreInstance.GetDB()
+	id := c.Param("id")
+
+	var s Session
+	err := db.QueryRowContext(ctx, `SELECT id, title, summary, created_at, updated_at FROM chat_sessions WHERE id = ?`, id).
+		Scan(&s.ID, &s.Title, &s.Summary, &s.CreatedAt, &s.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(404, gin.H{"error": "Session not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to get session: %v", err)})
+		return
+	}
+
+	messages, err := loadSessionMessages(ctx, db, id, 0)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to get messages: %v", err)})
+		return
+	}
+	if messages == nil {
+		messages = []SessionMessage{}
+	}
+	s.MessageCount = len(messages)
+
+	c.JSON(200, gin.H{"session": s, "messages": messages})
+}
+
+func handleDeleteSession(c *gin.Context) {
+	if vecStoreInstance == nil {
+		c.JSON(500, gin.H{"error": "VecStore not initialized"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	db := vecStoreInstance.GetDB()
+	id := c.Param("id")
+
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to delete session: %v", err)})
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM chat_sessions WHERE id = ?`, id)
+	if err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to delete session: %v", err)})
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		c.JSON(404, gin.H{"error": "Session not found"})
+		return
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM chat_messages WHERE session_id = ?`, id); err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to delete messages: %v", err)})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to delete session: %v", err)})
+		return
+	}
+
+	c.JSON(200, gin.H{"message": "Session deleted successfully"})
+}
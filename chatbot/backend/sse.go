@@ -0,0 +1,79 @@
+package main
+
+import (
+	"github.com/cloudwego/eino/schema"
+	"github.com/gin-gonic/gin"
+)
+
+// /api/chat 的流式协议版本，由请求的 sse_version 选择
+const (
+	// SSEVersionText 默认协议：message 事件为原始文本片段，回答结束后发送 citations 和 answer
+	SSEVersionText = 1
+	// SSEVersionEvents 结构化事件：retrieval_started、sources、token、usage、done、error，data 均为 JSON
+	SSEVersionEvents = 2
+)
+
+// 结构化事件类型
+const (
+	EventRetrievalStarted = "retrieval_started"
+	EventSources          = "sources"
+	EventToken            = "token"
+	EventUsage            = "usage"
+	EventDone             = "done"
+	EventError            = "error"
+)
+
+// RetrievalStartedEvent 开始检索，在调用模型前发送
+type RetrievalStartedEvent struct {
+	Query     string `json:"query"`
+	KB        string `json:"kb"`
+	SessionID string `json:"session_id"`
+}
+
+// TokenEvent 模型输出的文本片段
+type TokenEvent struct {
+	Content string `json:"content"`
+}
+
+// UsageEvent 模型返回的 token 用量，模型不返回用量时不发送
+type UsageEvent struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// DoneEvent 回答结束，answer_id 用于提交反馈，保存失败或没有生成内容时为空
+type DoneEvent struct {
+	SessionID    string `json:"session_id"`
+	AnswerID     string `json:"answer_id,omitempty"`
+	FinishReason string `json:"finish_reason,omitempty"`
+}
+
+// ErrorEvent 检索或生成失败，之后不再发送 done
+type ErrorEvent struct {
+	Error string `json:"error"`
+}
+
+// setSSEHeaders 设置流式响应的响应头
+func setSSEHeaders(c *gin.Context, sessionID string) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // 禁用 Nginx 缓存
+	c.Header("X-Session-ID", sessionID)
+}
+
+// sendEvent 发送一个 SSE 事件并立即刷新
+func sendEvent(c *gin.Context, event string, data any) {
+	c.SSEvent(event, data)
+	c.Writer.Flush()
+}
+
+// usageEvent 转换模型返回的用量
+func usageEvent(usage *schema.TokenUsage) UsageEvent {
+	return UsageEvent{
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sseEvent 响应中的一个 SSE 事件
+type sseEvent struct {
+	Event string
+	Data  string
+}
+
+// parseSSE 按顺序解析响应中的事件
+func parseSSE(t *testing.T, body string) []sseEvent {
+	t.Helper()
+	var events []sseEvent
+	var current sseEvent
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			current.Event = strings.TrimPrefix(line, "event:")
+		case strings.HasPrefix(line, "data:"):
+			current.Data = strings.TrimPrefix(line, "data:")
+		case line == "" && current.Event != "":
+			events = append(events, current)
+			current = sseEvent{}
+		}
+	}
+	require.NoError(t, scanner.Err())
+	return events
+}
+
+func eventNames(events []sseEvent) []string {
+	names := make([]string, len(events))
+	for i, e := range events {
+		names[i] = e.Event
+	}
+	return names
+}
+
+// setChatChain 把知识库的 RAG Chain 替换为返回固定检索结果和回答片段的 Chain
+// streamErr 不为空时在第一个片段之后返回该错误，chainErr 不为空时 Stream 直接失败
+func setChatChain(t *testing.T, kb *KnowledgeBase, chunks []string, streamErr, chainErr error) {
+	t.Helper()
+	lambda := compose.StreamableLambda(func(ctx context.Context, query string) (*schema.StreamReader[*schema.Message], error) {
+		if chainErr != nil {
+			return nil, chainErr
+		}
+		if collector, ok := ctx.Value(citationsKey{}).(*citationCollector); ok {
+			collector.docs = []*schema.Document{
+				{ID: "doc_chunk_0", Content: "retrieved content", MetaData: map[string]any{"filename": "doc.txt"}},
+			}
+		}
+		sr, sw := schema.Pipe[*schema.Message](len(chunks) + 1)
+		go func() {
+			defer sw.Close()
+			for i, chunk := range chunks {
+				msg := schema.AssistantMessage(chunk, nil)
+				if i == len(chunks)-1 {
+					msg.ResponseMeta = &schema.ResponseMeta{
+						FinishReason: "stop",
+						Usage:        &schema.TokenUsage{PromptTokens: 10, CompletionTokens: 2, TotalTokens: 12},
+					}
+				}
+				sw.Send(msg, nil)
+				if streamErr != nil {
+					sw.Send(nil, streamErr)
+					return
+				}
+			}
+		}()
+		return sr, nil
+	})
+	chain, err := compose.NewChain[string, *schema.Message]().AppendLambda(lambda).Compile(context.Background())
+	require.NoError(t, err)
+	kb.chain = chain
+}
+
+// postChat 通过真实的 HTTP 服务请求 /api/chat（流式响应需要 CloseNotifier），返回响应和响应体
+func postChat(t *testing.T, body string) (*http.Response, string) {
+	t.Helper()
+	r := gin.New()
+	r.POST("/api/chat", handleChat)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/api/chat", "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(data)
+}
+
+func TestChatSSEEvents(t *testing.T) {
+	_, kb := setupTestKB(t)
+	setChatChain(t, kb, []string{"Hello", " world"}, nil, nil)
+
+	resp, body := postChat(t, `{"message": "hi", "sse_version": 2}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	sessionID := resp.Header.Get("X-Session-ID")
+	require.NotEmpty(t, sessionID)
+
+	events := parseSSE(t, body)
+	assert.Equal(t, []string{EventRetrievalStarted, EventSources, EventToken, EventToken, EventUsage, EventDone}, eventNames(events))
+
+	var started RetrievalStartedEvent
+	require.NoError(t, json.Unmarshal([]byte(events[0].Data), &started))
+	assert.Equal(t, RetrievalStartedEvent{Query: "hi", KB: defaultKB, SessionID: sessionID}, started)
+
+	var sources []Citation
+	require.NoError(t, json.Unmarshal([]byte(events[1].Data), &sources))
+	require.Len(t, sources, 1)
+	assert.Equal(t, "doc_chunk_0", sources[0].DocumentID)
+
+	var token TokenEvent
+	require.NoError(t, json.Unmarshal([]byte(events[3].Data), &token))
+	assert.Equal(t, " world", token.Content)
+
+	var usage UsageEvent
+	require.NoError(t, json.Unmarshal([]byte(events[4].Data), &usage))
+	assert.Equal(t, UsageEvent{PromptTokens: 10, CompletionTokens: 2, TotalTokens: 12}, usage)
+
+	var done DoneEvent
+	require.NoError(t, json.Unmarshal([]byte(events[5].Data), &done))
+	assert.Equal(t, sessionID, done.SessionID)
+	assert.Equal(t, "stop", done.FinishReason)
+	assert.NotEmpty(t, done.AnswerID)
+
+	// 回答保存到会话中
+	messages, err := loadSessionMessages(context.Background(), vecStoreInstance.GetDB(), sessionID, 0)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "Hello world", messages[1].Content)
+}
+
+func TestChatSSETextEvents(t *testing.T) {
+	_, kb := setupTestKB(t)
+	setChatChain(t, kb, []string{"Hello", " world"}, nil, nil)
+
+	resp, body := postChat(t, `{"message": "hi"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	events := parseSSE(t, body)
+	assert.Equal(t, []string{"message", "message", "citations", "answer"}, eventNames(events))
+	assert.Equal(t, "Hello", events[0].Data)
+}
+
+func TestChatSSEErrors(t *testing.T) {
+	_, kb := setupTestKB(t)
+
+	// 检索失败：retrieval_started 之后只有 error
+	setChatChain(t, kb, nil, nil, errors.New("retriever unavailable"))
+	_, body := postChat(t, `{"message": "hi", "sse_version": 2}`)
+	events := parseSSE(t, body)
+	assert.Equal(t, []string{EventRetrievalStarted, EventError}, eventNames(events))
+	assert.Contains(t, events[1].Data, "retriever unavailable")
+
+	// 生成中途失败：已输出的片段之后是 error，不再发送 done
+	setChatChain(t, kb, []string{"Hello", " world"}, errors.New("connection reset"), nil)
+	_, body = postChat(t, `{"message": "hi", "sse_version": 2}`)
+	events = parseSSE(t, body)
+	assert.Equal(t, []string{EventRetrievalStarted, EventSources, EventToken, EventError}, eventNames(events))
+	assert.Contains(t, events[3].Data, "connection reset")
+
+	// 默认协议在检索失败时返回 JSON 错误
+	setChatChain(t, kb, nil, nil, errors.New("retriever unavailable"))
+	resp, _ := postChat(t, `{"message": "hi"}`)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+	resp, _ = postChat(t, `{"message": "hi", "sse_version": 3}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -4,24 +4,52 @@ import { useState, useRef, useEffect } from "react";
 import { Button } from "@/components/ui/button";
 import { Input } from "@/components/ui/input";
 import { Card, CardContent, CardHeader, CardTitle } from "@/components/ui/card";
-import { Send, Loader2, Upload, FileText, Trash2, X, Share2 } from "lucide-react";
-import Link from "next/link";
+import { Send, Loader2, Upload, FileText, Trash2, X, MessageSquarePlus, ThumbsUp, ThumbsDown } from "lucide-react";
 import ReactMarkdown from "react-markdown";
 import remarkGfm from "remark-gfm";
 
+interface Citation {
+  index: number;
+  document_id: string;
+  filename?: string;
+  url?: string;
+  page?: number;
+  section?: string;
+  score: number;
+  snippet: string;
+  source_url?: string;
+}
+
 interface Message {
   role: "user" | "assistant";
   content: string;
+  citations?: Citation[];
+  answerId?: string;
+  feedback?: "up" | "down";
+  usage?: Usage;
+}
+
+interface Usage {
+  prompt_tokens: number;
+  completion_tokens: number;
+  total_tokens: number;
 }
 
 const API_BASE_URL = process.env.NEXT_PUBLIC_API_URL || "http://localhost:45111/api";
+const SESSION_STORAGE_KEY = "chatbot_session_id";
+const KB_STORAGE_KEY = "chatbot_kb";
+const NEW_KB_OPTION = "__new__";
 
 export default function Home() {
   const [messages, setMessages] = useState<Message[]>([]);
+  const [sessionId, setSessionId] = useState<string | null>(null);
   const [input, setInput] = useState("");
   const [isLoading, setIsLoading] = useState(false);
   const [isUploading, setIsUploading] = useState(false);
+  const [uploadProgress, setUploadProgress] = useState(0);
   const [documents, setDocuments] = useState<any[]>([]);
+  const [kb, setKb] = useState("default");
+  const [knowledgeBases, setKnowledgeBases] = useState<any[]>([]);
   const [showDocs, setShowDocs] = useState(false);
   const [queryMode, setQueryMode] = useState<"global" | "hybrid" | "local" | "graph" | "naive">("global");
   const messagesEndRef = useRef<HTMLDivElement>(null);
@@ -36,12 +64,84 @@ export default function Home() {
   }, [messages]);
 
   useEffect(() => {
-    fetchDocuments();
+    setKb(localStorage.getItem(KB_STORAGE_KEY) || "default");
+    fetchKnowledgeBases();
+    restoreSession();
   }, []);
 
+  useEffect(() => {
+    fetchDocuments();
+  }, [kb]);
+
+  // 所有文档和对话接口都通过 kb 参数选择知识库
+  const withKb = (path: string) =>
+    `${API_BASE_URL}${path}${path.includes("?") ? "&" : "?"}kb=${encodeURIComponent(kb)}`;
+
+  const fetchKnowledgeBases = async () => {
+    try {
+      const response = await fetch(`${API_BASE_URL}/kbs`);
+      if (response.ok) {
+        const data = await response.json();
+        setKnowledgeBases(data.knowledge_bases || []);
+      }
+    } catch (error) {
+      console.error("Failed to fetch knowledge bases:", error);
+    }
+  };
+
+  const handleSelectKb = async (value: string) => {
+    if (value !== NEW_KB_OPTION) {
+      setKb(value);
+      localStorage.setItem(KB_STORAGE_KEY, value);
+      return;
+    }
+    const name = prompt("知识库名称（小写字母、数字或下划线）");
+    if (!name) return;
+    const response = await fetch(`${API_BASE_URL}/kbs`, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ name }),
+    });
+    if (!response.ok) {
+      const errorData = await response.json().catch(() => ({}));
+      alert(`创建失败：${errorData.error || response.status}`);
+      return;
+    }
+    await fetchKnowledgeBases();
+    setKb(name);
+    localStorage.setItem(KB_STORAGE_KEY, name);
+  };
+
+  // 恢复上次的会话，历史消息由后端保存
+  const restoreSession = async () => {
+    const savedId = localStorage.getItem(SESSION_STORAGE_KEY);
+    if (!savedId) return;
+    try {
+      const response = await fetch(`${API_BASE_URL}/sessions/${savedId}`);
+      if (!response.ok) {
+        localStorage.removeItem(SESSION_STORAGE_KEY);
+        return;
+      }
+      const data = await response.json();
+      setSessionId(savedId);
+      setMessages(
+        (data.messages || []).map((m: any) => ({ role: m.role, content: m.content }))
+      );
+    } catch (error) {
+      console.error("Failed to restore session:", error);
+    }
+  };
+
+  const handleNewSession = () => {
+    if (isLoading) return;
+    localStorage.removeItem(SESSION_STORAGE_KEY);
+    setSessionId(null);
+    setMessages([]);
+  };
+
   const fetchDocuments = async () => {
     try {
-      const response = await fetch(`${API_BASE_URL}/documents`);
+      const response = await fetch(withKb("/documents"));
       if (response.ok) {
         const data = await response.json();
         setDocuments(data.documents || []);
@@ -51,16 +151,32 @@ export default function Home() {
     }
   };
 
+  const waitForJob = async (jobId: string) => {
+    while (true) {
+      const response = await fetch(`${API_BASE_URL}/jobs/${jobId}`);
+      if (!response.ok) {
+        throw new Error(`Failed to get job: ${response.status}`);
+      }
+      const job = await response.json();
+      setUploadProgress(Math.round(job.progress * 100));
+      if (job.status === "succeeded" || job.status === "failed") {
+        return job;
+      }
+      await new Promise((resolve) => setTimeout(resolve, 1000));
+    }
+  };
+
   const handleFileUpload = async (e: React.ChangeEvent<HTMLInputElement>) => {
     const file = e.target.files?.[0];
     if (!file) return;
 
     setIsUploading(true);
+    setUploadProgress(0);
     const formData = new FormData();
     formData.append("file", file);
 
     try {
-      const response = await fetch(`${API_BASE_URL}/upload`, {
+      const response = await fetch(withKb("/upload"), {
         method: "POST",
         body: formData,
       });
@@ -69,8 +185,14 @@ export default function Home() {
         throw new Error("Upload failed");
       }
 
+      // 后台任务负责解析和索引，轮询任务状态直到结束
+      const { job_id } = await response.json();
+      const job = await waitForJob(job_id);
       await fetchDocuments();
-      alert("文件上传并索引成功！");
+      if (job.status !== "succeeded") {
+        throw new Error(job.error || "Indexing failed");
+      }
+      alert(`文件上传并索引成功！共 ${job.indexed_count} 个分块`);
     } catch (error) {
       console.error("Error uploading file:", error);
       alert("文件上传失败，请重试。");
@@ -86,7 +208,7 @@ export default function Home() {
     if (!confirm("确定要删除这个文档吗？")) return;
 
     try {
-      const response = await fetch(`${API_BASE_URL}/documents/${id}`, {
+      const response = await fetch(withKb(`/documents/${id}`), {
         method: "DELETE",
       });
 
@@ -101,6 +223,30 @@ export default function Home() {
     }
   };
 
+  // 提交对回答的评价，差评时可以填写正确答案，用于导出评估数据集
+  const handleFeedback = async (index: number, rating: "up" | "down") => {
+    const message = messages[index];
+    if (!message.answerId) return;
+    let correction = "";
+    if (rating === "down") {
+      correction = prompt("可以填写正确的答案（可选）") || "";
+    }
+    try {
+      const response = await fetch(`${API_BASE_URL}/feedback`, {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify({ answer_id: message.answerId, rating, correction }),
+      });
+      if (!response.ok) {
+        throw new Error(`Failed to save feedback: ${response.status}`);
+      }
+      setMessages((prev) => prev.map((m, i) => (i === index ? { ...m, feedback: rating } : m)));
+    } catch (error) {
+      console.error("Error saving feedback:", error);
+      alert("提交反馈失败");
+    }
+  };
+
   const handleSend = async () => {
     if (!input.trim() || isLoading) return;
 
@@ -114,15 +260,16 @@ export default function Home() {
     setIsLoading(true);
 
     try {
-      const response = await fetch(`${API_BASE_URL}/chat`, {
+      const response = await fetch(withKb("/chat"), {
         method: "POST",
         headers: {
           "Content-Type": "application/json",
         },
         body: JSON.stringify({
           message: input,
-          history: messages.map((m) => m.content),
+          session_id: sessionId || undefined,
           mode: queryMode,
+          sse_version: 2,
         }),
       });
 
@@ -135,6 +282,12 @@ export default function Home() {
         throw new Error("No response body");
       }
 
+      const newSessionId = response.headers.get("X-Session-ID");
+      if (newSessionId && newSessionId !== sessionId) {
+        setSessionId(newSessionId);
+        localStorage.setItem(SESSION_STORAGE_KEY, newSessionId);
+      }
+
       // 添加一个空的助手机持消息占位
       setMessages((prev) => [...prev, { role: "assistant", content: "" }]);
 
@@ -157,9 +310,12 @@ export default function Home() {
           if (!part.trim()) continue;
 
           const lines = part.split("\n");
+          let eventType = "message";
           let contentChunk = "";
           for (const line of lines) {
-            if (line.startsWith("data:")) {
+            if (line.startsWith("event:")) {
+              eventType = line.slice(6).trim();
+            } else if (line.startsWith("data:")) {
               let data = line.slice(5);
               if (data.startsWith(" ")) {
                 data = data.slice(1);
@@ -171,18 +327,37 @@ export default function Home() {
             }
           }
 
-          if (contentChunk) {
-            assistantContent += contentChunk;
+          // 结构化事件（sse_version 2），data 均为 JSON
+          const data = contentChunk ? JSON.parse(contentChunk) : {};
+          const updateAssistant = (patch: Partial<Message>) =>
             setMessages((prev) => {
               const newMessages = [...prev];
               if (newMessages.length > 0) {
                 newMessages[newMessages.length - 1] = {
                   ...newMessages[newMessages.length - 1],
-                  content: assistantContent,
+                  ...patch,
                 };
               }
-              return [...newMessages];
+              return newMessages;
             });
+
+          switch (eventType) {
+            case "sources":
+              // 检索完成后立即显示引用，回答随后流式输出
+              updateAssistant({ citations: data as Citation[] });
+              break;
+            case "token":
+              assistantContent += data.content;
+              updateAssistant({ content: assistantContent });
+              break;
+            case "usage":
+              updateAssistant({ usage: data as Usage });
+              break;
+            case "done":
+              updateAssistant({ answerId: data.answer_id });
+              break;
+            case "error":
+              throw new Error(data.error);
           }
         }
       }
@@ -213,6 +388,19 @@ export default function Home() {
           <p className="text-sm text-muted-foreground">基于 eino-lightrag 的多轮对话示例</p>
         </div>
         <div className="flex gap-2">
+          <select
+            value={kb}
+            onChange={(e) => handleSelectKb(e.target.value)}
+            className="bg-background border rounded-md px-2 py-1 text-sm focus:outline-none focus:ring-2 focus:ring-primary"
+            disabled={isLoading || isUploading}
+          >
+            {knowledgeBases.map((item) => (
+              <option key={item.name} value={item.name}>
+                {item.name} ({item.document_count})
+              </option>
+            ))}
+            <option value={NEW_KB_OPTION}>+ 新建知识库</option>
+          </select>
           <select
             value={queryMode}
             onChange={(e) => setQueryMode(e.target.value as any)}
@@ -225,12 +413,10 @@ export default function Home() {
             <option value="graph">Graph Mode</option>
             <option value="naive">Naive Mode</option>
           </select>
-          <Link href="/graph">
-            <Button variant="outline">
-              <Share2 className="h-4 w-4 mr-2" />
-              知识图谱
-            </Button>
-          </Link>
+          <Button variant="outline" onClick={handleNewSession} disabled={isLoading}>
+            <MessageSquarePlus className="h-4 w-4 mr-2" />
+            新对话
+          </Button>
           <Button variant="outline" onClick={() => setShowDocs(!showDocs)}>
             <FileText className="h-4 w-4 mr-2" />
             知识库 ({documents.length})
@@ -241,14 +427,14 @@ export default function Home() {
             ) : (
               <Upload className="h-4 w-4 mr-2" />
             )}
-            上传文档
+            {isUploading ? `索引中 ${uploadProgress}%` : "上传文档"}
           </Button>
           <input
             type="file"
             ref={fileInputRef}
             onChange={handleFileUpload}
             className="hidden"
-            accept=".txt,.md,.pdf,.docx,.doc"
+            accept=".txt,.md,.pdf,.docx,.doc,.xlsx,.csv,.tsv,.mp3,.wav,.m4a,.webm,.ogg,.flac"
           />
         </div>
       </header>
@@ -397,6 +583,66 @@ export default function Home() {
                       {message.content}
                     </ReactMarkdown>
                   )}
+                  {message.citations && message.citations.length > 0 && (
+                    <div className="mt-3 border-t pt-2 space-y-1 text-xs text-muted-foreground">
+                      {message.citations.map((citation) => {
+                        const location = [
+                          citation.page ? `第 ${citation.page} 页` : "",
+                          citation.section || "",
+                        ]
+                          .filter(Boolean)
+                          .join(" · ");
+                        const name = citation.filename || citation.url || citation.document_id;
+                        return (
+                          <div key={citation.index} title={citation.snippet}>
+                            <span className="font-bold text-primary">[{citation.index}]</span>{" "}
+                            {citation.source_url ? (
+                              <a
+                                href={withKb(`/documents/${encodeURIComponent(citation.document_id)}/source`)}
+                                className="underline"
+                                target="_blank"
+                                rel="noreferrer"
+                              >
+                                {name}
+                              </a>
+                            ) : citation.url ? (
+                              <a href={citation.url} className="underline" target="_blank" rel="noreferrer">
+                                {name}
+                              </a>
+                            ) : (
+                              name
+                            )}
+                            {location && ` (${location})`} · {citation.score.toFixed(2)}
+                          </div>
+                        );
+                      })}
+                    </div>
+                  )}
+                  {message.answerId && (
+                    <div className="mt-2 flex gap-1 items-center">
+                      <Button
+                        variant={message.feedback === "up" ? "default" : "ghost"}
+                        size="icon"
+                        className="h-7 w-7"
+                        onClick={() => handleFeedback(index, "up")}
+                      >
+                        <ThumbsUp className="h-3 w-3" />
+                      </Button>
+                      <Button
+                        variant={message.feedback === "down" ? "default" : "ghost"}
+                        size="icon"
+                        className="h-7 w-7"
+                        onClick={() => handleFeedback(index, "down")}
+                      >
+                        <ThumbsDown className="h-3 w-3" />
+                      </Button>
+                      {message.usage && (
+                        <span className="ml-2 text-xs text-muted-foreground">
+                          {message.usage.prompt_tokens} + {message.usage.completion_tokens} tokens
+                        </span>
+                      )}
+                    </div>
+                  )}
                 </div>
               </div>
             ))}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/lightrag"
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/lightrag/bench"
+)
+
+// benchFlags bench 命令的参数
+type benchFlags struct {
+	commonFlags
+	dataset   string
+	mode      string
+	cutoffs   string
+	noLLM     bool
+	json      bool
+	out       string
+	baseline  string
+	tolerance float64
+}
+
+func runBench(ctx context.Context, args []string) error {
+	var f benchFlags
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "用法: sqlite-ai bench [参数] <数据集.jsonl>")
+		fmt.Fprintln(flags.Output(), `数据集每行一个问题: {"question": "...", "relevant_ids": ["分块 ID", ...]}`)
+		flags.PrintDefaults()
+	}
+	f.register(flags)
+	flags.StringVar(&f.mode, "mode", "all", "检索模式: hybrid|vector|fulltext|graph|local|global|naive|mix|all")
+	flags.StringVar(&f.cutoffs, "k", "1,3,5,10", "统计 recall@k 的 k，逗号分隔")
+	flags.BoolVar(&f.noLLM, "no-llm", false, "不使用 LLM 提取查询关键词")
+	flags.BoolVar(&f.json, "json", false, "以 JSON 输出报告")
+	flags.StringVar(&f.out, "out", "", "把报告保存为 JSON 文件，可用作之后的 -baseline")
+	flags.StringVar(&f.baseline, "baseline", "", "与之前保存的报告比较，有指标下降时返回错误")
+	flags.Float64Var(&f.tolerance, "tolerance", 0.01, "与基线比较时允许的下降幅度")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("no dataset")
+	}
+	f.dataset = flags.Arg(0)
+	modes, err := parseModes(f.mode)
+	if err != nil {
+		return err
+	}
+	cutoffs, err := parseCutoffs(f.cutoffs)
+	if err != nil {
+		return err
+	}
+	cases, err := bench.LoadDataset(f.dataset)
+	if err != nil {
+		return err
+	}
+	var baseline *bench.Report
+	if f.baseline != "" {
+		if baseline, err = bench.LoadReport(f.baseline); err != nil {
+			return err
+		}
+	}
+	f.setupLogging()
+
+	cfg, err := loadConfig(f.configFile)
+	if err != nil {
+		return err
+	}
+	rag, err := openRAG(ctx, cfg, ragOptions{workingDir: f.workingDir, noLLM: f.noLLM})
+	if err != nil {
+		return err
+	}
+	defer rag.FinalizeStorages(context.Background())
+
+	bar := newProgressBar(os.Stderr, len(modes)*len(cases))
+	done := 0
+	report, err := bench.Run(ctx, rag, cases, bench.Options{
+		Modes:   modes,
+		Cutoffs: cutoffs,
+		Progress: func(mode lightrag.QueryMode, _, _ int) {
+			done++
+			bar.Update(done, string(mode))
+		},
+	})
+	bar.Done()
+	if err != nil {
+		return err
+	}
+	report.Dataset = f.dataset
+
+	if f.json {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("数据集 %s，%d 个问题\n\n", f.dataset, report.Cases)
+		if err := report.WriteTable(os.Stdout); err != nil {
+			return err
+		}
+	}
+	if f.out != "" {
+		if err := bench.SaveReport(f.out, report); err != nil {
+			return err
+		}
+	}
+
+	if baseline != nil {
+		regressions := bench.Compare(baseline, report, f.tolerance)
+		if len(regressions) > 0 {
+			fmt.Fprintf(os.Stderr, "\n相对基线 %s 下降的指标:\n", f.baseline)
+			for _, r := range regressions {
+				fmt.Fprintf(os.Stderr, "  %s\n", r)
+			}
+			return fmt.Errorf("%d metrics regressed", len(regressions))
+		}
+		fmt.Fprintf(os.Stderr, "\n与基线 %s 相比没有指标下降\n", f.baseline)
+	}
+	return nil
+}
+
+// parseCutoffs 解析 -k，如 1,3,5,10
+func parseCutoffs(s string) ([]int, error) {
+	var cutoffs []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		k, err := strconv.Atoi(part)
+		if err != nil || k <= 0 {
+			return nil, fmt.Errorf("invalid k: %q", part)
+		}
+		cutoffs = append(cutoffs, k)
+	}
+	if len(cutoffs) == 0 {
+		return nil, fmt.Errorf("no k")
+	}
+	return cutoffs, nil
+}
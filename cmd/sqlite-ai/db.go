@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/lightrag"
+)
+
+const dbUsage = `用法:
+  sqlite-ai db inspect [参数]       查看数据表、行数、全文索引、向量生成进度和结构版本
+  sqlite-ai db vacuum [参数]        更新统计信息并合并 WAL，回收删除数据占用的空间
+  sqlite-ai db fts-rebuild [参数]   重新分词并重建全文索引
+  sqlite-ai db reembed [参数]       重新生成向量（更换 embedding 模型后使用）
+  sqlite-ai db migrate [参数]       执行尚未执行的结构升级（其他命令打开工作目录时也会自动执行）
+`
+
+func runDB(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, dbUsage)
+		return fmt.Errorf("missing db subcommand")
+	}
+	switch args[0] {
+	case "inspect":
+		return runDBInspect(ctx, args[1:])
+	case "vacuum":
+		return runDBVacuum(ctx, args[1:])
+	case "fts-rebuild":
+		return runDBFTSRebuild(ctx, args[1:])
+	case "reembed":
+		return runDBReembed(ctx, args[1:])
+	case "migrate":
+		return runDBMigrate(ctx, args[1:])
+	case "-h", "--help", "help":
+		fmt.Fprint(os.Stderr, dbUsage)
+		return flag.ErrHelp
+	default:
+		fmt.Fprint(os.Stderr, dbUsage)
+		return fmt.Errorf("unknown db subcommand: %s", args[0])
+	}
+}
+
+// openDB 解析参数后打开工作目录；只有 reembed 需要 embedding，其余命令都不调用 LLM
+func openDB(ctx context.Context, f *commonFlags, flags *flag.FlagSet, args []string) (*lightrag.LightRAG, error) {
+	return openDBWithOptions(ctx, f, flags, args, ragOptions{})
+}
+
+func openDBWithOptions(ctx context.Context, f *commonFlags, flags *flag.FlagSet, args []string, opts ragOptions) (*lightrag.LightRAG, error) {
+	f.register(flags)
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	f.setupLogging()
+	cfg, err := loadConfig(f.configFile)
+	if err != nil {
+		return nil, err
+	}
+	opts.workingDir = f.workingDir
+	opts.noLLM = true
+	return openRAG(ctx, cfg, opts)
+}
+
+func runDBInspect(ctx context.Context, args []string) error {
+	var f commonFlags
+	var asJSON bool
+	flags := flag.NewFlagSet("db inspect", flag.ContinueOnError)
+	flags.BoolVar(&asJSON, "json", false, "以 JSON 输出")
+	rag, err := openDB(ctx, &f, flags, args)
+	if err != nil {
+		return err
+	}
+	defer rag.FinalizeStorages(context.Background())
+
+	info, err := rag.Inspect(ctx)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	}
+
+	fmt.Printf("数据库: %s（WAL %s）\n\n", info.DatabaseSize, info.WALSize)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "表\t行数")
+	for _, table := range info.Tables {
+		fmt.Fprintf(tw, "%s\t%d\n", table.Name, table.Rows)
+	}
+	tw.Flush()
+
+	fts := info.Fulltext
+	indexed := "未创建"
+	if fts.Indexed {
+		indexed = "已创建"
+	}
+	fmt.Printf("\n全文索引: %s（分词器 %s），%d 个文档已分词，%d 个缺少分词\n", indexed, fts.Analyzer, fts.Tokenized, fts.Untokenized)
+
+	emb := info.Embeddings
+	fmt.Printf("向量: %d/%d 已完成（%s），待处理 %d，处理中 %d，失败 %d，存有向量 %d\n",
+		emb.Completed, emb.Total, percent(emb.Completed, emb.Total), emb.Pending, emb.Processing, emb.Failed, emb.Vectors)
+	fmt.Printf("知识图谱: %d 条三元组\n", info.GraphTriples)
+	fmt.Printf("结构版本: %d/%d\n", info.SchemaVersion, info.LatestSchemaVersion)
+
+	if fts.Untokenized > 0 {
+		fmt.Println("\n提示: 有文档缺少分词结果，运行 sqlite-ai db fts-rebuild 后才能被全文检索")
+	}
+	if emb.Failed > 0 {
+		fmt.Println("提示: 有文档生成向量失败，运行 sqlite-ai db reembed -failed 重试")
+	}
+	if info.SchemaVersion < info.LatestSchemaVersion {
+		fmt.Println("提示: 有尚未执行的结构升级，运行 sqlite-ai db migrate")
+	}
+	return nil
+}
+
+func runDBVacuum(ctx context.Context, args []string) error {
+	var f commonFlags
+	rag, err := openDB(ctx, &f, flag.NewFlagSet("db vacuum", flag.ContinueOnError), args)
+	if err != nil {
+		return err
+	}
+	defer rag.FinalizeStorages(context.Background())
+
+	before, err := rag.Inspect(ctx)
+	if err != nil {
+		return err
+	}
+	if err := rag.Vacuum(ctx); err != nil {
+		return err
+	}
+	after, err := rag.Inspect(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("数据库: %s（WAL %s）-> %s（WAL %s）\n", before.DatabaseSize, before.WALSize, after.DatabaseSize, after.WALSize)
+	return nil
+}
+
+func runDBFTSRebuild(ctx context.Context, args []string) error {
+	var f commonFlags
+	rag, err := openDB(ctx, &f, flag.NewFlagSet("db fts-rebuild", flag.ContinueOnError), args)
+	if err != nil {
+		return err
+	}
+	defer rag.FinalizeStorages(context.Background())
+
+	start := time.Now()
+	n, err := rag.RebuildFulltextIndex(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("重建全文索引: %d 个文档，用时 %s\n", n, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+func runDBReembed(ctx context.Context, args []string) error {
+	var f commonFlags
+	var failedOnly bool
+	var wait time.Duration
+	flags := flag.NewFlagSet("db reembed", flag.ContinueOnError)
+	flags.BoolVar(&failedOnly, "failed", false, "只重新生成失败的向量")
+	flags.DurationVar(&wait, "wait", 30*time.Minute, "等待向量生成完成的最长时间")
+	rag, err := openDB(ctx, &f, flags, args)
+	if err != nil {
+		return err
+	}
+	defer rag.FinalizeStorages(context.Background())
+
+	n, err := rag.Reembed(ctx, failedOnly)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "重新生成 %d 个文档的向量...\n", n)
+	if n > 0 && wait > 0 {
+		if err := rag.WaitForEmbeddings(ctx, wait); err != nil {
+			return err
+		}
+	}
+
+	info, err := rag.Inspect(ctx)
+	if err != nil {
+		return err
+	}
+	emb := info.Embeddings
+	fmt.Printf("向量: %d/%d 已完成，失败 %d，未完成 %d\n", emb.Completed, emb.Total, emb.Failed, emb.Pending+emb.Processing)
+	if emb.Failed > 0 {
+		return fmt.Errorf("%d embeddings failed", emb.Failed)
+	}
+	return nil
+}
+
+func runDBMigrate(ctx context.Context, args []string) error {
+	var f commonFlags
+	var dryRun bool
+	flags := flag.NewFlagSet("db migrate", flag.ContinueOnError)
+	flags.BoolVar(&dryRun, "dry-run", false, "只列出尚未执行的结构升级")
+	// 关闭自动升级，才能列出和报告本次执行的升级
+	rag, err := openDBWithOptions(ctx, &f, flags, args, ragOptions{skipMigrations: true})
+	if err != nil {
+		return err
+	}
+	defer rag.FinalizeStorages(context.Background())
+
+	if dryRun {
+		pending, err := rag.PendingMigrations(ctx)
+		if err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			fmt.Println("结构已是最新版本")
+		}
+		for _, m := range pending {
+			fmt.Printf("%d  %s\n", m.Version, m.Description)
+		}
+		return nil
+	}
+
+	applied, err := rag.Migrate(ctx)
+	for _, m := range applied {
+		fmt.Printf("已执行 %d  %s\n", m.Version, m.Description)
+	}
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		fmt.Println("结构已是最新版本")
+	}
+	return nil
+}
+
+// percent 格式化百分比，total 为 0 时返回 -
+func percent(n, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", float64(n)*100/float64(total))
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/lightrag"
+)
+
+const graphUsage = `用法:
+  sqlite-ai graph export [参数]          导出知识图谱为 JSON
+  sqlite-ai graph import [参数] <文件|->  从 JSON 导入知识图谱，- 表示标准输入
+  sqlite-ai graph log [参数]             查看图谱提取的提示词和响应（需要以 -log-extraction 导入）
+  sqlite-ai graph summarize [参数]       用 LLM 合并实体的多条描述
+  sqlite-ai graph reextract [参数]       清除并用当前的提示词和模型重新提取知识图谱
+  sqlite-ai graph ui [参数]              启动知识图谱可视化界面
+`
+
+func runGraph(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, graphUsage)
+		return fmt.Errorf("missing graph subcommand")
+	}
+	switch args[0] {
+	case "export":
+		return runGraphExport(ctx, args[1:])
+	case "import":
+		return runGraphImport(ctx, args[1:])
+	case "log":
+		return runGraphLog(ctx, args[1:])
+	case "summarize":
+		return runGraphSummarize(ctx, args[1:])
+	case "reextract":
+		return runGraphReextract(ctx, args[1:])
+	case "ui":
+		return runGraphUI(ctx, args[1:])
+	case "-h", "--help", "help":
+		fmt.Fprint(os.Stderr, graphUsage)
+		return flag.ErrHelp
+	default:
+		fmt.Fprint(os.Stderr, graphUsage)
+		return fmt.Errorf("unknown graph subcommand: %s", args[0])
+	}
+}
+
+func runGraphExport(ctx context.Context, args []string) error {
+	var f commonFlags
+	var output, docID string
+	flags := flag.NewFlagSet("graph export", flag.ContinueOnError)
+	f.register(flags)
+	flags.StringVar(&output, "o", "", "输出文件，默认输出到标准输出")
+	flags.StringVar(&docID, "doc", "", "只导出该文档相关的子图")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	f.setupLogging()
+
+	cfg, err := loadConfig(f.configFile)
+	if err != nil {
+		return err
+	}
+	rag, err := openRAG(ctx, cfg, ragOptions{workingDir: f.workingDir, noLLM: true})
+	if err != nil {
+		return err
+	}
+	defer rag.FinalizeStorages(context.Background())
+
+	data, err := rag.ExportGraph(ctx, docID)
+	if err != nil {
+		return fmt.Errorf("failed to export graph: %w", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if output != "" {
+		file, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(data); err != nil {
+		return fmt.Errorf("failed to write graph: %w", err)
+	}
+	if output != "" {
+		fmt.Fprintf(os.Stderr, "导出 %d 个实体、%d 条关系到 %s\n", len(data.Entities), len(data.Relationships), output)
+	}
+	return nil
+}
+
+func runGraphImport(ctx context.Context, args []string) error {
+	var f commonFlags
+	flags := flag.NewFlagSet("graph import", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "用法: sqlite-ai graph import [参数] <文件|->")
+		flags.PrintDefaults()
+	}
+	f.register(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected one input file")
+	}
+	f.setupLogging()
+
+	var r io.Reader = os.Stdin
+	if name := flags.Arg(0); name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+	var data lightrag.GraphData
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return fmt.Errorf("failed to decode graph: %w", err)
+	}
+
+	cfg, err := loadConfig(f.configFile)
+	if err != nil {
+		return err
+	}
+	rag, err := openRAG(ctx, cfg, ragOptions{workingDir: f.workingDir, noLLM: true})
+	if err != nil {
+		return err
+	}
+	defer rag.FinalizeStorages(context.Background())
+
+	if err := rag.ImportGraph(ctx, &data); err != nil {
+		return err
+	}
+	fmt.Printf("导入 %d 个实体、%d 条关系\n", len(data.Entities), len(data.Relationships))
+	return nil
+}
+
+// runGraphLog 输出图谱提取记录，用于排查某些实体或关系为什么（没有）被提取
+func runGraphLog(ctx context.Context, args []string) error {
+	var f commonFlags
+	var docID string
+	var limit int
+	var asJSON bool
+	flags := flag.NewFlagSet("graph log", flag.ContinueOnError)
+	f.register(flags)
+	flags.StringVar(&docID, "doc", "", "只输出该文档的记录")
+	flags.IntVar(&limit, "limit", 20, "输出的记录数，最新的在前")
+	flags.BoolVar(&asJSON, "json", false, "以 JSON 输出")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	f.setupLogging()
+
+	cfg, err := loadConfig(f.configFile)
+	if err != nil {
+		return err
+	}
+	rag, err := openRAG(ctx, cfg, ragOptions{workingDir: f.workingDir, noLLM: true})
+	if err != nil {
+		return err
+	}
+	defer rag.FinalizeStorages(context.Background())
+
+	entries, err := rag.GetExtractionLogs(ctx, docID, limit)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(os.Stderr, "没有提取记录，导入时需要指定 -log-extraction")
+		return nil
+	}
+	for _, e := range entries {
+		fmt.Printf("== %s  文档 %s  %d 个实体、%d 条关系  %dms\n", e.CreatedAt.Format("2006-01-02 15:04:05"), e.DocID, e.Entities, e.Relationships, e.DurationMs)
+		if e.Error != "" {
+			fmt.Printf("错误: %s\n", e.Error)
+		}
+		fmt.Printf("-- 提示词\n%s\n-- 响应\n%s\n\n", e.Prompt, e.Response)
+	}
+	return nil
+}
+
+// runGraphSummarize 合并描述数达到 -min 的实体的描述
+func runGraphSummarize(ctx context.Context, args []string) error {
+	var f commonFlags
+	var minDescriptions int
+	flags := flag.NewFlagSet("graph summarize", flag.ContinueOnError)
+	f.register(flags)
+	flags.IntVar(&minDescriptions, "min", 2, "只合并描述数不少于该值的实体")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	f.setupLogging()
+
+	cfg, err := loadConfig(f.configFile)
+	if err != nil {
+		return err
+	}
+	rag, err := openRAG(ctx, cfg, ragOptions{workingDir: f.workingDir})
+	if err != nil {
+		return err
+	}
+	defer rag.FinalizeStorages(context.Background())
+
+	n, err := rag.SummarizeEntityDescriptions(ctx, minDescriptions)
+	fmt.Printf("合并了 %d 个实体的描述；%s\n", n, formatUsage(rag.GetUsageStats().Total))
+	return err
+}
+
+// runGraphReextract 重新提取匹配文档的知识图谱，默认跳过已用当前提示词和模型提取过的文档
+func runGraphReextract(ctx context.Context, args []string) error {
+	var f commonFlags
+	var source, filter string
+	var force bool
+	var rps float64
+	var concurrency int
+	flags := flag.NewFlagSet("graph reextract", flag.ContinueOnError)
+	f.register(flags)
+	flags.StringVar(&source, "source", "", "只处理 source_file 等于该值的分块，如 docs/a.md")
+	flags.StringVar(&filter, "filter", "", `只处理元数据匹配的分块，JSON 格式的过滤器，如 {"category": "news"}`)
+	flags.BoolVar(&force, "force", false, "也处理已用当前提示词和模型提取过的分块")
+	flags.Float64Var(&rps, "rps", 0, "每秒最多发起的提取请求数，0 表示不限制")
+	flags.IntVar(&concurrency, "concurrency", 4, "同时提取的分块数")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	f.setupLogging()
+
+	filters := map[string]any{}
+	if filter != "" {
+		if err := json.Unmarshal([]byte(filter), &filters); err != nil {
+			return fmt.Errorf("invalid -filter: %w", err)
+		}
+	}
+	if source != "" {
+		filters["source_file"] = source
+	}
+
+	cfg, err := loadConfig(f.configFile)
+	if err != nil {
+		return err
+	}
+	rag, err := openRAG(ctx, cfg, ragOptions{workingDir: f.workingDir})
+	if err != nil {
+		return err
+	}
+	defer rag.FinalizeStorages(context.Background())
+
+	var bar *progressBar
+	result, err := rag.ReextractGraph(ctx, lightrag.ReextractOptions{
+		Filter:            filters,
+		Force:             force,
+		RequestsPerSecond: rps,
+		Concurrency:       concurrency,
+		Progress: func(done, total int, docID string, _ error) {
+			if bar == nil {
+				bar = newProgressBar(os.Stderr, total)
+			}
+			bar.Update(done, docID)
+		},
+	})
+	if bar != nil {
+		bar.Done()
+	}
+	if result != nil {
+		fmt.Printf("重新提取 %d 个分块，跳过 %d 个，失败 %d 个，清除 %d 个实体；%s\n",
+			result.Documents, result.Skipped, result.Failed, result.RemovedEntities, formatUsage(rag.GetUsageStats().Total))
+	}
+	return err
+}
+
+// runGraphUI 启动只读的知识图谱可视化界面，Ctrl-C 退出
+func runGraphUI(ctx context.Context, args []string) error {
+	var f commonFlags
+	var addr string
+	flags := flag.NewFlagSet("graph ui", flag.ContinueOnError)
+	f.register(flags)
+	flags.StringVar(&addr, "addr", "127.0.0.1:8090", "监听地址，界面没有认证，默认只监听本机")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	f.setupLogging()
+
+	cfg, err := loadConfig(f.configFile)
+	if err != nil {
+		return err
+	}
+	rag, err := openRAG(ctx, cfg, ragOptions{workingDir: f.workingDir, noLLM: true})
+	if err != nil {
+		return err
+	}
+	defer rag.FinalizeStorages(context.Background())
+
+	httpServer := &http.Server{Addr: addr, Handler: rag.GraphUIHandler(), ReadHeaderTimeout: 10 * time.Second}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.ListenAndServe()
+	}()
+	fmt.Fprintf(os.Stderr, "知识图谱界面: http://%s/（工作目录 %s）\n", addr, f.workingDir)
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("failed to serve: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return httpServer.Shutdown(shutdownCtx)
+}
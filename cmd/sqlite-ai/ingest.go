@@ -0,0 +1,367 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	docxparser "github.com/cloudwego/eino-ext/components/document/parser/docx"
+	"github.com/cloudwego/eino/components/document"
+	"github.com/cloudwego/eino/components/document/parser"
+	"github.com/cloudwego/eino/schema"
+	csvparser "github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/document/parser/csv"
+	pdfparser "github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/document/parser/pdf"
+	xlsxparser "github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/document/parser/xlsx"
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/document/transformer/splitter/tfidf"
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/lightrag"
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/sego"
+)
+
+// ingestFlags ingest 命令的参数
+type ingestFlags struct {
+	commonFlags
+	include       string
+	noGraph       bool
+	logExtraction bool
+	wait          time.Duration
+	maxChunk      int
+	minChunk      int
+	labels        string
+}
+
+func runIngest(ctx context.Context, args []string) error {
+	var f ingestFlags
+	flags := flag.NewFlagSet("ingest", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "用法: sqlite-ai ingest [参数] <文件|目录|glob>...")
+		fmt.Fprintf(flags.Output(), "支持的格式: %s\n", strings.Join(supportedExtensions(), " "))
+		flags.PrintDefaults()
+	}
+	f.register(flags)
+	flags.StringVar(&f.include, "include", "", "只导入目录中文件名匹配的文件，逗号分隔的 glob，如 *.md,*.pdf")
+	flags.BoolVar(&f.noGraph, "no-graph", false, "不调用 LLM 提取知识图谱")
+	flags.BoolVar(&f.logExtraction, "log-extraction", false, "保存图谱提取的提示词和响应，用 graph log 查看")
+	flags.DurationVar(&f.wait, "wait", 10*time.Minute, "导入后等待向量生成完成的最长时间，0 表示不等待")
+	flags.IntVar(&f.maxChunk, "max-chunk", 800, "分块的最大字符数")
+	flags.IntVar(&f.minChunk, "min-chunk", 500, "分块的最小字符数")
+	flags.StringVar(&f.labels, "labels", "", "文档的访问标签，逗号分隔，如 user:alice,group:eng；查询时用 -labels 限定")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("no input files")
+	}
+	f.setupLogging()
+
+	files, err := collectFiles(flags.Args(), splitList(f.include))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no supported files found")
+	}
+
+	cfg, err := loadConfig(f.configFile)
+	if err != nil {
+		return err
+	}
+	parsers, splitter, err := newPipeline(ctx, f.maxChunk, f.minChunk)
+	if err != nil {
+		return err
+	}
+
+	rag, err := openRAG(ctx, cfg, ragOptions{workingDir: f.workingDir, noLLM: f.noGraph, logExtraction: f.logExtraction})
+	if err != nil {
+		return err
+	}
+	defer rag.FinalizeStorages(context.Background())
+
+	bar := newProgressBar(os.Stderr, len(files))
+	var chunks int
+	var failed []string
+	for i, file := range files {
+		bar.Update(i, file)
+		n, err := ingestFile(ctx, rag, parsers, splitter, file, splitList(f.labels))
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", file, err))
+		}
+		chunks += n
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	bar.Update(len(files), "")
+	bar.Done()
+
+	fmt.Fprintln(os.Stderr, "等待知识图谱提取完成...")
+	rag.Wait()
+	if f.wait > 0 {
+		fmt.Fprintln(os.Stderr, "等待向量生成完成...")
+		if err := rag.WaitForEmbeddings(ctx, f.wait); err != nil {
+			return err
+		}
+	}
+
+	stats := rag.GetExtractionStats()
+	fmt.Printf("导入 %d 个文件，%d 个分块；知识图谱提取成功 %d 次、失败 %d 次，共 %d 个实体、%d 条关系\n",
+		len(files)-len(failed), chunks, stats.SuccessCount, stats.FailureCount, stats.TotalEntities, stats.TotalRelationships)
+	fmt.Println(formatUsage(rag.GetUsageStats().Ingestion))
+	if len(failed) > 0 {
+		for _, msg := range failed {
+			fmt.Fprintln(os.Stderr, "  "+msg)
+		}
+		return fmt.Errorf("%d files failed to ingest", len(failed))
+	}
+	return ctx.Err()
+}
+
+// ingestFile 解析、分块并导入一个文件，返回导入的分块数
+// 文档 ID 由文件路径生成，重复导入同一文件时覆盖之前的分块；labels 不为空时作为分块的访问标签
+func ingestFile(ctx context.Context, rag *lightrag.LightRAG, parsers map[string]parser.Parser, splitter document.Transformer, path string, labels []string) (int, error) {
+	if _, ok := parsers[strings.ToLower(filepath.Ext(path))]; !ok {
+		return 0, fmt.Errorf("unsupported file type")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	return ingestDocument(ctx, rag, parsers, splitter, path, file, labels)
+}
+
+// ingestDocument 解析、分块并导入 reader 中的文档，source 为文件路径或远程地址，
+// 用于选择解析器（按扩展名）、生成文档 ID 和设置 source_file
+func ingestDocument(ctx context.Context, rag *lightrag.LightRAG, parsers map[string]parser.Parser, splitter document.Transformer, source string, reader io.Reader, labels []string) (int, error) {
+	p, ok := parsers[strings.ToLower(filepath.Ext(source))]
+	if !ok {
+		return 0, fmt.Errorf("unsupported file type")
+	}
+	docs, err := p.Parse(ctx, reader, parser.WithURI(source), parser.WithExtraMeta(map[string]any{"source_file": source}))
+	if err != nil && len(docs) == 0 {
+		return 0, fmt.Errorf("failed to parse: %w", err)
+	}
+	for i, doc := range docs {
+		if len(docs) == 1 {
+			doc.ID = filepath.ToSlash(source)
+		} else {
+			doc.ID = fmt.Sprintf("%s#%d", filepath.ToSlash(source), i)
+		}
+	}
+
+	chunks, err := splitter.Transform(ctx, docs)
+	if err != nil {
+		return 0, fmt.Errorf("failed to split: %w", err)
+	}
+	documents := make([]map[string]any, 0, len(chunks))
+	for _, chunk := range chunks {
+		if strings.TrimSpace(chunk.Content) == "" {
+			continue
+		}
+		doc := chunkToDocument(chunk)
+		if len(labels) > 0 {
+			doc[lightrag.ACLLabelsField] = labels
+		}
+		documents = append(documents, doc)
+	}
+	if len(documents) == 0 {
+		return 0, nil
+	}
+	ids, err := rag.InsertBatch(ctx, documents)
+	if err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// chunkToDocument 转换为 LightRAG InsertBatch 的文档格式，元数据作为顶层字段
+func chunkToDocument(chunk *schema.Document) map[string]any {
+	doc := make(map[string]any, len(chunk.MetaData)+2)
+	for k, v := range chunk.MetaData {
+		doc[k] = v
+	}
+	doc["id"] = chunk.ID
+	doc["content"] = chunk.Content
+	return doc
+}
+
+// newPipeline 创建解析器和 TF-IDF 分块器，ingest 和 watch 共用
+func newPipeline(ctx context.Context, maxChunk, minChunk int) (map[string]parser.Parser, document.Transformer, error) {
+	if err := sego.Init(); err != nil {
+		fmt.Fprintf(os.Stderr, "警告: sego 词典加载失败，中文分块效果可能变差: %v\n", err)
+	}
+	parsers, err := newParsers(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	splitter, err := tfidf.NewTFIDFSplitter(ctx, &tfidf.Config{
+		SimilarityThreshold: 0.2,
+		MaxChunkSize:        maxChunk,
+		MinChunkSize:        minChunk,
+		UseSego:             true,
+		IDGenerator: func(ctx context.Context, originalID string, splitIndex int) string {
+			return fmt.Sprintf("%s_chunk_%d", originalID, splitIndex)
+		},
+		FilterGarbageChunks: true,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create splitter: %w", err)
+	}
+	return parsers, splitter, nil
+}
+
+// newParsers 按扩展名创建解析器
+func newParsers(ctx context.Context) (map[string]parser.Parser, error) {
+	pdfParser, err := pdfparser.NewPDFParser(ctx, &pdfparser.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pdf parser: %w", err)
+	}
+	docxParser, err := docxparser.NewDocxParser(ctx, &docxparser.Config{
+		IncludeHeaders: true,
+		IncludeFooters: true,
+		IncludeTables:  true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create docx parser: %w", err)
+	}
+	csvParser, err := csvparser.NewCsvParser(ctx, &csvparser.Config{Mode: csvparser.ModeTable})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create csv parser: %w", err)
+	}
+	xlsxParser, err := xlsxparser.NewXlsxParser(ctx, &xlsxparser.Config{Mode: xlsxparser.ModeTable})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create xlsx parser: %w", err)
+	}
+
+	textParser := parser.TextParser{}
+	return map[string]parser.Parser{
+		".txt":      textParser,
+		".text":     textParser,
+		".md":       textParser,
+		".markdown": textParser,
+		".pdf":      pdfParser,
+		".docx":     docxParser,
+		".csv":      csvParser,
+		".tsv":      csvParser,
+		".xlsx":     xlsxParser,
+	}, nil
+}
+
+// supportedExtensions 可以导入的文件扩展名
+func supportedExtensions() []string {
+	return []string{".txt", ".text", ".md", ".markdown", ".pdf", ".docx", ".csv", ".tsv", ".xlsx"}
+}
+
+func isSupported(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, supported := range supportedExtensions() {
+		if ext == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList 拆分逗号分隔的列表（glob、访问标签），去掉空项
+func splitList(s string) []string {
+	var patterns []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// matchesInclude 判断文件名是否匹配 include 中的某个 glob，include 为空时都匹配
+func matchesInclude(include []string, path string) bool {
+	if len(include) == 0 {
+		return true
+	}
+	for _, pattern := range include {
+		if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// collectFiles 展开参数中的文件、目录和 glob，返回去重排序后的可导入文件
+// 目录递归遍历（跳过隐藏目录），其中的文件需要是支持的格式并匹配 include；
+// glob 匹配到的不支持的文件会被跳过，直接指定的不支持的文件视为错误
+func collectFiles(args []string, include []string) ([]string, error) {
+	for _, pattern := range include {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid include pattern %q: %w", pattern, err)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var files []string
+	add := func(path string) {
+		path = filepath.Clean(path)
+		if !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+
+	for _, arg := range args {
+		paths := []string{arg}
+		glob := false
+		if _, err := os.Stat(arg); err != nil && strings.ContainsAny(arg, "*?[") {
+			matches, err := filepath.Glob(arg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", arg, err)
+			}
+			paths, glob = matches, true
+		}
+		for _, path := range paths {
+			// 与 shell 一致，* 等通配符不匹配隐藏文件和目录
+			if glob && strings.HasPrefix(filepath.Base(path), ".") && !strings.HasPrefix(filepath.Base(arg), ".") {
+				continue
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				return nil, err
+			}
+			if !info.IsDir() {
+				if !isSupported(path) {
+					if glob {
+						continue
+					}
+					return nil, fmt.Errorf("unsupported file type: %s", path)
+				}
+				add(path)
+				continue
+			}
+			err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if d.IsDir() {
+					if p != path && strings.HasPrefix(d.Name(), ".") {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				if isSupported(p) && matchesInclude(include, p) {
+					add(p)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
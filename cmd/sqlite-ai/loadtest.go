@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/lightrag"
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/lightrag/loadtest"
+)
+
+// loadtestFlags loadtest 命令的参数
+type loadtestFlags struct {
+	commonFlags
+	backend  string
+	cfg      loadtest.Config
+	mode     string
+	dims     int
+	analyzer string
+	metric   string
+	wait     time.Duration
+	json     bool
+	out      string
+	compare  string
+}
+
+func runLoadtest(ctx context.Context, args []string) error {
+	var f loadtestFlags
+	flags := flag.NewFlagSet("loadtest", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "用法: sqlite-ai loadtest [参数]")
+		fmt.Fprintln(flags.Output(), "生成合成文档，测量导入吞吐、embedding 队列排空时间、各模式查询的 P50/P99 和内存占用")
+		flags.PrintDefaults()
+	}
+	// 压测会写入大量合成文档，默认使用临时目录而不是 ./rag_storage
+	flags.StringVar(&f.workingDir, "dir", "", "工作目录，默认使用临时目录并在结束后删除")
+	flags.BoolVar(&f.verbose, "v", false, "输出详细日志")
+	flags.StringVar(&f.backend, "backend", "duckdb", "存储后端: duckdb|sqlite|all")
+	flags.IntVar(&f.cfg.Documents, "docs", 1000, "合成文档数")
+	flags.IntVar(&f.cfg.Words, "words", 200, "每个文档的词数")
+	flags.IntVar(&f.cfg.BatchSize, "batch", 100, "每批导入的文档数")
+	flags.IntVar(&f.cfg.Queries, "queries", 100, "每个检索模式的查询数")
+	flags.IntVar(&f.cfg.Concurrency, "concurrency", 1, "并发执行查询的数量")
+	flags.IntVar(&f.cfg.Limit, "limit", 10, "每次检索的结果数")
+	flags.Int64Var(&f.cfg.Seed, "seed", 1, "生成文档和查询的随机种子")
+	flags.StringVar(&f.mode, "mode", "all", "检索模式，all 为后端支持的所有模式")
+	flags.IntVar(&f.dims, "dims", 256, "合成向量的维度")
+	flags.StringVar(&f.analyzer, "analyzer", "", "DuckDB 后端的全文分词器: sego|simple|icu")
+	flags.StringVar(&f.metric, "metric", "", "DuckDB 后端的向量相似度: cosine|l2|dot")
+	flags.DurationVar(&f.wait, "wait", 10*time.Minute, "等待 embedding 队列排空的最长时间")
+	flags.BoolVar(&f.json, "json", false, "以 JSON 输出报告")
+	flags.StringVar(&f.out, "out", "", "把报告保存为 JSON 文件，多个后端时保存为数组")
+	flags.StringVar(&f.compare, "compare", "", "与之前保存的报告对比输出，逗号分隔多个文件")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+	backends, err := parseBackends(f.backend)
+	if err != nil {
+		return err
+	}
+	if f.mode != "all" {
+		modes, err := parseModes(f.mode)
+		if err != nil {
+			return err
+		}
+		f.cfg.Modes = modes
+	}
+	var previous []*loadtest.Report
+	if f.compare != "" {
+		for _, path := range strings.Split(f.compare, ",") {
+			reports, err := loadReports(strings.TrimSpace(path))
+			if err != nil {
+				return err
+			}
+			previous = append(previous, reports...)
+		}
+	}
+	f.setupLogging()
+
+	dir := f.workingDir
+	if dir == "" {
+		if dir, err = os.MkdirTemp("", "sqlite-ai-loadtest-"); err != nil {
+			return fmt.Errorf("failed to create temp dir: %w", err)
+		}
+		defer os.RemoveAll(dir)
+	}
+
+	var reports []*loadtest.Report
+	for _, backend := range backends {
+		fmt.Fprintf(os.Stderr, "压测 %s：%d 个文档，每个 %d 词...\n", backend, f.cfg.Documents, f.cfg.Words)
+		report, err := f.run(ctx, backend, filepath.Join(dir, backend))
+		if err != nil {
+			return fmt.Errorf("%s: %w", backend, err)
+		}
+		reports = append(reports, report)
+	}
+
+	if f.json {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(reports); err != nil {
+			return err
+		}
+	} else if err := loadtest.WriteComparison(os.Stdout, append(previous, reports...)...); err != nil {
+		return err
+	}
+	if f.out != "" {
+		if len(reports) == 1 {
+			return loadtest.SaveReport(f.out, reports[0])
+		}
+		data, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(f.out, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	}
+	return nil
+}
+
+// run 对一个后端执行压测，每个后端使用独立的目录
+func (f *loadtestFlags) run(ctx context.Context, backend, dir string) (*loadtest.Report, error) {
+	var target loadtest.Target
+	var err error
+	tuning := map[string]string{}
+	switch backend {
+	case "duckdb":
+		target, err = loadtest.NewLightRAGTarget(ctx, loadtest.LightRAGOptions{
+			WorkingDir:       dir,
+			Dims:             f.dims,
+			VectorMetric:     lightrag.VectorMetric(f.metric),
+			FulltextAnalyzer: lightrag.Analyzer(f.analyzer),
+			WaitTimeout:      f.wait,
+		})
+		if f.analyzer != "" {
+			tuning["analyzer"] = f.analyzer
+		}
+		if f.metric != "" {
+			tuning["metric"] = f.metric
+		}
+	case "sqlite":
+		target, err = loadtest.NewSQLiteTarget(ctx, dir, f.dims)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer target.Close()
+
+	tuning["batch"] = fmt.Sprint(f.cfg.BatchSize)
+	tuning["concurrency"] = fmt.Sprint(f.cfg.Concurrency)
+	report, err := loadtest.Run(ctx, target, f.cfg)
+	if err != nil {
+		return nil, err
+	}
+	report.Tuning = tuning
+	return report, nil
+}
+
+// parseBackends 解析 -backend
+func parseBackends(s string) ([]string, error) {
+	switch s = strings.ToLower(strings.TrimSpace(s)); s {
+	case "all":
+		return []string{"duckdb", "sqlite"}, nil
+	case "duckdb", "sqlite":
+		return []string{s}, nil
+	}
+	return nil, fmt.Errorf("unknown backend: %s", s)
+}
+
+// loadReports 读取 -out 保存的报告，单个报告或报告数组
+func loadReports(path string) ([]*loadtest.Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report: %w", err)
+	}
+	var reports []*loadtest.Report
+	if err := json.Unmarshal(data, &reports); err == nil {
+		return reports, nil
+	}
+	report, err := loadtest.LoadReport(path)
+	if err != nil {
+		return nil, err
+	}
+	return []*loadtest.Report{report}, nil
+}
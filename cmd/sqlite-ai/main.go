@@ -0,0 +1,114 @@
+// sqlite-ai 命令行工具：不写 Go 代码即可把文件导入 LightRAG 工作目录、执行查询、导出导入知识图谱、维护数据库和启动 HTTP 服务
+//
+//	sqlite-ai ingest [flags] <文件|目录|glob>...
+//	sqlite-ai watch [flags] <目录|s3://存储桶/前缀|davs://主机/路径>...
+//	sqlite-ai query [flags] <问题>
+//	sqlite-ai graph export|import [flags]
+//	sqlite-ai db inspect|vacuum|fts-rebuild|reembed|migrate [flags]
+//	sqlite-ai serve [flags]
+//	sqlite-ai bench [flags] <数据集.jsonl>
+//	sqlite-ai loadtest [flags]
+//
+// LLM 和 embedding 服务通过 pkg/config 配置（-config 或 CONFIG_FILE 指定的配置文件，环境变量优先）
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+)
+
+const usage = `sqlite-ai - LightRAG 命令行工具
+
+用法:
+  sqlite-ai <命令> [参数]
+
+命令:
+  ingest   导入文件或目录（支持 glob），自动按扩展名选择解析器
+  watch    定期扫描目录、S3 存储桶或 WebDAV 目录，把新增、修改和删除的文件同步到知识库
+  query    执行一次查询，-mode all 依次使用所有检索模式
+  graph    导出（graph export）或导入（graph import）知识图谱
+  db       查看和维护数据库：inspect、vacuum、fts-rebuild、reembed、migrate
+  serve    启动 HTTP 服务
+  bench    在带标注的问答数据集上评估各检索模式的 recall@k 和 MRR
+  loadtest 用合成文档压测存储层，对比 DuckDB 和 SQLite 后端或不同配置
+
+使用 "sqlite-ai <命令> -h" 查看命令的参数
+`
+
+// command 子命令
+type command struct {
+	name string
+	run  func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{name: "ingest", run: runIngest},
+	{name: "watch", run: runWatch},
+	{name: "query", run: runQuery},
+	{name: "graph", run: runGraph},
+	{name: "db", run: runDB},
+	{name: "serve", run: runServe},
+	{name: "bench", run: runBench},
+	{name: "loadtest", run: runLoadtest},
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "--help" || os.Args[1] == "help" {
+		fmt.Fprint(os.Stderr, usage)
+		if len(os.Args) < 2 {
+			os.Exit(2)
+		}
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	name := os.Args[1]
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		if err := cmd.run(ctx, os.Args[2:]); err != nil {
+			if err == flag.ErrHelp {
+				return
+			}
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "未知命令: %s\n\n%s", name, usage)
+	os.Exit(2)
+}
+
+// commonFlags 各命令共用的参数
+type commonFlags struct {
+	workingDir string
+	configFile string
+	verbose    bool
+}
+
+// register 在 FlagSet 上注册共用参数
+func (f *commonFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.workingDir, "dir", "./rag_storage", "LightRAG 工作目录")
+	fs.StringVar(&f.configFile, "config", "", "配置文件（YAML、TOML 或 JSON），默认读取 CONFIG_FILE")
+	fs.BoolVar(&f.verbose, "v", false, "输出详细日志")
+}
+
+// setupLogging 默认只输出警告，避免 LightRAG 的日志打乱进度条和查询结果
+func (f *commonFlags) setupLogging() {
+	logrus.SetOutput(os.Stderr)
+	if f.verbose {
+		logrus.SetLevel(logrus.DebugLevel)
+	} else {
+		logrus.SetLevel(logrus.WarnLevel)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// progressWidth 进度条的字符宽度
+const progressWidth = 30
+
+// progressBar 在终端中原地刷新的进度条；输出不是终端时（如重定向到文件）每次更新输出一行
+type progressBar struct {
+	out      io.Writer
+	total    int
+	current  int
+	terminal bool
+}
+
+func newProgressBar(out *os.File, total int) *progressBar {
+	terminal := false
+	if info, err := out.Stat(); err == nil {
+		terminal = info.Mode()&os.ModeCharDevice != 0
+	}
+	return &progressBar{out: out, total: total, terminal: terminal}
+}
+
+// Update 将进度设为 current 并显示当前处理的内容
+func (p *progressBar) Update(current int, label string) {
+	p.current = current
+	line := p.render(label)
+	if p.terminal {
+		fmt.Fprintf(p.out, "\r\033[K%s", line)
+	} else {
+		fmt.Fprintln(p.out, line)
+	}
+}
+
+// Done 结束进度条，终端中换行保留最后的进度
+func (p *progressBar) Done() {
+	if p.terminal {
+		fmt.Fprintln(p.out)
+	}
+}
+
+// render 生成如 [=========>          ] 3/10 a.pdf 的进度行
+func (p *progressBar) render(label string) string {
+	filled := progressWidth
+	if p.total > 0 {
+		filled = p.current * progressWidth / p.total
+	}
+	bar := strings.Repeat("=", filled)
+	if filled < progressWidth {
+		bar += ">" + strings.Repeat(" ", progressWidth-filled-1)
+	}
+	return fmt.Sprintf("[%s] %d/%d %s", bar, p.current, p.total, truncateLabel(label, 40))
+}
+
+// truncateLabel 截断过长的文件名，保留结尾部分
+func truncateLabel(label string, max int) string {
+	if utf8.RuneCountInString(label) <= max {
+		return label
+	}
+	runes := []rune(label)
+	return "..." + string(runes[len(runes)-max+3:])
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/lightrag"
+)
+
+// allModes -mode all 时依次使用的检索模式
+var allModes = []lightrag.QueryMode{
+	lightrag.ModeHybrid,
+	lightrag.ModeVector,
+	lightrag.ModeFulltext,
+	lightrag.ModeGraph,
+	lightrag.ModeLocal,
+	lightrag.ModeGlobal,
+	lightrag.ModeNaive,
+	lightrag.ModeMix,
+}
+
+// queryFlags query 命令的参数
+type queryFlags struct {
+	commonFlags
+	mode      string
+	limit     int
+	threshold float64
+	retrieve  bool
+	noLLM     bool
+	json      bool
+	labels    string
+	neighbors int
+	hyde      bool
+	hydeFuse  bool
+}
+
+// queryOutput -json 输出的单个模式的结果
+type queryOutput struct {
+	Mode    lightrag.QueryMode      `json:"mode"`
+	Answer  string                  `json:"answer,omitempty"`
+	Results []lightrag.SearchResult `json:"results,omitempty"`
+	Error   string                  `json:"error,omitempty"`
+}
+
+func runQuery(ctx context.Context, args []string) error {
+	var f queryFlags
+	flags := flag.NewFlagSet("query", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "用法: sqlite-ai query [参数] <问题>")
+		flags.PrintDefaults()
+	}
+	f.register(flags)
+	flags.StringVar(&f.mode, "mode", string(lightrag.ModeHybrid), "检索模式: hybrid|vector|fulltext|graph|local|global|naive|mix|all")
+	flags.IntVar(&f.limit, "limit", 5, "返回的结果数")
+	flags.Float64Var(&f.threshold, "threshold", 0, "分数阈值")
+	flags.BoolVar(&f.retrieve, "retrieve", false, "只输出检索结果，不生成回答")
+	flags.BoolVar(&f.noLLM, "no-llm", false, "不调用 LLM，输出拼接的上下文")
+	flags.BoolVar(&f.json, "json", false, "以 JSON 输出")
+	flags.StringVar(&f.labels, "labels", "", "只检索没有访问标签或带有这些标签的文档，逗号分隔，如 user:alice,group:eng")
+	flags.IntVar(&f.neighbors, "neighbors", 0, "生成回答前把检索到的分块与前后各 N 个相邻分块拼接（最多 5）")
+	flags.BoolVar(&f.hyde, "hyde", false, "用 LLM 生成的假设回答的向量做向量检索（HyDE）")
+	flags.BoolVar(&f.hydeFuse, "hyde-fuse", false, "与 -hyde 一起使用，把假设回答的向量与查询的向量融合")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	question := strings.TrimSpace(strings.Join(flags.Args(), " "))
+	if question == "" {
+		flags.Usage()
+		return fmt.Errorf("no question")
+	}
+	modes, err := parseModes(f.mode)
+	if err != nil {
+		return err
+	}
+	f.setupLogging()
+
+	cfg, err := loadConfig(f.configFile)
+	if err != nil {
+		return err
+	}
+	// 只检索时不需要 LLM，但 local/global 等模式仍会在配置了 LLM 时用它提取关键词
+	rag, err := openRAG(ctx, cfg, ragOptions{workingDir: f.workingDir, noLLM: f.noLLM})
+	if err != nil {
+		return err
+	}
+	defer rag.FinalizeStorages(context.Background())
+
+	var outputs []queryOutput
+	var failed int
+	for _, mode := range modes {
+		param := lightrag.QueryParam{Mode: mode, Limit: f.limit, Threshold: f.threshold, AllowedLabels: splitList(f.labels), ExpandNeighbors: f.neighbors, UseHyDE: f.hyde, HyDEFuseQuery: f.hydeFuse}
+		out := queryOutput{Mode: mode}
+		if f.retrieve {
+			out.Results, err = rag.Retrieve(ctx, question, param)
+		} else {
+			out.Answer, err = rag.Query(ctx, question, param)
+		}
+		if err != nil {
+			// -mode all 时某个模式失败（如未配置 embedding 时的向量检索）不影响其他模式
+			out.Error = err.Error()
+			failed++
+		}
+		outputs = append(outputs, out)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+
+	if f.json {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		var v any = outputs
+		if len(outputs) == 1 {
+			v = outputs[0]
+		}
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+	} else {
+		for _, out := range outputs {
+			printQueryOutput(out, len(modes) > 1)
+		}
+	}
+
+	if failed == len(modes) {
+		return fmt.Errorf("query failed: %s", outputs[0].Error)
+	}
+	return nil
+}
+
+// parseModes 解析 -mode，all 展开为所有模式
+func parseModes(s string) ([]lightrag.QueryMode, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "all" {
+		return allModes, nil
+	}
+	for _, mode := range allModes {
+		if string(mode) == s {
+			return []lightrag.QueryMode{mode}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown mode: %s", s)
+}
+
+// printQueryOutput 以文本输出一个模式的结果，多个模式时先输出模式标题
+func printQueryOutput(out queryOutput, heading bool) {
+	if heading {
+		fmt.Printf("=== %s ===\n", out.Mode)
+	}
+	switch {
+	case out.Error != "":
+		fmt.Printf("错误: %s\n", out.Error)
+	case out.Results != nil:
+		for i, res := range out.Results {
+			fmt.Printf("[%d] %s (score %.4f, %s)\n", i+1, res.ID, res.Score, res.Source)
+			fmt.Println(strings.TrimSpace(res.Content))
+			for _, t := range res.RecalledTriples {
+				fmt.Printf("    %s -[%s]-> %s\n", t.Source, t.Relation, t.Target)
+			}
+		}
+	case out.Answer != "":
+		fmt.Println(strings.TrimSpace(out.Answer))
+	default:
+		fmt.Println("没有找到相关内容")
+	}
+	if heading {
+		fmt.Println()
+	}
+}
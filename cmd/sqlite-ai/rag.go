@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/config"
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/embedding"
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/lightrag"
+	"github.com/sirupsen/logrus"
+)
+
+// defaultDimension text-embedding-v4 的默认维度
+const defaultDimension = 1024
+
+// extractionLogMaxAge 图谱提取日志的保留时间
+const extractionLogMaxAge = 30 * 24 * time.Hour
+
+// summarizeDescriptionsAt 实体描述达到该数量时在提取后合并，见 graph summarize
+const summarizeDescriptionsAt = 5
+
+// defaultConfig 命令行工具的默认配置：与示例一致，使用 OpenAI 兼容接口的 text-embedding-v4 和 gpt-4o-mini
+func defaultConfig() config.Config {
+	return config.Config{
+		Server:    config.ServerConfig{Port: 45120},
+		Embedding: config.EmbeddingConfig{Provider: embedding.ProviderOpenAI},
+		LLM: config.LLMConfig{
+			BaseURL: "https://api.openai.com/v1",
+			Model:   "gpt-4o-mini",
+		},
+	}
+}
+
+// loadConfig 读取配置文件和环境变量
+func loadConfig(path string) (*config.Config, error) {
+	cfg, err := config.Load(path, defaultConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if cfg.Embedding.Provider == embedding.ProviderOpenAI && cfg.Embedding.Model == "" {
+		cfg.Embedding.Model = "text-embedding-v4"
+	}
+	return cfg, nil
+}
+
+// ragOptions 打开 LightRAG 的选项
+type ragOptions struct {
+	workingDir string
+	// noLLM 不使用 LLM：导入时不提取知识图谱，查询时只返回检索到的上下文
+	noLLM bool
+	// skipMigrations 打开时不自动执行结构升级
+	skipMigrations bool
+	// logExtraction 保存图谱提取的提示词和响应（屏蔽邮箱、手机号等，保留 30 天），用 graph log 查看
+	logExtraction bool
+	// queryCache 缓存的查询数，0 表示不缓存；文档或图谱有写入时失效
+	queryCache int
+}
+
+// openRAG 按配置创建 embedder 和 LLM 并初始化 LightRAG 存储，调用方负责 FinalizeStorages
+// embedding 服务未配置时只能使用全文和图谱检索；LLM 未配置密钥时等同于 noLLM
+func openRAG(ctx context.Context, cfg *config.Config, opts ragOptions) (*lightrag.LightRAG, error) {
+	var embedder lightrag.Embedder
+	provider, err := embedding.New(embedding.Config{
+		Provider:  cfg.Embedding.Provider,
+		APIKey:    cfg.Embedding.APIKey,
+		BaseURL:   cfg.Embedding.BaseURL,
+		Model:     cfg.Embedding.Model,
+		Dimension: cfg.Embedding.Dimension,
+	})
+	if err != nil {
+		logrus.WithError(err).Warn("Embedding is not configured, vector search is disabled")
+	} else {
+		dims := cfg.Embedding.Dimension
+		if dims <= 0 {
+			dims = defaultDimension
+		}
+		embedder = &embedderAdapter{provider: provider, dims: dims}
+	}
+
+	var llm lightrag.LLM
+	if !opts.noLLM && cfg.LLM.APIKey != "" {
+		llm = lightrag.NewOpenAILLM(&lightrag.OpenAIConfig{
+			APIKey:  cfg.LLM.APIKey,
+			BaseURL: cfg.LLM.BaseURL,
+			Model:   cfg.LLM.Model,
+		})
+	}
+
+	var extractionLog *lightrag.ExtractionLogConfig
+	if opts.logExtraction {
+		extractionLog = &lightrag.ExtractionLogConfig{Redact: lightrag.RedactPII, MaxAge: extractionLogMaxAge}
+	}
+
+	webhooks := make([]lightrag.WebhookConfig, 0, len(cfg.Webhooks))
+	for _, w := range cfg.Webhooks {
+		webhook := lightrag.WebhookConfig{URL: w.URL, Secret: w.Secret}
+		for _, event := range w.Events {
+			webhook.Events = append(webhook.Events, lightrag.EventType(event))
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	var queryCache *lightrag.QueryCacheConfig
+	if opts.queryCache > 0 {
+		queryCache = &lightrag.QueryCacheConfig{MaxEntries: opts.queryCache}
+	}
+
+	rag := lightrag.New(lightrag.Options{
+		WorkingDir:     opts.workingDir,
+		Embedder:       embedder,
+		LLM:            llm,
+		SkipMigrations: opts.skipMigrations,
+		VectorMetric:   lightrag.VectorMetric(cfg.Embedding.Metric),
+		ExtractionLog:  extractionLog,
+		QueryCache:     queryCache,
+		Webhooks:       webhooks,
+		// 同一实体出现在大量分块中时描述不断累积，及时合并
+		SummarizeDescriptionsAt: summarizeDescriptionsAt,
+		// 导入大量文档时服务偶尔限流或出错，重试后再放弃
+		CallPolicy: lightrag.DefaultCallPolicy(),
+		Pricing: &lightrag.Pricing{
+			PromptPerMillion:     cfg.LLM.PromptPrice,
+			CompletionPerMillion: cfg.LLM.CompletionPrice,
+			EmbeddingPerMillion:  cfg.Embedding.Price,
+		},
+	})
+	if err := rag.InitializeStorages(ctx); err != nil {
+		return nil, fmt.Errorf("failed to initialize storages: %w", err)
+	}
+	return rag, nil
+}
+
+// formatUsage 输出 token 用量，配置了价格时附带估算费用
+func formatUsage(u lightrag.TokenUsage) string {
+	s := fmt.Sprintf("模型调用 %d 次，LLM 输入 %d / 输出 %d token，embedding %d token", u.Calls, u.PromptTokens, u.CompletionTokens, u.EmbeddingTokens)
+	if u.Cost > 0 {
+		s += fmt.Sprintf("，估算费用 %.4f", u.Cost)
+	}
+	if u.EstimatedCalls > 0 {
+		s += fmt.Sprintf("（其中 %d 次调用的 token 数按文本长度估算）", u.EstimatedCalls)
+	}
+	return s
+}
+
+// embedderAdapter 将 embedding.Provider 适配为 lightrag.Embedder
+type embedderAdapter struct {
+	provider embedding.Provider
+	dims     int
+}
+
+func (e *embedderAdapter) Embed(ctx context.Context, text string) ([]float64, error) {
+	vectors, err := e.provider.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) == 0 {
+		return nil, fmt.Errorf("no embedding returned")
+	}
+	return vectors[0], nil
+}
+
+func (e *embedderAdapter) Dimensions() int {
+	return e.dims
+}
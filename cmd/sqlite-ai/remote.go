@@ -0,0 +1,492 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// remoteMaxSize 远程文档的最大字节数，更大的文档不导入
+const remoteMaxSize = 100 << 20
+
+// remoteTimeout 列出目录和下载文档的单次请求超时
+const remoteTimeout = 5 * time.Minute
+
+// remoteOptions 远程来源的参数，为空的项取环境变量
+type remoteOptions struct {
+	s3Endpoint string
+	s3Region   string
+	include    []string
+}
+
+// newRemoteSource 按地址创建远程来源：
+//
+//	s3://存储桶/前缀    S3 兼容的存储桶，凭证取 AWS_ACCESS_KEY_ID、AWS_SECRET_ACCESS_KEY、AWS_SESSION_TOKEN，未设置时匿名访问
+//	davs://主机/路径    WebDAV 目录（HTTPS），dav:// 使用 HTTP；用户名和密码写在地址中或取 WEBDAV_USERNAME、WEBDAV_PASSWORD
+func newRemoteSource(raw string, opts remoteOptions) (syncSource, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source %q: %w", raw, err)
+	}
+	client := &http.Client{Timeout: remoteTimeout}
+	switch u.Scheme {
+	case "s3":
+		return newS3Source(client, u, opts)
+	case "dav", "davs":
+		return newWebDAVSource(client, u, opts)
+	default:
+		return nil, fmt.Errorf("unsupported source %q, expected s3://, dav:// or davs://", raw)
+	}
+}
+
+// readRemote 读取响应体，超过 remoteMaxSize 时返回错误
+func readRemote(resp *http.Response) (io.ReadCloser, error) {
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, remoteStatusError(resp)
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, remoteMaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download: %w", err)
+	}
+	if len(content) > remoteMaxSize {
+		return nil, fmt.Errorf("document is larger than %d MB", remoteMaxSize>>20)
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+// remoteStatusError 把非预期的响应转换为错误，附带响应体的开头（通常是服务端的错误信息）
+func remoteStatusError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("%s %s returned status %d: %s", resp.Request.Method, resp.Request.URL.Redacted(), resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// s3Source S3 兼容的存储桶中前缀下的对象，以 ETag 作为版本；使用路径风格的地址（endpoint/bucket/key），MinIO 等服务同样适用
+type s3Source struct {
+	client   *http.Client
+	endpoint *url.URL
+	region   string
+	bucket   string
+	prefix   string
+	include  []string
+	creds    s3Credentials
+}
+
+// s3Credentials AWS 访问密钥，AccessKey 为空时不签名请求
+type s3Credentials struct {
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+}
+
+func newS3Source(client *http.Client, u *url.URL, opts remoteOptions) (*s3Source, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("missing bucket in %q", u.String())
+	}
+	region := firstNonEmpty(opts.s3Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), "us-east-1")
+	endpoint := firstNonEmpty(opts.s3Endpoint, os.Getenv("AWS_ENDPOINT_URL_S3"), os.Getenv("AWS_ENDPOINT_URL"), "https://s3."+region+".amazonaws.com")
+	ep, err := url.Parse(endpoint)
+	if err != nil || ep.Host == "" || (ep.Scheme != "http" && ep.Scheme != "https") {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", endpoint)
+	}
+	return &s3Source{
+		client:   client,
+		endpoint: ep,
+		region:   region,
+		bucket:   u.Host,
+		prefix:   strings.TrimPrefix(u.Path, "/"),
+		include:  opts.include,
+		creds: s3Credentials{
+			AccessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		},
+	}, nil
+}
+
+func (s *s3Source) String() string {
+	return "s3://" + s.bucket + "/" + s.prefix
+}
+
+// listObjectsResult ListObjectsV2 的响应
+type listObjectsResult struct {
+	Contents []struct {
+		Key  string `xml:"Key"`
+		ETag string `xml:"ETag"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (s *s3Source) list(ctx context.Context) (map[string]sourceEntry, error) {
+	entries := make(map[string]sourceEntry)
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {s.prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := s.do(ctx, "", query)
+		if err != nil {
+			return nil, err
+		}
+		var result listObjectsResult
+		if resp.StatusCode != http.StatusOK {
+			err = remoteStatusError(resp)
+		} else if err = xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+			err = fmt.Errorf("failed to decode object list: %w", err)
+		}
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range result.Contents {
+			if strings.HasSuffix(object.Key, "/") || !isSupported(object.Key) || !matchesInclude(s.include, object.Key) {
+				continue
+			}
+			entries[s.name(object.Key)] = sourceEntry{Version: strings.Trim(object.ETag, `"`)}
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return entries, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func (s *s3Source) open(ctx context.Context, name string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, strings.TrimPrefix(name, "s3://"+s.bucket+"/"), nil)
+	if err != nil {
+		return nil, err
+	}
+	return readRemote(resp)
+}
+
+func (s *s3Source) owns(name string) bool {
+	return strings.HasPrefix(name, s.String())
+}
+
+// name 对象的 source_file
+func (s *s3Source) name(key string) string {
+	return "s3://" + s.bucket + "/" + key
+}
+
+// do 发送 GET 请求，key 为空时请求存储桶本身
+func (s *s3Source) do(ctx context.Context, key string, query url.Values) (*http.Response, error) {
+	u := *s.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.bucket
+	if key != "" {
+		u.Path += "/" + key
+	}
+	// 自己编码路径和参数，保证发送的与签名的一致
+	u.RawPath = awsEscape(u.Path, false)
+	u.RawQuery = canonicalQuery(query)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if s.creds.AccessKey != "" {
+		signV4(req, s.creds, s.region, "s3", time.Now())
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request %s: %w", s.bucket, err)
+	}
+	return resp, nil
+}
+
+// emptyPayloadHash 空请求体的 SHA256
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// signV4 用 AWS Signature Version 4 签名没有请求体的请求，签名 host、x-amz-content-sha256、x-amz-date（和 x-amz-security-token）
+func signV4(req *http.Request, creds s3Credentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalURI := req.URL.EscapedPath()
+	if canonicalURI == "" {
+		canonicalURI = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		emptyPayloadHash,
+	}, "\n")
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretKey), date)
+	for _, part := range []string{region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery 按参数名排序并按 AWS 的规则编码查询参数
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, awsEscape(k, true)+"="+awsEscape(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// awsEscape 按 AWS 的规则编码：只保留字母、数字和 -_.~，encodeSlash 为 false 时保留 /
+func awsEscape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// webdavSource WebDAV 目录（递归），以 ETag 作为版本，服务端不返回 ETag 时使用修改时间和大小
+type webdavSource struct {
+	client   *http.Client
+	base     *url.URL // 目录的 HTTP 地址，不含用户名和密码
+	scheme   string   // dav 或 davs，用于 source_file
+	username string
+	password string
+	include  []string
+	files    map[string]string // source_file -> 下载地址
+}
+
+func newWebDAVSource(client *http.Client, u *url.URL, opts remoteOptions) (*webdavSource, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("missing host in %q", u.Redacted())
+	}
+	s := &webdavSource{
+		client:   client,
+		scheme:   u.Scheme,
+		username: os.Getenv("WEBDAV_USERNAME"),
+		password: os.Getenv("WEBDAV_PASSWORD"),
+		include:  opts.include,
+		files:    make(map[string]string),
+	}
+	if u.User != nil {
+		s.username = u.User.Username()
+		s.password, _ = u.User.Password()
+	}
+	base := *u
+	base.User = nil
+	base.Scheme = "https"
+	if u.Scheme == "dav" {
+		base.Scheme = "http"
+	}
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+		base.RawPath = ""
+	}
+	s.base = &base
+	return s, nil
+}
+
+func (s *webdavSource) String() string {
+	return s.name(s.base)
+}
+
+// name 文件的 source_file，使用解码后的路径，便于阅读
+func (s *webdavSource) name(u *url.URL) string {
+	return s.scheme + "://" + u.Host + u.Path
+}
+
+// davMultistatus PROPFIND 的响应
+type davMultistatus struct {
+	Responses []struct {
+		Href     string `xml:"DAV: href"`
+		Propstat []struct {
+			Status string `xml:"DAV: status"`
+			Prop   struct {
+				ResourceType struct {
+					Collection *struct{} `xml:"DAV: collection"`
+				} `xml:"DAV: resourcetype"`
+				ETag          string `xml:"DAV: getetag"`
+				LastModified  string `xml:"DAV: getlastmodified"`
+				ContentLength string `xml:"DAV: getcontentlength"`
+			} `xml:"DAV: prop"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:"><d:prop><d:resourcetype/><d:getetag/><d:getlastmodified/><d:getcontentlength/></d:prop></d:propfind>`
+
+// list 逐层 PROPFIND（Depth: 1，很多服务端禁用了 infinity），跳过隐藏目录
+func (s *webdavSource) list(ctx context.Context) (map[string]sourceEntry, error) {
+	entries := make(map[string]sourceEntry)
+	files := make(map[string]string)
+	visited := map[string]bool{s.base.Path: true}
+	queue := []*url.URL{s.base}
+	for len(queue) > 0 {
+		dir := queue[0]
+		queue = queue[1:]
+		result, err := s.propfind(ctx, dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, response := range result.Responses {
+			ref, err := url.Parse(response.Href)
+			if err != nil {
+				continue
+			}
+			u := dir.ResolveReference(ref)
+			// 只处理目录下的文件，忽略指向其他地址的 href
+			if u.Host != s.base.Host || !strings.HasPrefix(u.Path, s.base.Path) || strings.TrimSuffix(u.Path, "/") == strings.TrimSuffix(dir.Path, "/") {
+				continue
+			}
+			for _, propstat := range response.Propstat {
+				if !strings.Contains(propstat.Status, " 200") {
+					continue
+				}
+				prop := propstat.Prop
+				if prop.ResourceType.Collection != nil {
+					if !strings.HasSuffix(u.Path, "/") {
+						u.Path += "/"
+						u.RawPath = ""
+					}
+					if !visited[u.Path] && !strings.HasPrefix(path.Base(u.Path), ".") {
+						visited[u.Path] = true
+						queue = append(queue, u)
+					}
+					break
+				}
+				if !isSupported(u.Path) || !matchesInclude(s.include, u.Path) {
+					break
+				}
+				version := strings.Trim(strings.TrimPrefix(prop.ETag, "W/"), `"`)
+				if version == "" {
+					version = prop.LastModified + "-" + prop.ContentLength
+				}
+				name := s.name(u)
+				entries[name] = sourceEntry{Version: version}
+				files[name] = u.String()
+				break
+			}
+		}
+	}
+	s.files = files
+	return entries, nil
+}
+
+func (s *webdavSource) propfind(ctx context.Context, dir *url.URL) (*davMultistatus, error) {
+	req, err := http.NewRequestWithContext(ctx, "PROPFIND", dir.String(), strings.NewReader(propfindBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Depth", "1")
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, remoteStatusError(resp)
+	}
+	var result davMultistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode PROPFIND response: %w", err)
+	}
+	return &result, nil
+}
+
+func (s *webdavSource) open(ctx context.Context, name string) (io.ReadCloser, error) {
+	target, ok := s.files[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown file")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return readRemote(resp)
+}
+
+func (s *webdavSource) owns(name string) bool {
+	return strings.HasPrefix(name, s.String())
+}
+
+func (s *webdavSource) do(req *http.Request) (*http.Response, error) {
+	if s.username != "" || s.password != "" {
+		req.SetBasicAuth(s.username, s.password)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request %s: %w", s.base.Host, err)
+	}
+	return resp, nil
+}
+
+// firstNonEmpty 返回第一个不为空的值
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,536 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/config"
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/lightrag"
+	"github.com/sirupsen/logrus"
+)
+
+// maxRequestBody 请求体的默认上限
+const maxRequestBody = 10 << 20
+
+// server serve 命令的 HTTP 服务，直接暴露一个 LightRAG 工作目录
+type server struct {
+	rag *lightrag.LightRAG
+	cfg *config.Config
+}
+
+func runServe(ctx context.Context, args []string) error {
+	var f commonFlags
+	var addr string
+	var logExtraction bool
+	var queryCache int
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	f.register(flags)
+	flags.StringVar(&addr, "addr", "", "监听地址，默认使用配置的 server.port")
+	flags.BoolVar(&logExtraction, "log-extraction", false, "保存图谱提取的提示词和响应")
+	flags.IntVar(&queryCache, "query-cache", 0, "缓存的查询数，相同的查询直接返回上次的结果，0 表示不缓存")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	f.setupLogging()
+
+	cfg, err := loadConfig(f.configFile)
+	if err != nil {
+		return err
+	}
+	if addr == "" {
+		addr = ":" + strconv.Itoa(cfg.Server.Port)
+	}
+	rag, err := openRAG(ctx, cfg, ragOptions{workingDir: f.workingDir, logExtraction: logExtraction, queryCache: queryCache})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		rag.Wait()
+		rag.FinalizeStorages(context.Background())
+	}()
+
+	s := &server{rag: rag, cfg: cfg}
+	httpServer := &http.Server{Addr: addr, Handler: s.routes()}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.ListenAndServe()
+	}()
+	fmt.Fprintf(os.Stderr, "LightRAG 服务已启动: %s（工作目录 %s）\n", addr, f.workingDir)
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("failed to serve: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shutdown server: %w", err)
+	}
+	return nil
+}
+
+// routes 注册 API 路由
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", s.handleHealth)
+	mux.HandleFunc("POST /api/query", s.handleQuery)
+	mux.HandleFunc("POST /api/retrieve", s.handleRetrieve)
+	mux.HandleFunc("GET /api/search", s.handleSearch)
+	mux.HandleFunc("GET /api/documents", s.handleListDocuments)
+	mux.HandleFunc("POST /api/documents", s.handleAddDocuments)
+	mux.HandleFunc("DELETE /api/documents/{id}", s.handleDeleteDocument)
+	mux.HandleFunc("POST /api/documents/{id}/archive", s.handleArchiveDocument)
+	mux.HandleFunc("POST /api/documents/{id}/unarchive", s.handleUnarchiveDocument)
+	mux.HandleFunc("GET /api/documents/{id}/extractions", s.handleExtractionLogs)
+	mux.HandleFunc("GET /api/graph", s.handleExportGraph)
+	mux.HandleFunc("GET /api/graph/search", s.handleSearchGraph)
+	mux.HandleFunc("GET /api/graph/subgraph/{node}", s.handleSubgraph)
+	mux.HandleFunc("POST /api/graph/entities", s.handleAddEntity)
+	mux.HandleFunc("PUT /api/graph/entities/{name}/description", s.handleUpdateEntityDescription)
+	mux.HandleFunc("POST /api/graph/relationships", s.handleAddRelationship)
+	mux.HandleFunc("DELETE /api/graph/relationships", s.handleDeleteRelationship)
+	mux.HandleFunc("GET /api/usage", s.handleUsage)
+	mux.HandleFunc("GET /api/embeddings", s.handleEmbeddingStatus)
+	mux.HandleFunc("GET /api/synonyms", s.handleListSynonyms)
+	mux.HandleFunc("POST /api/synonyms", s.handleAddSynonyms)
+	mux.HandleFunc("DELETE /api/synonyms/{term}", s.handleRemoveSynonyms)
+	return s.cors(mux)
+}
+
+// cors 配置了 cors.allowed_origins 时只回显允许的来源，否则允许所有来源
+func (s *server) cors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(s.cfg.CORS.AllowedOrigins) == 0 {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else if origin := r.Header.Get("Origin"); origin != "" && s.originAllowed(origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Add("Vary", "Origin")
+		}
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *server) originAllowed(origin string) bool {
+	for _, allowed := range s.cfg.CORS.AllowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// queryRequest /api/query 和 /api/retrieve 的请求体
+type queryRequest struct {
+	Query           string             `json:"query"`
+	Mode            lightrag.QueryMode `json:"mode"`
+	Limit           int                `json:"limit"`
+	Threshold       float64            `json:"threshold"`
+	Filters         map[string]any     `json:"filters"`
+	ExpandSynonyms  bool               `json:"expand_synonyms"`
+	AllowedLabels   []string           `json:"allowed_labels"`
+	ExpandNeighbors int                `json:"expand_neighbors"`
+	UseHyDE         bool               `json:"use_hyde"`
+	HyDEFuseQuery   bool               `json:"hyde_fuse_query"`
+}
+
+func (req *queryRequest) param() lightrag.QueryParam {
+	mode := req.Mode
+	if mode == "" {
+		mode = lightrag.ModeHybrid
+	}
+	return lightrag.QueryParam{Mode: mode, Limit: req.Limit, Threshold: req.Threshold, Filters: req.Filters, ExpandSynonyms: req.ExpandSynonyms, AllowedLabels: req.AllowedLabels, ExpandNeighbors: req.ExpandNeighbors, UseHyDE: req.UseHyDE, HyDEFuseQuery: req.HyDEFuseQuery}
+}
+
+func (s *server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"status": "ok"})
+}
+
+func (s *server) handleQuery(w http.ResponseWriter, r *http.Request) {
+	var req queryRequest
+	if !decodeQuery(w, r, &req) {
+		return
+	}
+	ctx, tracker := lightrag.WithUsageTracker(r.Context())
+	answer, err := s.rag.Query(ctx, req.Query, req.param())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to query: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"answer": answer, "usage": tracker.Usage()})
+}
+
+func (s *server) handleRetrieve(w http.ResponseWriter, r *http.Request) {
+	var req queryRequest
+	if !decodeQuery(w, r, &req) {
+		return
+	}
+	results, err := s.rag.Retrieve(r.Context(), req.Query, req.param())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to retrieve: %v", err))
+		return
+	}
+	if results == nil {
+		results = []lightrag.SearchResult{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"results": results})
+}
+
+// handleSearch 全文搜索文档，q 支持短语、AND / OR / NOT、前缀和字段限定；syntax=false 时按普通关键词搜索，
+// synonyms=true 时用同义词扩展查询
+func (s *server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		writeError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	results, err := s.rag.SearchFulltext(r.Context(), q, lightrag.FulltextSearchOptions{
+		Limit:          queryInt(r, "limit", 10),
+		Syntax:         r.URL.Query().Get("syntax") != "false",
+		ExpandSynonyms: r.URL.Query().Get("synonyms") == "true",
+	})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Failed to search: %v", err))
+		return
+	}
+	docs := make([]map[string]any, 0, len(results))
+	for _, result := range results {
+		doc := map[string]any{"score": result.Score}
+		for k, v := range result.Document.Data() {
+			doc[k] = v
+		}
+		docs = append(docs, doc)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"results": docs})
+}
+
+// handleListDocuments 分页列出文档，fields 为逗号分隔的返回字段，filter 为 key:value 形式的元数据过滤（可重复），
+// states 为逗号分隔的文档状态（active、archived、deleted），默认只列出 active 的文档
+func (s *server) handleListDocuments(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	opts := lightrag.ListDocumentsOptions{
+		Limit:         queryInt(r, "limit", 100),
+		Offset:        queryInt(r, "offset", 0),
+		ContentPrefix: q.Get("prefix"),
+		OrderBy:       q.Get("order"),
+		Ascending:     q.Get("asc") == "true",
+	}
+	if fields := q.Get("fields"); fields != "" {
+		opts.Fields = strings.Split(fields, ",")
+	}
+	if states := q.Get("states"); states != "" {
+		for _, state := range strings.Split(states, ",") {
+			opts.States = append(opts.States, lightrag.DocumentState(state))
+		}
+	}
+	for _, f := range q["filter"] {
+		key, value, ok := strings.Cut(f, ":")
+		if !ok || key == "" {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid filter %q, expected key:value", f))
+			return
+		}
+		if opts.Filters == nil {
+			opts.Filters = make(map[string]any)
+		}
+		opts.Filters[key] = value
+	}
+	list, err := s.rag.ListDocumentsWithOptions(r.Context(), opts)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list documents: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// handleAddDocuments 导入文档，请求体为 {"documents": [{"id": "...", "content": "...", ...}]}
+func (s *server) handleAddDocuments(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Documents []map[string]any `json:"documents"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if len(req.Documents) == 0 {
+		writeError(w, http.StatusBadRequest, "documents is required")
+		return
+	}
+	for i, doc := range req.Documents {
+		if content, _ := doc["content"].(string); content == "" {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("documents[%d].content is required", i))
+			return
+		}
+	}
+	ids, err := s.rag.InsertBatch(r.Context(), req.Documents)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to insert documents: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ids": ids})
+}
+
+func (s *server) handleDeleteDocument(w http.ResponseWriter, r *http.Request) {
+	if err := s.rag.DeleteDocument(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete document: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"success": true})
+}
+
+// handleArchiveDocument 归档文档，归档后不参与检索，可以恢复
+func (s *server) handleArchiveDocument(w http.ResponseWriter, r *http.Request) {
+	n, err := s.rag.ArchiveDocuments(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to archive document: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"updated": n})
+}
+
+func (s *server) handleUnarchiveDocument(w http.ResponseWriter, r *http.Request) {
+	n, err := s.rag.UnarchiveDocuments(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to unarchive document: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"updated": n})
+}
+
+// handleExtractionLogs 文档的图谱提取记录，需要以 -log-extraction 导入
+func (s *server) handleExtractionLogs(w http.ResponseWriter, r *http.Request) {
+	entries, err := s.rag.GetExtractionLogs(r.Context(), r.PathValue("id"), queryInt(r, "limit", 20))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get extraction logs: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"extractions": entries})
+}
+
+func (s *server) handleExportGraph(w http.ResponseWriter, r *http.Request) {
+	data, err := s.rag.ExportGraph(r.Context(), r.URL.Query().Get("doc"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to export graph: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, data)
+}
+
+func (s *server) handleSearchGraph(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		writeError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	data, err := s.rag.SearchGraphWithDepth(r.Context(), q, queryInt(r, "depth", 1))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to search graph: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, data)
+}
+
+func (s *server) handleSubgraph(w http.ResponseWriter, r *http.Request) {
+	data, err := s.rag.GetSubgraph(r.Context(), r.PathValue("node"), queryInt(r, "depth", 1))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get subgraph: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, data)
+}
+
+// handleAddEntity 人工添加实体或替换实体的类型和描述，之后的自动提取不会覆盖
+func (s *server) handleAddEntity(w http.ResponseWriter, r *http.Request) {
+	var entity lightrag.Entity
+	if !decodeJSON(w, r, &entity) {
+		return
+	}
+	if entity.Name == "" || (entity.Type == "" && entity.Description == "") {
+		writeError(w, http.StatusBadRequest, "name and type or description are required")
+		return
+	}
+	if err := s.rag.AddEntity(r.Context(), entity); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to add entity: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, entity)
+}
+
+// handleUpdateEntityDescription 替换实体的所有描述
+func (s *server) handleUpdateEntityDescription(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Description string `json:"description"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Description == "" {
+		writeError(w, http.StatusBadRequest, "description is required")
+		return
+	}
+	if err := s.rag.UpdateEntityDescription(r.Context(), r.PathValue("name"), req.Description); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update entity description: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"name": r.PathValue("name"), "description": req.Description})
+}
+
+// handleAddRelationship 人工添加关系
+func (s *server) handleAddRelationship(w http.ResponseWriter, r *http.Request) {
+	var rel lightrag.Relationship
+	if !decodeJSON(w, r, &rel) {
+		return
+	}
+	if rel.Source == "" || rel.Target == "" || rel.Relation == "" {
+		writeError(w, http.StatusBadRequest, "source, relation and target are required")
+		return
+	}
+	if err := s.rag.AddRelationship(r.Context(), rel); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to add relationship: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, rel)
+}
+
+// handleDeleteRelationship 删除关系，source、relation 和 target 由查询参数指定，之后的自动提取不会重新添加
+func (s *server) handleDeleteRelationship(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	source, relation, target := q.Get("source"), q.Get("relation"), q.Get("target")
+	if source == "" || relation == "" || target == "" {
+		writeError(w, http.StatusBadRequest, "source, relation and target are required")
+		return
+	}
+	if err := s.rag.DeleteRelationship(r.Context(), source, relation, target); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete relationship: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"deleted": true})
+}
+
+// handleUsage 服务启动以来的模型调用用量；指定 doc 时返回该文档导入的用量
+func (s *server) handleUsage(w http.ResponseWriter, r *http.Request) {
+	if docID := r.URL.Query().Get("doc"); docID != "" {
+		usage, ok := s.rag.GetDocumentUsage(docID)
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Sprintf("no usage recorded for document %s", docID))
+			return
+		}
+		writeJSON(w, http.StatusOK, usage)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.rag.GetUsageStats())
+}
+
+// handleEmbeddingStatus 各状态的向量生成文档数和失败的文档
+func (s *server) handleEmbeddingStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := s.rag.GetEmbeddingStatus(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get embedding status: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, status)
+}
+
+// handleListSynonyms 列出同义词（词 -> 同义词）
+func (s *server) handleListSynonyms(w http.ResponseWriter, r *http.Request) {
+	synonyms, err := s.rag.ListSynonyms(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list synonyms: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"synonyms": synonyms})
+}
+
+// handleAddSynonyms 添加同义词，请求体为 {"term": "k8s", "synonyms": ["kubernetes"]}
+func (s *server) handleAddSynonyms(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Term     string   `json:"term"`
+		Synonyms []string `json:"synonyms"`
+	}
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+	if req.Term == "" || len(req.Synonyms) == 0 {
+		writeError(w, http.StatusBadRequest, "term and synonyms are required")
+		return
+	}
+	n, err := s.rag.AddSynonyms(r.Context(), req.Term, req.Synonyms...)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to add synonyms: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"added": n})
+}
+
+// handleRemoveSynonyms 删除词的同义词，synonym 参数（可重复）指定要删除的同义词，缺省时全部删除
+func (s *server) handleRemoveSynonyms(w http.ResponseWriter, r *http.Request) {
+	n, err := s.rag.RemoveSynonyms(r.Context(), r.PathValue("term"), r.URL.Query()["synonym"]...)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to remove synonyms: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"removed": n})
+}
+
+// decodeQuery 解析查询请求并检查 query 和 mode
+func decodeQuery(w http.ResponseWriter, r *http.Request, req *queryRequest) bool {
+	if !decodeJSON(w, r, req) {
+		return false
+	}
+	if req.Query == "" {
+		writeError(w, http.StatusBadRequest, "query is required")
+		return false
+	}
+	if req.Mode != "" {
+		if _, err := parseModes(string(req.Mode)); err != nil || req.Mode == "all" {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown mode: %s", req.Mode))
+			return false
+		}
+	}
+	return true
+}
+
+// decodeJSON 解析请求体，失败时返回 400
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody)).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return false
+	}
+	return true
+}
+
+// queryInt 读取整数查询参数，缺失或无效时返回默认值
+func queryInt(r *http.Request, name string, def int) int {
+	if v, err := strconv.Atoi(r.URL.Query().Get(name)); err == nil && v >= 0 {
+		return v
+	}
+	return def
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logrus.WithError(err).Warn("Failed to write response")
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]any{"error": msg})
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/document"
+	"github.com/cloudwego/eino/components/document/parser"
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/lightrag"
+)
+
+// watchStateFile 工作目录中记录已同步文档的状态文件，重启后据此找出停止期间修改和删除的文档
+const watchStateFile = "watch_state.json"
+
+// watchSettle 修改时间在这之内的文件可能还在写入，留到下一次扫描
+const watchSettle = 2 * time.Second
+
+// watchFlags watch 命令的参数
+type watchFlags struct {
+	commonFlags
+	include    string
+	interval   time.Duration
+	once       bool
+	noGraph    bool
+	labels     string
+	maxChunk   int
+	minChunk   int
+	s3Endpoint string
+	s3Region   string
+}
+
+// syncSource watch 同步的文档来源：本地目录、S3 兼容的存储桶或 WebDAV 目录
+type syncSource interface {
+	// String 提示信息中显示的来源
+	String() string
+	// list 列出来源中的文档，键为文档的 source_file
+	list(ctx context.Context) (map[string]sourceEntry, error)
+	// open 读取 list 返回的文档
+	open(ctx context.Context, name string) (io.ReadCloser, error)
+	// owns 判断上次同步的文档是否属于该来源，只删除属于该来源且已不存在的文档
+	owns(name string) bool
+}
+
+// sourceEntry 来源中的一个文档
+type sourceEntry struct {
+	// Version 文档的版本，变化时重新导入：本地文件为大小和修改时间，远程对象为 ETag
+	Version string
+	// Pending 文档可能还在写入，留到下一次扫描
+	Pending bool
+}
+
+// watchState 已同步的文档（source_file -> 同步时的版本）
+type watchState struct {
+	Files map[string]string `json:"files"`
+}
+
+// watcher 把目录和远程存储同步到知识库：导入新文档，重新导入修改过的文档，删除已删除文档的分块
+type watcher struct {
+	rag       *lightrag.LightRAG
+	parsers   map[string]parser.Parser
+	splitter  document.Transformer
+	sources   []syncSource
+	labels    []string
+	state     *watchState
+	statePath string
+}
+
+func runWatch(ctx context.Context, args []string) error {
+	var f watchFlags
+	flags := flag.NewFlagSet("watch", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "用法: sqlite-ai watch [参数] <目录|s3://存储桶/前缀|davs://主机/路径>...")
+		fmt.Fprintf(flags.Output(), "支持的格式: %s\n", strings.Join(supportedExtensions(), " "))
+		flags.PrintDefaults()
+	}
+	f.register(flags)
+	flags.StringVar(&f.include, "include", "", "只同步文件名匹配的文件，逗号分隔的 glob，如 *.md,*.pdf")
+	flags.DurationVar(&f.interval, "interval", 30*time.Second, "扫描的间隔")
+	flags.BoolVar(&f.once, "once", false, "只同步一次后退出，适合由 cron 等定时执行")
+	flags.BoolVar(&f.noGraph, "no-graph", false, "不调用 LLM 提取知识图谱")
+	flags.StringVar(&f.labels, "labels", "", "文档的访问标签，逗号分隔，如 user:alice,group:eng")
+	flags.IntVar(&f.maxChunk, "max-chunk", 800, "分块的最大字符数")
+	flags.IntVar(&f.minChunk, "min-chunk", 500, "分块的最小字符数")
+	flags.StringVar(&f.s3Endpoint, "s3-endpoint", "", "S3 兼容服务的地址，如 http://localhost:9000，默认取 AWS_ENDPOINT_URL_S3、AWS_ENDPOINT_URL 或 AWS 的地址")
+	flags.StringVar(&f.s3Region, "s3-region", "", "S3 的区域，默认取 AWS_REGION 或 us-east-1")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("no sources")
+	}
+	if f.interval <= 0 {
+		return fmt.Errorf("-interval must be positive")
+	}
+	f.setupLogging()
+
+	include := splitList(f.include)
+	// 提前检查 include 中的 glob
+	if _, err := collectFiles(nil, include); err != nil {
+		return err
+	}
+	// 使用绝对路径，从不同的目录启动时文档 ID 和状态保持一致
+	var roots []string
+	var sources []syncSource
+	for _, arg := range flags.Args() {
+		if strings.Contains(arg, "://") {
+			source, err := newRemoteSource(arg, remoteOptions{s3Endpoint: f.s3Endpoint, s3Region: f.s3Region, include: include})
+			if err != nil {
+				return err
+			}
+			sources = append(sources, source)
+			continue
+		}
+		root, err := filepath.Abs(arg)
+		if err != nil {
+			return err
+		}
+		info, err := os.Stat(root)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("not a directory: %s", arg)
+		}
+		roots = append(roots, root)
+	}
+	if len(roots) > 0 {
+		sources = append([]syncSource{&dirSource{roots: roots, include: include}}, sources...)
+	}
+
+	cfg, err := loadConfig(f.configFile)
+	if err != nil {
+		return err
+	}
+	parsers, splitter, err := newPipeline(ctx, f.maxChunk, f.minChunk)
+	if err != nil {
+		return err
+	}
+	rag, err := openRAG(ctx, cfg, ragOptions{workingDir: f.workingDir, noLLM: f.noGraph})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		rag.Wait()
+		rag.FinalizeStorages(context.Background())
+	}()
+
+	statePath := filepath.Join(f.workingDir, watchStateFile)
+	state, err := loadWatchState(statePath)
+	if err != nil {
+		return err
+	}
+	w := &watcher{
+		rag:       rag,
+		parsers:   parsers,
+		splitter:  splitter,
+		sources:   sources,
+		labels:    splitList(f.labels),
+		state:     state,
+		statePath: statePath,
+	}
+	if !f.once {
+		names := make([]string, len(sources))
+		for i, source := range sources {
+			names[i] = source.String()
+		}
+		fmt.Fprintf(os.Stderr, "开始同步 %s，每 %s 扫描一次，Ctrl+C 退出\n", strings.Join(names, " "), f.interval)
+	}
+	for {
+		if err := w.sync(ctx); err != nil {
+			if f.once {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "同步失败: %v\n", err)
+		}
+		if f.once {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(f.interval):
+		}
+	}
+}
+
+// sync 扫描一次所有来源并同步变化，单个来源或文档失败不影响其他的，失败的文档下次扫描时重试
+func (w *watcher) sync(ctx context.Context) error {
+	var changedCount, removedCount, chunks, deleted int
+	var errs []error
+	for _, source := range w.sources {
+		current, err := source.list(ctx)
+		if err != nil {
+			// 来源暂时不可读时不能当作文档都被删除
+			errs = append(errs, fmt.Errorf("%s: %w", source, err))
+			continue
+		}
+		changed, removed := diffWatchState(w.state.Files, current, source.owns)
+		for _, name := range removed {
+			ids, err := w.rag.DeleteDocumentsBySource(ctx, "source_file", name)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				continue
+			}
+			deleted += len(ids)
+			delete(w.state.Files, name)
+		}
+		for _, name := range changed {
+			if ctx.Err() != nil {
+				break
+			}
+			n, err := w.reindex(ctx, source, name)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				continue
+			}
+			chunks += n
+			w.state.Files[name] = current[name].Version
+		}
+		changedCount += len(changed)
+		removedCount += len(removed)
+	}
+	if changedCount == 0 && removedCount == 0 {
+		return errors.Join(errs...)
+	}
+	if err := saveWatchState(w.statePath, w.state); err != nil {
+		errs = append(errs, err)
+	}
+	fmt.Fprintf(os.Stderr, "%s 同步 %d 个新增或修改的文档（%d 个分块），删除 %d 个文档（%d 个分块）\n",
+		time.Now().Format("15:04:05"), changedCount, chunks, removedCount, deleted)
+	return errors.Join(errs...)
+}
+
+// reindex 删除文档旧的分块后重新导入，文档变短时多出的分块不会残留
+func (w *watcher) reindex(ctx context.Context, source syncSource, name string) (int, error) {
+	reader, err := source.open(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+	if _, err := w.rag.DeleteDocumentsBySource(ctx, "source_file", name); err != nil {
+		return 0, err
+	}
+	return ingestDocument(ctx, w.rag, w.parsers, w.splitter, name, reader, w.labels)
+}
+
+// diffWatchState 比较上次同步的状态和来源中当前的文档，返回需要（重新）导入和需要删除的文档
+// 还在写入的文档留到下一次扫描；只删除 owns 的文档，同一个工作目录可以分别同步多个来源
+func diffWatchState(previous map[string]string, current map[string]sourceEntry, owns func(string) bool) (changed, removed []string) {
+	for name, entry := range current {
+		if entry.Pending {
+			continue
+		}
+		if version, ok := previous[name]; !ok || version != entry.Version {
+			changed = append(changed, name)
+		}
+	}
+	for name := range previous {
+		if _, ok := current[name]; !ok && owns(name) {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(changed)
+	sort.Strings(removed)
+	return changed, removed
+}
+
+// dirSource 本地目录，扫描规则与 ingest 相同
+type dirSource struct {
+	roots   []string
+	include []string
+}
+
+func (s *dirSource) String() string {
+	return strings.Join(s.roots, " ")
+}
+
+func (s *dirSource) list(ctx context.Context) (map[string]sourceEntry, error) {
+	files, err := collectFiles(s.roots, s.include)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	entries := make(map[string]sourceEntry, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		entries[file] = sourceEntry{
+			Version: fmt.Sprintf("%d-%d", info.Size(), info.ModTime().UnixNano()),
+			Pending: now.Sub(info.ModTime()) < watchSettle,
+		}
+	}
+	return entries, nil
+}
+
+func (s *dirSource) open(ctx context.Context, name string) (io.ReadCloser, error) {
+	return os.Open(name)
+}
+
+func (s *dirSource) owns(name string) bool {
+	return underRoots(name, s.roots)
+}
+
+// underRoots 判断 path 是否在某个 root 目录下
+func underRoots(path string, roots []string) bool {
+	for _, root := range roots {
+		rel, err := filepath.Rel(root, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func loadWatchState(path string) (*watchState, error) {
+	state := &watchState{Files: make(map[string]string)}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read watch state: %w", err)
+	}
+	if err := json.Unmarshal(content, state); err != nil {
+		return nil, fmt.Errorf("invalid watch state %s: %w", path, err)
+	}
+	if state.Files == nil {
+		state.Files = make(map[string]string)
+	}
+	return state, nil
+}
+
+// saveWatchState 先写临时文件再重命名，中断时不会留下不完整的状态
+func saveWatchState(path string, state *watchState) error {
+	content, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode watch state: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return fmt.Errorf("failed to write watch state: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write watch state: %w", err)
+	}
+	return nil
+}
@@ -12,6 +12,7 @@ import (
 	docxparser "github.com/cloudwego/eino-ext/components/document/parser/docx"
 	openaiembedding "github.com/cloudwego/eino-ext/components/embedding/openai"
 	"github.com/cloudwego/eino/schema"
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/config"
 	"github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/document/transformer/splitter/tfidf"
 	lightrag "github.com/mozhou-tech/sqlite-ai-driver/pkg/lightrag"
 	"github.com/mozhou-tech/sqlite-ai-driver/pkg/sego"
@@ -20,17 +21,19 @@ import (
 func main() {
 	ctx := context.Background()
 
-	// 1. 设置环境变量和配置
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
-		fmt.Println("请设置 OPENAI_API_KEY 环境变量")
-		return
+	// 1. 读取配置（CONFIG_FILE 指向的配置文件，OPENAI_* 环境变量优先）
+	cfg, err := config.Load("", config.Config{
+		LLM: config.LLMConfig{BaseURL: "https://api.openai.com/v1", Model: "qwen-flash"},
+	})
+	if err != nil {
+		log.Fatalf("读取配置失败: %v", err)
 	}
-	baseURL := os.Getenv("OPENAI_BASE_URL")
-	if baseURL == "" {
-		baseURL = "https://api.openai.com/v1"
+	if cfg.LLM.APIKey == "" {
+		fmt.Println("请设置 OPENAI_API_KEY 环境变量或配置文件中的 llm.api_key")
+		return
 	}
-	model := "qwen-flash" // "gpt-4o-mini"
+	apiKey, baseURL, model := cfg.LLM.APIKey, cfg.LLM.BaseURL, cfg.LLM.Model
+
 	// 初始化 sego 词典以获得更好的中文处理效果
 	if err := sego.Init(); err != nil {
 		log.Printf("警告：sego 初始化失败: %v", err)
@@ -14,6 +14,7 @@ import (
 	"github.com/cloudwego/eino/components/retriever"
 	"github.com/cloudwego/eino/compose"
 	"github.com/cloudwego/eino/schema"
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/config"
 	"github.com/mozhou-tech/sqlite-ai-driver/pkg/lightrag"
 	"github.com/mozhou-tech/sqlite-ai-driver/pkg/sego"
 )
@@ -80,20 +81,18 @@ func (i *LightRAGIndexer) IsCallbacksEnabled() bool { return false }
 func main() {
 	ctx := context.Background()
 
-	// 1. 设置环境变量和配置
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
-		fmt.Println("请设置 OPENAI_API_KEY 环境变量")
-		return
-	}
-	baseURL := os.Getenv("OPENAI_BASE_URL")
-	if baseURL == "" {
-		baseURL = "https://api.openai.com/v1"
+	// 1. 读取配置（CONFIG_FILE 指向的配置文件，OPENAI_* 环境变量优先）
+	cfg, err := config.Load("", config.Config{
+		LLM: config.LLMConfig{BaseURL: "https://api.openai.com/v1", Model: "gpt-4o-mini"},
+	})
+	if err != nil {
+		log.Fatalf("读取配置失败: %v", err)
 	}
-	model := os.Getenv("OPENAI_MODEL")
-	if model == "" {
-		model = "gpt-4o-mini"
+	if cfg.LLM.APIKey == "" {
+		fmt.Println("请设置 OPENAI_API_KEY 环境变量或配置文件中的 llm.api_key")
+		return
 	}
+	apiKey, baseURL, model := cfg.LLM.APIKey, cfg.LLM.BaseURL, cfg.LLM.Model
 
 	// 初始化 sego 词典以获得更好的中文处理效果
 	if err := sego.Init(); err != nil {
@@ -10,11 +10,14 @@ require (
 	github.com/cloudwego/eino-ext/components/embedding/openai v0.0.0-20251226123311-1d93d527c144
 	github.com/cloudwego/eino-ext/components/model/openai v0.1.2
 	github.com/mozhou-tech/sqlite-ai-driver/pkg/cayley-driver v0.0.0
+	github.com/mozhou-tech/sqlite-ai-driver/pkg/config v0.0.0
 	github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext v0.0.0-00010101000000-000000000000
 	github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/document/parser/pdf v0.0.0
+	github.com/mozhou-tech/sqlite-ai-driver/pkg/embedding v0.0.0
 	github.com/mozhou-tech/sqlite-ai-driver/pkg/lightrag v0.0.0-00010101000000-000000000000
 	github.com/mozhou-tech/sqlite-ai-driver/pkg/sego v0.0.0
 	github.com/mozhou-tech/sqlite-ai-driver/pkg/sqlite3-driver v0.0.0
+	github.com/sirupsen/logrus v1.9.3
 	gorm.io/driver/sqlite v1.6.0
 	gorm.io/gorm v1.31.1
 	modernc.org/sqlite v1.38.2
@@ -86,7 +89,6 @@ require (
 	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
 	github.com/rioloc/tfidf-go v0.0.0-20250724175239-3a8f9fe7e629 // indirect
 	github.com/rivo/uniseg v0.4.4 // indirect
-	github.com/sirupsen/logrus v1.9.3 // indirect
 	github.com/slongfield/pyfmt v0.0.0-20220222012616-ea85ff4c361f // indirect
 	github.com/superfly/ltx v0.5.1 // indirect
 	github.com/tetratelabs/wazero v1.2.1 // indirect
@@ -118,9 +120,11 @@ require (
 replace (
 	github.com/mozhou-tech/sqlite-ai-driver/pkg/attachments => ./pkg/attachments
 	github.com/mozhou-tech/sqlite-ai-driver/pkg/cayley-driver => ./pkg/cayley-driver
+	github.com/mozhou-tech/sqlite-ai-driver/pkg/config => ./pkg/config
 	github.com/mozhou-tech/sqlite-ai-driver/pkg/duckdb-driver => ./pkg/duckdb-driver
 	github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext => ./pkg/eino-ext
 	github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/document/parser/pdf => ./pkg/eino-ext/document/parser/pdf
+	github.com/mozhou-tech/sqlite-ai-driver/pkg/embedding => ./pkg/embedding
 	github.com/mozhou-tech/sqlite-ai-driver/pkg/lightrag => ./pkg/lightrag
 	github.com/mozhou-tech/sqlite-ai-driver/pkg/sego => ./pkg/sego
 	github.com/mozhou-tech/sqlite-ai-driver/pkg/sqlite3-driver => ./pkg/sqlite3-driver
@@ -0,0 +1,53 @@
+package browserclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// CreateBackup 启动后台备份任务，通过 GetJob 查询进度，完成后 Job.File 为备份文件名
+func (c *Client) CreateBackup(ctx context.Context) (*Job, error) {
+	var job Job
+	if err := c.do(ctx, http.MethodPost, "/admin/backup", nil, nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ListBackups 列出备份文件
+func (c *Client) ListBackups(ctx context.Context) ([]BackupInfo, error) {
+	var resp struct {
+		Backups []BackupInfo `json:"backups"`
+	}
+	err := c.do(ctx, http.MethodGet, "/admin/backups", nil, nil, &resp)
+	return resp.Backups, err
+}
+
+// DownloadBackup 下载备份文件；调用方负责关闭返回的 ReadCloser
+func (c *Client) DownloadBackup(ctx context.Context, file string) (io.ReadCloser, error) {
+	resp, err := c.request(ctx, http.MethodGet, "/admin/backups/"+escape(file), nil, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// RestoreBackup 从备份目录中已有的备份恢复数据，通过 GetJob 查询进度
+func (c *Client) RestoreBackup(ctx context.Context, backup string) (*Job, error) {
+	var job Job
+	if err := c.do(ctx, http.MethodPost, "/admin/restore", nil, map[string]string{"backup": backup}, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetDBInfo 获取数据库信息
+func (c *Client) GetDBInfo(ctx context.Context) (name, path string, err error) {
+	var resp struct {
+		Name string `json:"name"`
+		Path string `json:"path"`
+	}
+	err = c.do(ctx, http.MethodGet, "/db/info", nil, nil, &resp)
+	return resp.Name, resp.Path, err
+}
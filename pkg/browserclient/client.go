@@ -0,0 +1,173 @@
+// Package browserclient 是 browser API 的 Go 客户端，方法名与 /api/openapi.json 中的 operationId 一致
+package browserclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client browser API 客户端，可以在多个 goroutine 中共用
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// Option 客户端选项
+type Option func(*Client)
+
+// WithAPIKey 设置请求携带的 API Key（Authorization: Bearer）
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+// WithHTTPClient 使用自定义的 http.Client，如需设置超时或代理
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+// New 创建客户端，baseURL 为服务地址，如 http://localhost:40121
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(strings.TrimSuffix(baseURL, "/"), "/api"),
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// APIError 服务端返回的错误
+type APIError struct {
+	StatusCode int
+	Message    string
+	// RetryAfter 被限流（429）时服务端建议的等待时间
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("browser api: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// IsNotFound 判断错误是否为 404
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// request 发送请求并返回成功的响应，调用方负责关闭响应体
+// body 为 io.Reader 时原样发送，否则编码为 JSON
+func (c *Client) request(ctx context.Context, method, path string, query url.Values, body interface{}, contentType string) (*http.Response, error) {
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case io.Reader:
+		reader = b
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+		contentType = "application/json"
+	}
+
+	u := c.baseURL + "/api" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &errResp) == nil && errResp.Error != "" {
+			apiErr.Message = errResp.Error
+		} else {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			apiErr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return nil, apiErr
+	}
+	return resp, nil
+}
+
+// do 发送 JSON 请求并将响应解码到 out，out 为 nil 时丢弃响应
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	resp, err := c.request(ctx, method, path, query, body, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		_, err = io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	return decodeJSON(resp.Body, out)
+}
+
+// decodeJSON 解码 JSON 响应
+func decodeJSON(r io.Reader, out interface{}) error {
+	if err := json.NewDecoder(r).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// escape 转义路径段
+func escape(segment string) string {
+	return url.PathEscape(segment)
+}
+
+// collectionPath 返回集合下的路径
+func collectionPath(name string, parts ...string) string {
+	path := "/collections/" + escape(name)
+	for _, part := range parts {
+		path += "/" + part
+	}
+	return path
+}
+
+// listQuery 将分页参数转换为查询参数，零值不发送
+func listQuery(opts ListOptions) url.Values {
+	query := url.Values{}
+	if opts.Skip > 0 {
+		query.Set("skip", strconv.Itoa(opts.Skip))
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Tag != "" {
+		query.Set("tag", opts.Tag)
+	}
+	return query
+}
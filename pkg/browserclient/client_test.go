@@ -0,0 +1,120 @@
+package browserclient
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordedRequest 测试服务收到的请求
+type recordedRequest struct {
+	method, path, query, auth, contentType, body string
+}
+
+// newTestServer 启动一个记录请求并返回固定状态码和响应的服务
+func newTestServer(t *testing.T, status int, response string, got *recordedRequest) *Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		*got = recordedRequest{
+			method:      r.Method,
+			path:        r.URL.EscapedPath(),
+			query:       r.URL.RawQuery,
+			auth:        r.Header.Get("Authorization"),
+			contentType: r.Header.Get("Content-Type"),
+			body:        string(body),
+		}
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "3")
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	return New(server.URL+"/api/", WithAPIKey("secret"))
+}
+
+func TestClientRequests(t *testing.T) {
+	ctx := context.Background()
+	var got recordedRequest
+
+	c := newTestServer(t, http.StatusOK, `{"documents": [{"id": "a", "data": {"title": "x"}}], "total": 1, "skip": 10, "limit": 5}`, &got)
+	list, err := c.ListDocuments(ctx, "my docs", ListOptions{Skip: 10, Limit: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.method != "GET" || got.path != "/api/collections/my%20docs/documents" || got.query != "limit=5&skip=10" || got.auth != "Bearer secret" {
+		t.Errorf("unexpected request: %+v", got)
+	}
+	if list.Total != 1 || list.Documents[0].Data["title"] != "x" {
+		t.Errorf("unexpected response: %+v", list)
+	}
+
+	c = newTestServer(t, http.StatusOK, `{"results": [], "query": "q", "fusion": "rrf", "modes": ["keyword"], "took": 1}`, &got)
+	weight := 0.5
+	if _, err := c.HybridSearch(ctx, "docs", HybridSearchRequest{Query: "q", KeywordWeight: &weight}); err != nil {
+		t.Fatal(err)
+	}
+	var body map[string]interface{}
+	json.Unmarshal([]byte(got.body), &body)
+	if got.method != "POST" || got.path != "/api/collections/docs/search" || got.contentType != "application/json" || body["keyword_weight"] != 0.5 {
+		t.Errorf("unexpected request: %+v", got)
+	}
+
+	c = newTestServer(t, http.StatusCreated, `{"name": "a/b.png", "content_type": "image/png", "size": 3}`, &got)
+	attachment, err := c.UploadAttachment(ctx, "docs", "1", "a/b.png", "image/png", strings.NewReader("png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.method != "PUT" || got.path != "/api/collections/docs/documents/1/attachments/a%2Fb.png" || got.contentType != "image/png" || got.body != "png" {
+		t.Errorf("unexpected request: %+v", got)
+	}
+	if attachment.Size != 3 {
+		t.Errorf("unexpected response: %+v", attachment)
+	}
+
+	c = newTestServer(t, http.StatusOK, `{"inserted": 2, "skipped": 0, "failed": 0, "errors": []}`, &got)
+	result, err := c.ImportDocuments(ctx, "docs", strings.NewReader("{\"id\":\"a\"}\n{\"id\":\"b\"}\n"), "skip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.path != "/api/collections/docs/documents:batch" || got.query != "on_conflict=skip" || result.Inserted != 2 {
+		t.Errorf("unexpected request: %+v", got)
+	}
+
+	c = newTestServer(t, http.StatusOK, `{"message": "Document moved to trash"}`, &got)
+	if err := c.DeleteDocument(ctx, "docs", "a", true); err != nil {
+		t.Fatal(err)
+	}
+	if got.method != "DELETE" || got.query != "permanent=true" {
+		t.Errorf("unexpected request: %+v", got)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	ctx := context.Background()
+	var got recordedRequest
+
+	c := newTestServer(t, http.StatusNotFound, `{"error": "Document not found"}`, &got)
+	_, err := c.GetDocument(ctx, "docs", "missing")
+	if !IsNotFound(err) || !strings.Contains(err.Error(), "Document not found") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	c = newTestServer(t, http.StatusTooManyRequests, `{"error": "rate limit exceeded"}`, &got)
+	_, err = c.ListCollections(ctx)
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.StatusCode != http.StatusTooManyRequests || apiErr.RetryAfter != 3*time.Second {
+		t.Errorf("unexpected error: %#v", err)
+	}
+
+	c = newTestServer(t, http.StatusBadGateway, `upstream failed`, &got)
+	if _, err = c.ListCollections(ctx); err == nil || !strings.Contains(err.Error(), "upstream failed") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
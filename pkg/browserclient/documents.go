@@ -0,0 +1,244 @@
+package browserclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// GetOpenAPISpec 获取服务端的 OpenAPI 文档
+func (c *Client) GetOpenAPISpec(ctx context.Context) (map[string]interface{}, error) {
+	var spec map[string]interface{}
+	err := c.do(ctx, http.MethodGet, "/openapi.json", nil, nil, &spec)
+	return spec, err
+}
+
+// ListCollections 列出当前 API Key 可以访问的集合
+func (c *Client) ListCollections(ctx context.Context) ([]CollectionInfo, error) {
+	var resp struct {
+		Collections []CollectionInfo `json:"collections"`
+	}
+	err := c.do(ctx, http.MethodGet, "/collections", nil, nil, &resp)
+	return resp.Collections, err
+}
+
+// CreateCollection 创建集合
+func (c *Client) CreateCollection(ctx context.Context, req CreateCollectionRequest) (*CollectionInfo, error) {
+	var info CollectionInfo
+	if err := c.do(ctx, http.MethodPost, "/collections", nil, req, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// GetCollection 获取集合概况
+func (c *Client) GetCollection(ctx context.Context, name string) (*Collection, error) {
+	var collection Collection
+	if err := c.do(ctx, http.MethodGet, collectionPath(name), nil, nil, &collection); err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+// UpdateCollection 修改集合配置或重命名集合
+func (c *Client) UpdateCollection(ctx context.Context, name string, req UpdateCollectionRequest) (*CollectionInfo, error) {
+	var info CollectionInfo
+	if err := c.do(ctx, http.MethodPatch, collectionPath(name), nil, req, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// DeleteCollection 删除集合及其所有文档，返回删除的文档数
+func (c *Client) DeleteCollection(ctx context.Context, name string) (int64, error) {
+	var resp struct {
+		Deleted int64 `json:"deleted"`
+	}
+	err := c.do(ctx, http.MethodDelete, collectionPath(name), nil, nil, &resp)
+	return resp.Deleted, err
+}
+
+// GetCollectionStats 获取集合统计和索引状态
+func (c *Client) GetCollectionStats(ctx context.Context, name string) (*CollectionStats, error) {
+	var stats CollectionStats
+	if err := c.do(ctx, http.MethodGet, collectionPath(name, "stats"), nil, nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// ListDocuments 分页列出文档
+func (c *Client) ListDocuments(ctx context.Context, name string, opts ListOptions) (*DocumentList, error) {
+	var list DocumentList
+	if err := c.do(ctx, http.MethodGet, collectionPath(name, "documents"), listQuery(opts), nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// GetDocument 获取文档
+func (c *Client) GetDocument(ctx context.Context, name, id string) (*Document, error) {
+	var doc Document
+	if err := c.do(ctx, http.MethodGet, collectionPath(name, "documents", escape(id)), nil, nil, &doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// CreateDocument 创建文档，data 中的 id 为空时由服务端生成，embedding 可选
+func (c *Client) CreateDocument(ctx context.Context, name string, data map[string]interface{}) (*Document, error) {
+	var doc Document
+	if err := c.do(ctx, http.MethodPost, collectionPath(name, "documents"), nil, data, &doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// UpdateDocument 更新文档，updates 中的字段合并到现有文档
+func (c *Client) UpdateDocument(ctx context.Context, name, id string, updates map[string]interface{}) (*Document, error) {
+	var doc Document
+	if err := c.do(ctx, http.MethodPut, collectionPath(name, "documents", escape(id)), nil, updates, &doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// DeleteDocument 删除文档，permanent 为 false 时移入回收站
+func (c *Client) DeleteDocument(ctx context.Context, name, id string, permanent bool) error {
+	var query url.Values
+	if permanent {
+		query = url.Values{"permanent": {"true"}}
+	}
+	return c.do(ctx, http.MethodDelete, collectionPath(name, "documents", escape(id)), query, nil, nil)
+}
+
+// QueryDocuments 按 Mango 风格的 selector 查询文档
+func (c *Client) QueryDocuments(ctx context.Context, name string, req QueryRequest) (*DocumentList, error) {
+	if req.Selector == nil {
+		req.Selector = map[string]interface{}{}
+	}
+	var list DocumentList
+	if err := c.do(ctx, http.MethodPost, collectionPath(name, "query"), nil, req, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// ImportDocuments 以流式方式批量导入文档，r 为 NDJSON 或 JSON 数组
+// onConflict 为 error（默认）、skip 或 replace
+func (c *Client) ImportDocuments(ctx context.Context, name string, r io.Reader, onConflict string) (*BatchImportResponse, error) {
+	var query url.Values
+	if onConflict != "" {
+		query = url.Values{"on_conflict": {onConflict}}
+	}
+	resp, err := c.request(ctx, http.MethodPost, collectionPath(name, "documents:batch"), query, r, "application/x-ndjson")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var result BatchImportResponse
+	if err := decodeJSON(resp.Body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ExportDocuments 导出集合中的所有文档，format 为 ndjson（默认）或 parquet；调用方负责关闭返回的 ReadCloser
+func (c *Client) ExportDocuments(ctx context.Context, name, format string) (io.ReadCloser, error) {
+	var query url.Values
+	if format != "" {
+		query = url.Values{"format": {format}}
+	}
+	resp, err := c.request(ctx, http.MethodGet, collectionPath(name, "export"), query, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// ListAttachments 列出文档的附件
+func (c *Client) ListAttachments(ctx context.Context, name, id string) ([]Attachment, error) {
+	var resp struct {
+		Attachments []Attachment `json:"attachments"`
+	}
+	err := c.do(ctx, http.MethodGet, collectionPath(name, "documents", escape(id), "attachments"), nil, nil, &resp)
+	return resp.Attachments, err
+}
+
+// UploadAttachment 以流式方式上传附件，同名附件会被覆盖
+func (c *Client) UploadAttachment(ctx context.Context, name, id, attachment, contentType string, r io.Reader) (*Attachment, error) {
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	resp, err := c.request(ctx, http.MethodPut, collectionPath(name, "documents", escape(id), "attachments", escape(attachment)), nil, r, contentType)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var result Attachment
+	if err := decodeJSON(resp.Body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// DownloadAttachment 下载附件，返回内容和 Content-Type；调用方负责关闭返回的 ReadCloser
+func (c *Client) DownloadAttachment(ctx context.Context, name, id, attachment string) (io.ReadCloser, string, error) {
+	resp, err := c.request(ctx, http.MethodGet, collectionPath(name, "documents", escape(id), "attachments", escape(attachment)), nil, nil, "")
+	if err != nil {
+		return nil, "", err
+	}
+	return resp.Body, resp.Header.Get("Content-Type"), nil
+}
+
+// DeleteAttachment 删除附件
+func (c *Client) DeleteAttachment(ctx context.Context, name, id, attachment string) error {
+	return c.do(ctx, http.MethodDelete, collectionPath(name, "documents", escape(id), "attachments", escape(attachment)), nil, nil, nil)
+}
+
+// ListTrash 列出回收站中的文档
+func (c *Client) ListTrash(ctx context.Context, name string, opts ListOptions) (*DocumentList, error) {
+	var list DocumentList
+	if err := c.do(ctx, http.MethodGet, collectionPath(name, "trash"), listQuery(opts), nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// RestoreDocument 从回收站恢复文档
+func (c *Client) RestoreDocument(ctx context.Context, name, id string) error {
+	return c.do(ctx, http.MethodPost, collectionPath(name, "trash", escape(id), "restore"), nil, nil, nil)
+}
+
+// PurgeDocument 永久删除回收站中的文档
+func (c *Client) PurgeDocument(ctx context.Context, name, id string) error {
+	return c.do(ctx, http.MethodDelete, collectionPath(name, "trash", escape(id)), nil, nil, nil)
+}
+
+// EmptyTrash 清空集合的回收站，返回永久删除的文档数
+func (c *Client) EmptyTrash(ctx context.Context, name string) (int64, error) {
+	var resp struct {
+		Purged int64 `json:"purged"`
+	}
+	err := c.do(ctx, http.MethodDelete, collectionPath(name, "trash"), nil, nil, &resp)
+	return resp.Purged, err
+}
+
+// ReembedCollection 启动后台任务重新生成 embedding，通过 GetJob 查询进度
+func (c *Client) ReembedCollection(ctx context.Context, name string, req ReembedRequest) (*Job, error) {
+	var job Job
+	if err := c.do(ctx, http.MethodPost, collectionPath(name, "reembed"), nil, req, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetJob 查询后台任务状态
+func (c *Client) GetJob(ctx context.Context, id string) (*Job, error) {
+	var job Job
+	if err := c.do(ctx, http.MethodGet, "/jobs/"+escape(id), nil, nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
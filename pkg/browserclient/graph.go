@@ -0,0 +1,125 @@
+package browserclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// GraphLink 创建边
+func (c *Client) GraphLink(ctx context.Context, link GraphLink) error {
+	return c.do(ctx, http.MethodPost, "/graph/link", nil, link, nil)
+}
+
+// GraphUnlink 删除边
+func (c *Client) GraphUnlink(ctx context.Context, link GraphLink) error {
+	return c.do(ctx, http.MethodDelete, "/graph/link", nil, link, nil)
+}
+
+// GraphBulkLink 在一个事务中批量创建边
+func (c *Client) GraphBulkLink(ctx context.Context, links []GraphLink) error {
+	return c.do(ctx, http.MethodPost, "/graph/links/bulk", nil, map[string]interface{}{"links": links}, nil)
+}
+
+// GraphNeighbors 分页获取节点的邻居，relation 为空时返回所有类型的边
+func (c *Client) GraphNeighbors(ctx context.Context, nodeID, relation string, page GraphPageOptions) (*GraphNeighbors, error) {
+	query := pageQuery(page)
+	if relation != "" {
+		query.Set("relation", relation)
+	}
+	var resp GraphNeighbors
+	if err := c.do(ctx, http.MethodGet, "/graph/neighbors/"+escape(nodeID), query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GraphSubgraph 从节点出发按广度优先展开子图
+func (c *Client) GraphSubgraph(ctx context.Context, nodeID string, opts SubgraphOptions) (*Subgraph, error) {
+	query := url.Values{}
+	if opts.Depth > 0 {
+		query.Set("depth", strconv.Itoa(opts.Depth))
+	}
+	if opts.Direction != "" {
+		query.Set("direction", opts.Direction)
+	}
+	if opts.Relation != "" {
+		query.Set("relation", opts.Relation)
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	var resp Subgraph
+	if err := c.do(ctx, http.MethodGet, "/graph/subgraph/"+escape(nodeID), query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// PutGraphNode 创建或更新图节点
+func (c *Client) PutGraphNode(ctx context.Context, req GraphNodeRequest) (*GraphNode, error) {
+	var node GraphNode
+	if err := c.do(ctx, http.MethodPost, "/graph/nodes", nil, req, &node); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+// GetGraphNode 获取图节点及其出入度
+func (c *Client) GetGraphNode(ctx context.Context, id string) (*GraphNodeDetail, error) {
+	var node GraphNodeDetail
+	if err := c.do(ctx, http.MethodGet, "/graph/nodes/"+escape(id), nil, nil, &node); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+// DeleteGraphNode 删除图节点，cascade 为 true 时同时删除节点的边，返回删除的边数
+func (c *Client) DeleteGraphNode(ctx context.Context, id string, cascade bool) (int, error) {
+	var query url.Values
+	if cascade {
+		query = url.Values{"cascade": {"true"}}
+	}
+	var resp struct {
+		DeletedEdges int `json:"deleted_edges"`
+	}
+	err := c.do(ctx, http.MethodDelete, "/graph/nodes/"+escape(id), query, nil, &resp)
+	return resp.DeletedEdges, err
+}
+
+// GraphPath 查找两个节点之间的路径
+func (c *Client) GraphPath(ctx context.Context, req GraphPathRequest) ([][]string, error) {
+	var resp struct {
+		Paths [][]string `json:"paths"`
+	}
+	err := c.do(ctx, http.MethodPost, "/graph/path", nil, req, &resp)
+	return resp.Paths, err
+}
+
+// GraphQuery 执行图遍历查询
+func (c *Client) GraphQuery(ctx context.Context, req GraphQueryRequest) (*GraphQueryResponse, error) {
+	var resp GraphQueryResponse
+	if err := c.do(ctx, http.MethodPost, "/graph/query", nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// pageQuery 将图查询的分页参数转换为查询参数，零值不发送
+func pageQuery(page GraphPageOptions) url.Values {
+	query := url.Values{}
+	if page.Limit > 0 {
+		query.Set("limit", strconv.Itoa(page.Limit))
+	}
+	if page.Offset > 0 {
+		query.Set("offset", strconv.Itoa(page.Offset))
+	}
+	if page.Cursor != "" {
+		query.Set("cursor", page.Cursor)
+	}
+	if page.Order != "" {
+		query.Set("order", page.Order)
+	}
+	return query
+}
@@ -0,0 +1,52 @@
+package browserclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// FulltextSearch 全文搜索
+func (c *Client) FulltextSearch(ctx context.Context, name string, req FulltextSearchRequest) (*FulltextSearchResponse, error) {
+	var resp FulltextSearchResponse
+	if err := c.do(ctx, http.MethodPost, collectionPath(name, "fulltext", "search"), nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Analyze 预览文本的分词结果
+func (c *Client) Analyze(ctx context.Context, text string) (*AnalyzeResponse, error) {
+	var resp AnalyzeResponse
+	if err := c.do(ctx, http.MethodGet, "/analyze", url.Values{"text": {text}}, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// VectorSearch 向量搜索
+func (c *Client) VectorSearch(ctx context.Context, name string, req VectorSearchRequest) (*VectorSearchResponse, error) {
+	var resp VectorSearchResponse
+	if err := c.do(ctx, http.MethodPost, collectionPath(name, "vector", "search"), nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// VectorMultiSearch 批量向量搜索
+func (c *Client) VectorMultiSearch(ctx context.Context, name string, req VectorMultiSearchRequest) (*VectorMultiSearchResponse, error) {
+	var resp VectorMultiSearchResponse
+	if err := c.do(ctx, http.MethodPost, collectionPath(name, "vector", "msearch"), nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// HybridSearch 混合检索（关键词 + 向量）
+func (c *Client) HybridSearch(ctx context.Context, name string, req HybridSearchRequest) (*HybridSearchResponse, error) {
+	var resp HybridSearchResponse
+	if err := c.do(ctx, http.MethodPost, collectionPath(name, "search"), nil, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
@@ -0,0 +1,379 @@
+package browserclient
+
+import "time"
+
+// 以下类型与 browser/api 的请求和响应一一对应，字段说明见 browser/README.md 和 /api/openapi.json
+
+// CollectionSettings 集合配置
+type CollectionSettings struct {
+	EmbedFields     []string `json:"embed_fields,omitempty"`
+	FTSFields       []string `json:"fts_fields,omitempty"`
+	VectorDimension int      `json:"vector_dimension,omitempty"`
+}
+
+// CollectionInfo 集合信息
+type CollectionInfo struct {
+	Name     string                 `json:"name"`
+	Schema   map[string]interface{} `json:"schema"`
+	Settings *CollectionSettings    `json:"settings,omitempty"`
+}
+
+// Collection GetCollection 返回的集合概况
+type Collection struct {
+	Name       string             `json:"name"`
+	Exists     bool               `json:"exists"`
+	Count      int64              `json:"count"`
+	Registered bool               `json:"registered"`
+	Settings   CollectionSettings `json:"settings"`
+}
+
+// CreateCollectionRequest 创建集合请求
+type CreateCollectionRequest struct {
+	Name string `json:"name"`
+	CollectionSettings
+}
+
+// UpdateCollectionRequest 修改集合请求，为 nil 的字段保持不变
+type UpdateCollectionRequest struct {
+	Name            *string   `json:"name,omitempty"`
+	EmbedFields     *[]string `json:"embed_fields,omitempty"`
+	FTSFields       *[]string `json:"fts_fields,omitempty"`
+	VectorDimension *int      `json:"vector_dimension,omitempty"`
+}
+
+// CollectionStats 集合统计和索引状态
+type CollectionStats struct {
+	Name          string     `json:"name"`
+	Registered    bool       `json:"registered"`
+	DocumentCount int64      `json:"document_count"`
+	Trashed       int64      `json:"trashed"`
+	Embedded      int64      `json:"embedded"`
+	Unembedded    int64      `json:"unembedded"`
+	LastUpdatedAt *time.Time `json:"last_updated_at,omitempty"`
+	Storage       struct {
+		DataBytes      int64 `json:"data_bytes"`
+		ContentBytes   int64 `json:"content_bytes"`
+		EmbeddingBytes int64 `json:"embedding_bytes"`
+		TotalBytes     int64 `json:"total_bytes"`
+	} `json:"storage"`
+	FTS struct {
+		Present bool       `json:"present"`
+		Fresh   bool       `json:"fresh"`
+		Indexed int64      `json:"indexed"`
+		Missing int64      `json:"missing"`
+		Stale   int64      `json:"stale"`
+		BuiltAt *time.Time `json:"built_at,omitempty"`
+	} `json:"fts"`
+	VectorIndex struct {
+		Present    bool   `json:"present"`
+		Name       string `json:"name,omitempty"`
+		ColumnType string `json:"column_type,omitempty"`
+		Dimension  int    `json:"dimension,omitempty"`
+	} `json:"vector_index"`
+}
+
+// Document 文档
+type Document struct {
+	ID        string                 `json:"id"`
+	Data      map[string]interface{} `json:"data"`
+	Warnings  []string               `json:"warnings,omitempty"`
+	DeletedAt *time.Time             `json:"deleted_at,omitempty"`
+}
+
+// DocumentList 文档分页列表
+type DocumentList struct {
+	Documents []Document `json:"documents"`
+	Total     int64      `json:"total"`
+	Skip      int        `json:"skip"`
+	Limit     int        `json:"limit"`
+	Took      int64      `json:"took,omitempty"` // 只有 QueryDocuments 返回
+}
+
+// ListOptions 分页参数，零值使用服务端默认值
+type ListOptions struct {
+	Skip  int
+	Limit int
+	Tag   string // 只用于 ListDocuments
+}
+
+// QueryRequest Mango 风格的文档查询
+type QueryRequest struct {
+	Selector map[string]interface{} `json:"selector"`
+	Fields   []string               `json:"fields,omitempty"`
+	Sort     []interface{}          `json:"sort,omitempty"`
+	Limit    int                    `json:"limit,omitempty"`
+	Skip     int                    `json:"skip,omitempty"`
+}
+
+// BatchImportResponse 批量导入结果
+type BatchImportResponse struct {
+	Inserted int `json:"inserted"`
+	Skipped  int `json:"skipped"`
+	Failed   int `json:"failed"`
+	Errors   []struct {
+		Index int    `json:"index"`
+		ID    string `json:"id,omitempty"`
+		Error string `json:"error"`
+	} `json:"errors"`
+}
+
+// Attachment 附件元数据
+type Attachment struct {
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Job 后台任务状态
+type Job struct {
+	ID         string     `json:"id"`
+	Type       string     `json:"type"`
+	Collection string     `json:"collection"`
+	Status     string     `json:"status"`
+	Stage      string     `json:"stage,omitempty"`
+	File       string     `json:"file,omitempty"`
+	Processed  int        `json:"processed"`
+	Skipped    int        `json:"skipped"`
+	Failed     int        `json:"failed"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// ReembedRequest 重新生成 embedding 的请求
+type ReembedRequest struct {
+	OnlyMissing *bool `json:"only_missing,omitempty"`
+	BatchSize   int   `json:"batch_size,omitempty"`
+}
+
+// SearchResult 一条检索结果
+type SearchResult struct {
+	Document Document `json:"document"`
+	Score    float64  `json:"score"`
+}
+
+// FulltextSearchRequest 全文搜索请求
+type FulltextSearchRequest struct {
+	Query     string  `json:"query"`
+	Limit     int     `json:"limit,omitempty"`
+	Threshold float64 `json:"threshold,omitempty"`
+}
+
+// FulltextSearchResponse 全文搜索结果
+type FulltextSearchResponse struct {
+	Results []SearchResult `json:"results"`
+	Query   string         `json:"query"`
+	Took    int64          `json:"took"`
+}
+
+// AnalyzeResponse 分词预览结果
+type AnalyzeResponse struct {
+	Text          string   `json:"text"`
+	Normalized    string   `json:"normalized"`
+	Tokens        []string `json:"tokens"`
+	Stopwords     []string `json:"stopwords"`
+	IndexedTokens []string `json:"indexed_tokens"`
+	ContentTokens string   `json:"content_tokens"`
+}
+
+// VectorSearchRequest 向量搜索请求，Query 和 QueryText 二选一
+type VectorSearchRequest struct {
+	Query     []float64 `json:"query,omitempty"`
+	QueryText string    `json:"query_text,omitempty"`
+	Limit     int       `json:"limit,omitempty"`
+	Offset    int       `json:"offset,omitempty"`
+	Field     string    `json:"field,omitempty"`
+	Threshold float64   `json:"threshold,omitempty"`
+}
+
+// VectorSearchResponse 向量搜索结果
+type VectorSearchResponse struct {
+	Results []SearchResult `json:"results"`
+	Query   string         `json:"query"`
+	Offset  int            `json:"offset"`
+	Limit   int            `json:"limit"`
+	HasMore bool           `json:"has_more"`
+	Took    int64          `json:"took"`
+}
+
+// VectorSearchQuery 批量向量搜索中的一个查询
+type VectorSearchQuery struct {
+	Query     []float64 `json:"query,omitempty"`
+	QueryText string    `json:"query_text,omitempty"`
+}
+
+// VectorMultiSearchRequest 批量向量搜索请求
+type VectorMultiSearchRequest struct {
+	Queries   []VectorSearchQuery `json:"queries"`
+	Limit     int                 `json:"limit,omitempty"`
+	Threshold float64             `json:"threshold,omitempty"`
+}
+
+// VectorMultiSearchResponse 批量向量搜索结果，Responses 与请求中的查询一一对应
+type VectorMultiSearchResponse struct {
+	Responses []struct {
+		Index   int            `json:"index"`
+		Query   string         `json:"query"`
+		Results []SearchResult `json:"results"`
+		HasMore bool           `json:"has_more"`
+	} `json:"responses"`
+	Limit int   `json:"limit"`
+	Took  int64 `json:"took"`
+}
+
+// HybridSearchRequest 混合检索请求
+type HybridSearchRequest struct {
+	Query         string                 `json:"query,omitempty"`
+	Vector        []float64              `json:"vector,omitempty"`
+	Limit         int                    `json:"limit,omitempty"`
+	Fusion        string                 `json:"fusion,omitempty"`
+	RRFK          int                    `json:"rrf_k,omitempty"`
+	KeywordWeight *float64               `json:"keyword_weight,omitempty"`
+	VectorWeight  *float64               `json:"vector_weight,omitempty"`
+	Candidates    int                    `json:"candidates,omitempty"`
+	Filter        map[string]interface{} `json:"filter,omitempty"`
+}
+
+// HybridSearchResult 一条混合检索结果，只命中一路检索时另一路的分数和排名为空
+type HybridSearchResult struct {
+	Document     Document `json:"document"`
+	Score        float64  `json:"score"`
+	KeywordScore *float64 `json:"keyword_score,omitempty"`
+	KeywordRank  *int     `json:"keyword_rank,omitempty"`
+	VectorScore  *float64 `json:"vector_score,omitempty"`
+	VectorRank   *int     `json:"vector_rank,omitempty"`
+}
+
+// HybridSearchResponse 混合检索结果
+type HybridSearchResponse struct {
+	Results  []HybridSearchResult `json:"results"`
+	Query    string               `json:"query"`
+	Fusion   string               `json:"fusion"`
+	Modes    []string             `json:"modes"`
+	Warnings []string             `json:"warnings,omitempty"`
+	Took     int64                `json:"took"`
+}
+
+// GraphLink 图中的一条边
+type GraphLink struct {
+	From     string `json:"from"`
+	Relation string `json:"relation"`
+	To       string `json:"to"`
+}
+
+// GraphPageOptions 图查询的分页参数，Offset 和 Cursor 不能同时使用
+type GraphPageOptions struct {
+	Limit  int
+	Offset int
+	Cursor string
+	Order  string // asc 或 desc
+}
+
+// GraphNeighbors 节点的邻居
+type GraphNeighbors struct {
+	NodeID     string   `json:"node_id"`
+	Relation   string   `json:"relation"`
+	Neighbors  []string `json:"neighbors"`
+	Total      int      `json:"total"`
+	Limit      int      `json:"limit"`
+	Offset     int      `json:"offset"`
+	HasMore    bool     `json:"has_more"`
+	NextCursor string   `json:"next_cursor"`
+}
+
+// SubgraphOptions 子图展开参数，零值使用服务端默认值
+type SubgraphOptions struct {
+	Depth     int
+	Direction string // out、in 或 both
+	Relation  string
+	Limit     int
+}
+
+// GraphNode 图节点
+type GraphNode struct {
+	ID         string                 `json:"id"`
+	Label      string                 `json:"label,omitempty"`
+	Properties map[string]interface{} `json:"properties"`
+	Depth      int                    `json:"depth"`
+}
+
+// Subgraph 从节点出发展开的子图
+type Subgraph struct {
+	Root      string      `json:"root"`
+	Depth     int         `json:"depth"`
+	Nodes     []GraphNode `json:"nodes"`
+	Edges     []GraphLink `json:"edges"`
+	Truncated bool        `json:"truncated"`
+}
+
+// GraphNodeRequest 创建或更新图节点请求
+type GraphNodeRequest struct {
+	ID         string                 `json:"id"`
+	Label      string                 `json:"label,omitempty"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+// GraphNodeDetail 图节点及其出入度
+type GraphNodeDetail struct {
+	ID         string                 `json:"id"`
+	Label      string                 `json:"label"`
+	Properties map[string]interface{} `json:"properties"`
+	OutDegree  int                    `json:"out_degree"`
+	InDegree   int                    `json:"in_degree"`
+}
+
+// GraphPathRequest 图路径请求
+type GraphPathRequest struct {
+	From      string   `json:"from"`
+	To        string   `json:"to"`
+	MaxDepth  int      `json:"max_depth"`
+	Relations []string `json:"relations,omitempty"`
+}
+
+// GraphTraversal JSON 形式的图遍历
+type GraphTraversal struct {
+	Start []string `json:"start"`
+	Steps []struct {
+		Op        string `json:"op"`
+		Predicate string `json:"predicate,omitempty"`
+		Object    string `json:"object,omitempty"`
+		Limit     int    `json:"limit,omitempty"`
+	} `json:"steps"`
+}
+
+// GraphQueryRequest 图查询请求，Query 和 Traversal 二选一
+type GraphQueryRequest struct {
+	Query     string          `json:"query,omitempty"`
+	Traversal *GraphTraversal `json:"traversal,omitempty"`
+	Limit     int             `json:"limit,omitempty"`
+	Offset    int             `json:"offset,omitempty"`
+	Cursor    string          `json:"cursor,omitempty"`
+	Sort      string          `json:"sort,omitempty"`
+	Order     string          `json:"order,omitempty"`
+}
+
+// GraphQueryResponse 图查询结果
+type GraphQueryResponse struct {
+	Query     string          `json:"query"`
+	Traversal *GraphTraversal `json:"traversal"`
+	Nodes     []string        `json:"nodes"`
+	Results   []struct {
+		Subject   string `json:"subject"`
+		Predicate string `json:"predicate"`
+		Object    string `json:"object"`
+	} `json:"results"`
+	Total      int    `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor"`
+}
+
+// BackupInfo 备份文件信息
+type BackupInfo struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -3,7 +3,9 @@ package cayley_driver
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -19,6 +21,19 @@ type Triple struct {
 	Object    string
 }
 
+// ErrStopIteration 在 TriplesIter 的回调中返回该错误可提前结束遍历，TriplesIter 本身返回 nil
+var ErrStopIteration = errors.New("stop iteration")
+
+// TripleFilter 定义流式遍历三元组时的过滤条件，零值表示不过滤
+type TripleFilter struct {
+	// Predicates 只返回这些类型的边，为空则返回所有类型
+	Predicates []string
+	// Subject 只返回以该节点为 subject 的边，为空则不限制
+	Subject string
+	// Object 只返回以该节点为 object 的边，为空则不限制
+	Object string
+}
+
 // Graph 定义图数据库的接口
 type Graph interface {
 	// Link 创建一条从 subject 到 object 的边，边的类型为 predicate
@@ -46,10 +61,46 @@ type Graph interface {
 	// AllTriples 获取图中所有的三元组
 	AllTriples(ctx context.Context) ([]Triple, error)
 
+	// TriplesIter 以流式方式遍历满足 filter 的三元组，每读取一条调用一次 fn
+	// 结果不会整体加载到内存中，适合导出或扫描大规模图
+	// fn 返回 ErrStopIteration 时提前结束遍历，返回其他错误时遍历终止并返回该错误
+	TriplesIter(ctx context.Context, filter TripleFilter, fn func(Triple) error) error
+
+	// BeginTx 开启一个事务，事务内的 Link/Unlink 在 Commit 时原子生效，Rollback 则全部撤销
+	BeginTx(ctx context.Context) (GraphTx, error)
+
+	// ImportNQuads 从 N-Quads 格式导入三元组，返回导入的条数
+	ImportNQuads(ctx context.Context, r io.Reader) (int, error)
+
+	// ExportNQuads 以 N-Quads 格式导出所有三元组，返回导出的条数
+	ExportNQuads(ctx context.Context, w io.Writer) (int, error)
+
+	// ImportJSONLD 从 JSON-LD 文档导入三元组，返回导入的条数
+	ImportJSONLD(ctx context.Context, r io.Reader) (int, error)
+
+	// ExportJSONLD 以 JSON-LD 格式导出所有三元组，返回导出的条数
+	ExportJSONLD(ctx context.Context, w io.Writer) (int, error)
+
 	// Close 关闭图数据库连接
 	Close() error
 }
 
+// GraphTx 定义图数据库事务的接口
+// 同一事务不能并发使用；Commit 或 Rollback 之后事务即失效
+type GraphTx interface {
+	// Link 在事务内创建一条边
+	Link(ctx context.Context, subject, predicate, object string) error
+
+	// Unlink 在事务内删除一条边
+	Unlink(ctx context.Context, subject, predicate, object string) error
+
+	// Commit 提交事务
+	Commit() error
+
+	// Rollback 回滚事务，已提交或已回滚的事务再次调用返回 sql.ErrTxDone
+	Rollback() error
+}
+
 // GraphQuery 定义图查询构建器的接口
 type GraphQuery interface {
 	// V 选择指定的节点
@@ -176,9 +227,15 @@ func (g *cayleyGraph) initSchema(ctx context.Context) error {
 	CREATE INDEX IF NOT EXISTS idx_%s_object ON %s(object);
 	CREATE INDEX IF NOT EXISTS idx_%s_sp ON %s(subject, predicate);
 	CREATE INDEX IF NOT EXISTS idx_%s_po ON %s(predicate, object);
-	`, tableName, tableName, tableName, tableName, tableName, tableName, tableName, tableName, tableName, tableName, tableName)
+	CREATE INDEX IF NOT EXISTS idx_%s_ops ON %s(object, predicate, subject);
+	`, tableName, tableName, tableName, tableName, tableName, tableName, tableName, tableName, tableName, tableName, tableName, tableName, tableName)
+
+	if _, err := g.db.ExecContext(ctx, createTableSQL); err != nil {
+		return err
+	}
 
-	_, err := g.db.ExecContext(ctx, createTableSQL)
+	// 让查询规划器根据已有数据的分布选择索引（对大图上的高出入度节点尤为重要）
+	_, err := g.db.ExecContext(ctx, `PRAGMA optimize`)
 	return err
 }
 
@@ -280,6 +337,8 @@ func (g *cayleyGraph) GetNeighbors(ctx context.Context, node, predicate string)
 }
 
 // GetInNeighbors 获取指向指定节点的邻居节点（入边）
+// 查询由 (object, predicate, subject) 覆盖索引直接满足，无需回表，
+// 因此对 docID 这类拥有大量入边的节点（如 APPEARS_IN）在数百万条边的规模下依然高效
 func (g *cayleyGraph) GetInNeighbors(ctx context.Context, node, predicate string) ([]string, error) {
 	var rows *sql.Rows
 	var err error
@@ -330,6 +389,88 @@ func (g *cayleyGraph) AllTriples(ctx context.Context) ([]Triple, error) {
 	return triples, rows.Err()
 }
 
+// TriplesIter 以流式方式遍历满足过滤条件的三元组
+func (g *cayleyGraph) TriplesIter(ctx context.Context, filter TripleFilter, fn func(Triple) error) error {
+	if fn == nil {
+		return fmt.Errorf("iterator callback is nil")
+	}
+
+	var conditions []string
+	var args []interface{}
+	if filter.Subject != "" {
+		conditions = append(conditions, "subject = ?")
+		args = append(args, filter.Subject)
+	}
+	if filter.Object != "" {
+		conditions = append(conditions, "object = ?")
+		args = append(args, filter.Object)
+	}
+	if len(filter.Predicates) > 0 {
+		placeholders := make([]string, len(filter.Predicates))
+		for i, p := range filter.Predicates {
+			placeholders[i] = "?"
+			args = append(args, p)
+		}
+		conditions = append(conditions, fmt.Sprintf("predicate IN (%s)", strings.Join(placeholders, ", ")))
+	}
+
+	query := fmt.Sprintf(`SELECT subject, predicate, object FROM %s`, g.tableName())
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	// 按 id 排序保证遍历顺序稳定（与插入顺序一致）
+	query += " ORDER BY id"
+
+	rows, err := g.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t Triple
+		if err := rows.Scan(&t.Subject, &t.Predicate, &t.Object); err != nil {
+			return err
+		}
+		if err := fn(t); err != nil {
+			if errors.Is(err, ErrStopIteration) {
+				return nil
+			}
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// BeginTx 开启一个事务
+func (g *cayleyGraph) BeginTx(ctx context.Context) (GraphTx, error) {
+	// 重试逻辑：处理 SQLITE_BUSY 错误
+	maxRetries := 5
+	var err error
+	for i := 0; i < maxRetries; i++ {
+		var tx *sql.Tx
+		tx, err = g.db.BeginTx(ctx, nil)
+		if err == nil {
+			return &cayleyTx{tx: tx, tableName: g.tableName()}, nil
+		}
+
+		errStr := err.Error()
+		if strings.Contains(errStr, "database is locked") || strings.Contains(errStr, "SQLITE_BUSY") {
+			waitTime := time.Duration(i+1) * 10 * time.Millisecond
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(waitTime):
+				continue
+			}
+		}
+
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	return nil, fmt.Errorf("failed to begin transaction: %w", err)
+}
+
 // Query 返回查询构建器
 func (g *cayleyGraph) Query() GraphQuery {
 	return &graphQuery{graph: g}
@@ -396,9 +537,41 @@ func (g *cayleyGraph) FindPath(ctx context.Context, from, to string, maxDepth in
 
 // Close 关闭数据库连接
 func (g *cayleyGraph) Close() error {
+	// 关闭前更新统计信息，失败不影响关闭
+	_, _ = g.db.Exec(`PRAGMA optimize`)
 	return g.db.Close()
 }
 
+// cayleyTx 实现 GraphTx 接口
+type cayleyTx struct {
+	tx        *sql.Tx
+	tableName string
+}
+
+// Link 在事务内创建一条边
+func (t *cayleyTx) Link(ctx context.Context, subject, predicate, object string) error {
+	query := fmt.Sprintf(`INSERT OR IGNORE INTO %s (subject, predicate, object) VALUES (?, ?, ?)`, t.tableName)
+	_, err := t.tx.ExecContext(ctx, query, subject, predicate, object)
+	return err
+}
+
+// Unlink 在事务内删除一条边
+func (t *cayleyTx) Unlink(ctx context.Context, subject, predicate, object string) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE subject = ? AND predicate = ? AND object = ?`, t.tableName)
+	_, err := t.tx.ExecContext(ctx, query, subject, predicate, object)
+	return err
+}
+
+// Commit 提交事务
+func (t *cayleyTx) Commit() error {
+	return t.tx.Commit()
+}
+
+// Rollback 回滚事务
+func (t *cayleyTx) Rollback() error {
+	return t.tx.Rollback()
+}
+
 // graphQuery 实现 GraphQuery 接口
 type graphQuery struct {
 	graph     *cayleyGraph
@@ -476,7 +649,25 @@ func (q *graphQuery) All(ctx context.Context) ([]Triple, error) {
 		var triples []Triple
 
 		for _, node := range currentNodes {
-			if step.direction == "out" {
+			if (step.direction == "out" || step.direction == "in") && step.predicate == "" {
+				// 不限边类型时逐条读取三元组，结果中保留每条边真实的类型
+				filter := TripleFilter{Subject: node}
+				if step.direction == "in" {
+					filter = TripleFilter{Object: node}
+				}
+				err := q.graph.TriplesIter(ctx, filter, func(t Triple) error {
+					triples = append(triples, t)
+					if step.direction == "out" {
+						nextNodes = append(nextNodes, t.Object)
+					} else {
+						nextNodes = append(nextNodes, t.Subject)
+					}
+					return nil
+				})
+				if err != nil {
+					return nil, err
+				}
+			} else if step.direction == "out" {
 				neighbors, err := q.graph.GetNeighbors(ctx, node, step.predicate)
 				if err != nil {
 					return nil, err
@@ -2,8 +2,10 @@ package cayley_driver
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -203,4 +205,203 @@ func TestGraphQueryChain(t *testing.T) {
 	if results[0].Subject != "B" || results[0].Object != "C" {
 		t.Errorf("Expected {B next C}, got %v", results[0])
 	}
+
+	// 不限边类型时 All 返回真实的边类型
+	graph.Link(ctx, "B", "skip", "D")
+	results, err = query.V("B").Out("").All(ctx)
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	if len(results) != 2 || results[0].Predicate != "next" || results[1].Predicate != "skip" {
+		t.Errorf("Expected predicates [next skip], got %v", results)
+	}
+	results, err = query.V("D").In("").All(ctx)
+	if err != nil {
+		t.Fatalf("Failed to query: %v", err)
+	}
+	if len(results) != 2 || results[0].Subject != "C" || results[1].Predicate != "skip" {
+		t.Errorf("Expected {C next D} and {B skip D}, got %v", results)
+	}
+}
+
+func TestGraphTriplesIter(t *testing.T) {
+	workingDir := t.TempDir()
+
+	graph, err := NewGraphWithNamespace(workingDir, "graph_iter.db", "iter_")
+	if err != nil {
+		t.Fatalf("Failed to create graph: %v", err)
+	}
+	defer graph.Close()
+
+	ctx := context.Background()
+
+	graph.Link(ctx, "e1", "APPEARS_IN", "doc1")
+	graph.Link(ctx, "e2", "APPEARS_IN", "doc1")
+	graph.Link(ctx, "e1", "TYPE", "PERSON")
+	graph.Link(ctx, "e1", "KNOWS", "e2")
+
+	// 不过滤时遍历所有三元组
+	var all []Triple
+	if err := graph.TriplesIter(ctx, TripleFilter{}, func(tr Triple) error {
+		all = append(all, tr)
+		return nil
+	}); err != nil {
+		t.Fatalf("Failed to iterate triples: %v", err)
+	}
+	if len(all) != 4 {
+		t.Errorf("Expected 4 triples, got %d", len(all))
+	}
+
+	// 按 predicate 过滤
+	count := 0
+	if err := graph.TriplesIter(ctx, TripleFilter{Predicates: []string{"APPEARS_IN", "TYPE"}}, func(tr Triple) error {
+		if tr.Predicate != "APPEARS_IN" && tr.Predicate != "TYPE" {
+			t.Errorf("Unexpected predicate %s", tr.Predicate)
+		}
+		count++
+		return nil
+	}); err != nil {
+		t.Fatalf("Failed to iterate triples: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 triples, got %d", count)
+	}
+
+	// 按 object 过滤
+	var subjects []string
+	if err := graph.TriplesIter(ctx, TripleFilter{Predicates: []string{"APPEARS_IN"}, Object: "doc1"}, func(tr Triple) error {
+		subjects = append(subjects, tr.Subject)
+		return nil
+	}); err != nil {
+		t.Fatalf("Failed to iterate triples: %v", err)
+	}
+	if len(subjects) != 2 || subjects[0] != "e1" || subjects[1] != "e2" {
+		t.Errorf("Expected [e1 e2], got %v", subjects)
+	}
+
+	// ErrStopIteration 提前结束遍历
+	count = 0
+	if err := graph.TriplesIter(ctx, TripleFilter{}, func(tr Triple) error {
+		count++
+		return ErrStopIteration
+	}); err != nil {
+		t.Fatalf("Expected nil error on stop, got %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected iteration to stop after 1 triple, got %d", count)
+	}
+}
+
+func TestGraphTx(t *testing.T) {
+	workingDir := t.TempDir()
+
+	graph, err := NewGraphWithNamespace(workingDir, "graph_tx.db", "tx_")
+	if err != nil {
+		t.Fatalf("Failed to create graph: %v", err)
+	}
+	defer graph.Close()
+
+	ctx := context.Background()
+
+	// 回滚后事务内的修改不可见
+	tx, err := graph.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin tx: %v", err)
+	}
+	if err := tx.Link(ctx, "a", "rel", "b"); err != nil {
+		t.Fatalf("Failed to link in tx: %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Failed to rollback: %v", err)
+	}
+	neighbors, err := graph.GetNeighbors(ctx, "a", "rel")
+	if err != nil {
+		t.Fatalf("Failed to get neighbors: %v", err)
+	}
+	if len(neighbors) != 0 {
+		t.Errorf("Expected no neighbors after rollback, got %v", neighbors)
+	}
+
+	// 提交后事务内的修改全部生效
+	tx, err = graph.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin tx: %v", err)
+	}
+	if err := tx.Link(ctx, "a", "rel", "b"); err != nil {
+		t.Fatalf("Failed to link in tx: %v", err)
+	}
+	if err := tx.Link(ctx, "a", "rel", "c"); err != nil {
+		t.Fatalf("Failed to link in tx: %v", err)
+	}
+	if err := tx.Unlink(ctx, "a", "rel", "c"); err != nil {
+		t.Fatalf("Failed to unlink in tx: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	neighbors, err = graph.GetNeighbors(ctx, "a", "rel")
+	if err != nil {
+		t.Fatalf("Failed to get neighbors: %v", err)
+	}
+	if len(neighbors) != 1 || neighbors[0] != "b" {
+		t.Errorf("Expected [b] after commit, got %v", neighbors)
+	}
+
+	// 已提交的事务不能再回滚
+	if err := tx.Rollback(); err == nil {
+		t.Error("Expected error when rolling back a committed tx")
+	}
+}
+
+func TestGraphInNeighborsUsesCoveringIndex(t *testing.T) {
+	workingDir := t.TempDir()
+
+	graph, err := NewGraphWithNamespace(workingDir, "graph_reverse.db", "reverse_")
+	if err != nil {
+		t.Fatalf("Failed to create graph: %v", err)
+	}
+	defer graph.Close()
+
+	ctx := context.Background()
+	tx, err := graph.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("Failed to begin tx: %v", err)
+	}
+	for i := 0; i < 1000; i++ {
+		if err := tx.Link(ctx, fmt.Sprintf("entity%d", i), "APPEARS_IN", "doc1"); err != nil {
+			t.Fatalf("Failed to link: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+
+	subjects, err := graph.GetInNeighbors(ctx, "doc1", "APPEARS_IN")
+	if err != nil {
+		t.Fatalf("Failed to get in neighbors: %v", err)
+	}
+	if len(subjects) != 1000 {
+		t.Errorf("Expected 1000 in neighbors, got %d", len(subjects))
+	}
+
+	// 入边查询应命中 (object, predicate, subject) 覆盖索引
+	g := graph.(*cayleyGraph)
+	rows, err := g.db.QueryContext(ctx, `EXPLAIN QUERY PLAN SELECT DISTINCT subject FROM reverse_quads WHERE object = ? AND predicate = ?`, "doc1", "APPEARS_IN")
+	if err != nil {
+		t.Fatalf("Failed to explain query: %v", err)
+	}
+	defer rows.Close()
+
+	var plan []string
+	for rows.Next() {
+		var id, parent, notused int
+		var detail string
+		if err := rows.Scan(&id, &parent, &notused, &detail); err != nil {
+			t.Fatalf("Failed to scan plan: %v", err)
+		}
+		plan = append(plan, detail)
+	}
+	if !strings.Contains(strings.Join(plan, "\n"), "COVERING INDEX idx_reverse_quads_ops") {
+		t.Errorf("Expected covering index in query plan, got %v", plan)
+	}
 }
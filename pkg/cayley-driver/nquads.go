@@ -0,0 +1,396 @@
+package cayley_driver
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// 图中的节点和边类型都以普通字符串存储，不区分 IRI 与字面量。
+// 导出时：subject 和 predicate 始终写成 IRI；object 如果是合法的 IRI 字符序列则写成 IRI，
+// 否则（例如包含空格的描述文本）写成字符串字面量。导入时 IRI、字面量和空白节点都还原为字符串，
+// 因此 Export -> Import 的往返是无损的。
+
+// ImportNQuads 从 N-Quads（或 N-Triples）格式导入三元组，返回导入的条数
+// 第四列（graph label）、语言标签和数据类型会被忽略；整个导入在一个事务中完成，任一行解析失败则全部回滚
+func (g *cayleyGraph) ImportNQuads(ctx context.Context, r io.Reader) (int, error) {
+	tx, err := g.BeginTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	count := 0
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		t, err := parseNQuadLine(line)
+		if err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if err := tx.Link(ctx, t.Subject, t.Predicate, t.Object); err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		count++
+	}
+	if err := scanner.Err(); err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit import: %w", err)
+	}
+	return count, nil
+}
+
+// ExportNQuads 以 N-Quads 格式导出所有三元组（不带 graph label），返回导出的条数
+func (g *cayleyGraph) ExportNQuads(ctx context.Context, w io.Writer) (int, error) {
+	bw := bufio.NewWriter(w)
+	count := 0
+	err := g.TriplesIter(ctx, TripleFilter{}, func(t Triple) error {
+		if _, err := fmt.Fprintf(bw, "%s %s %s .\n", formatIRI(t.Subject), formatIRI(t.Predicate), formatObject(t.Object)); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+	return count, bw.Flush()
+}
+
+// ImportJSONLD 从 JSON-LD 文档导入三元组，返回导入的条数
+// 支持顶层为单个节点对象、节点数组或带 @graph 的对象；不处理 @context 展开，属性名按原样作为边类型
+func (g *cayleyGraph) ImportJSONLD(ctx context.Context, r io.Reader) (int, error) {
+	var doc interface{}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return 0, fmt.Errorf("failed to decode JSON-LD: %w", err)
+	}
+
+	var nodes []interface{}
+	switch v := doc.(type) {
+	case []interface{}:
+		nodes = v
+	case map[string]interface{}:
+		if graph, ok := v["@graph"].([]interface{}); ok {
+			nodes = graph
+		} else {
+			nodes = []interface{}{v}
+		}
+	default:
+		return 0, fmt.Errorf("unsupported JSON-LD document type %T", doc)
+	}
+
+	var triples []Triple
+	for i, n := range nodes {
+		node, ok := n.(map[string]interface{})
+		if !ok {
+			return 0, fmt.Errorf("node %d: expected object, got %T", i, n)
+		}
+		subject, ok := node["@id"].(string)
+		if !ok || subject == "" {
+			return 0, fmt.Errorf("node %d: missing @id", i)
+		}
+		for predicate, value := range node {
+			if strings.HasPrefix(predicate, "@") {
+				continue
+			}
+			objects, err := jsonLDValues(value)
+			if err != nil {
+				return 0, fmt.Errorf("node %d, property %q: %w", i, predicate, err)
+			}
+			for _, obj := range objects {
+				triples = append(triples, Triple{Subject: subject, Predicate: predicate, Object: obj})
+			}
+		}
+	}
+
+	tx, err := g.BeginTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, t := range triples {
+		if err := tx.Link(ctx, t.Subject, t.Predicate, t.Object); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit import: %w", err)
+	}
+	return len(triples), nil
+}
+
+// ExportJSONLD 以 JSON-LD（{"@graph": [...]} 形式）导出所有三元组，返回导出的条数
+// 同一 subject 的边聚合到一个节点对象中，因此节点对象会在内存中构建
+func (g *cayleyGraph) ExportJSONLD(ctx context.Context, w io.Writer) (int, error) {
+	var order []string
+	nodes := make(map[string]map[string][]map[string]string)
+	count := 0
+	err := g.TriplesIter(ctx, TripleFilter{}, func(t Triple) error {
+		node, ok := nodes[t.Subject]
+		if !ok {
+			node = make(map[string][]map[string]string)
+			nodes[t.Subject] = node
+			order = append(order, t.Subject)
+		}
+		key := "@value"
+		if isIRISafe(t.Object) {
+			key = "@id"
+		}
+		node[t.Predicate] = append(node[t.Predicate], map[string]string{key: t.Object})
+		count++
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	graph := make([]map[string]interface{}, 0, len(order))
+	for _, subject := range order {
+		obj := map[string]interface{}{"@id": subject}
+		for predicate, values := range nodes[subject] {
+			obj[predicate] = values
+		}
+		graph = append(graph, obj)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(map[string]interface{}{"@graph": graph}); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// jsonLDValues 将 JSON-LD 属性值解析为字符串列表
+func jsonLDValues(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		var result []string
+		for _, item := range v {
+			values, err := jsonLDValues(item)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, values...)
+		}
+		return result, nil
+	case map[string]interface{}:
+		if id, ok := v["@id"].(string); ok {
+			return []string{id}, nil
+		}
+		if val, ok := v["@value"]; ok {
+			return []string{fmt.Sprint(val)}, nil
+		}
+		return nil, fmt.Errorf("value object must contain @id or @value")
+	case string:
+		return []string{v}, nil
+	case float64, bool:
+		return []string{fmt.Sprint(v)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported value type %T", value)
+	}
+}
+
+// parseNQuadLine 解析一行 N-Quads
+func parseNQuadLine(line string) (Triple, error) {
+	var terms []string
+	rest := line
+	for {
+		rest = strings.TrimLeft(rest, " \t")
+		if rest == "" {
+			return Triple{}, fmt.Errorf("missing terminating '.'")
+		}
+		if rest[0] == '.' {
+			if tail := strings.TrimSpace(rest[1:]); tail != "" && !strings.HasPrefix(tail, "#") {
+				return Triple{}, fmt.Errorf("unexpected content after '.': %q", tail)
+			}
+			break
+		}
+		term, n, err := parseNQuadTerm(rest)
+		if err != nil {
+			return Triple{}, err
+		}
+		terms = append(terms, term)
+		rest = rest[n:]
+	}
+
+	if len(terms) != 3 && len(terms) != 4 {
+		return Triple{}, fmt.Errorf("expected 3 or 4 terms, got %d", len(terms))
+	}
+	return Triple{Subject: terms[0], Predicate: terms[1], Object: terms[2]}, nil
+}
+
+// parseNQuadTerm 解析一个 term，返回其字符串值和消耗的字节数
+func parseNQuadTerm(s string) (string, int, error) {
+	switch {
+	case s[0] == '<':
+		end := strings.IndexByte(s, '>')
+		if end < 0 {
+			return "", 0, fmt.Errorf("unterminated IRI")
+		}
+		value, err := unescapeNQuads(s[1:end])
+		if err != nil {
+			return "", 0, err
+		}
+		return value, end + 1, nil
+	case s[0] == '"':
+		i := 1
+		for i < len(s) && s[i] != '"' {
+			if s[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(s) {
+			return "", 0, fmt.Errorf("unterminated literal")
+		}
+		value, err := unescapeNQuads(s[1:i])
+		if err != nil {
+			return "", 0, err
+		}
+		i++
+		// 跳过语言标签或数据类型
+		if i < len(s) && s[i] == '@' {
+			for i < len(s) && s[i] != ' ' && s[i] != '\t' {
+				i++
+			}
+		} else if strings.HasPrefix(s[i:], "^^<") {
+			end := strings.IndexByte(s[i:], '>')
+			if end < 0 {
+				return "", 0, fmt.Errorf("unterminated datatype IRI")
+			}
+			i += end + 1
+		}
+		return value, i, nil
+	case strings.HasPrefix(s, "_:"):
+		i := 2
+		for i < len(s) && s[i] != ' ' && s[i] != '\t' {
+			i++
+		}
+		return s[:i], i, nil
+	default:
+		return "", 0, fmt.Errorf("unexpected term starting with %q", s[0])
+	}
+}
+
+// unescapeNQuads 处理 ECHAR 和 UCHAR 转义
+func unescapeNQuads(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		if i >= len(s) {
+			return "", fmt.Errorf("dangling escape")
+		}
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'b':
+			b.WriteByte('\b')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case '"', '\'', '\\':
+			b.WriteByte(s[i])
+		case 'u', 'U':
+			size := 4
+			if s[i] == 'U' {
+				size = 8
+			}
+			if i+size >= len(s) {
+				return "", fmt.Errorf("invalid unicode escape")
+			}
+			code, err := strconv.ParseUint(s[i+1:i+1+size], 16, 32)
+			if err != nil {
+				return "", fmt.Errorf("invalid unicode escape: %w", err)
+			}
+			b.WriteRune(rune(code))
+			i += size
+		default:
+			return "", fmt.Errorf("invalid escape \\%c", s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// isIRISafe 判断字符串能否不经转义直接写成 IRIREF
+func isIRISafe(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r <= 0x20 || strings.ContainsRune("<>\"{}|^`\\", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// formatIRI 将字符串写成 IRIREF，非法字符使用 UCHAR 转义
+func formatIRI(s string) string {
+	var b strings.Builder
+	b.WriteByte('<')
+	for _, r := range s {
+		if r <= 0x20 || strings.ContainsRune("<>\"{}|^`\\", r) {
+			fmt.Fprintf(&b, "\\u%04X", r)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+// formatObject 将 object 写成 IRI 或字符串字面量
+func formatObject(s string) string {
+	if isIRISafe(s) {
+		return formatIRI(s)
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,136 @@
+package cayley_driver
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestGraphNQuadsRoundTrip(t *testing.T) {
+	workingDir := t.TempDir()
+
+	src, err := NewGraphWithNamespace(workingDir, "nquads.db", "nq_src_")
+	if err != nil {
+		t.Fatalf("Failed to create graph: %v", err)
+	}
+	defer src.Close()
+
+	ctx := context.Background()
+
+	input := `# comment line
+<alice> <follows> <bob> .
+<alice> <DESCRIPTION> "Alice is a \"developer\"\nfrom 北京" <graph1> .
+_:b1 <TYPE> "PERSON"@en .
+<bob> <age> "42"^^<http://www.w3.org/2001/XMLSchema#integer> .
+`
+	n, err := src.ImportNQuads(ctx, strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to import n-quads: %v", err)
+	}
+	if n != 4 {
+		t.Errorf("Expected 4 imported quads, got %d", n)
+	}
+
+	desc, err := src.GetNeighbors(ctx, "alice", "DESCRIPTION")
+	if err != nil {
+		t.Fatalf("Failed to get neighbors: %v", err)
+	}
+	if len(desc) != 1 || desc[0] != "Alice is a \"developer\"\nfrom 北京" {
+		t.Errorf("Unexpected description %q", desc)
+	}
+
+	var buf bytes.Buffer
+	n, err = src.ExportNQuads(ctx, &buf)
+	if err != nil {
+		t.Fatalf("Failed to export n-quads: %v", err)
+	}
+	if n != 4 {
+		t.Errorf("Expected 4 exported quads, got %d", n)
+	}
+
+	dst, err := NewGraphWithNamespace(workingDir, "nquads.db", "nq_dst_")
+	if err != nil {
+		t.Fatalf("Failed to create graph: %v", err)
+	}
+	defer dst.Close()
+
+	if _, err := dst.ImportNQuads(ctx, &buf); err != nil {
+		t.Fatalf("Failed to re-import n-quads: %v", err)
+	}
+	srcTriples, _ := src.AllTriples(ctx)
+	dstTriples, _ := dst.AllTriples(ctx)
+	if len(srcTriples) != len(dstTriples) {
+		t.Fatalf("Expected %d triples after round trip, got %d", len(srcTriples), len(dstTriples))
+	}
+	for i := range srcTriples {
+		if srcTriples[i] != dstTriples[i] {
+			t.Errorf("Triple %d mismatch: %v != %v", i, srcTriples[i], dstTriples[i])
+		}
+	}
+
+	// 非法输入整体回滚
+	_, err = dst.ImportNQuads(ctx, strings.NewReader("<x> <y> <z> .\n<broken> <line>\n"))
+	if err == nil {
+		t.Error("Expected error for malformed n-quads")
+	}
+	neighbors, _ := dst.GetNeighbors(ctx, "x", "y")
+	if len(neighbors) != 0 {
+		t.Errorf("Expected import to be rolled back, got %v", neighbors)
+	}
+}
+
+func TestGraphJSONLDRoundTrip(t *testing.T) {
+	workingDir := t.TempDir()
+
+	src, err := NewGraphWithNamespace(workingDir, "jsonld.db", "jl_src_")
+	if err != nil {
+		t.Fatalf("Failed to create graph: %v", err)
+	}
+	defer src.Close()
+
+	ctx := context.Background()
+
+	input := `{
+  "@context": {"name": "http://schema.org/name"},
+  "@graph": [
+    {"@id": "alice", "follows": [{"@id": "bob"}, {"@id": "carol"}], "name": "Alice Smith"},
+    {"@id": "bob", "age": {"@value": 42}}
+  ]
+}`
+	n, err := src.ImportJSONLD(ctx, strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to import JSON-LD: %v", err)
+	}
+	if n != 4 {
+		t.Errorf("Expected 4 imported triples, got %d", n)
+	}
+
+	follows, _ := src.GetNeighbors(ctx, "alice", "follows")
+	if len(follows) != 2 {
+		t.Errorf("Expected 2 follows, got %v", follows)
+	}
+
+	var buf bytes.Buffer
+	if _, err := src.ExportJSONLD(ctx, &buf); err != nil {
+		t.Fatalf("Failed to export JSON-LD: %v", err)
+	}
+
+	dst, err := NewGraphWithNamespace(workingDir, "jsonld.db", "jl_dst_")
+	if err != nil {
+		t.Fatalf("Failed to create graph: %v", err)
+	}
+	defer dst.Close()
+
+	n, err = dst.ImportJSONLD(ctx, &buf)
+	if err != nil {
+		t.Fatalf("Failed to re-import JSON-LD: %v", err)
+	}
+	if n != 4 {
+		t.Errorf("Expected 4 re-imported triples, got %d", n)
+	}
+	names, _ := dst.GetNeighbors(ctx, "alice", "name")
+	if len(names) != 1 || names[0] != "Alice Smith" {
+		t.Errorf("Expected [Alice Smith], got %v", names)
+	}
+}
@@ -0,0 +1,392 @@
+// Package config 加载 browser API、chatbot 后端和示例共用的配置：
+// 调用方提供默认值，依次用配置文件（YAML、TOML 或 JSON）和环境变量覆盖，最后统一校验
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvConfigFile 指定配置文件路径的环境变量
+const EnvConfigFile = "CONFIG_FILE"
+
+// 支持的向量化服务，与 pkg/embedding 一致
+var embeddingProviders = []string{"dashscope", "openai", "ollama", "custom"}
+
+// 支持的向量相似度，与 lightrag.VectorMetric 一致
+var embeddingMetrics = []string{"cosine", "dot", "l2"}
+
+// 支持的大模型服务，目前只支持 OpenAI 兼容接口
+var llmProviders = []string{"openai"}
+
+// 支持的 webhook 事件，与 lightrag.EventTypes 一致
+var webhookEvents = []string{"documents.ingested", "embeddings.completed", "extraction.completed", "extraction.failed", "maintenance.completed"}
+
+// Config 服务配置
+type Config struct {
+	Server    ServerConfig    `yaml:"server" toml:"server"`
+	Database  DatabaseConfig  `yaml:"database" toml:"database"`
+	CORS      CORSConfig      `yaml:"cors" toml:"cors"`
+	Embedding EmbeddingConfig `yaml:"embedding" toml:"embedding"`
+	LLM       LLMConfig       `yaml:"llm" toml:"llm"`
+	RateLimit RateLimitConfig `yaml:"rate_limit" toml:"rate_limit"`
+	Webhooks  []WebhookConfig `yaml:"webhooks" toml:"webhooks"`
+}
+
+// ServerConfig HTTP 服务配置
+type ServerConfig struct {
+	// Port 监听端口，环境变量 PORT
+	Port int `yaml:"port" toml:"port"`
+}
+
+// DatabaseConfig 数据库配置
+type DatabaseConfig struct {
+	// Path 数据目录，环境变量 DB_PATH
+	Path string `yaml:"path" toml:"path"`
+}
+
+// CORSConfig 跨域配置
+type CORSConfig struct {
+	// AllowedOrigins 允许的来源，为空时允许所有来源；环境变量 CORS_ALLOWED_ORIGINS（逗号分隔）
+	AllowedOrigins []string `yaml:"allowed_origins" toml:"allowed_origins"`
+}
+
+// EmbeddingConfig 向量化服务配置，字段含义与 embedding.Config 相同
+//
+// 环境变量 EMBEDDING_PROVIDER、EMBEDDING_API_KEY、EMBEDDING_BASE_URL、EMBEDDING_MODEL、EMBEDDING_DIMENSION、EMBEDDING_PRICE、EMBEDDING_METRIC；
+// 密钥和地址仍为空时依次回退到各服务的变量（DASHSCOPE_API_KEY；OLLAMA_BASE_URL 或 OLLAMA_HOST），
+// openai 回退到 LLM 的密钥和地址
+type EmbeddingConfig struct {
+	Provider  string `yaml:"provider" toml:"provider"`
+	APIKey    string `yaml:"api_key" toml:"api_key"`
+	BaseURL   string `yaml:"base_url" toml:"base_url"`
+	Model     string `yaml:"model" toml:"model"`
+	Dimension int    `yaml:"dimension" toml:"dimension"`
+	// Price 每百万 token 的价格，用于估算费用，0 表示不计费
+	Price float64 `yaml:"price" toml:"price"`
+	// Metric 向量检索的相似度：cosine（默认）、dot 或 l2，应与模型训练时使用的相似度一致
+	Metric string `yaml:"metric" toml:"metric"`
+}
+
+// LLMConfig 大模型配置，环境变量 OPENAI_API_KEY、OPENAI_BASE_URL、OPENAI_MODEL、OPENAI_PROMPT_PRICE、OPENAI_COMPLETION_PRICE
+type LLMConfig struct {
+	// Provider 服务类型，目前只支持 openai（OpenAI 兼容接口）
+	Provider string `yaml:"provider" toml:"provider"`
+	APIKey   string `yaml:"api_key" toml:"api_key"`
+	BaseURL  string `yaml:"base_url" toml:"base_url"`
+	Model    string `yaml:"model" toml:"model"`
+	// PromptPrice、CompletionPrice 输入和输出每百万 token 的价格，用于估算费用
+	PromptPrice     float64 `yaml:"prompt_price" toml:"prompt_price"`
+	CompletionPrice float64 `yaml:"completion_price" toml:"completion_price"`
+}
+
+// RateLimitConfig 请求限制，零值表示不限制
+type RateLimitConfig struct {
+	// RequestsPerSecond 每个客户端每秒允许的请求数，环境变量 RATE_LIMIT
+	RequestsPerSecond float64 `yaml:"requests_per_second" toml:"requests_per_second"`
+	// Burst 允许的突发请求数，默认为 RequestsPerSecond 向上取整；环境变量 RATE_LIMIT_BURST
+	Burst int `yaml:"burst" toml:"burst"`
+	// MaxBodySize 请求体大小上限（字节），环境变量 MAX_BODY_SIZE
+	MaxBodySize int64 `yaml:"max_body_size" toml:"max_body_size"`
+	// QueryTimeout 查询和搜索的超时，如 30s；环境变量 QUERY_TIMEOUT
+	QueryTimeout Duration `yaml:"query_timeout" toml:"query_timeout"`
+}
+
+// WebhookConfig 接收导入和提取事件的地址，字段含义与 lightrag.WebhookConfig 相同
+//
+// 环境变量 WEBHOOK_URLS（逗号分隔）替换配置文件中的地址，这些地址接收所有事件；
+// WEBHOOK_SECRET 为没有设置密钥的地址提供签名密钥
+type WebhookConfig struct {
+	URL string `yaml:"url" toml:"url"`
+	// Events 接收的事件类型，为空时接收所有事件
+	Events []string `yaml:"events" toml:"events"`
+	// Secret 签名密钥，不为空时请求带有 X-LightRAG-Signature 头
+	Secret string `yaml:"secret" toml:"secret"`
+}
+
+// Duration 配置文件中以字符串表示的时长，如 30s、1m30s
+type Duration time.Duration
+
+// UnmarshalText 实现 encoding.TextUnmarshaler
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText 实现 encoding.TextMarshaler
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Load 从 defaults 开始，依次用配置文件和环境变量覆盖，校验后返回配置
+// path 为空时读取 CONFIG_FILE 指向的文件，都为空时只使用环境变量
+func Load(path string, defaults Config) (*Config, error) {
+	cfg := defaults
+	if path == "" {
+		path = os.Getenv(EnvConfigFile)
+	}
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	cfg.applyFallbacks()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// loadFile 按扩展名解析配置文件，只覆盖文件中出现的字段；未知字段视为错误，避免拼写错误被忽略
+func (c *Config) loadFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml", ".json":
+		dec := yaml.NewDecoder(bytes.NewReader(content))
+		dec.KnownFields(true)
+		if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	case ".toml":
+		dec := toml.NewDecoder(bytes.NewReader(content))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(c); err != nil {
+			return fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("unsupported config file format %q (expected .yaml, .yml, .toml or .json)", ext)
+	}
+	return nil
+}
+
+// applyEnv 用已设置的环境变量覆盖配置
+func (c *Config) applyEnv() error {
+	var errs []error
+	setString := func(name string, dst *string) {
+		if v := os.Getenv(name); v != "" {
+			*dst = v
+		}
+	}
+	setInt := func(name string, dst *int) {
+		if v := os.Getenv(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s: %q", name, v))
+				return
+			}
+			*dst = n
+		}
+	}
+	setFloat := func(name string, dst *float64) {
+		if v := os.Getenv(name); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s: %q", name, v))
+				return
+			}
+			*dst = f
+		}
+	}
+
+	setInt("PORT", &c.Server.Port)
+	setString("DB_PATH", &c.Database.Path)
+	if v := os.Getenv("CORS_ALLOWED_ORIGINS"); v != "" {
+		c.CORS.AllowedOrigins = splitList(v)
+	}
+
+	setString("EMBEDDING_PROVIDER", &c.Embedding.Provider)
+	setString("EMBEDDING_API_KEY", &c.Embedding.APIKey)
+	setString("EMBEDDING_BASE_URL", &c.Embedding.BaseURL)
+	setString("EMBEDDING_MODEL", &c.Embedding.Model)
+	setInt("EMBEDDING_DIMENSION", &c.Embedding.Dimension)
+	setFloat("EMBEDDING_PRICE", &c.Embedding.Price)
+	setString("EMBEDDING_METRIC", &c.Embedding.Metric)
+
+	setString("OPENAI_API_KEY", &c.LLM.APIKey)
+	setString("OPENAI_BASE_URL", &c.LLM.BaseURL)
+	setString("OPENAI_MODEL", &c.LLM.Model)
+	setFloat("OPENAI_PROMPT_PRICE", &c.LLM.PromptPrice)
+	setFloat("OPENAI_COMPLETION_PRICE", &c.LLM.CompletionPrice)
+
+	if v := os.Getenv("RATE_LIMIT"); v != "" {
+		rate, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid RATE_LIMIT: %q", v))
+		} else {
+			c.RateLimit.RequestsPerSecond = rate
+			// 只设置了 RATE_LIMIT 时突发数随之调整
+			c.RateLimit.Burst = 0
+		}
+	}
+	setInt("RATE_LIMIT_BURST", &c.RateLimit.Burst)
+	if v := os.Getenv("MAX_BODY_SIZE"); v != "" {
+		size, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid MAX_BODY_SIZE: %q", v))
+		} else {
+			c.RateLimit.MaxBodySize = size
+		}
+	}
+	if v := os.Getenv("QUERY_TIMEOUT"); v != "" {
+		if err := c.RateLimit.QueryTimeout.UnmarshalText([]byte(v)); err != nil {
+			errs = append(errs, fmt.Errorf("invalid QUERY_TIMEOUT: %q", v))
+		}
+	}
+	if v := os.Getenv("WEBHOOK_URLS"); v != "" {
+		c.Webhooks = nil
+		for _, u := range splitList(v) {
+			c.Webhooks = append(c.Webhooks, WebhookConfig{URL: u})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// applyFallbacks 规范化服务名称，并为仍为空的字段填入回退值
+func (c *Config) applyFallbacks() {
+	c.Embedding.Provider = strings.ToLower(strings.TrimSpace(c.Embedding.Provider))
+	c.LLM.Provider = strings.ToLower(strings.TrimSpace(c.LLM.Provider))
+	c.Embedding.Metric = strings.ToLower(strings.TrimSpace(c.Embedding.Metric))
+	if c.LLM.Provider == "" && (c.LLM.APIKey != "" || c.LLM.BaseURL != "" || c.LLM.Model != "") {
+		c.LLM.Provider = "openai"
+	}
+
+	switch c.Embedding.Provider {
+	case "dashscope":
+		c.Embedding.APIKey = firstNonEmpty(c.Embedding.APIKey, os.Getenv("DASHSCOPE_API_KEY"))
+	case "openai":
+		if c.LLM.Provider == "openai" {
+			c.Embedding.APIKey = firstNonEmpty(c.Embedding.APIKey, c.LLM.APIKey)
+			c.Embedding.BaseURL = firstNonEmpty(c.Embedding.BaseURL, c.LLM.BaseURL)
+		}
+	case "ollama":
+		c.Embedding.BaseURL = firstNonEmpty(c.Embedding.BaseURL, os.Getenv("OLLAMA_BASE_URL"), ollamaHostURL(os.Getenv("OLLAMA_HOST")))
+	}
+
+	if c.RateLimit.Burst == 0 && c.RateLimit.RequestsPerSecond > 0 {
+		c.RateLimit.Burst = int(math.Ceil(c.RateLimit.RequestsPerSecond))
+	}
+
+	for i := range c.Webhooks {
+		c.Webhooks[i].Secret = firstNonEmpty(c.Webhooks[i].Secret, os.Getenv("WEBHOOK_SECRET"))
+	}
+}
+
+// Validate 校验配置，返回所有发现的问题
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Server.Port < 0 || c.Server.Port > 65535 {
+		errs = append(errs, fmt.Errorf("server.port out of range: %d", c.Server.Port))
+	}
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" {
+			continue
+		}
+		if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("cors.allowed_origins: invalid origin %q", origin))
+		}
+	}
+	if c.Embedding.Provider != "" && !contains(embeddingProviders, c.Embedding.Provider) {
+		errs = append(errs, fmt.Errorf("embedding.provider: unsupported provider %q (expected %s)",
+			c.Embedding.Provider, strings.Join(embeddingProviders, ", ")))
+	}
+	if c.Embedding.Dimension < 0 {
+		errs = append(errs, fmt.Errorf("embedding.dimension must not be negative"))
+	}
+	if c.Embedding.Price < 0 {
+		errs = append(errs, fmt.Errorf("embedding.price must not be negative"))
+	}
+	if c.Embedding.Metric != "" && !contains(embeddingMetrics, c.Embedding.Metric) {
+		errs = append(errs, fmt.Errorf("embedding.metric: unsupported metric %q (expected %s)",
+			c.Embedding.Metric, strings.Join(embeddingMetrics, ", ")))
+	}
+	if c.LLM.Provider != "" && !contains(llmProviders, c.LLM.Provider) {
+		errs = append(errs, fmt.Errorf("llm.provider: unsupported provider %q (expected %s)",
+			c.LLM.Provider, strings.Join(llmProviders, ", ")))
+	}
+	if c.LLM.PromptPrice < 0 || c.LLM.CompletionPrice < 0 {
+		errs = append(errs, fmt.Errorf("llm.prompt_price and llm.completion_price must not be negative"))
+	}
+	if c.RateLimit.RequestsPerSecond < 0 {
+		errs = append(errs, fmt.Errorf("rate_limit.requests_per_second must not be negative"))
+	}
+	if c.RateLimit.Burst < 0 {
+		errs = append(errs, fmt.Errorf("rate_limit.burst must not be negative"))
+	}
+	if c.RateLimit.MaxBodySize < 0 {
+		errs = append(errs, fmt.Errorf("rate_limit.max_body_size must not be negative"))
+	}
+	if c.RateLimit.QueryTimeout < 0 {
+		errs = append(errs, fmt.Errorf("rate_limit.query_timeout must not be negative"))
+	}
+	for i, webhook := range c.Webhooks {
+		if u, err := url.Parse(webhook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("webhooks[%d].url: invalid URL %q", i, webhook.URL))
+		}
+		for _, event := range webhook.Events {
+			if !contains(webhookEvents, event) {
+				errs = append(errs, fmt.Errorf("webhooks[%d].events: unsupported event %q (expected %s)",
+					i, event, strings.Join(webhookEvents, ", ")))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// splitList 拆分逗号分隔的列表，忽略空项
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// ollamaHostURL 将 OLLAMA_HOST（如 127.0.0.1:11434）补全为 URL
+func ollamaHostURL(host string) string {
+	if host == "" || strings.Contains(host, "://") {
+		return host
+	}
+	return "http://" + host
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,202 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearEnv 清空会影响配置的环境变量，避免受运行环境干扰
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{
+		EnvConfigFile, "PORT", "DB_PATH", "CORS_ALLOWED_ORIGINS",
+		"EMBEDDING_PROVIDER", "EMBEDDING_API_KEY", "EMBEDDING_BASE_URL", "EMBEDDING_MODEL", "EMBEDDING_DIMENSION", "EMBEDDING_PRICE", "EMBEDDING_METRIC",
+		"DASHSCOPE_API_KEY", "OLLAMA_BASE_URL", "OLLAMA_HOST",
+		"OPENAI_API_KEY", "OPENAI_BASE_URL", "OPENAI_MODEL", "OPENAI_PROMPT_PRICE", "OPENAI_COMPLETION_PRICE",
+		"RATE_LIMIT", "RATE_LIMIT_BURST", "MAX_BODY_SIZE", "QUERY_TIMEOUT",
+		"WEBHOOK_URLS", "WEBHOOK_SECRET",
+	} {
+		t.Setenv(name, "")
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+var testDefaults = Config{
+	Server:    ServerConfig{Port: 8080},
+	Database:  DatabaseConfig{Path: "./data"},
+	Embedding: EmbeddingConfig{Provider: "dashscope", Dimension: 1024},
+	RateLimit: RateLimitConfig{MaxBodySize: 1024, QueryTimeout: Duration(30 * time.Second)},
+}
+
+func TestLoadDefaults(t *testing.T) {
+	clearEnv(t)
+	cfg, err := Load("", testDefaults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*cfg, testDefaults) {
+		t.Errorf("got %+v, want defaults", *cfg)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	files := map[string]string{
+		"config.yaml": `
+server:
+  port: 9000
+cors:
+  allowed_origins: ["http://localhost:3000"]
+embedding:
+  provider: OpenAI
+  model: text-embedding-3-small
+llm:
+  api_key: sk-file
+  model: gpt-4o
+  prompt_price: 2.5
+rate_limit:
+  requests_per_second: 2.5
+  query_timeout: 5s
+webhooks:
+  - url: https://hooks.example.com/rag
+    events: [extraction.failed]
+    secret: s3cret
+`,
+		"config.toml": `
+[server]
+port = 9000
+
+[cors]
+allowed_origins = ["http://localhost:3000"]
+
+[embedding]
+provider = "OpenAI"
+model = "text-embedding-3-small"
+
+[llm]
+api_key = "sk-file"
+model = "gpt-4o"
+prompt_price = 2.5
+
+[rate_limit]
+requests_per_second = 2.5
+query_timeout = "5s"
+
+[[webhooks]]
+url = "https://hooks.example.com/rag"
+events = ["extraction.failed"]
+secret = "s3cret"
+`,
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			clearEnv(t)
+			cfg, err := Load(writeFile(t, name, content), testDefaults)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := Config{
+				Server:    ServerConfig{Port: 9000},
+				Database:  DatabaseConfig{Path: "./data"},
+				CORS:      CORSConfig{AllowedOrigins: []string{"http://localhost:3000"}},
+				Embedding: EmbeddingConfig{Provider: "openai", APIKey: "sk-file", Model: "text-embedding-3-small", Dimension: 1024},
+				LLM:       LLMConfig{Provider: "openai", APIKey: "sk-file", Model: "gpt-4o", PromptPrice: 2.5},
+				RateLimit: RateLimitConfig{RequestsPerSecond: 2.5, Burst: 3, MaxBodySize: 1024, QueryTimeout: Duration(5 * time.Second)},
+				Webhooks:  []WebhookConfig{{URL: "https://hooks.example.com/rag", Events: []string{"extraction.failed"}, Secret: "s3cret"}},
+			}
+			if !reflect.DeepEqual(*cfg, want) {
+				t.Errorf("got %+v\nwant %+v", *cfg, want)
+			}
+		})
+	}
+}
+
+func TestLoadEnvOverridesFile(t *testing.T) {
+	clearEnv(t)
+	path := writeFile(t, "config.yml", "server:\n  port: 9000\nembedding:\n  provider: ollama\n")
+	t.Setenv(EnvConfigFile, path)
+	t.Setenv("PORT", "9100")
+	t.Setenv("DB_PATH", "/var/lib/app")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://a.example.com, https://b.example.com,")
+	t.Setenv("OLLAMA_HOST", "127.0.0.1:11434")
+	t.Setenv("MAX_BODY_SIZE", "0")
+	t.Setenv("OPENAI_COMPLETION_PRICE", "10")
+	t.Setenv("EMBEDDING_METRIC", " Dot ")
+	t.Setenv("WEBHOOK_URLS", "http://a.example.com/hook,https://b.example.com/hook")
+	t.Setenv("WEBHOOK_SECRET", "env-secret")
+
+	cfg, err := Load("", testDefaults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Port != 9100 || cfg.Database.Path != "/var/lib/app" || cfg.RateLimit.MaxBodySize != 0 {
+		t.Errorf("env not applied: %+v", *cfg)
+	}
+	if want := []string{"https://a.example.com", "https://b.example.com"}; !reflect.DeepEqual(cfg.CORS.AllowedOrigins, want) {
+		t.Errorf("origins = %v, want %v", cfg.CORS.AllowedOrigins, want)
+	}
+	if cfg.LLM.CompletionPrice != 10 {
+		t.Errorf("completion price = %v", cfg.LLM.CompletionPrice)
+	}
+	if cfg.Embedding.Metric != "dot" {
+		t.Errorf("embedding metric = %q", cfg.Embedding.Metric)
+	}
+	if cfg.Embedding.BaseURL != "http://127.0.0.1:11434" {
+		t.Errorf("ollama base url = %q", cfg.Embedding.BaseURL)
+	}
+	if len(cfg.Webhooks) != 2 || cfg.Webhooks[1].URL != "https://b.example.com/hook" || cfg.Webhooks[1].Secret != "env-secret" {
+		t.Errorf("webhooks = %+v", cfg.Webhooks)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		env     map[string]string
+		want    string
+	}{
+		{name: "unknown field", file: "c.yaml", content: "server:\n  prot: 1\n", want: "prot"},
+		{name: "unknown toml field", file: "c.toml", content: "[llm]\nkey = \"x\"\n", want: "invalid config file"},
+		{name: "unsupported format", file: "c.ini", content: "", want: "unsupported config file format"},
+		{name: "bad env number", env: map[string]string{"PORT": "http"}, want: "invalid PORT"},
+		{name: "bad env duration", env: map[string]string{"QUERY_TIMEOUT": "soon"}, want: "invalid QUERY_TIMEOUT"},
+		{name: "port range", env: map[string]string{"PORT": "70000"}, want: "server.port"},
+		{name: "embedding provider", env: map[string]string{"EMBEDDING_PROVIDER": "cohere"}, want: "embedding.provider"},
+		{name: "origin", env: map[string]string{"CORS_ALLOWED_ORIGINS": "localhost:3000"}, want: "cors.allowed_origins"},
+		{name: "embedding metric", env: map[string]string{"EMBEDDING_METRIC": "manhattan"}, want: "embedding.metric"},
+		{name: "bad env price", env: map[string]string{"EMBEDDING_PRICE": "free"}, want: "invalid EMBEDDING_PRICE"},
+		{name: "negative price", env: map[string]string{"OPENAI_PROMPT_PRICE": "-0.5"}, want: "llm.prompt_price"},
+		{name: "negative rate", env: map[string]string{"RATE_LIMIT": "-1"}, want: "rate_limit.requests_per_second"},
+		{name: "webhook url", env: map[string]string{"WEBHOOK_URLS": "hooks.example.com"}, want: "webhooks[0].url"},
+		{name: "webhook event", file: "c.yaml", content: "webhooks:\n  - url: http://localhost/hook\n    events: [document.added]\n", want: "webhooks[0].events"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			path := ""
+			if tt.file != "" {
+				path = writeFile(t, tt.file, tt.content)
+			}
+			_, err := Load(path, testDefaults)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,8 @@
+module github.com/mozhou-tech/sqlite-ai-driver/pkg/config
+
+go 1.24.2
+
+require (
+	github.com/pelletier/go-toml/v2 v2.2.3
+	gopkg.in/yaml.v3 v3.0.1
+)
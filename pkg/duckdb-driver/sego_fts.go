@@ -58,9 +58,11 @@ func extractSearchTerms(query, queryTokens string) []string {
 }
 
 // TokenizeWithSego 使用 sego 对文本进行中文分词，返回用空格分隔的词
+// 分词结果经过规范化（小写、全角转半角、繁体转简体）并过滤停用词和标点，索引和查询两侧使用同一规则
+// 规则变化后已有的分词结果不会自动更新，需要用 UpdateContentTokens 重新生成并重建 FTS 索引
 // 这是 duckdb-driver 包提供的公共 API，供外部使用
 func TokenizeWithSego(text string) string {
-	return sego.Tokenize(text)
+	return sego.TokenizeFiltered(text)
 }
 
 // CreateFTSIndexWithSego 创建支持 sego 中文分词的 DuckDB FTS 索引
@@ -154,6 +156,79 @@ func UpdateContentTokens(ctx context.Context, db *sql.DB, tableName, idColumn, i
 	return nil
 }
 
+// Tokenizer 把文本切分为用空格分隔的词，索引和查询两侧必须使用同一个 Tokenizer
+type Tokenizer func(text string) string
+
+// RebuildFTSIndexWithSego 重新分词所有文档并重建 FTS 索引，返回重新分词的文档数
+// DuckDB 的 FTS 索引不会随表数据自动更新，插入或删除文档、更新 sego 词典后需要重建才能搜索到最新内容
+// 参数与 CreateFTSIndexWithSego 相同
+func RebuildFTSIndexWithSego(ctx context.Context, db *sql.DB, tableName, idColumn, contentColumn, tokensColumn string) (int, error) {
+	return RebuildFTSIndexWithTokenizer(ctx, db, tableName, idColumn, contentColumn, tokensColumn, TokenizeWithSego)
+}
+
+// RebuildFTSIndexWithTokenizer 与 RebuildFTSIndexWithSego 相同，但使用 tokenize 分词，
+// 更换分词器后用于重新生成所有文档的分词结果
+func RebuildFTSIndexWithTokenizer(ctx context.Context, db *sql.DB, tableName, idColumn, contentColumn, tokensColumn string, tokenize Tokenizer) (int, error) {
+	if tokensColumn == "" {
+		tokensColumn = contentColumn + "_tokens"
+	}
+
+	// 先读出所有文档再更新，避免在遍历结果集时写同一张表
+	selectSQL := fmt.Sprintf("SELECT %s, %s FROM %s WHERE %s IS NOT NULL", idColumn, contentColumn, tableName, contentColumn)
+	rows, err := db.QueryContext(ctx, selectSQL)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query documents: %w", err)
+	}
+	type document struct {
+		id      string
+		content string
+	}
+	var docs []document
+	for rows.Next() {
+		var doc document
+		if err := rows.Scan(&doc.id, &doc.content); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan row: %w", err)
+		}
+		docs = append(docs, doc)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("row iteration error: %w", err)
+	}
+
+	updateSQL := fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ?", tableName, tokensColumn, idColumn)
+	for _, doc := range docs {
+		if _, err := db.ExecContext(ctx, updateSQL, tokenize(doc.content), doc.id); err != nil {
+			return 0, fmt.Errorf("failed to update tokens: %w", err)
+		}
+	}
+
+	// overwrite=1 替换已有的索引
+	rebuildSQL := fmt.Sprintf(`PRAGMA create_fts_index('%s', '%s', '%s', '%s', overwrite=1);`,
+		tableName, idColumn, contentColumn, tokensColumn)
+	if _, err := db.ExecContext(ctx, rebuildSQL); err != nil {
+		return 0, fmt.Errorf("failed to rebuild FTS index: %w", err)
+	}
+
+	return len(docs), nil
+}
+
+// FTSIndexExists 检查表是否已经创建了 FTS 索引
+// DuckDB 的 FTS 扩展会为每个索引创建 fts_main_<tableName> schema
+func FTSIndexExists(ctx context.Context, db *sql.DB, tableName string) (bool, error) {
+	var count int
+	err := db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM information_schema.schemata
+		WHERE schema_name = ?
+	`, "fts_main_"+tableName).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check FTS index: %w", err)
+	}
+	return count > 0, nil
+}
+
 // SearchWithSego 使用 sego 分词进行全文搜索
 // 参数：
 //   - ctx: 上下文
@@ -166,12 +241,25 @@ func UpdateContentTokens(ctx context.Context, db *sql.DB, tableName, idColumn, i
 //
 // 返回：匹配的文档 ID 列表和错误
 func SearchWithSego(ctx context.Context, db *sql.DB, tableName, query, contentColumn, tokensColumn string, limit int) ([]string, error) {
+	return SearchWithSegoFilter(ctx, db, tableName, query, contentColumn, tokensColumn, limit, "")
+}
+
+// SearchWithSegoFilter 与 SearchWithSego 相同，但只返回同时满足 where 条件的文档
+// where 为 SQL 条件表达式（不含 WHERE 关键字），whereArgs 为其中 ? 占位符的参数，为空时不过滤
+// 过滤在 SQL 中完成，结果数不会因为过滤而少于 limit
+func SearchWithSegoFilter(ctx context.Context, db *sql.DB, tableName, query, contentColumn, tokensColumn string, limit int, where string, whereArgs ...any) ([]string, error) {
+	return SearchWithTokenizerFilter(ctx, db, tableName, query, contentColumn, tokensColumn, TokenizeWithSego, limit, where, whereArgs...)
+}
+
+// SearchWithTokenizerFilter 与 SearchWithSegoFilter 相同，但使用 tokenize 对查询分词，
+// tokenize 应与生成 tokensColumn 时使用的分词器一致
+func SearchWithTokenizerFilter(ctx context.Context, db *sql.DB, tableName, query, contentColumn, tokensColumn string, tokenize Tokenizer, limit int, where string, whereArgs ...any) ([]string, error) {
 	if tokensColumn == "" {
 		tokensColumn = contentColumn + "_tokens"
 	}
 
-	// 使用 sego 对查询进行分词
-	queryTokens := TokenizeWithSego(query)
+	// 对查询进行分词
+	queryTokens := tokenize(query)
 
 	// 检查 tokensColumn 是否存在
 	checkColumnSQL := fmt.Sprintf(`
@@ -201,28 +289,34 @@ func SearchWithSego(ctx context.Context, db *sql.DB, tableName, query, contentCo
 		return nil, fmt.Errorf("failed to get ID column: %w", err)
 	}
 
+	filter := ""
+	if where != "" {
+		filter = " AND (" + where + ")"
+	}
+
 	if count > 0 && queryTokens != "" {
 		// 使用 match_bm25 函数搜索
 		// DuckDB FTS 索引创建后会生成 fts_main_tableName.match_bm25(idColumn, query)
 		searchSQL = fmt.Sprintf(`
 			SELECT %s
 			FROM %s
-			WHERE fts_main_%s.match_bm25(%s, ?) IS NOT NULL
+			WHERE fts_main_%s.match_bm25(%s, ?) IS NOT NULL%s
 			LIMIT ?
-		`, idColumn, tableName, tableName, idColumn)
+		`, idColumn, tableName, tableName, idColumn, filter)
 		searchText = queryTokens
 	} else {
 		// 回退到原始 content 字段搜索
 		searchSQL = fmt.Sprintf(`
 			SELECT %s
 			FROM %s
-			WHERE fts_main_%s.match_bm25(%s, ?) IS NOT NULL
+			WHERE fts_main_%s.match_bm25(%s, ?) IS NOT NULL%s
 			LIMIT ?
-		`, idColumn, tableName, tableName, idColumn)
+		`, idColumn, tableName, tableName, idColumn, filter)
 		searchText = query
 	}
 
-	rows, err := db.QueryContext(ctx, searchSQL, searchText, limit)
+	args := append([]any{searchText}, whereArgs...)
+	rows, err := db.QueryContext(ctx, searchSQL, append(args, limit)...)
 	useFallback := false
 	if err != nil {
 		// 如果 FTS 查询失败，使用 LIKE 查询作为回退
@@ -281,9 +375,10 @@ func SearchWithSego(ctx context.Context, db *sql.DB, tableName, query, contentCo
 		searchSQL = fmt.Sprintf(`
 			SELECT %s
 			FROM %s
-			WHERE %s
+			WHERE (%s)%s
 			LIMIT ?
-		`, idColumn, tableName, strings.Join(conditions, " OR "))
+		`, idColumn, tableName, strings.Join(conditions, " OR "), filter)
+		args = append(args, whereArgs...)
 		args = append(args, limit)
 
 		rows, err = db.QueryContext(ctx, searchSQL, args...)
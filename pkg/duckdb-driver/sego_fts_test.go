@@ -358,6 +358,69 @@ func TestUpdateContentTokens(t *testing.T) {
 	})
 }
 
+func TestRebuildFTSIndexWithSego(t *testing.T) {
+	ctx := context.Background()
+	db, dbPath := setupTestDB(t, "sego_fts_rebuild.db")
+	defer cleanupTestDB(t, db, dbPath)
+
+	_, err := db.ExecContext(ctx, `
+		CREATE OR REPLACE TABLE rebuild_documents (
+			id VARCHAR PRIMARY KEY,
+			content TEXT,
+			content_tokens TEXT
+		)
+	`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+	if err := CreateFTSIndexWithSego(ctx, db, "rebuild_documents", "id", "content", "content_tokens"); err != nil {
+		t.Fatalf("Failed to create FTS index: %v", err)
+	}
+	exists, err := FTSIndexExists(ctx, db, "rebuild_documents")
+	if err != nil {
+		t.Fatalf("Failed to check FTS index: %v", err)
+	}
+	if !exists {
+		t.Fatal("FTS index should exist after creation")
+	}
+
+	// 索引创建之后插入的文档没有分词结果，也不在索引中
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO rebuild_documents (id, content) VALUES ('doc1', '自然语言处理是人工智能的重要分支')
+	`)
+	if err != nil {
+		t.Fatalf("Failed to insert document: %v", err)
+	}
+
+	n, err := RebuildFTSIndexWithSego(ctx, db, "rebuild_documents", "id", "content", "content_tokens")
+	if err != nil {
+		t.Fatalf("Failed to rebuild FTS index: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 document to be tokenized, got %d", n)
+	}
+
+	var tokens string
+	if err := db.QueryRowContext(ctx, `SELECT content_tokens FROM rebuild_documents WHERE id = 'doc1'`).Scan(&tokens); err != nil {
+		t.Fatalf("Failed to query tokens: %v", err)
+	}
+	if tokens == "" {
+		t.Error("Tokens should be filled after rebuild")
+	}
+
+	var id string
+	err = db.QueryRowContext(ctx, `
+		SELECT id FROM rebuild_documents
+		WHERE fts_main_rebuild_documents.match_bm25(id, ?) IS NOT NULL
+	`, TokenizeWithSego("自然语言")).Scan(&id)
+	if err != nil {
+		t.Fatalf("Rebuilt index should find the document: %v", err)
+	}
+	if id != "doc1" {
+		t.Errorf("Expected doc1, got %s", id)
+	}
+}
+
 func TestSearchWithSego(t *testing.T) {
 	ctx := context.Background()
 
@@ -532,6 +595,22 @@ func TestSearchWithSego(t *testing.T) {
 			t.Logf("Found documents: %v", ids)
 		}
 	})
+
+	t.Run("带过滤条件搜索", func(t *testing.T) {
+		db, dbPath := setupTestDB(t, "sego_fts_filter.db")
+		defer cleanupTestDB(t, db, dbPath)
+
+		setupTestTable(t, db, "documents")
+		insertTestDocs(t, db, "documents")
+
+		ids, err := SearchWithSegoFilter(ctx, db, "documents", "自然语言", "content", "content_tokens", 10, "id <> ?", "doc1")
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		if len(ids) != 1 || ids[0] != "doc4" {
+			t.Errorf("Expected only doc4, got %v", ids)
+		}
+	})
 }
 
 func TestSegoFTS_Integration(t *testing.T) {
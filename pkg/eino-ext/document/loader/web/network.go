@@ -0,0 +1,103 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress is returned when a URL, or a redirect it leads to, resolves to a loopback,
+// private, link-local or otherwise non-public address and Config.AllowPrivateNetworks is not set.
+var ErrPrivateAddress = errors.New("url resolves to a non-public address")
+
+// maxRedirects 默认客户端最多跟随的重定向次数
+const maxRedirects = 10
+
+// reservedPrefixes 标准库判断之外不能公开访问的地址段
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // 本网络（RFC 1122），0.x.x.x 在部分系统上会连接到本机
+	netip.MustParsePrefix("100.64.0.0/10"), // 运营商级 NAT（RFC 6598），部分云厂商的元数据服务位于其中（如 100.100.100.200）
+	netip.MustParsePrefix("198.18.0.0/15"), // 网络设备基准测试（RFC 2544）
+	netip.MustParsePrefix("240.0.0.0/4"),   // 保留地址（RFC 1112），含广播地址 255.255.255.255
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64（RFC 6052），内嵌的 IPv4 地址可能是内网地址
+}
+
+// newDefaultClient 创建默认的 HTTP 客户端：只允许 http/https 重定向；
+// allowPrivate 为 false 时在 DNS 解析之后检查实际连接的地址，每一次重定向都会检查，
+// 且不使用环境变量中的代理，避免通过代理绕过检查
+func newDefaultClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !allowPrivate {
+		dialer.Control = checkDialAddress
+		transport.Proxy = nil
+	}
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("unsupported redirect url scheme %q", req.URL.Scheme)
+			}
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return nil
+		},
+	}
+}
+
+// checkDialAddress 作为 net.Dialer.Control 在建立连接前检查解析后的地址
+func checkDialAddress(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, address)
+	}
+	if !isPublicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// isPublicAddr 判断地址是否可以公开访问：排除回环、私有（含 IPv6 ULA）、链路本地（含 169.254.169.254 元数据服务）、
+// 未指定、组播以及 reservedPrefixes 中的地址
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() ||
+		addr.IsLoopback() ||
+		addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() ||
+		addr.IsUnspecified() {
+		return false
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
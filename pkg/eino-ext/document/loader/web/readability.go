@@ -0,0 +1,544 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// 以下是 Readability 算法的简化实现：
+// 1. 删除脚本、导航、页脚、侧栏等不可能是正文的节点；
+// 2. 为每个段落打分（长度和逗号数），分数累加到父节点和祖父节点；
+// 3. 按链接密度修正分数，取最高分的节点作为正文容器，并合并分数足够高的兄弟节点；
+// 4. 将正文渲染为带 Markdown 标题、列表和表格的纯文本，方便后续按章节切分。
+
+var (
+	unlikelyCandidates = regexp.MustCompile(`(?i)-ad-|ad-break|adbox|advert|banner|breadcrumb|combx|comment|community|cookie|disqus|extra|footer|gdpr|header|legends|menu|modal|nav|pager|pagination|popup|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe|tags|toolbar|widget`)
+	maybeCandidate     = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+	positiveClass      = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|pagination|post|text|blog|story`)
+	negativeClass      = regexp.MustCompile(`(?i)-ad-|hidden|^hid$| hid$| hid |^hid |banner|combx|comment|com-|contact|foot|footer|footnote|gdpr|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget`)
+	multipleNewlines   = regexp.MustCompile(`\n{3,}`)
+)
+
+// removedTags 直接删除的节点
+var removedTags = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Iframe: true, atom.Object: true,
+	atom.Embed: true, atom.Form: true, atom.Button: true, atom.Input: true, atom.Select: true,
+	atom.Textarea: true, atom.Svg: true, atom.Canvas: true, atom.Nav: true, atom.Footer: true,
+	atom.Aside: true, atom.Template: true, atom.Dialog: true,
+}
+
+// blockTags 渲染时需要换行的块级节点
+var blockTags = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Blockquote: true, atom.Dd: true, atom.Div: true,
+	atom.Dl: true, atom.Dt: true, atom.Figcaption: true, atom.Figure: true, atom.Header: true,
+	atom.Hr: true, atom.Li: true, atom.Main: true, atom.Ol: true, atom.P: true, atom.Pre: true,
+	atom.Section: true, atom.Table: true, atom.Tr: true, atom.Ul: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+}
+
+// article 从页面中提取的正文和元信息
+type article struct {
+	Title         string
+	Content       string
+	CanonicalURL  string
+	Description   string
+	SiteName      string
+	Author        string
+	PublishedTime string
+	Language      string
+}
+
+// extractArticle 提取正文和元信息，base 为页面的最终地址，用于解析相对的 canonical 链接
+func extractArticle(doc *html.Node, base *url.URL) *article {
+	a := &article{}
+	readMeta(doc, base, a)
+	if a.CanonicalURL == "" {
+		a.CanonicalURL = base.String()
+	}
+
+	body := findFirst(doc, atom.Body)
+	if body == nil {
+		body = doc
+	}
+	removeUnlikely(body)
+
+	top := topCandidate(body)
+	a.Content = renderContent(top)
+	if a.Title == "" {
+		if h1 := findFirst(body, atom.H1); h1 != nil {
+			a.Title = collapseSpaces(textContent(h1))
+		}
+	}
+	return a
+}
+
+// readMeta 读取 <html lang>、<title>、<link rel=canonical> 和 meta 标签（含 Open Graph）
+func readMeta(doc *html.Node, base *url.URL, a *article) {
+	var title, ogTitle, ogURL, description, ogDescription string
+	walk(doc, func(n *html.Node) bool {
+		switch n.DataAtom {
+		case atom.Html:
+			a.Language = attr(n, "lang")
+		case atom.Title:
+			if title == "" {
+				title = collapseSpaces(textContent(n))
+			}
+		case atom.Link:
+			for _, rel := range strings.Fields(strings.ToLower(attr(n, "rel"))) {
+				if rel == "canonical" && a.CanonicalURL == "" {
+					a.CanonicalURL = resolveURL(base, attr(n, "href"))
+				}
+			}
+		case atom.Meta:
+			key := strings.ToLower(attr(n, "property"))
+			if key == "" {
+				key = strings.ToLower(attr(n, "name"))
+			}
+			content := strings.TrimSpace(attr(n, "content"))
+			if content == "" {
+				break
+			}
+			switch key {
+			case "og:title":
+				ogTitle = content
+			case "og:url":
+				ogURL = content
+			case "og:site_name":
+				a.SiteName = content
+			case "og:description":
+				ogDescription = content
+			case "description":
+				description = content
+			case "author", "article:author":
+				if a.Author == "" {
+					a.Author = content
+				}
+			case "article:published_time":
+				a.PublishedTime = content
+			}
+		case atom.Body:
+			return false
+		}
+		return true
+	})
+
+	a.Title = firstNonEmpty(ogTitle, title)
+	a.Description = firstNonEmpty(description, ogDescription)
+	if a.CanonicalURL == "" && ogURL != "" {
+		a.CanonicalURL = resolveURL(base, ogURL)
+	}
+}
+
+// removeUnlikely 删除脚本、导航等节点，以及 class/id 表明不是正文且不像正文容器的节点
+func removeUnlikely(root *html.Node) {
+	var remove []*html.Node
+	walk(root, func(n *html.Node) bool {
+		if n.Type == html.CommentNode {
+			remove = append(remove, n)
+			return false
+		}
+		if n.Type != html.ElementNode || n == root {
+			return true
+		}
+		if removedTags[n.DataAtom] || isHidden(n) {
+			remove = append(remove, n)
+			return false
+		}
+		switch n.DataAtom {
+		case atom.Article, atom.Main, atom.Table, atom.Tbody, atom.Thead, atom.Tr, atom.Td, atom.Th, atom.A, atom.Code, atom.Pre:
+			return true
+		}
+		match := attr(n, "class") + " " + attr(n, "id") + " " + attr(n, "role")
+		if unlikelyCandidates.MatchString(match) && !maybeCandidate.MatchString(match) {
+			remove = append(remove, n)
+			return false
+		}
+		return true
+	})
+	for _, n := range remove {
+		if n.Parent != nil {
+			n.Parent.RemoveChild(n)
+		}
+	}
+}
+
+func isHidden(n *html.Node) bool {
+	if _, ok := attrOK(n, "hidden"); ok {
+		return true
+	}
+	if strings.EqualFold(attr(n, "aria-hidden"), "true") {
+		return true
+	}
+	style := strings.ReplaceAll(strings.ToLower(attr(n, "style")), " ", "")
+	return strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden")
+}
+
+// topCandidate 为段落打分并返回正文容器；兄弟节点中分数足够高的段落会一起保留（挂到一个新的 div 下）
+func topCandidate(body *html.Node) *html.Node {
+	scores := make(map[*html.Node]float64)
+	var order []*html.Node
+	initScore := func(n *html.Node) {
+		if _, ok := scores[n]; ok {
+			return
+		}
+		scores[n] = tagWeight(n) + classWeight(n)
+		order = append(order, n)
+	}
+
+	walk(body, func(n *html.Node) bool {
+		if n.Type != html.ElementNode || !isParagraph(n) {
+			return true
+		}
+		text := collapseSpaces(textContent(n))
+		length := utf8.RuneCountInString(text)
+		if length < 25 {
+			return true
+		}
+		score := 1 + float64(strings.Count(text, ",")+strings.Count(text, "，")+strings.Count(text, "、"))
+		score += minFloat(float64(length)/100, 3)
+
+		if parent := n.Parent; parent != nil && parent.Type == html.ElementNode {
+			initScore(parent)
+			scores[parent] += score
+			if grand := parent.Parent; grand != nil && grand.Type == html.ElementNode {
+				initScore(grand)
+				scores[grand] += score / 2
+			}
+		}
+		return true
+	})
+
+	var top *html.Node
+	best := 0.0
+	for _, n := range order {
+		scores[n] *= 1 - linkDensity(n)
+		if top == nil || scores[n] > best {
+			top, best = n, scores[n]
+		}
+	}
+	if top == nil {
+		return body
+	}
+
+	// 合并兄弟节点：分数达到阈值的候选节点，以及链接很少的长段落
+	parent := top.Parent
+	if parent == nil {
+		return top
+	}
+	threshold := maxFloat(10, best*0.2)
+	container := &html.Node{Type: html.ElementNode, DataAtom: atom.Div, Data: "div"}
+	var siblings []*html.Node
+	for s := parent.FirstChild; s != nil; s = s.NextSibling {
+		siblings = append(siblings, s)
+	}
+	for _, s := range siblings {
+		keep := s == top
+		if !keep && s.Type == html.ElementNode {
+			if score, ok := scores[s]; ok && score >= threshold {
+				keep = true
+			} else if s.DataAtom == atom.P {
+				text := collapseSpaces(textContent(s))
+				length := utf8.RuneCountInString(text)
+				density := linkDensity(s)
+				keep = (length > 80 && density < 0.25) ||
+					(length > 0 && density == 0 && strings.ContainsAny(text, ".。!！?？"))
+			}
+		}
+		if keep {
+			parent.RemoveChild(s)
+			container.AppendChild(s)
+		}
+	}
+	return container
+}
+
+// isParagraph 段落：p、pre、td、blockquote，以及不包含块级子节点的 div
+func isParagraph(n *html.Node) bool {
+	switch n.DataAtom {
+	case atom.P, atom.Pre, atom.Td, atom.Blockquote:
+		return true
+	case atom.Div:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && blockTags[c.DataAtom] {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func tagWeight(n *html.Node) float64 {
+	switch n.DataAtom {
+	case atom.Div, atom.Article, atom.Main:
+		return 5
+	case atom.Pre, atom.Td, atom.Blockquote, atom.Section:
+		return 3
+	case atom.Address, atom.Ol, atom.Ul, atom.Dl, atom.Dd, atom.Dt, atom.Li, atom.Form:
+		return -3
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Th:
+		return -5
+	}
+	return 0
+}
+
+func classWeight(n *html.Node) float64 {
+	weight := 0.0
+	for _, v := range []string{attr(n, "class"), attr(n, "id")} {
+		if v == "" {
+			continue
+		}
+		if negativeClass.MatchString(v) {
+			weight -= 25
+		}
+		if positiveClass.MatchString(v) {
+			weight += 25
+		}
+	}
+	return weight
+}
+
+// linkDensity 链接文字占全部文字的比例
+func linkDensity(n *html.Node) float64 {
+	total := utf8.RuneCountInString(collapseSpaces(textContent(n)))
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	walk(n, func(c *html.Node) bool {
+		if c.Type == html.ElementNode && c.DataAtom == atom.A {
+			links += utf8.RuneCountInString(collapseSpaces(textContent(c)))
+			return false
+		}
+		return true
+	})
+	return float64(links) / float64(total)
+}
+
+// renderContent 将正文节点渲染为文本：标题输出为 Markdown 标题，列表项以 "- " 开头，表格输出为 Markdown 表格
+func renderContent(root *html.Node) string {
+	var b strings.Builder
+	renderNode(&b, root)
+	lines := strings.Split(b.String(), "\n")
+	inCode := false
+	for i, line := range lines {
+		if line == "```" {
+			inCode = !inCode
+			continue
+		}
+		// 代码块保留缩进，其他行去掉首尾空格并合并连续空格
+		if !inCode {
+			lines[i] = collapseSpaces(line)
+		}
+	}
+	text := multipleNewlines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(text)
+}
+
+func renderNode(b *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		// 保留行内元素边界处的空格，多余的空格在 renderContent 中合并
+		text := collapseSpaces(n.Data)
+		if text == "" {
+			if n.Data != "" {
+				b.WriteString(" ")
+			}
+			return
+		}
+		if strings.TrimLeft(n.Data, " \t\r\n") != n.Data {
+			b.WriteString(" ")
+		}
+		b.WriteString(text)
+		if strings.TrimRight(n.Data, " \t\r\n") != n.Data {
+			b.WriteString(" ")
+		}
+		return
+	case html.ElementNode, html.DocumentNode:
+	default:
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Br:
+		b.WriteString("\n")
+		return
+	case atom.Hr:
+		b.WriteString("\n\n")
+		return
+	case atom.Img:
+		return
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		if text := collapseSpaces(textContent(n)); text != "" {
+			level := int(n.Data[1] - '0')
+			b.WriteString("\n\n" + strings.Repeat("#", level) + " " + text + "\n\n")
+		}
+		return
+	case atom.Pre:
+		b.WriteString("\n\n```\n" + strings.Trim(textContent(n), "\n") + "\n```\n\n")
+		return
+	case atom.Table:
+		renderTable(b, n)
+		return
+	case atom.Li:
+		b.WriteString("\n- ")
+		renderChildren(b, n)
+		return
+	}
+
+	block := blockTags[n.DataAtom]
+	if block {
+		b.WriteString("\n\n")
+	}
+	renderChildren(b, n)
+	if block {
+		b.WriteString("\n\n")
+	}
+}
+
+func renderChildren(b *strings.Builder, n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		renderNode(b, c)
+	}
+}
+
+// renderTable 渲染为 Markdown 表格，第一行作为表头
+func renderTable(b *strings.Builder, table *html.Node) {
+	var rows [][]string
+	walk(table, func(n *html.Node) bool {
+		if n.Type != html.ElementNode || n.DataAtom != atom.Tr {
+			return true
+		}
+		var cells []string
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.ElementNode && (c.DataAtom == atom.Td || c.DataAtom == atom.Th) {
+				cells = append(cells, strings.ReplaceAll(collapseSpaces(textContent(c)), "|", `\|`))
+			}
+		}
+		if len(cells) > 0 {
+			rows = append(rows, cells)
+		}
+		return false
+	})
+	if len(rows) == 0 {
+		return
+	}
+
+	b.WriteString("\n\n")
+	for i, row := range rows {
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			b.WriteString("|" + strings.Repeat(" --- |", len(row)) + "\n")
+		}
+	}
+	b.WriteString("\n")
+}
+
+// walk 先序遍历，fn 返回 false 时不再进入子节点
+func walk(n *html.Node, fn func(*html.Node) bool) {
+	if !fn(n) {
+		return
+	}
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		walk(c, fn)
+		c = next
+	}
+}
+
+func findFirst(n *html.Node, a atom.Atom) *html.Node {
+	var found *html.Node
+	walk(n, func(c *html.Node) bool {
+		if found != nil {
+			return false
+		}
+		if c.Type == html.ElementNode && c.DataAtom == a {
+			found = c
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	walk(n, func(c *html.Node) bool {
+		if c.Type == html.TextNode {
+			b.WriteString(c.Data)
+		}
+		return true
+	})
+	return b.String()
+}
+
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func attr(n *html.Node, key string) string {
+	v, _ := attrOK(n, key)
+	return v
+}
+
+func attrOK(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && strings.EqualFold(a.Key, key) {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func resolveURL(base *url.URL, ref string) string {
+	u, err := base.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return ""
+	}
+	u.Fragment = ""
+	return u.String()
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
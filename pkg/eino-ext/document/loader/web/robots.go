@@ -0,0 +1,201 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxRobotsSize robots.txt 只读取前 500KB（与 Google 的限制一致）
+const maxRobotsSize = 500 * 1024
+
+// robotsRule 一条 Allow/Disallow 规则
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// robotsGroup 一组 User-agent 及其规则
+type robotsGroup struct {
+	agents []string
+	rules  []robotsRule
+}
+
+// robotsTxt 解析后的 robots.txt，只处理 User-agent、Allow 和 Disallow
+type robotsTxt struct {
+	groups []robotsGroup
+	// disallowAll 表示 robots.txt 无法获取（服务端错误或网络错误），保守地禁止抓取整个站点
+	disallowAll bool
+}
+
+// parseRobotsTxt 解析 robots.txt，连续的 User-agent 行属于同一组
+func parseRobotsTxt(r io.Reader) *robotsTxt {
+	robots := &robotsTxt{}
+	var current *robotsGroup
+	inAgents := false
+
+	scanner := bufio.NewScanner(io.LimitReader(r, maxRobotsSize))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				robots.groups = append(robots.groups, robotsGroup{})
+				current = &robots.groups[len(robots.groups)-1]
+				inAgents = true
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			inAgents = false
+			if current == nil {
+				continue
+			}
+			// 空的 Disallow 表示允许全部，不需要记录
+			if value == "" {
+				continue
+			}
+			current.rules = append(current.rules, robotsRule{allow: key == "allow", pattern: value})
+		default:
+			inAgents = false
+		}
+	}
+	return robots
+}
+
+// allowed 判断 userAgent 能否抓取 path（含查询字符串）
+// 优先使用名字匹配 userAgent 的组，否则使用 * 组；多条规则匹配时最长的规则生效，长度相同时 Allow 优先
+func (r *robotsTxt) allowed(userAgent, path string) bool {
+	if r.disallowAll {
+		return false
+	}
+
+	agent := strings.ToLower(userAgent)
+	if i := strings.IndexAny(agent, "/ "); i >= 0 {
+		agent = agent[:i]
+	}
+	var matched, wildcard []robotsRule
+	for _, g := range r.groups {
+		for _, a := range g.agents {
+			switch {
+			case a == "*":
+				wildcard = append(wildcard, g.rules...)
+			case agent != "" && strings.Contains(agent, a):
+				matched = append(matched, g.rules...)
+			}
+		}
+	}
+	rules := matched
+	if rules == nil {
+		rules = wildcard
+	}
+
+	allow, best := true, -1
+	for _, rule := range rules {
+		if !matchRobotsPattern(rule.pattern, path) {
+			continue
+		}
+		if n := len(rule.pattern); n > best || (n == best && rule.allow) {
+			allow, best = rule.allow, n
+		}
+	}
+	return allow
+}
+
+// matchRobotsPattern 前缀匹配，支持 * 通配符和结尾的 $ 锚点
+func matchRobotsPattern(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for i, part := range parts[1:] {
+		if i == len(parts)-2 && anchored {
+			return strings.HasSuffix(rest, part)
+		}
+		idx := strings.Index(rest, part)
+		if idx < 0 {
+			return false
+		}
+		rest = rest[idx+len(part):]
+	}
+	return !anchored || rest == ""
+}
+
+// robotsFor 获取并缓存站点的 robots.txt：4xx 视为没有限制，5xx 和网络错误视为禁止抓取（这种结果不缓存，下次重新获取）
+func (l *Loader) robotsFor(ctx context.Context, u *url.URL) (*robotsTxt, error) {
+	key := u.Scheme + "://" + u.Host
+	l.mu.Lock()
+	robots, ok := l.robots[key]
+	l.mu.Unlock()
+	if ok {
+		return robots, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, key+"/robots.txt", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create robots.txt request: %w", err)
+	}
+	req.Header.Set("User-Agent", l.userAgent)
+
+	resp, err := l.client.Do(req)
+	switch {
+	case err != nil:
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if errors.Is(err, ErrPrivateAddress) {
+			return nil, fmt.Errorf("failed to fetch %s/robots.txt: %w", key, err)
+		}
+		robots = &robotsTxt{disallowAll: true}
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		robots = parseRobotsTxt(resp.Body)
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		robots = &robotsTxt{}
+	default:
+		robots = &robotsTxt{disallowAll: true}
+	}
+	if resp != nil {
+		resp.Body.Close()
+	}
+
+	if !robots.disallowAll {
+		l.mu.Lock()
+		l.robots[key] = robots
+		l.mu.Unlock()
+	}
+	return robots, nil
+}
@@ -0,0 +1,247 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/eino/components/document"
+	"github.com/cloudwego/eino/components/document/parser"
+	"github.com/cloudwego/eino/schema"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	// MetaKeyURL is the MetaData key of the requested URL
+	MetaKeyURL = "url"
+	// MetaKeyCanonicalURL is the MetaData key of the canonical URL of the page: <link rel="canonical">,
+	// then og:url, then the final URL after redirects. It is also used as the document ID when it is on
+	// the same host as the final URL; otherwise the final URL is the ID.
+	MetaKeyCanonicalURL = "canonical_url"
+	// MetaKeyTitle is the MetaData key of the page title
+	MetaKeyTitle = "title"
+	// MetaKeyDescription is the MetaData key of the page description
+	MetaKeyDescription = "description"
+	// MetaKeySiteName is the MetaData key of the site name (og:site_name)
+	MetaKeySiteName = "site_name"
+	// MetaKeyAuthor is the MetaData key of the article author
+	MetaKeyAuthor = "author"
+	// MetaKeyPublishedTime is the MetaData key of the article publish time (article:published_time)
+	MetaKeyPublishedTime = "published_time"
+	// MetaKeyLanguage is the MetaData key of the page language (<html lang>)
+	MetaKeyLanguage = "language"
+	// MetaKeyContentType is the MetaData key of the media type of the response
+	MetaKeyContentType = "content_type"
+)
+
+// DefaultUserAgent is the default User-Agent, also used to select rules in robots.txt
+const DefaultUserAgent = "sqlite-ai-driver-bot/1.0"
+
+// ErrDisallowedByRobots is returned when robots.txt disallows fetching the URL
+var ErrDisallowedByRobots = errors.New("url is disallowed by robots.txt")
+
+// Config is the configuration for web loader.
+type Config struct {
+	// HTTPClient is used to fetch pages and robots.txt. Default is a client with a 30 second timeout
+	// that only follows http and https redirects and refuses to connect to non-public addresses
+	// (see AllowPrivateNetworks). A custom client is used as is, without these checks.
+	HTTPClient *http.Client
+	// AllowPrivateNetworks lets the default client connect to loopback, private, link-local and other
+	// non-public addresses, e.g. to import intranet pages. By default such URLs, including redirects
+	// that lead to them, fail with ErrPrivateAddress, so user-supplied URLs cannot reach internal
+	// services or cloud metadata endpoints.
+	AllowPrivateNetworks bool
+	// UserAgent is sent with every request. Default is DefaultUserAgent.
+	UserAgent string
+	// IgnoreRobotsTxt disables robots.txt checks. By default the loader refuses URLs disallowed
+	// for its User-Agent and treats an unreachable robots.txt (5xx or network error) as disallow all.
+	IgnoreRobotsTxt bool
+	// MaxBodySize is the maximum number of response bytes read; larger responses are truncated. Default is 10MB.
+	MaxBodySize int64
+	// Parser parses non-HTML, non-text responses such as PDF. If nil, such responses are rejected.
+	Parser parser.Parser
+}
+
+// Loader fetches web pages and extracts the main content with a readability-style algorithm:
+// navigation, sidebars, footers, ads and comments are removed, and headings, lists and tables
+// are kept as Markdown. Plain text responses are returned as is.
+type Loader struct {
+	client          *http.Client
+	userAgent       string
+	ignoreRobotsTxt bool
+	maxBodySize     int64
+	parser          parser.Parser
+
+	mu     sync.Mutex
+	robots map[string]*robotsTxt // 按 scheme://host 缓存
+}
+
+// NewLoader creates a new web loader.
+func NewLoader(ctx context.Context, config *Config) (*Loader, error) {
+	if config == nil {
+		config = &Config{}
+	}
+	client := config.HTTPClient
+	if client == nil {
+		client = newDefaultClient(config.AllowPrivateNetworks)
+	}
+	userAgent := config.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	maxBodySize := config.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = 10 * 1024 * 1024
+	}
+	return &Loader{
+		client:          client,
+		userAgent:       userAgent,
+		ignoreRobotsTxt: config.IgnoreRobotsTxt,
+		maxBodySize:     maxBodySize,
+		parser:          config.Parser,
+		robots:          make(map[string]*robotsTxt),
+	}, nil
+}
+
+// Load fetches src.URI and returns a single document with the page content.
+func (l *Loader) Load(ctx context.Context, src document.Source, opts ...document.LoaderOption) ([]*schema.Document, error) {
+	u, err := url.Parse(strings.TrimSpace(src.URI))
+	if err != nil {
+		return nil, fmt.Errorf("invalid url %q: %w", src.URI, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported url scheme %q", u.Scheme)
+	}
+	u.Fragment = ""
+
+	if !l.ignoreRobotsTxt {
+		robots, err := l.robotsFor(ctx, u)
+		if err != nil {
+			return nil, err
+		}
+		if !robots.allowed(l.userAgent, u.RequestURI()) {
+			return nil, fmt.Errorf("%w: %s", ErrDisallowedByRobots, u)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", l.userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,text/plain;q=0.9,*/*;q=0.8")
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: status %d", u, resp.StatusCode)
+	}
+
+	body := io.LimitReader(resp.Body, l.maxBodySize)
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	finalURL := resp.Request.URL
+
+	meta := map[string]any{
+		MetaKeyURL:         u.String(),
+		MetaKeyContentType: mediaType,
+	}
+
+	switch {
+	case mediaType == "" || mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		reader, err := charset.NewReader(body, contentType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", u, err)
+		}
+		doc, err := html.Parse(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse html of %s: %w", u, err)
+		}
+		a := extractArticle(doc, finalURL)
+		if a.Content == "" {
+			return nil, fmt.Errorf("no content extracted from %s", u)
+		}
+		meta[MetaKeyCanonicalURL] = a.CanonicalURL
+		for k, v := range map[string]string{
+			MetaKeyTitle:         a.Title,
+			MetaKeyDescription:   a.Description,
+			MetaKeySiteName:      a.SiteName,
+			MetaKeyAuthor:        a.Author,
+			MetaKeyPublishedTime: a.PublishedTime,
+			MetaKeyLanguage:      a.Language,
+		} {
+			if v != "" {
+				meta[k] = v
+			}
+		}
+		return []*schema.Document{{
+			ID:       documentID(a.CanonicalURL, finalURL),
+			Content:  a.Content,
+			MetaData: meta,
+		}}, nil
+
+	case strings.HasPrefix(mediaType, "text/"):
+		reader, err := charset.NewReader(body, contentType)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", u, err)
+		}
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", u, err)
+		}
+		meta[MetaKeyCanonicalURL] = finalURL.String()
+		return []*schema.Document{{
+			ID:       finalURL.String(),
+			Content:  string(content),
+			MetaData: meta,
+		}}, nil
+
+	case l.parser != nil:
+		meta[MetaKeyCanonicalURL] = finalURL.String()
+		commonOpts := document.GetLoaderCommonOptions(&document.LoaderOptions{}, opts...)
+		parserOpts := append([]parser.Option{
+			parser.WithURI(finalURL.String()),
+			parser.WithExtraMeta(meta),
+		}, commonOpts.ParserOptions...)
+		return l.parser.Parse(ctx, body, parserOpts...)
+
+	default:
+		return nil, fmt.Errorf("unsupported content type %q of %s", mediaType, u)
+	}
+}
+
+// documentID 返回 HTML 页面的文档 ID：canonical 链接与最终地址在同一主机时使用 canonical 链接，
+// 否则使用最终地址，避免页面通过 canonical 链接冒用其他站点文档的 ID
+func documentID(canonical string, final *url.URL) string {
+	if c, err := url.Parse(canonical); err == nil && strings.EqualFold(c.Host, final.Host) {
+		return canonical
+	}
+	return final.String()
+}
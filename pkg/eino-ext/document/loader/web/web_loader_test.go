@@ -0,0 +1,321 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package web
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/document"
+	"github.com/cloudwego/eino/components/document/parser"
+	"github.com/cloudwego/eino/schema"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+const testArticleHTML = `<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>向量检索入门 - 技术博客</title>
+<meta name="description" content="介绍向量检索的基本概念">
+<meta property="og:site_name" content="技术博客">
+<meta name="author" content="李四">
+<meta property="article:published_time" content="2024-05-01T08:00:00+08:00">
+<link rel="canonical" href="/posts/vector-search">
+<script>var tracking = "should not appear";</script>
+<style>.x { color: red; }</style>
+</head>
+<body>
+<nav class="top-nav"><a href="/">首页</a> <a href="/about">关于</a></nav>
+<div class="sidebar"><a href="/a">热门文章一</a><a href="/b">热门文章二</a></div>
+<div id="main-content" class="post">
+  <h1>向量检索入门</h1>
+  <p>向量检索把文本、图片等数据转换为高维向量，再通过近似最近邻算法找到与查询最相似的结果，这是 <b>RAG</b> 系统的核心组件之一。</p>
+  <h2>常见的索引结构</h2>
+  <p>常见的索引结构包括 HNSW、IVF 和 PQ，它们在召回率、内存占用和查询速度之间做出不同的权衡，需要根据数据规模选择。</p>
+  <ul><li>HNSW：基于图的索引</li><li>IVF：基于聚类的倒排索引</li></ul>
+  <table><tr><th>索引</th><th>内存</th></tr><tr><td>HNSW</td><td>高</td></tr><tr><td>IVF</td><td>中</td></tr></table>
+  <pre>func main() {
+    search()
+}</pre>
+  <div class="share-buttons"><a href="/share">分享到微博</a></div>
+  <p style="display:none">隐藏的段落不应该出现在正文中，即使它足够长并且包含标点符号。</p>
+</div>
+<div class="comments"><p>评论：写得很好，学习了，感谢作者的分享，期待更多的文章！</p></div>
+<footer>版权所有 © 2024</footer>
+</body>
+</html>`
+
+func newTestSite(robots string, robotsStatus int) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(robotsStatus)
+		_, _ = io.WriteString(w, robots)
+	})
+	mux.HandleFunc("/posts/vector-search", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = io.WriteString(w, testArticleHTML)
+	})
+	mux.HandleFunc("/syndicated", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = io.WriteString(w, strings.Replace(testArticleHTML, `href="/posts/vector-search"`, `href="https://other.example/posts/vector-search"`, 1))
+	})
+	mux.HandleFunc("/old-link", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/posts/vector-search?utm_source=feed", http.StatusFound)
+	})
+	mux.HandleFunc("/notes.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = io.WriteString(w, "plain notes")
+	})
+	mux.HandleFunc("/private/report", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "<p>secret</p>")
+	})
+	mux.HandleFunc("/file.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		_, _ = io.WriteString(w, "%PDF-1.4")
+	})
+	return httptest.NewServer(mux)
+}
+
+// stubParser 记录收到的选项，返回固定文档
+type stubParser struct{}
+
+func (stubParser) Parse(ctx context.Context, reader io.Reader, opts ...parser.Option) ([]*schema.Document, error) {
+	commonOpts := parser.GetCommonOptions(nil, opts...)
+	data, _ := io.ReadAll(reader)
+	return []*schema.Document{{Content: string(data), MetaData: commonOpts.ExtraMeta}}, nil
+}
+
+func TestLoader(t *testing.T) {
+	ctx := context.Background()
+
+	convey.Convey("Test Loader readability extraction", t, func() {
+		srv := newTestSite("User-agent: *\nDisallow: /private/\n", http.StatusOK)
+		defer srv.Close()
+
+		loader, err := NewLoader(ctx, &Config{AllowPrivateNetworks: true})
+		convey.So(err, convey.ShouldBeNil)
+
+		docs, err := loader.Load(ctx, document.Source{URI: srv.URL + "/old-link#top"})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(len(docs), convey.ShouldEqual, 1)
+
+		doc := docs[0]
+		convey.So(doc.ID, convey.ShouldEqual, srv.URL+"/posts/vector-search")
+		convey.So(doc.MetaData[MetaKeyURL], convey.ShouldEqual, srv.URL+"/old-link")
+		convey.So(doc.MetaData[MetaKeyCanonicalURL], convey.ShouldEqual, srv.URL+"/posts/vector-search")
+		convey.So(doc.MetaData[MetaKeyTitle], convey.ShouldEqual, "向量检索入门 - 技术博客")
+		convey.So(doc.MetaData[MetaKeyDescription], convey.ShouldEqual, "介绍向量检索的基本概念")
+		convey.So(doc.MetaData[MetaKeySiteName], convey.ShouldEqual, "技术博客")
+		convey.So(doc.MetaData[MetaKeyAuthor], convey.ShouldEqual, "李四")
+		convey.So(doc.MetaData[MetaKeyPublishedTime], convey.ShouldEqual, "2024-05-01T08:00:00+08:00")
+		convey.So(doc.MetaData[MetaKeyLanguage], convey.ShouldEqual, "zh-CN")
+		convey.So(doc.MetaData[MetaKeyContentType], convey.ShouldEqual, "text/html")
+
+		convey.So(doc.Content, convey.ShouldEqual, "# 向量检索入门\n\n"+
+			"向量检索把文本、图片等数据转换为高维向量，再通过近似最近邻算法找到与查询最相似的结果，这是 RAG 系统的核心组件之一。\n\n"+
+			"## 常见的索引结构\n\n"+
+			"常见的索引结构包括 HNSW、IVF 和 PQ，它们在召回率、内存占用和查询速度之间做出不同的权衡，需要根据数据规模选择。\n\n"+
+			"- HNSW：基于图的索引\n"+
+			"- IVF：基于聚类的倒排索引\n\n"+
+			"| 索引 | 内存 |\n| --- | --- |\n| HNSW | 高 |\n| IVF | 中 |\n\n"+
+			"```\nfunc main() {\n    search()\n}\n```")
+
+		// 指向其他主机的 canonical 链接只记录在元数据中，ID 使用实际地址
+		docs, err = loader.Load(ctx, document.Source{URI: srv.URL + "/syndicated"})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(docs[0].ID, convey.ShouldEqual, srv.URL+"/syndicated")
+		convey.So(docs[0].MetaData[MetaKeyCanonicalURL], convey.ShouldEqual, "https://other.example/posts/vector-search")
+	})
+
+	convey.Convey("Test Loader plain text and parser fallback", t, func() {
+		srv := newTestSite("", http.StatusNotFound)
+		defer srv.Close()
+
+		loader, err := NewLoader(ctx, &Config{Parser: stubParser{}, AllowPrivateNetworks: true})
+		convey.So(err, convey.ShouldBeNil)
+
+		docs, err := loader.Load(ctx, document.Source{URI: srv.URL + "/notes.txt"})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(docs[0].Content, convey.ShouldEqual, "plain notes")
+		convey.So(docs[0].MetaData[MetaKeyContentType], convey.ShouldEqual, "text/plain")
+
+		docs, err = loader.Load(ctx, document.Source{URI: srv.URL + "/file.pdf"})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(docs[0].Content, convey.ShouldEqual, "%PDF-1.4")
+		convey.So(docs[0].MetaData[MetaKeyCanonicalURL], convey.ShouldEqual, srv.URL+"/file.pdf")
+
+		loader, err = NewLoader(ctx, &Config{AllowPrivateNetworks: true})
+		convey.So(err, convey.ShouldBeNil)
+		_, err = loader.Load(ctx, document.Source{URI: srv.URL + "/file.pdf"})
+		convey.So(err, convey.ShouldNotBeNil)
+	})
+
+	convey.Convey("Test Loader robots.txt", t, func() {
+		srv := newTestSite("User-agent: *\nDisallow: /private/\n", http.StatusOK)
+		defer srv.Close()
+
+		loader, err := NewLoader(ctx, &Config{AllowPrivateNetworks: true})
+		convey.So(err, convey.ShouldBeNil)
+		_, err = loader.Load(ctx, document.Source{URI: srv.URL + "/private/report"})
+		convey.So(errors.Is(err, ErrDisallowedByRobots), convey.ShouldBeTrue)
+
+		loader, err = NewLoader(ctx, &Config{IgnoreRobotsTxt: true, AllowPrivateNetworks: true})
+		convey.So(err, convey.ShouldBeNil)
+		docs, err := loader.Load(ctx, document.Source{URI: srv.URL + "/private/report"})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(docs[0].Content, convey.ShouldEqual, "secret")
+
+		down := newTestSite("", http.StatusServiceUnavailable)
+		defer down.Close()
+		loader, err = NewLoader(ctx, &Config{AllowPrivateNetworks: true})
+		convey.So(err, convey.ShouldBeNil)
+		_, err = loader.Load(ctx, document.Source{URI: down.URL + "/notes.txt"})
+		convey.So(errors.Is(err, ErrDisallowedByRobots), convey.ShouldBeTrue)
+	})
+
+	convey.Convey("Test Loader invalid url", t, func() {
+		loader, err := NewLoader(ctx, &Config{AllowPrivateNetworks: true})
+		convey.So(err, convey.ShouldBeNil)
+		_, err = loader.Load(ctx, document.Source{URI: "ftp://example.com/a"})
+		convey.So(err, convey.ShouldNotBeNil)
+	})
+}
+
+func TestRobotsTxt(t *testing.T) {
+	convey.Convey("Test robots.txt rules", t, func() {
+		robots := parseRobotsTxt(strings.NewReader(`
+# comment
+User-agent: Googlebot
+Disallow: /
+
+User-agent: sqlite-ai-driver-bot
+User-agent: other
+Disallow: /admin
+Allow: /admin/public
+Disallow: /*.json$
+
+User-agent: *
+Disallow: /tmp/
+`))
+		ua := DefaultUserAgent
+		convey.So(robots.allowed(ua, "/"), convey.ShouldBeTrue)
+		convey.So(robots.allowed(ua, "/admin/users"), convey.ShouldBeFalse)
+		convey.So(robots.allowed(ua, "/admin/public/page"), convey.ShouldBeTrue)
+		convey.So(robots.allowed(ua, "/data/list.json"), convey.ShouldBeFalse)
+		convey.So(robots.allowed(ua, "/data/list.json?page=2"), convey.ShouldBeTrue)
+		// 匹配到具体的 User-agent 组后不再使用 * 组
+		convey.So(robots.allowed(ua, "/tmp/file"), convey.ShouldBeTrue)
+
+		convey.So(robots.allowed("SomeBot/2.0", "/tmp/file"), convey.ShouldBeFalse)
+		convey.So(robots.allowed("Googlebot", "/anything"), convey.ShouldBeFalse)
+	})
+
+	convey.Convey("Test matchRobotsPattern", t, func() {
+		convey.So(matchRobotsPattern("/a*b", "/a/x/b/c"), convey.ShouldBeTrue)
+		convey.So(matchRobotsPattern("/a*b$", "/a/x/b/c"), convey.ShouldBeFalse)
+		convey.So(matchRobotsPattern("/a$", "/a"), convey.ShouldBeTrue)
+		convey.So(matchRobotsPattern("/a$", "/ab"), convey.ShouldBeFalse)
+	})
+}
+
+func TestPrivateNetworks(t *testing.T) {
+	ctx := context.Background()
+
+	convey.Convey("Test default client refuses non-public addresses", t, func() {
+		srv := newTestSite("", http.StatusNotFound)
+		defer srv.Close()
+
+		loader, err := NewLoader(ctx, nil)
+		convey.So(err, convey.ShouldBeNil)
+		_, err = loader.Load(ctx, document.Source{URI: srv.URL + "/notes.txt"})
+		convey.So(errors.Is(err, ErrPrivateAddress), convey.ShouldBeTrue)
+
+		loader, err = NewLoader(ctx, &Config{IgnoreRobotsTxt: true})
+		convey.So(err, convey.ShouldBeNil)
+		for _, uri := range []string{
+			srv.URL + "/notes.txt",
+			strings.Replace(srv.URL, "127.0.0.1", "localhost", 1) + "/notes.txt",
+			"http://169.254.169.254/latest/meta-data/",
+			"http://[::1]/",
+			"http://0.0.0.0/",
+		} {
+			_, err = loader.Load(ctx, document.Source{URI: uri})
+			convey.So(errors.Is(err, ErrPrivateAddress), convey.ShouldBeTrue)
+		}
+		_, err = loader.Load(ctx, document.Source{URI: "file:///etc/passwd"})
+		convey.So(err, convey.ShouldNotBeNil)
+
+		loader, err = NewLoader(ctx, &Config{IgnoreRobotsTxt: true, AllowPrivateNetworks: true})
+		convey.So(err, convey.ShouldBeNil)
+		docs, err := loader.Load(ctx, document.Source{URI: srv.URL + "/notes.txt"})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(docs[0].Content, convey.ShouldEqual, "plain notes")
+	})
+
+	convey.Convey("Test redirects are checked", t, func() {
+		redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, r.URL.Query().Get("to"), http.StatusFound)
+		}))
+		defer redirect.Close()
+
+		client := newDefaultClient(true)
+		resp, err := client.Get(redirect.URL + "/?to=file:///etc/passwd")
+		if resp != nil {
+			resp.Body.Close()
+		}
+		convey.So(err, convey.ShouldNotBeNil)
+		convey.So(err.Error(), convey.ShouldContainSubstring, "unsupported redirect url scheme")
+
+		// 地址在建立每个连接时检查，重定向到其他主机同样会被拒绝
+		convey.So(errors.Is(checkDialAddress("tcp", "169.254.169.254:80", nil), ErrPrivateAddress), convey.ShouldBeTrue)
+		convey.So(checkDialAddress("tcp", "93.184.216.34:443", nil), convey.ShouldBeNil)
+	})
+
+	convey.Convey("Test address classification", t, func() {
+		for addr, public := range map[string]bool{
+			"93.184.216.34":        true,
+			"2606:4700:4700::1111": true,
+			"127.0.0.1":            false,
+			"10.1.2.3":             false,
+			"172.16.0.1":           false,
+			"192.168.1.1":          false,
+			"169.254.169.254":      false,
+			"100.100.100.200":      false,
+			"0.0.0.0":              false,
+			"::1":                  false,
+			"fd00:ec2::254":        false,
+			"fe80::1":              false,
+			"::ffff:127.0.0.1":     false,
+			"::ffff:93.184.216.34": true,
+			"224.0.0.1":            false,
+			"0.1.2.3":              false,
+			"198.18.0.1":           false,
+			"240.0.0.1":            false,
+			"255.255.255.255":      false,
+			"64:ff9b::a00:1":       false,
+		} {
+			convey.So(isPublicAddr(netip.MustParseAddr(addr)), convey.ShouldEqual, public)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package audio
+
+import (
+	"time"
+
+	"github.com/cloudwego/eino/components/document/parser"
+)
+
+type options struct {
+	language      *string
+	prompt        *string
+	fileName      *string
+	chunkDuration *time.Duration
+}
+
+// WithLanguage is a parser option that specifies the ISO-639-1 language of the audio, e.g. "zh".
+func WithLanguage(language string) parser.Option {
+	return parser.WrapImplSpecificOptFn(func(opts *options) {
+		opts.language = &language
+	})
+}
+
+// WithPrompt is a parser option that specifies a prompt to guide the transcription,
+// e.g. the spelling of names and terms used in the meeting.
+func WithPrompt(prompt string) parser.Option {
+	return parser.WrapImplSpecificOptFn(func(opts *options) {
+		opts.prompt = &prompt
+	})
+}
+
+// WithFileName is a parser option that specifies the file name sent to the endpoint.
+// Its extension tells the endpoint the audio format.
+func WithFileName(fileName string) parser.Option {
+	return parser.WrapImplSpecificOptFn(func(opts *options) {
+		opts.fileName = &fileName
+	})
+}
+
+// WithChunkDuration is a parser option that specifies the maximum duration covered by one document.
+func WithChunkDuration(d time.Duration) parser.Option {
+	return parser.WrapImplSpecificOptFn(func(opts *options) {
+		opts.chunkDuration = &d
+	})
+}
@@ -0,0 +1,297 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package audio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/eino/components/document/parser"
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	// MetaKeyStart is the MetaData key of the start time of the document in seconds (float64)
+	MetaKeyStart = "start"
+	// MetaKeyEnd is the MetaData key of the end time of the document in seconds (float64)
+	MetaKeyEnd = "end"
+	// MetaKeyLanguage is the MetaData key of the (detected) language of the audio
+	MetaKeyLanguage = "language"
+	// MetaKeyDuration is the MetaData key of the duration of the whole recording in seconds (float64)
+	MetaKeyDuration = "duration"
+)
+
+// Config is the configuration for Whisper audio parser.
+type Config struct {
+	// BaseURL is the base URL of a Whisper-compatible API. Default is "https://api.openai.com/v1".
+	// The parser posts to BaseURL + "/audio/transcriptions".
+	BaseURL string
+	// APIKey is sent as a Bearer token. Empty means no Authorization header, e.g. for a local server.
+	APIKey string
+	// Model is the transcription model. Default is "whisper-1".
+	Model string
+	// Language is the ISO-639-1 language of the audio, e.g. "zh". Empty means auto-detect.
+	Language string
+	// Prompt guides the transcription style and vocabulary.
+	Prompt string
+	// ChunkDuration is the maximum duration covered by one document. Consecutive segments are grouped
+	// until adding the next one would exceed it. 0 means one document for the whole recording.
+	ChunkDuration time.Duration
+	// HTTPClient is used to call the API. Default is a client with a 10 minute timeout.
+	HTTPClient *http.Client
+}
+
+// WhisperParser transcribes audio files (mp3, wav, m4a, webm, ...) with a Whisper-compatible
+// transcription endpoint and emits timestamped transcript documents, one line per segment:
+//
+//	[00:01:05] 我们先看一下上周的进度。
+type WhisperParser struct {
+	BaseURL       string
+	APIKey        string
+	Model         string
+	Language      string
+	Prompt        string
+	ChunkDuration time.Duration
+
+	client *http.Client
+}
+
+// NewWhisperParser creates a new Whisper audio parser.
+func NewWhisperParser(ctx context.Context, config *Config) (*WhisperParser, error) {
+	if config == nil {
+		config = &Config{}
+	}
+	if config.ChunkDuration < 0 {
+		return nil, fmt.Errorf("invalid chunk duration %s", config.ChunkDuration)
+	}
+	baseURL := strings.TrimRight(config.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	model := config.Model
+	if model == "" {
+		model = "whisper-1"
+	}
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Minute}
+	}
+	return &WhisperParser{
+		BaseURL:       baseURL,
+		APIKey:        config.APIKey,
+		Model:         model,
+		Language:      config.Language,
+		Prompt:        config.Prompt,
+		ChunkDuration: config.ChunkDuration,
+		client:        client,
+	}, nil
+}
+
+// transcription verbose_json 格式的转写结果
+type transcription struct {
+	Text     string    `json:"text"`
+	Language string    `json:"language"`
+	Duration float64   `json:"duration"`
+	Segments []segment `json:"segments"`
+}
+
+type segment struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// Parse transcribes the audio content from io.Reader.
+func (wp *WhisperParser) Parse(ctx context.Context, reader io.Reader, opts ...parser.Option) ([]*schema.Document, error) {
+	commonOpts := parser.GetCommonOptions(nil, opts...)
+
+	specificOpts := parser.GetImplSpecificOptions(&options{
+		language:      &wp.Language,
+		prompt:        &wp.Prompt,
+		chunkDuration: &wp.ChunkDuration,
+	}, opts...)
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("audio parser read all from reader failed: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("audio parser: empty input")
+	}
+
+	fileName := ""
+	if specificOpts.fileName != nil {
+		fileName = *specificOpts.fileName
+	}
+	if fileName == "" && commonOpts.URI != "" {
+		fileName = path.Base(commonOpts.URI)
+	}
+	if path.Ext(fileName) == "" {
+		fileName = "audio" + sniffExtension(data)
+	}
+
+	result, err := wp.transcribe(ctx, data, fileName, *specificOpts.language, *specificOpts.prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	// 不支持分段的服务只返回全文，作为一个覆盖整段录音的分段处理
+	segments := result.Segments
+	if len(segments) == 0 && strings.TrimSpace(result.Text) != "" {
+		segments = []segment{{Start: 0, End: result.Duration, Text: result.Text}}
+	}
+
+	var docs []*schema.Document
+	for _, group := range groupSegments(segments, *specificOpts.chunkDuration) {
+		lines := make([]string, 0, len(group))
+		for _, seg := range group {
+			lines = append(lines, fmt.Sprintf("[%s] %s", formatTimestamp(seg.Start), strings.TrimSpace(seg.Text)))
+		}
+
+		meta := make(map[string]any, len(commonOpts.ExtraMeta)+4)
+		for k, v := range commonOpts.ExtraMeta {
+			meta[k] = v
+		}
+		meta[MetaKeyStart] = group[0].Start
+		meta[MetaKeyEnd] = group[len(group)-1].End
+		if result.Duration > 0 {
+			meta[MetaKeyDuration] = result.Duration
+		}
+		if result.Language != "" {
+			meta[MetaKeyLanguage] = result.Language
+		}
+		docs = append(docs, &schema.Document{
+			Content:  strings.Join(lines, "\n"),
+			MetaData: meta,
+		})
+	}
+	return docs, nil
+}
+
+// transcribe 调用 /audio/transcriptions 接口，请求 verbose_json 格式以获得分段时间戳
+func (wp *WhisperParser) transcribe(ctx context.Context, data []byte, fileName, language, prompt string) (*transcription, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := fw.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to write form file: %w", err)
+	}
+	fields := [][2]string{
+		{"model", wp.Model},
+		{"response_format", "verbose_json"},
+		{"timestamp_granularities[]", "segment"},
+	}
+	if language != "" {
+		fields = append(fields, [2]string{"language", language})
+	}
+	if prompt != "" {
+		fields = append(fields, [2]string{"prompt", prompt})
+	}
+	for _, f := range fields {
+		if err := mw.WriteField(f[0], f[1]); err != nil {
+			return nil, fmt.Errorf("failed to write form field %s: %w", f[0], err)
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close multipart writer: %w", err)
+	}
+
+	url := wp.BaseURL + "/audio/transcriptions"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	if wp.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+wp.APIKey)
+	}
+
+	resp, err := wp.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("transcription API error: status %d, body: %s", resp.StatusCode, string(respBody))
+	}
+
+	var result transcription
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &result, nil
+}
+
+// groupSegments 将连续的分段按时长分组，maxDuration 为 0 时所有分段作为一组；空白分段会被忽略
+func groupSegments(segments []segment, maxDuration time.Duration) [][]segment {
+	var (
+		groups  [][]segment
+		current []segment
+	)
+	limit := maxDuration.Seconds()
+	for _, seg := range segments {
+		if strings.TrimSpace(seg.Text) == "" {
+			continue
+		}
+		if len(current) > 0 && limit > 0 && seg.End-current[0].Start > limit {
+			groups = append(groups, current)
+			current = nil
+		}
+		current = append(current, seg)
+	}
+	if len(current) > 0 {
+		groups = append(groups, current)
+	}
+	return groups
+}
+
+// formatTimestamp 将秒数格式化为 HH:MM:SS
+func formatTimestamp(seconds float64) string {
+	total := int(seconds)
+	return fmt.Sprintf("%02d:%02d:%02d", total/3600, total%3600/60, total%60)
+}
+
+// sniffExtension 根据文件头猜测音频格式的扩展名，接口依靠扩展名识别格式，无法识别时按 mp3 处理
+func sniffExtension(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("RIFF")) && len(data) >= 12 && string(data[8:12]) == "WAVE":
+		return ".wav"
+	case bytes.HasPrefix(data, []byte("OggS")):
+		return ".ogg"
+	case bytes.HasPrefix(data, []byte("fLaC")):
+		return ".flac"
+	case bytes.HasPrefix(data, []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		return ".webm"
+	case len(data) >= 12 && string(data[4:8]) == "ftyp":
+		return ".m4a"
+	default:
+		return ".mp3"
+	}
+}
@@ -0,0 +1,164 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package audio
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/components/document/parser"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+const testTranscription = `{
+	"text": "大家好。我们先看一下上周的进度。接下来讨论下个版本。",
+	"language": "chinese",
+	"duration": 95.5,
+	"segments": [
+		{"id": 0, "start": 0.0, "end": 2.5, "text": " 大家好。"},
+		{"id": 1, "start": 2.5, "end": 40.0, "text": " 我们先看一下上周的进度。"},
+		{"id": 2, "start": 40.0, "end": 41.0, "text": "  "},
+		{"id": 3, "start": 65.2, "end": 95.5, "text": " 接下来讨论下个版本。"}
+	]
+}`
+
+type recordedRequest struct {
+	path          string
+	authorization string
+	fields        map[string]string
+	fileName      string
+	file          []byte
+}
+
+func newTestServer(status int, body string, rec *recordedRequest) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec.path = r.URL.Path
+		rec.authorization = r.Header.Get("Authorization")
+		rec.fields = make(map[string]string)
+		if err := r.ParseMultipartForm(1 << 20); err == nil {
+			for k, v := range r.MultipartForm.Value {
+				rec.fields[k] = v[0]
+			}
+			if fhs := r.MultipartForm.File["file"]; len(fhs) > 0 {
+				rec.fileName = fhs[0].Filename
+				f, _ := fhs[0].Open()
+				rec.file, _ = io.ReadAll(f)
+				_ = f.Close()
+			}
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+}
+
+func TestWhisperParser(t *testing.T) {
+	ctx := context.Background()
+
+	convey.Convey("Test WhisperParser whole recording", t, func() {
+		rec := &recordedRequest{}
+		srv := newTestServer(http.StatusOK, testTranscription, rec)
+		defer srv.Close()
+
+		p, err := NewWhisperParser(ctx, &Config{BaseURL: srv.URL + "/v1/", APIKey: "sk-test", Language: "zh"})
+		convey.So(err, convey.ShouldBeNil)
+
+		audio := []byte("RIFF\x00\x00\x00\x00WAVEfmt ")
+		docs, err := p.Parse(ctx, bytes.NewReader(audio), parser.WithExtraMeta(map[string]any{"source": "meeting"}))
+		convey.So(err, convey.ShouldBeNil)
+
+		convey.So(rec.path, convey.ShouldEqual, "/v1/audio/transcriptions")
+		convey.So(rec.authorization, convey.ShouldEqual, "Bearer sk-test")
+		convey.So(rec.fields["model"], convey.ShouldEqual, "whisper-1")
+		convey.So(rec.fields["response_format"], convey.ShouldEqual, "verbose_json")
+		convey.So(rec.fields["language"], convey.ShouldEqual, "zh")
+		convey.So(rec.fields, convey.ShouldNotContainKey, "prompt")
+		convey.So(rec.fileName, convey.ShouldEqual, "audio.wav")
+		convey.So(rec.file, convey.ShouldResemble, audio)
+
+		convey.So(len(docs), convey.ShouldEqual, 1)
+		convey.So(docs[0].Content, convey.ShouldEqual, "[00:00:00] 大家好。\n[00:00:02] 我们先看一下上周的进度。\n[00:01:05] 接下来讨论下个版本。")
+		convey.So(docs[0].MetaData[MetaKeyStart], convey.ShouldEqual, 0.0)
+		convey.So(docs[0].MetaData[MetaKeyEnd], convey.ShouldEqual, 95.5)
+		convey.So(docs[0].MetaData[MetaKeyDuration], convey.ShouldEqual, 95.5)
+		convey.So(docs[0].MetaData[MetaKeyLanguage], convey.ShouldEqual, "chinese")
+		convey.So(docs[0].MetaData["source"], convey.ShouldEqual, "meeting")
+	})
+
+	convey.Convey("Test WhisperParser chunk duration and options", t, func() {
+		rec := &recordedRequest{}
+		srv := newTestServer(http.StatusOK, testTranscription, rec)
+		defer srv.Close()
+
+		p, err := NewWhisperParser(ctx, &Config{BaseURL: srv.URL, Model: "large-v3"})
+		convey.So(err, convey.ShouldBeNil)
+
+		docs, err := p.Parse(ctx, bytes.NewReader([]byte("ID3data")),
+			parser.WithURI("/uploads/weekly.m4a"),
+			WithPrompt("LightRAG, DuckDB"),
+			WithChunkDuration(time.Minute))
+		convey.So(err, convey.ShouldBeNil)
+
+		convey.So(rec.authorization, convey.ShouldEqual, "")
+		convey.So(rec.fields["model"], convey.ShouldEqual, "large-v3")
+		convey.So(rec.fields["prompt"], convey.ShouldEqual, "LightRAG, DuckDB")
+		convey.So(rec.fileName, convey.ShouldEqual, "weekly.m4a")
+
+		convey.So(len(docs), convey.ShouldEqual, 2)
+		convey.So(docs[0].Content, convey.ShouldEqual, "[00:00:00] 大家好。\n[00:00:02] 我们先看一下上周的进度。")
+		convey.So(docs[0].MetaData[MetaKeyEnd], convey.ShouldEqual, 40.0)
+		convey.So(docs[1].Content, convey.ShouldEqual, "[00:01:05] 接下来讨论下个版本。")
+		convey.So(docs[1].MetaData[MetaKeyStart], convey.ShouldEqual, 65.2)
+	})
+
+	convey.Convey("Test WhisperParser response without segments", t, func() {
+		rec := &recordedRequest{}
+		srv := newTestServer(http.StatusOK, `{"text": "hello world", "duration": 3}`, rec)
+		defer srv.Close()
+
+		p, err := NewWhisperParser(ctx, &Config{BaseURL: srv.URL})
+		convey.So(err, convey.ShouldBeNil)
+		docs, err := p.Parse(ctx, bytes.NewReader([]byte("data")), WithFileName("a.mp3"))
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(rec.fileName, convey.ShouldEqual, "a.mp3")
+		convey.So(len(docs), convey.ShouldEqual, 1)
+		convey.So(docs[0].Content, convey.ShouldEqual, "[00:00:00] hello world")
+		convey.So(docs[0].MetaData[MetaKeyEnd], convey.ShouldEqual, 3.0)
+	})
+
+	convey.Convey("Test WhisperParser errors", t, func() {
+		rec := &recordedRequest{}
+		srv := newTestServer(http.StatusBadRequest, `{"error": "bad audio"}`, rec)
+		defer srv.Close()
+
+		p, err := NewWhisperParser(ctx, &Config{BaseURL: srv.URL})
+		convey.So(err, convey.ShouldBeNil)
+		_, err = p.Parse(ctx, bytes.NewReader([]byte("data")))
+		convey.So(err, convey.ShouldNotBeNil)
+		convey.So(err.Error(), convey.ShouldContainSubstring, "bad audio")
+
+		_, err = p.Parse(ctx, bytes.NewReader(nil))
+		convey.So(err, convey.ShouldNotBeNil)
+
+		_, err = NewWhisperParser(ctx, &Config{ChunkDuration: -time.Second})
+		convey.So(err, convey.ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,173 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package csv
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	stdcsv "encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cloudwego/eino/components/document/parser"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/document/parser/internal/tabular"
+)
+
+const (
+	// MetaKeyRow is the MetaData key of the 1-based line number of the record (ModeRows only)
+	MetaKeyRow = tabular.MetaKeyRow
+	// MetaKeyColumns is the MetaData key of the column names ([]string)
+	MetaKeyColumns = tabular.MetaKeyColumns
+	// MetaKeyRowCount is the MetaData key of the number of data rows (ModeTable only)
+	MetaKeyRowCount = tabular.MetaKeyRowCount
+)
+
+// Mode controls how rows are converted into documents
+type Mode = tabular.Mode
+
+const (
+	// ModeTable emits a single document whose content is a Markdown table.
+	ModeTable = tabular.ModeTable
+	// ModeRows emits one document per data row, whose content is "column: value; ..." text.
+	ModeRows = tabular.ModeRows
+)
+
+// candidateCommas 自动识别分隔符时的候选项
+var candidateCommas = []rune{',', ';', '\t', '|'}
+
+// Config is the configuration for CSV parser.
+type Config struct {
+	// Mode controls how rows are converted into documents. Default is ModeTable.
+	Mode Mode
+	// Comma is the field delimiter. 0 means auto-detect among ',', ';', '\t' and '|'
+	// from the first line.
+	Comma rune
+	// NoHeader indicates that the first row is data rather than column names;
+	// columns are then named column1, column2, ...
+	NoHeader bool
+}
+
+// CsvParser parses CSV (and TSV) files. A leading UTF-8 BOM is ignored, quotes are parsed leniently
+// and rows may have different numbers of fields.
+type CsvParser struct {
+	Mode     Mode
+	Comma    rune
+	NoHeader bool
+}
+
+// NewCsvParser creates a new CSV parser.
+func NewCsvParser(ctx context.Context, config *Config) (*CsvParser, error) {
+	if config == nil {
+		config = &Config{}
+	}
+	if err := config.Mode.Validate(); err != nil {
+		return nil, err
+	}
+	if config.Comma == '"' || config.Comma == '\r' || config.Comma == '\n' {
+		return nil, fmt.Errorf("invalid comma %q", config.Comma)
+	}
+	mode := config.Mode
+	if mode == "" {
+		mode = ModeTable
+	}
+	return &CsvParser{
+		Mode:     mode,
+		Comma:    config.Comma,
+		NoHeader: config.NoHeader,
+	}, nil
+}
+
+// Parse parses the CSV content from io.Reader.
+func (cp *CsvParser) Parse(ctx context.Context, reader io.Reader, opts ...parser.Option) ([]*schema.Document, error) {
+	commonOpts := parser.GetCommonOptions(nil, opts...)
+
+	specificOpts := parser.GetImplSpecificOptions(&options{
+		mode:  &cp.Mode,
+		comma: &cp.Comma,
+	}, opts...)
+	if err := specificOpts.mode.Validate(); err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(reader)
+	// 跳过 UTF-8 BOM
+	if bom, err := br.Peek(3); err == nil && bytes.Equal(bom, []byte{0xEF, 0xBB, 0xBF}) {
+		_, _ = br.Discard(3)
+	}
+
+	comma := *specificOpts.comma
+	if comma == 0 {
+		comma = detectComma(br)
+	}
+
+	r := stdcsv.NewReader(br)
+	r.Comma = comma
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	var rows []tabular.Row
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("csv parser read record failed: %w", err)
+		}
+		line, _ := r.FieldPos(0)
+		rows = append(rows, tabular.Row{Number: line, Cells: record})
+	}
+
+	return tabular.Documents([]tabular.Sheet{{Rows: rows}}, tabular.Options{
+		Mode:      *specificOpts.mode,
+		NoHeader:  cp.NoHeader,
+		ExtraMeta: commonOpts.ExtraMeta,
+	}), nil
+}
+
+// detectComma 按首行中出现次数最多的候选分隔符确定分隔符（引号内的字符不计），默认为逗号
+func detectComma(br *bufio.Reader) rune {
+	line, _ := br.Peek(br.Size())
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+
+	counts := make(map[rune]int, len(candidateCommas))
+	inQuote := false
+	for _, r := range string(line) {
+		if r == '"' {
+			inQuote = !inQuote
+			continue
+		}
+		if !inQuote && strings.ContainsRune(string(candidateCommas), r) {
+			counts[r]++
+		}
+	}
+
+	comma, best := ',', 0
+	for _, c := range candidateCommas {
+		if counts[c] > best {
+			comma, best = c, counts[c]
+		}
+	}
+	return comma
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package csv
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestCsvParser(t *testing.T) {
+	ctx := context.Background()
+
+	convey.Convey("Test CsvParser ModeTable", t, func() {
+		p, err := NewCsvParser(ctx, nil)
+		convey.So(err, convey.ShouldBeNil)
+
+		input := "\ufeff地区,销售额,备注\n华东,1200,\"含,逗号\"\n\n华南,900\n"
+		docs, err := p.Parse(ctx, strings.NewReader(input))
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(len(docs), convey.ShouldEqual, 1)
+		convey.So(docs[0].Content, convey.ShouldEqual, "| 地区 | 销售额 | 备注 |\n"+
+			"| --- | --- | --- |\n"+
+			"| 华东 | 1200 | 含,逗号 |\n"+
+			"| 华南 | 900 |  |")
+		convey.So(docs[0].MetaData[MetaKeyRowCount], convey.ShouldEqual, 2)
+		convey.So(docs[0].MetaData[MetaKeyColumns], convey.ShouldResemble, []string{"地区", "销售额", "备注"})
+		convey.So(docs[0].MetaData["sheet"], convey.ShouldBeNil)
+	})
+
+	convey.Convey("Test CsvParser ModeRows with detected delimiter", t, func() {
+		p, err := NewCsvParser(ctx, &Config{Mode: ModeRows})
+		convey.So(err, convey.ShouldBeNil)
+
+		input := "name;city\n\"Smith; John\";Berlin\nAlice;\"Multi\nline\"\nBob;Paris\n"
+		docs, err := p.Parse(ctx, strings.NewReader(input))
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(len(docs), convey.ShouldEqual, 3)
+		convey.So(docs[0].Content, convey.ShouldEqual, "name: Smith; John; city: Berlin")
+		convey.So(docs[0].MetaData[MetaKeyRow], convey.ShouldEqual, 2)
+		convey.So(docs[1].Content, convey.ShouldEqual, "name: Alice; city: Multi\nline")
+		convey.So(docs[2].MetaData[MetaKeyRow], convey.ShouldEqual, 5)
+	})
+
+	convey.Convey("Test CsvParser TSV without header", t, func() {
+		p, err := NewCsvParser(ctx, &Config{NoHeader: true})
+		convey.So(err, convey.ShouldBeNil)
+
+		docs, err := p.Parse(ctx, strings.NewReader("a\tb\nc\td\n"), WithMode(ModeRows), WithComma('\t'))
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(len(docs), convey.ShouldEqual, 2)
+		convey.So(docs[0].Content, convey.ShouldEqual, "column1: a; column2: b")
+		convey.So(docs[0].MetaData[MetaKeyRow], convey.ShouldEqual, 1)
+	})
+
+	convey.Convey("Test CsvParser errors", t, func() {
+		_, err := NewCsvParser(ctx, &Config{Comma: '"'})
+		convey.So(err, convey.ShouldNotBeNil)
+		_, err = NewCsvParser(ctx, &Config{Mode: "unknown"})
+		convey.So(err, convey.ShouldNotBeNil)
+	})
+}
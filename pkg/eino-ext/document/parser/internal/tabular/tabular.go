@@ -0,0 +1,197 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package tabular converts rows of spreadsheet-like sources (XLSX sheets, CSV files) into documents.
+// It is shared by the xlsx and csv parsers.
+package tabular
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/document/transformer/splitter/table"
+)
+
+const (
+	MetaKeySheet      = "sheet"
+	MetaKeySheetIndex = "sheet_index"
+	MetaKeyRow        = "row"
+	MetaKeyColumns    = "columns"
+	MetaKeyRowCount   = "row_count"
+)
+
+// Mode controls how a sheet is converted into documents
+type Mode string
+
+const (
+	// ModeTable emits one document per sheet whose content is a Markdown table
+	ModeTable Mode = "table"
+	// ModeRows emits one document per data row whose content is "column: value; ..." text
+	ModeRows Mode = "rows"
+)
+
+// Validate 检查 Mode 是否受支持，空值视为 ModeTable
+func (m Mode) Validate() error {
+	switch m {
+	case "", ModeTable, ModeRows:
+		return nil
+	default:
+		return fmt.Errorf("unsupported mode %q", m)
+	}
+}
+
+// Sheet 一个工作表（CSV 文件视为没有名字的单个工作表）
+type Sheet struct {
+	Name  string
+	Index int // 工作表在工作簿中的序号，从 0 开始
+	Rows  []Row
+}
+
+// Row 一行数据，Number 为源文件中的行号（从 1 开始）
+type Row struct {
+	Number int
+	Cells  []string
+}
+
+// Options 转换选项
+type Options struct {
+	Mode Mode
+	// NoHeader 表示第一行不是表头，此时列名为 column1、column2...
+	NoHeader  bool
+	ExtraMeta map[string]any
+}
+
+// Documents 将工作表转换为文档：空行和行尾的空单元格会被忽略，没有数据的工作表不输出
+func Documents(sheets []Sheet, opts Options) []*schema.Document {
+	var docs []*schema.Document
+	for _, sheet := range sheets {
+		rows := trimRows(sheet.Rows)
+		if len(rows) == 0 {
+			continue
+		}
+
+		var columns []string
+		if !opts.NoHeader {
+			columns = rows[0].Cells
+			rows = rows[1:]
+		}
+		width := len(columns)
+		for _, row := range rows {
+			if len(row.Cells) > width {
+				width = len(row.Cells)
+			}
+		}
+		columns = columnNames(columns, width)
+
+		if opts.Mode == ModeRows {
+			for _, row := range rows {
+				meta := sheetMeta(opts.ExtraMeta, sheet)
+				meta[MetaKeyRow] = row.Number
+				meta[MetaKeyColumns] = columns
+				docs = append(docs, &schema.Document{
+					Content:  table.SerializeRow(columns, row.Cells),
+					MetaData: meta,
+				})
+			}
+			continue
+		}
+
+		meta := sheetMeta(opts.ExtraMeta, sheet)
+		meta[MetaKeyColumns] = columns
+		meta[MetaKeyRowCount] = len(rows)
+		docs = append(docs, &schema.Document{
+			Content:  markdownTable(columns, rows),
+			MetaData: meta,
+		})
+	}
+	return docs
+}
+
+// trimRows 去掉行尾的空单元格和空行
+func trimRows(rows []Row) []Row {
+	ret := make([]Row, 0, len(rows))
+	for _, row := range rows {
+		cells := row.Cells
+		for len(cells) > 0 && strings.TrimSpace(cells[len(cells)-1]) == "" {
+			cells = cells[:len(cells)-1]
+		}
+		if len(cells) == 0 {
+			continue
+		}
+		ret = append(ret, Row{Number: row.Number, Cells: cells})
+	}
+	return ret
+}
+
+// columnNames 补齐列名，空列名使用 columnN
+func columnNames(header []string, width int) []string {
+	columns := make([]string, width)
+	for i := range columns {
+		if i < len(header) {
+			columns[i] = strings.TrimSpace(header[i])
+		}
+		if columns[i] == "" {
+			columns[i] = fmt.Sprintf("column%d", i+1)
+		}
+	}
+	return columns
+}
+
+func markdownTable(columns []string, rows []Row) string {
+	var b strings.Builder
+	writeMarkdownRow(&b, columns, len(columns))
+	b.WriteString("\n|")
+	b.WriteString(strings.Repeat(" --- |", len(columns)))
+	for _, row := range rows {
+		b.WriteString("\n")
+		writeMarkdownRow(&b, row.Cells, len(columns))
+	}
+	return b.String()
+}
+
+func writeMarkdownRow(b *strings.Builder, cells []string, width int) {
+	b.WriteString("|")
+	for i := 0; i < width; i++ {
+		cell := ""
+		if i < len(cells) {
+			cell = markdownCell(cells[i])
+		}
+		b.WriteString(" ")
+		b.WriteString(cell)
+		b.WriteString(" |")
+	}
+}
+
+// markdownCell 转义竖线，单元格内的换行替换为空格
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", " ")
+	s = strings.NewReplacer("\n", " ", "\r", " ", "|", `\|`).Replace(s)
+	return strings.TrimSpace(s)
+}
+
+func sheetMeta(extra map[string]any, sheet Sheet) map[string]any {
+	meta := make(map[string]any, len(extra)+4)
+	for k, v := range extra {
+		meta[k] = v
+	}
+	if sheet.Name != "" {
+		meta[MetaKeySheet] = sheet.Name
+		meta[MetaKeySheetIndex] = sheet.Index
+	}
+	return meta
+}
@@ -0,0 +1,419 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdf
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ledongthuc/pdf"
+)
+
+const (
+	// MetaKeyPage is the MetaData key of the 1-based page number of a page document (layout mode)
+	MetaKeyPage = "page"
+	// MetaKeyTables is the MetaData key of the tables ([]TableInfo) detected in the document (layout mode)
+	MetaKeyTables = "tables"
+	// MetaKeyColumns is the MetaData key of the number of text columns of a page document (layout mode)
+	MetaKeyColumns = "layout_columns"
+)
+
+// BBox is a bounding box in PDF user space (points, origin at the bottom-left of the page)
+type BBox struct {
+	X0 float64 `json:"x0"`
+	Y0 float64 `json:"y0"`
+	X1 float64 `json:"x1"`
+	Y1 float64 `json:"y1"`
+}
+
+// TableInfo describes a table detected on a page and emitted as Markdown
+type TableInfo struct {
+	Page    int  `json:"page"`
+	BBox    BBox `json:"bbox"`
+	Rows    int  `json:"rows"` // 包含表头行
+	Columns int  `json:"columns"`
+}
+
+// pageLayout 版面分析的结果
+type pageLayout struct {
+	text    string
+	tables  []TableInfo
+	columns int
+}
+
+// segment 一行中连续的一段文字（表格单元格或一栏中的文字）
+type segment struct {
+	x0, x1 float64
+	text   string
+}
+
+// textLine 基线相同的一行文字
+type textLine struct {
+	y        float64
+	size     float64
+	segments []segment
+}
+
+// glyphWidth 返回字符宽度，字体没有宽度信息时按字号估算
+func glyphWidth(t pdf.Text) float64 {
+	if t.W > 0 {
+		return t.W
+	}
+	for _, r := range t.S {
+		if unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r) {
+			return t.FontSize
+		}
+	}
+	return t.FontSize * 0.5
+}
+
+// buildLines 将字符按基线分组为行，行内按水平间距拆分为 segment
+// 间距超过字号 2 倍视为不同的单元格或栏，超过字号 1/4 视为单词之间的空格
+func buildLines(texts []pdf.Text) []textLine {
+	glyphs := make([]pdf.Text, 0, len(texts))
+	for _, t := range texts {
+		if t.S != "" {
+			glyphs = append(glyphs, t)
+		}
+	}
+	sort.SliceStable(glyphs, func(i, j int) bool {
+		if math.Abs(glyphs[i].Y-glyphs[j].Y) > 0.5 {
+			return glyphs[i].Y > glyphs[j].Y
+		}
+		return glyphs[i].X < glyphs[j].X
+	})
+
+	var (
+		lines   []textLine
+		current []pdf.Text
+	)
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		sort.SliceStable(current, func(i, j int) bool { return current[i].X < current[j].X })
+		line := textLine{y: current[0].Y}
+		var (
+			seg  segment
+			text strings.Builder
+		)
+		for i, g := range current {
+			line.size = math.Max(line.size, g.FontSize)
+			gap := g.X - seg.x1
+			switch {
+			case i == 0:
+				seg = segment{x0: g.X}
+			case gap > g.FontSize*2:
+				seg.text = strings.TrimSpace(text.String())
+				if seg.text != "" {
+					line.segments = append(line.segments, seg)
+				}
+				seg = segment{x0: g.X}
+				text.Reset()
+			case gap > g.FontSize*0.25 && !strings.HasSuffix(text.String(), " "):
+				text.WriteByte(' ')
+			}
+			if strings.TrimSpace(g.S) == "" && text.Len() == 0 {
+				// 段首的空白不计入 segment 的起始位置
+				seg.x0 = g.X + glyphWidth(g)
+			}
+			text.WriteString(g.S)
+			seg.x1 = g.X + glyphWidth(g)
+		}
+		seg.text = strings.TrimSpace(text.String())
+		if seg.text != "" {
+			line.segments = append(line.segments, seg)
+		}
+		if len(line.segments) > 0 {
+			lines = append(lines, line)
+		}
+		current = nil
+	}
+
+	for _, g := range glyphs {
+		if len(current) > 0 && math.Abs(g.Y-current[0].Y) > math.Max(current[0].FontSize*0.5, 1) {
+			flush()
+		}
+		current = append(current, g)
+	}
+	flush()
+	return lines
+}
+
+// anchorIndex 返回 x 对应的列锚点下标，没有匹配的锚点时返回 -1
+func anchorIndex(anchors []float64, x, tolerance float64) int {
+	for i, a := range anchors {
+		if math.Abs(a-x) <= tolerance {
+			return i
+		}
+	}
+	return -1
+}
+
+// tableRun 从 start 开始查找列对齐的连续多行，返回结束位置（不包含）和列锚点
+// 表格至少两行且至少两列；只有两列时要求单元格较短，避免把双栏正文误判为表格
+func tableRun(lines []textLine, start int, textWidth float64) (int, []float64) {
+	first := lines[start]
+	if len(first.segments) < 2 {
+		return start, nil
+	}
+	anchors := make([]float64, len(first.segments))
+	for i, seg := range first.segments {
+		anchors[i] = seg.x0
+	}
+
+	end := start + 1
+	for end < len(lines) {
+		line := lines[end]
+		if len(line.segments) < 2 {
+			break
+		}
+		used := make(map[int]bool)
+		ok := true
+		for _, seg := range line.segments {
+			idx := anchorIndex(anchors, seg.x0, line.size)
+			if idx < 0 || used[idx] {
+				ok = false
+				break
+			}
+			used[idx] = true
+		}
+		if !ok {
+			break
+		}
+		end++
+	}
+	if end-start < 2 {
+		return start, nil
+	}
+
+	if len(anchors) == 2 && textWidth > 0 {
+		var total float64
+		count := 0
+		for _, line := range lines[start:end] {
+			for _, seg := range line.segments {
+				total += seg.x1 - seg.x0
+				count++
+			}
+		}
+		if total/float64(count) > textWidth*0.35 {
+			return start, nil
+		}
+	}
+	return end, anchors
+}
+
+// renderTable 将表格行渲染为 Markdown 表格，第一行作为表头
+func renderTable(lines []textLine, anchors []float64) string {
+	var b strings.Builder
+	for r, line := range lines {
+		cells := make([]string, len(anchors))
+		for _, seg := range line.segments {
+			if idx := anchorIndex(anchors, seg.x0, line.size); idx >= 0 {
+				cells[idx] = strings.ReplaceAll(cleanLine(seg.text), "|", `\|`)
+			}
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		if r == 0 {
+			b.WriteString(strings.Repeat("| --- ", len(anchors)) + "|\n")
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// findGutter 查找双栏版面中两栏之间的空白区域，返回其中心位置；没有时返回 false
+// 空白区域需位于文字宽度的 25%~75% 之间、宽度至少为字号的 2 倍，且没有任何一行（跨栏的宽行除外）的文字跨过它
+func findGutter(lines []textLine, minX, maxX float64) (float64, bool) {
+	if len(lines) < 4 || maxX <= minX {
+		return 0, false
+	}
+	width := int(math.Ceil(maxX - minX))
+	covered := make([]bool, width+1)
+	var size float64
+	for _, line := range lines {
+		size = math.Max(size, line.size)
+		for _, seg := range line.segments {
+			for x := int(seg.x0 - minX); x <= int(seg.x1-minX) && x <= width; x++ {
+				if x >= 0 {
+					covered[x] = true
+				}
+			}
+		}
+	}
+
+	lo, hi := int(float64(width)*0.25), int(float64(width)*0.75)
+	bestStart, bestLen := -1, 0
+	for x := lo; x <= hi; x++ {
+		if covered[x] {
+			continue
+		}
+		start := x
+		for x <= width && !covered[x] {
+			x++
+		}
+		if x-start > bestLen {
+			bestStart, bestLen = start, x-start
+		}
+	}
+	if bestStart < 0 || float64(bestLen) < size*2 {
+		return 0, false
+	}
+	gutter := minX + float64(bestStart) + float64(bestLen)/2
+
+	// 两栏都要有足够的文字
+	left, right := 0, 0
+	for _, line := range lines {
+		for _, seg := range line.segments {
+			if seg.x1 <= gutter {
+				left++
+			} else {
+				right++
+			}
+		}
+	}
+	if left < 2 || right < 2 {
+		return 0, false
+	}
+	return gutter, true
+}
+
+// extractLayout 对页面做版面分析：识别表格并输出为 Markdown，双栏正文按先左栏后右栏的顺序输出
+func extractLayout(p pdf.Page, pageNum int) pageLayout {
+	lines := buildLines(p.Content().Text)
+	if len(lines) == 0 {
+		return pageLayout{}
+	}
+
+	minX, maxX := math.Inf(1), math.Inf(-1)
+	for _, line := range lines {
+		for _, seg := range line.segments {
+			minX = math.Min(minX, seg.x0)
+			maxX = math.Max(maxX, seg.x1)
+		}
+	}
+
+	var (
+		out    []string
+		result = pageLayout{columns: 1}
+		block  []textLine // 非表格的连续行
+	)
+	flushBlock := func() {
+		if len(block) == 0 {
+			return
+		}
+		// 查找分栏时忽略跨栏的宽行（如标题、页脚）
+		var narrow []textLine
+		for _, line := range block {
+			if width := line.segments[len(line.segments)-1].x1 - line.segments[0].x0; len(line.segments) > 1 || width <= (maxX-minX)*0.5 {
+				narrow = append(narrow, line)
+			}
+		}
+		gutter, ok := findGutter(narrow, minX, maxX)
+		if !ok {
+			for _, line := range block {
+				out = append(out, lineText(line.segments))
+			}
+			block = nil
+			return
+		}
+
+		result.columns = 2
+		var left, right []string
+		flushColumns := func() {
+			out = append(out, left...)
+			out = append(out, right...)
+			left, right = nil, nil
+		}
+		for _, line := range block {
+			var l, r []segment
+			crossing := false
+			for _, seg := range line.segments {
+				switch {
+				case seg.x1 <= gutter:
+					l = append(l, seg)
+				case seg.x0 >= gutter:
+					r = append(r, seg)
+				default:
+					crossing = true
+				}
+			}
+			if crossing {
+				// 跨栏的行：先输出之前的两栏内容，再按原位置输出该行
+				flushColumns()
+				out = append(out, lineText(line.segments))
+				continue
+			}
+			if len(l) > 0 {
+				left = append(left, lineText(l))
+			}
+			if len(r) > 0 {
+				right = append(right, lineText(r))
+			}
+		}
+		flushColumns()
+		block = nil
+	}
+
+	for i := 0; i < len(lines); {
+		end, anchors := tableRun(lines, i, maxX-minX)
+		if end == i {
+			block = append(block, lines[i])
+			i++
+			continue
+		}
+
+		flushBlock()
+		rows := lines[i:end]
+		out = append(out, renderTable(rows, anchors))
+		info := TableInfo{
+			Page:    pageNum,
+			Rows:    len(rows),
+			Columns: len(anchors),
+			BBox:    BBox{X0: math.Inf(1), Y0: math.Inf(1), X1: math.Inf(-1), Y1: math.Inf(-1)},
+		}
+		for _, row := range rows {
+			info.BBox.Y0 = math.Min(info.BBox.Y0, row.y)
+			info.BBox.Y1 = math.Max(info.BBox.Y1, row.y+row.size)
+			for _, seg := range row.segments {
+				info.BBox.X0 = math.Min(info.BBox.X0, seg.x0)
+				info.BBox.X1 = math.Max(info.BBox.X1, seg.x1)
+			}
+		}
+		result.tables = append(result.tables, info)
+		i = end
+	}
+	flushBlock()
+
+	result.text = strings.Join(out, "\n")
+	return result
+}
+
+// lineText 将一行中的 segment 拼接为文本
+func lineText(segments []segment) string {
+	parts := make([]string, 0, len(segments))
+	for _, seg := range segments {
+		parts = append(parts, seg.text)
+	}
+	return cleanLine(strings.Join(parts, " "))
+}
+
+// cleanLine 过滤无效字符并合并空白，用于版面模式下保留换行的文本
+func cleanLine(s string) string {
+	return normalizeWhitespace(keepOnlyValidChars(s))
+}
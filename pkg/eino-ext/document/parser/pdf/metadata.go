@@ -0,0 +1,143 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdf
+
+import (
+	"strings"
+	"time"
+
+	"github.com/ledongthuc/pdf"
+)
+
+const (
+	// MetaKeyTitle is the MetaData key of the document title from the PDF info dictionary
+	MetaKeyTitle = "title"
+	// MetaKeyAuthor is the MetaData key of the document author
+	MetaKeyAuthor = "author"
+	// MetaKeySubject is the MetaData key of the document subject
+	MetaKeySubject = "subject"
+	// MetaKeyKeywords is the MetaData key of the document keywords
+	MetaKeyKeywords = "keywords"
+	// MetaKeyCreator is the MetaData key of the application that created the original document
+	MetaKeyCreator = "creator"
+	// MetaKeyProducer is the MetaData key of the application that produced the PDF
+	MetaKeyProducer = "producer"
+	// MetaKeyCreationDate is the MetaData key of the creation date (time.Time)
+	MetaKeyCreationDate = "creation_date"
+	// MetaKeyModDate is the MetaData key of the last modification date (time.Time)
+	MetaKeyModDate = "mod_date"
+	// MetaKeyPageCount is the MetaData key of the total number of pages of the PDF
+	MetaKeyPageCount = "page_count"
+	// MetaKeyOutline is the MetaData key of the document outline ([]OutlineEntry)
+	MetaKeyOutline = "outline"
+)
+
+// OutlineEntry is an entry of the document outline (bookmarks / table of contents)
+type OutlineEntry struct {
+	Title string `json:"title"`
+	Level int    `json:"level"` // 顶级书签为 1
+}
+
+// infoTextKeys 信息字典中的文本字段及其对应的 MetaData key
+var infoTextKeys = []struct {
+	name string
+	key  string
+}{
+	{"Title", MetaKeyTitle},
+	{"Author", MetaKeyAuthor},
+	{"Subject", MetaKeySubject},
+	{"Keywords", MetaKeyKeywords},
+	{"Creator", MetaKeyCreator},
+	{"Producer", MetaKeyProducer},
+}
+
+// readMetadata 读取文档信息字典、页数和书签，空字段不会输出
+func readMetadata(r *pdf.Reader) map[string]any {
+	meta := map[string]any{
+		MetaKeyPageCount: r.NumPage(),
+	}
+
+	info := r.Trailer().Key("Info")
+	for _, k := range infoTextKeys {
+		if v := strings.TrimSpace(info.Key(k.name).Text()); v != "" {
+			meta[k.key] = v
+		}
+	}
+	if t, ok := parsePDFDate(info.Key("CreationDate").Text()); ok {
+		meta[MetaKeyCreationDate] = t
+	}
+	if t, ok := parsePDFDate(info.Key("ModDate").Text()); ok {
+		meta[MetaKeyModDate] = t
+	}
+
+	var outline []OutlineEntry
+	var walk func(items []pdf.Outline, level int)
+	walk = func(items []pdf.Outline, level int) {
+		for _, item := range items {
+			if title := strings.TrimSpace(item.Title); title != "" {
+				outline = append(outline, OutlineEntry{Title: title, Level: level})
+			}
+			walk(item.Child, level+1)
+		}
+	}
+	walk(r.Outline().Child, 1)
+	if len(outline) > 0 {
+		meta[MetaKeyOutline] = outline
+	}
+	return meta
+}
+
+// parsePDFDate 解析 PDF 日期字符串，格式为 D:YYYYMMDDHHmmSSOHH'mm'，除年份外各部分均可省略
+func parsePDFDate(s string) (time.Time, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "D:")
+	s = strings.ReplaceAll(s, "'", "")
+	if len(s) < 4 {
+		return time.Time{}, false
+	}
+
+	// 拆分时区部分
+	digits, zone := s, ""
+	if i := strings.IndexAny(s, "Z+-"); i >= 0 {
+		digits, zone = s[:i], s[i:]
+	}
+	layouts := map[int]string{
+		4:  "2006",
+		6:  "200601",
+		8:  "20060102",
+		10: "2006010215",
+		12: "200601021504",
+		14: "20060102150405",
+	}
+	layout, ok := layouts[len(digits)]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	loc := time.UTC
+	if len(zone) >= 3 && zone[0] != 'Z' {
+		offset, err := time.Parse("-0700", (zone + "00")[:5])
+		if err == nil {
+			_, sec := offset.Zone()
+			loc = time.FixedZone("", sec)
+		}
+	}
+	t, err := time.ParseInLocation(layout, digits, loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
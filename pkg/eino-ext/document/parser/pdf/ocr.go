@@ -0,0 +1,182 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdf
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MetaKeyOCR is set to true in MetaData of page documents whose text comes from OCR
+	MetaKeyOCR = "ocr"
+	// MetaKeyOCRConfidence is the MetaData key of the OCR confidence (0~1) of a page document
+	MetaKeyOCRConfidence = "ocr_confidence"
+	// MetaKeyOCRLanguages is the MetaData key of the language hints used for OCR
+	MetaKeyOCRLanguages = "ocr_languages"
+	// MetaKeyOCRPages is the MetaData key of the page numbers ([]int) recognized by OCR in a merged document
+	MetaKeyOCRPages = "ocr_pages"
+	// MetaKeyOCRConfidences is the MetaData key of the OCR confidence per page (map[int]float64) in a merged document
+	MetaKeyOCRConfidences = "ocr_confidences"
+)
+
+// OCRRequest describes a page to be recognized
+type OCRRequest struct {
+	// PDF is the content of the whole PDF file
+	PDF []byte
+	// PageNumber is the 1-based page number
+	PageNumber int
+	// Languages are the language hints of the page, e.g. "chi_sim", "eng"
+	Languages []string
+	// Password is the user password of an encrypted PDF, empty if the PDF is not encrypted
+	Password string
+}
+
+// OCRResult is the text recognized from a page
+type OCRResult struct {
+	Text string
+	// Confidence is the mean confidence in [0, 1]
+	Confidence float64
+}
+
+// OCR recognizes text from scanned PDF pages. It is used as a fallback for pages
+// that yield no embedded text.
+type OCR interface {
+	Recognize(ctx context.Context, req *OCRRequest) (*OCRResult, error)
+}
+
+// TesseractOCR is an OCR backend that renders the page with pdftoppm (poppler-utils)
+// and recognizes it with the tesseract command line tool.
+//
+// pdftoppm only accepts the password of an encrypted PDF on the command line (-upw), so while
+// a page renders the password is visible in the process list to other users of the host.
+// Use a custom OCR backend if that is not acceptable.
+type TesseractOCR struct {
+	// TesseractPath is the tesseract executable. Default is "tesseract".
+	TesseractPath string
+	// PdftoppmPath is the pdftoppm executable. Default is "pdftoppm".
+	PdftoppmPath string
+	// DPI is the resolution the page is rendered at. Default is 300.
+	DPI int
+}
+
+// Recognize 渲染页面为 PNG 并使用 tesseract 识别，置信度为所有词置信度的平均值
+func (t *TesseractOCR) Recognize(ctx context.Context, req *OCRRequest) (*OCRResult, error) {
+	tesseract := t.TesseractPath
+	if tesseract == "" {
+		tesseract = "tesseract"
+	}
+	pdftoppm := t.PdftoppmPath
+	if pdftoppm == "" {
+		pdftoppm = "pdftoppm"
+	}
+	dpi := t.DPI
+	if dpi <= 0 {
+		dpi = 300
+	}
+
+	dir, err := os.MkdirTemp("", "pdf-ocr-*")
+	if err != nil {
+		return nil, fmt.Errorf("create temp dir failed: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	pdfPath := filepath.Join(dir, "input.pdf")
+	if err := os.WriteFile(pdfPath, req.PDF, 0o600); err != nil {
+		return nil, fmt.Errorf("write temp pdf failed: %w", err)
+	}
+
+	page := strconv.Itoa(req.PageNumber)
+	prefix := filepath.Join(dir, "page")
+	renderArgs := []string{"-f", page, "-l", page, "-r", strconv.Itoa(dpi), "-png", "-singlefile"}
+	if req.Password != "" {
+		// pdftoppm 没有从文件或标准输入读取密码的方式，密码会出现在进程参数中
+		renderArgs = append(renderArgs, "-upw", req.Password)
+	}
+	renderArgs = append(renderArgs, pdfPath, prefix)
+	if out, err := exec.CommandContext(ctx, pdftoppm, renderArgs...).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("render page %d failed: %w: %s", req.PageNumber, err, strings.TrimSpace(string(out)))
+	}
+
+	args := []string{prefix + ".png", "stdout"}
+	if len(req.Languages) > 0 {
+		args = append(args, "-l", strings.Join(req.Languages, "+"))
+	}
+	args = append(args, "tsv")
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, tesseract, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("tesseract page %d failed: %w: %s", req.PageNumber, err, strings.TrimSpace(stderr.String()))
+	}
+	return parseTesseractTSV(out), nil
+}
+
+// parseTesseractTSV 解析 tesseract 的 TSV 输出：按 block/段落/行 重建文本，并计算平均置信度
+// 列：level page_num block_num par_num line_num word_num left top width height conf text
+func parseTesseractTSV(data []byte) *OCRResult {
+	var (
+		lines     []string
+		words     []string
+		lineKey   string
+		confSum   float64
+		wordCount int
+	)
+	flush := func() {
+		if len(words) > 0 {
+			lines = append(lines, strings.Join(words, " "))
+		}
+		words = nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 12 || fields[0] != "5" { // level 5 为词
+			continue
+		}
+		conf, err := strconv.ParseFloat(fields[10], 64)
+		text := strings.TrimSpace(fields[11])
+		if err != nil || conf < 0 || text == "" {
+			continue
+		}
+
+		key := strings.Join(fields[1:5], "/")
+		if key != lineKey {
+			flush()
+			lineKey = key
+		}
+		words = append(words, text)
+		confSum += conf
+		wordCount++
+	}
+	flush()
+
+	result := &OCRResult{Text: strings.Join(lines, "\n")}
+	if wordCount > 0 {
+		result.Confidence = confSum / float64(wordCount) / 100
+	}
+	return result
+}
@@ -19,8 +19,14 @@ package pdf
 import "github.com/cloudwego/eino/components/document/parser"
 
 type options struct {
-	toPages         *bool
+	toPages          *bool
 	minContentLength *int
+	ocrLanguages     []string
+	extractLayout    *bool
+	password         *string
+	startPage        *int
+	endPage          *int
+	extractMetadata  *bool
 }
 
 // WithToPages is a parser option that specifies whether to parse the PDF into pages.
@@ -37,3 +43,43 @@ func WithMinContentLength(length int) parser.Option {
 		opts.minContentLength = &length
 	})
 }
+
+// WithOCRLanguages is a parser option that specifies the default OCR language hints
+// (e.g. "chi_sim", "eng") for this call. Per-page hints in Config.OCRPageLanguages take precedence.
+func WithOCRLanguages(languages ...string) parser.Option {
+	return parser.WrapImplSpecificOptFn(func(opts *options) {
+		opts.ocrLanguages = languages
+	})
+}
+
+// WithExtractLayout is a parser option that specifies whether to analyze the page layout:
+// tables are emitted as Markdown and multi-column text is read column by column.
+func WithExtractLayout(extractLayout bool) parser.Option {
+	return parser.WrapImplSpecificOptFn(func(opts *options) {
+		opts.extractLayout = &extractLayout
+	})
+}
+
+// WithPassword is a parser option that specifies the user password of an encrypted PDF.
+func WithPassword(password string) parser.Option {
+	return parser.WrapImplSpecificOptFn(func(opts *options) {
+		opts.password = &password
+	})
+}
+
+// WithPageRange is a parser option that specifies the 1-based inclusive range of pages to parse.
+// 0 means the first page for start and the last page for end.
+func WithPageRange(start, end int) parser.Option {
+	return parser.WrapImplSpecificOptFn(func(opts *options) {
+		opts.startPage = &start
+		opts.endPage = &end
+	})
+}
+
+// WithExtractMetadata is a parser option that specifies whether to emit the document metadata
+// (title, author, dates, page count and outline) into MetaData.
+func WithExtractMetadata(extractMetadata bool) parser.Option {
+	return parser.WrapImplSpecificOptFn(func(opts *options) {
+		opts.extractMetadata = &extractMetadata
+	})
+}
@@ -19,6 +19,7 @@ package pdf
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -27,11 +28,40 @@ import (
 	"github.com/cloudwego/eino/components/document/parser"
 	"github.com/cloudwego/eino/schema"
 	"github.com/ledongthuc/pdf"
+	"github.com/sirupsen/logrus"
 )
 
 // Config is the configuration for PDF parser.
 type Config struct {
 	ToPages bool // whether to
+
+	// OCR is an optional OCR backend used for pages that yield no embedded text,
+	// e.g. scanned pages. If nil, such pages are skipped.
+	OCR OCR
+	// OCRLanguages are the default language hints passed to OCR, e.g. []string{"chi_sim", "eng"}.
+	OCRLanguages []string
+	// OCRPageLanguages overrides the language hints for specific pages (1-based page numbers).
+	OCRPageLanguages map[int][]string
+
+	// Logger receives per-page progress, skipped pages and OCR results.
+	// Defaults to logrus.StandardLogger(). Progress is logged at Debug level, skipped pages at Warn level.
+	Logger logrus.FieldLogger
+
+	// ExtractLayout enables layout analysis: tables are detected and emitted as Markdown tables,
+	// multi-column pages are read column by column, lines are kept on separate lines, and
+	// page numbers, table bounding boxes and column counts are recorded in MetaData.
+	ExtractLayout bool
+
+	// Password is the user password used to decrypt password-protected PDFs.
+	// It is also passed to OCR; see TesseractOCR for how its password is exposed.
+	Password string
+	// StartPage and EndPage limit parsing to a 1-based inclusive page range.
+	// 0 means the first page and the last page respectively.
+	StartPage int
+	EndPage   int
+	// ExtractMetadata emits the document info (title, author, subject, keywords, creator, producer,
+	// creation and modification dates), the page count and the outline into MetaData of every document.
+	ExtractMetadata bool
 }
 
 // PDFParser reads from io.Reader and parse its content as plain text.
@@ -39,6 +69,18 @@ type Config struct {
 // For example, it will not preserve whitespace and new line for now.
 type PDFParser struct {
 	ToPages bool
+
+	OCR              OCR
+	OCRLanguages     []string
+	OCRPageLanguages map[int][]string
+	Logger           logrus.FieldLogger
+
+	ExtractLayout bool
+
+	Password        string
+	StartPage       int
+	EndPage         int
+	ExtractMetadata bool
 }
 
 // NewPDFParser creates a new PDF parser.
@@ -46,7 +88,25 @@ func NewPDFParser(ctx context.Context, config *Config) (*PDFParser, error) {
 	if config == nil {
 		config = &Config{}
 	}
-	return &PDFParser{ToPages: config.ToPages}, nil
+	if config.StartPage < 0 || config.EndPage < 0 || (config.EndPage > 0 && config.StartPage > config.EndPage) {
+		return nil, fmt.Errorf("invalid page range [%d, %d]", config.StartPage, config.EndPage)
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = logrus.StandardLogger()
+	}
+	return &PDFParser{
+		ToPages:          config.ToPages,
+		OCR:              config.OCR,
+		OCRLanguages:     config.OCRLanguages,
+		OCRPageLanguages: config.OCRPageLanguages,
+		Logger:           logger,
+		ExtractLayout:    config.ExtractLayout,
+		Password:         config.Password,
+		StartPage:        config.StartPage,
+		EndPage:          config.EndPage,
+		ExtractMetadata:  config.ExtractMetadata,
+	}, nil
 }
 
 // Parse parses the PDF content from io.Reader.
@@ -54,9 +114,20 @@ func (pp *PDFParser) Parse(ctx context.Context, reader io.Reader, opts ...parser
 	commonOpts := parser.GetCommonOptions(nil, opts...)
 
 	specificOpts := parser.GetImplSpecificOptions(&options{
-		toPages: &pp.ToPages,
+		toPages:         &pp.ToPages,
+		ocrLanguages:    pp.OCRLanguages,
+		extractLayout:   &pp.ExtractLayout,
+		password:        &pp.Password,
+		startPage:       &pp.StartPage,
+		endPage:         &pp.EndPage,
+		extractMetadata: &pp.ExtractMetadata,
 	}, opts...)
 
+	logger := pp.Logger
+	if logger == nil {
+		logger = logrus.StandardLogger()
+	}
+
 	data, err := io.ReadAll(reader)
 	if err != nil {
 		return nil, fmt.Errorf("pdf parser read all from reader failed: %w", err)
@@ -64,15 +135,47 @@ func (pp *PDFParser) Parse(ctx context.Context, reader io.Reader, opts ...parser
 
 	readerAt := bytes.NewReader(data)
 
-	f, err := pdf.NewReader(readerAt, int64(readerAt.Len()))
+	// 密码只尝试一次，返回空字符串时 pdf 库会停止重试
+	password := ""
+	if specificOpts.password != nil {
+		password = *specificOpts.password
+	}
+	tried := false
+	f, err := pdf.NewReaderEncrypted(readerAt, int64(readerAt.Len()), func() string {
+		if tried {
+			return ""
+		}
+		tried = true
+		return password
+	})
 	if err != nil {
+		if errors.Is(err, pdf.ErrInvalidPassword) {
+			return nil, fmt.Errorf("pdf is encrypted and the password is missing or incorrect: %w", err)
+		}
 		return nil, fmt.Errorf("create new pdf reader failed: %w", err)
 	}
 
 	pages := f.NumPage()
+	startPage, endPage, err := pageRange(specificOpts.startPage, specificOpts.endPage, pages)
+	if err != nil {
+		return nil, err
+	}
+	if startPage > endPage { // 没有页面
+		return nil, nil
+	}
+
+	// 文档级元数据附加到每个输出文档
+	baseMeta := commonOpts.ExtraMeta
+	if specificOpts.extractMetadata != nil && *specificOpts.extractMetadata {
+		baseMeta = copyMeta(commonOpts.ExtraMeta)
+		for k, v := range readMetadata(f) {
+			baseMeta[k] = v
+		}
+	}
 	var (
-		buf             bytes.Buffer
-		toPages         = specificOpts.toPages != nil && *specificOpts.toPages
+		buf              bytes.Buffer
+		toPages          = specificOpts.toPages != nil && *specificOpts.toPages
+		useLayout        = specificOpts.extractLayout != nil && *specificOpts.extractLayout
 		minContentLength = 100 // 默认值
 	)
 	if specificOpts.minContentLength != nil {
@@ -80,10 +183,15 @@ func (pp *PDFParser) Parse(ctx context.Context, reader io.Reader, opts ...parser
 	}
 	fonts := make(map[string]*pdf.Font)
 	skippedPages := 0
-	for i := 1; i <= pages; i++ {
+	var (
+		ocrPages       []int
+		ocrConfidences = make(map[int]float64)
+		tables         []TableInfo
+	)
+	for i := startPage; i <= endPage; i++ {
 		p := f.Page(i)
 		if p.V.IsNull() { // ledongthuc/pdf.Page is a struct, its internal value V is checked via IsNull()
-			fmt.Printf("[PDF Parser] 警告：页面 %d 无效，跳过\n", i)
+			logger.WithField("page", i).Warn("[PDF Parser] 页面无效，跳过")
 			skippedPages++
 			// 不再创建空文档，直接跳过无效页面
 			continue
@@ -93,9 +201,9 @@ func (pp *PDFParser) Parse(ctx context.Context, reader io.Reader, opts ...parser
 		pageFonts := p.Fonts()
 		if len(pageFonts) == 0 {
 			// 如果没有字体，可能是扫描版PDF（图片），尝试其他方法
-			fmt.Printf("[PDF Parser] 页面 %d: 未检测到字体，可能是扫描版PDF\n", i)
+			logger.WithField("page", i).Debug("[PDF Parser] 未检测到字体，可能是扫描版PDF")
 		} else {
-			fmt.Printf("[PDF Parser] 页面 %d: 检测到 %d 个字体: %v\n", i, len(pageFonts), pageFonts)
+			logger.WithFields(logrus.Fields{"page": i, "fonts": pageFonts}).Debug("[PDF Parser] 检测到字体")
 		}
 
 		for _, name := range pageFonts { // cache fonts so we don't continually parse charmap
@@ -107,42 +215,106 @@ func (pp *PDFParser) Parse(ctx context.Context, reader io.Reader, opts ...parser
 
 		text, err := p.GetPlainText(fonts)
 		if err != nil {
-			// 跳过有问题的页面，继续处理其他页面
-			fmt.Printf("[PDF Parser] 警告：页面 %d 解析失败: %v，跳过此页\n", i, err)
-			skippedPages++
-			// 不再创建空文档，直接跳过解析失败的页面
-			continue
+			if pp.OCR == nil {
+				// 跳过有问题的页面，继续处理其他页面
+				logger.WithField("page", i).WithError(err).Warn("[PDF Parser] 页面解析失败，跳过此页")
+				skippedPages++
+				// 不再创建空文档，直接跳过解析失败的页面
+				continue
+			}
+			logger.WithField("page", i).WithError(err).Warn("[PDF Parser] 页面解析失败，尝试 OCR")
+			text = ""
 		}
 
-		// 先过滤文本，只保留汉字、英文、数字和中英文标点符号
-		filteredText := keepOnlyValidChars(text)
-		// 保留空格，但移除换行符、制表符等其他空白字符，以保持文本结构
-		cleanedText := normalizeWhitespace(filteredText)
+		// 页面没有可提取的文字（通常是扫描版页面）时使用 OCR
+		var (
+			ocrResult    *OCRResult
+			ocrLanguages []string
+		)
+		if pp.OCR != nil && strings.TrimSpace(keepOnlyValidChars(text)) == "" {
+			ocrLanguages = specificOpts.ocrLanguages
+			if langs, ok := pp.OCRPageLanguages[i]; ok {
+				ocrLanguages = langs
+			}
+			ocrResult, err = pp.OCR.Recognize(ctx, &OCRRequest{
+				PDF:        data,
+				PageNumber: i,
+				Languages:  ocrLanguages,
+				Password:   password,
+			})
+			if err != nil {
+				logger.WithField("page", i).WithError(err).Warn("[PDF Parser] OCR 失败，跳过此页")
+				skippedPages++
+				continue
+			}
+			logger.WithFields(logrus.Fields{"page": i, "confidence": ocrResult.Confidence}).Debug("[PDF Parser] OCR 识别完成")
+			text = ocrResult.Text
+		}
+
+		var (
+			cleanedText string
+			layout      pageLayout
+		)
+		if useLayout && ocrResult == nil {
+			// 版面模式：按行输出，表格输出为 Markdown
+			layout = extractLayout(p, i)
+			cleanedText = layout.text
+			tables = append(tables, layout.tables...)
+		} else {
+			// 先过滤文本，只保留汉字、英文、数字和中英文标点符号
+			filteredText := keepOnlyValidChars(text)
+			// 保留空格，但移除换行符、制表符等其他空白字符，以保持文本结构
+			cleanedText = normalizeWhitespace(filteredText)
+		}
 
 		// 调试：显示提取到的文本信息
 		textLength := len(cleanedText)
-		fmt.Printf("[PDF Parser] 页面 %d: 原始文本长度=%d, 去除所有空白后长度=%d\n", i, len(text), textLength)
+		logger.WithFields(logrus.Fields{"page": i, "raw_length": len(text), "length": textLength}).Debug("[PDF Parser] 提取到文本")
 
 		// 只处理超过最小内容长度的页面
 		if textLength < minContentLength {
-			fmt.Printf("[PDF Parser] 页面 %d: 内容长度 %d < %d，跳过此页\n", i, textLength, minContentLength)
+			logger.WithFields(logrus.Fields{"page": i, "length": textLength, "min_length": minContentLength}).Debug("[PDF Parser] 内容过短，跳过此页")
 			skippedPages++
 			// 不再创建空文档，直接跳过内容过短的页面
 			continue
 		}
 
 		if textLength > 0 && textLength <= 50 {
-			fmt.Printf("[PDF Parser] 页面 %d: 文本预览=%q\n", i, cleanedText[:minInt(textLength, 100)])
+			logger.WithFields(logrus.Fields{"page": i, "preview": cleanedText[:minInt(textLength, 100)]}).Debug("[PDF Parser] 文本预览")
 		} else if textLength > 100 {
-			fmt.Printf("[PDF Parser] 页面 %d: 文本预览（前100字符）=%q\n", i, cleanedText[:100])
+			logger.WithFields(logrus.Fields{"page": i, "preview": cleanedText[:100]}).Debug("[PDF Parser] 文本预览（前100字符）")
 		}
 
 		if toPages {
+			metaData := baseMeta
+			if ocrResult != nil || useLayout {
+				metaData = copyMeta(baseMeta)
+			}
+			if useLayout {
+				metaData[MetaKeyPage] = i
+				if ocrResult == nil {
+					metaData[MetaKeyColumns] = layout.columns
+				}
+				if len(layout.tables) > 0 {
+					metaData[MetaKeyTables] = layout.tables
+				}
+			}
+			if ocrResult != nil {
+				metaData[MetaKeyOCR] = true
+				metaData[MetaKeyOCRConfidence] = ocrResult.Confidence
+				if len(ocrLanguages) > 0 {
+					metaData[MetaKeyOCRLanguages] = ocrLanguages
+				}
+			}
 			docs = append(docs, &schema.Document{
 				Content:  cleanedText,
-				MetaData: commonOpts.ExtraMeta,
+				MetaData: metaData,
 			})
 		} else {
+			if ocrResult != nil {
+				ocrPages = append(ocrPages, i)
+				ocrConfidences[i] = ocrResult.Confidence
+			}
 			// 合并模式：添加页面分隔符，便于后续分割时识别页面边界
 			if buf.Len() > 0 {
 				buf.WriteString("\n\n--- 页面 " + fmt.Sprintf("%d", i) + " ---\n\n")
@@ -153,19 +325,71 @@ func (pp *PDFParser) Parse(ctx context.Context, reader io.Reader, opts ...parser
 
 	// 输出统计信息
 	if skippedPages > 0 {
-		fmt.Printf("[PDF Parser] 完成：总共 %d 页，成功解析 %d 页，跳过 %d 页\n", pages, pages-skippedPages, skippedPages)
+		parsedPages := endPage - startPage + 1
+		logger.WithFields(logrus.Fields{
+			"pages":   parsedPages,
+			"parsed":  parsedPages - skippedPages,
+			"skipped": skippedPages,
+		}).Info("[PDF Parser] 解析完成")
 	}
 
 	if !toPages {
+		metaData := baseMeta
+		if len(ocrPages) > 0 || len(tables) > 0 {
+			metaData = copyMeta(baseMeta)
+		}
+		if len(ocrPages) > 0 {
+			metaData[MetaKeyOCRPages] = ocrPages
+			metaData[MetaKeyOCRConfidences] = ocrConfidences
+		}
+		if len(tables) > 0 {
+			metaData[MetaKeyTables] = tables
+		}
 		docs = append(docs, &schema.Document{
 			Content:  buf.String(),
-			MetaData: commonOpts.ExtraMeta,
+			MetaData: metaData,
 		})
 	}
 
 	return docs, nil
 }
 
+// pageRange 计算实际解析的页码范围（1-based，闭区间），0 表示不限制；PDF 没有页面时返回空范围 [1, 0]
+func pageRange(startOpt, endOpt *int, pages int) (int, int, error) {
+	start, end := 0, 0
+	if startOpt != nil {
+		start = *startOpt
+	}
+	if endOpt != nil {
+		end = *endOpt
+	}
+	if start < 0 || end < 0 || (end > 0 && start > end) {
+		return 0, 0, fmt.Errorf("invalid page range [%d, %d]", start, end)
+	}
+	if pages == 0 {
+		return 1, 0, nil
+	}
+	if start == 0 {
+		start = 1
+	}
+	if end == 0 || end > pages {
+		end = pages
+	}
+	if start > end {
+		return 0, 0, fmt.Errorf("start page %d exceeds page count %d", start, pages)
+	}
+	return start, end, nil
+}
+
+// copyMeta 复制 MetaData，避免修改调用方传入的 ExtraMeta
+func copyMeta(meta map[string]any) map[string]any {
+	ret := make(map[string]any, len(meta)+2)
+	for k, v := range meta {
+		ret[k] = v
+	}
+	return ret
+}
+
 func minInt(a, b int) int {
 	if a < b {
 		return a
@@ -17,11 +17,18 @@
 package pdf
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/cloudwego/eino/components/document/parser"
+	"github.com/ledongthuc/pdf"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -44,3 +51,343 @@ func TestLoader_Load(t *testing.T) {
 		assert.Equal(t, map[string]any{"test": "test"}, docs[1].MetaData)
 	})
 }
+
+type mockOCR struct {
+	requests []*OCRRequest
+	err      error
+}
+
+func (m *mockOCR) Recognize(ctx context.Context, req *OCRRequest) (*OCRResult, error) {
+	m.requests = append(m.requests, req)
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &OCRResult{Text: fmt.Sprintf("扫描页面 %d 的识别文字", req.PageNumber), Confidence: 0.9}, nil
+}
+
+func TestPDFParser_OCR(t *testing.T) {
+	ctx := context.Background()
+	data := buildTestPDF(
+		[]testLine{{X: 72, Y: 720, Text: "This page has embedded text."}},
+		nil, // 扫描页面：没有可提取的文字
+		nil,
+	)
+
+	t.Run("pages", func(t *testing.T) {
+		ocr := &mockOCR{}
+		p, err := NewPDFParser(ctx, &Config{
+			OCR:              ocr,
+			OCRLanguages:     []string{"chi_sim", "eng"},
+			OCRPageLanguages: map[int][]string{3: {"eng"}},
+		})
+		assert.NoError(t, err)
+
+		extra := map[string]any{"test": "test"}
+		docs, err := p.Parse(ctx, bytes.NewReader(data), WithToPages(true), WithMinContentLength(0), parser.WithExtraMeta(extra))
+		assert.NoError(t, err)
+		assert.Equal(t, 3, len(docs))
+
+		// 只有没有文字的页面使用 OCR
+		assert.Equal(t, 2, len(ocr.requests))
+		assert.Equal(t, 2, ocr.requests[0].PageNumber)
+		assert.Equal(t, []string{"chi_sim", "eng"}, ocr.requests[0].Languages)
+		assert.Equal(t, []string{"eng"}, ocr.requests[1].Languages)
+		assert.Equal(t, data, ocr.requests[0].PDF)
+
+		assert.Equal(t, "This page has embedded text.", docs[0].Content)
+		assert.Equal(t, extra, docs[0].MetaData)
+		assert.Equal(t, "扫描页面 2 的识别文字", docs[1].Content)
+		assert.Equal(t, true, docs[1].MetaData[MetaKeyOCR])
+		assert.Equal(t, 0.9, docs[1].MetaData[MetaKeyOCRConfidence])
+		assert.Equal(t, "test", docs[1].MetaData["test"])
+		// 不修改调用方传入的 ExtraMeta
+		assert.Equal(t, map[string]any{"test": "test"}, extra)
+	})
+
+	t.Run("merged", func(t *testing.T) {
+		p, err := NewPDFParser(ctx, &Config{OCR: &mockOCR{}})
+		assert.NoError(t, err)
+
+		docs, err := p.Parse(ctx, bytes.NewReader(data), WithMinContentLength(0), WithOCRLanguages("jpn"))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(docs))
+		assert.Contains(t, docs[0].Content, "扫描页面 3 的识别文字")
+		assert.Equal(t, []int{2, 3}, docs[0].MetaData[MetaKeyOCRPages])
+		assert.Equal(t, map[int]float64{2: 0.9, 3: 0.9}, docs[0].MetaData[MetaKeyOCRConfidences])
+	})
+
+	t.Run("OCR failure is logged", func(t *testing.T) {
+		var logs bytes.Buffer
+		logger := logrus.New()
+		logger.SetOutput(&logs)
+		p, err := NewPDFParser(ctx, &Config{OCR: &mockOCR{err: errors.New("tesseract not found")}, Logger: logger})
+		assert.NoError(t, err)
+
+		docs, err := p.Parse(ctx, bytes.NewReader(data), WithToPages(true), WithMinContentLength(0))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(docs))
+		assert.Contains(t, logs.String(), "OCR 失败")
+		assert.Contains(t, logs.String(), "page=2")
+		assert.Contains(t, logs.String(), "tesseract not found")
+	})
+
+	t.Run("without OCR", func(t *testing.T) {
+		p, err := NewPDFParser(ctx, nil)
+		assert.NoError(t, err)
+		docs, err := p.Parse(ctx, bytes.NewReader(data), WithToPages(true), WithMinContentLength(1))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(docs))
+	})
+}
+
+func TestParseTesseractTSV(t *testing.T) {
+	tsv := "level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext\n" +
+		"1\t1\t0\t0\t0\t0\t0\t0\t600\t800\t-1\t\n" +
+		"5\t1\t1\t1\t1\t1\t10\t10\t50\t20\t90\tHello\n" +
+		"5\t1\t1\t1\t1\t2\t70\t10\t50\t20\t80\tworld\n" +
+		"5\t1\t1\t1\t2\t1\t10\t40\t50\t20\t70\t你好\n" +
+		"5\t1\t1\t1\t2\t2\t70\t40\t50\t20\t-1\t \n"
+
+	result := parseTesseractTSV([]byte(tsv))
+	assert.Equal(t, "Hello world\n你好", result.Text)
+	assert.InDelta(t, 0.8, result.Confidence, 1e-9)
+}
+
+func TestPDFParser_ExtractLayout(t *testing.T) {
+	ctx := context.Background()
+	data := buildTestPDF(
+		[]testLine{
+			{X: 72, Y: 740, Text: "Quarterly Report"},
+			{X: 72, Y: 700, Text: "Region"}, {X: 200, Y: 700, Text: "Sales"}, {X: 330, Y: 700, Text: "Growth"},
+			{X: 72, Y: 684, Text: "North"}, {X: 200, Y: 684, Text: "1200"}, {X: 330, Y: 684, Text: "5%"},
+			{X: 72, Y: 668, Text: "South"}, {X: 200, Y: 668, Text: "900"}, {X: 330, Y: 668, Text: "-2%"},
+			{X: 72, Y: 630, Text: "The table above lists sales by region for the quarter."},
+		},
+		[]testLine{
+			{X: 72, Y: 740, Text: "A Title That Spans Across Both Columns Of The Page"},
+			{X: 72, Y: 710, Text: "Left column text, first line."}, {X: 320, Y: 710, Text: "Right column text, first line."},
+			{X: 72, Y: 696, Text: "Left column text, second line."}, {X: 320, Y: 696, Text: "Right column text, second line."},
+			{X: 72, Y: 682, Text: "Left column text, third line."}, {X: 320, Y: 682, Text: "Right column text, third line."},
+			{X: 72, Y: 668, Text: "Left column end."},
+		},
+	)
+
+	t.Run("pages", func(t *testing.T) {
+		p, err := NewPDFParser(ctx, &Config{ExtractLayout: true})
+		assert.NoError(t, err)
+		docs, err := p.Parse(ctx, bytes.NewReader(data), WithToPages(true), WithMinContentLength(0))
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(docs))
+
+		assert.Equal(t, "Quarterly Report\n"+
+			"| Region | Sales | Growth |\n"+
+			"| --- | --- | --- |\n"+
+			"| North | 1200 | 5% |\n"+
+			"| South | 900 | -2% |\n"+
+			"The table above lists sales by region for the quarter.", docs[0].Content)
+		assert.Equal(t, 1, docs[0].MetaData[MetaKeyPage])
+		assert.Equal(t, 1, docs[0].MetaData[MetaKeyColumns])
+		tables, ok := docs[0].MetaData[MetaKeyTables].([]TableInfo)
+		assert.True(t, ok)
+		assert.Equal(t, 1, len(tables))
+		assert.Equal(t, TableInfo{Page: 1, Rows: 3, Columns: 3, BBox: BBox{X0: 72, Y0: 668, X1: 366, Y1: 712}}, tables[0])
+
+		// 双栏：先左栏后右栏，跨栏标题保持在最前面
+		assert.Equal(t, "A Title That Spans Across Both Columns Of The Page\n"+
+			"Left column text, first line.\n"+
+			"Left column text, second line.\n"+
+			"Left column text, third line.\n"+
+			"Left column end.\n"+
+			"Right column text, first line.\n"+
+			"Right column text, second line.\n"+
+			"Right column text, third line.", docs[1].Content)
+		assert.Equal(t, 2, docs[1].MetaData[MetaKeyPage])
+		assert.Equal(t, 2, docs[1].MetaData[MetaKeyColumns])
+		assert.Nil(t, docs[1].MetaData[MetaKeyTables])
+	})
+
+	t.Run("merged", func(t *testing.T) {
+		p, err := NewPDFParser(ctx, nil)
+		assert.NoError(t, err)
+		docs, err := p.Parse(ctx, bytes.NewReader(data), WithMinContentLength(0), WithExtractLayout(true))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(docs))
+		assert.Contains(t, docs[0].Content, "| North | 1200 | 5% |")
+		tables, ok := docs[0].MetaData[MetaKeyTables].([]TableInfo)
+		assert.True(t, ok)
+		assert.Equal(t, 1, len(tables))
+	})
+}
+
+func TestPDFParser_PageRange(t *testing.T) {
+	ctx := context.Background()
+	data := buildTestPDF(
+		[]testLine{{X: 72, Y: 720, Text: "Page one"}},
+		[]testLine{{X: 72, Y: 720, Text: "Page two"}},
+		[]testLine{{X: 72, Y: 720, Text: "Page three"}},
+	)
+
+	t.Run("config", func(t *testing.T) {
+		p, err := NewPDFParser(ctx, &Config{ToPages: true, StartPage: 2, EndPage: 3})
+		assert.NoError(t, err)
+		docs, err := p.Parse(ctx, bytes.NewReader(data), WithMinContentLength(0))
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(docs))
+		assert.Equal(t, "Page two", docs[0].Content)
+		assert.Equal(t, "Page three", docs[1].Content)
+	})
+
+	t.Run("option overrides config and end is clamped", func(t *testing.T) {
+		p, err := NewPDFParser(ctx, &Config{ToPages: true, StartPage: 2, EndPage: 2})
+		assert.NoError(t, err)
+		docs, err := p.Parse(ctx, bytes.NewReader(data), WithMinContentLength(0), WithPageRange(3, 10))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(docs))
+		assert.Equal(t, "Page three", docs[0].Content)
+	})
+
+	t.Run("invalid range", func(t *testing.T) {
+		_, err := NewPDFParser(ctx, &Config{StartPage: 3, EndPage: 2})
+		assert.Error(t, err)
+
+		p, err := NewPDFParser(ctx, nil)
+		assert.NoError(t, err)
+		_, err = p.Parse(ctx, bytes.NewReader(data), WithPageRange(4, 0))
+		assert.Error(t, err)
+	})
+
+	t.Run("no pages", func(t *testing.T) {
+		start, end, err := pageRange(nil, nil, 0)
+		assert.NoError(t, err)
+		assert.True(t, start > end)
+
+		p, err := NewPDFParser(ctx, nil)
+		assert.NoError(t, err)
+		docs, err := p.Parse(ctx, bytes.NewReader(buildTestPDF()))
+		assert.NoError(t, err)
+		assert.Empty(t, docs)
+	})
+}
+
+func TestPDFParser_Password(t *testing.T) {
+	ctx := context.Background()
+	data := testPDF{
+		pages:    [][]testLine{{{X: 72, Y: 720, Text: "Top secret content"}}},
+		password: "s3cret",
+	}.build()
+
+	t.Run("correct password", func(t *testing.T) {
+		p, err := NewPDFParser(ctx, &Config{Password: "s3cret"})
+		assert.NoError(t, err)
+		docs, err := p.Parse(ctx, bytes.NewReader(data), WithMinContentLength(0))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(docs))
+		assert.Equal(t, "Top secret content", docs[0].Content)
+	})
+
+	t.Run("password option", func(t *testing.T) {
+		p, err := NewPDFParser(ctx, nil)
+		assert.NoError(t, err)
+		docs, err := p.Parse(ctx, bytes.NewReader(data), WithMinContentLength(0), WithPassword("s3cret"))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(docs))
+	})
+
+	t.Run("missing or wrong password", func(t *testing.T) {
+		p, err := NewPDFParser(ctx, nil)
+		assert.NoError(t, err)
+		_, err = p.Parse(ctx, bytes.NewReader(data))
+		assert.ErrorIs(t, err, pdf.ErrInvalidPassword)
+
+		_, err = p.Parse(ctx, bytes.NewReader(data), WithPassword("wrong"))
+		assert.ErrorIs(t, err, pdf.ErrInvalidPassword)
+	})
+
+	t.Run("ocr receives password", func(t *testing.T) {
+		scanned := testPDF{
+			pages:    [][]testLine{nil},
+			password: "s3cret",
+		}.build()
+		ocr := &mockOCR{}
+		p, err := NewPDFParser(ctx, &Config{Password: "s3cret", OCR: ocr})
+		assert.NoError(t, err)
+		_, err = p.Parse(ctx, bytes.NewReader(scanned), WithMinContentLength(0))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(ocr.requests))
+		assert.Equal(t, "s3cret", ocr.requests[0].Password)
+	})
+
+	t.Run("pdftoppm user password", func(t *testing.T) {
+		// 用脚本代替 pdftoppm，记录参数后失败
+		dir := t.TempDir()
+		argsFile := filepath.Join(dir, "args")
+		script := filepath.Join(dir, "pdftoppm")
+		assert.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\necho \"$@\" > "+argsFile+"\nexit 1\n"), 0o755))
+
+		ocr := &TesseractOCR{PdftoppmPath: script}
+		_, err := ocr.Recognize(ctx, &OCRRequest{PDF: data, PageNumber: 1, Password: "s3cret"})
+		assert.Error(t, err)
+		args, err := os.ReadFile(argsFile)
+		assert.NoError(t, err)
+		assert.Contains(t, string(args), "-upw s3cret")
+	})
+}
+
+func TestPDFParser_ExtractMetadata(t *testing.T) {
+	ctx := context.Background()
+	data := testPDF{
+		pages: [][]testLine{
+			{{X: 72, Y: 720, Text: "Introduction"}},
+			{{X: 72, Y: 720, Text: "Details"}},
+		},
+		info: map[string]string{
+			"Title":        "Annual Report",
+			"Author":       "张三",
+			"Keywords":     "report, finance",
+			"CreationDate": "D:20240131083000+08'00'",
+			"ModDate":      "D:20240201Z",
+		},
+		outline: []testOutline{
+			{Title: "Chapter 1", Children: []testOutline{{Title: "Section 1.1"}}},
+			{Title: "Chapter 2"},
+		},
+	}.build()
+
+	p, err := NewPDFParser(ctx, &Config{ExtractMetadata: true})
+	assert.NoError(t, err)
+
+	extra := map[string]any{"source": "report.pdf"}
+	docs, err := p.Parse(ctx, bytes.NewReader(data), WithToPages(true), WithMinContentLength(0),
+		parser.WithExtraMeta(extra))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(docs))
+	assert.Equal(t, 1, len(extra))
+
+	for _, doc := range docs {
+		assert.Equal(t, "report.pdf", doc.MetaData["source"])
+		assert.Equal(t, "Annual Report", doc.MetaData[MetaKeyTitle])
+		assert.Equal(t, "张三", doc.MetaData[MetaKeyAuthor])
+		assert.Equal(t, "report, finance", doc.MetaData[MetaKeyKeywords])
+		assert.Nil(t, doc.MetaData[MetaKeySubject])
+		assert.Equal(t, 2, doc.MetaData[MetaKeyPageCount])
+		assert.Equal(t, []OutlineEntry{
+			{Title: "Chapter 1", Level: 1},
+			{Title: "Section 1.1", Level: 2},
+			{Title: "Chapter 2", Level: 1},
+		}, doc.MetaData[MetaKeyOutline])
+
+		created, ok := doc.MetaData[MetaKeyCreationDate].(time.Time)
+		assert.True(t, ok)
+		assert.True(t, created.Equal(time.Date(2024, 1, 31, 0, 30, 0, 0, time.UTC)))
+		modified, ok := doc.MetaData[MetaKeyModDate].(time.Time)
+		assert.True(t, ok)
+		assert.True(t, modified.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)))
+	}
+
+	// 默认不输出元数据
+	p, err = NewPDFParser(ctx, nil)
+	assert.NoError(t, err)
+	docs, err = p.Parse(ctx, bytes.NewReader(data), WithMinContentLength(0))
+	assert.NoError(t, err)
+	assert.Nil(t, docs[0].MetaData[MetaKeyTitle])
+}
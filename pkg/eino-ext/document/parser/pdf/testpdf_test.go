@@ -0,0 +1,230 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pdf
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rc4"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// testLine 测试 PDF 中的一行文字
+type testLine struct {
+	X, Y float64
+	Text string
+}
+
+// testOutline 测试 PDF 中的一个书签
+type testOutline struct {
+	Title    string
+	Children []testOutline
+}
+
+// testPDF 描述一个测试用 PDF
+type testPDF struct {
+	pages    [][]testLine
+	info     map[string]string // 文档信息字典，如 Title、Author、CreationDate
+	outline  []testOutline
+	password string // 非空时使用 RC4 128 位（V2 R3）加密
+}
+
+// buildTestPDF 生成一个简单的 PDF：每个元素为一页，页面中的每一行文字使用 Helvetica 绘制在指定坐标；
+// 没有文字的页面不包含字体，模拟扫描版页面
+func buildTestPDF(pages ...[]testLine) []byte {
+	return testPDF{pages: pages}.build()
+}
+
+// testPasswordPad PDF 标准安全处理器的密码填充串
+var testPasswordPad = []byte{
+	0x28, 0xBF, 0x4E, 0x5E, 0x4E, 0x75, 0x8A, 0x41, 0x64, 0x00, 0x4E, 0x56, 0xFF, 0xFA, 0x01, 0x08,
+	0x2E, 0x2E, 0x00, 0xB6, 0xD0, 0x68, 0x3E, 0x80, 0x2F, 0x0C, 0xA9, 0xFE, 0x64, 0x53, 0x69, 0x7A,
+}
+
+func (tp testPDF) build() []byte {
+	var objects []string // objects[i] 为第 i+1 个对象
+	reserve := func() int {
+		objects = append(objects, "")
+		return len(objects)
+	}
+
+	id := []byte("0123456789abcdef")
+	var key, o, u []byte
+	if tp.password != "" {
+		key, o, u = testEncryptionKey(tp.password, id)
+	}
+	crypt := func(obj int, data string) string {
+		if key == nil {
+			return data
+		}
+		h := md5.New()
+		h.Write(key)
+		h.Write([]byte{byte(obj), byte(obj >> 8), byte(obj >> 16), 0, 0})
+		c, _ := rc4.NewCipher(h.Sum(nil))
+		out := []byte(data)
+		c.XORKeyStream(out, out)
+		return string(out)
+	}
+	// 文本字符串：非 ASCII 内容使用带 BOM 的 UTF-16BE 编码
+	str := func(obj int, s string) string {
+		for _, r := range s {
+			if r > unicode.MaxASCII {
+				encoded := []byte{0xFE, 0xFF}
+				for _, u := range utf16.Encode([]rune(s)) {
+					encoded = append(encoded, byte(u>>8), byte(u))
+				}
+				s = string(encoded)
+				break
+			}
+		}
+		return "<" + hex.EncodeToString([]byte(crypt(obj, s))) + ">"
+	}
+
+	catalog := reserve()
+	pagesObj := reserve()
+	font := reserve()
+	// 所有字符宽度均为 500（字号的一半），使字符坐标与实际排版一致
+	objects[font-1] = fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding /FirstChar 32 /LastChar 126 /Widths [%s] >>",
+		strings.TrimSpace(strings.Repeat("500 ", 126-32+1)))
+
+	pageRefs := make([]string, 0, len(tp.pages))
+	for _, lines := range tp.pages {
+		pageObj := reserve()
+		contentObj := reserve()
+		pageRefs = append(pageRefs, fmt.Sprintf("%d 0 R", pageObj))
+
+		var content strings.Builder
+		for _, l := range lines {
+			text := strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(l.Text)
+			fmt.Fprintf(&content, "BT /F1 12 Tf %g %g Td (%s) Tj ET\n", l.X, l.Y, text)
+		}
+		resources := "<< >>"
+		if len(lines) > 0 {
+			resources = fmt.Sprintf("<< /Font << /F1 %d 0 R >> >>", font)
+		}
+		data := crypt(contentObj, content.String())
+		objects[pageObj-1] = fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 612 792] /Resources %s /Contents %d 0 R >>", pagesObj, resources, contentObj)
+		objects[contentObj-1] = fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(data), data)
+	}
+	objects[pagesObj-1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(pageRefs, " "), len(tp.pages))
+
+	catalogDict := fmt.Sprintf("/Type /Catalog /Pages %d 0 R", pagesObj)
+	if len(tp.outline) > 0 {
+		var addOutline func(parent int, items []testOutline) (first, last int)
+		addOutline = func(parent int, items []testOutline) (first, last int) {
+			nums := make([]int, len(items))
+			for i := range items {
+				nums[i] = reserve()
+			}
+			for i, item := range items {
+				dict := fmt.Sprintf("/Title %s /Parent %d 0 R", str(nums[i], item.Title), parent)
+				if i > 0 {
+					dict += fmt.Sprintf(" /Prev %d 0 R", nums[i-1])
+				}
+				if i+1 < len(items) {
+					dict += fmt.Sprintf(" /Next %d 0 R", nums[i+1])
+				}
+				if len(item.Children) > 0 {
+					f, l := addOutline(nums[i], item.Children)
+					dict += fmt.Sprintf(" /First %d 0 R /Last %d 0 R /Count %d", f, l, len(item.Children))
+				}
+				objects[nums[i]-1] = "<< " + dict + " >>"
+			}
+			return nums[0], nums[len(nums)-1]
+		}
+		outlines := reserve()
+		first, last := addOutline(outlines, tp.outline)
+		objects[outlines-1] = fmt.Sprintf("<< /Type /Outlines /First %d 0 R /Last %d 0 R /Count %d >>", first, last, len(tp.outline))
+		catalogDict += fmt.Sprintf(" /Outlines %d 0 R", outlines)
+	}
+	objects[catalog-1] = "<< " + catalogDict + " >>"
+
+	trailer := fmt.Sprintf("/Root %d 0 R /ID [<%x> <%x>]", catalog, id, id)
+	if len(tp.info) > 0 {
+		infoObj := reserve()
+		keys := make([]string, 0, len(tp.info))
+		for k := range tp.info {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var dict strings.Builder
+		for _, k := range keys {
+			fmt.Fprintf(&dict, "/%s %s ", k, str(infoObj, tp.info[k]))
+		}
+		objects[infoObj-1] = "<< " + dict.String() + ">>"
+		trailer += fmt.Sprintf(" /Info %d 0 R", infoObj)
+	}
+	if key != nil {
+		trailer += fmt.Sprintf(" /Encrypt << /Filter /Standard /V 2 /R 3 /Length 128 /P -4 /O <%x> /U <%x> >>", o, u)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d %s >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, trailer, xref)
+	return buf.Bytes()
+}
+
+// testEncryptionKey 按 PDF 标准安全处理器（V2 R3，128 位）计算加密密钥以及 O、U 值
+// O 值只参与密钥计算，这里直接使用填充串
+func testEncryptionKey(password string, id []byte) (key, o, u []byte) {
+	pw := []byte(password)
+	padded := append(append([]byte{}, pw...), testPasswordPad[:32-len(pw)]...)
+	o = append([]byte{}, testPasswordPad...)
+	p := uint32(0xFFFFFFFC) // P = -4
+
+	h := md5.New()
+	h.Write(padded)
+	h.Write(o)
+	h.Write([]byte{byte(p), byte(p >> 8), byte(p >> 16), byte(p >> 24)})
+	h.Write(id)
+	key = h.Sum(nil)
+	for i := 0; i < 50; i++ {
+		sum := md5.Sum(key[:16])
+		key = sum[:]
+	}
+	key = key[:16]
+
+	h.Reset()
+	h.Write(testPasswordPad)
+	h.Write(id)
+	u = h.Sum(nil)
+	for i := 0; i <= 19; i++ {
+		k := make([]byte, len(key))
+		for j := range key {
+			k[j] = key[j] ^ byte(i)
+		}
+		c, _ := rc4.NewCipher(k)
+		c.XORKeyStream(u, u)
+	}
+	u = append(u, make([]byte, 16)...)
+	return key, o, u
+}
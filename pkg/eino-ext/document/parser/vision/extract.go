@@ -0,0 +1,127 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vision
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ExtractOfficeImages extracts the embedded images of an Office Open XML package (DOCX, PPTX or XLSX),
+// i.e. the files under word/media, ppt/media or xl/media, sorted by path.
+func ExtractOfficeImages(data []byte) ([]Image, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("open office package failed: %w", err)
+	}
+
+	var images []Image
+	for _, f := range zr.File {
+		dir := path.Dir(f.Name)
+		if f.FileInfo().IsDir() || (dir != "word/media" && dir != "ppt/media" && dir != "xl/media") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("open %s failed: %w", f.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read %s failed: %w", f.Name, err)
+		}
+		images = append(images, Image{Data: content, Source: f.Name})
+	}
+	sort.Slice(images, func(i, j int) bool {
+		return images[i].Source < images[j].Source
+	})
+	return images, nil
+}
+
+// PDFImageExtractor extracts the embedded images of a PDF with the pdfimages command line tool (poppler-utils).
+type PDFImageExtractor struct {
+	// PdfimagesPath is the pdfimages executable. Default is "pdfimages".
+	PdfimagesPath string
+}
+
+// Extract 使用 pdfimages -png -p 导出所有图片，文件名形如 img-001-000.png（页码-序号）
+func (e *PDFImageExtractor) Extract(ctx context.Context, pdf []byte) ([]Image, error) {
+	pdfimages := e.PdfimagesPath
+	if pdfimages == "" {
+		pdfimages = "pdfimages"
+	}
+
+	dir, err := os.MkdirTemp("", "pdf-images-*")
+	if err != nil {
+		return nil, fmt.Errorf("create temp dir failed: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	pdfPath := filepath.Join(dir, "input.pdf")
+	if err := os.WriteFile(pdfPath, pdf, 0o600); err != nil {
+		return nil, fmt.Errorf("write temp pdf failed: %w", err)
+	}
+	outDir := filepath.Join(dir, "out")
+	if err := os.Mkdir(outDir, 0o700); err != nil {
+		return nil, fmt.Errorf("create output dir failed: %w", err)
+	}
+	if out, err := exec.CommandContext(ctx, pdfimages, "-png", "-p", pdfPath, filepath.Join(outDir, "img")).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("pdfimages failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	entries, err := os.ReadDir(outDir)
+	if err != nil {
+		return nil, fmt.Errorf("read output dir failed: %w", err)
+	}
+	var images []Image
+	for _, entry := range entries {
+		content, err := os.ReadFile(filepath.Join(outDir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read %s failed: %w", entry.Name(), err)
+		}
+		images = append(images, Image{
+			Data:   content,
+			Source: entry.Name(),
+			Page:   pdfimagesPage(entry.Name()),
+		})
+	}
+	return images, nil
+}
+
+// pdfimagesPage 从 pdfimages -p 输出的文件名（img-PPP-NNN.ext）中解析页码，无法解析时返回 0
+func pdfimagesPage(name string) int {
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	parts := strings.Split(name, "-")
+	if len(parts) < 3 {
+		return 0
+	}
+	page, err := strconv.Atoi(parts[len(parts)-2])
+	if err != nil {
+		return 0
+	}
+	return page
+}
@@ -0,0 +1,241 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vision
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/cloudwego/eino/components/document/parser"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// MetaKeyImageSource is the MetaData key of the source of the image, e.g. the file URI or
+	// the path of the image inside a DOCX package
+	MetaKeyImageSource = "image_source"
+	// MetaKeyImageMIME is the MetaData key of the MIME type of the image
+	MetaKeyImageMIME = "image_mime"
+	// MetaKeyImageWidth is the MetaData key of the image width in pixels, if it can be decoded
+	MetaKeyImageWidth = "image_width"
+	// MetaKeyImageHeight is the MetaData key of the image height in pixels, if it can be decoded
+	MetaKeyImageHeight = "image_height"
+	// MetaKeyImagePage is the MetaData key of the 1-based page number the image was extracted from
+	MetaKeyImagePage = "image_page"
+	// MetaKeyImageIndex is the MetaData key of the index of the image in the input of Describe
+	MetaKeyImageIndex = "image_index"
+)
+
+// DefaultPrompt is the default instruction sent to the vision model with each image
+const DefaultPrompt = "请详细描述这张图片的内容，用于后续的检索。如果是图表，请说明图表类型、坐标轴、关键数据和趋势；" +
+	"如果是流程图或架构图，请说明各个组成部分及其关系；如果图片中包含文字，请完整转录。只输出描述本身。"
+
+// supportedMIMETypes 视觉模型普遍支持的图片格式
+var supportedMIMETypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// Image is an image to be described
+type Image struct {
+	Data []byte
+	// Source identifies where the image comes from, e.g. "word/media/image1.png"
+	Source string
+	// Page is the 1-based page number the image was extracted from, 0 if unknown
+	Page int
+}
+
+// Config is the configuration for vision parser.
+type Config struct {
+	// ChatModel is a vision-capable chat model, e.g. an OpenAI-compatible gpt-4o or qwen-vl model. Required.
+	ChatModel model.BaseChatModel
+	// Prompt is the instruction sent with each image. Default is DefaultPrompt.
+	Prompt string
+	// Detail is the image detail level passed to the model. Default is schema.ImageURLDetailAuto.
+	Detail schema.ImageURLDetail
+	// MinWidth and MinHeight skip smaller images in Describe, e.g. icons and bullets extracted from
+	// documents. Images whose size cannot be decoded (e.g. webp) are never skipped. 0 means no limit.
+	MinWidth  int
+	MinHeight int
+	// Logger receives debug events for images skipped by Describe.
+	// Defaults to logrus.StandardLogger(). Events are logged at Debug level.
+	Logger logrus.FieldLogger
+}
+
+// VisionParser describes images with a vision model and emits the descriptions as documents,
+// making the content of figures searchable.
+type VisionParser struct {
+	ChatModel model.BaseChatModel
+	Prompt    string
+	Detail    schema.ImageURLDetail
+	MinWidth  int
+	MinHeight int
+	Logger    logrus.FieldLogger
+}
+
+// NewVisionParser creates a new vision parser.
+func NewVisionParser(ctx context.Context, config *Config) (*VisionParser, error) {
+	if config == nil || config.ChatModel == nil {
+		return nil, fmt.Errorf("chat model is required")
+	}
+	prompt := config.Prompt
+	if prompt == "" {
+		prompt = DefaultPrompt
+	}
+	detail := config.Detail
+	if detail == "" {
+		detail = schema.ImageURLDetailAuto
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = logrus.StandardLogger()
+	}
+	return &VisionParser{
+		ChatModel: config.ChatModel,
+		Prompt:    prompt,
+		Detail:    detail,
+		MinWidth:  config.MinWidth,
+		MinHeight: config.MinHeight,
+		Logger:    logger,
+	}, nil
+}
+
+// Parse describes the image read from io.Reader and returns a single document.
+func (vp *VisionParser) Parse(ctx context.Context, reader io.Reader, opts ...parser.Option) ([]*schema.Document, error) {
+	commonOpts := parser.GetCommonOptions(nil, opts...)
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("vision parser read all from reader failed: %w", err)
+	}
+	mime := http.DetectContentType(data)
+	if !supportedMIMETypes[mime] {
+		return nil, fmt.Errorf("unsupported image type %q", mime)
+	}
+
+	doc, err := vp.describe(ctx, Image{Data: data, Source: commonOpts.URI}, mime, vp.prompt(opts), commonOpts.ExtraMeta)
+	if err != nil {
+		return nil, err
+	}
+	return []*schema.Document{doc}, nil
+}
+
+// Describe describes a batch of images, e.g. figures extracted by ExtractOfficeImages or PDFImageExtractor.
+// Unsupported formats (such as EMF/WMF) and images smaller than MinWidth x MinHeight are skipped.
+// The parser.WithExtraMeta option is applied to every document.
+func (vp *VisionParser) Describe(ctx context.Context, images []Image, opts ...parser.Option) ([]*schema.Document, error) {
+	commonOpts := parser.GetCommonOptions(nil, opts...)
+	prompt := vp.prompt(opts)
+	logger := vp.Logger
+	if logger == nil {
+		logger = logrus.StandardLogger()
+	}
+
+	var docs []*schema.Document
+	for i, img := range images {
+		mime := http.DetectContentType(img.Data)
+		if !supportedMIMETypes[mime] {
+			logger.WithFields(logrus.Fields{
+				"image_index":  i,
+				"image_source": img.Source,
+				"mime":         mime,
+			}).Debug("Skipping image with unsupported format")
+			continue
+		}
+		if cfg, _, err := image.DecodeConfig(bytes.NewReader(img.Data)); err == nil &&
+			(cfg.Width < vp.MinWidth || cfg.Height < vp.MinHeight) {
+			logger.WithFields(logrus.Fields{
+				"image_index":  i,
+				"image_source": img.Source,
+				"width":        cfg.Width,
+				"height":       cfg.Height,
+			}).Debug("Skipping image smaller than the minimum size")
+			continue
+		}
+
+		doc, err := vp.describe(ctx, img, mime, prompt, commonOpts.ExtraMeta)
+		if err != nil {
+			return nil, fmt.Errorf("describe image %d (%s) failed: %w", i, img.Source, err)
+		}
+		doc.MetaData[MetaKeyImageIndex] = i
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+func (vp *VisionParser) prompt(opts []parser.Option) string {
+	specificOpts := parser.GetImplSpecificOptions(&options{prompt: &vp.Prompt}, opts...)
+	return *specificOpts.prompt
+}
+
+// describe 以 base64 形式将图片发送给视觉模型，生成描述文档
+func (vp *VisionParser) describe(ctx context.Context, img Image, mime, prompt string, extraMeta map[string]any) (*schema.Document, error) {
+	encoded := base64.StdEncoding.EncodeToString(img.Data)
+	msg, err := vp.ChatModel.Generate(ctx, []*schema.Message{{
+		Role: schema.User,
+		UserInputMultiContent: []schema.MessageInputPart{
+			{Type: schema.ChatMessagePartTypeText, Text: prompt},
+			{Type: schema.ChatMessagePartTypeImageURL, Image: &schema.MessageInputImage{
+				MessagePartCommon: schema.MessagePartCommon{
+					Base64Data: &encoded,
+					MIMEType:   mime,
+				},
+				Detail: vp.Detail,
+			}},
+		},
+	}})
+	if err != nil {
+		return nil, fmt.Errorf("vision model generate failed: %w", err)
+	}
+	caption := strings.TrimSpace(msg.Content)
+	if caption == "" {
+		return nil, fmt.Errorf("vision model returned an empty description")
+	}
+
+	meta := make(map[string]any, len(extraMeta)+6)
+	for k, v := range extraMeta {
+		meta[k] = v
+	}
+	meta[MetaKeyImageMIME] = mime
+	if img.Source != "" {
+		meta[MetaKeyImageSource] = img.Source
+	}
+	if img.Page > 0 {
+		meta[MetaKeyImagePage] = img.Page
+	}
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(img.Data)); err == nil {
+		meta[MetaKeyImageWidth] = cfg.Width
+		meta[MetaKeyImageHeight] = cfg.Height
+	}
+	return &schema.Document{
+		Content:  caption,
+		MetaData: meta,
+	}, nil
+}
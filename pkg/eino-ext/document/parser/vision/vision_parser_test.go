@@ -0,0 +1,145 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vision
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/cloudwego/eino/components/document/parser"
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/schema"
+	"github.com/sirupsen/logrus"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+// fakeVisionModel 记录收到的消息，返回固定格式的描述
+type fakeVisionModel struct {
+	inputs [][]*schema.Message
+}
+
+func (m *fakeVisionModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	m.inputs = append(m.inputs, input)
+	img := input[0].UserInputMultiContent[1].Image
+	return schema.AssistantMessage(fmt.Sprintf(" 一张 %s 图片 ", img.MIMEType), nil), nil
+}
+
+func (m *fakeVisionModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func testPNG(w, h int) []byte {
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h)))
+	return buf.Bytes()
+}
+
+func TestVisionParser(t *testing.T) {
+	ctx := context.Background()
+
+	convey.Convey("Test VisionParser Parse", t, func() {
+		cm := &fakeVisionModel{}
+		p, err := NewVisionParser(ctx, &Config{ChatModel: cm})
+		convey.So(err, convey.ShouldBeNil)
+
+		docs, err := p.Parse(ctx, bytes.NewReader(testPNG(64, 32)),
+			parser.WithURI("figures/arch.png"), parser.WithExtraMeta(map[string]any{"kb": "default"}))
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(len(docs), convey.ShouldEqual, 1)
+		convey.So(docs[0].Content, convey.ShouldEqual, "一张 image/png 图片")
+		convey.So(docs[0].MetaData[MetaKeyImageSource], convey.ShouldEqual, "figures/arch.png")
+		convey.So(docs[0].MetaData[MetaKeyImageMIME], convey.ShouldEqual, "image/png")
+		convey.So(docs[0].MetaData[MetaKeyImageWidth], convey.ShouldEqual, 64)
+		convey.So(docs[0].MetaData[MetaKeyImageHeight], convey.ShouldEqual, 32)
+		convey.So(docs[0].MetaData["kb"], convey.ShouldEqual, "default")
+
+		parts := cm.inputs[0][0].UserInputMultiContent
+		convey.So(parts[0].Text, convey.ShouldEqual, DefaultPrompt)
+		convey.So(parts[1].Image.Detail, convey.ShouldEqual, schema.ImageURLDetailAuto)
+		convey.So(*parts[1].Image.Base64Data, convey.ShouldNotBeEmpty)
+
+		_, err = p.Parse(ctx, bytes.NewReader([]byte("plain text")))
+		convey.So(err, convey.ShouldNotBeNil)
+	})
+
+	convey.Convey("Test VisionParser Describe", t, func() {
+		cm := &fakeVisionModel{}
+		var logs bytes.Buffer
+		logger := logrus.New()
+		logger.SetOutput(&logs)
+		logger.SetLevel(logrus.DebugLevel)
+		p, err := NewVisionParser(ctx, &Config{ChatModel: cm, MinWidth: 16, MinHeight: 16, Logger: logger})
+		convey.So(err, convey.ShouldBeNil)
+
+		images := []Image{
+			{Data: testPNG(8, 8), Source: "icon.png"},
+			{Data: []byte{0x01, 0x00, 0x00, 0x00}, Source: "image1.emf"},
+			{Data: testPNG(100, 80), Source: "img-002-000.png", Page: 2},
+		}
+		docs, err := p.Describe(ctx, images, WithPrompt("描述这张图"))
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(len(docs), convey.ShouldEqual, 1)
+		convey.So(docs[0].MetaData[MetaKeyImageSource], convey.ShouldEqual, "img-002-000.png")
+		convey.So(docs[0].MetaData[MetaKeyImagePage], convey.ShouldEqual, 2)
+		convey.So(docs[0].MetaData[MetaKeyImageIndex], convey.ShouldEqual, 2)
+		convey.So(len(cm.inputs), convey.ShouldEqual, 1)
+		convey.So(cm.inputs[0][0].UserInputMultiContent[0].Text, convey.ShouldEqual, "描述这张图")
+		convey.So(logs.String(), convey.ShouldContainSubstring, "image_source=icon.png")
+		convey.So(logs.String(), convey.ShouldContainSubstring, "image_source=image1.emf")
+	})
+
+	convey.Convey("Test NewVisionParser requires chat model", t, func() {
+		_, err := NewVisionParser(ctx, nil)
+		convey.So(err, convey.ShouldNotBeNil)
+	})
+}
+
+func TestExtractImages(t *testing.T) {
+	convey.Convey("Test ExtractOfficeImages", t, func() {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for name, content := range map[string][]byte{
+			"word/document.xml":     []byte("<w:document/>"),
+			"word/media/image2.png": testPNG(4, 4),
+			"word/media/image1.png": testPNG(2, 2),
+			"word/theme/theme1.xml": []byte("<theme/>"),
+		} {
+			w, _ := zw.Create(name)
+			_, _ = w.Write(content)
+		}
+		_ = zw.Close()
+
+		images, err := ExtractOfficeImages(buf.Bytes())
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(len(images), convey.ShouldEqual, 2)
+		convey.So(images[0].Source, convey.ShouldEqual, "word/media/image1.png")
+		convey.So(images[1].Source, convey.ShouldEqual, "word/media/image2.png")
+
+		_, err = ExtractOfficeImages([]byte("not a zip"))
+		convey.So(err, convey.ShouldNotBeNil)
+	})
+
+	convey.Convey("Test pdfimagesPage", t, func() {
+		convey.So(pdfimagesPage("img-012-003.png"), convey.ShouldEqual, 12)
+		convey.So(pdfimagesPage("img-000.png"), convey.ShouldEqual, 0)
+	})
+}
@@ -0,0 +1,364 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/document/parser/internal/tabular"
+)
+
+// XLSX 是 zip 包中的一组 XML 文件，这里只读取单元格的值：
+// xl/workbook.xml（工作表列表）、xl/_rels/workbook.xml.rels（工作表文件路径）、
+// xl/sharedStrings.xml（共享字符串）、xl/styles.xml（用于识别日期格式）和各个 worksheet
+
+const relTypeWorksheet = "/worksheet"
+
+// maxColumns Excel 工作表的最大列数（最后一列为 XFD）
+const maxColumns = 16384
+
+type xlsxWorkbook struct {
+	WorkbookPr struct {
+		Date1904 bool `xml:"date1904,attr"`
+	} `xml:"workbookPr"`
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Type   string `xml:"Type,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxRichText 共享字符串和内联字符串：纯文本在 t 中，富文本分布在多个 r/t 中（rPh 注音会被忽略）
+type xlsxRichText struct {
+	T string `xml:"t"`
+	R []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (s xlsxRichText) text() string {
+	if len(s.R) == 0 {
+		return s.T
+	}
+	var b strings.Builder
+	b.WriteString(s.T)
+	for _, r := range s.R {
+		b.WriteString(r.T)
+	}
+	return b.String()
+}
+
+type xlsxSST struct {
+	Items []xlsxRichText `xml:"si"`
+}
+
+type xlsxStyles struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellXfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			R  string       `xml:"r,attr"`
+			T  string       `xml:"t,attr"`
+			S  int          `xml:"s,attr"`
+			V  string       `xml:"v"`
+			Is xlsxRichText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// workbook 解析后的工作簿
+type workbook struct {
+	files     map[string]*zip.File
+	date1904  bool
+	strings   []string
+	dateStyle []bool // 按样式序号标记是否为日期格式
+}
+
+// readWorkbook 读取工作簿中所有 worksheet 的单元格
+func readWorkbook(r io.ReaderAt, size int64) ([]tabular.Sheet, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("open xlsx failed: %w", err)
+	}
+	wb := &workbook{files: make(map[string]*zip.File, len(zr.File))}
+	for _, f := range zr.File {
+		wb.files[strings.TrimPrefix(f.Name, "/")] = f
+	}
+
+	var book xlsxWorkbook
+	if err := wb.decode("xl/workbook.xml", &book); err != nil {
+		return nil, err
+	}
+	wb.date1904 = book.WorkbookPr.Date1904
+
+	var rels xlsxRelationships
+	if err := wb.decode("xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string, len(rels.Relationships))
+	for _, rel := range rels.Relationships {
+		if !strings.HasSuffix(rel.Type, relTypeWorksheet) {
+			continue // 图表页等不是 worksheet
+		}
+		target := rel.Target
+		if strings.HasPrefix(target, "/") {
+			target = strings.TrimPrefix(target, "/")
+		} else {
+			target = path.Join("xl", target)
+		}
+		targets[rel.ID] = target
+	}
+
+	if _, ok := wb.files["xl/sharedStrings.xml"]; ok {
+		var sst xlsxSST
+		if err := wb.decode("xl/sharedStrings.xml", &sst); err != nil {
+			return nil, err
+		}
+		wb.strings = make([]string, len(sst.Items))
+		for i, item := range sst.Items {
+			wb.strings[i] = item.text()
+		}
+	}
+	if _, ok := wb.files["xl/styles.xml"]; ok {
+		var styles xlsxStyles
+		if err := wb.decode("xl/styles.xml", &styles); err != nil {
+			return nil, err
+		}
+		wb.dateStyle = dateStyles(styles)
+	}
+
+	var sheets []tabular.Sheet
+	for i, s := range book.Sheets {
+		target, ok := targets[s.RID]
+		if !ok {
+			continue
+		}
+		sheets = append(sheets, tabular.Sheet{Name: s.Name, Index: i})
+		if err := wb.readSheet(target, &sheets[len(sheets)-1]); err != nil {
+			return nil, fmt.Errorf("read sheet %q failed: %w", s.Name, err)
+		}
+	}
+	return sheets, nil
+}
+
+func (wb *workbook) decode(name string, v any) error {
+	f, ok := wb.files[name]
+	if !ok {
+		return fmt.Errorf("invalid xlsx: missing %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("open %s failed: %w", name, err)
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("decode %s failed: %w", name, err)
+	}
+	return nil
+}
+
+func (wb *workbook) readSheet(name string, sheet *tabular.Sheet) error {
+	var ws xlsxWorksheet
+	if err := wb.decode(name, &ws); err != nil {
+		return err
+	}
+	lastRow := 0
+	for _, row := range ws.Rows {
+		number := row.R
+		if number <= 0 {
+			number = lastRow + 1
+		}
+		lastRow = number
+
+		var cells []string
+		for _, c := range row.Cells {
+			col := len(cells)
+			if c.R != "" {
+				if idx, ok := columnIndex(c.R); ok {
+					col = idx
+				}
+			}
+			// 构造的引用（如 ZZZZZZZZ1）会导致分配巨大的行，超出 Excel 列数范围时直接报错
+			if col >= maxColumns {
+				return fmt.Errorf("invalid xlsx: cell %q in row %d is beyond column XFD", c.R, number)
+			}
+			for len(cells) <= col {
+				cells = append(cells, "")
+			}
+			cells[col] = wb.cellValue(c.T, c.S, c.V, c.Is)
+		}
+		sheet.Rows = append(sheet.Rows, tabular.Row{Number: number, Cells: cells})
+	}
+	return nil
+}
+
+// cellValue 按单元格类型取值：共享字符串、内联字符串、布尔、公式字符串、错误值或数字
+func (wb *workbook) cellValue(typ string, style int, v string, is xlsxRichText) string {
+	switch typ {
+	case "s":
+		idx, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || idx < 0 || idx >= len(wb.strings) {
+			return ""
+		}
+		return wb.strings[idx]
+	case "inlineStr":
+		return is.text()
+	case "b":
+		if strings.TrimSpace(v) == "1" {
+			return "TRUE"
+		}
+		return "FALSE"
+	case "str", "e", "d":
+		return v
+	}
+
+	f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil {
+		return v
+	}
+	if style >= 0 && style < len(wb.dateStyle) && wb.dateStyle[style] {
+		return formatExcelDate(f, wb.date1904)
+	}
+	return formatNumber(f)
+}
+
+// columnIndex 将单元格引用（如 "AB12"）的列部分转换为从 0 开始的列序号
+// 列字母超过 3 个时返回 maxColumns，由调用方报错，避免整数溢出
+func columnIndex(ref string) (int, bool) {
+	idx := 0
+	n := 0
+	for _, r := range ref {
+		if r >= 'a' && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		if n == 3 {
+			return maxColumns, true
+		}
+		idx = idx*26 + int(r-'A'+1)
+		n++
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return idx - 1, true
+}
+
+// formatNumber 按 Excel 的显示精度（15 位有效数字）输出数字，避免 0.1+0.2 之类的浮点误差
+func formatNumber(f float64) string {
+	rounded, err := strconv.ParseFloat(strconv.FormatFloat(f, 'g', 15, 64), 64)
+	if err != nil {
+		rounded = f
+	}
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
+
+// dateStyles 根据 cellXfs 引用的数字格式判断每个样式是否为日期/时间格式
+func dateStyles(styles xlsxStyles) []bool {
+	custom := make(map[int]string, len(styles.NumFmts))
+	for _, f := range styles.NumFmts {
+		custom[f.ID] = f.Code
+	}
+	ret := make([]bool, len(styles.CellXfs))
+	for i, xf := range styles.CellXfs {
+		if code, ok := custom[xf.NumFmtID]; ok {
+			ret[i] = isDateFormat(code)
+			continue
+		}
+		ret[i] = isBuiltinDateFormat(xf.NumFmtID)
+	}
+	return ret
+}
+
+// isBuiltinDateFormat 内置的日期/时间格式编号
+func isBuiltinDateFormat(id int) bool {
+	return (id >= 14 && id <= 22) || (id >= 27 && id <= 36) || (id >= 45 && id <= 47) || (id >= 50 && id <= 58)
+}
+
+// isDateFormat 判断自定义格式是否为日期格式：忽略引号中的文字、转义字符和 [Red] 等方括号内容后，包含 y/m/d/h/s
+func isDateFormat(code string) bool {
+	inQuote, inBracket, escaped := false, false, false
+	for _, r := range code {
+		switch {
+		case escaped:
+			escaped = false
+		case inQuote:
+			inQuote = r != '"'
+		case inBracket:
+			inBracket = r != ']'
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			inQuote = true
+		case r == '[':
+			inBracket = true
+		case r == ';':
+			return false // 只看第一段格式
+		default:
+			switch r {
+			case 'y', 'Y', 'm', 'M', 'd', 'D', 'h', 'H', 's', 'S':
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// formatExcelDate 将 Excel 序列日期转换为文本：整数输出日期，带小数输出日期时间，小于 1 的值只输出时间
+func formatExcelDate(serial float64, date1904 bool) string {
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if date1904 {
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	days := math.Floor(serial)
+	seconds := math.Round((serial - days) * 86400)
+	t := epoch.AddDate(0, 0, int(days)).Add(time.Duration(seconds) * time.Second)
+	switch {
+	case serial < 1 && !date1904:
+		return t.Format("15:04:05")
+	case seconds == 0:
+		return t.Format("2006-01-02")
+	default:
+		return t.Format("2006-01-02 15:04:05")
+	}
+}
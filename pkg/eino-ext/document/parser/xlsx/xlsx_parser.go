@@ -0,0 +1,140 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xlsx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/cloudwego/eino/components/document/parser"
+	"github.com/cloudwego/eino/schema"
+
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/document/parser/internal/tabular"
+)
+
+const (
+	// MetaKeySheet is the MetaData key of the sheet name
+	MetaKeySheet = tabular.MetaKeySheet
+	// MetaKeySheetIndex is the MetaData key of the 0-based index of the sheet in the workbook
+	MetaKeySheetIndex = tabular.MetaKeySheetIndex
+	// MetaKeyRow is the MetaData key of the 1-based row number in the sheet (ModeRows only)
+	MetaKeyRow = tabular.MetaKeyRow
+	// MetaKeyColumns is the MetaData key of the column names ([]string)
+	MetaKeyColumns = tabular.MetaKeyColumns
+	// MetaKeyRowCount is the MetaData key of the number of data rows of the sheet (ModeTable only)
+	MetaKeyRowCount = tabular.MetaKeyRowCount
+)
+
+// Mode controls how a sheet is converted into documents
+type Mode = tabular.Mode
+
+const (
+	// ModeTable emits one document per sheet, whose content is a Markdown table.
+	// Large tables can be split later by the table splitter, which repeats the header in every chunk.
+	ModeTable = tabular.ModeTable
+	// ModeRows emits one document per data row, whose content is "column: value; ..." text.
+	ModeRows = tabular.ModeRows
+)
+
+// Config is the configuration for XLSX parser.
+type Config struct {
+	// Mode controls how sheets are converted into documents. Default is ModeTable.
+	Mode Mode
+	// Sheets limits parsing to the sheets with the given names. Empty means all sheets.
+	Sheets []string
+	// NoHeader indicates that the first row is data rather than column names;
+	// columns are then named column1, column2, ...
+	NoHeader bool
+}
+
+// XlsxParser parses XLSX workbooks. Cell values are read as displayed text where possible:
+// shared and inline strings, booleans as TRUE/FALSE, numbers with up to 15 significant digits,
+// and date-formatted numbers as "2006-01-02" or "2006-01-02 15:04:05". Formulas are not evaluated,
+// their cached values are used.
+type XlsxParser struct {
+	Mode     Mode
+	Sheets   []string
+	NoHeader bool
+}
+
+// NewXlsxParser creates a new XLSX parser.
+func NewXlsxParser(ctx context.Context, config *Config) (*XlsxParser, error) {
+	if config == nil {
+		config = &Config{}
+	}
+	if err := config.Mode.Validate(); err != nil {
+		return nil, err
+	}
+	mode := config.Mode
+	if mode == "" {
+		mode = ModeTable
+	}
+	return &XlsxParser{
+		Mode:     mode,
+		Sheets:   config.Sheets,
+		NoHeader: config.NoHeader,
+	}, nil
+}
+
+// Parse parses the XLSX content from io.Reader.
+func (xp *XlsxParser) Parse(ctx context.Context, reader io.Reader, opts ...parser.Option) ([]*schema.Document, error) {
+	commonOpts := parser.GetCommonOptions(nil, opts...)
+
+	specificOpts := parser.GetImplSpecificOptions(&options{
+		mode:   &xp.Mode,
+		sheets: xp.Sheets,
+	}, opts...)
+	if err := specificOpts.mode.Validate(); err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("xlsx parser read all from reader failed: %w", err)
+	}
+
+	sheets, err := readWorkbook(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(specificOpts.sheets) > 0 {
+		wanted := make(map[string]bool, len(specificOpts.sheets))
+		for _, name := range specificOpts.sheets {
+			wanted[name] = true
+		}
+		selected := sheets[:0]
+		for _, sheet := range sheets {
+			if wanted[sheet.Name] {
+				selected = append(selected, sheet)
+				delete(wanted, sheet.Name)
+			}
+		}
+		for name := range wanted {
+			return nil, fmt.Errorf("sheet %q not found", name)
+		}
+		sheets = selected
+	}
+
+	return tabular.Documents(sheets, tabular.Options{
+		Mode:      *specificOpts.mode,
+		NoHeader:  xp.NoHeader,
+		ExtraMeta: commonOpts.ExtraMeta,
+	}), nil
+}
@@ -0,0 +1,194 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/cloudwego/eino/components/document/parser"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+// buildTestXLSX 生成一个最小的 XLSX 文件，files 为 zip 内的文件名到内容
+func buildTestXLSX(files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, _ := zw.Create(name)
+		_, _ = w.Write([]byte(content))
+	}
+	_ = zw.Close()
+	return buf.Bytes()
+}
+
+var testWorkbook = map[string]string{
+	"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets>
+<sheet name="销售" sheetId="1" r:id="rId1"/>
+<sheet name="图表" sheetId="2" r:id="rId2"/>
+<sheet name="备注" sheetId="3" r:id="rId3"/>
+</sheets>
+</workbook>`,
+	"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/chartsheet" Target="chartsheets/sheet1.xml"/>
+<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="/xl/worksheets/sheet2.xml"/>
+</Relationships>`,
+	"xl/sharedStrings.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<si><t>地区</t></si>
+<si><t>销售额</t></si>
+<si><t>日期</t></si>
+<si><r><t>华</t></r><r><t>东</t></r></si>
+<si><t>A|B</t></si>
+</sst>`,
+	"xl/styles.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy/mm/dd hh:mm"/></numFmts>
+<cellXfs count="3"><xf numFmtId="0"/><xf numFmtId="14"/><xf numFmtId="164"/></cellXfs>
+</styleSheet>`,
+	"xl/worksheets/sheet1.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c></row>
+<row r="2"><c r="A2" t="s"><v>3</v></c><c r="B2"><v>1200.5</v></c><c r="C2" s="1"><v>45292</v></c></row>
+<row r="4"><c r="A4" t="inlineStr"><is><t>华南</t></is></c><c r="C4" s="2"><v>45292.75</v></c></row>
+<row r="5"><c r="A5" t="s"><v>4</v></c><c r="B5"><v>0.30000000000000004</v></c><c r="D5" t="b"><v>1</v></c></row>
+</sheetData>
+</worksheet>`,
+	"xl/worksheets/sheet2.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<sheetData>
+<row r="1"><c r="A1" t="inlineStr"><is><t>说明</t></is></c></row>
+<row r="2"><c r="A2" t="inlineStr"><is><t>数据来自内部统计</t></is></c></row>
+</sheetData>
+</worksheet>`,
+}
+
+func TestXlsxParser(t *testing.T) {
+	ctx := context.Background()
+	data := buildTestXLSX(testWorkbook)
+
+	convey.Convey("Test XlsxParser ModeTable", t, func() {
+		p, err := NewXlsxParser(ctx, nil)
+		convey.So(err, convey.ShouldBeNil)
+
+		docs, err := p.Parse(ctx, bytes.NewReader(data), parser.WithExtraMeta(map[string]any{"source": "sales.xlsx"}))
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(len(docs), convey.ShouldEqual, 2)
+
+		convey.So(docs[0].Content, convey.ShouldEqual, "| 地区 | 销售额 | 日期 | column4 |\n"+
+			"| --- | --- | --- | --- |\n"+
+			"| 华东 | 1200.5 | 2024-01-01 |  |\n"+
+			"| 华南 |  | 2024-01-01 18:00:00 |  |\n"+
+			`| A\|B | 0.3 |  | TRUE |`)
+		convey.So(docs[0].MetaData[MetaKeySheet], convey.ShouldEqual, "销售")
+		convey.So(docs[0].MetaData[MetaKeySheetIndex], convey.ShouldEqual, 0)
+		convey.So(docs[0].MetaData[MetaKeyRowCount], convey.ShouldEqual, 3)
+		convey.So(docs[0].MetaData[MetaKeyColumns], convey.ShouldResemble, []string{"地区", "销售额", "日期", "column4"})
+		convey.So(docs[0].MetaData["source"], convey.ShouldEqual, "sales.xlsx")
+
+		convey.So(docs[1].MetaData[MetaKeySheet], convey.ShouldEqual, "备注")
+		convey.So(docs[1].MetaData[MetaKeySheetIndex], convey.ShouldEqual, 2)
+		convey.So(docs[1].Content, convey.ShouldEqual, "| 说明 |\n| --- |\n| 数据来自内部统计 |")
+	})
+
+	convey.Convey("Test XlsxParser ModeRows", t, func() {
+		p, err := NewXlsxParser(ctx, &Config{Mode: ModeRows})
+		convey.So(err, convey.ShouldBeNil)
+
+		docs, err := p.Parse(ctx, bytes.NewReader(data), WithSheets("销售"))
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(len(docs), convey.ShouldEqual, 3)
+		convey.So(docs[0].Content, convey.ShouldEqual, "地区: 华东; 销售额: 1200.5; 日期: 2024-01-01")
+		convey.So(docs[0].MetaData[MetaKeyRow], convey.ShouldEqual, 2)
+		convey.So(docs[1].Content, convey.ShouldEqual, "地区: 华南; 日期: 2024-01-01 18:00:00")
+		convey.So(docs[1].MetaData[MetaKeyRow], convey.ShouldEqual, 4)
+		convey.So(docs[2].Content, convey.ShouldEqual, "地区: A|B; 销售额: 0.3; column4: TRUE")
+	})
+
+	convey.Convey("Test XlsxParser NoHeader", t, func() {
+		p, err := NewXlsxParser(ctx, &Config{NoHeader: true, Sheets: []string{"备注"}})
+		convey.So(err, convey.ShouldBeNil)
+
+		docs, err := p.Parse(ctx, bytes.NewReader(data), WithMode(ModeRows))
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(len(docs), convey.ShouldEqual, 2)
+		convey.So(docs[0].Content, convey.ShouldEqual, "column1: 说明")
+		convey.So(docs[0].MetaData[MetaKeyRow], convey.ShouldEqual, 1)
+	})
+
+	convey.Convey("Test XlsxParser errors", t, func() {
+		_, err := NewXlsxParser(ctx, &Config{Mode: "unknown"})
+		convey.So(err, convey.ShouldNotBeNil)
+
+		p, err := NewXlsxParser(ctx, nil)
+		convey.So(err, convey.ShouldBeNil)
+		_, err = p.Parse(ctx, bytes.NewReader(data), WithSheets("不存在"))
+		convey.So(err, convey.ShouldNotBeNil)
+		_, err = p.Parse(ctx, bytes.NewReader([]byte("not a zip")))
+		convey.So(err, convey.ShouldNotBeNil)
+
+		// 超出 XFD 的单元格引用直接报错，不按引用分配整行
+		files := map[string]string{}
+		for name, content := range testWorkbook {
+			files[name] = content
+		}
+		files["xl/worksheets/sheet1.xml"] = `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<sheetData><row r="1"><c r="ZZZZZZZZ1" t="inlineStr"><is><t>x</t></is></c></row></sheetData>
+</worksheet>`
+		_, err = p.Parse(ctx, bytes.NewReader(buildTestXLSX(files)))
+		convey.So(err, convey.ShouldNotBeNil)
+		convey.So(err.Error(), convey.ShouldContainSubstring, "beyond column XFD")
+	})
+}
+
+func TestCellHelpers(t *testing.T) {
+	convey.Convey("Test columnIndex", t, func() {
+		idx, ok := columnIndex("A1")
+		convey.So(ok, convey.ShouldBeTrue)
+		convey.So(idx, convey.ShouldEqual, 0)
+		idx, _ = columnIndex("AB12")
+		convey.So(idx, convey.ShouldEqual, 27)
+		_, ok = columnIndex("12")
+		convey.So(ok, convey.ShouldBeFalse)
+		idx, _ = columnIndex("XFD1")
+		convey.So(idx, convey.ShouldEqual, maxColumns-1)
+		idx, _ = columnIndex("ZZZZZZZZZZZZZZ1")
+		convey.So(idx, convey.ShouldEqual, maxColumns)
+	})
+
+	convey.Convey("Test isDateFormat", t, func() {
+		convey.So(isDateFormat("yyyy-mm-dd"), convey.ShouldBeTrue)
+		convey.So(isDateFormat("[$-409]h:mm AM/PM"), convey.ShouldBeTrue)
+		convey.So(isDateFormat("#,##0.00"), convey.ShouldBeFalse)
+		convey.So(isDateFormat(`0.00" days"`), convey.ShouldBeFalse)
+		convey.So(isDateFormat("[Red]0.00"), convey.ShouldBeFalse)
+	})
+
+	convey.Convey("Test formatExcelDate", t, func() {
+		convey.So(formatExcelDate(45292, false), convey.ShouldEqual, "2024-01-01")
+		convey.So(formatExcelDate(0.5, false), convey.ShouldEqual, "12:00:00")
+		convey.So(formatExcelDate(43830, true), convey.ShouldEqual, "2024-01-01")
+	})
+}
@@ -0,0 +1,136 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+package metadata
+
+import (
+	"strings"
+	"unicode"
+)
+
+// LanguageUndetermined is returned by DetectLanguage when the language cannot be
+// determined (no letters, or a Latin text without known stopwords).
+const LanguageUndetermined = "und"
+
+// scriptLanguages 只对应一种主要语言的文字
+var scriptLanguages = []struct {
+	table *unicode.RangeTable
+	lang  string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Greek, "el"},
+	{unicode.Thai, "th"},
+	{unicode.Devanagari, "hi"},
+}
+
+// latinStopwords 拉丁字母语言的高频功能词
+var latinStopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "for", "with", "are", "this", "be"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "mit", "ein", "eine", "den", "sich", "auf"},
+	"fr": {"le", "la", "les", "et", "des", "est", "une", "du", "pour", "dans", "que", "pas"},
+	"es": {"el", "los", "las", "y", "es", "una", "por", "para", "con", "del", "que", "se"},
+	"it": {"il", "di", "che", "è", "della", "per", "una", "sono", "gli", "non", "con", "del"},
+	"pt": {"o", "os", "as", "e", "de", "uma", "não", "para", "com", "do", "da", "que"},
+}
+
+// latinLangOrder 固定顺序，保证得分相同时结果稳定
+var latinLangOrder = []string{"en", "de", "fr", "es", "it", "pt"}
+
+// DetectLanguage detects the dominant language of text and returns an ISO 639-1 code
+// ("zh", "ja", "ko", "en", "de", ...) or LanguageUndetermined.
+// The dominant script decides the language; Han text counts as Japanese when it
+// contains kana. Latin text is told apart by stopword frequency.
+func DetectLanguage(text string) string {
+	var han, kana, latin, total int
+	others := make(map[string]int)
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		total++
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		default:
+			for _, s := range scriptLanguages {
+				if unicode.Is(s.table, r) {
+					others[s.lang]++
+					break
+				}
+			}
+		}
+	}
+	if total == 0 {
+		return LanguageUndetermined
+	}
+
+	lang, count := "", 0
+	for _, s := range scriptLanguages {
+		if others[s.lang] > count {
+			lang, count = s.lang, others[s.lang]
+		}
+	}
+
+	// 汉字的信息密度高于拉丁字母，汉字和假名数量达到拉丁字母的一半即视为中日文
+	cjk := han + kana
+	switch {
+	case cjk > 0 && cjk*2 >= latin && cjk >= count:
+		// 日文中假名通常占相当比例，少量假名（如引用的日文专名）不改变判断
+		if kana > 0 && kana*5 >= cjk {
+			return "ja"
+		}
+		return "zh"
+	case count > latin:
+		return lang
+	case latin > 0:
+		return detectLatin(text)
+	}
+	return LanguageUndetermined
+}
+
+// detectLatin 按停用词命中数判断拉丁字母语言
+func detectLatin(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	scores := make(map[string]int, len(latinStopwords))
+	for _, w := range words {
+		for lang, stopwords := range latinStopwords {
+			for _, s := range stopwords {
+				if w == s {
+					scores[lang]++
+					break
+				}
+			}
+		}
+	}
+
+	best, bestScore := LanguageUndetermined, 0
+	for _, lang := range latinLangOrder {
+		if scores[lang] > bestScore {
+			best, bestScore = lang, scores[lang]
+		}
+	}
+	return best
+}
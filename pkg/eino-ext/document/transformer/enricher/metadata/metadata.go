@@ -0,0 +1,129 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+package metadata
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/components/document"
+	"github.com/cloudwego/eino/schema"
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/document/transformer/splitter/tfidf"
+)
+
+const (
+	// MetaKeyLanguage is the detected language of the content, see DetectLanguage.
+	MetaKeyLanguage = "language"
+	// MetaKeyRuneCount is the number of characters (runes) of the content.
+	MetaKeyRuneCount = "rune_count"
+	// MetaKeyTokenCount is the number of tokens of the content, counted by Config.TokenCounter.
+	MetaKeyTokenCount = "token_count"
+	// MetaKeyContentHash is the hex encoded SHA-256 of the content.
+	MetaKeyContentHash = "content_hash"
+	// MetaKeyIngestedAt is the time the document passed the enricher, formatted as RFC 3339.
+	MetaKeyIngestedAt = "ingested_at"
+)
+
+type Config struct {
+	// TokenCounter counts the tokens of the content. Defaults to tfidf.EstimateTokens;
+	// set it to the tokenizer of the embedding model for exact counts.
+	TokenCounter func(text string) int
+	// LanguageDetector detects the language of the content. Defaults to DetectLanguage.
+	LanguageDetector func(text string) string
+	// Now returns the ingestion time. Defaults to time.Now.
+	Now func() time.Time
+	// Overwrite replaces values that are already present in the document metadata
+	// (e.g. a language set by the parser). By default existing values are kept.
+	Overwrite bool
+}
+
+// NewMetadataEnricher 创建元数据增强器，Transform 为每个文档补充语言、字数、token 数、内容哈希和入库时间
+func NewMetadataEnricher(ctx context.Context, config *Config) (*MetadataEnricher, error) {
+	if config == nil {
+		config = &Config{}
+	}
+	if config.TokenCounter == nil {
+		config.TokenCounter = tfidf.EstimateTokens
+	}
+	if config.LanguageDetector == nil {
+		config.LanguageDetector = DetectLanguage
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	return &MetadataEnricher{config: config}, nil
+}
+
+// MetadataEnricher 元数据增强器，实现 document.Transformer
+// 返回的文档是输入文档的浅拷贝，MetaData 为新的 map，不会修改输入
+type MetadataEnricher struct {
+	config *Config
+}
+
+func (e *MetadataEnricher) Transform(ctx context.Context, docs []*schema.Document, opts ...document.TransformerOption) ([]*schema.Document, error) {
+	if e == nil || e.config == nil {
+		return nil, fmt.Errorf("MetadataEnricher is nil")
+	}
+
+	// 同一批次使用相同的入库时间
+	ingestedAt := e.config.Now().UTC().Format(time.RFC3339)
+
+	ret := make([]*schema.Document, 0, len(docs))
+	for _, doc := range docs {
+		if doc == nil {
+			continue
+		}
+		meta := make(map[string]any, len(doc.MetaData)+5)
+		for k, v := range doc.MetaData {
+			meta[k] = v
+		}
+
+		e.set(meta, MetaKeyLanguage, func() any { return e.config.LanguageDetector(doc.Content) })
+		e.set(meta, MetaKeyRuneCount, func() any { return utf8.RuneCountInString(doc.Content) })
+		e.set(meta, MetaKeyTokenCount, func() any { return e.config.TokenCounter(doc.Content) })
+		e.set(meta, MetaKeyContentHash, func() any { return ContentHash(doc.Content) })
+		e.set(meta, MetaKeyIngestedAt, func() any { return ingestedAt })
+
+		enriched := *doc
+		enriched.MetaData = meta
+		ret = append(ret, &enriched)
+	}
+	return ret, nil
+}
+
+// set 在 key 不存在或配置了 Overwrite 时写入值
+func (e *MetadataEnricher) set(meta map[string]any, key string, value func() any) {
+	if _, exists := meta[key]; exists && !e.config.Overwrite {
+		return
+	}
+	meta[key] = value()
+}
+
+func (e *MetadataEnricher) GetType() string {
+	return "MetadataEnricher"
+}
+
+// ContentHash 返回内容的 SHA-256 十六进制编码，与 MetaKeyContentHash 的值一致
+func ContentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
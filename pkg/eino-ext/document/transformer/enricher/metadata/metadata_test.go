@@ -0,0 +1,104 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestMetadataEnricher(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 3, 1, 8, 30, 0, 0, time.FixedZone("CST", 8*3600))
+
+	convey.Convey("Test MetadataEnricher Transform", t, func() {
+		enricher, err := NewMetadataEnricher(ctx, &Config{
+			Now:          func() time.Time { return now },
+			TokenCounter: func(text string) int { return len(text) },
+		})
+		convey.So(err, convey.ShouldBeNil)
+
+		input := []*schema.Document{
+			{ID: "1", Content: "这是一个测试文档", MetaData: map[string]any{"source": "a.txt"}},
+			{ID: "2", Content: "The quick brown fox jumps over the lazy dog"},
+			nil,
+		}
+		docs, err := enricher.Transform(ctx, input)
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(len(docs), convey.ShouldEqual, 2)
+
+		meta := docs[0].MetaData
+		convey.So(docs[0].ID, convey.ShouldEqual, "1")
+		convey.So(meta["source"], convey.ShouldEqual, "a.txt")
+		convey.So(meta[MetaKeyLanguage], convey.ShouldEqual, "zh")
+		convey.So(meta[MetaKeyRuneCount], convey.ShouldEqual, 8)
+		convey.So(meta[MetaKeyTokenCount], convey.ShouldEqual, 24)
+		convey.So(meta[MetaKeyContentHash], convey.ShouldEqual, ContentHash("这是一个测试文档"))
+		convey.So(meta[MetaKeyIngestedAt], convey.ShouldEqual, "2025-03-01T00:30:00Z")
+		convey.So(docs[1].MetaData[MetaKeyLanguage], convey.ShouldEqual, "en")
+
+		// 输入文档不被修改
+		convey.So(len(input[0].MetaData), convey.ShouldEqual, 1)
+		convey.So(input[1].MetaData, convey.ShouldBeNil)
+	})
+
+	convey.Convey("Test MetadataEnricher Overwrite", t, func() {
+		doc := &schema.Document{Content: "hello", MetaData: map[string]any{MetaKeyLanguage: "fr"}}
+
+		enricher, err := NewMetadataEnricher(ctx, nil)
+		convey.So(err, convey.ShouldBeNil)
+		docs, err := enricher.Transform(ctx, []*schema.Document{doc})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(docs[0].MetaData[MetaKeyLanguage], convey.ShouldEqual, "fr")
+		convey.So(docs[0].MetaData[MetaKeyTokenCount], convey.ShouldEqual, 2)
+
+		enricher, err = NewMetadataEnricher(ctx, &Config{
+			Overwrite:        true,
+			LanguageDetector: func(string) string { return "xx" },
+		})
+		convey.So(err, convey.ShouldBeNil)
+		docs, err = enricher.Transform(ctx, []*schema.Document{doc})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(docs[0].MetaData[MetaKeyLanguage], convey.ShouldEqual, "xx")
+	})
+}
+
+func TestDetectLanguage(t *testing.T) {
+	convey.Convey("Test DetectLanguage", t, func() {
+		cases := map[string]string{
+			"":          LanguageUndetermined,
+			"12345 !!!": LanguageUndetermined,
+			"系统需要支持文档的上传、解析和检索功能。":                       "zh",
+			"Eino 是一个 LLM 应用开发框架":                        "zh",
+			"これは日本語の文章です。":                               "ja",
+			"이것은 한국어 문장입니다":                              "ko",
+			"Это предложение на русском языке":           "ru",
+			"This is a sentence in English":              "en",
+			"Das ist ein Satz und nicht mehr":            "de",
+			"Les chats sont dans la maison et le jardin": "fr",
+			"Los perros y las casas para el pueblo":      "es",
+			"xyzzy plugh":                                LanguageUndetermined,
+		}
+		for text, want := range cases {
+			convey.So(DetectLanguage(text), convey.ShouldEqual, want)
+		}
+	})
+}
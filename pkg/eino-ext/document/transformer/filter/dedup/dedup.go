@@ -0,0 +1,233 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+package dedup
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/eino/components/document"
+	"github.com/cloudwego/eino/schema"
+	"github.com/sirupsen/logrus"
+)
+
+// Algorithm selects how near-duplicates are detected
+type Algorithm string
+
+const (
+	// AlgorithmMinHash estimates the Jaccard similarity of the character shingle sets.
+	// It is accurate for chunks of any length and is the default.
+	AlgorithmMinHash Algorithm = "minhash"
+	// AlgorithmSimHash compares 64-bit fingerprints; similarity is 1 - hamming distance / 64.
+	// It is cheaper than MinHash but less precise for short chunks.
+	AlgorithmSimHash Algorithm = "simhash"
+)
+
+// Action decides what happens to a near-duplicate
+type Action string
+
+const (
+	// ActionDrop keeps the first document of every group of near-duplicates and drops the others.
+	ActionDrop Action = "drop"
+	// ActionMerge keeps one document per group with the longest content (the most complete
+	// version), fills in metadata keys missing from it with those of the other members and
+	// records the IDs of the merged documents under MetaKeyDuplicateIDs.
+	ActionMerge Action = "merge"
+)
+
+// MetaKeyDuplicateIDs lists the IDs of the documents merged into a document ([]string).
+const MetaKeyDuplicateIDs = "duplicate_ids"
+
+// Duplicate describes a document detected as a near-duplicate of another one
+type Duplicate struct {
+	DocID      string
+	OfDocID    string
+	Similarity float64
+}
+
+// DuplicateCallback is called for every near-duplicate
+type DuplicateCallback func(ctx context.Context, dup Duplicate)
+
+type Config struct {
+	// Algorithm is the similarity algorithm. Default is AlgorithmMinHash.
+	Algorithm Algorithm
+	// Threshold is the minimum similarity in (0, 1] for two documents to be near-duplicates.
+	// Default is 0.9.
+	Threshold float64
+	// Action decides whether near-duplicates are dropped or merged. Default is ActionDrop.
+	Action Action
+	// ShingleSize is the number of characters per shingle. Whitespace and punctuation are
+	// removed before shingling, so the same size works for CJK and Latin text. Default is 3.
+	ShingleSize int
+	// NumHashes is the number of MinHash permutations; the estimate error is about
+	// 1/sqrt(NumHashes). Default is 128.
+	NumHashes int
+	// Logger receives a debug event for every near-duplicate. Defaults to logrus.StandardLogger().
+	Logger logrus.FieldLogger
+	// OnDuplicate is an optional callback invoked for every near-duplicate.
+	OnDuplicate DuplicateCallback
+}
+
+// NewDedupFilter 创建近似重复过滤器，Transform 在批次内检测近似重复的 chunk 并丢弃或合并
+func NewDedupFilter(ctx context.Context, config *Config) (*DedupFilter, error) {
+	if config == nil {
+		config = &Config{}
+	}
+	switch config.Algorithm {
+	case "":
+		config.Algorithm = AlgorithmMinHash
+	case AlgorithmMinHash, AlgorithmSimHash:
+	default:
+		return nil, fmt.Errorf("unknown algorithm %q", config.Algorithm)
+	}
+	switch config.Action {
+	case "":
+		config.Action = ActionDrop
+	case ActionDrop, ActionMerge:
+	default:
+		return nil, fmt.Errorf("unknown action %q", config.Action)
+	}
+	if config.Threshold < 0 || config.Threshold > 1 {
+		return nil, fmt.Errorf("threshold must be in (0, 1], got %v", config.Threshold)
+	}
+	if config.Threshold == 0 {
+		config.Threshold = 0.9
+	}
+	if config.ShingleSize <= 0 {
+		config.ShingleSize = 3
+	}
+	if config.NumHashes <= 0 {
+		config.NumHashes = 128
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = logrus.StandardLogger()
+	}
+	return &DedupFilter{
+		config: config,
+		seeds:  minHashSeeds(config.NumHashes),
+		logger: logger,
+	}, nil
+}
+
+// DedupFilter 近似重复 chunk 过滤器，实现 document.Transformer
+type DedupFilter struct {
+	config *Config
+	seeds  []uint64
+	logger logrus.FieldLogger
+}
+
+// group 一组近似重复的文档，members[0] 为最先出现的文档
+type group struct {
+	sig     signature
+	members []*schema.Document
+}
+
+func (f *DedupFilter) Transform(ctx context.Context, docs []*schema.Document, opts ...document.TransformerOption) ([]*schema.Document, error) {
+	if f == nil || f.config == nil {
+		return nil, fmt.Errorf("DedupFilter is nil")
+	}
+
+	// 两两比较每个文档与已有分组的代表文档，批次规模通常为一次上传的 chunk 数，O(n*groups) 足够
+	var groups []*group
+	for _, doc := range docs {
+		if doc == nil {
+			continue
+		}
+		sig := f.sign(doc.Content)
+		matched := false
+		for _, g := range groups {
+			similarity := f.similarity(sig, g.sig)
+			if similarity < f.config.Threshold {
+				continue
+			}
+			f.logger.WithFields(logrus.Fields{
+				"doc_id":     doc.ID,
+				"of_doc_id":  g.members[0].ID,
+				"similarity": similarity,
+			}).Debug("Detected near-duplicate chunk")
+			if f.config.OnDuplicate != nil {
+				f.config.OnDuplicate(ctx, Duplicate{
+					DocID:      doc.ID,
+					OfDocID:    g.members[0].ID,
+					Similarity: similarity,
+				})
+			}
+			g.members = append(g.members, doc)
+			matched = true
+			break
+		}
+		if !matched {
+			groups = append(groups, &group{sig: sig, members: []*schema.Document{doc}})
+		}
+	}
+
+	ret := make([]*schema.Document, 0, len(groups))
+	for _, g := range groups {
+		if f.config.Action == ActionMerge && len(g.members) > 1 {
+			ret = append(ret, merge(g.members))
+		} else {
+			ret = append(ret, g.members[0])
+		}
+	}
+	return ret, nil
+}
+
+func (f *DedupFilter) GetType() string {
+	return "DedupFilter"
+}
+
+// Similarity 返回两段文本按当前配置计算的相似度，范围 [0, 1]
+func (f *DedupFilter) Similarity(a, b string) float64 {
+	return f.similarity(f.sign(a), f.sign(b))
+}
+
+// merge 合并一组近似重复文档：保留内容最长的文档，补齐缺失的元数据并记录被合并的 ID
+func merge(members []*schema.Document) *schema.Document {
+	keep := members[0]
+	for _, doc := range members[1:] {
+		if len([]rune(doc.Content)) > len([]rune(keep.Content)) {
+			keep = doc
+		}
+	}
+
+	meta := make(map[string]any, len(keep.MetaData)+1)
+	for k, v := range keep.MetaData {
+		meta[k] = v
+	}
+	duplicateIDs := make([]string, 0, len(members)-1)
+	if existing, ok := meta[MetaKeyDuplicateIDs].([]string); ok {
+		duplicateIDs = append(duplicateIDs, existing...)
+	}
+	for _, doc := range members {
+		if doc == keep {
+			continue
+		}
+		duplicateIDs = append(duplicateIDs, doc.ID)
+		for k, v := range doc.MetaData {
+			if _, exists := meta[k]; !exists {
+				meta[k] = v
+			}
+		}
+	}
+	meta[MetaKeyDuplicateIDs] = duplicateIDs
+
+	merged := *keep
+	merged.MetaData = meta
+	return &merged
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dedup
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+const (
+	textV1 = "系统需要支持文档的上传、解析和检索功能。系统应当采用模块化设计，便于扩展和维护。所有接口都需要进行权限校验，并记录操作日志以便审计。"
+	// textV2 与 textV1 只有一处措辞不同
+	textV2 = "系统需要支持文档的上传、解析和检索功能。系统应当采用模块化设计，方便扩展和维护。所有接口都需要进行权限校验，并记录操作日志以便审计。"
+	// textV3 在 textV1 基础上追加了一句
+	textV3    = textV1 + "日志保留九十天。"
+	textOther = "The quick brown fox jumps over the lazy dog while the cat sleeps in the warm afternoon sun."
+)
+
+func TestDedupFilter(t *testing.T) {
+	ctx := context.Background()
+
+	for _, algorithm := range []Algorithm{AlgorithmMinHash, AlgorithmSimHash} {
+		convey.Convey("Test DedupFilter drop with "+string(algorithm), t, func() {
+			var dups []Duplicate
+			f, err := NewDedupFilter(ctx, &Config{
+				Algorithm:   algorithm,
+				Threshold:   0.8,
+				OnDuplicate: func(ctx context.Context, dup Duplicate) { dups = append(dups, dup) },
+			})
+			convey.So(err, convey.ShouldBeNil)
+
+			docs, err := f.Transform(ctx, []*schema.Document{
+				{ID: "v1", Content: textV1},
+				{ID: "other", Content: textOther},
+				{ID: "v2", Content: textV2},
+				{ID: "v1-copy", Content: "  " + textV1 + "\n"},
+			})
+			convey.So(err, convey.ShouldBeNil)
+			convey.So(len(docs), convey.ShouldEqual, 2)
+			convey.So(docs[0].ID, convey.ShouldEqual, "v1")
+			convey.So(docs[1].ID, convey.ShouldEqual, "other")
+
+			convey.So(len(dups), convey.ShouldEqual, 2)
+			convey.So(dups[0].DocID, convey.ShouldEqual, "v2")
+			convey.So(dups[0].OfDocID, convey.ShouldEqual, "v1")
+			convey.So(dups[1].Similarity, convey.ShouldEqual, 1)
+		})
+	}
+
+	convey.Convey("Test DedupFilter merge", t, func() {
+		f, err := NewDedupFilter(ctx, &Config{Action: ActionMerge, Threshold: 0.8})
+		convey.So(err, convey.ShouldBeNil)
+
+		docs, err := f.Transform(ctx, []*schema.Document{
+			{ID: "v1", Content: textV1, MetaData: map[string]any{"source": "v1.pdf", "page": 1}},
+			{ID: "v3", Content: textV3, MetaData: map[string]any{"source": "v3.pdf"}},
+			{ID: "other", Content: textOther},
+		})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(len(docs), convey.ShouldEqual, 2)
+
+		// 保留内容最长的版本，补齐缺失的元数据
+		convey.So(docs[0].ID, convey.ShouldEqual, "v3")
+		convey.So(docs[0].Content, convey.ShouldEqual, textV3)
+		convey.So(docs[0].MetaData["source"], convey.ShouldEqual, "v3.pdf")
+		convey.So(docs[0].MetaData["page"], convey.ShouldEqual, 1)
+		convey.So(docs[0].MetaData[MetaKeyDuplicateIDs], convey.ShouldResemble, []string{"v1"})
+		convey.So(docs[1].ID, convey.ShouldEqual, "other")
+		convey.So(docs[1].MetaData, convey.ShouldBeNil)
+	})
+
+	convey.Convey("Test DedupFilter Similarity", t, func() {
+		f, err := NewDedupFilter(ctx, nil)
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(f.Similarity(textV1, textV1), convey.ShouldEqual, 1)
+		convey.So(f.Similarity(textV1, textV2), convey.ShouldBeGreaterThan, 0.7)
+		convey.So(f.Similarity(textV1, textOther), convey.ShouldBeLessThan, 0.1)
+		convey.So(f.Similarity("", "。。。"), convey.ShouldEqual, 1)
+		convey.So(f.Similarity("", textV1), convey.ShouldEqual, 0)
+	})
+
+	convey.Convey("Test NewDedupFilter validation", t, func() {
+		_, err := NewDedupFilter(ctx, &Config{Algorithm: "lsh"})
+		convey.So(err, convey.ShouldNotBeNil)
+		_, err = NewDedupFilter(ctx, &Config{Action: "keep"})
+		convey.So(err, convey.ShouldNotBeNil)
+		_, err = NewDedupFilter(ctx, &Config{Threshold: 1.5})
+		convey.So(err, convey.ShouldNotBeNil)
+	})
+}
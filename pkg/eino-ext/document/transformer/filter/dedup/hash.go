@@ -0,0 +1,157 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+package dedup
+
+import (
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"strings"
+	"unicode"
+)
+
+// signature 文档的 MinHash 签名或 SimHash 指纹
+type signature struct {
+	minhash []uint64
+	simhash uint64
+	// empty 表示文本在归一化后为空，空文本只与空文本相同
+	empty bool
+}
+
+// sign 按配置的算法计算文本签名
+func (f *DedupFilter) sign(text string) signature {
+	shingles := shingle(text, f.config.ShingleSize)
+	if len(shingles) == 0 {
+		return signature{empty: true}
+	}
+	if f.config.Algorithm == AlgorithmSimHash {
+		return signature{simhash: simHash(shingles)}
+	}
+	return signature{minhash: minHash(shingles, f.seeds)}
+}
+
+// similarity 计算两个签名的相似度
+func (f *DedupFilter) similarity(a, b signature) float64 {
+	if a.empty || b.empty {
+		if a.empty && b.empty {
+			return 1
+		}
+		return 0
+	}
+	if f.config.Algorithm == AlgorithmSimHash {
+		return 1 - float64(bits.OnesCount64(a.simhash^b.simhash))/64
+	}
+	matches := 0
+	for i := range a.minhash {
+		if a.minhash[i] == b.minhash[i] {
+			matches++
+		}
+	}
+	return float64(matches) / float64(len(a.minhash))
+}
+
+// shingle 去掉空白和标点并转为小写后，按 size 个字符切分为重叠的 shingle，返回其哈希集合
+// 文本短于 size 时整段作为一个 shingle
+func shingle(text string, size int) map[uint64]struct{} {
+	runes := make([]rune, 0, len(text))
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			runes = append(runes, unicode.ToLower(r))
+		}
+	}
+	set := make(map[uint64]struct{})
+	if len(runes) == 0 {
+		return set
+	}
+	if len(runes) <= size {
+		set[hashString(string(runes))] = struct{}{}
+		return set
+	}
+	var sb strings.Builder
+	for i := 0; i+size <= len(runes); i++ {
+		sb.Reset()
+		for _, r := range runes[i : i+size] {
+			sb.WriteRune(r)
+		}
+		set[hashString(sb.String())] = struct{}{}
+	}
+	return set
+}
+
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// mix64 splitmix64 的最终混合函数，用于从一个哈希派生出多个独立的哈希
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// minHashSeeds 生成固定的种子，保证同一配置下签名可复现
+func minHashSeeds(n int) []uint64 {
+	seeds := make([]uint64, n)
+	state := uint64(0x9e3779b97f4a7c15)
+	for i := range seeds {
+		state += 0x9e3779b97f4a7c15
+		seeds[i] = mix64(state)
+	}
+	return seeds
+}
+
+func minHash(shingles map[uint64]struct{}, seeds []uint64) []uint64 {
+	sig := make([]uint64, len(seeds))
+	for i := range sig {
+		sig[i] = math.MaxUint64
+	}
+	for h := range shingles {
+		for i, seed := range seeds {
+			if v := mix64(h ^ seed); v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+	return sig
+}
+
+func simHash(shingles map[uint64]struct{}) uint64 {
+	var weights [64]int
+	for h := range shingles {
+		h = mix64(h)
+		for bit := 0; bit < 64; bit++ {
+			if h&(1<<uint(bit)) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+	var fingerprint uint64
+	for bit, w := range weights {
+		if w > 0 {
+			fingerprint |= 1 << uint(bit)
+		}
+	}
+	return fingerprint
+}
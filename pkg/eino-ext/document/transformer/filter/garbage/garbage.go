@@ -0,0 +1,318 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package garbage
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/cloudwego/eino/components/document"
+	"github.com/cloudwego/eino/schema"
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/sego"
+	"github.com/sirupsen/logrus"
+)
+
+// Reason describes why a chunk was considered garbage
+type Reason string
+
+const (
+	// ReasonNoTokens: the chunk produced no tokens
+	ReasonNoTokens Reason = "no_tokens"
+	// ReasonLowValidTokens: the ratio of valid (multi-character) tokens is below MinValidTokenRatio
+	ReasonLowValidTokens Reason = "low_valid_tokens"
+	// ReasonSingleChars: few valid tokens and too many single-character tokens
+	ReasonSingleChars Reason = "single_chars"
+	// ReasonNonCJK: the ratio of non-CJK letters exceeds MaxNonCJKRatio
+	ReasonNonCJK Reason = "non_cjk"
+	// ReasonSymbols: the ratio of symbols and punctuation exceeds MaxSymbolRatio
+	ReasonSymbols Reason = "symbols"
+	// ReasonLowEntropy: the character entropy is below MinEntropy (repetitive content)
+	ReasonLowEntropy Reason = "low_entropy"
+	// ReasonHighEntropy: the character entropy exceeds MaxEntropy (random content)
+	ReasonHighEntropy Reason = "high_entropy"
+	// ReasonScript: too few letters belong to AllowedScripts
+	ReasonScript Reason = "script"
+)
+
+// Stats are the measurements a chunk is judged on
+type Stats struct {
+	Tokens             int
+	ValidTokenRatio    float64
+	SingleCharRatio    float64
+	NonCJKRatio        float64 // 非中日韩字母占全部字母的比例
+	SymbolRatio        float64 // 标点、符号和替换字符占非空白字符的比例
+	Entropy            float64 // 字符分布的香农熵（bit/字符）
+	AllowedScriptRatio float64 // 属于 AllowedScripts 的字母占全部字母的比例，未配置时为 1
+}
+
+// Dropped describes a chunk removed by the filter
+type Dropped struct {
+	DocID   string
+	Content string
+	Reason  Reason
+	Stats   Stats
+}
+
+// DropCallback is called for every dropped chunk
+type DropCallback func(ctx context.Context, dropped Dropped)
+
+type Config struct {
+	// MinValidTokenRatio is the minimum ratio of valid tokens (at least two characters and
+	// containing a letter or digit) among all sego tokens. Default is 0.2.
+	MinValidTokenRatio float64
+	// WeakValidTokenRatio and MaxSingleCharRatio: a chunk whose valid token ratio is below
+	// WeakValidTokenRatio and whose single-character token ratio exceeds MaxSingleCharRatio
+	// is garbage. Defaults are 0.3 and 0.5.
+	WeakValidTokenRatio float64
+	MaxSingleCharRatio  float64
+	// MaxNonCJKRatio is the maximum ratio of non-CJK letters among all letters, for corpora
+	// that are expected to be Chinese/Japanese/Korean. 0 disables the check.
+	MaxNonCJKRatio float64
+	// MaxSymbolRatio is the maximum ratio of punctuation, symbols and replacement characters
+	// among all non-space characters. 0 disables the check.
+	MaxSymbolRatio float64
+	// MinEntropy and MaxEntropy bound the Shannon entropy (bits per character) of the chunk.
+	// Very low entropy indicates repeated patterns, very high entropy random bytes.
+	// 0 disables the respective check.
+	MinEntropy float64
+	MaxEntropy float64
+	// AllowedScripts is a language allow-list expressed as unicode script names,
+	// e.g. []string{"Han", "Latin"}. Empty allows all scripts.
+	AllowedScripts []string
+	// MinAllowedScriptRatio is the minimum ratio of letters in AllowedScripts. Default is 0.5.
+	MinAllowedScriptRatio float64
+	// MinRunes skips the checks for chunks shorter than this many characters. Default is 0.
+	MinRunes int
+	// Logger receives a debug event for every dropped chunk. Defaults to logrus.StandardLogger().
+	Logger logrus.FieldLogger
+	// OnDrop is an optional callback invoked for every dropped chunk.
+	OnDrop DropCallback
+}
+
+// defaultFilter 使用默认阈值的过滤器，供 IsGarbage 使用
+var defaultFilter = newGarbageFilter(&Config{})
+
+// IsGarbage 使用默认阈值判断文本是否为乱码（如 PDF 解析产生的损坏文本）
+func IsGarbage(text string) bool {
+	garbage, _, _ := defaultFilter.Check(text)
+	return garbage
+}
+
+// NewGarbageFilter 创建乱码过滤器，Transform 会丢弃被判断为乱码的文档
+func NewGarbageFilter(ctx context.Context, config *Config) (*GarbageFilter, error) {
+	if config == nil {
+		config = &Config{}
+	}
+	for _, name := range config.AllowedScripts {
+		if _, ok := unicode.Scripts[name]; !ok {
+			return nil, fmt.Errorf("unknown unicode script %q", name)
+		}
+	}
+	return newGarbageFilter(config), nil
+}
+
+func newGarbageFilter(config *Config) *GarbageFilter {
+	if config.MinValidTokenRatio <= 0 {
+		config.MinValidTokenRatio = 0.2
+	}
+	if config.WeakValidTokenRatio <= 0 {
+		config.WeakValidTokenRatio = 0.3
+	}
+	if config.MaxSingleCharRatio <= 0 {
+		config.MaxSingleCharRatio = 0.5
+	}
+	if config.MinAllowedScriptRatio <= 0 {
+		config.MinAllowedScriptRatio = 0.5
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = logrus.StandardLogger()
+	}
+	scripts := make([]*unicode.RangeTable, 0, len(config.AllowedScripts))
+	for _, name := range config.AllowedScripts {
+		if table, ok := unicode.Scripts[name]; ok {
+			scripts = append(scripts, table)
+		}
+	}
+	return &GarbageFilter{
+		config:  config,
+		scripts: scripts,
+		logger:  logger,
+	}
+}
+
+// GarbageFilter 可配置的乱码 chunk 过滤器，实现 document.Transformer
+type GarbageFilter struct {
+	config  *Config
+	scripts []*unicode.RangeTable
+	logger  logrus.FieldLogger
+}
+
+func (f *GarbageFilter) Transform(ctx context.Context, docs []*schema.Document, opts ...document.TransformerOption) ([]*schema.Document, error) {
+	if f == nil || f.config == nil {
+		return nil, fmt.Errorf("GarbageFilter is nil")
+	}
+
+	ret := make([]*schema.Document, 0, len(docs))
+	for _, doc := range docs {
+		if doc == nil {
+			continue
+		}
+		garbage, reason, stats := f.Check(doc.Content)
+		if !garbage {
+			ret = append(ret, doc)
+			continue
+		}
+
+		f.logger.WithFields(logrus.Fields{
+			"doc_id": doc.ID,
+			"reason": reason,
+		}).Debug("Dropped garbage chunk")
+		if f.config.OnDrop != nil {
+			f.config.OnDrop(ctx, Dropped{
+				DocID:   doc.ID,
+				Content: doc.Content,
+				Reason:  reason,
+				Stats:   stats,
+			})
+		}
+	}
+	return ret, nil
+}
+
+func (f *GarbageFilter) GetType() string {
+	return "GarbageFilter"
+}
+
+// Check 判断文本是否为乱码，返回判断原因和统计数据；空文本不算乱码
+func (f *GarbageFilter) Check(text string) (bool, Reason, Stats) {
+	stats := Stats{AllowedScriptRatio: 1}
+	if len(text) == 0 || len([]rune(text)) < f.config.MinRunes {
+		return false, "", stats
+	}
+
+	f.charStats(text, &stats)
+	if reason := f.checkChars(stats); reason != "" {
+		return true, reason, stats
+	}
+
+	// 基于 sego 分词判断
+	segmenter, err := sego.GetSegmenter()
+	if err != nil {
+		// 如果 sego 初始化失败，无法判断，不认为是乱码
+		return false, "", stats
+	}
+
+	var validTokens, singleCharTokens int
+	for _, seg := range segmenter.Segment([]byte(text)) {
+		tokenRunes := []rune(strings.TrimSpace(seg.Token().Text()))
+		if len(tokenRunes) == 0 {
+			continue
+		}
+		stats.Tokens++
+
+		if len(tokenRunes) == 1 {
+			singleCharTokens++
+			continue
+		}
+		// 多字符词，检查是否包含有效字符
+		for _, r := range tokenRunes {
+			if unicode.Is(unicode.Han, r) || unicode.IsLetter(r) || unicode.IsNumber(r) {
+				validTokens++
+				break
+			}
+		}
+	}
+	if stats.Tokens == 0 {
+		return true, ReasonNoTokens, stats
+	}
+
+	stats.ValidTokenRatio = float64(validTokens) / float64(stats.Tokens)
+	stats.SingleCharRatio = float64(singleCharTokens) / float64(stats.Tokens)
+	if stats.ValidTokenRatio < f.config.MinValidTokenRatio {
+		return true, ReasonLowValidTokens, stats
+	}
+	if stats.ValidTokenRatio < f.config.WeakValidTokenRatio && stats.SingleCharRatio > f.config.MaxSingleCharRatio {
+		return true, ReasonSingleChars, stats
+	}
+	return false, "", stats
+}
+
+// charStats 统计字符级别的指标
+func (f *GarbageFilter) charStats(text string, stats *Stats) {
+	var (
+		nonSpace, letters, nonCJK, symbols, allowed int
+		counts                                      = make(map[rune]int)
+	)
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		nonSpace++
+		counts[r]++
+		switch {
+		case unicode.IsLetter(r):
+			letters++
+			if !isCJK(r) {
+				nonCJK++
+			}
+			if len(f.scripts) == 0 || unicode.In(r, f.scripts...) {
+				allowed++
+			}
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || r == unicode.ReplacementChar || unicode.IsControl(r):
+			symbols++
+		}
+	}
+
+	if letters > 0 {
+		stats.NonCJKRatio = float64(nonCJK) / float64(letters)
+		stats.AllowedScriptRatio = float64(allowed) / float64(letters)
+	}
+	if nonSpace > 0 {
+		stats.SymbolRatio = float64(symbols) / float64(nonSpace)
+		for _, c := range counts {
+			p := float64(c) / float64(nonSpace)
+			stats.Entropy -= p * math.Log2(p)
+		}
+	}
+}
+
+// checkChars 根据字符级别的指标判断，未命中时返回空字符串
+func (f *GarbageFilter) checkChars(stats Stats) Reason {
+	switch {
+	case f.config.MaxSymbolRatio > 0 && stats.SymbolRatio > f.config.MaxSymbolRatio:
+		return ReasonSymbols
+	case f.config.MaxNonCJKRatio > 0 && stats.NonCJKRatio > f.config.MaxNonCJKRatio:
+		return ReasonNonCJK
+	case len(f.scripts) > 0 && stats.AllowedScriptRatio < f.config.MinAllowedScriptRatio:
+		return ReasonScript
+	case f.config.MinEntropy > 0 && stats.Entropy < f.config.MinEntropy:
+		return ReasonLowEntropy
+	case f.config.MaxEntropy > 0 && stats.Entropy > f.config.MaxEntropy:
+		return ReasonHighEntropy
+	}
+	return ""
+}
+
+// isCJK 判断字符是否为中日韩文字
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
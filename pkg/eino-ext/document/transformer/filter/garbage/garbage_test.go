@@ -0,0 +1,101 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package garbage
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+const garbageText = "Au1k)g¡,9&C88DGAA'88DGAA'88DGAA'g¡,9&C264,#A'24\"#,264,#A'264,#A'264,#A'KKE,#A'PKSC%\"LE'88E›K(,#A'88E›K(,#A'Au1k)Au1k)Au1k)7F')7F')7F')"
+
+func TestGarbageFilter(t *testing.T) {
+	convey.Convey("Test GarbageFilter defaults", t, func() {
+		ctx := context.Background()
+		convey.So(IsGarbage(garbageText), convey.ShouldBeTrue)
+		convey.So(IsGarbage("这是一段正常的中文文本，用于测试乱码过滤。"), convey.ShouldBeFalse)
+		convey.So(IsGarbage("This is a normal English sentence."), convey.ShouldBeFalse)
+		convey.So(IsGarbage(""), convey.ShouldBeFalse)
+
+		var dropped []Dropped
+		filter, err := NewGarbageFilter(ctx, &Config{
+			OnDrop: func(ctx context.Context, d Dropped) {
+				dropped = append(dropped, d)
+			},
+		})
+		convey.So(err, convey.ShouldBeNil)
+		docs, err := filter.Transform(ctx, []*schema.Document{
+			{ID: "ok", Content: "正常的段落内容，包含多个有效词语。"},
+			{ID: "bad", Content: garbageText},
+			nil,
+		})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(len(docs), convey.ShouldEqual, 1)
+		convey.So(docs[0].ID, convey.ShouldEqual, "ok")
+		convey.So(len(dropped), convey.ShouldEqual, 1)
+		convey.So(dropped[0].DocID, convey.ShouldEqual, "bad")
+		convey.So(dropped[0].Reason, convey.ShouldNotBeEmpty)
+		convey.So(dropped[0].Stats.Tokens, convey.ShouldBeGreaterThan, 0)
+	})
+
+	convey.Convey("Test GarbageFilter character thresholds", t, func() {
+		ctx := context.Background()
+
+		// 中文语料中的大段英文
+		filter, err := NewGarbageFilter(ctx, &Config{MaxNonCJKRatio: 0.5})
+		convey.So(err, convey.ShouldBeNil)
+		garbage, reason, stats := filter.Check("This is a normal English sentence.")
+		convey.So(garbage, convey.ShouldBeTrue)
+		convey.So(reason, convey.ShouldEqual, ReasonNonCJK)
+		convey.So(stats.NonCJKRatio, convey.ShouldEqual, 1)
+		garbage, _, _ = filter.Check("这是中文，夹杂少量 English。")
+		convey.So(garbage, convey.ShouldBeFalse)
+
+		filter, err = NewGarbageFilter(ctx, &Config{MaxSymbolRatio: 0.3})
+		convey.So(err, convey.ShouldBeNil)
+		_, reason, _ = filter.Check("正常文本 ###@@@!!!$$$%%%^^^")
+		convey.So(reason, convey.ShouldEqual, ReasonSymbols)
+
+		// 重复内容熵很低
+		filter, err = NewGarbageFilter(ctx, &Config{MinEntropy: 2})
+		convey.So(err, convey.ShouldBeNil)
+		_, reason, stats = filter.Check(strings.Repeat("啊啊啊哦", 10))
+		convey.So(reason, convey.ShouldEqual, ReasonLowEntropy)
+		convey.So(stats.Entropy, convey.ShouldBeLessThan, 2)
+
+		// 语言白名单
+		filter, err = NewGarbageFilter(ctx, &Config{AllowedScripts: []string{"Han", "Latin"}})
+		convey.So(err, convey.ShouldBeNil)
+		_, reason, _ = filter.Check("Привет, как дела? Это русский текст.")
+		convey.So(reason, convey.ShouldEqual, ReasonScript)
+		garbage, _, _ = filter.Check("中文和 English 混合的正常文本。")
+		convey.So(garbage, convey.ShouldBeFalse)
+
+		// 短文本不判断
+		filter, err = NewGarbageFilter(ctx, &Config{MinRunes: 1000})
+		convey.So(err, convey.ShouldBeNil)
+		garbage, _, _ = filter.Check(garbageText)
+		convey.So(garbage, convey.ShouldBeFalse)
+
+		_, err = NewGarbageFilter(ctx, &Config{AllowedScripts: []string{"Klingon"}})
+		convey.So(err, convey.ShouldNotBeNil)
+	})
+}
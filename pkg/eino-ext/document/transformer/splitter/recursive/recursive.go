@@ -0,0 +1,217 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package recursive
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/components/document"
+	"github.com/cloudwego/eino/schema"
+)
+
+// DefaultSeparators 默认的分隔符层级：段落、换行、中英文句末标点、分句标点、空格，最后按字符切分
+var DefaultSeparators = []string{"\n\n", "\n", "。", "！", "？", ". ", "! ", "? ", "；", "; ", "，", ", ", " ", ""}
+
+// IDGenerator generates new IDs for split chunks
+type IDGenerator func(ctx context.Context, originalID string, splitIndex int) string
+
+func defaultIDGenerator(ctx context.Context, originalID string, _ int) string {
+	return originalID
+}
+
+type Config struct {
+	// ChunkSize is the maximum size of a chunk, measured by LengthFunc.
+	// A chunk only exceeds it when a piece cannot be split further by any separator.
+	// Default is 1000.
+	ChunkSize int
+	// ChunkOverlap is the maximum size of the trailing pieces of the previous chunk that are
+	// repeated at the beginning of the next chunk. Must be smaller than ChunkSize. Default is 0.
+	ChunkOverlap int
+	// Separators is the separator hierarchy, tried in order: text is split by the first
+	// separator it contains, and pieces still larger than ChunkSize are split recursively
+	// by the following separators. An empty separator splits into single characters.
+	// Separators are kept at the end of the preceding piece. Default is DefaultSeparators.
+	Separators []string
+	// LengthFunc measures the size of a piece of text. Defaults to counting characters (runes).
+	LengthFunc func(text string) int
+	// IDGenerator is an optional function to generate new IDs for split chunks.
+	// If nil, the original document ID will be used for all splits.
+	IDGenerator IDGenerator
+}
+
+// NewRecursiveSplitter 创建递归字符分割器：按分隔符层级递归切分文本，再把小片段合并为不超过 ChunkSize 的 chunk
+// 不做语义分析，适合对切分质量要求不高、需要快速稳定切分的场景
+func NewRecursiveSplitter(ctx context.Context, config *Config) (document.Transformer, error) {
+	if config == nil {
+		config = &Config{}
+	}
+	if config.ChunkSize <= 0 {
+		config.ChunkSize = 1000 // 默认最大 1000 字符
+	}
+	if config.ChunkOverlap < 0 {
+		config.ChunkOverlap = 0
+	}
+	if config.ChunkOverlap >= config.ChunkSize {
+		return nil, fmt.Errorf("ChunkOverlap (%d) must be smaller than ChunkSize (%d)", config.ChunkOverlap, config.ChunkSize)
+	}
+	separators := config.Separators
+	if len(separators) == 0 {
+		separators = DefaultSeparators
+	}
+	lengthFunc := config.LengthFunc
+	if lengthFunc == nil {
+		lengthFunc = utf8.RuneCountInString
+	}
+	idGenerator := config.IDGenerator
+	if idGenerator == nil {
+		idGenerator = defaultIDGenerator
+	}
+	return &recursiveSplitter{
+		config:      config,
+		separators:  separators,
+		lengthFunc:  lengthFunc,
+		idGenerator: idGenerator,
+	}, nil
+}
+
+type recursiveSplitter struct {
+	config      *Config
+	separators  []string
+	lengthFunc  func(text string) int
+	idGenerator IDGenerator
+}
+
+func (s *recursiveSplitter) Transform(ctx context.Context, docs []*schema.Document, opts ...document.TransformerOption) ([]*schema.Document, error) {
+	if s == nil || s.config == nil {
+		return nil, fmt.Errorf("recursiveSplitter is nil")
+	}
+
+	ret := make([]*schema.Document, 0)
+	for _, doc := range docs {
+		if doc == nil {
+			continue
+		}
+		for i, chunk := range s.splitText(doc.Content, s.separators) {
+			ret = append(ret, &schema.Document{
+				ID:       s.idGenerator(ctx, doc.ID, i),
+				Content:  chunk,
+				MetaData: deepCopyAnyMap(doc.MetaData),
+			})
+		}
+	}
+	return ret, nil
+}
+
+func (s *recursiveSplitter) GetType() string {
+	return "RecursiveSplitter"
+}
+
+// splitText 使用文本中出现的第一个分隔符切分，仍然超过 ChunkSize 的片段使用后续分隔符递归切分
+func (s *recursiveSplitter) splitText(text string, separators []string) []string {
+	separator := ""
+	var rest []string
+	for i, sep := range separators {
+		if sep == "" || strings.Contains(text, sep) {
+			separator = sep
+			rest = separators[i+1:]
+			break
+		}
+	}
+
+	var (
+		chunks []string
+		pieces []string // 尚未合并的小片段
+	)
+	for _, piece := range splitKeepSeparator(text, separator) {
+		if s.lengthFunc(piece) <= s.config.ChunkSize {
+			pieces = append(pieces, piece)
+			continue
+		}
+		chunks = append(chunks, s.mergePieces(pieces)...)
+		pieces = nil
+		if len(rest) == 0 {
+			// 没有更细的分隔符，只能保留超长片段
+			if trimmed := strings.TrimSpace(piece); trimmed != "" {
+				chunks = append(chunks, trimmed)
+			}
+			continue
+		}
+		chunks = append(chunks, s.splitText(piece, rest)...)
+	}
+	return append(chunks, s.mergePieces(pieces)...)
+}
+
+// mergePieces 将小片段依次合并为不超过 ChunkSize 的 chunk，相邻 chunk 之间保留最多 ChunkOverlap 的重叠片段
+func (s *recursiveSplitter) mergePieces(pieces []string) []string {
+	var (
+		chunks  []string
+		current []string
+		total   int
+	)
+	emit := func() {
+		if chunk := strings.TrimSpace(strings.Join(current, "")); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+	}
+	for _, piece := range pieces {
+		length := s.lengthFunc(piece)
+		if total+length > s.config.ChunkSize && len(current) > 0 {
+			emit()
+			// 从头部移除片段，直到剩余部分不超过重叠大小且能容纳新片段
+			for len(current) > 0 && (total > s.config.ChunkOverlap || total+length > s.config.ChunkSize) {
+				total -= s.lengthFunc(current[0])
+				current = current[1:]
+			}
+		}
+		current = append(current, piece)
+		total += length
+	}
+	if len(current) > 0 {
+		emit()
+	}
+	return chunks
+}
+
+// splitKeepSeparator 按分隔符切分文本，分隔符保留在前一个片段的末尾；分隔符为空时按字符切分
+func splitKeepSeparator(text, separator string) []string {
+	if separator == "" {
+		pieces := make([]string, 0, utf8.RuneCountInString(text))
+		for _, r := range text {
+			pieces = append(pieces, string(r))
+		}
+		return pieces
+	}
+	pieces := strings.SplitAfter(text, separator)
+	if len(pieces) > 0 && pieces[len(pieces)-1] == "" {
+		pieces = pieces[:len(pieces)-1]
+	}
+	return pieces
+}
+
+func deepCopyAnyMap(anyMap map[string]any) map[string]any {
+	if anyMap == nil {
+		return nil
+	}
+	ret := make(map[string]any)
+	for k, v := range anyMap {
+		ret[k] = v
+	}
+	return ret
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package recursive
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestRecursiveSplitter(t *testing.T) {
+	convey.Convey("Test RecursiveSplitter separator hierarchy", t, func() {
+		ctx := context.Background()
+		text := "第一段第一句。第一段第二句。\n\n第二段很短。\n\n第三段是一个非常非常长的句子，包含多个分句，需要继续按逗号切分，才能满足大小限制。"
+
+		splitter, err := NewRecursiveSplitter(ctx, &Config{ChunkSize: 20})
+		convey.So(err, convey.ShouldBeNil)
+		splitDocs, err := splitter.Transform(ctx, []*schema.Document{{
+			ID:       "doc1",
+			Content:  text,
+			MetaData: map[string]any{"source": "a.txt"},
+		}})
+		convey.So(err, convey.ShouldBeNil)
+
+		contents := make([]string, 0, len(splitDocs))
+		for _, doc := range splitDocs {
+			convey.So(utf8.RuneCountInString(doc.Content), convey.ShouldBeLessThanOrEqualTo, 20)
+			convey.So(doc.ID, convey.ShouldEqual, "doc1")
+			convey.So(doc.MetaData["source"], convey.ShouldEqual, "a.txt")
+			contents = append(contents, doc.Content)
+		}
+		convey.So(contents[0], convey.ShouldEqual, "第一段第一句。第一段第二句。")
+		convey.So(contents[1], convey.ShouldEqual, "第二段很短。")
+		// 超长段落按逗号切分，分隔符保留在片段末尾
+		convey.So(contents[2], convey.ShouldEqual, "第三段是一个非常非常长的句子，")
+		// 不重叠时所有内容都保留且只出现一次
+		convey.So(strings.Join(contents, ""), convey.ShouldEqual, strings.ReplaceAll(text, "\n", ""))
+	})
+
+	convey.Convey("Test RecursiveSplitter ChunkOverlap", t, func() {
+		ctx := context.Background()
+		text := "one two three four five six seven eight nine ten"
+
+		splitter, err := NewRecursiveSplitter(ctx, &Config{ChunkSize: 15, ChunkOverlap: 6})
+		convey.So(err, convey.ShouldBeNil)
+		splitDocs, err := splitter.Transform(ctx, []*schema.Document{{ID: "doc2", Content: text}})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(len(splitDocs), convey.ShouldBeGreaterThan, 1)
+		for i := 1; i < len(splitDocs); i++ {
+			prevWords := strings.Fields(splitDocs[i-1].Content)
+			// 每个 chunk 以上一个 chunk 的最后一个单词开头
+			convey.So(splitDocs[i].Content, convey.ShouldStartWith, prevWords[len(prevWords)-1])
+		}
+		convey.So(splitDocs[len(splitDocs)-1].Content, convey.ShouldEndWith, "ten")
+
+		_, err = NewRecursiveSplitter(ctx, &Config{ChunkSize: 10, ChunkOverlap: 10})
+		convey.So(err, convey.ShouldNotBeNil)
+	})
+
+	convey.Convey("Test RecursiveSplitter character fallback and LengthFunc", t, func() {
+		ctx := context.Background()
+
+		// 没有任何分隔符时按字符切分
+		splitter, err := NewRecursiveSplitter(ctx, &Config{ChunkSize: 4})
+		convey.So(err, convey.ShouldBeNil)
+		splitDocs, err := splitter.Transform(ctx, []*schema.Document{{ID: "doc3", Content: "abcdefghij"}})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(len(splitDocs), convey.ShouldEqual, 3)
+		convey.So(splitDocs[2].Content, convey.ShouldEqual, "ij")
+
+		// 按单词数计算大小
+		splitter, err = NewRecursiveSplitter(ctx, &Config{
+			ChunkSize:  3,
+			Separators: []string{" "},
+			LengthFunc: func(text string) int { return len(strings.Fields(text)) },
+		})
+		convey.So(err, convey.ShouldBeNil)
+		splitDocs, err = splitter.Transform(ctx, []*schema.Document{{ID: "doc4", Content: "a bb ccc dddd eeeee ffffff g"}})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(len(splitDocs), convey.ShouldEqual, 3)
+		convey.So(splitDocs[0].Content, convey.ShouldEqual, "a bb ccc")
+
+		splitDocs, err = splitter.Transform(ctx, []*schema.Document{nil, {ID: "empty", Content: "  "}})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(len(splitDocs), convey.ShouldEqual, 0)
+	})
+}
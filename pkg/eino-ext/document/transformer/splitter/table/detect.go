@@ -0,0 +1,231 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package table
+
+import (
+	"encoding/csv"
+	"regexp"
+	"strings"
+)
+
+// minCSVLines CSV 块至少需要的行数（表头 + 2 行数据），避免把带逗号的普通句子误判为表格
+const minCSVLines = 3
+
+var (
+	separatorRowPattern  = regexp.MustCompile(`\|[\s:]*[-:]+[\s:]*\|`)
+	separatorCellPattern = regexp.MustCompile(`^:?-+:?$`)
+)
+
+// table 文档中识别出的一个表格
+type table struct {
+	format  Format
+	header  []string   // 表头原始行（Markdown 包含分隔行），没有表头时为空
+	rows    []string   // 数据行原始文本
+	columns []string   // 列名，没有表头时为空
+	records [][]string // 每个数据行的单元格
+}
+
+// block 文档中的一段连续内容：表格或普通文本
+type block struct {
+	text  string
+	table *table
+}
+
+// IsMarkdownRow 判断一行是否是 Markdown 表格行
+// 表格行特征：
+// - 以 | 开头（可选前导空格）
+// - 包含至少一个 | 分隔符
+// - 或者是表格分隔行（如 | --- | :---: |）
+func IsMarkdownRow(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if len(trimmed) == 0 {
+		return false
+	}
+
+	// 必须包含 | 字符
+	if !strings.Contains(trimmed, "|") {
+		return false
+	}
+
+	// 检查是否是表格分隔行（包含 --- 或 :--- 等格式）
+	// 例如：| --- | --- | 或 |:---|:---:|---:|
+	if strings.Contains(trimmed, "---") {
+		return separatorRowPattern.MatchString(trimmed)
+	}
+
+	// 普通表格行：以 | 开头（去除前导空格后）
+	return strings.HasPrefix(trimmed, "|")
+}
+
+// isSeparatorRow 判断 Markdown 表格行是否为表头分隔行
+func isSeparatorRow(line string) bool {
+	cells := markdownCells(line)
+	if len(cells) == 0 {
+		return false
+	}
+	for _, cell := range cells {
+		if !separatorCellPattern.MatchString(cell) {
+			return false
+		}
+	}
+	return true
+}
+
+// markdownCells 解析 Markdown 表格行的单元格，支持 \| 转义
+func markdownCells(line string) []string {
+	trimmed := strings.TrimSpace(line)
+	trimmed = strings.TrimPrefix(trimmed, "|")
+	if strings.HasSuffix(trimmed, "|") && !strings.HasSuffix(trimmed, `\|`) {
+		trimmed = trimmed[:len(trimmed)-1]
+	}
+
+	var (
+		cells []string
+		cell  strings.Builder
+	)
+	for i := 0; i < len(trimmed); i++ {
+		c := trimmed[i]
+		if c == '\\' && i+1 < len(trimmed) && trimmed[i+1] == '|' {
+			cell.WriteByte('|')
+			i++
+			continue
+		}
+		if c == '|' {
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+			continue
+		}
+		cell.WriteByte(c)
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// csvFields 将一行解析为 CSV 字段，少于 2 个字段时返回 false
+func csvFields(line string) ([]string, bool) {
+	if !strings.Contains(line, ",") {
+		return nil, false
+	}
+	r := csv.NewReader(strings.NewReader(line))
+	r.LazyQuotes = true
+	r.TrimLeadingSpace = true
+	fields, err := r.Read()
+	if err != nil || len(fields) < 2 {
+		return nil, false
+	}
+	return fields, true
+}
+
+// parseBlocks 将文本拆分为表格和普通文本块
+func parseBlocks(text string, detectCSV bool) []block {
+	lines := strings.Split(text, "\n")
+
+	var (
+		blocks []block
+		buf    []string
+	)
+	flushText := func() {
+		if content := strings.TrimSpace(strings.Join(buf, "\n")); content != "" {
+			blocks = append(blocks, block{text: content})
+		}
+		buf = nil
+	}
+
+	for i := 0; i < len(lines); {
+		if IsMarkdownRow(lines[i]) {
+			end := i
+			for end < len(lines) && IsMarkdownRow(lines[end]) {
+				end++
+			}
+			flushText()
+			blocks = append(blocks, block{table: newMarkdownTable(lines[i:end])})
+			i = end
+			continue
+		}
+
+		if detectCSV {
+			if end := csvBlockEnd(lines, i); end-i >= minCSVLines {
+				flushText()
+				blocks = append(blocks, block{table: newCSVTable(lines[i:end])})
+				i = end
+				continue
+			}
+		}
+
+		buf = append(buf, lines[i])
+		i++
+	}
+	flushText()
+
+	return blocks
+}
+
+// csvBlockEnd 返回从 start 开始字段数一致的连续 CSV 行的结束位置（不包含）
+func csvBlockEnd(lines []string, start int) int {
+	first, ok := csvFields(lines[start])
+	if !ok {
+		return start
+	}
+	end := start + 1
+	for end < len(lines) {
+		fields, ok := csvFields(lines[end])
+		if !ok || len(fields) != len(first) {
+			break
+		}
+		end++
+	}
+	return end
+}
+
+// newMarkdownTable 从连续的 Markdown 表格行构建表格，第二行为分隔行时第一行作为表头
+func newMarkdownTable(lines []string) *table {
+	t := &table{format: FormatMarkdown}
+	rows := make([]string, 0, len(lines))
+	for _, line := range lines {
+		rows = append(rows, strings.TrimSpace(line))
+	}
+
+	if len(rows) >= 2 && isSeparatorRow(rows[1]) {
+		t.header = rows[:2]
+		t.columns = markdownCells(rows[0])
+		rows = rows[2:]
+	}
+	for _, row := range rows {
+		if isSeparatorRow(row) {
+			continue
+		}
+		t.rows = append(t.rows, row)
+		t.records = append(t.records, markdownCells(row))
+	}
+	return t
+}
+
+// newCSVTable 从连续的 CSV 行构建表格，第一行作为表头
+func newCSVTable(lines []string) *table {
+	t := &table{format: FormatCSV}
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		fields, _ := csvFields(line)
+		if i == 0 {
+			t.header = []string{line}
+			t.columns = fields
+			continue
+		}
+		t.rows = append(t.rows, line)
+		t.records = append(t.records, fields)
+	}
+	return t
+}
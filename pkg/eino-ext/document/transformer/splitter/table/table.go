@@ -0,0 +1,271 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package table
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cloudwego/eino/components/document"
+	"github.com/cloudwego/eino/schema"
+)
+
+const (
+	// MetaKeyTableIndex is the MetaData key of the index of the table within its source document
+	MetaKeyTableIndex = "table_index"
+	// MetaKeyTableFormat is the MetaData key of the Format of the table
+	MetaKeyTableFormat = "table_format"
+	// MetaKeyTableColumns is the MetaData key of the column names ([]string) of the table, if it has a header
+	MetaKeyTableColumns = "table_columns"
+	// MetaKeyTablePart is the MetaData key of the index of the sub-chunk within its table
+	MetaKeyTablePart = "table_part"
+)
+
+// Format is the source format of a detected table
+type Format string
+
+const (
+	// FormatMarkdown is a Markdown pipe table
+	FormatMarkdown Format = "markdown"
+	// FormatCSV is a block of comma-separated lines
+	FormatCSV Format = "csv"
+)
+
+// IDGenerator generates new IDs for split chunks
+type IDGenerator func(ctx context.Context, originalID string, splitIndex int) string
+
+func defaultIDGenerator(ctx context.Context, originalID string, _ int) string {
+	return originalID
+}
+
+type Config struct {
+	// MaxChunkSize is the maximum size of a table chunk, measured by SizeFunc.
+	// Large tables are split by rows; the header is repeated in every sub-chunk.
+	// A single row larger than MaxChunkSize is never split. Default is 1000.
+	MaxChunkSize int
+	// SizeFunc measures the size of a chunk. Defaults to counting characters (runes).
+	SizeFunc func(text string) int
+	// Serialize converts every data row into "column: value" text (one row per line),
+	// which usually embeds better than raw table syntax. Tables without a header are
+	// kept as is. Default is false.
+	Serialize bool
+	// DetectCSV enables detection of CSV blocks in addition to Markdown tables.
+	// Default is false.
+	DetectCSV bool
+	// IDGenerator is an optional function to generate new IDs for split chunks.
+	// If nil, the original document ID will be used for all splits.
+	IDGenerator IDGenerator
+}
+
+// NewTableSplitter 创建表格切分器：识别文档中的表格，将大表格按行切分并在每个子 chunk 中保留表头；
+// 表格之外的文本原样作为独立的 chunk 输出，可以再交给其他 splitter 处理
+func NewTableSplitter(ctx context.Context, config *Config) (document.Transformer, error) {
+	if config == nil {
+		config = &Config{}
+	}
+	if config.MaxChunkSize <= 0 {
+		config.MaxChunkSize = 1000 // 默认最大 1000 字符
+	}
+	sizeFunc := config.SizeFunc
+	if sizeFunc == nil {
+		sizeFunc = utf8.RuneCountInString
+	}
+	idGenerator := config.IDGenerator
+	if idGenerator == nil {
+		idGenerator = defaultIDGenerator
+	}
+	return &tableSplitter{
+		config:      config,
+		sizeFunc:    sizeFunc,
+		idGenerator: idGenerator,
+	}, nil
+}
+
+type tableSplitter struct {
+	config      *Config
+	sizeFunc    func(text string) int
+	idGenerator IDGenerator
+}
+
+func (s *tableSplitter) Transform(ctx context.Context, docs []*schema.Document, opts ...document.TransformerOption) ([]*schema.Document, error) {
+	if s == nil || s.config == nil {
+		return nil, fmt.Errorf("tableSplitter is nil")
+	}
+
+	ret := make([]*schema.Document, 0)
+	for _, doc := range docs {
+		if doc == nil || strings.TrimSpace(doc.Content) == "" {
+			continue
+		}
+
+		splitIndex := 0
+		tableIndex := 0
+		for _, b := range parseBlocks(doc.Content, s.config.DetectCSV) {
+			if b.table == nil {
+				ret = append(ret, &schema.Document{
+					ID:       s.idGenerator(ctx, doc.ID, splitIndex),
+					Content:  b.text,
+					MetaData: deepCopyAnyMap(doc.MetaData),
+				})
+				splitIndex++
+				continue
+			}
+
+			for part, chunk := range s.splitTable(b.table) {
+				metaData := deepCopyAnyMap(doc.MetaData)
+				if metaData == nil {
+					metaData = make(map[string]any)
+				}
+				metaData[MetaKeyTableIndex] = tableIndex
+				metaData[MetaKeyTableFormat] = b.table.format
+				metaData[MetaKeyTablePart] = part
+				if len(b.table.columns) > 0 {
+					metaData[MetaKeyTableColumns] = b.table.columns
+				}
+				ret = append(ret, &schema.Document{
+					ID:       s.idGenerator(ctx, doc.ID, splitIndex),
+					Content:  chunk,
+					MetaData: metaData,
+				})
+				splitIndex++
+			}
+			tableIndex++
+		}
+	}
+	return ret, nil
+}
+
+func (s *tableSplitter) GetType() string {
+	return "TableSplitter"
+}
+
+// splitTable 将表格切分为不超过 MaxChunkSize 的 chunk
+func (s *tableSplitter) splitTable(t *table) []string {
+	if s.config.Serialize && len(t.columns) > 0 {
+		lines := make([]string, 0, len(t.records))
+		for _, record := range t.records {
+			if line := SerializeRow(t.columns, record); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		return SplitRows(nil, lines, s.config.MaxChunkSize, s.sizeFunc)
+	}
+	return SplitRows(t.header, t.rows, s.config.MaxChunkSize, s.sizeFunc)
+}
+
+// SplitMarkdown 将连续的 Markdown 表格行按大小切分，第二行为分隔行时前两行作为表头在每个子 chunk 中重复，
+// 与 NewTableSplitter 对 Markdown 表格的处理相同；lines 中的元素可以包含多行，空行会被忽略；size 为空时按字符数计算
+func SplitMarkdown(lines []string, maxSize int, size func(text string) int) []string {
+	var rows []string
+	for _, line := range strings.Split(strings.Join(lines, "\n"), "\n") {
+		if strings.TrimSpace(line) != "" {
+			rows = append(rows, line)
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	t := newMarkdownTable(rows)
+	return SplitRows(t.header, t.rows, maxSize, size)
+}
+
+// SerializeRow 将一行数据序列化为 "列名: 值" 的文本，空单元格会被忽略
+func SerializeRow(columns, record []string) string {
+	pairs := make([]string, 0, len(record))
+	for i, value := range record {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		column := ""
+		if i < len(columns) {
+			column = strings.TrimSpace(columns[i])
+		}
+		if column == "" {
+			column = fmt.Sprintf("column%d", i+1)
+		}
+		pairs = append(pairs, column+": "+value)
+	}
+	return strings.Join(pairs, "; ")
+}
+
+// SplitRows 将表格行按大小分组，每组都以表头行开头，组内各行以换行符连接
+// size 为空时按字符数计算；单独一行超过 maxSize 时不会再被拆分；表头加一行就超过 maxSize 时该组不带表头
+func SplitRows(header, rows []string, maxSize int, size func(text string) int) []string {
+	if size == nil {
+		size = utf8.RuneCountInString
+	}
+	if len(rows) == 0 {
+		if len(header) == 0 {
+			return nil
+		}
+		return []string{strings.Join(header, "\n")}
+	}
+
+	headerLength := 0
+	if len(header) > 0 {
+		// 表头行之间以及表头和数据行之间的换行符
+		headerLength = size(strings.Join(header, "\n")) + 1
+	}
+
+	var (
+		chunks        []string
+		currentChunk  []string
+		currentLength int
+	)
+	for _, row := range rows {
+		rowLength := size(row)
+
+		// 如果添加这一行会超过限制，且当前 chunk 不为空，先保存当前 chunk
+		if len(currentChunk) > 0 && currentLength+rowLength+1 > maxSize {
+			chunks = append(chunks, strings.Join(currentChunk, "\n"))
+			currentChunk = nil
+		}
+
+		if len(currentChunk) == 0 {
+			// 表头+行超过限制时只添加行
+			if len(header) > 0 && headerLength+rowLength <= maxSize {
+				currentChunk = append(currentChunk, header...)
+				currentLength = headerLength + rowLength
+			} else {
+				currentLength = rowLength
+			}
+			currentChunk = append(currentChunk, row)
+			continue
+		}
+
+		currentChunk = append(currentChunk, row)
+		currentLength += rowLength + 1 // +1 for newline
+	}
+	if len(currentChunk) > 0 {
+		chunks = append(chunks, strings.Join(currentChunk, "\n"))
+	}
+	return chunks
+}
+
+func deepCopyAnyMap(anyMap map[string]any) map[string]any {
+	if anyMap == nil {
+		return nil
+	}
+	ret := make(map[string]any)
+	for k, v := range anyMap {
+		ret[k] = v
+	}
+	return ret
+}
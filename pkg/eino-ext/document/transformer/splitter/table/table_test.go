@@ -0,0 +1,138 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package table
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/schema"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+func TestTableSplitter(t *testing.T) {
+	convey.Convey("Test TableSplitter Markdown header repeat", t, func() {
+		ctx := context.Background()
+		var b strings.Builder
+		b.WriteString("下表列出了各地区的销售额。\n\n")
+		b.WriteString("| 地区 | 销售额 |\n| --- | ---: |\n")
+		for i := 1; i <= 20; i++ {
+			fmt.Fprintf(&b, "| 地区%02d | %d |\n", i, i*100)
+		}
+		b.WriteString("\n数据来源：内部统计。")
+
+		splitter, err := NewTableSplitter(ctx, &Config{MaxChunkSize: 100})
+		convey.So(err, convey.ShouldBeNil)
+		splitDocs, err := splitter.Transform(ctx, []*schema.Document{{
+			ID:       "doc_md",
+			Content:  b.String(),
+			MetaData: map[string]any{"source": "report.md"},
+		}})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(len(splitDocs), convey.ShouldBeGreaterThan, 3)
+
+		// 表格前后的文本原样输出
+		convey.So(splitDocs[0].Content, convey.ShouldEqual, "下表列出了各地区的销售额。")
+		convey.So(splitDocs[0].MetaData[MetaKeyTableIndex], convey.ShouldBeNil)
+		convey.So(splitDocs[len(splitDocs)-1].Content, convey.ShouldEqual, "数据来源：内部统计。")
+
+		tableDocs := splitDocs[1 : len(splitDocs)-1]
+		rows := 0
+		for i, doc := range tableDocs {
+			convey.So(doc.Content, convey.ShouldStartWith, "| 地区 | 销售额 |\n| --- | ---: |\n")
+			convey.So(len([]rune(doc.Content)), convey.ShouldBeLessThanOrEqualTo, 100)
+			convey.So(doc.MetaData["source"], convey.ShouldEqual, "report.md")
+			convey.So(doc.MetaData[MetaKeyTableIndex], convey.ShouldEqual, 0)
+			convey.So(doc.MetaData[MetaKeyTablePart], convey.ShouldEqual, i)
+			convey.So(doc.MetaData[MetaKeyTableFormat], convey.ShouldEqual, FormatMarkdown)
+			convey.So(doc.MetaData[MetaKeyTableColumns], convey.ShouldResemble, []string{"地区", "销售额"})
+			rows += strings.Count(doc.Content, "\n") - 1
+		}
+		convey.So(rows, convey.ShouldEqual, 20)
+	})
+
+	convey.Convey("Test TableSplitter Serialize", t, func() {
+		ctx := context.Background()
+		text := "| 姓名 | 部门 | 备注 |\n|:---|:---:|---|\n| 张三 | 研发 | 组长 |\n| 李四 | 产品 |  |\n| 王五 | a\\|b | - |"
+
+		splitter, err := NewTableSplitter(ctx, &Config{Serialize: true})
+		convey.So(err, convey.ShouldBeNil)
+		splitDocs, err := splitter.Transform(ctx, []*schema.Document{{ID: "doc_ser", Content: text}})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(len(splitDocs), convey.ShouldEqual, 1)
+		convey.So(splitDocs[0].Content, convey.ShouldEqual,
+			"姓名: 张三; 部门: 研发; 备注: 组长\n姓名: 李四; 部门: 产品\n姓名: 王五; 部门: a|b; 备注: -")
+	})
+
+	convey.Convey("Test TableSplitter CSV", t, func() {
+		ctx := context.Background()
+		text := "Quarterly numbers, in thousands.\nregion,q1,q2\nnorth,10,12\nsouth,\"7,5\",9\n\nEnd of report."
+
+		splitter, err := NewTableSplitter(ctx, &Config{DetectCSV: true, Serialize: true})
+		convey.So(err, convey.ShouldBeNil)
+		splitDocs, err := splitter.Transform(ctx, []*schema.Document{{ID: "doc_csv", Content: text}})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(len(splitDocs), convey.ShouldEqual, 3)
+		convey.So(splitDocs[0].Content, convey.ShouldEqual, "Quarterly numbers, in thousands.")
+		convey.So(splitDocs[1].Content, convey.ShouldEqual, "region: north; q1: 10; q2: 12\nregion: south; q1: 7,5; q2: 9")
+		convey.So(splitDocs[1].MetaData[MetaKeyTableFormat], convey.ShouldEqual, FormatCSV)
+		convey.So(splitDocs[2].Content, convey.ShouldEqual, "End of report.")
+
+		// 未开启 DetectCSV 时整段作为文本输出
+		splitter, err = NewTableSplitter(ctx, nil)
+		convey.So(err, convey.ShouldBeNil)
+		splitDocs, err = splitter.Transform(ctx, []*schema.Document{{ID: "doc_csv", Content: text}})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(len(splitDocs), convey.ShouldEqual, 1)
+	})
+
+	convey.Convey("Test SplitRows", t, func() {
+		header := []string{"| a | b |", "| - | - |"}
+		rows := []string{"| 1 | 2 |", "| 3 | 4 |", "| 5 | 6 |"}
+
+		// 表头 19 字符 + 换行，每组只能容纳一行数据
+		chunks := SplitRows(header, rows, 30, nil)
+		convey.So(len(chunks), convey.ShouldEqual, 3)
+		for i, chunk := range chunks {
+			convey.So(chunk, convey.ShouldEqual, strings.Join(append(append([]string{}, header...), rows[i]), "\n"))
+		}
+
+		// 表头加一行就超过限制时不带表头
+		chunks = SplitRows(header, rows, 10, nil)
+		convey.So(chunks, convey.ShouldResemble, rows)
+
+		convey.So(SplitRows(nil, nil, 10, nil), convey.ShouldBeNil)
+	})
+
+	convey.Convey("Test SplitMarkdown", t, func() {
+		// 表格可以逐行传入，也可以整体作为一个元素传入
+		lines := []string{"| a | b |", "| - | - |\n| 1 | 2 |", "| 3 | 4 |\n"}
+		chunks := SplitMarkdown(lines, 30, nil)
+		convey.So(chunks, convey.ShouldResemble, []string{
+			"| a | b |\n| - | - |\n| 1 | 2 |",
+			"| a | b |\n| - | - |\n| 3 | 4 |",
+		})
+
+		// 没有分隔行时不识别表头
+		chunks = SplitMarkdown([]string{"| 1 | 2 |", "| 3 | 4 |"}, 10, nil)
+		convey.So(chunks, convey.ShouldResemble, []string{"| 1 | 2 |", "| 3 | 4 |"})
+
+		convey.So(SplitMarkdown(nil, 10, nil), convey.ShouldBeNil)
+	})
+}
@@ -0,0 +1,182 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tfidf
+
+import (
+	"strings"
+)
+
+const (
+	// MetaKeySectionPath is the MetaData key of the heading breadcrumb of a chunk, e.g. "第3章 > 3.2 设计要求"
+	MetaKeySectionPath = "section_path"
+	// MetaKeySectionTitles is the MetaData key of the heading titles ([]string) from the top level down
+	MetaKeySectionTitles = "section_titles"
+	// SectionPathSeparator separates the titles in MetaKeySectionPath
+	SectionPathSeparator = " > "
+)
+
+// section 文档中一个标题及其正文（到下一个任意级别标题为止）
+type section struct {
+	titles  []string // 从顶级标题开始的标题路径
+	heading string   // 标题行，标题之前的前言部分为空
+	body    string
+	group   int // 所属顶级章节的序号，前言部分为 0
+}
+
+// headingLevel 解析 Markdown 标题行，返回标题级别和标题文字；不是标题时级别为 0
+func headingLevel(line string) (int, string) {
+	trimmed := strings.TrimSpace(line)
+	if !isMarkdownHeader(trimmed) {
+		return 0, ""
+	}
+	level := 0
+	for level < len(trimmed) && trimmed[level] == '#' {
+		level++
+	}
+	// 去掉可选的闭合 #，如 "## 标题 ##"
+	title := strings.TrimSpace(strings.TrimRight(strings.TrimSpace(trimmed[level:]), "#"))
+	if title == "" {
+		return 0, ""
+	}
+	return level, title
+}
+
+// parseSections 按 Markdown 标题把文本拆分为章节，并为每个章节构建标题路径
+// 代码块（```）中的 # 行不视为标题；文档中出现的最高级别标题作为顶级章节
+func parseSections(text string) []section {
+	lines := strings.Split(text, "\n")
+
+	// 第一遍：找出顶级标题的级别
+	minLevel := 0
+	inFence := false
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		if level, _ := headingLevel(line); level > 0 && (minLevel == 0 || level < minLevel) {
+			minLevel = level
+		}
+	}
+
+	type heading struct {
+		level int
+		title string
+	}
+	var (
+		sections []section
+		stack    []heading
+		current  section
+		body     strings.Builder
+		group    int
+	)
+	flush := func() {
+		current.body = body.String()
+		if current.heading != "" || strings.TrimSpace(current.body) != "" {
+			sections = append(sections, current)
+		}
+		body.Reset()
+	}
+
+	inFence = false
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+		}
+		level, title := 0, ""
+		if !inFence {
+			level, title = headingLevel(line)
+		}
+		if level == 0 {
+			body.WriteString(line)
+			body.WriteByte('\n')
+			continue
+		}
+
+		flush()
+		for len(stack) > 0 && stack[len(stack)-1].level >= level {
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, heading{level: level, title: title})
+		if level <= minLevel {
+			group++
+		}
+
+		titles := make([]string, len(stack))
+		for i, h := range stack {
+			titles[i] = h.title
+		}
+		current = section{titles: titles, heading: strings.TrimSpace(line), group: group}
+	}
+	flush()
+
+	return sections
+}
+
+// splitSections 按标题层级切分文本，返回 chunk 及每个 chunk 对应的标题路径
+// 每个章节的正文单独切分，标题行拼接到该章节的第一个 chunk 开头，因此不同章节（包括不同顶级章节）的内容不会合并；
+// 只有标题没有正文的章节（如紧跟子标题的章标题）会与同一顶级章节中下一个章节的标题一起拼接
+func (s *tfidfSplitter) splitSections(text string) ([]string, [][]string, error) {
+	joinSep := " "
+	if s.config.RemoveWhitespace {
+		joinSep = ""
+	}
+
+	sections := parseSections(text)
+
+	var (
+		chunks   []string
+		titles   [][]string
+		headings []string // 尚未输出的标题行
+	)
+	for i, sec := range sections {
+		if sec.heading != "" {
+			headings = append(headings, s.cleanChunk(sec.heading))
+		}
+
+		bodyChunks, err := s.splitText(sec.body)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(bodyChunks) == 0 {
+			// 只有标题：下一个章节属于同一顶级章节时与其标题一起输出，否则单独作为一个 chunk
+			if i+1 < len(sections) && sections[i+1].group == sec.group {
+				continue
+			}
+			if len(headings) > 0 {
+				chunks = append(chunks, strings.Join(headings, joinSep))
+				titles = append(titles, sec.titles)
+				headings = nil
+			}
+			continue
+		}
+
+		if len(headings) > 0 {
+			bodyChunks[0] = strings.Join(headings, joinSep) + joinSep + bodyChunks[0]
+			headings = nil
+		}
+		for _, chunk := range bodyChunks {
+			chunks = append(chunks, chunk)
+			titles = append(titles, sec.titles)
+		}
+	}
+
+	return chunks, titles, nil
+}
@@ -0,0 +1,69 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tfidf
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// 硬性限制：embedding API 通常限制在 8192 个 token（或按字符估算），我们设置为 8000 以留出余量
+// 单位与 Config.SizeUnit 一致；使用 SizeTokens 并配置模型的 TokenCounter 时该限制是精确的
+const maxEmbeddingSize = 8000
+
+// size 按 Config.SizeUnit 计算文本大小
+func (s *tfidfSplitter) size(text string) int {
+	if s.config.SizeUnit == SizeTokens && s.config.TokenCounter != nil {
+		return s.config.TokenCounter(text)
+	}
+	return utf8.RuneCountInString(text)
+}
+
+// EstimateTokens estimates the number of tokens of text without a model tokenizer,
+// roughly following BPE tokenizers such as cl100k_base: every CJK character counts as
+// one token, runs of letters and digits count as one token per 4 bytes, and every
+// other non-space symbol counts as one token.
+func EstimateTokens(text string) int {
+	tokens := 0
+	wordBytes := 0
+	flushWord := func() {
+		tokens += (wordBytes + 3) / 4
+		wordBytes = 0
+	}
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord()
+			tokens++
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			wordBytes += utf8.RuneLen(r)
+		case unicode.IsSpace(r):
+			flushWord()
+		default:
+			flushWord()
+			tokens++
+		}
+	}
+	flushWord()
+	return tokens
+}
+
+// isCJK 判断字符是否为中日韩文字
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
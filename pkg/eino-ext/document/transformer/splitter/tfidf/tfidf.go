@@ -27,9 +27,12 @@ import (
 
 	"github.com/cloudwego/eino/components/document"
 	"github.com/cloudwego/eino/schema"
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/document/transformer/filter/garbage"
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/document/transformer/splitter/table"
 	"github.com/mozhou-tech/sqlite-ai-driver/pkg/sego"
 	"github.com/rioloc/tfidf-go"
 	"github.com/rioloc/tfidf-go/token"
+	"github.com/sirupsen/logrus"
 )
 
 var (
@@ -49,16 +52,73 @@ func defaultIDGenerator(ctx context.Context, originalID string, _ int) string {
 	return originalID
 }
 
+const (
+	// MetaKeyParentID is the MetaData key of the ID of the source document a chunk was split from,
+	// set when the source document has an ID
+	MetaKeyParentID = "parent_id"
+	// MetaKeyChunkIndex is the MetaData key of the zero-based position of a chunk within its source document;
+	// together with MetaKeyParentID it lets readers find the adjacent chunks of a retrieved chunk
+	MetaKeyChunkIndex = "chunk_index"
+)
+
+// ChunkInfo describes a chunk produced by the splitter, passed to Config.OnChunk
+type ChunkInfo struct {
+	// DocID is the ID of the source document
+	DocID string
+	// ChunkID is the ID assigned to the chunk by the IDGenerator
+	ChunkID string
+	// Index is the zero-based position of the chunk within the source document
+	Index int
+	// Total is the number of chunks the source document was split into
+	Total int
+	// Content is the chunk text
+	Content string
+	// RuneCount is the length of the chunk in runes
+	RuneCount int
+	// SectionPath is the heading breadcrumb of the chunk when HeadingHierarchy is enabled
+	SectionPath string
+}
+
+// ChunkCallback is invoked once for every chunk produced by Transform
+type ChunkCallback func(ctx context.Context, info ChunkInfo)
+
+// previewRunes is the maximum number of runes of chunk content included in log events
+const previewRunes = 100
+
+// OverlapUnit is the unit in which Config.ChunkOverlap is measured
+type OverlapUnit string
+
+const (
+	// OverlapRunes measures overlap in characters (runes)
+	OverlapRunes OverlapUnit = "runes"
+	// OverlapSentences measures overlap in whole sentences
+	OverlapSentences OverlapUnit = "sentences"
+)
+
+// SizeUnit is the unit in which Config.MaxChunkSize and Config.MinChunkSize are measured
+type SizeUnit string
+
+const (
+	// SizeRunes measures chunk size in characters (runes)
+	SizeRunes SizeUnit = "runes"
+	// SizeTokens measures chunk size in tokens, counted by Config.TokenCounter
+	SizeTokens SizeUnit = "tokens"
+)
+
+// TokenCounter returns the number of tokens in text. It is compatible with tiktoken-style
+// encoders, e.g. func(text string) int { return len(enc.Encode(text, nil, nil)) }.
+type TokenCounter func(text string) int
+
 type Config struct {
 	// SimilarityThreshold is the minimum cosine similarity between sentences to keep them in the same chunk.
 	// If similarity is below this, a new chunk is started.
 	// Default is 0.2.
 	SimilarityThreshold float64
-	// MaxChunkSize is the maximum number of characters (runes) in a chunk.
+	// MaxChunkSize is the maximum size of a chunk, measured in SizeUnit.
 	// When a chunk reaches this size, it will be split regardless of similarity.
 	// Default is 1000.
 	MaxChunkSize int
-	// MinChunkSize is the minimum number of characters (runes) in a chunk.
+	// MinChunkSize is the minimum size of a chunk, measured in SizeUnit.
 	// If a chunk is shorter than this, more sentences will be added even if similarity is low.
 	// Default is 50.
 	MinChunkSize int
@@ -80,6 +140,33 @@ type Config struct {
 	// FilterGarbageChunks specifies whether to filter out garbage chunks (like corrupted text from PDF parsing).
 	// Defaults to true. Set to false to disable filtering.
 	FilterGarbageChunks bool
+	// Logger receives structured debug events (documents split, garbage chunks dropped).
+	// Defaults to logrus.StandardLogger(). Events are logged at Debug level, so they are silent
+	// unless the logger is configured for debug output.
+	Logger logrus.FieldLogger
+	// Verbose additionally logs a debug event with a content preview for every produced chunk.
+	// Default is false.
+	Verbose bool
+	// OnChunk is an optional callback invoked for every produced chunk, for callers that
+	// want to inspect or display chunk previews.
+	OnChunk ChunkCallback
+	// ChunkOverlap is the amount of trailing context of the previous chunk that is prepended
+	// to each chunk, so that adjacent chunks share boundary context. The overlap is added on
+	// top of MaxChunkSize. Table chunks never overlap. Default is 0 (no overlap).
+	ChunkOverlap int
+	// OverlapUnit is the unit of ChunkOverlap. Default is OverlapRunes.
+	OverlapUnit OverlapUnit
+	// HeadingHierarchy enables markdown heading-aware splitting: the document is split per
+	// heading section, content of different top-level sections is never merged into one chunk,
+	// and the heading breadcrumb (e.g. "第3章 > 3.2 设计要求") is attached to each chunk's
+	// MetaData under MetaKeySectionPath and MetaKeySectionTitles. Default is false.
+	HeadingHierarchy bool
+	// SizeUnit is the unit of MaxChunkSize, MinChunkSize and the hard embedding size limit.
+	// Default is SizeRunes.
+	SizeUnit SizeUnit
+	// TokenCounter counts tokens when SizeUnit is SizeTokens. Set it to the tokenizer of the
+	// embedding model for exact limits. Defaults to EstimateTokens.
+	TokenCounter TokenCounter
 }
 
 func NewTFIDFSplitter(ctx context.Context, config *Config) (document.Transformer, error) {
@@ -102,6 +189,29 @@ func NewTFIDFSplitter(ctx context.Context, config *Config) (document.Transformer
 	if config.SimilarityThreshold <= 0 {
 		config.SimilarityThreshold = 0.2
 	}
+	if config.ChunkOverlap < 0 {
+		config.ChunkOverlap = 0
+	}
+	switch config.OverlapUnit {
+	case "":
+		config.OverlapUnit = OverlapRunes
+	case OverlapRunes, OverlapSentences:
+	default:
+		return nil, fmt.Errorf("unsupported overlap unit %q", config.OverlapUnit)
+	}
+	switch config.SizeUnit {
+	case "":
+		config.SizeUnit = SizeRunes
+	case SizeRunes, SizeTokens:
+	default:
+		return nil, fmt.Errorf("unsupported size unit %q", config.SizeUnit)
+	}
+	if config.SizeUnit == SizeTokens && config.TokenCounter == nil {
+		config.TokenCounter = EstimateTokens
+	}
+	if config.SizeUnit == SizeRunes && config.OverlapUnit == OverlapRunes && config.ChunkOverlap >= config.MaxChunkSize {
+		return nil, fmt.Errorf("ChunkOverlap (%d) must be smaller than MaxChunkSize (%d)", config.ChunkOverlap, config.MaxChunkSize)
+	}
 	// FilterGarbageChunks defaults to true
 	// Since bool zero value is false, we can't distinguish "unset" from "explicitly false"
 	// We default to true when config was nil (user didn't provide config)
@@ -115,15 +225,21 @@ func NewTFIDFSplitter(ctx context.Context, config *Config) (document.Transformer
 	if idGenerator == nil {
 		idGenerator = defaultIDGenerator
 	}
+	logger := config.Logger
+	if logger == nil {
+		logger = logrus.StandardLogger()
+	}
 	return &tfidfSplitter{
 		config:      config,
 		idGenerator: idGenerator,
+		logger:      logger,
 	}, nil
 }
 
 type tfidfSplitter struct {
 	config      *Config
 	idGenerator IDGenerator
+	logger      logrus.FieldLogger
 }
 
 func (s *tfidfSplitter) Transform(ctx context.Context, docs []*schema.Document, opts ...document.TransformerOption) ([]*schema.Document, error) {
@@ -133,73 +249,104 @@ func (s *tfidfSplitter) Transform(ctx context.Context, docs []*schema.Document,
 	if s.config == nil {
 		return nil, fmt.Errorf("config is nil")
 	}
+	logger := s.logger
+	if logger == nil {
+		logger = logrus.StandardLogger()
+	}
+
 	ret := make([]*schema.Document, 0) // 初始化为空切片而不是 nil
 	for _, doc := range docs {
 		if doc == nil {
 			continue
 		}
-		// 调试：显示原始内容
-		runeCount := utf8.RuneCountInString(doc.Content)
-		fmt.Printf("\n[DEBUG] 处理文档 %s，原始内容长度: %d 字符 (Runes)\n", doc.ID, runeCount)
-		if runeCount > 0 && runeCount <= 200 {
-			fmt.Printf("[DEBUG] 原始内容预览: %q\n", doc.Content)
-		} else if runeCount > 200 {
-			// 安全地截取前 200 个 rune
-			runes := []rune(doc.Content)
-			fmt.Printf("[DEBUG] 原始内容预览（前200字符）: %q\n", string(runes[:200]))
-		}
 
-		chunks, err := s.splitText(doc.Content)
+		var (
+			chunks []string
+			titles [][]string
+			err    error
+		)
+		if s.config.HeadingHierarchy {
+			chunks, titles, err = s.splitSections(doc.Content)
+		} else {
+			chunks, err = s.splitText(doc.Content)
+		}
 		if err != nil {
 			return nil, fmt.Errorf("failed to split document %s: %w", doc.ID, err)
 		}
-		fmt.Printf("[DEBUG] splitText 返回 %d 个 chunks\n", len(chunks))
 
 		// 如果 splitText 返回 nil 或空切片，至少保留原始文档
 		if len(chunks) == 0 {
 			// 如果内容为空，跳过该文档
 			if doc.Content == "" {
-				fmt.Printf("\n========== 文档 %s (跳过，内容为空) ==========\n\n", doc.ID)
+				logger.WithField("doc_id", doc.ID).Debug("Skipping empty document")
 				continue
 			}
 			// 否则创建一个包含原始内容的文档
-			chunkID := s.idGenerator(ctx, doc.ID, 0)
-			fmt.Printf("\n========== 文档 %s 分割为 1 个 chunk (未分割) ==========\n", doc.ID)
-			fmt.Printf("\n--- Chunk 1 (ID: %s) ---\n", chunkID)
-			fmt.Printf("长度: %d 字符 (Runes)\n", runeCount)
-			fmt.Printf("内容:\n%s\n", doc.Content)
-			fmt.Printf("---\n")
-			fmt.Printf("==========================================\n\n")
+			chunks = []string{doc.Content}
+		}
+
+		logger.WithFields(logrus.Fields{
+			"doc_id":        doc.ID,
+			"content_runes": utf8.RuneCountInString(doc.Content),
+			"chunks":        len(chunks),
+		}).Debug("Document split into chunks")
+
+		for i, chunk := range chunks {
+			chunkID := s.idGenerator(ctx, doc.ID, i)
+			metaData := deepCopyAnyMap(doc.MetaData)
+			if metaData == nil {
+				metaData = make(map[string]any)
+			}
+			if doc.ID != "" {
+				metaData[MetaKeyParentID] = doc.ID
+			}
+			metaData[MetaKeyChunkIndex] = i
+			info := ChunkInfo{
+				DocID:     doc.ID,
+				ChunkID:   chunkID,
+				Index:     i,
+				Total:     len(chunks),
+				Content:   chunk,
+				RuneCount: utf8.RuneCountInString(chunk),
+			}
+			if i < len(titles) && len(titles[i]) > 0 {
+				info.SectionPath = strings.Join(titles[i], SectionPathSeparator)
+				metaData[MetaKeySectionPath] = info.SectionPath
+				metaData[MetaKeySectionTitles] = titles[i]
+			}
+			if s.config.Verbose {
+				logger.WithFields(logrus.Fields{
+					"doc_id":   info.DocID,
+					"chunk_id": info.ChunkID,
+					"index":    info.Index,
+					"runes":    info.RuneCount,
+					"preview":  preview(chunk),
+				}).Debug("Chunk produced")
+			}
+			if s.config.OnChunk != nil {
+				s.config.OnChunk(ctx, info)
+			}
 
 			nDoc := &schema.Document{
 				ID:       chunkID,
-				Content:  doc.Content,
-				MetaData: deepCopyAnyMap(doc.MetaData),
+				Content:  chunk,
+				MetaData: metaData,
 			}
 			ret = append(ret, nDoc)
-		} else {
-			fmt.Printf("\n========== 文档 %s 分割为 %d 个 chunks ==========\n", doc.ID, len(chunks))
-			for i, chunk := range chunks {
-				chunkID := s.idGenerator(ctx, doc.ID, i)
-				cRuneCount := utf8.RuneCountInString(chunk)
-				fmt.Printf("\n--- Chunk %d (ID: %s) ---\n", i+1, chunkID)
-				fmt.Printf("长度: %d 字符 (Runes)\n", cRuneCount)
-				fmt.Printf("内容:\n%s\n", chunk)
-				fmt.Printf("---\n")
-
-				nDoc := &schema.Document{
-					ID:       chunkID,
-					Content:  chunk,
-					MetaData: deepCopyAnyMap(doc.MetaData),
-				}
-				ret = append(ret, nDoc)
-			}
-			fmt.Printf("==========================================\n\n")
 		}
 	}
 	return ret, nil
 }
 
+// preview 截取 chunk 的前 previewRunes 个字符用于日志
+func preview(chunk string) string {
+	runes := []rune(chunk)
+	if len(runes) <= previewRunes {
+		return chunk
+	}
+	return string(runes[:previewRunes]) + "..."
+}
+
 func (s *tfidfSplitter) GetType() string {
 	return "TFIDFSplitter"
 }
@@ -217,7 +364,7 @@ func (s *tfidfSplitter) splitText(text string) ([]string, error) {
 
 	// 如果文本太短（少于 MinChunkSize 个字符），直接返回原始文本，不进行分割
 	trimmed := strings.TrimSpace(text)
-	if utf8.RuneCountInString(trimmed) < s.config.MinChunkSize {
+	if s.size(trimmed) < s.config.MinChunkSize {
 		if trimmed == "" {
 			return []string{}, nil
 		}
@@ -302,7 +449,86 @@ func (s *tfidfSplitter) splitText(text string) ([]string, error) {
 	}
 
 	// 3. Group sentences into chunks
-	return s.groupSentences(sentences, tfidfMatrix), nil
+	chunks := s.groupSentences(sentences, tfidfMatrix)
+
+	// 4. Add overlap between adjacent chunks
+	return s.applyOverlap(chunks), nil
+}
+
+// applyOverlap 将上一个 chunk 的末尾内容拼接到每个 chunk 的开头，使相邻 chunk 共享边界上下文
+// 表格 chunk 既不提供也不接收重叠内容，以保持表格结构完整
+func (s *tfidfSplitter) applyOverlap(chunks []string) []string {
+	if s.config.ChunkOverlap <= 0 || len(chunks) < 2 {
+		return chunks
+	}
+
+	joinSep := " "
+	if s.config.RemoveWhitespace {
+		joinSep = ""
+	}
+
+	result := make([]string, len(chunks))
+	result[0] = chunks[0]
+	for i := 1; i < len(chunks); i++ {
+		result[i] = chunks[i]
+		prev := chunks[i-1]
+		if containsTable(prev) || containsTable(chunks[i]) {
+			continue
+		}
+
+		var overlap string
+		if s.config.OverlapUnit == OverlapSentences {
+			overlap = tailSentences(prev, s.config.ChunkOverlap, joinSep)
+		} else {
+			overlap = tailRunes(prev, s.config.ChunkOverlap)
+		}
+		if overlap == "" {
+			continue
+		}
+
+		merged := overlap + joinSep + chunks[i]
+		if s.size(merged) <= maxEmbeddingSize {
+			result[i] = merged
+		}
+	}
+	return result
+}
+
+// tailRunes 返回文本末尾最多 n 个字符
+// 为避免从词或句子中间截断，会尽量从截取范围内的第一个句子分隔符或空白之后开始
+func tailRunes(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return strings.TrimSpace(text)
+	}
+	tail := runes[len(runes)-n:]
+
+	// 优先从句子边界开始，其次是空白
+	for _, isBoundary := range []func(rune) bool{isSentenceDelimiter, unicode.IsSpace} {
+		for j, r := range tail[:len(tail)-1] {
+			if isBoundary(r) {
+				if trimmed := strings.TrimSpace(string(tail[j+1:])); trimmed != "" {
+					return trimmed
+				}
+				break
+			}
+		}
+	}
+	return strings.TrimSpace(string(tail))
+}
+
+// tailSentences 返回文本的最后 n 个句子
+func tailSentences(text string, n int, joinSep string) string {
+	sentences := splitIntoSentences(text)
+	if len(sentences) > n {
+		sentences = sentences[len(sentences)-n:]
+	}
+	return strings.TrimSpace(strings.Join(sentences, joinSep))
+}
+
+// isSentenceDelimiter 判断字符是否为句子分隔符
+func isSentenceDelimiter(r rune) bool {
+	return strings.ContainsRune(".!?。！？；;", r)
 }
 
 func (s *tfidfSplitter) groupSentences(sentences []string, tfidfMatrix [][]float64) []string {
@@ -313,90 +539,39 @@ func (s *tfidfSplitter) groupSentences(sentences []string, tfidfMatrix [][]float
 	var chunks []string
 	var currentChunk []string
 	var currentLength int
-	var inTable bool // 标记当前是否在处理表格
+	var tableRows []string // 当前表格的连续行
 
 	joinSep := " "
 	if s.config.RemoveWhitespace {
 		joinSep = ""
 	}
 
-	for i := 0; i < len(sentences); i++ {
-		sentence := sentences[i]
-		isTableRow := isMarkdownTableRow(sentence)
-
-		// 检测表格开始
-		if isTableRow && !inTable {
-			// 如果当前 chunk 不为空，先保存它
-			if len(currentChunk) > 0 {
-				chunk := s.cleanChunk(strings.Join(currentChunk, joinSep))
-				chunks = append(chunks, chunk)
-				currentChunk = []string{}
-				currentLength = 0
-			}
-			inTable = true
+	// flushTable 表格结束时交给 table 包按行切分，超过 embedding 限制的表格在每个子 chunk 中保留表头
+	flushTable := func() {
+		if len(tableRows) > 0 {
+			chunks = append(chunks, table.SplitMarkdown(tableRows, maxEmbeddingSize, s.size)...)
+			tableRows = nil
 		}
+	}
 
-		// 检测表格结束
-		if !isTableRow && inTable {
-			// 表格结束，检查是否需要分割
+	for i := 0; i < len(sentences); i++ {
+		sentence := sentences[i]
+		if table.IsMarkdownRow(sentence) {
+			// 表格开始时先保存当前 chunk
 			if len(currentChunk) > 0 {
-				// 硬性限制：embedding API 通常限制在 8192 字符，我们设置为 8000 以留出余量
-				const maxEmbeddingSize = 8000
-				tableChunk := strings.Join(currentChunk, "\n")
-				chunkLen := utf8.RuneCountInString(tableChunk)
-
-				if chunkLen > maxEmbeddingSize {
-					// 表格超过硬性限制，需要按行分割
-					tableChunks := s.splitLargeTable(currentChunk, maxEmbeddingSize)
-					chunks = append(chunks, tableChunks...)
-				} else {
-					// 表格内容保留换行符，使用 "\n" 连接
-					chunks = append(chunks, tableChunk)
-				}
-				currentChunk = []string{}
+				chunks = append(chunks, s.cleanChunk(strings.Join(currentChunk, joinSep)))
+				currentChunk = nil
 				currentLength = 0
 			}
-			inTable = false
+			tableRows = append(tableRows, sentence)
+			continue
 		}
+		flushTable()
 
 		// 如果是第一个句子，直接添加
 		if len(currentChunk) == 0 {
 			currentChunk = append(currentChunk, sentence)
-			if inTable {
-				// 表格行长度计算（包含换行符）
-				currentLength = utf8.RuneCountInString(sentence)
-			} else {
-				currentLength = utf8.RuneCountInString(sentence)
-			}
-			continue
-		}
-
-		// 如果正在处理表格，检查是否需要分割
-		if inTable {
-			// 硬性限制：embedding API 通常限制在 8192 字符，我们设置为 8000 以留出余量
-			const maxEmbeddingSize = 8000
-			potentialLength := currentLength + utf8.RuneCountInString(sentence) + 1 // +1 for newline
-
-			// 如果添加这一行会超过限制，先保存当前 chunk
-			if potentialLength > maxEmbeddingSize && len(currentChunk) > 0 {
-				tableChunk := strings.Join(currentChunk, "\n")
-				chunkLen := utf8.RuneCountInString(tableChunk)
-				if chunkLen > maxEmbeddingSize {
-					// 当前 chunk 已经超过限制，需要分割
-					tableChunks := s.splitLargeTable(currentChunk, maxEmbeddingSize)
-					chunks = append(chunks, tableChunks...)
-					// 开始新的 chunk
-					currentChunk = []string{sentence}
-					currentLength = utf8.RuneCountInString(sentence)
-				} else {
-					chunks = append(chunks, tableChunk)
-					currentChunk = []string{sentence}
-					currentLength = utf8.RuneCountInString(sentence)
-				}
-			} else {
-				currentChunk = append(currentChunk, sentence)
-				currentLength = potentialLength
-			}
+			currentLength = s.size(sentence)
 			continue
 		}
 
@@ -420,38 +595,25 @@ func (s *tfidfSplitter) groupSentences(sentences []string, tfidfMatrix [][]float
 			chunk := s.cleanChunk(strings.Join(currentChunk, joinSep))
 			chunks = append(chunks, chunk)
 			currentChunk = []string{sentence}
-			currentLength = utf8.RuneCountInString(sentence)
+			currentLength = s.size(sentence)
 		} else {
 			currentChunk = append(currentChunk, sentence)
-			currentLength += utf8.RuneCountInString(sentence) + utf8.RuneCountInString(joinSep)
+			currentLength += s.size(sentence) + s.size(joinSep)
 		}
 	}
 
+	flushTable()
+
 	// 处理最后一个 chunk
 	if len(currentChunk) > 0 {
-		var chunk string
-		if inTable {
-			// 表格内容保留换行符
-			chunk = strings.Join(currentChunk, "\n")
-		} else {
-			chunk = s.cleanChunk(strings.Join(currentChunk, joinSep))
-		}
-		chunkLen := utf8.RuneCountInString(chunk)
-
-		// 硬性限制：embedding API 通常限制在 8192 字符，我们设置为 8000 以留出余量
-		const maxEmbeddingSize = 8000
-
-		if inTable && chunkLen > maxEmbeddingSize {
-			// 表格超过硬性限制，需要按行分割，但保持表格行完整
-			tableChunks := s.splitLargeTable(currentChunk, maxEmbeddingSize)
-			chunks = append(chunks, tableChunks...)
-		} else if !inTable && chunkLen < s.config.MinChunkSize && len(chunks) > 0 {
+		chunk := s.cleanChunk(strings.Join(currentChunk, joinSep))
+		if s.size(chunk) < s.config.MinChunkSize && len(chunks) > 0 {
 			// 对于普通文本，强制合并最后一个 Chunk，只要它小于 MinChunkSize 且前面还有 Chunk
 			prevChunk := chunks[len(chunks)-1]
 			mergedChunk := prevChunk + joinSep + chunk
 			// 放宽限制：为了满足 MinChunkSize，允许合并后的结果超过 MaxChunkSize
 			// 但我们仍然保留一个合理的上限，比如 MaxChunkSize * 3，但不能超过 embedding 限制
-			mergedLen := utf8.RuneCountInString(mergedChunk)
+			mergedLen := s.size(mergedChunk)
 			if mergedLen <= s.config.MaxChunkSize*3 && mergedLen <= maxEmbeddingSize {
 				chunks[len(chunks)-1] = mergedChunk
 			} else {
@@ -466,21 +628,23 @@ func (s *tfidfSplitter) groupSentences(sentences []string, tfidfMatrix [][]float
 	if s.config.FilterGarbageChunks {
 		filteredChunks := make([]string, 0, len(chunks))
 		for i, chunk := range chunks {
-			if isGarbageChunk(chunk) {
-				chunkLen := utf8.RuneCountInString(chunk)
-				// 截取前100个字符用于日志显示
-				preview := chunk
-				if chunkLen > 100 {
-					runes := []rune(chunk)
-					preview = string(runes[:100]) + "..."
+			if garbage.IsGarbage(chunk) {
+				if s.logger != nil {
+					s.logger.WithFields(logrus.Fields{
+						"index":   i,
+						"runes":   utf8.RuneCountInString(chunk),
+						"preview": preview(chunk),
+					}).Debug("Dropped garbage chunk")
 				}
-				fmt.Printf("[DEBUG] 过滤乱码 Chunk %d (长度: %d 字符): %q\n", i+1, chunkLen, preview)
 			} else {
 				filteredChunks = append(filteredChunks, chunk)
 			}
 		}
-		if len(filteredChunks) < len(chunks) {
-			fmt.Printf("[DEBUG] 共过滤 %d 个乱码 chunk，保留 %d 个有效 chunk\n", len(chunks)-len(filteredChunks), len(filteredChunks))
+		if len(filteredChunks) < len(chunks) && s.logger != nil {
+			s.logger.WithFields(logrus.Fields{
+				"dropped": len(chunks) - len(filteredChunks),
+				"kept":    len(filteredChunks),
+			}).Debug("Filtered garbage chunks")
 		}
 		return filteredChunks
 	}
@@ -488,113 +652,6 @@ func (s *tfidfSplitter) groupSentences(sentences []string, tfidfMatrix [][]float
 	return chunks
 }
 
-// splitLargeTable 将大表格按行分割成多个 chunk，保持表格行完整
-// 尽量保持表格的语义完整性：如果可能，在每个 chunk 中包含表头
-func (s *tfidfSplitter) splitLargeTable(tableRows []string, maxSize int) []string {
-	if len(tableRows) == 0 {
-		return nil
-	}
-
-	var chunks []string
-	var currentChunk []string
-	var currentLength int
-
-	// 尝试识别表头（通常是前两行：标题行和分隔行）
-	var headerRows []string
-	headerEndIndex := 0
-
-	// 查找表头：第一行通常是标题，第二行可能是分隔行（包含 ---）
-	if len(tableRows) > 0 && isMarkdownTableRow(tableRows[0]) {
-		headerRows = append(headerRows, tableRows[0])
-		headerEndIndex = 1
-		currentLength = utf8.RuneCountInString(tableRows[0])
-
-		// 检查第二行是否是分隔行
-		if len(tableRows) > 1 && isMarkdownTableRow(tableRows[1]) {
-			secondRow := tableRows[1]
-			if strings.Contains(secondRow, "---") {
-				headerRows = append(headerRows, secondRow)
-				headerEndIndex = 2
-				currentLength += utf8.RuneCountInString(secondRow) + 1 // +1 for newline
-			}
-		}
-	}
-
-	// 计算表头长度
-	headerLength := 0
-	if len(headerRows) > 0 {
-		headerLength = utf8.RuneCountInString(strings.Join(headerRows, "\n"))
-	}
-
-	// 从表头之后开始处理数据行
-	for i := headerEndIndex; i < len(tableRows); i++ {
-		row := tableRows[i]
-		rowLength := utf8.RuneCountInString(row)
-
-		// 计算添加这一行后的长度
-		// 如果当前 chunk 为空，需要加上表头（如果存在）
-		var potentialLength int
-		if len(currentChunk) == 0 && len(headerRows) > 0 {
-			// 表头 + 新行：headerLength 已经包含了表头行之间的换行符
-			// 还需要加上表头和数据行之间的换行符（len(headerRows) 个换行符）
-			potentialLength = headerLength + rowLength + len(headerRows)
-		} else {
-			potentialLength = currentLength + rowLength + 1 // +1 for newline
-		}
-
-		// 如果添加这一行会超过限制，且当前 chunk 不为空，先保存当前 chunk
-		if potentialLength > maxSize && len(currentChunk) > 0 {
-			chunk := strings.Join(currentChunk, "\n")
-			chunks = append(chunks, chunk)
-			currentChunk = []string{}
-			currentLength = 0
-			// 重新计算 potentialLength（因为 currentChunk 已清空）
-			if len(headerRows) > 0 {
-				potentialLength = headerLength + rowLength + len(headerRows)
-			} else {
-				potentialLength = rowLength
-			}
-		}
-
-		// 如果当前 chunk 为空，添加表头（如果存在且不会超过限制）
-		if len(currentChunk) == 0 {
-			if len(headerRows) > 0 {
-				// 检查单独一行是否超过限制
-				if rowLength > maxSize {
-					// 单行就超过限制，无法处理，直接添加（这种情况很少见）
-					currentChunk = append(currentChunk, row)
-					currentLength = rowLength
-				} else if potentialLength <= maxSize {
-					// 可以添加表头
-					currentChunk = append(currentChunk, headerRows...)
-					currentChunk = append(currentChunk, row)
-					currentLength = potentialLength
-				} else {
-					// 表头+行超过限制，只添加行
-					currentChunk = append(currentChunk, row)
-					currentLength = rowLength
-				}
-			} else {
-				// 没有表头，直接添加行
-				currentChunk = append(currentChunk, row)
-				currentLength = rowLength
-			}
-		} else {
-			// 添加数据行到现有 chunk
-			currentChunk = append(currentChunk, row)
-			currentLength = potentialLength
-		}
-	}
-
-	// 处理最后一个 chunk
-	if len(currentChunk) > 0 {
-		chunk := strings.Join(currentChunk, "\n")
-		chunks = append(chunks, chunk)
-	}
-
-	return chunks
-}
-
 func (s *tfidfSplitter) cleanChunk(chunk string) string {
 	// 检查是否包含表格
 	if containsTable(chunk) {
@@ -604,7 +661,7 @@ func (s *tfidfSplitter) cleanChunk(chunk string) string {
 		lines := strings.Split(chunk, "\n")
 		var result []string
 		for _, line := range lines {
-			if isMarkdownTableRow(line) {
+			if table.IsMarkdownRow(line) {
 				// 表格行：保留原样（包括前导和尾随空格）
 				result = append(result, line)
 			} else {
@@ -648,84 +705,6 @@ func removeAllWhitespace(s string) string {
 	}, s)
 }
 
-// isGarbageChunk 基于 sego 分词判断一个 chunk 是否是乱码
-// 乱码特征：
-// 1. 有效词比例过低（有效词比例 < 20%）
-// 2. 有效词比例较低且单字符词比例过高（有效词比例 < 30% 且单字符词比例 > 50%）
-func isGarbageChunk(chunk string) bool {
-	if len(chunk) == 0 {
-		return false // 空 chunk 不算乱码
-	}
-
-	// 基于 sego 分词判断
-	segmenter, err := sego.GetSegmenter()
-	if err != nil {
-		// 如果 sego 初始化失败，无法判断，返回 false（不认为是乱码）
-		return false
-	}
-
-	segments := segmenter.Segment([]byte(chunk))
-	if len(segments) == 0 {
-		// 如果分词结果为空，可能是乱码
-		return true
-	}
-
-	var (
-		validTokens      int // 有效词：长度 >= 2 且包含有效字符的词
-		singleCharTokens int // 单字符词
-		totalTokens      int // 总词数
-	)
-
-	for _, seg := range segments {
-		tokenStr := seg.Token().Text()
-		tokenStr = strings.TrimSpace(tokenStr)
-		if len(tokenStr) == 0 {
-			continue
-		}
-		totalTokens++
-
-		tokenRunes := []rune(tokenStr)
-		tokenLen := len(tokenRunes)
-
-		// 单字符词
-		if tokenLen == 1 {
-			singleCharTokens++
-		} else {
-			// 多字符词，检查是否包含有效字符
-			hasValidChar := false
-			for _, r := range tokenRunes {
-				if unicode.Is(unicode.Han, r) || unicode.IsLetter(r) || unicode.IsNumber(r) {
-					hasValidChar = true
-					break
-				}
-			}
-			if hasValidChar {
-				validTokens++
-			}
-		}
-	}
-
-	if totalTokens == 0 {
-		// 如果分词结果为空，认为是乱码
-		return true
-	}
-
-	validTokenRatio := float64(validTokens) / float64(totalTokens)
-	singleCharRatio := float64(singleCharTokens) / float64(totalTokens)
-
-	// 判断规则：
-	// 1. 如果有效词比例 < 20%，直接认为是乱码
-	// 2. 如果有效词比例 < 30% 且单字符词比例 > 50%，认为是乱码
-	if validTokenRatio < 0.2 {
-		return true
-	}
-	if validTokenRatio < 0.3 && singleCharRatio > 0.5 {
-		return true
-	}
-
-	return false
-}
-
 func (s *tfidfSplitter) initSego() error {
 	return nil
 }
@@ -767,34 +746,6 @@ type tableRange struct {
 	end   int // 表格结束位置（字节索引）
 }
 
-// isMarkdownTableRow 判断一行是否是 Markdown 表格行
-// 表格行特征：
-// - 以 | 开头（可选前导空格）
-// - 包含至少一个 | 分隔符
-// - 以 | 结尾（可选尾随空格）
-func isMarkdownTableRow(line string) bool {
-	trimmed := strings.TrimSpace(line)
-	if len(trimmed) == 0 {
-		return false
-	}
-
-	// 必须包含 | 字符
-	if !strings.Contains(trimmed, "|") {
-		return false
-	}
-
-	// 检查是否是表格分隔行（包含 --- 或 :--- 等格式）
-	// 例如：| --- | --- | 或 |:---|:---:|---:|
-	if strings.Contains(trimmed, "---") {
-		// 检查是否包含表格分隔符模式
-		sepPattern := regexp.MustCompile(`\|[\s:]*[-:]+[\s:]*\|`)
-		return sepPattern.MatchString(trimmed)
-	}
-
-	// 普通表格行：以 | 开头（去除前导空格后）
-	return strings.HasPrefix(trimmed, "|")
-}
-
 // findMarkdownTables 识别文本中所有 Markdown 表格的位置范围
 // 返回表格范围的切片，按起始位置排序
 func findMarkdownTables(text string) []tableRange {
@@ -811,7 +762,7 @@ func findMarkdownTables(text string) []tableRange {
 		lineStart := currentPos
 		lineEnd := currentPos + len(line)
 
-		isTableRow := isMarkdownTableRow(line)
+		isTableRow := table.IsMarkdownRow(line)
 
 		if isTableRow {
 			if currentTableStart == -1 {
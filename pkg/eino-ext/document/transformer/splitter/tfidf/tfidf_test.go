@@ -17,12 +17,16 @@
 package tfidf
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"strings"
 	"testing"
+	"unicode/utf8"
 
+	"github.com/cloudwego/eino/components/document"
 	"github.com/cloudwego/eino/schema"
+	"github.com/sirupsen/logrus"
 	"github.com/smartystreets/goconvey/convey"
 )
 
@@ -461,4 +465,216 @@ func TestTFIDFSplitter(t *testing.T) {
 		}
 		convey.So(hasGarbage, convey.ShouldBeTrue)
 	})
+
+	convey.Convey("Test TFIDFSplitter Logger and OnChunk", t, func() {
+		ctx := context.Background()
+		var buf bytes.Buffer
+		logger := logrus.New()
+		logger.SetOutput(&buf)
+		logger.SetLevel(logrus.DebugLevel)
+
+		var infos []ChunkInfo
+		config := &Config{
+			SimilarityThreshold:  0.1,
+			MaxChunkSize:         50,
+			MinChunkSize:         1,
+			MaxSentencesPerChunk: 2,
+			Logger:               logger,
+			Verbose:              true,
+			OnChunk: func(ctx context.Context, info ChunkInfo) {
+				infos = append(infos, info)
+			},
+		}
+
+		splitter, err := NewTFIDFSplitter(ctx, config)
+		convey.So(err, convey.ShouldBeNil)
+
+		text := "This is the first sentence. It is about cats. This is the second sentence. It is about dogs."
+		splitDocs, err := splitter.Transform(ctx, []*schema.Document{{ID: "doc_log", Content: text}})
+		convey.So(err, convey.ShouldBeNil)
+
+		convey.So(len(infos), convey.ShouldEqual, len(splitDocs))
+		for i, info := range infos {
+			convey.So(info.DocID, convey.ShouldEqual, "doc_log")
+			convey.So(info.Index, convey.ShouldEqual, i)
+			convey.So(info.Total, convey.ShouldEqual, len(splitDocs))
+			convey.So(info.Content, convey.ShouldEqual, splitDocs[i].Content)
+		}
+		convey.So(buf.String(), convey.ShouldContainSubstring, "Chunk produced")
+
+		// 默认不输出逐 chunk 的日志
+		buf.Reset()
+		config.Verbose = false
+		splitter, err = NewTFIDFSplitter(ctx, config)
+		convey.So(err, convey.ShouldBeNil)
+		_, err = splitter.Transform(ctx, []*schema.Document{{ID: "doc_log", Content: text}})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(buf.String(), convey.ShouldNotContainSubstring, "Chunk produced")
+	})
+
+	convey.Convey("Test TFIDFSplitter ChunkOverlap", t, func() {
+		ctx := context.Background()
+		text := "This is the first sentence. It is about cats. This is the second sentence. It is about dogs. The third part is different. It discusses airplanes and rockets."
+
+		// 按句子重叠：每个 chunk 以上一个 chunk 的最后一句开头
+		splitter, err := NewTFIDFSplitter(ctx, &Config{
+			SimilarityThreshold:  0.1,
+			MaxChunkSize:         50,
+			MinChunkSize:         1,
+			MaxSentencesPerChunk: 2,
+			ChunkOverlap:         1,
+			OverlapUnit:          OverlapSentences,
+		})
+		convey.So(err, convey.ShouldBeNil)
+		splitDocs, err := splitter.Transform(ctx, []*schema.Document{{ID: "doc_overlap", Content: text}})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(len(splitDocs), convey.ShouldBeGreaterThanOrEqualTo, 3)
+		for i := 1; i < len(splitDocs); i++ {
+			prevSentences := splitIntoSentences(splitDocs[i-1].Content)
+			convey.So(splitDocs[i].Content, convey.ShouldStartWith, prevSentences[len(prevSentences)-1])
+		}
+
+		// 按字符重叠：与不重叠的结果相比，除第一个 chunk 外都以上一个 chunk 的末尾内容开头
+		newSplitter := func(overlap int) document.Transformer {
+			splitter, err := NewTFIDFSplitter(ctx, &Config{
+				SimilarityThreshold:  0.1,
+				MaxChunkSize:         50,
+				MinChunkSize:         1,
+				MaxSentencesPerChunk: 2,
+				ChunkOverlap:         overlap,
+			})
+			convey.So(err, convey.ShouldBeNil)
+			return splitter
+		}
+		plain, err := newSplitter(0).Transform(ctx, []*schema.Document{{ID: "doc_overlap", Content: text}})
+		convey.So(err, convey.ShouldBeNil)
+		overlapped, err := newSplitter(20).Transform(ctx, []*schema.Document{{ID: "doc_overlap", Content: text}})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(len(overlapped), convey.ShouldEqual, len(plain))
+		convey.So(overlapped[0].Content, convey.ShouldEqual, plain[0].Content)
+		for i := 1; i < len(overlapped); i++ {
+			convey.So(overlapped[i].Content, convey.ShouldEndWith, plain[i].Content)
+			overlap := strings.TrimSuffix(overlapped[i].Content, plain[i].Content)
+			convey.So(strings.TrimSpace(overlap), convey.ShouldNotBeEmpty)
+			convey.So(len([]rune(strings.TrimSpace(overlap))), convey.ShouldBeLessThanOrEqualTo, 20)
+			convey.So(plain[i-1].Content, convey.ShouldEndWith, strings.TrimSpace(overlap))
+		}
+
+		_, err = NewTFIDFSplitter(ctx, &Config{MaxChunkSize: 100, ChunkOverlap: 100})
+		convey.So(err, convey.ShouldNotBeNil)
+		_, err = NewTFIDFSplitter(ctx, &Config{ChunkOverlap: 10, OverlapUnit: "pages"})
+		convey.So(err, convey.ShouldNotBeNil)
+	})
+
+	convey.Convey("Test TFIDFSplitter HeadingHierarchy", t, func() {
+		ctx := context.Background()
+		splitter, err := NewTFIDFSplitter(ctx, &Config{
+			SimilarityThreshold:  0.1,
+			MaxChunkSize:         200,
+			MinChunkSize:         50,
+			MaxSentencesPerChunk: 10,
+			HeadingHierarchy:     true,
+		})
+		convey.So(err, convey.ShouldBeNil)
+
+		text := "# 第2章 总体设计\n本章介绍系统的总体架构设计。\n" +
+			"# 第3章 详细设计\n" +
+			"## 3.1 功能要求\n系统需要支持文档的上传、解析和检索功能。\n" +
+			"## 3.2 设计要求\n系统应当采用模块化设计，便于扩展和维护。\n" +
+			"```\n# 这是代码注释，不是标题\n```\n"
+		splitDocs, err := splitter.Transform(ctx, []*schema.Document{{
+			ID:       "doc_sections",
+			Content:  text,
+			MetaData: map[string]any{"source": "design.md"},
+		}})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(len(splitDocs), convey.ShouldEqual, 3)
+
+		expected := []string{"第2章 总体设计", "第3章 详细设计 > 3.1 功能要求", "第3章 详细设计 > 3.2 设计要求"}
+		for i, d := range splitDocs {
+			convey.So(d.MetaData[MetaKeySectionPath], convey.ShouldEqual, expected[i])
+			convey.So(d.MetaData["source"], convey.ShouldEqual, "design.md")
+			convey.So(d.MetaData[MetaKeyParentID], convey.ShouldEqual, "doc_sections")
+			convey.So(d.MetaData[MetaKeyChunkIndex], convey.ShouldEqual, i)
+		}
+		// 标题与正文在同一个 chunk 中；只有标题的顶级章节与其第一个子章节一起输出
+		convey.So(splitDocs[1].Content, convey.ShouldContainSubstring, "第3章 详细设计")
+		convey.So(splitDocs[1].Content, convey.ShouldContainSubstring, "功能要求")
+		convey.So(splitDocs[0].Content, convey.ShouldStartWith, "# 第2章 总体设计")
+		convey.So(splitDocs[0].Content, convey.ShouldNotContainSubstring, "第3章")
+		convey.So(splitDocs[2].MetaData[MetaKeySectionTitles], convey.ShouldResemble, []string{"第3章 详细设计", "3.2 设计要求"})
+	})
+
+	convey.Convey("Test TFIDFSplitter Large Table", t, func() {
+		ctx := context.Background()
+		header := "| id | name | description |\n| --- | --- | --- |"
+		var rows []string
+		for i := 0; i < 400; i++ {
+			rows = append(rows, fmt.Sprintf("| %d | item %d | description of item %d |", i, i, i))
+		}
+		text := "Inventory overview.\n\n" + header + "\n" + strings.Join(rows, "\n") + "\n\nEnd of inventory."
+
+		splitter, err := NewTFIDFSplitter(ctx, &Config{MinChunkSize: 1})
+		convey.So(err, convey.ShouldBeNil)
+		splitDocs, err := splitter.Transform(ctx, []*schema.Document{{ID: "doc_table", Content: text}})
+		convey.So(err, convey.ShouldBeNil)
+
+		// 超过 embedding 限制的表格按行切分，每个子 chunk 都以表头开头，数据行不丢失也不重复
+		var tableChunks []string
+		for _, doc := range splitDocs {
+			if strings.Contains(doc.Content, "| --- |") || strings.Contains(doc.Content, "| item ") {
+				tableChunks = append(tableChunks, doc.Content)
+			}
+		}
+		convey.So(len(tableChunks), convey.ShouldBeGreaterThan, 1)
+		rowCount := 0
+		for _, chunk := range tableChunks {
+			convey.So(strings.HasPrefix(chunk, header+"\n"), convey.ShouldBeTrue)
+			convey.So(utf8.RuneCountInString(chunk), convey.ShouldBeLessThanOrEqualTo, maxEmbeddingSize)
+			rowCount += strings.Count(chunk, "| item ")
+		}
+		convey.So(rowCount, convey.ShouldEqual, len(rows))
+	})
+
+	convey.Convey("Test TFIDFSplitter SizeUnit tokens", t, func() {
+		ctx := context.Background()
+		text := "Sentence one. Sentence two. Sentence three."
+		docs := []*schema.Document{{ID: "doc_tokens", Content: text}}
+
+		// 按字符计算时 MaxChunkSize=15 会强制分割，按 token（这里以单词计）计算时不会
+		counted := 0
+		splitter, err := NewTFIDFSplitter(ctx, &Config{
+			MaxChunkSize:         15,
+			MinChunkSize:         1,
+			MaxSentencesPerChunk: 50,
+			SizeUnit:             SizeTokens,
+			TokenCounter: func(text string) int {
+				counted++
+				return len(strings.Fields(text))
+			},
+		})
+		convey.So(err, convey.ShouldBeNil)
+		splitDocs, err := splitter.Transform(ctx, docs)
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(len(splitDocs), convey.ShouldEqual, 1)
+		convey.So(counted, convey.ShouldBeGreaterThan, 0)
+
+		splitter, err = NewTFIDFSplitter(ctx, &Config{
+			MaxChunkSize:         15,
+			MinChunkSize:         1,
+			MaxSentencesPerChunk: 50,
+		})
+		convey.So(err, convey.ShouldBeNil)
+		splitDocs, err = splitter.Transform(ctx, docs)
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(len(splitDocs), convey.ShouldBeGreaterThanOrEqualTo, 2)
+
+		// 未配置 TokenCounter 时使用 EstimateTokens
+		convey.So(EstimateTokens("你好世界"), convey.ShouldEqual, 4)
+		convey.So(EstimateTokens("hello world"), convey.ShouldEqual, 4)
+		convey.So(EstimateTokens("Go, 语言!"), convey.ShouldEqual, 5)
+
+		_, err = NewTFIDFSplitter(ctx, &Config{SizeUnit: "bytes"})
+		convey.So(err, convey.ShouldNotBeNil)
+	})
 }
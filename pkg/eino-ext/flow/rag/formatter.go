@@ -0,0 +1,117 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+package rag
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/schema"
+)
+
+// Triple is a knowledge graph relationship: Subject --(Predicate)--> Object.
+type Triple struct {
+	Subject   string
+	Predicate string
+	Object    string
+}
+
+// GraphSearcher returns the knowledge graph triples related to a query.
+type GraphSearcher func(ctx context.Context, query string) ([]Triple, error)
+
+// ContextInput is what a ContextFormatter receives.
+type ContextInput struct {
+	// Query is the user query.
+	Query string
+	// Docs are the retrieved documents, in retriever order.
+	Docs []*schema.Document
+	// Triples are the graph triples from Config.GraphSearcher, empty if not configured.
+	Triples []Triple
+}
+
+// ContextFormatter renders the retrieval results into the text bound to VarContext.
+type ContextFormatter func(ctx context.Context, input *ContextInput) (string, error)
+
+// NoContextText is the context text used by DefaultContextFormatter when nothing was retrieved.
+const NoContextText = "未找到相关知识内容。"
+
+// DefaultContextFormatter lists graph triples first (if any) and then the retrieved
+// documents numbered [1], [2], ... so the model can cite them. Each document shows
+// its score when the retriever provides one.
+func DefaultContextFormatter(ctx context.Context, input *ContextInput) (string, error) {
+	if input == nil || (len(input.Docs) == 0 && len(input.Triples) == 0) {
+		return NoContextText, nil
+	}
+
+	var sb strings.Builder
+	// 优先注入结构化的图谱信息
+	if len(input.Triples) > 0 {
+		sb.WriteString("### 核心知识关联（三元组）(Knowledge Graph):\n")
+		for _, t := range input.Triples {
+			fmt.Fprintf(&sb, "- %s --(%s)--> %s\n", t.Subject, t.Predicate, t.Object)
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(input.Docs) > 0 {
+		sb.WriteString("### 相关参考文档 (Reference Documents):\n")
+		for i, doc := range input.Docs {
+			if score, ok := DocScore(doc); ok {
+				fmt.Fprintf(&sb, "[%d] (Score: %.4f) %s\n", i+1, score, doc.Content)
+			} else {
+				fmt.Fprintf(&sb, "[%d] %s\n", i+1, doc.Content)
+			}
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// DefaultPromptTemplate returns the knowledge base QA prompt that asks the model to
+// answer from the context only and cite sources inline as [n]. The conversation
+// history, if any, is inserted before the user query.
+func DefaultPromptTemplate() prompt.ChatTemplate {
+	return prompt.FromMessages(
+		schema.FString,
+		schema.SystemMessage("你是一个专业的知识库助手。请根据提供的背景信息回答问题。\n\n"+
+			"要求：\n"+
+			"1. 回答内容必须严格基于背景信息。\n"+
+			"2. 在引用背景信息的内容处，必须在行内使用 [n] 格式标注引用来源（例如 [1], [2]）。\n"+
+			"3. 如果背景信息中没有相关内容，请说明你不知道。\n\n"+
+			"背景信息：\n{"+VarContext+"}"),
+		schema.MessagesPlaceholder(VarHistory, true),
+		schema.UserMessage("{"+VarInput+"}"),
+	)
+}
+
+// DocScore returns the similarity score of a retrieved document, read from the
+// "score" metadata or converted from "distance" (distance = 1 - similarity).
+func DocScore(doc *schema.Document) (float64, bool) {
+	if doc == nil || doc.MetaData == nil {
+		return 0, false
+	}
+	if score, ok := doc.MetaData["score"].(float64); ok {
+		return score, true
+	}
+	if distance, ok := doc.MetaData["distance"].(float64); ok {
+		return 1.0 - distance, true
+	}
+	return 0, false
+}
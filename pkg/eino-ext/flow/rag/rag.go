@@ -0,0 +1,234 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+package rag
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/prompt"
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/compose"
+	"github.com/cloudwego/eino/schema"
+	"github.com/sirupsen/logrus"
+)
+
+// Prompt variables filled in by the chain before the prompt template runs.
+const (
+	// VarInput is the user query.
+	VarInput = "input"
+	// VarContext is the formatted retrieval context produced by the ContextFormatter.
+	VarContext = "context"
+	// VarHistory is the conversation history set with WithHistory, empty if not set.
+	VarHistory = "history"
+)
+
+type historyKey struct{}
+
+// WithHistory attaches the previous conversation messages to ctx. The chain binds
+// them to VarHistory, which DefaultPromptTemplate places between the system prompt
+// and the user query. Retrieval still uses the query only.
+func WithHistory(ctx context.Context, history []*schema.Message) context.Context {
+	return context.WithValue(ctx, historyKey{}, history)
+}
+
+// historyFromContext 读取 WithHistory 设置的历史消息
+func historyFromContext(ctx context.Context) []*schema.Message {
+	history, _ := ctx.Value(historyKey{}).([]*schema.Message)
+	return history
+}
+
+// Config defines the configuration for NewRAGChain.
+type Config struct {
+	// Retriever retrieves the documents for the query, required.
+	Retriever retriever.Retriever
+	// ChatModel generates the answer, required.
+	ChatModel model.BaseChatModel
+	// PromptTemplate renders the messages sent to ChatModel. It receives the
+	// VarInput, VarContext and VarHistory variables, default DefaultPromptTemplate().
+	PromptTemplate prompt.ChatTemplate
+	// ContextFormatter formats retrieved documents and graph triples into the
+	// VarContext variable, default DefaultContextFormatter.
+	ContextFormatter ContextFormatter
+	// GraphSearcher optionally returns knowledge graph triples related to the
+	// query, which are passed to ContextFormatter. Errors are logged and ignored.
+	GraphSearcher GraphSearcher
+	// RetrieverOptions are passed to every Retrieve call.
+	RetrieverOptions []retriever.Option
+	// Streaming makes the chain always call ChatModel.Stream; Invoke concatenates
+	// the chunks into a single message. Use it for providers that only support
+	// streaming responses. Without it Invoke calls Generate and Stream calls Stream.
+	Streaming bool
+}
+
+// NewRAGChain builds and compiles the retrieve -> format -> prompt -> model chain.
+func NewRAGChain(ctx context.Context, config *Config) (compose.Runnable[string, *schema.Message], error) {
+	if config == nil {
+		return nil, fmt.Errorf("[NewRAGChain] config is nil")
+	}
+	if config.Retriever == nil {
+		return nil, fmt.Errorf("[NewRAGChain] retriever not provided")
+	}
+	if config.ChatModel == nil {
+		return nil, fmt.Errorf("[NewRAGChain] chat model not provided")
+	}
+
+	tpl := config.PromptTemplate
+	if tpl == nil {
+		tpl = DefaultPromptTemplate()
+	}
+	formatter := config.ContextFormatter
+	if formatter == nil {
+		formatter = DefaultContextFormatter
+	}
+
+	chain := compose.NewChain[string, *schema.Message]().
+		AppendLambda(compose.InvokableLambda(func(ctx context.Context, query string) (map[string]any, error) {
+			return buildVariables(ctx, config, formatter, query)
+		})).
+		AppendChatTemplate(tpl)
+
+	if config.Streaming {
+		lambda, err := streamingModelLambda(config.ChatModel)
+		if err != nil {
+			return nil, fmt.Errorf("[NewRAGChain] failed to create streaming model node: %w", err)
+		}
+		chain.AppendLambda(lambda)
+	} else {
+		chain.AppendChatModel(config.ChatModel)
+	}
+
+	runnable, err := chain.Compile(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("[NewRAGChain] failed to compile chain: %w", err)
+	}
+	return runnable, nil
+}
+
+// Explanation is what the chain would send to the chat model for a query.
+type Explanation struct {
+	// Input holds the retrieved documents and graph triples.
+	Input *ContextInput
+	// Context is the formatted VarContext text.
+	Context string
+	// Messages are the rendered prompt messages, including the history set with WithHistory.
+	Messages []*schema.Message
+}
+
+// Explain runs the retrieve, format and prompt steps of the chain built from config
+// without calling the chat model, so that retrieval results can be inspected.
+func Explain(ctx context.Context, config *Config, query string) (*Explanation, error) {
+	if config == nil || config.Retriever == nil {
+		return nil, fmt.Errorf("[Explain] retriever not provided")
+	}
+	tpl := config.PromptTemplate
+	if tpl == nil {
+		tpl = DefaultPromptTemplate()
+	}
+	formatter := config.ContextFormatter
+	if formatter == nil {
+		formatter = DefaultContextFormatter
+	}
+
+	input, contextText, err := retrieveContext(ctx, config, formatter, query)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := tpl.Format(ctx, templateVariables(ctx, query, contextText))
+	if err != nil {
+		return nil, fmt.Errorf("[Explain] failed to render prompt: %w", err)
+	}
+	return &Explanation{Input: input, Context: contextText, Messages: messages}, nil
+}
+
+// buildVariables 检索文档、查询图谱并生成模板变量
+func buildVariables(ctx context.Context, config *Config, formatter ContextFormatter, query string) (map[string]any, error) {
+	_, contextText, err := retrieveContext(ctx, config, formatter, query)
+	if err != nil {
+		return nil, err
+	}
+	return templateVariables(ctx, query, contextText), nil
+}
+
+// templateVariables 生成模板变量
+func templateVariables(ctx context.Context, query, contextText string) map[string]any {
+	return map[string]any{
+		VarInput:   query,
+		VarContext: contextText,
+		VarHistory: historyFromContext(ctx),
+	}
+}
+
+// retrieveContext 检索文档、查询图谱并格式化上下文
+func retrieveContext(ctx context.Context, config *Config, formatter ContextFormatter, query string) (*ContextInput, string, error) {
+	docs, err := config.Retriever.Retrieve(ctx, query, config.RetrieverOptions...)
+	if err != nil {
+		return nil, "", fmt.Errorf("[RAGChain] failed to retrieve documents: %w", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"query":       query,
+		"chunk_count": len(docs),
+	}).Info("召回的chunk信息")
+	for i, doc := range docs {
+		score, _ := DocScore(doc)
+		logrus.WithFields(logrus.Fields{
+			"index":          i + 1,
+			"id":             doc.ID,
+			"score":          score,
+			"metadata":       doc.MetaData,
+			"content_length": len(doc.Content),
+		}).Debug("召回的chunk详情")
+	}
+
+	var triples []Triple
+	if config.GraphSearcher != nil {
+		triples, err = config.GraphSearcher(ctx, query)
+		if err != nil {
+			logrus.WithError(err).WithField("query", query).Warn("Graph search failed, continuing without triples")
+			triples = nil
+		}
+	}
+
+	input := &ContextInput{
+		Query:   query,
+		Docs:    docs,
+		Triples: triples,
+	}
+	contextText, err := formatter(ctx, input)
+	if err != nil {
+		return nil, "", fmt.Errorf("[RAGChain] failed to format context: %w", err)
+	}
+	return input, contextText, nil
+}
+
+// streamingModelLambda 将 ChatModel 包装为始终以流式方式调用的节点
+func streamingModelLambda(cm model.BaseChatModel) (*compose.Lambda, error) {
+	invoke := func(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+		sr, err := cm.Stream(ctx, input, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return schema.ConcatMessageStream(sr)
+	}
+	stream := func(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+		return cm.Stream(ctx, input, opts...)
+	}
+	return compose.AnyLambda(invoke, stream, nil, nil)
+}
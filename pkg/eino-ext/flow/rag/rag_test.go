@@ -0,0 +1,233 @@
+/*
+ * Copyright 2024 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+package rag
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/eino/components/model"
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+	"github.com/smartystreets/goconvey/convey"
+)
+
+// fakeRetriever 返回固定的文档
+type fakeRetriever struct {
+	docs  []*schema.Document
+	query string
+}
+
+func (r *fakeRetriever) Retrieve(ctx context.Context, query string, opts ...retriever.Option) ([]*schema.Document, error) {
+	r.query = query
+	return r.docs, nil
+}
+
+// fakeChatModel 记录收到的消息，回答为 system 消息的内容
+// Generate 被禁用时只能通过 Stream 调用
+type fakeChatModel struct {
+	inputs      [][]*schema.Message
+	noGenerate  bool
+	streamCalls int
+}
+
+func (m *fakeChatModel) Generate(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.Message, error) {
+	if m.noGenerate {
+		return nil, fmt.Errorf("generate not supported")
+	}
+	m.inputs = append(m.inputs, input)
+	return schema.AssistantMessage("answer", nil), nil
+}
+
+func (m *fakeChatModel) Stream(ctx context.Context, input []*schema.Message, opts ...model.Option) (*schema.StreamReader[*schema.Message], error) {
+	m.inputs = append(m.inputs, input)
+	m.streamCalls++
+	return schema.StreamReaderFromArray([]*schema.Message{
+		schema.AssistantMessage("ans", nil),
+		schema.AssistantMessage("wer", nil),
+	}), nil
+}
+
+func TestNewRAGChain(t *testing.T) {
+	ctx := context.Background()
+
+	convey.Convey("Test NewRAGChain validation", t, func() {
+		_, err := NewRAGChain(ctx, &Config{ChatModel: &fakeChatModel{}})
+		convey.So(err, convey.ShouldNotBeNil)
+		_, err = NewRAGChain(ctx, &Config{Retriever: &fakeRetriever{}})
+		convey.So(err, convey.ShouldNotBeNil)
+	})
+
+	convey.Convey("Test NewRAGChain Invoke with default prompt and formatter", t, func() {
+		ret := &fakeRetriever{docs: []*schema.Document{
+			{ID: "1", Content: "Eino 是一个 LLM 应用框架", MetaData: map[string]any{"distance": 0.25}},
+			{ID: "2", Content: "LightRAG 结合图谱与向量检索"},
+		}}
+		cm := &fakeChatModel{}
+		chain, err := NewRAGChain(ctx, &Config{
+			Retriever: ret,
+			ChatModel: cm,
+			GraphSearcher: func(ctx context.Context, query string) ([]Triple, error) {
+				return []Triple{{Subject: "Eino", Predicate: "属于", Object: "CloudWeGo"}}, nil
+			},
+		})
+		convey.So(err, convey.ShouldBeNil)
+
+		msg, err := chain.Invoke(ctx, "什么是 Eino？")
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(msg.Content, convey.ShouldEqual, "answer")
+		convey.So(ret.query, convey.ShouldEqual, "什么是 Eino？")
+
+		convey.So(len(cm.inputs), convey.ShouldEqual, 1)
+		messages := cm.inputs[0]
+		convey.So(len(messages), convey.ShouldEqual, 2)
+		system := messages[0].Content
+		convey.So(system, convey.ShouldContainSubstring, "- Eino --(属于)--> CloudWeGo")
+		convey.So(system, convey.ShouldContainSubstring, "[1] (Score: 0.7500) Eino 是一个 LLM 应用框架")
+		convey.So(system, convey.ShouldContainSubstring, "[2] LightRAG 结合图谱与向量检索")
+		// 图谱信息在参考文档之前
+		convey.So(strings.Index(system, "CloudWeGo"), convey.ShouldBeLessThan, strings.Index(system, "[1] (Score"))
+		convey.So(messages[1].Content, convey.ShouldEqual, "什么是 Eino？")
+	})
+
+	convey.Convey("Test NewRAGChain custom formatter and graph search failure", t, func() {
+		cm := &fakeChatModel{}
+		var got *ContextInput
+		chain, err := NewRAGChain(ctx, &Config{
+			Retriever: &fakeRetriever{},
+			ChatModel: cm,
+			GraphSearcher: func(ctx context.Context, query string) ([]Triple, error) {
+				return nil, fmt.Errorf("graph unavailable")
+			},
+			ContextFormatter: func(ctx context.Context, input *ContextInput) (string, error) {
+				got = input
+				return "CUSTOM", nil
+			},
+		})
+		convey.So(err, convey.ShouldBeNil)
+
+		_, err = chain.Invoke(ctx, "q")
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(got.Query, convey.ShouldEqual, "q")
+		convey.So(len(got.Triples), convey.ShouldEqual, 0)
+		convey.So(cm.inputs[0][0].Content, convey.ShouldContainSubstring, "CUSTOM")
+	})
+
+	convey.Convey("Test NewRAGChain with history", t, func() {
+		ret := &fakeRetriever{}
+		cm := &fakeChatModel{}
+		chain, err := NewRAGChain(ctx, &Config{Retriever: ret, ChatModel: cm})
+		convey.So(err, convey.ShouldBeNil)
+
+		history := []*schema.Message{
+			schema.UserMessage("什么是 Eino？"),
+			schema.AssistantMessage("Eino 是一个 LLM 应用框架", nil),
+		}
+		_, err = chain.Invoke(WithHistory(ctx, history), "它由谁开发？")
+		convey.So(err, convey.ShouldBeNil)
+		// 检索只使用当前问题
+		convey.So(ret.query, convey.ShouldEqual, "它由谁开发？")
+
+		messages := cm.inputs[0]
+		convey.So(len(messages), convey.ShouldEqual, 4)
+		convey.So(messages[0].Role, convey.ShouldEqual, schema.System)
+		convey.So(messages[1].Content, convey.ShouldEqual, "什么是 Eino？")
+		convey.So(messages[2].Role, convey.ShouldEqual, schema.Assistant)
+		convey.So(messages[3].Content, convey.ShouldEqual, "它由谁开发？")
+	})
+
+	convey.Convey("Test NewRAGChain Streaming", t, func() {
+		cm := &fakeChatModel{noGenerate: true}
+		chain, err := NewRAGChain(ctx, &Config{
+			Retriever: &fakeRetriever{},
+			ChatModel: cm,
+			Streaming: true,
+		})
+		convey.So(err, convey.ShouldBeNil)
+
+		// Invoke 也走 Stream 并拼接结果
+		msg, err := chain.Invoke(ctx, "q")
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(msg.Content, convey.ShouldEqual, "answer")
+		convey.So(cm.inputs[0][0].Content, convey.ShouldContainSubstring, NoContextText)
+
+		sr, err := chain.Stream(ctx, "q")
+		convey.So(err, convey.ShouldBeNil)
+		var chunks []string
+		for {
+			chunk, err := sr.Recv()
+			if err == io.EOF {
+				break
+			}
+			convey.So(err, convey.ShouldBeNil)
+			chunks = append(chunks, chunk.Content)
+		}
+		convey.So(chunks, convey.ShouldResemble, []string{"ans", "wer"})
+		convey.So(cm.streamCalls, convey.ShouldEqual, 2)
+	})
+}
+
+func TestDefaultContextFormatter(t *testing.T) {
+	ctx := context.Background()
+
+	convey.Convey("Test DefaultContextFormatter", t, func() {
+		text, err := DefaultContextFormatter(ctx, &ContextInput{})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(text, convey.ShouldEqual, NoContextText)
+
+		text, err = DefaultContextFormatter(ctx, &ContextInput{Docs: []*schema.Document{
+			{Content: "a", MetaData: map[string]any{"score": 0.9}},
+		}})
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(text, convey.ShouldEqual, "### 相关参考文档 (Reference Documents):\n[1] (Score: 0.9000) a\n")
+	})
+}
+
+func TestExplain(t *testing.T) {
+	ctx := context.Background()
+
+	convey.Convey("Test Explain", t, func() {
+		_, err := Explain(ctx, &Config{}, "q")
+		convey.So(err, convey.ShouldNotBeNil)
+
+		ret := &fakeRetriever{docs: []*schema.Document{{ID: "1", Content: "Eino 是一个 LLM 应用框架"}}}
+		cm := &fakeChatModel{}
+		config := &Config{
+			Retriever: ret,
+			ChatModel: cm,
+			GraphSearcher: func(ctx context.Context, query string) ([]Triple, error) {
+				return []Triple{{Subject: "Eino", Predicate: "属于", Object: "CloudWeGo"}}, nil
+			},
+		}
+		history := []*schema.Message{schema.UserMessage("你好")}
+		exp, err := Explain(WithHistory(ctx, history), config, "什么是 Eino？")
+		convey.So(err, convey.ShouldBeNil)
+		convey.So(ret.query, convey.ShouldEqual, "什么是 Eino？")
+		convey.So(len(exp.Input.Docs), convey.ShouldEqual, 1)
+		convey.So(len(exp.Input.Triples), convey.ShouldEqual, 1)
+		convey.So(exp.Context, convey.ShouldContainSubstring, "[1] Eino 是一个 LLM 应用框架")
+		convey.So(len(exp.Messages), convey.ShouldEqual, 3)
+		convey.So(exp.Messages[0].Content, convey.ShouldContainSubstring, exp.Context)
+		convey.So(exp.Messages[2].Content, convey.ShouldEqual, "什么是 Eino？")
+		// 不调用模型
+		convey.So(len(cm.inputs), convey.ShouldEqual, 0)
+	})
+}
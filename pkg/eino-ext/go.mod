@@ -6,6 +6,7 @@ require (
 	github.com/cloudwego/eino v0.7.14
 	github.com/rioloc/tfidf-go v0.0.0-20250724175239-3a8f9fe7e629
 	github.com/sirupsen/logrus v1.9.3
+	golang.org/x/net v0.47.0
 )
 
 require (
@@ -75,6 +76,7 @@ require (
 	golang.org/x/sync v0.18.0 // indirect
 	golang.org/x/sys v0.38.0 // indirect
 	golang.org/x/telemetry v0.0.0-20251008203120-078029d740a8 // indirect
+	golang.org/x/text v0.31.0 // indirect
 	golang.org/x/time v0.5.0 // indirect
 	golang.org/x/tools v0.38.0 // indirect
 	golang.org/x/xerrors v0.0.0-20240903120638-7835f813f4da // indirect
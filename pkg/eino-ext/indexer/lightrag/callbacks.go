@@ -0,0 +1,65 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lightrag
+
+import (
+	"github.com/cloudwego/eino/schema"
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/document/transformer/splitter/tfidf"
+)
+
+// Keys of the Extra maps attached to the Indexer and Retriever callback inputs and outputs.
+const (
+	// CallbackExtraDocCount is the number of documents received (input) or written/returned (output).
+	CallbackExtraDocCount = "doc_count"
+	// CallbackExtraTokenCount is the number of tokens of the document contents. For the
+	// indexer output it only counts the documents actually written, i.e. the embedding workload.
+	CallbackExtraTokenCount = "token_count"
+	// CallbackExtraSkippedCount is the number of documents the indexer skipped as duplicates.
+	CallbackExtraSkippedCount = "skipped_count"
+	// CallbackExtraQueryTokens is the number of tokens of the retriever query.
+	CallbackExtraQueryTokens = "query_tokens"
+	// CallbackExtraMode is the retrieval mode used by the retriever.
+	CallbackExtraMode = "mode"
+)
+
+// docsTokens 统计文档内容的 token 数，counter 为空时使用 tfidf.EstimateTokens 估算
+func docsTokens(counter func(string) int, docs []*schema.Document) int {
+	if counter == nil {
+		counter = tfidf.EstimateTokens
+	}
+	total := 0
+	for _, doc := range docs {
+		if doc != nil {
+			total += counter(doc.Content)
+		}
+	}
+	return total
+}
+
+// mapsTokens 与 docsTokens 相同，但作用于 DocumentToMap 转换后的文档
+func mapsTokens(counter func(string) int, docs []map[string]any) int {
+	if counter == nil {
+		counter = tfidf.EstimateTokens
+	}
+	total := 0
+	for _, doc := range docs {
+		if content, ok := doc["content"].(string); ok {
+			total += counter(content)
+		}
+	}
+	return total
+}
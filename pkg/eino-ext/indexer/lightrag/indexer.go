@@ -27,14 +27,34 @@ import (
 	"github.com/cloudwego/eino/schema"
 )
 
+// WriteMode controls how Store handles documents that already exist in LightRAG.
+type WriteMode string
+
+const (
+	// WriteModeInsert writes every document as is. A document with an existing ID
+	// replaces the stored one; duplicate content under a new ID is stored again.
+	WriteModeInsert WriteMode = "insert"
+	// WriteModeSkipDuplicates skips documents whose content hash is already stored
+	// (or appears earlier in the same batch). The ID of the existing document is
+	// returned in place of the skipped one.
+	WriteModeSkipDuplicates WriteMode = "skip_duplicates"
+	// WriteModeUpsert replaces existing documents matched by ID or by content hash,
+	// so re-ingesting the same chunk under a new ID does not leave the old copy behind.
+	WriteModeUpsert WriteMode = "upsert"
+)
+
 // IndexerConfig defines the configuration for the LightRAG indexer.
 type IndexerConfig struct {
 	// LightRAG is the LightRAG instance to use for indexing.
 	LightRAG *LightRAG
+	// WriteMode controls duplicate handling, default WriteModeInsert.
+	WriteMode WriteMode
 	// DocumentToMap optionally overrides the default conversion from eino document to map.
 	DocumentToMap func(ctx context.Context, doc *schema.Document) (map[string]any, error)
 	// Transformer optionally transforms documents before indexing (e.g. splitting).
 	Transformer document.Transformer
+	// TokenCounter counts the tokens reported in callback extras, default tfidf.EstimateTokens.
+	TokenCounter func(text string) int
 }
 
 // Indexer implements the Eino indexer.Indexer interface for LightRAG.
@@ -52,6 +72,14 @@ func NewIndexer(ctx context.Context, config *IndexerConfig) (*Indexer, error) {
 		config.DocumentToMap = defaultDocumentToMap
 	}
 
+	switch config.WriteMode {
+	case "":
+		config.WriteMode = WriteModeInsert
+	case WriteModeInsert, WriteModeSkipDuplicates, WriteModeUpsert:
+	default:
+		return nil, fmt.Errorf("[NewIndexer] unknown write mode: %s", config.WriteMode)
+	}
+
 	return &Indexer{
 		config: config,
 	}, nil
@@ -66,7 +94,13 @@ func (i *Indexer) Store(ctx context.Context, docs []*schema.Document, opts ...in
 		return nil, fmt.Errorf("[Store] config is nil")
 	}
 	ctx = callbacks.EnsureRunInfo(ctx, i.GetType(), components.ComponentOfIndexer)
-	ctx = callbacks.OnStart(ctx, &indexer.CallbackInput{Docs: docs})
+	ctx = callbacks.OnStart(ctx, &indexer.CallbackInput{
+		Docs: docs,
+		Extra: map[string]any{
+			CallbackExtraDocCount:   len(docs),
+			CallbackExtraTokenCount: docsTokens(i.config.TokenCounter, docs),
+		},
+	})
 	defer func() {
 		if err != nil {
 			callbacks.OnError(ctx, err)
@@ -102,16 +136,117 @@ func (i *Indexer) Store(ctx context.Context, docs []*schema.Document, opts ...in
 	if i.config.LightRAG == nil {
 		return nil, fmt.Errorf("[Store] LightRAG instance is nil")
 	}
-	ids, err = i.config.LightRAG.InsertBatch(ctx, toStore)
-	if err != nil {
-		return nil, fmt.Errorf("[Store] failed to insert batch into lightrag: %w", err)
+	written := toStore
+	if i.config.WriteMode == WriteModeInsert {
+		ids, err = i.config.LightRAG.InsertBatch(ctx, toStore)
+		if err != nil {
+			return nil, fmt.Errorf("[Store] failed to insert batch into lightrag: %w", err)
+		}
+	} else {
+		ids, written, err = i.storeDedup(ctx, toStore)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	callbacks.OnEnd(ctx, &indexer.CallbackOutput{IDs: ids})
+	callbacks.OnEnd(ctx, &indexer.CallbackOutput{
+		IDs: ids,
+		Extra: map[string]any{
+			CallbackExtraDocCount:     len(written),
+			CallbackExtraSkippedCount: len(toStore) - len(written),
+			CallbackExtraTokenCount:   mapsTokens(i.config.TokenCounter, written),
+		},
+	})
 
 	return ids, nil
 }
 
+// DeleteByIDs removes the documents with the given IDs from LightRAG.
+func (i *Indexer) DeleteByIDs(ctx context.Context, ids []string) error {
+	if i == nil || i.config == nil || i.config.LightRAG == nil {
+		return fmt.Errorf("[DeleteByIDs] LightRAG instance is nil")
+	}
+	return i.config.LightRAG.DeleteByIDs(ctx, ids)
+}
+
+// storeDedup 按内容哈希去重后写入，返回的 ID 与输入文档一一对应，同时返回实际写入的文档
+func (i *Indexer) storeDedup(ctx context.Context, docs []map[string]any) ([]string, []map[string]any, error) {
+	rag := i.config.LightRAG
+
+	hashes := make([]string, len(docs))
+	lookup := make([]string, 0, len(docs))
+	for idx, doc := range docs {
+		// 空内容不参与去重
+		if content, _ := doc["content"].(string); content != "" {
+			hashes[idx] = contentHash(content)
+			lookup = append(lookup, hashes[idx])
+		}
+	}
+	existing, err := rag.idsByContentHash(ctx, lookup)
+	if err != nil {
+		return nil, nil, fmt.Errorf("[Store] failed to look up content hashes: %w", err)
+	}
+
+	ids := make([]string, len(docs))
+	toInsert := make([]map[string]any, 0, len(docs))
+	var toDelete []string
+	seen := make(map[string]string, len(docs))
+	for idx, doc := range docs {
+		id, _ := doc["id"].(string)
+		hash := hashes[idx]
+		if hash == "" {
+			ids[idx] = id
+			toInsert = append(toInsert, doc)
+			continue
+		}
+		// 同一批次中内容重复的文档只写入第一条
+		if firstID, ok := seen[hash]; ok {
+			ids[idx] = firstID
+			continue
+		}
+		if existingIDs := existing[hash]; len(existingIDs) > 0 {
+			if i.config.WriteMode == WriteModeSkipDuplicates {
+				seen[hash] = existingIDs[0]
+				ids[idx] = existingIDs[0]
+				continue
+			}
+			// Upsert: 同 ID 由 INSERT OR REPLACE 覆盖，不同 ID 的旧记录在写入成功后删除
+			for _, existingID := range existingIDs {
+				if existingID != id {
+					toDelete = append(toDelete, existingID)
+				}
+			}
+		}
+		seen[hash] = id
+		ids[idx] = id
+		toInsert = append(toInsert, doc)
+	}
+
+	// 先写入再删除旧记录：InsertBatch 在事务外做 embedding，失败时旧文档和图谱关联仍然保留
+	if _, err := rag.InsertBatch(ctx, toInsert); err != nil {
+		return nil, nil, fmt.Errorf("[Store] failed to insert batch into lightrag: %w", err)
+	}
+	// 旧 ID 可能恰好是本批次另一篇文档的 ID，刚写入的记录不能删除
+	inserted := make(map[string]bool, len(toInsert))
+	for _, doc := range toInsert {
+		if id, _ := doc["id"].(string); id != "" {
+			inserted[id] = true
+		}
+	}
+	stale := toDelete[:0]
+	for _, id := range toDelete {
+		if !inserted[id] {
+			stale = append(stale, id)
+		}
+	}
+	if len(stale) > 0 {
+		if err := rag.DeleteByIDs(ctx, stale); err != nil {
+			return nil, nil, fmt.Errorf("[Store] failed to delete replaced documents: %w", err)
+		}
+	}
+	return ids, toInsert, nil
+}
+
 // GetType returns the component type.
 func (i *Indexer) GetType() string {
 	return "LightRAG"
@@ -18,11 +18,15 @@ package lightrag
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
+	"github.com/cloudwego/eino/callbacks"
 	"github.com/cloudwego/eino/components/embedding"
+	"github.com/cloudwego/eino/components/indexer"
 	"github.com/cloudwego/eino/schema"
 )
 
@@ -42,6 +46,13 @@ func (e *simpleEmbedder) EmbedStrings(ctx context.Context, texts []string, opts
 	return vectors, nil
 }
 
+// failingEmbedder 总是返回错误，用于验证写入失败时的数据状态
+type failingEmbedder struct{}
+
+func (failingEmbedder) EmbedStrings(ctx context.Context, texts []string, opts ...embedding.Option) ([][]float64, error) {
+	return nil, errors.New("embedding service unavailable")
+}
+
 func TestIndexer(t *testing.T) {
 	ctx := context.Background()
 	workingDir := "./rag_storage_test_indexer"
@@ -101,3 +112,299 @@ func TestIndexer(t *testing.T) {
 		t.Errorf("expected ids [1, 2], got %v", ids)
 	}
 }
+
+func newTestLightRAG(t *testing.T, workingDir string) *LightRAG {
+	t.Helper()
+	os.RemoveAll(workingDir)
+	t.Cleanup(func() { os.RemoveAll(workingDir) })
+
+	if err := os.MkdirAll(workingDir, 0755); err != nil {
+		t.Fatalf("Failed to create working directory: %v", err)
+	}
+	rag, err := New(Options{
+		WorkingDir: workingDir,
+		DuckDBPath: filepath.Join(workingDir, "duckdb.db"),
+		GraphPath:  filepath.Join(workingDir, "graph.db"),
+		Embedder:   &simpleEmbedder{dims: 8},
+		TableName:  "documents",
+	})
+	if err != nil {
+		t.Fatalf("failed to create LightRAG: %v", err)
+	}
+	t.Cleanup(func() { rag.Close() })
+	return rag
+}
+
+func storedIDs(t *testing.T, rag *LightRAG) []string {
+	t.Helper()
+	rows, err := rag.db.Query("SELECT id FROM documents ORDER BY id")
+	if err != nil {
+		t.Fatalf("failed to query documents: %v", err)
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			t.Fatalf("failed to scan id: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func TestIndexer_WriteModes(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("insert keeps duplicate content", func(t *testing.T) {
+		rag := newTestLightRAG(t, "./rag_storage_test_insert")
+		idx, err := NewIndexer(ctx, &IndexerConfig{LightRAG: rag})
+		if err != nil {
+			t.Fatalf("failed to create indexer: %v", err)
+		}
+		if _, err := idx.Store(ctx, []*schema.Document{{ID: "a", Content: "same"}}); err != nil {
+			t.Fatalf("failed to store: %v", err)
+		}
+		if _, err := idx.Store(ctx, []*schema.Document{{ID: "b", Content: "same"}}); err != nil {
+			t.Fatalf("failed to store: %v", err)
+		}
+		if got := storedIDs(t, rag); !reflect.DeepEqual(got, []string{"a", "b"}) {
+			t.Errorf("expected [a b], got %v", got)
+		}
+	})
+
+	t.Run("skip duplicates", func(t *testing.T) {
+		rag := newTestLightRAG(t, "./rag_storage_test_skip")
+		idx, err := NewIndexer(ctx, &IndexerConfig{LightRAG: rag, WriteMode: WriteModeSkipDuplicates})
+		if err != nil {
+			t.Fatalf("failed to create indexer: %v", err)
+		}
+		if _, err := idx.Store(ctx, []*schema.Document{{ID: "a", Content: "same"}}); err != nil {
+			t.Fatalf("failed to store: %v", err)
+		}
+		ids, err := idx.Store(ctx, []*schema.Document{
+			{ID: "b", Content: "same"},
+			{ID: "c", Content: "other"},
+			{ID: "d", Content: "other"},
+		})
+		if err != nil {
+			t.Fatalf("failed to store: %v", err)
+		}
+		if !reflect.DeepEqual(ids, []string{"a", "c", "c"}) {
+			t.Errorf("expected ids [a c c], got %v", ids)
+		}
+		if got := storedIDs(t, rag); !reflect.DeepEqual(got, []string{"a", "c"}) {
+			t.Errorf("expected [a c], got %v", got)
+		}
+	})
+
+	t.Run("upsert replaces by id and hash", func(t *testing.T) {
+		rag := newTestLightRAG(t, "./rag_storage_test_upsert")
+		idx, err := NewIndexer(ctx, &IndexerConfig{LightRAG: rag, WriteMode: WriteModeUpsert})
+		if err != nil {
+			t.Fatalf("failed to create indexer: %v", err)
+		}
+		if _, err := idx.Store(ctx, []*schema.Document{
+			{ID: "a", Content: "first"},
+			{ID: "b", Content: "second"},
+		}); err != nil {
+			t.Fatalf("failed to store: %v", err)
+		}
+		// 相同内容换了 ID，旧记录 a 被替换；b 按 ID 覆盖
+		ids, err := idx.Store(ctx, []*schema.Document{
+			{ID: "a2", Content: "first"},
+			{ID: "b", Content: "second, updated"},
+		})
+		if err != nil {
+			t.Fatalf("failed to store: %v", err)
+		}
+		if !reflect.DeepEqual(ids, []string{"a2", "b"}) {
+			t.Errorf("expected ids [a2 b], got %v", ids)
+		}
+		if got := storedIDs(t, rag); !reflect.DeepEqual(got, []string{"a2", "b"}) {
+			t.Errorf("expected [a2 b], got %v", got)
+		}
+		var content string
+		if err := rag.db.QueryRow("SELECT content FROM documents WHERE id = 'b'").Scan(&content); err != nil {
+			t.Fatalf("failed to read document b: %v", err)
+		}
+		if content != "second, updated" {
+			t.Errorf("expected updated content, got %q", content)
+		}
+	})
+
+	t.Run("upsert keeps replaced documents when insert fails", func(t *testing.T) {
+		rag := newTestLightRAG(t, "./rag_storage_test_upsert_fail")
+		idx, err := NewIndexer(ctx, &IndexerConfig{LightRAG: rag, WriteMode: WriteModeUpsert})
+		if err != nil {
+			t.Fatalf("failed to create indexer: %v", err)
+		}
+		if _, err := idx.Store(ctx, []*schema.Document{{ID: "a", Content: "first"}}); err != nil {
+			t.Fatalf("failed to store: %v", err)
+		}
+		rag.embedder = failingEmbedder{}
+		if _, err := idx.Store(ctx, []*schema.Document{{ID: "a2", Content: "first"}}); err == nil {
+			t.Fatal("expected store to fail")
+		}
+		if got := storedIDs(t, rag); !reflect.DeepEqual(got, []string{"a"}) {
+			t.Errorf("expected [a] to be kept, got %v", got)
+		}
+	})
+
+	t.Run("upsert keeps ids reused in the same batch", func(t *testing.T) {
+		rag := newTestLightRAG(t, "./rag_storage_test_upsert_reuse")
+		idx, err := NewIndexer(ctx, &IndexerConfig{LightRAG: rag, WriteMode: WriteModeUpsert})
+		if err != nil {
+			t.Fatalf("failed to create indexer: %v", err)
+		}
+		if _, err := idx.Store(ctx, []*schema.Document{{ID: "a", Content: "first"}}); err != nil {
+			t.Fatalf("failed to store: %v", err)
+		}
+		// "first" 换成 ID b，同时 ID a 写入新内容
+		if _, err := idx.Store(ctx, []*schema.Document{
+			{ID: "b", Content: "first"},
+			{ID: "a", Content: "replacement"},
+		}); err != nil {
+			t.Fatalf("failed to store: %v", err)
+		}
+		if got := storedIDs(t, rag); !reflect.DeepEqual(got, []string{"a", "b"}) {
+			t.Errorf("expected [a b], got %v", got)
+		}
+	})
+
+	t.Run("rows written before content_hash", func(t *testing.T) {
+		rag := newTestLightRAG(t, "./rag_storage_test_backfill")
+		// 旧版本的表没有 content_hash 列
+		if _, err := rag.db.Exec(`DROP TABLE documents`); err != nil {
+			t.Fatalf("failed to drop table: %v", err)
+		}
+		if _, err := rag.db.Exec(`CREATE TABLE documents (
+			id VARCHAR PRIMARY KEY,
+			content TEXT,
+			vector_content FLOAT[],
+			metadata JSON,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`); err != nil {
+			t.Fatalf("failed to create legacy table: %v", err)
+		}
+		if _, err := rag.db.Exec(`INSERT INTO documents (id, content, metadata) VALUES ('old', 'same', '{}'), ('old2', 'first', '{}')`); err != nil {
+			t.Fatalf("failed to insert legacy rows: %v", err)
+		}
+		if err := rag.initSchema(ctx); err != nil {
+			t.Fatalf("failed to migrate schema: %v", err)
+		}
+
+		var hash string
+		if err := rag.db.QueryRow("SELECT content_hash FROM documents WHERE id = 'old'").Scan(&hash); err != nil {
+			t.Fatalf("failed to read content hash: %v", err)
+		}
+		if hash != contentHash("same") {
+			t.Errorf("expected backfilled hash %s, got %s", contentHash("same"), hash)
+		}
+
+		skip, err := NewIndexer(ctx, &IndexerConfig{LightRAG: rag, WriteMode: WriteModeSkipDuplicates})
+		if err != nil {
+			t.Fatalf("failed to create indexer: %v", err)
+		}
+		ids, err := skip.Store(ctx, []*schema.Document{{ID: "new", Content: "same"}})
+		if err != nil {
+			t.Fatalf("failed to store: %v", err)
+		}
+		if !reflect.DeepEqual(ids, []string{"old"}) {
+			t.Errorf("expected existing id [old], got %v", ids)
+		}
+
+		upsert, err := NewIndexer(ctx, &IndexerConfig{LightRAG: rag, WriteMode: WriteModeUpsert})
+		if err != nil {
+			t.Fatalf("failed to create indexer: %v", err)
+		}
+		if _, err := upsert.Store(ctx, []*schema.Document{{ID: "first", Content: "first"}}); err != nil {
+			t.Fatalf("failed to store: %v", err)
+		}
+		if got := storedIDs(t, rag); !reflect.DeepEqual(got, []string{"first", "old"}) {
+			t.Errorf("expected [first old], got %v", got)
+		}
+	})
+
+	t.Run("unknown mode", func(t *testing.T) {
+		if _, err := NewIndexer(ctx, &IndexerConfig{LightRAG: &LightRAG{}, WriteMode: "merge"}); err == nil {
+			t.Error("expected error for unknown write mode")
+		}
+	})
+}
+
+func TestIndexer_DeleteByIDs(t *testing.T) {
+	ctx := context.Background()
+	rag := newTestLightRAG(t, "./rag_storage_test_delete")
+
+	idx, err := NewIndexer(ctx, &IndexerConfig{LightRAG: rag})
+	if err != nil {
+		t.Fatalf("failed to create indexer: %v", err)
+	}
+	if _, err := idx.Store(ctx, []*schema.Document{
+		{ID: "1", Content: "one"},
+		{ID: "2", Content: "two"},
+		{ID: "3", Content: "three"},
+	}); err != nil {
+		t.Fatalf("failed to store: %v", err)
+	}
+
+	if err := idx.DeleteByIDs(ctx, []string{"1", "3", "missing"}); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	if got := storedIDs(t, rag); !reflect.DeepEqual(got, []string{"2"}) {
+		t.Errorf("expected [2], got %v", got)
+	}
+
+	neighbors, err := rag.graph.GetNeighbors(ctx, "1", "is_document")
+	if err != nil {
+		t.Fatalf("failed to get neighbors: %v", err)
+	}
+	if len(neighbors) != 0 {
+		t.Errorf("expected graph links of deleted document to be removed, got %v", neighbors)
+	}
+}
+
+func TestIndexer_Callbacks(t *testing.T) {
+	rag := newTestLightRAG(t, "./rag_storage_test_callbacks")
+
+	var input *indexer.CallbackInput
+	var output *indexer.CallbackOutput
+	handler := callbacks.NewHandlerBuilder().
+		OnStartFn(func(ctx context.Context, info *callbacks.RunInfo, in callbacks.CallbackInput) context.Context {
+			input = indexer.ConvCallbackInput(in)
+			return ctx
+		}).
+		OnEndFn(func(ctx context.Context, info *callbacks.RunInfo, out callbacks.CallbackOutput) context.Context {
+			output = indexer.ConvCallbackOutput(out)
+			return ctx
+		}).
+		Build()
+	ctx := callbacks.InitCallbacks(context.Background(), &callbacks.RunInfo{}, handler)
+
+	idx, err := NewIndexer(ctx, &IndexerConfig{
+		LightRAG:     rag,
+		WriteMode:    WriteModeSkipDuplicates,
+		TokenCounter: func(text string) int { return len(text) },
+	})
+	if err != nil {
+		t.Fatalf("failed to create indexer: %v", err)
+	}
+	if _, err := idx.Store(ctx, []*schema.Document{
+		{ID: "1", Content: "hello"},
+		{ID: "2", Content: "hello"},
+		{ID: "3", Content: "world!"},
+	}); err != nil {
+		t.Fatalf("failed to store: %v", err)
+	}
+
+	if input == nil || output == nil {
+		t.Fatalf("expected start and end callbacks, got input=%v output=%v", input, output)
+	}
+	if input.Extra[CallbackExtraDocCount] != 3 || input.Extra[CallbackExtraTokenCount] != 16 {
+		t.Errorf("unexpected input extra: %v", input.Extra)
+	}
+	if output.Extra[CallbackExtraDocCount] != 2 || output.Extra[CallbackExtraSkippedCount] != 1 || output.Extra[CallbackExtraTokenCount] != 11 {
+		t.Errorf("unexpected output extra: %v", output.Extra)
+	}
+}
@@ -18,7 +18,9 @@ package lightrag
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -151,6 +153,7 @@ func (r *LightRAG) initSchema(ctx context.Context) error {
 			content TEXT,
 			vector_content FLOAT[],
 			metadata JSON,
+			content_hash VARCHAR,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		)
 	`, r.tableName)
@@ -160,6 +163,18 @@ func (r *LightRAG) initSchema(ctx context.Context) error {
 		return fmt.Errorf("[initSchema] failed to create table: %w", err)
 	}
 
+	// 旧版本创建的表没有 content_hash 列，这里补上
+	_, err = r.db.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS content_hash VARCHAR`, r.tableName))
+	if err != nil {
+		return fmt.Errorf("[initSchema] failed to add content_hash column: %w", err)
+	}
+	// 添加列之前写入的文档没有内容哈希，补算后才能参与 skip_duplicates 和 upsert 的去重
+	// DuckDB 的 sha256 返回小写十六进制，与 contentHash 一致
+	_, err = r.db.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET content_hash = sha256(content) WHERE content_hash IS NULL AND content IS NOT NULL`, r.tableName))
+	if err != nil {
+		return fmt.Errorf("[initSchema] failed to backfill content_hash: %w", err)
+	}
+
 	// Create fulltext index (DuckDB supports fulltext search via FTS extension)
 	// Note: DuckDB's FTS extension may need to be loaded separately
 	// For now, we'll use LIKE queries or implement a simple fulltext index
@@ -315,8 +330,8 @@ func (r *LightRAG) InsertBatch(ctx context.Context, docs []map[string]any) ([]st
 		// Prepare insert statement
 		// Note: For DuckDB, we need to use ::FLOAT[] to convert string to FLOAT[]
 		stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(`
-			INSERT OR REPLACE INTO %s (id, content, vector_content, metadata)
-			VALUES (?, ?, ?::FLOAT[], ?)
+			INSERT OR REPLACE INTO %s (id, content, vector_content, metadata, content_hash)
+			VALUES (?, ?, ?::FLOAT[], ?, ?)
 		`, r.tableName))
 		if err != nil {
 			return nil, fmt.Errorf("[InsertBatch] failed to prepare statement: %w", err)
@@ -379,7 +394,7 @@ func (r *LightRAG) InsertBatch(ctx context.Context, docs []map[string]any) ([]st
 				vectorArg = vectorStr
 			}
 
-			_, err = stmt.ExecContext(ctx, id, content, vectorArg, string(metadataJSON))
+			_, err = stmt.ExecContext(ctx, id, content, vectorArg, string(metadataJSON), contentHash(content))
 			if err != nil {
 				stmt.Close()
 				return nil, fmt.Errorf("[InsertBatch] failed to execute statement: %w", err)
@@ -407,6 +422,104 @@ func (r *LightRAG) InsertBatch(ctx context.Context, docs []map[string]any) ([]st
 	return allIds, nil
 }
 
+// DeleteByIDs deletes documents by ID together with their graph relationships.
+// IDs that do not exist are ignored.
+func (r *LightRAG) DeleteByIDs(ctx context.Context, ids []string) error {
+	if r == nil {
+		return fmt.Errorf("[DeleteByIDs] LightRAG instance is nil")
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	if r.db == nil {
+		return fmt.Errorf("[DeleteByIDs] database connection is not available")
+	}
+
+	placeholders := make([]string, len(ids))
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	_, err := r.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id IN (%s)`, r.tableName, strings.Join(placeholders, ", ")), args...)
+	if err != nil {
+		return fmt.Errorf("[DeleteByIDs] failed to delete documents: %w", err)
+	}
+
+	if r.graph == nil {
+		return nil
+	}
+	// 删除以文档为 subject 或 object 的所有边
+	var triples []cayley_driver.Triple
+	collect := func(t cayley_driver.Triple) error {
+		triples = append(triples, t)
+		return nil
+	}
+	for _, id := range ids {
+		if err := r.graph.TriplesIter(ctx, cayley_driver.TripleFilter{Subject: id}, collect); err != nil {
+			return fmt.Errorf("[DeleteByIDs] failed to list graph links: %w", err)
+		}
+		if err := r.graph.TriplesIter(ctx, cayley_driver.TripleFilter{Object: id}, collect); err != nil {
+			return fmt.Errorf("[DeleteByIDs] failed to list graph links: %w", err)
+		}
+	}
+	if len(triples) == 0 {
+		return nil
+	}
+	tx, err := r.graph.BeginTx(ctx)
+	if err != nil {
+		return fmt.Errorf("[DeleteByIDs] failed to begin graph transaction: %w", err)
+	}
+	for _, t := range triples {
+		if err := tx.Unlink(ctx, t.Subject, t.Predicate, t.Object); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("[DeleteByIDs] failed to remove graph link: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("[DeleteByIDs] failed to commit graph transaction: %w", err)
+	}
+	return nil
+}
+
+// idsByContentHash 查询已存在的内容哈希，返回 hash -> ids 的映射，ids 按写入时间排序
+func (r *LightRAG) idsByContentHash(ctx context.Context, hashes []string) (map[string][]string, error) {
+	result := make(map[string][]string, len(hashes))
+	if len(hashes) == 0 {
+		return result, nil
+	}
+	if r.db == nil {
+		return nil, fmt.Errorf("[idsByContentHash] database connection is not available")
+	}
+
+	placeholders := make([]string, len(hashes))
+	args := make([]any, len(hashes))
+	for i, h := range hashes {
+		placeholders[i] = "?"
+		args[i] = h
+	}
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`SELECT content_hash, id FROM %s WHERE content_hash IN (%s) ORDER BY created_at`, r.tableName, strings.Join(placeholders, ", ")), args...)
+	if err != nil {
+		return nil, fmt.Errorf("[idsByContentHash] failed to query content hashes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hash, id string
+		if err := rows.Scan(&hash, &id); err != nil {
+			return nil, fmt.Errorf("[idsByContentHash] failed to scan row: %w", err)
+		}
+		result[hash] = append(result[hash], id)
+	}
+	return result, rows.Err()
+}
+
+// contentHash 计算文档内容的 SHA-256 哈希，用于重复检测
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
 // Retrieve retrieves documents based on query and parameters
 func (r *LightRAG) Retrieve(ctx context.Context, query string, param QueryParam) ([]QueryResult, error) {
 	if r == nil {
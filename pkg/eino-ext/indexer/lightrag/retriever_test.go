@@ -0,0 +1,190 @@
+/*
+ * Copyright 2025 CloudWeGo Authors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lightrag
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloudwego/eino/callbacks"
+	"github.com/cloudwego/eino/components/retriever"
+	"github.com/cloudwego/eino/schema"
+)
+
+func TestRetriever(t *testing.T) {
+	ctx := context.Background()
+	workingDir := "./rag_storage_test_retriever"
+	os.RemoveAll(workingDir)
+	defer os.RemoveAll(workingDir)
+
+	// Create directories for databases
+	if err := os.MkdirAll(workingDir, 0755); err != nil {
+		t.Fatalf("Failed to create working directory: %v", err)
+	}
+	duckdbPath := filepath.Join(workingDir, "duckdb.db")
+	graphPath := filepath.Join(workingDir, "graph.db")
+
+	rag, err := New(Options{
+		WorkingDir: workingDir,
+		DuckDBPath: duckdbPath,
+		GraphPath:  graphPath,
+		Embedder:   &simpleEmbedder{dims: 768},
+		TableName:  "documents",
+	})
+	if err != nil {
+		t.Fatalf("failed to create LightRAG: %v", err)
+	}
+	defer rag.Close()
+
+	idx, err := NewIndexer(ctx, &IndexerConfig{LightRAG: rag})
+	if err != nil {
+		t.Fatalf("failed to create indexer: %v", err)
+	}
+	ret, err := NewRetriever(ctx, &RetrieverConfig{
+		LightRAG: rag,
+		TopK:     1,
+		Mode:     ModeFulltext,
+	})
+	if err != nil {
+		t.Fatalf("failed to create retriever: %v", err)
+	}
+
+	// Both components can be used as eino interfaces from this package
+	var _ retriever.Retriever = ret
+
+	_, err = idx.Store(ctx, []*schema.Document{
+		{
+			ID:       "1",
+			Content:  "Hello world",
+			MetaData: map[string]any{"source": "test"},
+		},
+		{
+			ID:      "2",
+			Content: "Eino is a framework for building LLM applications",
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to store documents: %v", err)
+	}
+
+	results, err := ret.Retrieve(ctx, "Eino")
+	if err != nil {
+		t.Fatalf("failed to retrieve: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if results[0].ID != "2" {
+		t.Errorf("expected document 2, got %s", results[0].ID)
+	}
+	if _, ok := results[0].MetaData["score"]; !ok {
+		t.Errorf("expected score in metadata, got %v", results[0].MetaData)
+	}
+
+	// TopK can be overridden per call
+	results, err = ret.Retrieve(ctx, "o", retriever.WithTopK(2))
+	if err != nil {
+		t.Fatalf("failed to retrieve: %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("expected 2 results with WithTopK(2), got %d", len(results))
+	}
+}
+
+func TestNewRetriever_Defaults(t *testing.T) {
+	ctx := context.Background()
+
+	if _, err := NewRetriever(ctx, &RetrieverConfig{}); err == nil {
+		t.Error("expected error when LightRAG is not provided")
+	}
+
+	config := &RetrieverConfig{LightRAG: &LightRAG{}}
+	ret, err := NewRetriever(ctx, config)
+	if err != nil {
+		t.Fatalf("failed to create retriever: %v", err)
+	}
+	if config.TopK != 5 {
+		t.Errorf("expected default TopK 5, got %d", config.TopK)
+	}
+	if config.Mode != ModeHybrid {
+		t.Errorf("expected default mode %s, got %s", ModeHybrid, config.Mode)
+	}
+	if ret.GetType() != "LightRAG" {
+		t.Errorf("unexpected type: %s", ret.GetType())
+	}
+}
+
+func TestRetriever_Callbacks(t *testing.T) {
+	rag := newTestLightRAG(t, "./rag_storage_test_retriever_callbacks")
+	if _, err := rag.InsertBatch(context.Background(), []map[string]any{
+		{"id": "1", "content": "Eino graph"},
+	}); err != nil {
+		t.Fatalf("failed to insert batch: %v", err)
+	}
+
+	var input *retriever.CallbackInput
+	var output *retriever.CallbackOutput
+	var cbErr error
+	handler := callbacks.NewHandlerBuilder().
+		OnStartFn(func(ctx context.Context, info *callbacks.RunInfo, in callbacks.CallbackInput) context.Context {
+			input = retriever.ConvCallbackInput(in)
+			return ctx
+		}).
+		OnEndFn(func(ctx context.Context, info *callbacks.RunInfo, out callbacks.CallbackOutput) context.Context {
+			output = retriever.ConvCallbackOutput(out)
+			return ctx
+		}).
+		OnErrorFn(func(ctx context.Context, info *callbacks.RunInfo, err error) context.Context {
+			cbErr = err
+			return ctx
+		}).
+		Build()
+	ctx := callbacks.InitCallbacks(context.Background(), &callbacks.RunInfo{}, handler)
+
+	ret, err := NewRetriever(ctx, &RetrieverConfig{
+		LightRAG:     rag,
+		Mode:         ModeFulltext,
+		TokenCounter: func(text string) int { return len(text) },
+	})
+	if err != nil {
+		t.Fatalf("failed to create retriever: %v", err)
+	}
+	if _, err := ret.Retrieve(ctx, "Eino"); err != nil {
+		t.Fatalf("failed to retrieve: %v", err)
+	}
+
+	if input == nil || output == nil {
+		t.Fatalf("expected start and end callbacks, got input=%v output=%v", input, output)
+	}
+	if input.Extra[CallbackExtraMode] != ModeFulltext || input.Extra[CallbackExtraQueryTokens] != 4 {
+		t.Errorf("unexpected input extra: %v", input.Extra)
+	}
+	if output.Extra[CallbackExtraDocCount] != 1 || output.Extra[CallbackExtraTokenCount] != 10 {
+		t.Errorf("unexpected output extra: %v", output.Extra)
+	}
+
+	// 未知模式触发 OnError
+	ret.config.Mode = "unknown"
+	if _, err := ret.Retrieve(ctx, "Eino"); err == nil {
+		t.Fatal("expected error for unknown mode")
+	}
+	if cbErr == nil {
+		t.Error("expected OnError callback")
+	}
+}
@@ -14,121 +14,25 @@
  * limitations under the License.
  */
 
+// Package lightrag exposes the LightRAG retriever under the retriever tree.
+// The implementation lives in indexer/lightrag next to the Indexer so that
+// both components can be built from the same package; the names here are
+// kept as aliases for existing callers.
 package lightrag
 
 import (
 	"context"
-	"fmt"
 
-	"github.com/cloudwego/eino/callbacks"
-	"github.com/cloudwego/eino/components"
-	"github.com/cloudwego/eino/components/document"
-	"github.com/cloudwego/eino/components/retriever"
-	"github.com/cloudwego/eino/schema"
 	"github.com/mozhou-tech/sqlite-ai-driver/pkg/eino-ext/indexer/lightrag"
 )
 
 // RetrieverConfig defines the configuration for the LightRAG retriever.
-type RetrieverConfig struct {
-	// LightRAG is the LightRAG instance to use for retrieval.
-	LightRAG *lightrag.LightRAG
-	// TopK limits the number of results returned, default 5.
-	TopK int
-	// Mode is the retrieval mode, default ModeHybrid.
-	Mode lightrag.QueryMode
-	// Transformer optionally transforms documents after retrieval (e.g. splitting).
-	Transformer document.Transformer
-}
+type RetrieverConfig = lightrag.RetrieverConfig
 
 // Retriever implements the Eino retriever.Retriever interface for LightRAG.
-type Retriever struct {
-	config *RetrieverConfig
-}
+type Retriever = lightrag.Retriever
 
 // NewRetriever creates a new LightRAG retriever.
 func NewRetriever(ctx context.Context, config *RetrieverConfig) (*Retriever, error) {
-	if config.LightRAG == nil {
-		return nil, fmt.Errorf("[NewRetriever] lightrag instance not provided")
-	}
-
-	if config.TopK <= 0 {
-		config.TopK = 5
-	}
-
-	if config.Mode == "" {
-		config.Mode = lightrag.QueryMode(lightrag.ModeHybrid)
-	}
-
-	return &Retriever{
-		config: config,
-	}, nil
-}
-
-// Retrieve retrieves relevant documents from LightRAG.
-func (r *Retriever) Retrieve(ctx context.Context, query string, opts ...retriever.Option) (docs []*schema.Document, err error) {
-	co := retriever.GetCommonOptions(&retriever.Options{
-		TopK: &r.config.TopK,
-	}, opts...)
-
-	ctx = callbacks.EnsureRunInfo(ctx, r.GetType(), components.ComponentOfRetriever)
-	ctx = callbacks.OnStart(ctx, &retriever.CallbackInput{
-		Query:          query,
-		TopK:           *co.TopK,
-		ScoreThreshold: co.ScoreThreshold,
-	})
-	defer func() {
-		if err != nil {
-			callbacks.OnError(ctx, err)
-		}
-	}()
-
-	param := lightrag.QueryParam{
-		Mode:  lightrag.QueryMode(r.config.Mode),
-		Limit: *co.TopK,
-	}
-	if co.ScoreThreshold != nil {
-		param.Threshold = *co.ScoreThreshold
-	}
-
-	results, err := r.config.LightRAG.Retrieve(ctx, query, param)
-	if err != nil {
-		return nil, fmt.Errorf("[Retrieve] lightrag retrieval failed: %w", err)
-	}
-
-	docs = make([]*schema.Document, 0, len(results))
-	for _, res := range results {
-		doc := &schema.Document{
-			ID:      res.ID,
-			Content: res.Content,
-		}
-		if res.Metadata != nil {
-			doc.MetaData = res.Metadata
-		} else {
-			doc.MetaData = make(map[string]any)
-		}
-		// Add score to metadata
-		doc.MetaData["score"] = res.Score
-		docs = append(docs, doc)
-	}
-
-	if r.config.Transformer != nil {
-		docs, err = r.config.Transformer.Transform(ctx, docs)
-		if err != nil {
-			return nil, fmt.Errorf("[Retrieve] failed to transform documents: %w", err)
-		}
-	}
-
-	callbacks.OnEnd(ctx, &retriever.CallbackOutput{Docs: docs})
-
-	return docs, nil
-}
-
-// GetType returns the component type.
-func (r *Retriever) GetType() string {
-	return "LightRAG"
-}
-
-// IsCallbacksEnabled returns true as this component supports callbacks.
-func (r *Retriever) IsCallbacksEnabled() bool {
-	return true
+	return lightrag.NewRetriever(ctx, config)
 }
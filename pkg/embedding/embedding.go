@@ -0,0 +1,149 @@
+// Package embedding 提供可切换的文本向量化服务（DashScope、OpenAI 兼容服务、Ollama 和自定义 HTTP 服务），
+// 供 browser API 和 chatbot 后端共用
+package embedding
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ProviderDashScope = "dashscope"
+	ProviderOpenAI    = "openai"
+	ProviderOllama    = "ollama"
+	ProviderCustom    = "custom"
+
+	// DefaultTimeout 单次向量化请求的默认超时时间
+	DefaultTimeout = 30 * time.Second
+)
+
+// Provider 文本向量化服务
+type Provider interface {
+	// Embed 为每段文本生成一个向量，返回顺序与输入一致
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// Config 向量化服务配置
+type Config struct {
+	// Provider 服务类型：dashscope、openai、ollama 或 custom
+	Provider string
+	// APIKey 访问服务的密钥，ollama 不需要；custom 为空时不发送 Authorization 请求头
+	APIKey string
+	// BaseURL 服务地址：openai 为 API 根路径（如 https://api.openai.com/v1），
+	// ollama 为服务地址（如 http://localhost:11434），custom 为完整的请求 URL
+	BaseURL string
+	// Model 模型名称，为空时使用各服务的默认模型
+	Model string
+	// Dimension 向量维度，大于 0 时随请求传给服务端（ollama 和 custom 不支持时会忽略）
+	Dimension int
+	// Timeout 单次请求的超时时间，默认 DefaultTimeout
+	Timeout time.Duration
+}
+
+// 各服务的默认模型
+var defaultModels = map[string]string{
+	ProviderDashScope: "text-embedding-v4",
+	ProviderOpenAI:    "text-embedding-3-small",
+	ProviderOllama:    "bge-m3",
+}
+
+// New 根据配置创建向量化服务
+func New(cfg Config) (Provider, error) {
+	cfg.Provider = strings.ToLower(strings.TrimSpace(cfg.Provider))
+	if cfg.Model == "" {
+		cfg.Model = defaultModels[cfg.Provider]
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	client := &httpClient{apiKey: cfg.APIKey, timeout: cfg.Timeout}
+
+	switch cfg.Provider {
+	case ProviderDashScope:
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("dashscope embedding requires an API key")
+		}
+		return &dashScopeProvider{client: client, model: cfg.Model, dimension: cfg.Dimension}, nil
+	case ProviderOpenAI:
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("openai embedding requires an API key")
+		}
+		baseURL := cfg.BaseURL
+		if baseURL == "" {
+			baseURL = "https://api.openai.com/v1"
+		}
+		return &openAIProvider{client: client, baseURL: strings.TrimRight(baseURL, "/"), model: cfg.Model, dimension: cfg.Dimension}, nil
+	case ProviderOllama:
+		baseURL := cfg.BaseURL
+		if baseURL == "" {
+			baseURL = "http://localhost:11434"
+		}
+		return &ollamaProvider{client: client, baseURL: strings.TrimRight(baseURL, "/"), model: cfg.Model, dimension: cfg.Dimension}, nil
+	case ProviderCustom:
+		if cfg.BaseURL == "" {
+			return nil, fmt.Errorf("custom embedding requires a URL")
+		}
+		return &customProvider{client: client, url: cfg.BaseURL, model: cfg.Model, dimension: cfg.Dimension}, nil
+	default:
+		return nil, fmt.Errorf("unsupported embedding provider: %q (expected dashscope, openai, ollama or custom)", cfg.Provider)
+	}
+}
+
+// ConfigFromEnv 从环境变量读取配置，defaultProvider 为 EMBEDDING_PROVIDER 未设置时使用的服务
+//
+//   - EMBEDDING_PROVIDER：dashscope、openai、ollama 或 custom
+//   - EMBEDDING_MODEL、EMBEDDING_DIMENSION：模型和维度
+//   - EMBEDDING_API_KEY、EMBEDDING_BASE_URL：通用的密钥和地址，优先于下面各服务的变量
+//   - DASHSCOPE_API_KEY；OPENAI_API_KEY、OPENAI_BASE_URL；OLLAMA_BASE_URL（或 OLLAMA_HOST）
+func ConfigFromEnv(defaultProvider string) Config {
+	cfg := Config{
+		Provider: os.Getenv("EMBEDDING_PROVIDER"),
+		APIKey:   os.Getenv("EMBEDDING_API_KEY"),
+		BaseURL:  os.Getenv("EMBEDDING_BASE_URL"),
+		Model:    os.Getenv("EMBEDDING_MODEL"),
+	}
+	if cfg.Provider == "" {
+		cfg.Provider = defaultProvider
+	}
+	cfg.Provider = strings.ToLower(strings.TrimSpace(cfg.Provider))
+	if dim, err := strconv.Atoi(os.Getenv("EMBEDDING_DIMENSION")); err == nil && dim > 0 {
+		cfg.Dimension = dim
+	}
+
+	switch cfg.Provider {
+	case ProviderDashScope:
+		cfg.APIKey = firstNonEmpty(cfg.APIKey, os.Getenv("DASHSCOPE_API_KEY"))
+	case ProviderOpenAI:
+		cfg.APIKey = firstNonEmpty(cfg.APIKey, os.Getenv("OPENAI_API_KEY"))
+		cfg.BaseURL = firstNonEmpty(cfg.BaseURL, os.Getenv("OPENAI_BASE_URL"))
+	case ProviderOllama:
+		cfg.BaseURL = firstNonEmpty(cfg.BaseURL, os.Getenv("OLLAMA_BASE_URL"), ollamaHostURL(os.Getenv("OLLAMA_HOST")))
+	}
+	return cfg
+}
+
+// NewFromEnv 按环境变量创建向量化服务
+func NewFromEnv(defaultProvider string) (Provider, error) {
+	return New(ConfigFromEnv(defaultProvider))
+}
+
+// ollamaHostURL 将 OLLAMA_HOST（如 127.0.0.1:11434）补全为 URL
+func ollamaHostURL(host string) string {
+	if host == "" || strings.Contains(host, "://") {
+		return host
+	}
+	return "http://" + host
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,181 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// newTestServer 启动一个记录请求并返回固定响应的服务
+func newTestServer(t *testing.T, response string, got *map[string]interface{}, path *string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*path = r.URL.Path
+		(*got)["authorization"] = r.Header.Get("Authorization")
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		for k, v := range body {
+			(*got)[k] = v
+		}
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestProviders(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		response string
+		path     string
+		check    func(t *testing.T, got map[string]interface{})
+	}{
+		{
+			name:     "openai",
+			cfg:      Config{Provider: "OpenAI", APIKey: "sk", Dimension: 2},
+			response: `{"data": [{"index": 1, "embedding": [3, 4]}, {"index": 0, "embedding": [1, 2]}]}`,
+			path:     "/v1/embeddings",
+			check: func(t *testing.T, got map[string]interface{}) {
+				if got["model"] != "text-embedding-3-small" || got["dimensions"] != float64(2) || got["authorization"] != "Bearer sk" {
+					t.Errorf("unexpected request: %v", got)
+				}
+			},
+		},
+		{
+			name:     "ollama",
+			cfg:      Config{Provider: ProviderOllama, Model: "nomic-embed-text"},
+			response: `{"embeddings": [[1, 2], [3, 4]]}`,
+			path:     "/api/embed",
+			check: func(t *testing.T, got map[string]interface{}) {
+				if got["model"] != "nomic-embed-text" || got["authorization"] != "" {
+					t.Errorf("unexpected request: %v", got)
+				}
+			},
+		},
+		{
+			name:     "custom",
+			cfg:      Config{Provider: ProviderCustom, APIKey: "k"},
+			response: `{"embeddings": [[1, 2], [3, 4]]}`,
+			path:     "/embed",
+			check: func(t *testing.T, got map[string]interface{}) {
+				if !reflect.DeepEqual(got["texts"], []interface{}{"a", "b"}) || got["authorization"] != "Bearer k" {
+					t.Errorf("unexpected request: %v", got)
+				}
+			},
+		},
+		{
+			name:     "dashscope",
+			cfg:      Config{Provider: ProviderDashScope, APIKey: "ds", Dimension: 2},
+			response: `{"output": {"embeddings": [{"text_index": 0, "embedding": [1, 2]}, {"text_index": 1, "embedding": [3, 4]}]}}`,
+			path:     "/",
+			check: func(t *testing.T, got map[string]interface{}) {
+				if got["model"] != "text-embedding-v4" || got["parameters"] == nil {
+					t.Errorf("unexpected request: %v", got)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]interface{}{}
+			var path string
+			server := newTestServer(t, tt.response, &got, &path)
+
+			cfg := tt.cfg
+			switch tt.name {
+			case "openai":
+				cfg.BaseURL = server.URL + "/v1/"
+			case "custom":
+				cfg.BaseURL = server.URL + "/embed"
+			case "dashscope":
+				old := dashScopeURL
+				dashScopeURL = server.URL + "/"
+				defer func() { dashScopeURL = old }()
+			default:
+				cfg.BaseURL = server.URL
+			}
+
+			p, err := New(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			vectors, err := p.Embed(context.Background(), []string{"a", "b"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := [][]float64{{1, 2}, {3, 4}}; !reflect.DeepEqual(vectors, want) {
+				t.Errorf("vectors = %v, want %v", vectors, want)
+			}
+			if path != tt.path {
+				t.Errorf("path = %s, want %s", path, tt.path)
+			}
+			tt.check(t, got)
+		})
+	}
+}
+
+func TestProviderErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/short" {
+			w.Write([]byte(`{"embeddings": [[1]]}`))
+			return
+		}
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	p, _ := New(Config{Provider: ProviderCustom, BaseURL: server.URL + "/fail"})
+	if _, err := p.Embed(context.Background(), []string{"a"}); err == nil {
+		t.Error("expected error for non-200 response")
+	}
+	p, _ = New(Config{Provider: ProviderCustom, BaseURL: server.URL + "/short"})
+	if _, err := p.Embed(context.Background(), []string{"a", "b"}); err == nil {
+		t.Error("expected error for missing embeddings")
+	}
+
+	for _, cfg := range []Config{
+		{Provider: ProviderDashScope},
+		{Provider: ProviderOpenAI},
+		{Provider: ProviderCustom},
+		{Provider: "unknown"},
+	} {
+		if _, err := New(cfg); err == nil {
+			t.Errorf("New(%+v) should fail", cfg)
+		}
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("EMBEDDING_PROVIDER", "")
+	t.Setenv("DASHSCOPE_API_KEY", "ds")
+	t.Setenv("EMBEDDING_DIMENSION", "512")
+	cfg := ConfigFromEnv(ProviderDashScope)
+	if cfg.Provider != ProviderDashScope || cfg.APIKey != "ds" || cfg.Dimension != 512 {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+
+	t.Setenv("EMBEDDING_PROVIDER", "openai")
+	t.Setenv("OPENAI_API_KEY", "sk")
+	t.Setenv("OPENAI_BASE_URL", "http://proxy/v1")
+	cfg = ConfigFromEnv(ProviderDashScope)
+	if cfg.Provider != ProviderOpenAI || cfg.APIKey != "sk" || cfg.BaseURL != "http://proxy/v1" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+
+	// 通用变量优先于各服务的变量
+	t.Setenv("EMBEDDING_API_KEY", "generic")
+	if cfg = ConfigFromEnv(""); cfg.APIKey != "generic" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+
+	t.Setenv("EMBEDDING_PROVIDER", "ollama")
+	t.Setenv("OLLAMA_HOST", "127.0.0.1:11434")
+	if cfg = ConfigFromEnv(""); cfg.BaseURL != "http://127.0.0.1:11434" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+}
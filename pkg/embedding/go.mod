@@ -0,0 +1,3 @@
+module github.com/mozhou-tech/sqlite-ai-driver/pkg/embedding
+
+go 1.24.2
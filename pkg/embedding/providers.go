@@ -0,0 +1,227 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// httpClient 发送 JSON 请求的公共逻辑
+type httpClient struct {
+	apiKey  string
+	timeout time.Duration
+}
+
+// post 发送 JSON 请求并解析响应，apiKey 非空时通过 Bearer 认证
+func (c *httpClient) post(ctx context.Context, url string, reqBody, respBody interface{}) error {
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	client := &http.Client{Timeout: c.timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	if err := json.Unmarshal(body, respBody); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// dashScopeProvider 调用 DashScope 文本向量 API
+type dashScopeProvider struct {
+	client    *httpClient
+	model     string
+	dimension int
+}
+
+type dashScopeRequest struct {
+	Model string `json:"model"`
+	Input struct {
+		Texts []string `json:"texts"`
+	} `json:"input"`
+	Parameters *dashScopeParameters `json:"parameters,omitempty"`
+}
+
+type dashScopeParameters struct {
+	Dimension int `json:"dimension,omitempty"`
+}
+
+type dashScopeResponse struct {
+	Output struct {
+		Embeddings []struct {
+			TextIndex int       `json:"text_index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"embeddings"`
+	} `json:"output"`
+}
+
+// dashScopeURL DashScope 文本向量 API 地址（测试中可替换）
+var dashScopeURL = "https://dashscope.aliyuncs.com/api/v1/services/embeddings/text-embedding/text-embedding"
+
+// Embed 实现 Provider
+func (p *dashScopeProvider) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	reqBody := dashScopeRequest{Model: p.model}
+	reqBody.Input.Texts = texts
+	if p.dimension > 0 {
+		reqBody.Parameters = &dashScopeParameters{Dimension: p.dimension}
+	}
+
+	var apiResp dashScopeResponse
+	if err := p.client.post(ctx, dashScopeURL, reqBody, &apiResp); err != nil {
+		return nil, err
+	}
+	if len(apiResp.Output.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(apiResp.Output.Embeddings))
+	}
+
+	result := make([][]float64, len(texts))
+	for _, item := range apiResp.Output.Embeddings {
+		if item.TextIndex < 0 || item.TextIndex >= len(texts) {
+			return nil, fmt.Errorf("embedding text_index %d out of range", item.TextIndex)
+		}
+		result[item.TextIndex] = float32sToFloat64s(item.Embedding)
+	}
+	return result, nil
+}
+
+// openAIProvider 调用 OpenAI 兼容的 /embeddings API
+type openAIProvider struct {
+	client    *httpClient
+	baseURL   string
+	model     string
+	dimension int
+}
+
+type openAIRequest struct {
+	Model      string   `json:"model"`
+	Input      []string `json:"input"`
+	Dimensions int      `json:"dimensions,omitempty"`
+}
+
+type openAIResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embed 实现 Provider
+func (p *openAIProvider) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	reqBody := openAIRequest{Model: p.model, Input: texts, Dimensions: p.dimension}
+
+	var apiResp openAIResponse
+	if err := p.client.post(ctx, p.baseURL+"/embeddings", reqBody, &apiResp); err != nil {
+		return nil, err
+	}
+	if len(apiResp.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(apiResp.Data))
+	}
+
+	result := make([][]float64, len(texts))
+	for _, item := range apiResp.Data {
+		if item.Index < 0 || item.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", item.Index)
+		}
+		result[item.Index] = float32sToFloat64s(item.Embedding)
+	}
+	return result, nil
+}
+
+// ollamaProvider 调用 Ollama 的 /api/embed API
+type ollamaProvider struct {
+	client    *httpClient
+	baseURL   string
+	model     string
+	dimension int
+}
+
+type ollamaRequest struct {
+	Model      string   `json:"model"`
+	Input      []string `json:"input"`
+	Dimensions int      `json:"dimensions,omitempty"`
+}
+
+type ollamaResponse struct {
+	Embeddings [][]float64 `json:"embeddings"`
+}
+
+// Embed 实现 Provider
+func (p *ollamaProvider) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	reqBody := ollamaRequest{Model: p.model, Input: texts, Dimensions: p.dimension}
+
+	var apiResp ollamaResponse
+	if err := p.client.post(ctx, p.baseURL+"/api/embed", reqBody, &apiResp); err != nil {
+		return nil, err
+	}
+	if len(apiResp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(apiResp.Embeddings))
+	}
+	return apiResp.Embeddings, nil
+}
+
+// customProvider 调用自定义 HTTP 服务：
+// 请求 {"texts": [...], "model": "...", "dimension": N}，响应 {"embeddings": [[...], ...]}，顺序与 texts 一致
+type customProvider struct {
+	client    *httpClient
+	url       string
+	model     string
+	dimension int
+}
+
+type customRequest struct {
+	Texts     []string `json:"texts"`
+	Model     string   `json:"model,omitempty"`
+	Dimension int      `json:"dimension,omitempty"`
+}
+
+type customResponse struct {
+	Embeddings [][]float64 `json:"embeddings"`
+}
+
+// Embed 实现 Provider
+func (p *customProvider) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	reqBody := customRequest{Texts: texts, Model: p.model, Dimension: p.dimension}
+
+	var apiResp customResponse
+	if err := p.client.post(ctx, p.url, reqBody, &apiResp); err != nil {
+		return nil, err
+	}
+	if len(apiResp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(apiResp.Embeddings))
+	}
+	return apiResp.Embeddings, nil
+}
+
+// float32sToFloat64s 转换向量精度
+func float32sToFloat64s(v []float32) []float64 {
+	result := make([]float64, len(v))
+	for i, f := range v {
+		result[i] = float64(f)
+	}
+	return result
+}
@@ -0,0 +1,147 @@
+package lightrag
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ACLLabelsField 文档的访问标签字段，导入时设置（字符串或字符串数组），如 "user:alice"、"group:eng"、"tenant:acme"
+// 检索时 QueryParam.AllowedLabels 不为 nil 则只返回标签与其有交集的文档；没有标签的文档对所有查询可见
+const ACLLabelsField = "acl_labels"
+
+// normalizeACLLabels 把导入时的标签转换为去重的字符串数组，空标签会被去掉
+func normalizeACLLabels(value any) ([]string, error) {
+	var raw []string
+	switch v := value.(type) {
+	case nil:
+	case string:
+		raw = strings.Split(v, ",")
+	case []string:
+		raw = v
+	case []any:
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must contain strings, got %T", ACLLabelsField, item)
+			}
+			raw = append(raw, s)
+		}
+	default:
+		return nil, fmt.Errorf("%s must be a string or an array of strings, got %T", ACLLabelsField, value)
+	}
+
+	seen := make(map[string]bool, len(raw))
+	labels := make([]string, 0, len(raw))
+	for _, label := range raw {
+		label = strings.TrimSpace(label)
+		if label != "" && !seen[label] {
+			seen[label] = true
+			labels = append(labels, label)
+		}
+	}
+	return labels, nil
+}
+
+// normalizeDocumentACL 规范化文档的访问标签，没有有效标签时去掉该字段（文档对所有查询可见）
+func normalizeDocumentACL(doc map[string]any) error {
+	value, ok := doc[ACLLabelsField]
+	if !ok {
+		return nil
+	}
+	labels, err := normalizeACLLabels(value)
+	if err != nil {
+		return err
+	}
+	if len(labels) == 0 {
+		delete(doc, ACLLabelsField)
+		return nil
+	}
+	doc[ACLLabelsField] = labels
+	return nil
+}
+
+// withAllowedLabels 把允许的标签合并到过滤器中（$acl 操作符），labels 为 nil 时原样返回；
+// 过滤器中已有的 acl_labels 条件会被替换，调用方无法绕过访问控制
+func withAllowedLabels(filters map[string]any, labels []string) map[string]any {
+	if labels == nil {
+		return filters
+	}
+	values := make([]any, len(labels))
+	for i, label := range labels {
+		values[i] = label
+	}
+	merged := make(map[string]any, len(filters)+1)
+	for k, v := range filters {
+		merged[k] = v
+	}
+	merged[ACLLabelsField] = map[string]any{"$acl": values}
+	return merged
+}
+
+// matchesACL 判断文档的标签是否满足 $acl 条件：没有标签，或至少有一个标签在 allowed 中
+func matchesACL(docLabels any, allowed []any) bool {
+	labels, err := normalizeACLLabels(docLabels)
+	if err != nil {
+		return false
+	}
+	if len(labels) == 0 {
+		return true
+	}
+	for _, label := range labels {
+		for _, a := range allowed {
+			if a == label {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// visibleTriples 只保留两端实体同时出现在某个满足过滤条件的文档中的三元组，
+// 避免把调用方无权查看的文档中提取的关系和描述带入上下文；filters 为空时原样返回
+func (r *LightRAG) visibleTriples(ctx context.Context, triples []Relationship, filters map[string]any) []Relationship {
+	if len(filters) == 0 || len(triples) == 0 {
+		return triples
+	}
+	if r.docs == nil || r.graph == nil {
+		return nil
+	}
+	visibleDocs := make(map[string]bool)    // 文档 ID -> 是否满足过滤条件
+	entityDocs := make(map[string][]string) // 实体 -> 满足过滤条件的文档
+	docsOf := func(entity string) []string {
+		if ids, ok := entityDocs[entity]; ok {
+			return ids
+		}
+		neighbors, _ := r.graph.GetNeighbors(ctx, entity, "APPEARS_IN")
+		var ids []string
+		for _, id := range neighbors {
+			visible, ok := visibleDocs[id]
+			if !ok {
+				doc, err := r.docs.FindByID(ctx, id)
+				visible = err == nil && doc != nil && matchesFilters(doc.Data(), filters)
+				visibleDocs[id] = visible
+			}
+			if visible {
+				ids = append(ids, id)
+			}
+		}
+		entityDocs[entity] = ids
+		return ids
+	}
+
+	kept := make([]Relationship, 0, len(triples))
+	for _, triple := range triples {
+		targetDocs := make(map[string]bool)
+		for _, id := range docsOf(triple.Target) {
+			targetDocs[id] = true
+		}
+		for _, id := range docsOf(triple.Source) {
+			if targetDocs[id] {
+				kept = append(kept, triple)
+				break
+			}
+		}
+	}
+	return kept
+}
@@ -0,0 +1,207 @@
+package lightrag
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"unicode"
+
+	duckdb_driver "github.com/mozhou-tech/sqlite-ai-driver/pkg/duckdb-driver"
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/sego"
+)
+
+// Analyzer 全文检索的分词器，决定文档 content_tokens 的内容以及查询的分词方式
+type Analyzer string
+
+const (
+	// AnalyzerSego sego 中文分词（默认），适合中文或中英混合的语料
+	AnalyzerSego Analyzer = "sego"
+	// AnalyzerSimple 按空白和标点切分，转小写、去掉停用词并做轻量的英文词干提取（复数、-ing、-ed），适合纯英文语料
+	AnalyzerSimple Analyzer = "simple"
+	// AnalyzerICU 按 Unicode 文字类别切分：字母和数字连续的部分成词，中日韩文字按相邻两个字切分（bigram），
+	// 不依赖词典，适合多语言语料
+	AnalyzerICU Analyzer = "icu"
+)
+
+// collectionSettingsTable 记录每个集合的设置，目前只有全文分词器
+const collectionSettingsTable = "lightrag_collection_settings"
+
+// parseAnalyzer 检查分词器名称，为空时返回空值（表示沿用集合已保存的分词器）
+func parseAnalyzer(name string) (Analyzer, error) {
+	switch a := Analyzer(strings.ToLower(strings.TrimSpace(name))); a {
+	case "", AnalyzerSego, AnalyzerSimple, AnalyzerICU:
+		return a, nil
+	}
+	return "", fmt.Errorf("unsupported analyzer %q, expected sego, simple or icu", name)
+}
+
+// tokenize 按分词器切分文本，返回用空格分隔的词
+func (a Analyzer) tokenize(text string) string {
+	switch a {
+	case AnalyzerSimple:
+		return simpleTokenize(text)
+	case AnalyzerICU:
+		return icuTokenize(text)
+	}
+	return duckdb_driver.TokenizeWithSego(text)
+}
+
+func simpleTokenize(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := make([]string, 0, len(words))
+	for _, w := range words {
+		if sego.IsStopword(w) {
+			continue
+		}
+		tokens = append(tokens, stemEnglish(w))
+	}
+	return strings.Join(tokens, " ")
+}
+
+// stemEnglish 轻量的英文词干提取：去掉复数和 -ing、-ed 后缀，只处理 ASCII 单词
+// 不追求语言学上的准确，只要求同一个词的不同形式得到相同的结果
+func stemEnglish(w string) string {
+	if len(w) <= 3 {
+		return w
+	}
+	for _, r := range w {
+		if r < 'a' || r > 'z' {
+			return w
+		}
+	}
+	switch {
+	case strings.HasSuffix(w, "sses"):
+		return w[:len(w)-2]
+	case strings.HasSuffix(w, "ies") && len(w) > 4:
+		return w[:len(w)-3] + "y"
+	case strings.HasSuffix(w, "xes") || strings.HasSuffix(w, "ches") || strings.HasSuffix(w, "shes"):
+		return w[:len(w)-2]
+	case strings.HasSuffix(w, "ss") || strings.HasSuffix(w, "us") || strings.HasSuffix(w, "is"):
+		return w
+	case strings.HasSuffix(w, "s"):
+		return w[:len(w)-1]
+	case strings.HasSuffix(w, "ing"):
+		return trimVerbSuffix(w, w[:len(w)-3])
+	case strings.HasSuffix(w, "ed"):
+		return trimVerbSuffix(w, w[:len(w)-2])
+	}
+	return w
+}
+
+// trimVerbSuffix 去掉 -ing、-ed 后的词干至少三个字母且含有元音时才使用，并还原双写的辅音（running -> run）
+func trimVerbSuffix(w, stem string) string {
+	if len(stem) < 3 || !strings.ContainsAny(stem, "aeiouy") {
+		return w
+	}
+	if n := len(stem); stem[n-1] == stem[n-2] && !strings.ContainsRune("aeioulsz", rune(stem[n-1])) {
+		return stem[:n-1]
+	}
+	return stem
+}
+
+func icuTokenize(text string) string {
+	var tokens []string
+	var word []rune
+	var cjk []rune
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		if len(cjk) == 1 {
+			tokens = append(tokens, string(cjk))
+		}
+		for i := 0; i+1 < len(cjk); i++ {
+			tokens = append(tokens, string(cjk[i:i+2]))
+		}
+		cjk = cjk[:0]
+	}
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r):
+			flushCJK()
+			word = append(word, unicode.ToLower(r))
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return strings.Join(tokens, " ")
+}
+
+// isCJK 中日韩文字，包括片假名的长音符（ー 属于 Common 文字）
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) || r == 'ー'
+}
+
+func ensureCollectionSettingsTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			table_name VARCHAR PRIMARY KEY,
+			analyzer VARCHAR,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`, collectionSettingsTable))
+	if err != nil {
+		return fmt.Errorf("failed to create collection settings table: %w", err)
+	}
+	return nil
+}
+
+// loadAnalyzer 返回集合保存的分词器，没有保存过时为 AnalyzerSego
+func loadAnalyzer(ctx context.Context, db *sql.DB, tableName string) (Analyzer, error) {
+	if err := ensureCollectionSettingsTable(ctx, db); err != nil {
+		return "", err
+	}
+	var analyzer sql.NullString
+	err := db.QueryRowContext(ctx, fmt.Sprintf(`SELECT analyzer FROM %s WHERE table_name = ?`, collectionSettingsTable), tableName).Scan(&analyzer)
+	if err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("failed to load analyzer: %w", err)
+	}
+	if !analyzer.Valid || analyzer.String == "" {
+		return AnalyzerSego, nil
+	}
+	return Analyzer(analyzer.String), nil
+}
+
+// saveAnalyzer 保存集合的分词器
+func saveAnalyzer(ctx context.Context, db *sql.DB, tableName string, analyzer Analyzer) error {
+	if err := ensureCollectionSettingsTable(ctx, db); err != nil {
+		return err
+	}
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (table_name, analyzer) VALUES (?, ?)
+		ON CONFLICT (table_name) DO UPDATE SET analyzer = EXCLUDED.analyzer, updated_at = now()
+	`, collectionSettingsTable), tableName, string(analyzer))
+	if err != nil {
+		return fmt.Errorf("failed to save analyzer: %w", err)
+	}
+	return nil
+}
+
+// getAnalyzer 返回集合当前使用的分词器
+func (c *duckdbCollection) getAnalyzer() Analyzer {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.analyzer == "" {
+		return AnalyzerSego
+	}
+	return c.analyzer
+}
+
+// setAnalyzer 设置集合使用的分词器，保存到集合设置由调用方在重新分词之后完成
+func (c *duckdbCollection) setAnalyzer(analyzer Analyzer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.analyzer = analyzer
+}
@@ -0,0 +1,94 @@
+package lightrag
+
+import (
+	"context"
+	"testing"
+
+	duckdb_driver "github.com/mozhou-tech/sqlite-ai-driver/pkg/duckdb-driver"
+)
+
+func TestAnalyzerTokenize(t *testing.T) {
+	tests := []struct {
+		analyzer Analyzer
+		text     string
+		want     string
+	}{
+		{AnalyzerSimple, "The workers are Indexing the documents", "worker index document"},
+		{AnalyzerSimple, "Running queries, passes and boxes", "run query pass box"},
+		{AnalyzerSimple, "status analysis used", "status analysis used"},
+		{AnalyzerICU, "Café naïve 2024", "café naïve 2024"},
+		{AnalyzerICU, "全文检索 with ICU", "全文 文检 检索 with icu"},
+		{AnalyzerICU, "東京タワー", "東京 京タ タワ ワー"},
+	}
+	for _, tt := range tests {
+		if got := tt.analyzer.tokenize(tt.text); got != tt.want {
+			t.Errorf("%s.tokenize(%q) = %q, want %q", tt.analyzer, tt.text, got, tt.want)
+		}
+	}
+
+	// 默认使用 sego
+	if got, want := Analyzer("").tokenize("全文检索"), duckdb_driver.TokenizeWithSego("全文检索"); got != want {
+		t.Errorf("default analyzer = %q, want sego %q", got, want)
+	}
+
+	if a, err := parseAnalyzer(" ICU "); err != nil || a != AnalyzerICU {
+		t.Errorf("parseAnalyzer(ICU) = %q, %v", a, err)
+	}
+	if _, err := parseAnalyzer("porter"); err == nil {
+		t.Error("expected error for unsupported analyzer")
+	}
+}
+
+func TestCollectionAnalyzer(t *testing.T) {
+	ctx := context.Background()
+	const table = "lightrag_analyzer_test"
+	d := newWorkerTestDatabase(t, table)
+
+	c, err := d.Collection(ctx, table, Schema{PrimaryKey: "id"})
+	if err != nil {
+		t.Fatalf("Collection failed: %v", err)
+	}
+	coll := c.(*duckdbCollection)
+	if a := coll.getAnalyzer(); a != AnalyzerSego {
+		t.Fatalf("expected sego by default, got %q", a)
+	}
+
+	// 保存在集合设置中，重新打开集合时沿用
+	if err := saveAnalyzer(ctx, d.db, table, AnalyzerSimple); err != nil {
+		t.Fatalf("saveAnalyzer failed: %v", err)
+	}
+	c, err = d.Collection(ctx, table, Schema{PrimaryKey: "id"})
+	if err != nil {
+		t.Fatalf("Collection failed: %v", err)
+	}
+	coll = c.(*duckdbCollection)
+	if a := coll.getAnalyzer(); a != AnalyzerSimple {
+		t.Fatalf("expected saved analyzer simple, got %q", a)
+	}
+
+	if _, err := c.Insert(ctx, map[string]any{"id": "doc-1", "content": "The indexes are rebuilt nightly"}); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if _, err := c.BulkUpsert(ctx, []map[string]any{{"id": "doc-2", "content": "Vectors and embeddings for retrieval"}}); err != nil {
+		t.Fatalf("BulkUpsert failed: %v", err)
+	}
+	for id, want := range map[string]string{"doc-1": "index rebuilt nightly", "doc-2": "vector embedding retrieval"} {
+		var tokens string
+		if err := d.db.QueryRowContext(ctx, `SELECT content_tokens FROM `+table+` WHERE id = ?`, id).Scan(&tokens); err != nil {
+			t.Fatalf("failed to read content_tokens: %v", err)
+		}
+		if tokens != want {
+			t.Errorf("content_tokens of %s = %q, want %q", id, tokens, want)
+		}
+	}
+
+	// 查询使用同一个分词器，indexing 与文档中的 indexes 得到相同的词
+	search := &duckdbFulltextSearch{db: d.db, tableName: table, analyzer: coll.getAnalyzer()}
+	results, err := search.FindWithScores(ctx, "indexing", FulltextSearchOptions{Limit: 10})
+	if err != nil {
+		t.Fatalf("FindWithScores failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Document.ID() != "doc-1" {
+		t.Errorf("expected doc-1 for a stemmed query, got %v", results)
+	}
+}
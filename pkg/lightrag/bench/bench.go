@@ -0,0 +1,310 @@
+// Package bench 在带标注的问答数据集上评估 LightRAG 的检索质量
+//
+// 数据集为 JSONL，每行一个问题及其相关分块的 ID：
+//
+//	{"question": "长城是为了防御什么修建的？", "relevant_ids": ["great-wall#0", "great-wall#3"]}
+//
+// Run 按检索模式依次执行每个问题，统计 recall@k 和 MRR；保存的报告可以作为基线，
+// 修改检索逻辑或参数后用 Compare 找出指标下降的模式，而不是凭感觉判断
+package bench
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/lightrag"
+)
+
+// DefaultModes 未指定模式时评估的检索模式
+var DefaultModes = []lightrag.QueryMode{
+	lightrag.ModeHybrid,
+	lightrag.ModeVector,
+	lightrag.ModeFulltext,
+	lightrag.ModeGraph,
+	lightrag.ModeLocal,
+	lightrag.ModeGlobal,
+	lightrag.ModeNaive,
+	lightrag.ModeMix,
+}
+
+// DefaultCutoffs 未指定时统计的 recall@k
+var DefaultCutoffs = []int{1, 3, 5, 10}
+
+// Case 数据集中的一个问题
+type Case struct {
+	Question    string   `json:"question"`
+	RelevantIDs []string `json:"relevant_ids"`
+}
+
+// Retriever 被评估的检索实现，*lightrag.LightRAG 满足该接口
+type Retriever interface {
+	Retrieve(ctx context.Context, query string, param lightrag.QueryParam) ([]lightrag.SearchResult, error)
+}
+
+// Options 评估选项
+type Options struct {
+	// Modes 评估的检索模式，为空时使用 DefaultModes
+	Modes []lightrag.QueryMode
+	// Cutoffs 统计 recall@k 的 k，为空时使用 DefaultCutoffs；检索的结果数为其中的最大值
+	Cutoffs []int
+	// Filters 每次检索使用的元数据过滤器
+	Filters map[string]any
+	// Progress 每完成一个问题调用一次，可以为 nil
+	Progress func(mode lightrag.QueryMode, done, total int)
+}
+
+// ModeReport 一个检索模式的指标，Recall 和 MRR 是所有成功检索的问题的平均值
+type ModeReport struct {
+	Mode   lightrag.QueryMode `json:"mode"`
+	Cases  int                `json:"cases"`
+	Errors int                `json:"errors"`
+	// Recall k -> recall@k：前 k 个结果中相关分块占所有相关分块的比例
+	Recall map[int]float64 `json:"recall"`
+	// MRR 第一个相关结果排名的倒数，前 max(Cutoffs) 个结果中没有相关结果时计为 0
+	MRR float64 `json:"mrr"`
+	// LatencyMs 平均每次检索的耗时（毫秒）
+	LatencyMs float64 `json:"latency_ms"`
+	// FirstError 第一次检索失败的原因，如未配置 embedding 时的向量检索
+	FirstError string `json:"first_error,omitempty"`
+}
+
+// Report 评估报告
+type Report struct {
+	Dataset string       `json:"dataset,omitempty"`
+	Cases   int          `json:"cases"`
+	Cutoffs []int        `json:"cutoffs"`
+	Modes   []ModeReport `json:"modes"`
+}
+
+// LoadDataset 读取 JSONL 数据集
+func LoadDataset(path string) ([]Case, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dataset: %w", err)
+	}
+	defer f.Close()
+	return ReadDataset(f)
+}
+
+// ReadDataset 从 r 读取 JSONL 数据集，跳过空行和以 # 开头的行
+func ReadDataset(r io.Reader) ([]Case, error) {
+	var cases []Case
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		var c Case
+		if err := json.Unmarshal([]byte(text), &c); err != nil {
+			return nil, fmt.Errorf("line %d: invalid case: %w", line, err)
+		}
+		if strings.TrimSpace(c.Question) == "" {
+			return nil, fmt.Errorf("line %d: question is required", line)
+		}
+		if len(c.RelevantIDs) == 0 {
+			return nil, fmt.Errorf("line %d: relevant_ids is required", line)
+		}
+		cases = append(cases, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dataset: %w", err)
+	}
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("dataset is empty")
+	}
+	return cases, nil
+}
+
+// Run 按模式依次检索每个问题并统计指标
+// 某个模式的检索失败（如未配置 embedding）只计入该模式的 Errors，不影响其他模式；ctx 取消时返回错误
+func Run(ctx context.Context, r Retriever, cases []Case, opts Options) (*Report, error) {
+	modes := opts.Modes
+	if len(modes) == 0 {
+		modes = DefaultModes
+	}
+	cutoffs := append([]int(nil), opts.Cutoffs...)
+	if len(cutoffs) == 0 {
+		cutoffs = append(cutoffs, DefaultCutoffs...)
+	}
+	sort.Ints(cutoffs)
+	if cutoffs[0] <= 0 {
+		return nil, fmt.Errorf("cutoffs must be positive, got %d", cutoffs[0])
+	}
+	limit := cutoffs[len(cutoffs)-1]
+
+	report := &Report{Cases: len(cases), Cutoffs: cutoffs}
+	for _, mode := range modes {
+		mr := ModeReport{Mode: mode, Cases: len(cases), Recall: make(map[int]float64, len(cutoffs))}
+		var succeeded int
+		var latency time.Duration
+		for i, c := range cases {
+			start := time.Now()
+			results, err := r.Retrieve(ctx, c.Question, lightrag.QueryParam{Mode: mode, Limit: limit, Filters: opts.Filters})
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+			if err != nil {
+				mr.Errors++
+				if mr.FirstError == "" {
+					mr.FirstError = err.Error()
+				}
+			} else {
+				succeeded++
+				latency += time.Since(start)
+				recall, rr := score(results, c.RelevantIDs, cutoffs)
+				for k, v := range recall {
+					mr.Recall[k] += v
+				}
+				mr.MRR += rr
+			}
+			if opts.Progress != nil {
+				opts.Progress(mode, i+1, len(cases))
+			}
+		}
+		if succeeded > 0 {
+			for k := range mr.Recall {
+				mr.Recall[k] /= float64(succeeded)
+			}
+			mr.MRR /= float64(succeeded)
+			mr.LatencyMs = float64(latency.Microseconds()) / 1000 / float64(succeeded)
+		}
+		report.Modes = append(report.Modes, mr)
+	}
+	return report, nil
+}
+
+// score 计算一个问题的 recall@k 和倒数排名，结果中重复的 ID 只按第一次出现计算
+func score(results []lightrag.SearchResult, relevantIDs []string, cutoffs []int) (map[int]float64, float64) {
+	relevant := make(map[string]bool, len(relevantIDs))
+	for _, id := range relevantIDs {
+		relevant[id] = true
+	}
+
+	recall := make(map[int]float64, len(cutoffs))
+	seen := make(map[string]bool)
+	var hits int
+	var rr float64
+	rank := 0
+	next := 0
+	for _, res := range results {
+		if seen[res.ID] {
+			continue
+		}
+		seen[res.ID] = true
+		rank++
+		if rank > cutoffs[len(cutoffs)-1] {
+			break
+		}
+		for next < len(cutoffs) && cutoffs[next] < rank {
+			recall[cutoffs[next]] = float64(hits) / float64(len(relevant))
+			next++
+		}
+		if relevant[res.ID] {
+			hits++
+			if rr == 0 {
+				rr = 1 / float64(rank)
+			}
+		}
+	}
+	for ; next < len(cutoffs); next++ {
+		recall[cutoffs[next]] = float64(hits) / float64(len(relevant))
+	}
+	return recall, rr
+}
+
+// WriteTable 以表格输出各模式的指标
+func (r *Report) WriteTable(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-10s", "mode")
+	for _, k := range r.Cutoffs {
+		fmt.Fprintf(&b, " %9s", fmt.Sprintf("recall@%d", k))
+	}
+	fmt.Fprintf(&b, " %7s %10s %7s\n", "mrr", "latency", "errors")
+	for _, m := range r.Modes {
+		fmt.Fprintf(&b, "%-10s", m.Mode)
+		for _, k := range r.Cutoffs {
+			fmt.Fprintf(&b, " %9.3f", m.Recall[k])
+		}
+		fmt.Fprintf(&b, " %7.3f %8.1fms %7d\n", m.MRR, m.LatencyMs, m.Errors)
+	}
+	for _, m := range r.Modes {
+		if m.FirstError != "" {
+			fmt.Fprintf(&b, "%s: %d/%d 次检索失败，例如: %s\n", m.Mode, m.Errors, m.Cases, m.FirstError)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Regression 相对基线下降的指标
+type Regression struct {
+	Mode     lightrag.QueryMode `json:"mode"`
+	Metric   string             `json:"metric"` // recall@k 或 mrr
+	Baseline float64            `json:"baseline"`
+	Current  float64            `json:"current"`
+}
+
+func (r Regression) String() string {
+	return fmt.Sprintf("%s %s: %.3f -> %.3f", r.Mode, r.Metric, r.Baseline, r.Current)
+}
+
+// Compare 返回 current 中比 baseline 下降超过 tolerance 的指标，只比较两者都有的模式和 k
+func Compare(baseline, current *Report, tolerance float64) []Regression {
+	base := make(map[lightrag.QueryMode]ModeReport, len(baseline.Modes))
+	for _, m := range baseline.Modes {
+		base[m.Mode] = m
+	}
+	var regressions []Regression
+	for _, cur := range current.Modes {
+		prev, ok := base[cur.Mode]
+		if !ok {
+			continue
+		}
+		for _, k := range current.Cutoffs {
+			before, ok := prev.Recall[k]
+			if ok && cur.Recall[k] < before-tolerance {
+				regressions = append(regressions, Regression{Mode: cur.Mode, Metric: fmt.Sprintf("recall@%d", k), Baseline: before, Current: cur.Recall[k]})
+			}
+		}
+		if cur.MRR < prev.MRR-tolerance {
+			regressions = append(regressions, Regression{Mode: cur.Mode, Metric: "mrr", Baseline: prev.MRR, Current: cur.MRR})
+		}
+	}
+	return regressions
+}
+
+// LoadReport 读取 JSON 格式的报告，用作 Compare 的基线
+func LoadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report: %w", err)
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse report: %w", err)
+	}
+	return &report, nil
+}
+
+// SaveReport 以 JSON 保存报告
+func SaveReport(path string, report *Report) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
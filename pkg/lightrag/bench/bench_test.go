@@ -0,0 +1,130 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/lightrag"
+)
+
+// fakeRetriever 按模式和问题返回固定的结果 ID
+type fakeRetriever map[lightrag.QueryMode]map[string][]string
+
+func (f fakeRetriever) Retrieve(ctx context.Context, query string, param lightrag.QueryParam) ([]lightrag.SearchResult, error) {
+	byQuery, ok := f[param.Mode]
+	if !ok {
+		return nil, errors.New("mode not available")
+	}
+	var results []lightrag.SearchResult
+	for _, id := range byQuery[query] {
+		results = append(results, lightrag.SearchResult{ID: id})
+	}
+	if len(results) > param.Limit {
+		results = results[:param.Limit]
+	}
+	return results, nil
+}
+
+func TestReadDataset(t *testing.T) {
+	cases, err := ReadDataset(strings.NewReader(`
+# 注释
+{"question": "q1", "relevant_ids": ["a"]}
+
+{"question": "q2", "relevant_ids": ["b", "c"]}
+`))
+	if err != nil {
+		t.Fatalf("ReadDataset failed: %v", err)
+	}
+	if len(cases) != 2 || cases[1].Question != "q2" || len(cases[1].RelevantIDs) != 2 {
+		t.Errorf("unexpected cases: %+v", cases)
+	}
+
+	for _, input := range []string{"", `{"question": "q"}`, `{"relevant_ids": ["a"]}`, `not json`} {
+		if _, err := ReadDataset(strings.NewReader(input)); err == nil {
+			t.Errorf("expected error for %q", input)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	cases := []Case{
+		{Question: "q1", RelevantIDs: []string{"a"}},
+		{Question: "q2", RelevantIDs: []string{"b", "c"}},
+	}
+	retriever := fakeRetriever{
+		lightrag.ModeFulltext: {
+			"q1": {"a", "x", "y"},
+			"q2": {"x", "x", "b", "y", "c"}, // 重复的 x 只算一个排名
+		},
+		lightrag.ModeHybrid: {
+			"q1": {"x", "y", "z", "a"},
+			"q2": {},
+		},
+	}
+
+	report, err := Run(context.Background(), retriever, cases, Options{
+		Modes:   []lightrag.QueryMode{lightrag.ModeFulltext, lightrag.ModeHybrid, lightrag.ModeVector},
+		Cutoffs: []int{3, 1},
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(report.Modes) != 3 || report.Cutoffs[0] != 1 || report.Cutoffs[1] != 3 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	approx := func(got, want float64) bool { return math.Abs(got-want) < 1e-9 }
+	fulltext := report.Modes[0]
+	// q1: recall@1 = 1, recall@3 = 1, rr = 1；q2: 排名为 x, b, y, c，recall@1 = 0, recall@3 = 0.5, rr = 1/2
+	if !approx(fulltext.Recall[1], 0.5) || !approx(fulltext.Recall[3], 0.75) || !approx(fulltext.MRR, 0.75) {
+		t.Errorf("unexpected fulltext metrics: %+v", fulltext)
+	}
+	// a 排在第 4 位，超出最大的 k
+	hybrid := report.Modes[1]
+	if hybrid.Recall[3] != 0 || hybrid.MRR != 0 || hybrid.Errors != 0 {
+		t.Errorf("unexpected hybrid metrics: %+v", hybrid)
+	}
+	vector := report.Modes[2]
+	if vector.Errors != 2 || vector.FirstError == "" {
+		t.Errorf("expected vector mode to fail: %+v", vector)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteTable(&buf); err != nil {
+		t.Fatalf("WriteTable failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "recall@3") || !strings.Contains(buf.String(), "mode not available") {
+		t.Errorf("unexpected table:\n%s", buf.String())
+	}
+
+	if _, err := Run(context.Background(), retriever, cases, Options{Cutoffs: []int{0}}); err == nil {
+		t.Error("expected error for non-positive cutoff")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	baseline := &Report{Cutoffs: []int{1, 5}, Modes: []ModeReport{
+		{Mode: lightrag.ModeHybrid, Recall: map[int]float64{1: 0.5, 5: 0.8}, MRR: 0.6},
+		{Mode: lightrag.ModeFulltext, Recall: map[int]float64{1: 0.4, 5: 0.7}, MRR: 0.5},
+	}}
+	current := &Report{Cutoffs: []int{1, 5, 10}, Modes: []ModeReport{
+		{Mode: lightrag.ModeHybrid, Recall: map[int]float64{1: 0.49, 5: 0.7, 10: 0.9}, MRR: 0.6},
+		{Mode: lightrag.ModeFulltext, Recall: map[int]float64{1: 0.5, 5: 0.7}, MRR: 0.4},
+		{Mode: lightrag.ModeVector, Recall: map[int]float64{1: 0}, MRR: 0},
+	}}
+
+	regressions := Compare(baseline, current, 0.02)
+	if len(regressions) != 2 {
+		t.Fatalf("expected 2 regressions, got %v", regressions)
+	}
+	if regressions[0].Mode != lightrag.ModeHybrid || regressions[0].Metric != "recall@5" {
+		t.Errorf("unexpected regression: %v", regressions[0])
+	}
+	if regressions[1].Mode != lightrag.ModeFulltext || regressions[1].Metric != "mrr" {
+		t.Errorf("unexpected regression: %v", regressions[1])
+	}
+}
@@ -0,0 +1,174 @@
+package lightrag
+
+import (
+	"container/list"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QueryCacheConfig 查询结果缓存的配置
+// 缓存 Retrieve 的结果和 Query 的回答，键为规范化的查询（小写、合并空白）、模式和过滤条件；
+// 文档集合或图谱发生任何写入（插入、删除、状态变更、向量生成、图谱提取、同义词变更）后缓存全部失效
+type QueryCacheConfig struct {
+	// MaxEntries 最多缓存的查询数，超出时淘汰最久未使用的，默认为 1000
+	MaxEntries int
+	// TTL 缓存的有效期，0 表示只在写入时失效
+	TTL time.Duration
+}
+
+// QueryCacheStats 查询结果缓存的统计
+type QueryCacheStats struct {
+	Entries int    `json:"entries"`
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+}
+
+// queryCacheEntry 缓存的结果，version 为写入时数据库的写入计数
+type queryCacheEntry struct {
+	key      string
+	version  uint64
+	storedAt time.Time
+	results  []SearchResult
+	answer   string
+}
+
+// queryCache 按最近使用淘汰的查询结果缓存
+type queryCache struct {
+	config QueryCacheConfig
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // 最近使用的在前
+	hits    uint64
+	misses  uint64
+}
+
+func newQueryCache(config *QueryCacheConfig) *queryCache {
+	if config == nil {
+		return nil
+	}
+	c := *config
+	if c.MaxEntries <= 0 {
+		c.MaxEntries = 1000
+	}
+	return &queryCache{
+		config:  c,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// normalizeQuery 规范化查询文本：转小写并合并空白，只有大小写或空白不同的查询共用缓存
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
+
+// queryCacheKey 返回缓存的键，kind 区分 Retrieve 的结果和 Query 的回答
+func queryCacheKey(kind, query string, param QueryParam) string {
+	key, _ := json.Marshal(struct {
+		Kind            string         `json:"kind"`
+		Query           string         `json:"query"`
+		Mode            QueryMode      `json:"mode"`
+		Limit           int            `json:"limit"`
+		Threshold       float64        `json:"threshold"`
+		Filters         map[string]any `json:"filters"`
+		ExpandSynonyms  bool           `json:"expand_synonyms"`
+		AllowedLabels   []string       `json:"allowed_labels"`
+		ExpandNeighbors int            `json:"expand_neighbors"`
+		UseHyDE         bool           `json:"use_hyde"`
+		HyDEFuseQuery   bool           `json:"hyde_fuse_query"`
+	}{kind, normalizeQuery(query), param.Mode, param.Limit, param.Threshold, param.Filters, param.ExpandSynonyms, param.AllowedLabels, param.ExpandNeighbors, param.UseHyDE, param.HyDEFuseQuery})
+	return string(key)
+}
+
+// get 返回未失效的缓存，version 为当前的写入计数
+func (c *queryCache) get(key string, version uint64) (*queryCacheEntry, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if ok {
+		entry := elem.Value.(*queryCacheEntry)
+		if entry.version == version && (c.config.TTL <= 0 || time.Since(entry.storedAt) < c.config.TTL) {
+			c.order.MoveToFront(elem)
+			c.hits++
+			return entry, true
+		}
+		c.order.Remove(elem)
+		delete(c.entries, key)
+	}
+	c.misses++
+	return nil, false
+}
+
+// put 保存结果，version 为执行查询之前的写入计数，查询期间发生写入时结果不会被之后的查询使用
+func (c *queryCache) put(key string, version uint64, results []SearchResult, answer string) {
+	if c == nil {
+		return
+	}
+	entry := &queryCacheEntry{
+		key:      key,
+		version:  version,
+		storedAt: time.Now(),
+		results:  results,
+		answer:   answer,
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.config.MaxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*queryCacheEntry).key)
+	}
+}
+
+func (c *queryCache) clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*list.Element)
+	c.order.Init()
+}
+
+func (c *queryCache) stats() QueryCacheStats {
+	if c == nil {
+		return QueryCacheStats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return QueryCacheStats{Entries: c.order.Len(), Hits: c.hits, Misses: c.misses}
+}
+
+// cacheVersion 返回数据库当前的写入计数，未开启缓存或数据库无法跟踪写入时返回 false
+func (r *LightRAG) cacheVersion() (uint64, bool) {
+	if r.queryCache == nil {
+		return 0, false
+	}
+	d, ok := r.db.(*duckdbDatabase)
+	if !ok {
+		return 0, false
+	}
+	return d.writes.Load(), true
+}
+
+// QueryCacheStats 返回查询结果缓存的统计，未开启缓存时为零值
+func (r *LightRAG) QueryCacheStats() QueryCacheStats {
+	return r.queryCache.stats()
+}
+
+// ClearQueryCache 清空查询结果缓存，如 LLM 或检索配置变化之后
+func (r *LightRAG) ClearQueryCache() {
+	r.queryCache.clear()
+}
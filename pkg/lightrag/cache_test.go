@@ -0,0 +1,112 @@
+package lightrag
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestQueryCache(t *testing.T) {
+	c := newQueryCache(&QueryCacheConfig{MaxEntries: 2})
+
+	// 只有大小写和空白不同的查询共用缓存，模式和过滤条件不同的查询分开缓存
+	key := queryCacheKey("retrieve", "  What is  Go? ", QueryParam{Mode: ModeHybrid, Limit: 5})
+	if key != queryCacheKey("retrieve", "what is go?", QueryParam{Mode: ModeHybrid, Limit: 5}) {
+		t.Error("expected normalized queries to share the cache key")
+	}
+	for _, other := range []string{
+		queryCacheKey("query", "what is go?", QueryParam{Mode: ModeHybrid, Limit: 5}),
+		queryCacheKey("retrieve", "what is go?", QueryParam{Mode: ModeLocal, Limit: 5}),
+		queryCacheKey("retrieve", "what is go?", QueryParam{Mode: ModeHybrid, Limit: 5, Filters: map[string]any{"source": "wiki"}}),
+	} {
+		if other == key {
+			t.Errorf("expected different cache key, got %s", other)
+		}
+	}
+
+	c.put(key, 1, []SearchResult{{ID: "a"}}, "")
+	if entry, ok := c.get(key, 1); !ok || len(entry.results) != 1 || entry.results[0].ID != "a" {
+		t.Fatalf("expected cached results, got %v %v", entry, ok)
+	}
+	// 写入计数变化后失效
+	if _, ok := c.get(key, 2); ok {
+		t.Error("expected entry to be invalidated by a write")
+	}
+	if _, ok := c.get(key, 1); ok {
+		t.Error("expected invalidated entry to be removed")
+	}
+
+	// 超出容量时淘汰最久未使用的
+	c.put("a", 1, nil, "A")
+	c.put("b", 1, nil, "B")
+	c.get("a", 1)
+	c.put("c", 1, nil, "C")
+	if _, ok := c.get("b", 1); ok {
+		t.Error("expected least recently used entry to be evicted")
+	}
+	if entry, ok := c.get("a", 1); !ok || entry.answer != "A" {
+		t.Error("expected recently used entry to be kept")
+	}
+	if stats := c.stats(); stats.Entries != 2 || stats.Hits != 3 || stats.Misses != 3 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	c.clear()
+	if _, ok := c.get("a", 1); ok {
+		t.Error("expected cache to be empty after clear")
+	}
+
+	ttl := newQueryCache(&QueryCacheConfig{TTL: time.Millisecond})
+	ttl.put("a", 1, nil, "A")
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := ttl.get("a", 1); ok {
+		t.Error("expected entry to expire")
+	}
+
+	var disabled *queryCache
+	disabled.put("a", 1, nil, "A")
+	if _, ok := disabled.get("a", 1); ok {
+		t.Error("expected nil cache to miss")
+	}
+}
+
+func TestQueryCacheInvalidatedOnWrite(t *testing.T) {
+	ctx := context.Background()
+	const table = "lightrag_query_cache_test"
+	d := newWorkerTestDatabase(t, table)
+	c, err := d.Collection(ctx, table, Schema{PrimaryKey: "id"})
+	if err != nil {
+		t.Fatalf("Collection failed: %v", err)
+	}
+	r := &LightRAG{db: d, queryCache: newQueryCache(&QueryCacheConfig{})}
+
+	version, ok := r.cacheVersion()
+	if !ok {
+		t.Fatal("expected cache to be enabled")
+	}
+	r.queryCache.put("key", version, nil, "answer")
+
+	if _, err := c.Insert(ctx, map[string]any{"id": "doc1", "content": "Go is a programming language"}); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	next, _ := r.cacheVersion()
+	if next == version {
+		t.Fatal("expected insert to bump the write counter")
+	}
+	if _, ok := r.queryCache.get("key", next); ok {
+		t.Error("expected cached answer to be invalidated by insert")
+	}
+
+	r.queryCache.put("key", next, nil, "answer")
+	if err := c.Delete(ctx, "doc1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	last, _ := r.cacheVersion()
+	if _, ok := r.queryCache.get("key", last); ok {
+		t.Error("expected cached answer to be invalidated by delete")
+	}
+
+	if _, ok := (&LightRAG{db: d}).cacheVersion(); ok {
+		t.Error("expected cache to be disabled without QueryCache")
+	}
+}
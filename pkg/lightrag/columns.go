@@ -0,0 +1,160 @@
+package lightrag
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Column Schema 中声明的类型化列
+// 列创建为真实的 DuckDB 列，插入时从文档的同名字段填充（字段同时保留在 metadata 中），
+// Find、全文和向量搜索的 Selector 以及 ListDocumentsOptions.Filters 中的同名字段直接比较该列
+type Column struct {
+	Name string
+	// Type DuckDB 类型：VARCHAR、INTEGER、BIGINT、DOUBLE、BOOLEAN、DATE、TIMESTAMP（INT、TEXT 等别名会被规范化）
+	Type string
+	// Index 为 true 时为该列创建索引
+	Index bool
+}
+
+// columnTypeNames 支持的列类型，键为大写的类型名或别名
+var columnTypeNames = map[string]string{
+	"VARCHAR":   "VARCHAR",
+	"TEXT":      "VARCHAR",
+	"STRING":    "VARCHAR",
+	"INTEGER":   "INTEGER",
+	"INT":       "INTEGER",
+	"BIGINT":    "BIGINT",
+	"DOUBLE":    "DOUBLE",
+	"FLOAT":     "DOUBLE",
+	"BOOLEAN":   "BOOLEAN",
+	"BOOL":      "BOOLEAN",
+	"DATE":      "DATE",
+	"TIMESTAMP": "TIMESTAMP",
+}
+
+// reservedColumns 集合内置的列，不能声明为类型化列
+var reservedColumns = map[string]bool{
+	"id":               true,
+	"content":          true,
+	"metadata":         true,
+	"created_at":       true,
+	"_rev":             true,
+	"content_tokens":   true,
+	"embedding_status": true,
+	"embedding_error":  true,
+	"chunk_length":     true,
+	"state":            true,
+}
+
+var columnNamePattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// normalizeColumns 检查列名和类型，返回列名到规范化类型的映射
+func normalizeColumns(columns []Column) (map[string]string, error) {
+	types := make(map[string]string, len(columns))
+	for _, col := range columns {
+		if !columnNamePattern.MatchString(col.Name) {
+			return nil, fmt.Errorf("invalid column name %q: use lowercase letters, digits and underscores", col.Name)
+		}
+		if reservedColumns[col.Name] || strings.HasPrefix(col.Name, "vector_") {
+			return nil, fmt.Errorf("column name %q is reserved", col.Name)
+		}
+		if _, ok := types[col.Name]; ok {
+			return nil, fmt.Errorf("duplicate column %q", col.Name)
+		}
+		columnType, ok := columnTypeNames[strings.ToUpper(strings.TrimSpace(col.Type))]
+		if !ok {
+			return nil, fmt.Errorf("unsupported type %q for column %s", col.Type, col.Name)
+		}
+		types[col.Name] = columnType
+	}
+	return types, nil
+}
+
+// ensureColumns 创建 Schema 中声明的类型化列和索引
+// 新增的列从已有文档的 metadata 中回填，无法转换为列类型的值保留为 NULL
+func ensureColumns(ctx context.Context, db *sql.DB, tableName string, columns []Column) error {
+	types, err := normalizeColumns(columns)
+	if err != nil {
+		return err
+	}
+	for _, col := range columns {
+		columnType := types[col.Name]
+
+		var exists int
+		err := db.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM information_schema.columns
+			WHERE table_name = ? AND column_name = ?
+		`, tableName, col.Name).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("failed to check column %s: %w", col.Name, err)
+		}
+		if exists == 0 {
+			alterSQL := fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, tableName, col.Name, columnType)
+			if _, err := db.ExecContext(ctx, alterSQL); err != nil {
+				return fmt.Errorf("failed to add column %s: %w", col.Name, err)
+			}
+			backfillSQL := fmt.Sprintf(`UPDATE %s SET %s = TRY_CAST(json_extract_string(metadata, ?) AS %s)`, tableName, col.Name, columnType)
+			if _, err := db.ExecContext(ctx, backfillSQL, jsonPointer(col.Name)); err != nil {
+				return fmt.Errorf("failed to backfill column %s: %w", col.Name, err)
+			}
+			logrus.WithFields(logrus.Fields{
+				"table":  tableName,
+				"column": col.Name,
+				"type":   columnType,
+			}).Info("Typed column added")
+		}
+
+		if col.Index {
+			indexSQL := fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_%s ON %s (%s)`, tableName, col.Name, tableName, col.Name)
+			if _, err := db.ExecContext(ctx, indexSQL); err != nil {
+				return fmt.Errorf("failed to create index on %s: %w", col.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// columnTypes 返回集合的类型化列（列名 -> 类型），Collection 创建时已检查过
+func (c *duckdbCollection) columnTypes() map[string]string {
+	types, _ := normalizeColumns(c.schema.Columns)
+	return types
+}
+
+// upsertSQL 返回插入或更新文档的 SQL，参数依次为 id、content、metadata、chunk_length 和各类型化列的值
+func (c *duckdbCollection) upsertSQL() string {
+	insertColumns := "id, content, metadata, _rev, embedding_status, chunk_length"
+	values := "?, ?, ?::JSON, 1, 'pending', ?"
+	var updates strings.Builder
+	types := c.columnTypes()
+	for _, col := range c.schema.Columns {
+		insertColumns += ", " + col.Name
+		// 与回填一致使用 TRY_CAST：无法转换的值写入 NULL，原值仍保留在 metadata 中
+		values += fmt.Sprintf(", TRY_CAST(? AS %s)", types[col.Name])
+		fmt.Fprintf(&updates, ",\n\t\t\t%s = EXCLUDED.%s", col.Name, col.Name)
+	}
+	return fmt.Sprintf(`
+		INSERT INTO %s (%s)
+		VALUES (%s)
+		ON CONFLICT (id) DO UPDATE SET
+			content = EXCLUDED.content,
+			metadata = EXCLUDED.metadata,
+			_rev = %s._rev + 1,
+			embedding_status = 'pending',
+			chunk_length = EXCLUDED.chunk_length,
+			state = 'active'%s
+	`, c.tableName, insertColumns, values, c.tableName, updates.String())
+}
+
+// upsertArgs 返回 upsertSQL 的参数，文档中没有的类型化字段为 NULL
+func (c *duckdbCollection) upsertArgs(doc map[string]any, id, content, metadataJSON string, chunkLength int) []any {
+	args := []any{id, content, metadataJSON, chunkLength}
+	for _, col := range c.schema.Columns {
+		args = append(args, doc[col.Name])
+	}
+	return args
+}
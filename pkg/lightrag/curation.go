@@ -0,0 +1,257 @@
+package lightrag
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// graphCurationTable 人工维护知识图谱的记录，自动提取不会覆盖这些修改
+//
+//	kind = locked:  subject 的 predicate（TYPE 或 DESCRIPTION）由人工设置，提取时不再添加，也不参与描述合并
+//	kind = deleted: 人工删除的关系 subject -[predicate]-> object，提取时不再添加
+const graphCurationTable = "lightrag_graph_curation"
+
+const (
+	curationLocked  = "locked"
+	curationDeleted = "deleted"
+)
+
+func ensureGraphCurationTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			kind VARCHAR,
+			subject VARCHAR,
+			predicate VARCHAR,
+			object VARCHAR,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (kind, subject, predicate, object)
+		)
+	`, graphCurationTable))
+	if err != nil {
+		return fmt.Errorf("failed to create graph curation table: %w", err)
+	}
+	return nil
+}
+
+// graphCuration 一次提取涉及的人工修改：被锁定的实体字段和被删除的关系
+type graphCuration struct {
+	locked  map[string]bool // 实体 + "\x00" + TYPE / DESCRIPTION
+	deleted map[string]bool // 主体 + "\x00" + 关系 + "\x00" + 客体
+}
+
+func (c *graphCuration) isLocked(entity, field string) bool {
+	return c != nil && c.locked[entity+"\x00"+field]
+}
+
+func (c *graphCuration) isDeleted(source, relation, target string) bool {
+	return c != nil && c.deleted[source+"\x00"+relation+"\x00"+target]
+}
+
+// loadGraphCuration 读取 subjects 相关的人工修改；表不存在或读取失败时返回 nil，提取照常进行
+func loadGraphCuration(ctx context.Context, db *sql.DB, subjects []string) *graphCuration {
+	if len(subjects) == 0 {
+		return nil
+	}
+	if err := ensureGraphCurationTable(ctx, db); err != nil {
+		logrus.WithError(err).Warn("Failed to load graph curation")
+		return nil
+	}
+	args := make([]any, len(subjects))
+	for i, s := range subjects {
+		args[i] = s
+	}
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT kind, subject, predicate, object FROM %s WHERE subject IN (?%s)
+	`, graphCurationTable, strings.Repeat(", ?", len(subjects)-1)), args...)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to load graph curation")
+		return nil
+	}
+	defer rows.Close()
+
+	c := &graphCuration{locked: make(map[string]bool), deleted: make(map[string]bool)}
+	for rows.Next() {
+		var kind, subject, predicate, object string
+		if err := rows.Scan(&kind, &subject, &predicate, &object); err != nil {
+			logrus.WithError(err).Warn("Failed to load graph curation")
+			return nil
+		}
+		if kind == curationLocked {
+			c.locked[subject+"\x00"+predicate] = true
+		} else {
+			c.deleted[subject+"\x00"+predicate+"\x00"+object] = true
+		}
+	}
+	return c
+}
+
+func recordGraphCuration(ctx context.Context, db *sql.DB, kind, subject, predicate, object string) error {
+	if err := ensureGraphCurationTable(ctx, db); err != nil {
+		return err
+	}
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (kind, subject, predicate, object) VALUES (?, ?, ?, ?) ON CONFLICT DO NOTHING
+	`, graphCurationTable), kind, subject, predicate, object)
+	if err != nil {
+		return fmt.Errorf("failed to record graph curation: %w", err)
+	}
+	return nil
+}
+
+func removeGraphCuration(ctx context.Context, db *sql.DB, kind, subject, predicate, object string) error {
+	if err := ensureGraphCurationTable(ctx, db); err != nil {
+		return err
+	}
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+		DELETE FROM %s WHERE kind = ? AND subject = ? AND predicate = ? AND object = ?
+	`, graphCurationTable), kind, subject, predicate, object)
+	if err != nil {
+		return fmt.Errorf("failed to remove graph curation: %w", err)
+	}
+	return nil
+}
+
+// curationDB 返回保存人工修改的数据库，同时检查图谱是否可用
+func (r *LightRAG) curationDB() (*sql.DB, error) {
+	c, err := r.maintenanceCollection()
+	if err != nil {
+		return nil, err
+	}
+	if r.graph == nil {
+		return nil, fmt.Errorf("graph database not available")
+	}
+	return c.db, nil
+}
+
+// AddEntity 人工添加实体，或替换已有实体的类型和描述
+// 设置的类型和描述会被锁定：之后的自动提取不会再为该实体添加类型和描述，描述合并也会跳过它；
+// 提取仍会把实体链接到它出现的分块
+func (r *LightRAG) AddEntity(ctx context.Context, entity Entity) error {
+	entity.Name = strings.TrimSpace(entity.Name)
+	if entity.Name == "" {
+		return fmt.Errorf("entity name is required")
+	}
+	db, err := r.curationDB()
+	if err != nil {
+		return err
+	}
+	if entity.Type == "" && entity.Description == "" {
+		// 图谱中的实体由它的边表示，没有类型和描述的实体无法保存
+		return fmt.Errorf("entity type or description is required")
+	}
+	if entity.Type != "" {
+		if err := r.replaceEntityField(ctx, db, entity.Name, "TYPE", entity.Type); err != nil {
+			return err
+		}
+	}
+	if entity.Description != "" {
+		if err := r.replaceEntityField(ctx, db, entity.Name, "DESCRIPTION", entity.Description); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateEntityDescription 把实体的所有描述替换为 description，并锁定描述（见 AddEntity）
+func (r *LightRAG) UpdateEntityDescription(ctx context.Context, name, description string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("entity name is required")
+	}
+	if strings.TrimSpace(description) == "" {
+		return fmt.Errorf("description is required")
+	}
+	db, err := r.curationDB()
+	if err != nil {
+		return err
+	}
+	return r.replaceEntityField(ctx, db, name, "DESCRIPTION", description)
+}
+
+// replaceEntityField 在一个事务中把实体的 field 边替换为 value，并锁定该字段
+func (r *LightRAG) replaceEntityField(ctx context.Context, db *sql.DB, entity, field, value string) error {
+	existing, err := r.graph.GetNeighbors(ctx, entity, field)
+	if err != nil {
+		return fmt.Errorf("failed to get %s of %s: %w", strings.ToLower(field), entity, err)
+	}
+	if err := r.graph.UpdateLinks(ctx, entity, field, existing, []string{value}); err != nil {
+		return fmt.Errorf("failed to update %s of %s: %w", strings.ToLower(field), entity, err)
+	}
+	return recordGraphCuration(ctx, db, curationLocked, entity, field, "")
+}
+
+// AddRelationship 人工添加关系；之前人工删除过的同一关系会重新允许自动提取
+func (r *LightRAG) AddRelationship(ctx context.Context, rel Relationship) error {
+	if err := checkRelationship(rel); err != nil {
+		return err
+	}
+	db, err := r.curationDB()
+	if err != nil {
+		return err
+	}
+	if err := r.graph.Link(ctx, rel.Source, rel.Relation, rel.Target); err != nil {
+		return fmt.Errorf("failed to add relationship %s -[%s]-> %s: %w", rel.Source, rel.Relation, rel.Target, err)
+	}
+	return removeGraphCuration(ctx, db, curationDeleted, rel.Source, rel.Relation, rel.Target)
+}
+
+// DeleteRelationship 删除关系 source -[relation]-> target，并记录下来，之后的自动提取不会重新添加
+// 关系不存在时也会记录，可以预先阻止错误的提取结果
+func (r *LightRAG) DeleteRelationship(ctx context.Context, source, relation, target string) error {
+	rel := Relationship{Source: source, Relation: relation, Target: target}
+	if err := checkRelationship(rel); err != nil {
+		return err
+	}
+	db, err := r.curationDB()
+	if err != nil {
+		return err
+	}
+	if err := r.graph.Unlink(ctx, source, relation, target); err != nil {
+		return fmt.Errorf("failed to delete relationship %s -[%s]-> %s: %w", source, relation, target, err)
+	}
+	return recordGraphCuration(ctx, db, curationDeleted, source, relation, target)
+}
+
+// checkRelationship 检查关系的两端和类型，TYPE、DESCRIPTION 和 APPEARS_IN 是保留的谓词
+func checkRelationship(rel Relationship) error {
+	if strings.TrimSpace(rel.Source) == "" || strings.TrimSpace(rel.Target) == "" || strings.TrimSpace(rel.Relation) == "" {
+		return fmt.Errorf("source, relation and target are required")
+	}
+	switch rel.Relation {
+	case "TYPE", "DESCRIPTION", "APPEARS_IN":
+		return fmt.Errorf("relation %s is reserved", rel.Relation)
+	}
+	return nil
+}
+
+// curationFor 读取 subjects 相关的人工修改，文档集合不是 DuckDB 集合时返回 nil
+func (r *LightRAG) curationFor(ctx context.Context, subjects []string) *graphCuration {
+	c, ok := r.docs.(*duckdbCollection)
+	if !ok || c.db == nil {
+		return nil
+	}
+	return loadGraphCuration(ctx, c.db, subjects)
+}
+
+// loadExtractionCuration 读取提取结果涉及的人工修改
+func (r *LightRAG) loadExtractionCuration(ctx context.Context, result *ExtractionResult) *graphCuration {
+	seen := make(map[string]bool)
+	var subjects []string
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			subjects = append(subjects, name)
+		}
+	}
+	for _, e := range result.Entities {
+		add(e.Name)
+	}
+	for _, rel := range result.Relationships {
+		add(rel.Source)
+	}
+	return r.curationFor(ctx, subjects)
+}
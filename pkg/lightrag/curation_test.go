@@ -0,0 +1,112 @@
+package lightrag
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	cayley_driver "github.com/mozhou-tech/sqlite-ai-driver/pkg/cayley-driver"
+	duckdb_driver "github.com/mozhou-tech/sqlite-ai-driver/pkg/duckdb-driver"
+)
+
+func newCurationTestRAG(t *testing.T, llm LLM) *LightRAG {
+	t.Helper()
+	db, err := sql.Open("duckdb", duckdb_driver.INDEX_DB_FILE)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	db.Exec(`DROP TABLE IF EXISTS ` + graphCurationTable)
+	t.Cleanup(func() {
+		db.Exec(`DROP TABLE IF EXISTS ` + graphCurationTable)
+		db.Close()
+	})
+	graph, err := cayley_driver.NewGraphWithNamespace(t.TempDir(), cayley_driver.GRAPH_DB_FILE, "curation_test_")
+	if err != nil {
+		t.Fatalf("failed to create graph: %v", err)
+	}
+	t.Cleanup(func() { graph.Close() })
+	return &LightRAG{initialized: true, llm: llm, docs: &duckdbCollection{db: db}, graph: &duckdbGraphDatabase{graph: graph}}
+}
+
+func TestGraphCuration(t *testing.T) {
+	ctx := context.Background()
+	llm := &FlexibleLLM{ResponseFunc: func(prompt string) (string, error) {
+		return `{"entities": [
+			{"name": "Go", "type": "Animal", "description": "A board game"},
+			{"name": "Rust", "type": "Language", "description": "A systems language"}
+		], "relationships": [
+			{"source": "Go", "target": "Google", "relation": "CREATED_BY"},
+			{"source": "Go", "target": "Rust", "relation": "SAME_AS"}
+		]}`, nil
+	}}
+	rag := newCurationTestRAG(t, llm)
+
+	// 已有自动提取的描述
+	rag.graph.Link(ctx, "Go", "DESCRIPTION", "An extracted description")
+	rag.graph.Link(ctx, "Go", "DESCRIPTION", "Another extracted description")
+
+	if err := rag.AddEntity(ctx, Entity{Name: "Go", Type: "Language"}); err != nil {
+		t.Fatalf("AddEntity failed: %v", err)
+	}
+	if err := rag.UpdateEntityDescription(ctx, "Go", "A language created at Google"); err != nil {
+		t.Fatalf("UpdateEntityDescription failed: %v", err)
+	}
+	if descriptions, _ := rag.graph.GetNeighbors(ctx, "Go", "DESCRIPTION"); len(descriptions) != 1 || descriptions[0] != "A language created at Google" {
+		t.Errorf("expected description to be replaced, got %v", descriptions)
+	}
+	if err := rag.DeleteRelationship(ctx, "Go", "SAME_AS", "Rust"); err != nil {
+		t.Fatalf("DeleteRelationship failed: %v", err)
+	}
+	if err := rag.AddRelationship(ctx, Relationship{Source: "Go", Target: "Gopher", Relation: "MASCOT"}); err != nil {
+		t.Fatalf("AddRelationship failed: %v", err)
+	}
+
+	// 之后的自动提取不覆盖人工修改
+	if err := rag.extractAndStore(ctx, "text", "doc1"); err != nil {
+		t.Fatalf("extractAndStore failed: %v", err)
+	}
+	if types, _ := rag.graph.GetNeighbors(ctx, "Go", "TYPE"); len(types) != 1 || types[0] != "Language" {
+		t.Errorf("expected curated type to be kept, got %v", types)
+	}
+	if descriptions, _ := rag.graph.GetNeighbors(ctx, "Go", "DESCRIPTION"); len(descriptions) != 1 {
+		t.Errorf("expected curated description to be kept, got %v", descriptions)
+	}
+	if targets, _ := rag.graph.GetNeighbors(ctx, "Go", "SAME_AS"); len(targets) != 0 {
+		t.Errorf("expected deleted relationship not to be re-added, got %v", targets)
+	}
+	if targets, _ := rag.graph.GetNeighbors(ctx, "Go", "CREATED_BY"); len(targets) != 1 {
+		t.Errorf("expected other relationships to be extracted, got %v", targets)
+	}
+	if docs, _ := rag.graph.GetNeighbors(ctx, "Go", "APPEARS_IN"); len(docs) != 1 {
+		t.Errorf("expected curated entity to be linked to the chunk, got %v", docs)
+	}
+	if types, _ := rag.graph.GetNeighbors(ctx, "Rust", "TYPE"); len(types) != 1 {
+		t.Errorf("expected uncurated entity to be extracted, got %v", types)
+	}
+
+	// 人工设置的描述不参与合并
+	rag.graph.Link(ctx, "Go", "DESCRIPTION", "Linked by hand")
+	if ok, err := rag.summarizeEntity(ctx, "Go", []string{"A language created at Google", "Linked by hand"}); ok || err != nil {
+		t.Errorf("expected curated description not to be summarized, got %v, %v", ok, err)
+	}
+
+	// 重新添加删除过的关系后允许自动提取
+	if err := rag.AddRelationship(ctx, Relationship{Source: "Go", Target: "Rust", Relation: "SAME_AS"}); err != nil {
+		t.Fatalf("AddRelationship failed: %v", err)
+	}
+	if rag.curationFor(ctx, []string{"Go"}).isDeleted("Go", "SAME_AS", "Rust") {
+		t.Error("expected deletion record to be removed")
+	}
+
+	for _, err := range []error{
+		rag.AddEntity(ctx, Entity{Name: " "}),
+		rag.AddEntity(ctx, Entity{Name: "Empty"}),
+		rag.UpdateEntityDescription(ctx, "Go", ""),
+		rag.AddRelationship(ctx, Relationship{Source: "Go", Target: "Language", Relation: "TYPE"}),
+		rag.DeleteRelationship(ctx, "Go", "", "Rust"),
+	} {
+		if err == nil {
+			t.Error("expected validation error")
+		}
+	}
+}
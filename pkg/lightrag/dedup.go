@@ -0,0 +1,84 @@
+package lightrag
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+)
+
+// simhashShingle 计算 SimHash 时每个特征包含的字符数，按字符切分对中文和英文都适用
+const simhashShingle = 3
+
+// simhash 计算文本的 64 位 SimHash，特征为去掉空白并转为小写后的字符 3-gram
+func simhash(text string) uint64 {
+	runes := make([]rune, 0, len(text))
+	for _, c := range strings.ToLower(text) {
+		if !unicode.IsSpace(c) {
+			runes = append(runes, c)
+		}
+	}
+	if len(runes) == 0 {
+		return 0
+	}
+	n := simhashShingle
+	if len(runes) < n {
+		n = len(runes)
+	}
+
+	var weights [64]int
+	h := fnv.New64a()
+	for i := 0; i+n <= len(runes); i++ {
+		h.Reset()
+		h.Write([]byte(string(runes[i : i+n])))
+		sum := h.Sum64()
+		for b := 0; b < 64; b++ {
+			if sum&(1<<b) != 0 {
+				weights[b]++
+			} else {
+				weights[b]--
+			}
+		}
+	}
+
+	var fingerprint uint64
+	for b, w := range weights {
+		if w > 0 {
+			fingerprint |= 1 << b
+		}
+	}
+	return fingerprint
+}
+
+// simhashSimilarity 两个 SimHash 的相似度，1 表示指纹相同
+func simhashSimilarity(a, b uint64) float64 {
+	return 1 - float64(bits.OnesCount64(a^b))/64
+}
+
+// suppressRedundant 按顺序保留结果，与已保留结果的内容相似度超过 maxRedundancy 的结果被丢弃，
+// 其召回的三元组合并到相似的结果中；maxRedundancy <= 0 或 >= 1 时不去重
+func suppressRedundant(results []SearchResult, maxRedundancy float64) []SearchResult {
+	if maxRedundancy <= 0 || maxRedundancy >= 1 || len(results) < 2 {
+		return results
+	}
+	kept := make([]SearchResult, 0, len(results))
+	fingerprints := make([]uint64, 0, len(results))
+	for _, res := range results {
+		fp := simhash(res.Content)
+		duplicate := -1
+		for i, kfp := range fingerprints {
+			if simhashSimilarity(fp, kfp) > maxRedundancy {
+				duplicate = i
+				break
+			}
+		}
+		if duplicate >= 0 {
+			triples := append([]Relationship(nil), kept[duplicate].RecalledTriples...)
+			kept[duplicate].RecalledTriples = append(triples, res.RecalledTriples...)
+			continue
+		}
+		kept = append(kept, res)
+		fingerprints = append(fingerprints, fp)
+	}
+	return kept
+}
@@ -0,0 +1,45 @@
+package lightrag
+
+import "testing"
+
+func TestSimhashSimilarity(t *testing.T) {
+	base := "LightRAG combines a knowledge graph with vector retrieval to answer questions about large document collections."
+	overlap := "LightRAG combines a knowledge graph with vector retrieval to answer questions about large document sets."
+	other := "DuckDB is an in-process analytical database with a columnar engine and SQL support."
+
+	if got := simhashSimilarity(simhash(base), simhash(base)); got != 1 {
+		t.Errorf("identical text similarity = %v, want 1", got)
+	}
+	near := simhashSimilarity(simhash(base), simhash(overlap))
+	far := simhashSimilarity(simhash(base), simhash(other))
+	if near < 0.85 || far > 0.75 || near <= far {
+		t.Errorf("unexpected similarities: near=%v far=%v", near, far)
+	}
+	// 空白和大小写不影响指纹
+	if simhash("Go  Channels\n") != simhash("go channels") {
+		t.Error("whitespace and case should be ignored")
+	}
+}
+
+func TestMergeSearchResults_SuppressesNearDuplicates(t *testing.T) {
+	rag := &LightRAG{}
+	triple := Relationship{Source: "LightRAG", Relation: "USES", Target: "DuckDB"}
+	local := []SearchResult{
+		{ID: "chunk-1", Content: "LightRAG combines a knowledge graph with vector retrieval to answer questions about large document collections.", Score: 2},
+		{ID: "chunk-3", Content: "DuckDB is an in-process analytical database with a columnar engine and SQL support.", Score: 1},
+	}
+	global := []SearchResult{
+		{ID: "chunk-2", Content: "LightRAG combines a knowledge graph with vector retrieval to answer questions about large document sets.", Score: 1.5, RecalledTriples: []Relationship{triple}},
+	}
+
+	if merged := rag.mergeSearchResults(local, global, 5, 0); len(merged) != 3 {
+		t.Fatalf("expected ID-only dedup to keep 3 results, got %d", len(merged))
+	}
+	merged := rag.mergeSearchResults(local, global, 5, 0.85)
+	if len(merged) != 2 || merged[0].ID != "chunk-1" || merged[1].ID != "chunk-3" {
+		t.Fatalf("unexpected merged results: %+v", merged)
+	}
+	if len(merged[0].RecalledTriples) != 1 || merged[0].RecalledTriples[0] != triple {
+		t.Errorf("triples of the suppressed result should be kept, got %+v", merged[0].RecalledTriples)
+	}
+}
@@ -0,0 +1,193 @@
+package lightrag
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// documentOrderColumns ListDocumentsOptions.OrderBy 支持的排序字段
+var documentOrderColumns = map[string]string{
+	"":             "created_at",
+	"created_at":   "created_at",
+	"id":           "id",
+	"chunk_length": "chunk_length",
+}
+
+// ListDocumentsOptions ListDocumentsWithOptions 的选项
+type ListDocumentsOptions struct {
+	// Limit 返回的文档数，默认 100
+	Limit  int
+	Offset int
+	// Fields 返回的字段（如 id、content 或元数据字段），为空时返回全部字段，id 总会返回
+	// 不包含 content 时不读取文档内容
+	Fields []string
+	// Filters 元数据过滤器（Mango Selector），支持字段值相等以及 $eq、$ne、$in
+	Filters map[string]any
+	// ContentPrefix 只返回内容以该前缀开头的文档
+	ContentPrefix string
+	// OrderBy 排序字段：created_at（默认）、id、chunk_length
+	OrderBy string
+	// Ascending 为 true 时升序，默认降序
+	Ascending bool
+	// States 只返回这些状态的文档，为空时只返回 active 的文档
+	States []DocumentState
+}
+
+// DocumentList 文档列表和满足过滤条件的文档总数
+type DocumentList struct {
+	Documents []map[string]any `json:"documents"`
+	Total     int              `json:"total"`
+}
+
+// ListDocumentsWithOptions 分页获取文档列表，支持字段投影、元数据过滤、内容前缀搜索、排序和总数统计
+// 文档状态通过 _state 字段返回
+func (r *LightRAG) ListDocumentsWithOptions(ctx context.Context, opts ListDocumentsOptions) (*DocumentList, error) {
+	c, err := r.maintenanceCollection()
+	if err != nil {
+		return nil, err
+	}
+	orderColumn, ok := documentOrderColumns[opts.OrderBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported order field: %s", opts.OrderBy)
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 100
+	}
+	if opts.Offset < 0 {
+		opts.Offset = 0
+	}
+
+	stateFilter, args, err := stateClause(opts.States)
+	if err != nil {
+		return nil, err
+	}
+	conditions := []string{stateFilter}
+	if opts.ContentPrefix != "" {
+		conditions = append(conditions, "starts_with(content, ?)")
+		args = append(args, opts.ContentPrefix)
+	}
+	filter, filterArgs, err := selectorClauseWithColumns(opts.Filters, c.columnTypes())
+	if err != nil {
+		return nil, err
+	}
+	if filter != "" {
+		conditions = append(conditions, filter)
+		args = append(args, filterArgs...)
+	}
+	where := " WHERE " + strings.Join(conditions, " AND ")
+
+	list := &DocumentList{Documents: []map[string]any{}}
+	countSQL := fmt.Sprintf(`SELECT COUNT(*) FROM %s%s`, c.tableName, where)
+	if err := c.db.QueryRowContext(ctx, countSQL, args...).Scan(&list.Total); err != nil {
+		return nil, fmt.Errorf("failed to count documents: %w", err)
+	}
+
+	fields := make(map[string]bool, len(opts.Fields))
+	for _, f := range opts.Fields {
+		fields[f] = true
+	}
+	projected := len(fields) > 0
+	contentColumn := "content"
+	if projected && !fields["content"] {
+		contentColumn = "''"
+	}
+	direction := "DESC"
+	if opts.Ascending {
+		direction = "ASC"
+	}
+	selectSQL := fmt.Sprintf(`
+		SELECT id, %s, metadata, state FROM %s%s
+		ORDER BY %s %s, id %s LIMIT ? OFFSET ?
+	`, contentColumn, c.tableName, where, orderColumn, direction, direction)
+	rows, err := c.db.QueryContext(ctx, selectSQL, append(args, opts.Limit, opts.Offset)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list documents: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, content, state string
+		var metadataVal any
+		if err := rows.Scan(&id, &content, &metadataVal, &state); err != nil {
+			return nil, fmt.Errorf("failed to scan document: %w", err)
+		}
+		doc := map[string]any{"id": id}
+		if !projected || fields["content"] {
+			doc["content"] = content
+		}
+		if !projected || fields[StateField] {
+			doc[StateField] = state
+		}
+		for k, v := range decodeMetadata(metadataVal) {
+			if !projected || fields[k] {
+				doc[k] = v
+			}
+		}
+		list.Documents = append(list.Documents, doc)
+	}
+	return list, rows.Err()
+}
+
+// DeleteDocumentsBySource 删除元数据字段 field 等于 value 的所有文档（包括归档和软删除的），
+// 并去掉实体到这些文档的 APPEARS_IN 链接，返回删除的文档 ID；用于来源文件被修改或删除后清理旧的分块
+func (r *LightRAG) DeleteDocumentsBySource(ctx context.Context, field string, value any) ([]string, error) {
+	c, err := r.maintenanceCollection()
+	if err != nil {
+		return nil, err
+	}
+	filter, args, err := selectorClauseWithColumns(map[string]any{field: value}, c.columnTypes())
+	if err != nil {
+		return nil, err
+	}
+	rows, err := c.db.QueryContext(ctx, fmt.Sprintf(`SELECT id FROM %s WHERE %s ORDER BY id`, c.tableName, filter), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find documents: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan document: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to find documents: %w", err)
+	}
+
+	for _, id := range ids {
+		if r.graph != nil {
+			entities, err := r.graph.GetInNeighbors(ctx, id, "APPEARS_IN")
+			if err != nil {
+				return nil, fmt.Errorf("failed to get entities of %s: %w", id, err)
+			}
+			for _, entity := range entities {
+				if err := r.graph.Unlink(ctx, entity, "APPEARS_IN", id); err != nil {
+					return nil, fmt.Errorf("failed to unlink %s from %s: %w", entity, id, err)
+				}
+			}
+		}
+		if err := c.Delete(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// decodeMetadata 解析 metadata 列的值
+func decodeMetadata(val any) map[string]any {
+	var metadata map[string]any
+	switch v := val.(type) {
+	case string:
+		_ = json.Unmarshal([]byte(v), &metadata)
+	case []byte:
+		_ = json.Unmarshal(v, &metadata)
+	case map[string]any:
+		metadata = v
+	}
+	return metadata
+}
@@ -0,0 +1,152 @@
+package lightrag
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	cayley_driver "github.com/mozhou-tech/sqlite-ai-driver/pkg/cayley-driver"
+	duckdb_driver "github.com/mozhou-tech/sqlite-ai-driver/pkg/duckdb-driver"
+)
+
+func TestListDocumentsWithOptions(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("duckdb", duckdb_driver.INDEX_DB_FILE)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	const table = "lightrag_list_documents_test"
+	db.Exec(`DROP TABLE IF EXISTS ` + table)
+	t.Cleanup(func() {
+		db.Exec(`DROP TABLE IF EXISTS ` + table)
+		db.Close()
+	})
+	if _, err := db.Exec(`CREATE TABLE ` + table + ` (id VARCHAR PRIMARY KEY, content TEXT, metadata JSON, created_at TIMESTAMP, chunk_length INTEGER, state VARCHAR DEFAULT 'active')`); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	for _, row := range []struct {
+		id, content, metadata, createdAt string
+	}{
+		{"a", "Go is a language", `{"source": "wiki", "page": 1}`, "2026-01-01"},
+		{"b", "Go channels", `{"source": "blog", "page": 2}`, "2026-01-02"},
+		{"c", "Rust ownership", `{"source": "wiki", "page": 3, "a/b": true}`, "2026-01-03"},
+	} {
+		if _, err := db.Exec(`INSERT INTO `+table+` (id, content, metadata, created_at, chunk_length) VALUES (?, ?, ?::JSON, ?::TIMESTAMP, ?)`,
+			row.id, row.content, row.metadata, row.createdAt, len(row.content)); err != nil {
+			t.Fatalf("failed to insert document: %v", err)
+		}
+	}
+	rag := &LightRAG{initialized: true, docs: &duckdbCollection{db: db, tableName: table}}
+
+	list, err := rag.ListDocumentsWithOptions(ctx, ListDocumentsOptions{Limit: 2})
+	if err != nil {
+		t.Fatalf("ListDocumentsWithOptions failed: %v", err)
+	}
+	if list.Total != 3 || len(list.Documents) != 2 || list.Documents[0]["id"] != "c" || list.Documents[0]["source"] != "wiki" {
+		t.Errorf("unexpected default listing: %+v", list)
+	}
+
+	// 字段投影 + 内容前缀 + 升序
+	list, err = rag.ListDocumentsWithOptions(ctx, ListDocumentsOptions{
+		Fields:        []string{"source"},
+		ContentPrefix: "Go",
+		OrderBy:       "id",
+		Ascending:     true,
+	})
+	if err != nil {
+		t.Fatalf("ListDocumentsWithOptions failed: %v", err)
+	}
+	if list.Total != 2 || list.Documents[0]["id"] != "a" || list.Documents[1]["source"] != "blog" {
+		t.Errorf("unexpected prefix listing: %+v", list)
+	}
+	if _, ok := list.Documents[0]["content"]; ok || len(list.Documents[0]) != 2 {
+		t.Errorf("expected only id and source, got %+v", list.Documents[0])
+	}
+
+	// 元数据过滤，字段名可以包含 /
+	list, err = rag.ListDocumentsWithOptions(ctx, ListDocumentsOptions{Filters: map[string]any{"source": "wiki", "a/b": true}})
+	if err != nil {
+		t.Fatalf("ListDocumentsWithOptions failed: %v", err)
+	}
+	if list.Total != 1 || list.Documents[0]["id"] != "c" {
+		t.Errorf("unexpected filtered listing: %+v", list)
+	}
+	if list, _ := rag.ListDocumentsWithOptions(ctx, ListDocumentsOptions{Filters: map[string]any{"page": 2}}); list == nil || list.Total != 1 || list.Documents[0]["id"] != "b" {
+		t.Errorf("expected numeric filter to match b, got %+v", list)
+	}
+
+	if _, err := rag.ListDocumentsWithOptions(ctx, ListDocumentsOptions{OrderBy: "content; DROP TABLE x"}); err == nil {
+		t.Error("expected error for unsupported order field")
+	}
+
+	// 归档的文档默认不列出，可以按状态列出
+	if n, err := rag.ArchiveDocuments(ctx, "a", "missing"); err != nil || n != 1 {
+		t.Fatalf("ArchiveDocuments = %d, %v", n, err)
+	}
+	if list, _ := rag.ListDocumentsWithOptions(ctx, ListDocumentsOptions{}); list == nil || list.Total != 2 {
+		t.Errorf("expected archived document to be hidden, got %+v", list)
+	}
+	list, err = rag.ListDocumentsWithOptions(ctx, ListDocumentsOptions{States: []DocumentState{StateArchived}, Fields: []string{StateField}})
+	if err != nil {
+		t.Fatalf("ListDocumentsWithOptions failed: %v", err)
+	}
+	if list.Total != 1 || list.Documents[0]["id"] != "a" || list.Documents[0][StateField] != "archived" {
+		t.Errorf("unexpected archived listing: %+v", list)
+	}
+	if _, err := rag.ListDocumentsWithOptions(ctx, ListDocumentsOptions{States: []DocumentState{"gone"}}); err == nil {
+		t.Error("expected error for unsupported state")
+	}
+}
+
+func TestDeleteDocumentsBySource(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("duckdb", duckdb_driver.INDEX_DB_FILE)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	const table = "lightrag_delete_source_test"
+	db.Exec(`DROP TABLE IF EXISTS ` + table)
+	t.Cleanup(func() {
+		db.Exec(`DROP TABLE IF EXISTS ` + table)
+		db.Close()
+	})
+	if _, err := db.Exec(`CREATE TABLE ` + table + ` (id VARCHAR PRIMARY KEY, content TEXT, metadata JSON, state VARCHAR DEFAULT 'active')`); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	for _, row := range [][3]string{
+		{"a.md_chunk_0", `{"source_file": "docs/a.md"}`, "active"},
+		{"a.md_chunk_1", `{"source_file": "docs/a.md"}`, "archived"},
+		{"b.md_chunk_0", `{"source_file": "docs/b.md"}`, "active"},
+	} {
+		if _, err := db.Exec(`INSERT INTO `+table+` (id, content, metadata, state) VALUES (?, 'content', ?::JSON, ?)`, row[0], row[1], row[2]); err != nil {
+			t.Fatalf("failed to insert document: %v", err)
+		}
+	}
+	graph, err := cayley_driver.NewGraphWithNamespace(t.TempDir(), cayley_driver.GRAPH_DB_FILE, "delete_source_test_")
+	if err != nil {
+		t.Fatalf("failed to create graph: %v", err)
+	}
+	t.Cleanup(func() { graph.Close() })
+	rag := &LightRAG{initialized: true, docs: &duckdbCollection{db: db, tableName: table}, graph: &duckdbGraphDatabase{graph: graph}}
+	rag.graph.Link(ctx, "Go", "APPEARS_IN", "a.md_chunk_0")
+	rag.graph.Link(ctx, "Go", "APPEARS_IN", "b.md_chunk_0")
+
+	ids, err := rag.DeleteDocumentsBySource(ctx, "source_file", "docs/a.md")
+	if err != nil {
+		t.Fatalf("DeleteDocumentsBySource failed: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != "a.md_chunk_0" || ids[1] != "a.md_chunk_1" {
+		t.Errorf("unexpected deleted ids: %v", ids)
+	}
+	var remaining int
+	db.QueryRow(`SELECT COUNT(*) FROM ` + table).Scan(&remaining)
+	if remaining != 1 {
+		t.Errorf("expected one remaining document, got %d", remaining)
+	}
+	if docs, _ := rag.graph.GetNeighbors(ctx, "Go", "APPEARS_IN"); len(docs) != 1 || docs[0] != "b.md_chunk_0" {
+		t.Errorf("expected only the link to b to remain, got %v", docs)
+	}
+	if ids, err := rag.DeleteDocumentsBySource(ctx, "source_file", "docs/missing.md"); err != nil || len(ids) != 0 {
+		t.Errorf("expected nothing to delete, got %v, %v", ids, err)
+	}
+}
@@ -0,0 +1,115 @@
+package lightrag
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+// embeddingWorker 数据库级别的后台 embedding worker
+// 同一个数据库的所有集合共享一个 worker、一个处理队列和一个速率限制器，
+// 集合数量增加时 embedding API 的调用速率不会成倍增加
+type embeddingWorker struct {
+	limiter *rate.Limiter // Embedding API 速率限制器（每秒5次）
+	wake    chan struct{} // 插入文档后唤醒 worker，不必等到下一次定时检查
+
+	mu          sync.Mutex
+	collections []*duckdbCollection // 按注册顺序轮流处理 pending embedding 的集合
+	cancel      context.CancelFunc
+	stopped     bool
+	wg          sync.WaitGroup
+}
+
+// newEmbeddingWorker 创建 embedding worker，调用 start 后才开始处理
+func newEmbeddingWorker() *embeddingWorker {
+	// 每秒5次，burst 为1（严格限制，不允许突发）
+	// rate.Limit(5) 表示每秒5次 = 每200ms一次
+	// burst 1 表示令牌桶中最多有1个令牌，每次请求消耗1个令牌
+	// 这样确保严格按每秒5次的速率执行，不允许突发
+	limiter := rate.NewLimiter(rate.Limit(5), 1)
+	logrus.WithFields(logrus.Fields{
+		"rate":  "5 per second",
+		"burst": 1,
+	}).Info("Embedding rate limiter initialized")
+	return &embeddingWorker{
+		limiter: limiter,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// register 把集合加入处理队列，重复注册的集合只处理一次
+func (w *embeddingWorker) register(c *duckdbCollection) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, existing := range w.collections {
+		if existing == c {
+			return
+		}
+	}
+	w.collections = append(w.collections, c)
+}
+
+// start 启动后台 goroutine（如果还没有启动），stop 之后不会再启动
+func (w *embeddingWorker) start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cancel != nil || w.stopped {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.wg.Add(1)
+	go w.run(ctx)
+	logrus.Info("Background embedding worker started")
+}
+
+// notify 唤醒 worker 处理新的 pending 文档，worker 正忙时合并为一次唤醒
+func (w *embeddingWorker) notify() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// stop 停止后台 goroutine 并等待正在处理的文档完成
+func (w *embeddingWorker) stop() {
+	w.mu.Lock()
+	w.stopped = true
+	cancel := w.cancel
+	w.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		w.wg.Wait()
+		logrus.Info("Background embedding worker stopped")
+	}
+}
+
+// run 定期（或被唤醒时）依次处理所有集合中 pending 状态的 embedding
+func (w *embeddingWorker) run(ctx context.Context) {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(2 * time.Second) // 每2秒检查一次
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.wake:
+		}
+
+		w.mu.Lock()
+		collections := append([]*duckdbCollection(nil), w.collections...)
+		w.mu.Unlock()
+		for _, c := range collections {
+			if ctx.Err() != nil {
+				return
+			}
+			c.processPendingEmbeddings(ctx)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package lightrag
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// EventType 导入和提取的生命周期事件类型
+type EventType string
+
+const (
+	EventDocumentsIngested    EventType = "documents.ingested"    // Insert、InsertBatch 写入了文档
+	EventEmbeddingsCompleted  EventType = "embeddings.completed"  // 后台 worker 处理完一批 pending 文档的向量（包括失败的文档）
+	EventExtractionCompleted  EventType = "extraction.completed"  // 一个分块的知识图谱提取完成
+	EventExtractionFailed     EventType = "extraction.failed"     // 一个分块的知识图谱提取失败
+	EventMaintenanceCompleted EventType = "maintenance.completed" // Vacuum、RebuildFulltextIndex、Reembed、SummarizeEntityDescriptions、ReextractGraph 完成
+)
+
+// EventTypes 所有事件类型
+var EventTypes = []EventType{
+	EventDocumentsIngested,
+	EventEmbeddingsCompleted,
+	EventExtractionCompleted,
+	EventExtractionFailed,
+	EventMaintenanceCompleted,
+}
+
+// Event 生命周期事件，Data 的字段随类型不同：
+//
+//	documents.ingested:    ids、count
+//	embeddings.completed:  completed（成功的文档 ID）、failed（[{id, error}]）
+//	extraction.completed:  doc_id、entities、relationships
+//	extraction.failed:     doc_id、error
+//	maintenance.completed: task（vacuum、rebuild_fulltext、reembed、summarize_descriptions、reextract）及任务的结果
+type Event struct {
+	ID   string         `json:"id"`
+	Type EventType      `json:"type"`
+	Time time.Time      `json:"time"`
+	Data map[string]any `json:"data"`
+}
+
+// EventHandler 处理事件，在订阅者自己的 goroutine 中按发生顺序调用
+type EventHandler func(Event)
+
+// eventQueueSize 每个订阅者缓冲的事件数，处理不过来时丢弃新的事件
+const eventQueueSize = 1024
+
+// eventFlushTimeout FinalizeStorages 等待订阅者处理完剩余事件的最长时间
+const eventFlushTimeout = 10 * time.Second
+
+// WebhookConfig 接收事件的 HTTP 地址，事件以 JSON（Event）POST 到 URL
+type WebhookConfig struct {
+	URL string
+	// Events 接收的事件类型，为空时接收所有事件
+	Events []EventType
+	// Secret 不为空时用 HMAC-SHA256 签名请求体，签名放在 X-LightRAG-Signature 头中（sha256=<hex>）
+	Secret string
+	// Headers 附加的请求头，如认证信息
+	Headers map[string]string
+	// Timeout 单次请求的超时，默认 10s
+	Timeout time.Duration
+	// MaxRetries 请求失败（网络错误、429、5xx）时的重试次数，默认 3，负数表示不重试
+	MaxRetries int
+}
+
+// eventBus 把事件分发给订阅者，每个订阅者有自己的队列和 goroutine，慢的订阅者不影响其他订阅者和发布方
+type eventBus struct {
+	mu          sync.RWMutex
+	subscribers map[int]*eventSubscriber
+	nextID      int
+	closed      bool
+}
+
+type eventSubscriber struct {
+	types   map[EventType]bool // 为空时接收所有事件
+	handler EventHandler
+	queue   chan Event
+	done    chan struct{}
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{subscribers: make(map[int]*eventSubscriber)}
+}
+
+// subscribe 添加订阅者，返回取消订阅的函数（会等待已排队的事件处理完）
+func (b *eventBus) subscribe(handler EventHandler, types []EventType) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return func() {}
+	}
+	s := &eventSubscriber{handler: handler, queue: make(chan Event, eventQueueSize), done: make(chan struct{})}
+	if len(types) > 0 {
+		s.types = make(map[EventType]bool, len(types))
+		for _, t := range types {
+			s.types[t] = true
+		}
+	}
+	id := b.nextID
+	b.nextID++
+	b.subscribers[id] = s
+	go s.run()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			_, ok := b.subscribers[id]
+			delete(b.subscribers, id)
+			b.mu.Unlock()
+			if ok {
+				close(s.queue)
+				<-s.done
+			}
+		})
+	}
+}
+
+func (s *eventSubscriber) run() {
+	defer close(s.done)
+	for event := range s.queue {
+		s.handle(event)
+	}
+}
+
+// handle 调用处理函数，处理函数 panic 不影响之后的事件
+func (s *eventSubscriber) handle(event Event) {
+	defer func() {
+		if p := recover(); p != nil {
+			logrus.WithField("event", event.Type).Errorf("Event handler panicked: %v", p)
+		}
+	}()
+	s.handler(event)
+}
+
+// publish 把事件放入订阅者的队列，不等待处理；没有订阅者时不做任何事
+func (b *eventBus) publish(eventType EventType, data map[string]any) {
+	if b == nil {
+		return
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.subscribers) == 0 {
+		return
+	}
+	event := Event{ID: newEventID(), Type: eventType, Time: time.Now(), Data: data}
+	for _, s := range b.subscribers {
+		if s.types != nil && !s.types[eventType] {
+			continue
+		}
+		select {
+		case s.queue <- event:
+		default:
+			logrus.WithField("event", eventType).Warn("Event queue is full, dropping event")
+		}
+	}
+}
+
+// close 停止接收事件，并在 timeout 内等待订阅者处理完已排队的事件
+func (b *eventBus) close(timeout time.Duration) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	subscribers := b.subscribers
+	b.subscribers = make(map[int]*eventSubscriber)
+	b.mu.Unlock()
+
+	deadline := time.After(timeout)
+	for _, s := range subscribers {
+		close(s.queue)
+	}
+	for _, s := range subscribers {
+		select {
+		case <-s.done:
+		case <-deadline:
+			logrus.Warn("Timed out waiting for event handlers, remaining events are dropped")
+			return
+		}
+	}
+}
+
+func newEventID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%d-%s", time.Now().UnixNano(), hex.EncodeToString(b))
+}
+
+// Subscribe 订阅生命周期事件，types 为空时接收所有事件；返回取消订阅的函数
+// 事件在后台按发生顺序交给 handler，handler 处理过慢导致队列满时丢弃新的事件；
+// FinalizeStorages 时最多等待 10 秒处理剩余的事件，之后不再发送事件
+func (r *LightRAG) Subscribe(handler EventHandler, types ...EventType) func() {
+	if r == nil || r.events == nil || handler == nil {
+		return func() {}
+	}
+	return r.events.subscribe(handler, types)
+}
+
+// webhookHandler 返回把事件 POST 到 webhook 的处理函数，失败时按指数退避重试
+func webhookHandler(cfg WebhookConfig) EventHandler {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	retries := cfg.MaxRetries
+	if retries == 0 {
+		retries = 3
+	}
+	client := &http.Client{Timeout: timeout}
+	return func(event Event) {
+		body, err := json.Marshal(event)
+		if err != nil {
+			logrus.WithError(err).WithField("event", event.Type).Error("Failed to encode webhook event")
+			return
+		}
+		backoff := time.Second
+		for attempt := 0; ; attempt++ {
+			retry, err := deliverWebhook(client, cfg, event, body)
+			if err == nil {
+				return
+			}
+			if !retry || attempt >= retries {
+				logrus.WithError(err).WithFields(logrus.Fields{
+					"url":   cfg.URL,
+					"event": event.Type,
+				}).Error("Failed to deliver webhook")
+				return
+			}
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+}
+
+// deliverWebhook 发送一次请求，返回失败时是否应该重试
+func deliverWebhook(client *http.Client, cfg WebhookConfig, event Event, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-LightRAG-Event", string(event.Type))
+	req.Header.Set("X-LightRAG-Delivery", event.ID)
+	for k, v := range cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	if cfg.Secret != "" {
+		req.Header.Set("X-LightRAG-Signature", "sha256="+signWebhook(cfg.Secret, body))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("failed to send webhook: %w", err)
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+}
+
+// signWebhook 返回请求体的 HMAC-SHA256 签名（十六进制），接收方用同样的密钥计算并比较
+func signWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,117 @@
+package lightrag
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	cayley_driver "github.com/mozhou-tech/sqlite-ai-driver/pkg/cayley-driver"
+)
+
+func TestEventBus(t *testing.T) {
+	bus := newEventBus()
+	var mu sync.Mutex
+	var all, failed []EventType
+	bus.subscribe(func(e Event) {
+		mu.Lock()
+		all = append(all, e.Type)
+		mu.Unlock()
+	}, nil)
+	unsubscribe := bus.subscribe(func(e Event) {
+		mu.Lock()
+		failed = append(failed, e.Type)
+		mu.Unlock()
+		panic("handler panics are recovered")
+	}, []EventType{EventExtractionFailed})
+
+	bus.publish(EventDocumentsIngested, map[string]any{"count": 1})
+	bus.publish(EventExtractionFailed, map[string]any{"doc_id": "a"})
+	unsubscribe()
+	bus.publish(EventExtractionFailed, map[string]any{"doc_id": "b"})
+	bus.close(time.Second)
+	bus.publish(EventMaintenanceCompleted, nil)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if fmt.Sprint(all) != "[documents.ingested extraction.failed extraction.failed]" {
+		t.Errorf("unexpected events: %v", all)
+	}
+	if len(failed) != 1 {
+		t.Errorf("expected one event before unsubscribing, got %v", failed)
+	}
+
+	var nilBus *eventBus
+	nilBus.publish(EventDocumentsIngested, nil)
+}
+
+func TestWebhook(t *testing.T) {
+	var mu sync.Mutex
+	var received []Event
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if got, want := r.Header.Get("X-LightRAG-Signature"), "sha256="+signWebhook("secret", body); got != want {
+			t.Errorf("signature = %s, want %s", got, want)
+		}
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("missing custom header")
+		}
+		var e Event
+		if err := json.Unmarshal(body, &e); err != nil {
+			t.Errorf("invalid body: %v", err)
+		}
+		if r.Header.Get("X-LightRAG-Event") != string(e.Type) {
+			t.Errorf("event header does not match body: %s", r.Header.Get("X-LightRAG-Event"))
+		}
+		received = append(received, e)
+	}))
+	defer server.Close()
+
+	graph, err := cayley_driver.NewGraphWithNamespace(t.TempDir(), cayley_driver.GRAPH_DB_FILE, "events_test_")
+	if err != nil {
+		t.Fatalf("failed to create graph: %v", err)
+	}
+	defer graph.Close()
+	rag := New(Options{
+		LLM: &FlexibleLLM{ResponseFunc: func(prompt string) (string, error) {
+			return `{"entities": [{"name": "Go", "type": "Language"}], "relationships": []}`, nil
+		}},
+		Webhooks: []WebhookConfig{{
+			URL:     server.URL,
+			Events:  []EventType{EventExtractionCompleted},
+			Secret:  "secret",
+			Headers: map[string]string{"Authorization": "Bearer token"},
+		}},
+	})
+	rag.graph = &duckdbGraphDatabase{graph: graph}
+
+	if err := rag.extractAndStore(context.Background(), "Go is a language", "doc1"); err != nil {
+		t.Fatalf("extractAndStore failed: %v", err)
+	}
+	rag.events.publish(EventDocumentsIngested, map[string]any{"count": 1})
+	// 第一次请求返回 503，重试后送达
+	rag.events.close(10 * time.Second)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 2 || len(received) != 1 {
+		t.Fatalf("expected one event delivered after a retry, got %d attempts, %+v", attempts, received)
+	}
+	e := received[0]
+	if e.Type != EventExtractionCompleted || e.Data["doc_id"] != "doc1" || e.Data["entities"] != float64(1) || e.ID == "" {
+		t.Errorf("unexpected event: %+v", e)
+	}
+}
@@ -0,0 +1,191 @@
+package lightrag
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// extractionLogTable 保存图谱提取的原始提示词和响应
+const extractionLogTable = "lightrag_extraction_log"
+
+// extractionLogPruneInterval 每写入多少条记录执行一次保留策略
+const extractionLogPruneInterval = 100
+
+// ExtractionLogConfig 图谱提取日志的配置，用于排查某些实体或关系为什么（没有）被提取
+type ExtractionLogConfig struct {
+	// Redact 写入前处理提示词和响应（如 RedactPII），为 nil 时原样保存
+	Redact func(string) string
+	// MaxAge 记录的保留时间，0 表示不按时间清理
+	MaxAge time.Duration
+	// MaxEntries 最多保留的记录数，超出时删除最早的记录，0 表示不限制
+	MaxEntries int
+}
+
+// ExtractionLogEntry 一次图谱提取的记录
+type ExtractionLogEntry struct {
+	ID            string    `json:"id"`
+	DocID         string    `json:"doc_id"`
+	CreatedAt     time.Time `json:"created_at"`
+	Prompt        string    `json:"prompt"`
+	Response      string    `json:"response"`
+	Error         string    `json:"error,omitempty"`
+	Entities      int       `json:"entities"`
+	Relationships int       `json:"relationships"`
+	DurationMs    int64     `json:"duration_ms"`
+	Redacted      bool      `json:"redacted"`
+}
+
+var (
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	phonePattern  = regexp.MustCompile(`(?:\+?\d{1,3}[ -]?)?1[3-9]\d{9}\b|\b\d{3,4}-\d{7,8}\b`)
+	idCardPattern = regexp.MustCompile(`\b\d{17}[\dXx]\b`)
+	apiKeyPattern = regexp.MustCompile(`\b(?:sk|ak|pk)-[A-Za-z0-9_-]{16,}`)
+)
+
+// RedactPII 屏蔽常见的敏感信息：邮箱、手机号和固定电话、身份证号以及形如 sk-xxx 的密钥
+func RedactPII(s string) string {
+	s = emailPattern.ReplaceAllString(s, "[EMAIL]")
+	s = apiKeyPattern.ReplaceAllString(s, "[KEY]")
+	s = idCardPattern.ReplaceAllString(s, "[ID]")
+	return phonePattern.ReplaceAllString(s, "[PHONE]")
+}
+
+// extractionLogger 把图谱提取的提示词和响应写入 extractionLogTable
+type extractionLogger struct {
+	db     *sql.DB
+	config ExtractionLogConfig
+	writes atomic.Int64
+	seq    atomic.Int64
+}
+
+func newExtractionLogger(ctx context.Context, db *sql.DB, config ExtractionLogConfig) (*extractionLogger, error) {
+	if err := ensureExtractionLogTable(ctx, db); err != nil {
+		return nil, err
+	}
+	l := &extractionLogger{db: db, config: config}
+	if err := l.prune(ctx); err != nil {
+		logrus.WithError(err).Warn("Failed to prune extraction log")
+	}
+	return l, nil
+}
+
+func ensureExtractionLogTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			id VARCHAR PRIMARY KEY,
+			doc_id VARCHAR,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			prompt TEXT,
+			response TEXT,
+			error TEXT,
+			entities INTEGER,
+			relationships INTEGER,
+			duration_ms BIGINT,
+			redacted BOOLEAN
+		);
+		CREATE INDEX IF NOT EXISTS idx_%s_doc_id ON %s(doc_id);
+	`, extractionLogTable, extractionLogTable, extractionLogTable))
+	if err != nil {
+		return fmt.Errorf("failed to create extraction log table: %w", err)
+	}
+	return nil
+}
+
+// record 写入一次提取的记录，失败只记录日志，不影响提取本身
+func (l *extractionLogger) record(ctx context.Context, entry *ExtractionLogEntry) {
+	if l == nil {
+		return
+	}
+	if l.config.Redact != nil {
+		entry.Prompt = l.config.Redact(entry.Prompt)
+		entry.Response = l.config.Redact(entry.Response)
+		entry.Error = l.config.Redact(entry.Error)
+		entry.Redacted = true
+	}
+	entry.ID = strconv.FormatInt(time.Now().UnixNano(), 10) + "-" + strconv.FormatInt(l.seq.Add(1), 10)
+
+	_, err := l.db.ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (id, doc_id, prompt, response, error, entities, relationships, duration_ms, redacted)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, extractionLogTable), entry.ID, entry.DocID, entry.Prompt, entry.Response, entry.Error,
+		entry.Entities, entry.Relationships, entry.DurationMs, entry.Redacted)
+	if err != nil {
+		logrus.WithError(err).WithField("doc_id", entry.DocID).Warn("Failed to write extraction log")
+		return
+	}
+	if l.writes.Add(1)%extractionLogPruneInterval == 0 {
+		if err := l.prune(ctx); err != nil {
+			logrus.WithError(err).Warn("Failed to prune extraction log")
+		}
+	}
+}
+
+// prune 按 MaxAge 和 MaxEntries 删除过期的记录
+func (l *extractionLogger) prune(ctx context.Context) error {
+	if l.config.MaxAge > 0 {
+		cutoff := time.Now().Add(-l.config.MaxAge)
+		deleteSQL := fmt.Sprintf(`DELETE FROM %s WHERE created_at < ?`, extractionLogTable)
+		if _, err := l.db.ExecContext(ctx, deleteSQL, cutoff); err != nil {
+			return fmt.Errorf("failed to delete expired extraction logs: %w", err)
+		}
+	}
+	if l.config.MaxEntries > 0 {
+		deleteSQL := fmt.Sprintf(`
+			DELETE FROM %s WHERE id NOT IN (
+				SELECT id FROM %s ORDER BY created_at DESC, id DESC LIMIT ?
+			)
+		`, extractionLogTable, extractionLogTable)
+		if _, err := l.db.ExecContext(ctx, deleteSQL, l.config.MaxEntries); err != nil {
+			return fmt.Errorf("failed to delete old extraction logs: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetExtractionLogs 返回文档的图谱提取记录，最新的在前；docID 为空时返回所有文档的记录
+// 需要通过 Options.ExtractionLog 开启记录，从未开启过时返回空列表
+func (r *LightRAG) GetExtractionLogs(ctx context.Context, docID string, limit int) ([]ExtractionLogEntry, error) {
+	c, err := r.maintenanceCollection()
+	if err != nil {
+		return nil, err
+	}
+	var exists int
+	err = c.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM information_schema.tables WHERE table_name = ?`, extractionLogTable).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check extraction log table: %w", err)
+	}
+	if exists == 0 {
+		return []ExtractionLogEntry{}, nil
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, doc_id, created_at, prompt, response, COALESCE(error, ''), entities, relationships, duration_ms, redacted
+		FROM %s WHERE (? = '' OR doc_id = ?) ORDER BY created_at DESC, id DESC LIMIT ?
+	`, extractionLogTable)
+	rows, err := c.db.QueryContext(ctx, query, docID, docID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query extraction logs: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []ExtractionLogEntry{}
+	for rows.Next() {
+		var e ExtractionLogEntry
+		if err := rows.Scan(&e.ID, &e.DocID, &e.CreatedAt, &e.Prompt, &e.Response, &e.Error,
+			&e.Entities, &e.Relationships, &e.DurationMs, &e.Redacted); err != nil {
+			return nil, fmt.Errorf("failed to scan extraction log: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
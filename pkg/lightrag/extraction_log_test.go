@@ -0,0 +1,84 @@
+package lightrag
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	duckdb_driver "github.com/mozhou-tech/sqlite-ai-driver/pkg/duckdb-driver"
+)
+
+func TestRedactPII(t *testing.T) {
+	input := "联系 zhang.san@example.com 或 13812345678，身份证 11010519491231002X，密钥 sk-abcdefghijklmnop1234"
+	got := RedactPII(input)
+	for _, leaked := range []string{"zhang.san@example.com", "13812345678", "11010519491231002X", "sk-abcdefghijklmnop1234"} {
+		if strings.Contains(got, leaked) {
+			t.Errorf("%q not redacted: %s", leaked, got)
+		}
+	}
+	if !strings.Contains(got, "[EMAIL]") || !strings.Contains(got, "[PHONE]") || !strings.Contains(got, "[ID]") || !strings.Contains(got, "[KEY]") {
+		t.Errorf("unexpected redaction: %s", got)
+	}
+}
+
+func TestExtractionLog(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("duckdb", duckdb_driver.INDEX_DB_FILE)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	db.Exec(`DROP TABLE IF EXISTS ` + extractionLogTable)
+	t.Cleanup(func() {
+		db.Exec(`DROP TABLE IF EXISTS ` + extractionLogTable)
+		db.Close()
+	})
+	rag := &LightRAG{initialized: true, docs: &duckdbCollection{db: db}}
+
+	// 未开启记录时返回空列表
+	entries, err := rag.GetExtractionLogs(ctx, "doc1", 0)
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("expected no entries before logging is enabled, got %v, %v", entries, err)
+	}
+
+	logger, err := newExtractionLogger(ctx, db, ExtractionLogConfig{Redact: RedactPII, MaxEntries: 2})
+	if err != nil {
+		t.Fatalf("newExtractionLogger failed: %v", err)
+	}
+	logger.record(ctx, &ExtractionLogEntry{DocID: "doc1", Prompt: "mail a@example.com", Response: `{"entities": []}`})
+	logger.record(ctx, &ExtractionLogEntry{DocID: "doc2", Prompt: "p", Error: "failed to parse extraction result"})
+	logger.record(ctx, &ExtractionLogEntry{DocID: "doc1", Prompt: "second", Response: `{}`, Entities: 3, Relationships: 1})
+
+	entries, err = rag.GetExtractionLogs(ctx, "doc1", 0)
+	if err != nil {
+		t.Fatalf("GetExtractionLogs failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Prompt != "second" || entries[0].Entities != 3 {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+	if entries[1].Prompt != "mail [EMAIL]" || !entries[1].Redacted {
+		t.Errorf("prompt should be redacted: %+v", entries[1])
+	}
+	if all, _ := rag.GetExtractionLogs(ctx, "", 0); len(all) != 3 || all[1].Error == "" {
+		t.Errorf("unexpected entries for all documents: %+v", all)
+	}
+
+	// 超出 MaxEntries 的记录被删除
+	if err := logger.prune(ctx); err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+	if all, _ := rag.GetExtractionLogs(ctx, "", 0); len(all) != 2 || all[1].DocID != "doc2" {
+		t.Errorf("expected the 2 newest entries after prune, got %+v", all)
+	}
+
+	// 超出 MaxAge 的记录被删除
+	db.Exec(`UPDATE `+extractionLogTable+` SET created_at = ? WHERE doc_id = 'doc2'`, time.Now().Add(-48*time.Hour))
+	logger.config = ExtractionLogConfig{MaxAge: 24 * time.Hour}
+	if err := logger.prune(ctx); err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+	if entries, _ := rag.GetExtractionLogs(ctx, "doc2", 0); len(entries) != 0 {
+		t.Errorf("expected expired entries to be deleted, got %+v", entries)
+	}
+}
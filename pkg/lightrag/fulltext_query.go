@@ -0,0 +1,371 @@
+package lightrag
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// 全文查询语法（FulltextSearchOptions.Syntax）：
+//
+//	golang channel          相邻的词都要匹配（隐式 AND）
+//	"worker pool"           短语，按顺序连续出现
+//	go OR rust              任意一个匹配
+//	go AND NOT java, go -java  排除
+//	(go OR rust) AND memory 括号分组
+//	gorout*                 前缀匹配
+//	title:golang, source:"Go Blog"  只在字段中匹配：content、id、类型化列或元数据字段
+//
+// AND、OR、NOT 需要大写，小写时作为普通的词；英文词按单词边界匹配且不区分大小写，中文按子串匹配
+
+// ftsNode 查询语法树的节点
+type ftsNode interface {
+	// sql 返回匹配条件及其参数
+	sql(columns map[string]string) (string, []any)
+}
+
+type ftsAnd struct{ children []ftsNode }
+type ftsOr struct{ children []ftsNode }
+type ftsNot struct{ child ftsNode }
+
+// ftsTerm 词、短语或前缀，field 为空时在 content 中匹配
+type ftsTerm struct {
+	field  string
+	text   string
+	prefix bool
+}
+
+func (n ftsAnd) sql(columns map[string]string) (string, []any) {
+	return joinFTSNodes(n.children, " AND ", columns)
+}
+
+func (n ftsOr) sql(columns map[string]string) (string, []any) {
+	return joinFTSNodes(n.children, " OR ", columns)
+}
+
+func (n ftsNot) sql(columns map[string]string) (string, []any) {
+	clause, args := n.child.sql(columns)
+	return "NOT " + clause, args
+}
+
+func joinFTSNodes(nodes []ftsNode, sep string, columns map[string]string) (string, []any) {
+	clauses := make([]string, len(nodes))
+	var args []any
+	for i, node := range nodes {
+		clause, nodeArgs := node.sql(columns)
+		clauses[i] = clause
+		args = append(args, nodeArgs...)
+	}
+	return "(" + strings.Join(clauses, sep) + ")", args
+}
+
+func (t ftsTerm) sql(columns map[string]string) (string, []any) {
+	target, args := "content", []any(nil)
+	switch {
+	case t.field == "" || t.field == "content" || t.field == "id":
+		if t.field == "id" {
+			target = "id"
+		}
+	case columns[t.field] != "":
+		target = fmt.Sprintf("CAST(%s AS VARCHAR)", t.field)
+	default:
+		target, args = "json_extract_string(metadata, ?)", []any{jsonPointer(t.field)}
+	}
+
+	// 字段不存在时按不匹配处理，NOT 之后仍然成立
+	if pattern, ok := t.wordPattern(); ok {
+		return fmt.Sprintf("COALESCE(regexp_matches(%s, ?), false)", target), append(args, pattern)
+	}
+	like := "%" + escapeLike(t.text) + "%"
+	return fmt.Sprintf("COALESCE(%s ILIKE ? ESCAPE '\\', false)", target), append(args, like)
+}
+
+// wordPattern 英文词和短语按单词边界匹配，返回 RE2 正则；包含中文等没有单词边界的文字时返回 false
+func (t ftsTerm) wordPattern() (string, bool) {
+	for _, r := range t.text {
+		if r >= utf8.RuneSelf {
+			return "", false
+		}
+	}
+	words := strings.Fields(t.text)
+	if len(words) == 0 {
+		return "", false
+	}
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = regexp.QuoteMeta(w)
+	}
+	pattern := "(?i)"
+	if isWordChar(words[0][0]) {
+		pattern += `\b`
+	}
+	pattern += strings.Join(quoted, `\s+`)
+	last := words[len(words)-1]
+	if !t.prefix && isWordChar(last[len(last)-1]) {
+		pattern += `\b`
+	}
+	return pattern, true
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// escapeLike 转义 LIKE 中的通配符，配合 ESCAPE '\' 使用
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// rankTerms 返回不在 NOT 之下、在 content 中匹配的词，用于 BM25 排序
+func rankTerms(node ftsNode) []string {
+	switch n := node.(type) {
+	case ftsAnd:
+		var terms []string
+		for _, child := range n.children {
+			terms = append(terms, rankTerms(child)...)
+		}
+		return terms
+	case ftsOr:
+		var terms []string
+		for _, child := range n.children {
+			terms = append(terms, rankTerms(child)...)
+		}
+		return terms
+	case ftsTerm:
+		if n.field == "" || n.field == "content" {
+			return []string{n.text}
+		}
+	}
+	return nil
+}
+
+// hasPositiveTerm 检查查询中是否有不在 NOT 之下的词，只有排除条件的查询会匹配几乎所有文档
+func hasPositiveTerm(node ftsNode) bool {
+	switch n := node.(type) {
+	case ftsAnd:
+		for _, child := range n.children {
+			if hasPositiveTerm(child) {
+				return true
+			}
+		}
+	case ftsOr:
+		for _, child := range n.children {
+			if hasPositiveTerm(child) {
+				return true
+			}
+		}
+	case ftsTerm:
+		return true
+	}
+	return false
+}
+
+// ftsToken 查询的词法单元
+type ftsToken struct {
+	kind  string // word、phrase、(、)、AND、OR、NOT、-
+	text  string
+	field string
+}
+
+var ftsFieldPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// lexFulltextQuery 把查询切分为词法单元
+func lexFulltextQuery(query string) ([]ftsToken, error) {
+	var tokens []ftsToken
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, ftsToken{kind: string(r)})
+			i++
+		case r == '-' && (i == 0 || unicode.IsSpace(runes[i-1]) || runes[i-1] == '('):
+			tokens = append(tokens, ftsToken{kind: "-"})
+			i++
+		case r == '"':
+			phrase, next, err := readPhrase(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, ftsToken{kind: "phrase", text: phrase})
+			i = next
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' && runes[i] != '"' {
+				i++
+			}
+			word := string(runes[start:i])
+			if word == "AND" || word == "OR" || word == "NOT" {
+				tokens = append(tokens, ftsToken{kind: word})
+				continue
+			}
+			field, value, ok := strings.Cut(word, ":")
+			if !ok || !ftsFieldPattern.MatchString(field) || strings.HasPrefix(value, "//") {
+				tokens = append(tokens, ftsToken{kind: "word", text: word})
+				continue
+			}
+			field = strings.ToLower(field)
+			if value != "" {
+				tokens = append(tokens, ftsToken{kind: "word", text: value, field: field})
+				continue
+			}
+			// field:"短语"
+			if i >= len(runes) || runes[i] != '"' {
+				return nil, fmt.Errorf("missing value for field %s", field)
+			}
+			phrase, next, err := readPhrase(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, ftsToken{kind: "phrase", text: phrase, field: field})
+			i = next
+		}
+	}
+	return tokens, nil
+}
+
+// readPhrase 读取从 runes[start]（引号）开始的短语，返回短语和引号之后的位置
+func readPhrase(runes []rune, start int) (string, int, error) {
+	end := start + 1
+	for end < len(runes) && runes[end] != '"' {
+		end++
+	}
+	if end >= len(runes) {
+		return "", 0, fmt.Errorf("unterminated phrase at position %d", start)
+	}
+	return string(runes[start+1 : end]), end + 1, nil
+}
+
+// ftsParser 递归下降解析器：or := and (OR and)*；and := unary ([AND] unary)*；unary := (NOT|-) unary | primary
+type ftsParser struct {
+	tokens []ftsToken
+	pos    int
+}
+
+// parseFulltextQuery 按查询语法解析 query
+func parseFulltextQuery(query string) (ftsNode, error) {
+	tokens, err := lexFulltextQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty query")
+	}
+	p := &ftsParser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos].kind)
+	}
+	if !hasPositiveTerm(node) {
+		return nil, fmt.Errorf("query must contain at least one term that is not excluded")
+	}
+	return node, nil
+}
+
+func (p *ftsParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos].kind
+	}
+	return ""
+}
+
+func (p *ftsParser) parseOr() (ftsNode, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	children := []ftsNode{first}
+	for p.peek() == "OR" {
+		p.pos++
+		next, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, next)
+	}
+	if len(children) == 1 {
+		return first, nil
+	}
+	return ftsOr{children: children}, nil
+}
+
+func (p *ftsParser) parseAnd() (ftsNode, error) {
+	first, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	children := []ftsNode{first}
+	for {
+		switch p.peek() {
+		case "AND":
+			p.pos++
+		case "word", "phrase", "(", "NOT", "-":
+		default:
+			if len(children) == 1 {
+				return first, nil
+			}
+			return ftsAnd{children: children}, nil
+		}
+		next, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, next)
+	}
+}
+
+func (p *ftsParser) parseUnary() (ftsNode, error) {
+	switch p.peek() {
+	case "NOT", "-":
+		p.pos++
+		child, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return ftsNot{child: child}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *ftsParser) parsePrimary() (ftsNode, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of query")
+	}
+	tok := p.tokens[p.pos]
+	p.pos++
+	switch tok.kind {
+	case "(":
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return node, nil
+	case "phrase":
+		if strings.TrimSpace(tok.text) == "" {
+			return nil, fmt.Errorf("empty phrase")
+		}
+		return ftsTerm{field: tok.field, text: tok.text}, nil
+	case "word":
+		term := ftsTerm{field: tok.field, text: tok.text}
+		if strings.HasSuffix(term.text, "*") {
+			term.text = strings.TrimRight(term.text, "*")
+			term.prefix = true
+		}
+		if term.text == "" {
+			return nil, fmt.Errorf("wildcard without prefix")
+		}
+		return term, nil
+	}
+	return nil, fmt.Errorf("unexpected %q", tok.kind)
+}
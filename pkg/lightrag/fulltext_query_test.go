@@ -0,0 +1,107 @@
+package lightrag
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"testing"
+
+	duckdb_driver "github.com/mozhou-tech/sqlite-ai-driver/pkg/duckdb-driver"
+)
+
+func TestParseFulltextQuery(t *testing.T) {
+	node, err := parseFulltextQuery(`(go OR rust) "worker pool" -java source:"Go Blog" gorout*`)
+	if err != nil {
+		t.Fatalf("parseFulltextQuery failed: %v", err)
+	}
+	clause, args := node.sql(map[string]string{"source": "VARCHAR"})
+	want := `((COALESCE(regexp_matches(content, ?), false) OR COALESCE(regexp_matches(content, ?), false)) AND ` +
+		`COALESCE(regexp_matches(content, ?), false) AND NOT COALESCE(regexp_matches(content, ?), false) AND ` +
+		`COALESCE(regexp_matches(CAST(source AS VARCHAR), ?), false) AND COALESCE(regexp_matches(content, ?), false))`
+	if clause != want {
+		t.Errorf("clause = %s\nwant %s", clause, want)
+	}
+	if len(args) != 6 || args[2] != `(?i)\bworker\s+pool\b` || args[4] != `(?i)\bGo\s+Blog\b` || args[5] != `(?i)\bgorout` {
+		t.Errorf("unexpected args: %v", args)
+	}
+	if terms := rankTerms(node); len(terms) != 4 {
+		t.Errorf("expected go, rust, worker pool and gorout as rank terms, got %v", terms)
+	}
+
+	// 中文按子串匹配，元数据字段从 metadata 读取
+	node, err = parseFulltextQuery(`author:张三 AND 并发%`)
+	if err != nil {
+		t.Fatalf("parseFulltextQuery failed: %v", err)
+	}
+	clause, args = node.sql(nil)
+	if clause != `(COALESCE(json_extract_string(metadata, ?) ILIKE ? ESCAPE '\', false) AND COALESCE(content ILIKE ? ESCAPE '\', false))` {
+		t.Errorf("unexpected clause: %s", clause)
+	}
+	if len(args) != 3 || args[0] != "/author" || args[1] != "%张三%" || args[2] != `%并发\%%` {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	for _, query := range []string{"", "-java", `"unterminated`, "(go OR rust", "go OR", "*", "title:", "go )"} {
+		if _, err := parseFulltextQuery(query); err == nil {
+			t.Errorf("expected error for %q", query)
+		}
+	}
+}
+
+func TestFulltextSearchSyntax(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("duckdb", duckdb_driver.INDEX_DB_FILE)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	const table = "lightrag_fulltext_syntax_test"
+	db.Exec(`DROP TABLE IF EXISTS ` + table)
+	t.Cleanup(func() {
+		db.Exec(`DROP TABLE IF EXISTS ` + table)
+		db.Close()
+	})
+	if _, err := db.Exec(`CREATE TABLE ` + table + ` (id VARCHAR PRIMARY KEY, content TEXT, metadata JSON, content_tokens TEXT, state VARCHAR DEFAULT 'active', created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP)`); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	for _, row := range [][3]string{
+		{"a", "Go uses goroutines and a worker pool", `{"source": "Go Blog"}`},
+		{"b", "Rust worker threads share a pool", `{"source": "wiki"}`},
+		{"c", "Java worker pool executors", `{"source": "wiki"}`},
+		{"d", "Google search ranking", `{}`},
+	} {
+		if _, err := db.Exec(`INSERT INTO `+table+` (id, content, metadata) VALUES (?, ?, ?::JSON)`, row[0], row[1], row[2]); err != nil {
+			t.Fatalf("failed to insert document: %v", err)
+		}
+	}
+	search := &duckdbFulltextSearch{db: db, tableName: table}
+
+	for _, tc := range []struct {
+		query string
+		want  []string
+	}{
+		{`"worker pool"`, []string{"a", "c"}},
+		{`"worker pool" -java`, []string{"a"}},
+		{`go OR rust`, []string{"a", "b"}},
+		{`goroutine*`, []string{"a"}},
+		{`go`, []string{"a"}},
+		{`source:wiki AND NOT java`, []string{"b"}},
+		{`source:"go blog" OR google`, []string{"a", "d"}},
+	} {
+		results, err := search.FindWithScores(ctx, tc.query, FulltextSearchOptions{Limit: 10, Syntax: true})
+		if err != nil {
+			t.Fatalf("FindWithScores(%q) failed: %v", tc.query, err)
+		}
+		var ids []string
+		for _, r := range results {
+			ids = append(ids, r.Document.ID())
+		}
+		sort.Strings(ids)
+		if len(ids) != len(tc.want) || (len(ids) > 0 && ids[0] != tc.want[0]) || (len(ids) > 1 && ids[1] != tc.want[1]) {
+			t.Errorf("FindWithScores(%q) = %v, want %v", tc.query, ids, tc.want)
+		}
+	}
+
+	if _, err := search.FindWithScores(ctx, `"worker`, FulltextSearchOptions{Syntax: true}); err == nil {
+		t.Error("expected error for invalid query")
+	}
+}
@@ -0,0 +1,299 @@
+package lightrag
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+//go:embed graphui/index.html
+var graphUIFiles embed.FS
+
+// defaultGraphUINodes 图谱界面首次加载时最多显示的实体数，按关系数从多到少选取
+const defaultGraphUINodes = 300
+
+// EntityChunk 实体出现的分块
+type EntityChunk struct {
+	ID       string         `json:"id"`
+	Content  string         `json:"content"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// EntityChunks 返回实体出现的分块（实体的 APPEARS_IN 链接指向的文档），limit <= 0 时返回全部
+func (r *LightRAG) EntityChunks(ctx context.Context, name string, limit int) ([]EntityChunk, error) {
+	if r == nil {
+		return nil, fmt.Errorf("LightRAG instance is nil")
+	}
+	if !r.initialized {
+		return nil, fmt.Errorf("storages not initialized")
+	}
+	if r.graph == nil {
+		return nil, fmt.Errorf("graph database not available")
+	}
+	ids, err := r.graph.GetNeighbors(ctx, name, "APPEARS_IN")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chunks of entity %s: %w", name, err)
+	}
+	sort.Strings(ids)
+	if limit > 0 && len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	chunks := make([]EntityChunk, 0, len(ids))
+	for _, id := range ids {
+		chunk := EntityChunk{ID: id}
+		if r.docs != nil {
+			if doc, err := r.docs.FindByID(ctx, id); err == nil && doc != nil {
+				data := doc.Data()
+				chunk.Content, _ = data["content"].(string)
+				chunk.Metadata = make(map[string]any)
+				for k, v := range data {
+					if k != "id" && k != "content" && k != "embedding" && !strings.HasPrefix(k, "_") {
+						chunk.Metadata[k] = v
+					}
+				}
+			}
+		}
+		chunks = append(chunks, chunk)
+	}
+	return chunks, nil
+}
+
+// GraphUIHandler 返回知识图谱可视化界面的 http.Handler，可以挂载到已有的服务上（需要去掉路径前缀）
+//
+//	GET /                          界面（力导向图）
+//	GET /api/graph?limit=300       关系数最多的 limit 个实体及其之间的关系
+//	GET /api/search?q=长城&limit=20 按名称搜索实体
+//	GET /api/subgraph?node=长城&depth=1 实体的邻域
+//	GET /api/entity?name=长城       实体的类型、描述和出现的分块
+func (r *LightRAG) GraphUIHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, req *http.Request) {
+		page, err := graphUIFiles.ReadFile("graphui/index.html")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(page)
+	})
+	mux.HandleFunc("GET /api/graph", func(w http.ResponseWriter, req *http.Request) {
+		data, err := r.ExportFullGraph(req.Context())
+		if err != nil {
+			writeGraphUIError(w, err)
+			return
+		}
+		limit := queryInt(req, "limit", defaultGraphUINodes)
+		total := len(data.Entities)
+		writeGraphUIJSON(w, map[string]any{
+			"graph":          topEntities(data, limit),
+			"total_entities": total,
+			"truncated":      limit > 0 && total > limit,
+		})
+	})
+	mux.HandleFunc("GET /api/search", func(w http.ResponseWriter, req *http.Request) {
+		data, err := r.ExportFullGraph(req.Context())
+		if err != nil {
+			writeGraphUIError(w, err)
+			return
+		}
+		writeGraphUIJSON(w, map[string]any{
+			"entities": searchEntities(data, req.URL.Query().Get("q"), queryInt(req, "limit", 20)),
+		})
+	})
+	mux.HandleFunc("GET /api/subgraph", func(w http.ResponseWriter, req *http.Request) {
+		node := req.URL.Query().Get("node")
+		if node == "" {
+			http.Error(w, "node is required", http.StatusBadRequest)
+			return
+		}
+		data, err := r.GetSubgraph(req.Context(), node, min(queryInt(req, "depth", 1), 3))
+		if err != nil {
+			writeGraphUIError(w, err)
+			return
+		}
+		writeGraphUIJSON(w, map[string]any{"graph": tidySubgraph(data, node)})
+	})
+	mux.HandleFunc("GET /api/entity", func(w http.ResponseWriter, req *http.Request) {
+		name := req.URL.Query().Get("name")
+		if name == "" {
+			http.Error(w, "name is required", http.StatusBadRequest)
+			return
+		}
+		chunks, err := r.EntityChunks(req.Context(), name, queryInt(req, "limit", 50))
+		if err != nil {
+			writeGraphUIError(w, err)
+			return
+		}
+		entity := Entity{Name: name}
+		if types, err := r.graph.GetNeighbors(req.Context(), name, "TYPE"); err == nil && len(types) > 0 {
+			entity.Type = types[0]
+		}
+		if descriptions, err := r.graph.GetNeighbors(req.Context(), name, "DESCRIPTION"); err == nil {
+			entity.Description = strings.Join(descriptions, "\n")
+		}
+		writeGraphUIJSON(w, map[string]any{"entity": entity, "chunks": chunks})
+	})
+	return mux
+}
+
+// ServeGraphUI 在 addr（如 ":8090"）上启动知识图谱可视化界面，阻塞直到服务出错
+// 界面只读，不会修改图谱；没有认证，不要监听在公网地址上
+func (r *LightRAG) ServeGraphUI(addr string) error {
+	if r == nil || !r.initialized || r.graph == nil {
+		return fmt.Errorf("graph database not available")
+	}
+	server := &http.Server{Addr: addr, Handler: r.GraphUIHandler(), ReadHeaderTimeout: 10 * time.Second}
+	logrus.WithField("addr", addr).Info("Graph UI listening")
+	return server.ListenAndServe()
+}
+
+// topEntities 返回关系数最多的 limit 个实体和它们之间的关系，limit <= 0 时返回全部
+func topEntities(data *GraphData, limit int) *GraphData {
+	if limit <= 0 || len(data.Entities) <= limit {
+		return data
+	}
+	degree := make(map[string]int)
+	for _, rel := range data.Relationships {
+		degree[rel.Source]++
+		degree[rel.Target]++
+	}
+	entities := append([]Entity(nil), data.Entities...)
+	sort.SliceStable(entities, func(i, j int) bool {
+		if degree[entities[i].Name] != degree[entities[j].Name] {
+			return degree[entities[i].Name] > degree[entities[j].Name]
+		}
+		return entities[i].Name < entities[j].Name
+	})
+	entities = entities[:limit]
+
+	kept := make(map[string]bool, len(entities))
+	for _, e := range entities {
+		kept[e.Name] = true
+	}
+	result := &GraphData{Entities: entities, Relationships: make([]Relationship, 0)}
+	for _, rel := range data.Relationships {
+		if kept[rel.Source] && kept[rel.Target] {
+			result.Relationships = append(result.Relationships, rel)
+		}
+	}
+	return result
+}
+
+// searchEntities 返回名称包含 q 的实体（不区分大小写），名称以 q 开头的排在前面
+func searchEntities(data *GraphData, q string, limit int) []Entity {
+	q = strings.ToLower(strings.TrimSpace(q))
+	matches := make([]Entity, 0)
+	if q == "" {
+		return matches
+	}
+	for _, e := range data.Entities {
+		if strings.Contains(strings.ToLower(e.Name), q) {
+			matches = append(matches, e)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		pi := strings.HasPrefix(strings.ToLower(matches[i].Name), q)
+		pj := strings.HasPrefix(strings.ToLower(matches[j].Name), q)
+		if pi != pj {
+			return pi
+		}
+		return len(matches[i].Name) < len(matches[j].Name)
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// tidySubgraph GetSubgraph 把 TYPE 和 DESCRIPTION 也当作关系返回，这里把它们合并到实体上，并去掉类型和描述节点；
+// 深度大于 1 时经由类型节点到达的实体（同类型的所有实体）与 root 之间没有实际的关系，也一并去掉
+func tidySubgraph(data *GraphData, root string) *GraphData {
+	attributes := make(map[string]*Entity)
+	for _, rel := range data.Relationships {
+		if rel.Relation != "TYPE" && rel.Relation != "DESCRIPTION" {
+			continue
+		}
+		e, ok := attributes[rel.Source]
+		if !ok {
+			e = &Entity{Name: rel.Source}
+			attributes[rel.Source] = e
+		}
+		if rel.Relation == "TYPE" {
+			e.Type = rel.Target
+		} else if e.Description == "" {
+			e.Description = rel.Target
+		} else {
+			e.Description += "\n" + rel.Target
+		}
+	}
+
+	// 只保留去掉类型和描述之后仍与 root 连通的部分
+	adjacent := make(map[string][]string)
+	for _, rel := range data.Relationships {
+		if rel.Relation != "TYPE" && rel.Relation != "DESCRIPTION" {
+			adjacent[rel.Source] = append(adjacent[rel.Source], rel.Target)
+			adjacent[rel.Target] = append(adjacent[rel.Target], rel.Source)
+		}
+	}
+	connected := map[string]bool{root: true}
+	queue := []string{root}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, next := range adjacent[node] {
+			if !connected[next] {
+				connected[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	result := &GraphData{Entities: make([]Entity, 0, len(connected)), Relationships: make([]Relationship, 0, len(data.Relationships))}
+	for _, e := range data.Entities {
+		if !connected[e.Name] {
+			continue
+		}
+		if attr, ok := attributes[e.Name]; ok {
+			e.Type, e.Description = attr.Type, attr.Description
+		}
+		result.Entities = append(result.Entities, e)
+	}
+	for _, rel := range data.Relationships {
+		if rel.Relation != "TYPE" && rel.Relation != "DESCRIPTION" && connected[rel.Source] {
+			result.Relationships = append(result.Relationships, rel)
+		}
+	}
+	return result
+}
+
+func queryInt(req *http.Request, name string, def int) int {
+	v, err := strconv.Atoi(req.URL.Query().Get(name))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+func writeGraphUIJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		logrus.WithError(err).Debug("Failed to write graph UI response")
+	}
+}
+
+func writeGraphUIError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
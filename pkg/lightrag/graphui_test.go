@@ -0,0 +1,75 @@
+package lightrag
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGraphUIHelpers(t *testing.T) {
+	data := &GraphData{
+		Entities: []Entity{{Name: "Go"}, {Name: "Google"}, {Name: "Rust"}, {Name: "Gopher"}, {Name: "Mozilla"}},
+		Relationships: []Relationship{
+			{Source: "Go", Target: "Google", Relation: "CREATED_BY"},
+			{Source: "Gopher", Target: "Go", Relation: "MASCOT_OF"},
+			{Source: "Rust", Target: "Mozilla", Relation: "CREATED_BY"},
+		},
+	}
+
+	top := topEntities(data, 2)
+	if len(top.Entities) != 2 || top.Entities[0].Name != "Go" || len(top.Relationships) != 1 {
+		t.Errorf("unexpected top entities: %+v", top)
+	}
+	if all := topEntities(data, 0); len(all.Entities) != 5 {
+		t.Errorf("expected all entities without limit, got %d", len(all.Entities))
+	}
+
+	matches := searchEntities(data, "GO", 10)
+	if len(matches) != 3 || matches[0].Name != "Go" || matches[2].Name != "Google" && matches[2].Name != "Gopher" {
+		t.Errorf("unexpected matches: %+v", matches)
+	}
+	if matches := searchEntities(data, "o", 1); len(matches) != 1 {
+		t.Errorf("expected limit to apply, got %+v", matches)
+	}
+
+	// depth 2 的子图经由类型节点到达了 Rust
+	subgraph := &GraphData{
+		Entities: []Entity{{Name: "Go"}, {Name: "Google"}, {Name: "LANGUAGE"}, {Name: "A language"}, {Name: "Rust"}},
+		Relationships: []Relationship{
+			{Source: "Go", Target: "Google", Relation: "CREATED_BY"},
+			{Source: "Go", Target: "LANGUAGE", Relation: "TYPE"},
+			{Source: "Go", Target: "A language", Relation: "DESCRIPTION"},
+			{Source: "Rust", Target: "LANGUAGE", Relation: "TYPE"},
+		},
+	}
+	tidy := tidySubgraph(subgraph, "Go")
+	if len(tidy.Entities) != 2 || len(tidy.Relationships) != 1 {
+		t.Fatalf("unexpected tidy subgraph: %+v", tidy)
+	}
+	if tidy.Entities[0].Type != "LANGUAGE" || tidy.Entities[0].Description != "A language" {
+		t.Errorf("expected type and description to be merged into the entity: %+v", tidy.Entities[0])
+	}
+}
+
+func TestGraphUIHandler(t *testing.T) {
+	handler := New(Options{}).GraphUIHandler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "知识图谱") {
+		t.Errorf("unexpected index response: %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/subgraph", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without node, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/entity?name=Go", nil))
+	if rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), "not initialized") {
+		t.Errorf("expected error before initialization, got %d %s", rec.Code, rec.Body.String())
+	}
+}
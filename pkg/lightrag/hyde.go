@@ -0,0 +1,83 @@
+package lightrag
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// queryEmbedding 返回向量检索使用的查询向量
+// 开启 QueryParam.UseHyDE 时嵌入 LLM 生成的假设回答（HyDE），HyDEFuseQuery 时再与查询的向量融合；
+// 未配置 LLM 或生成失败时记录日志并使用查询的向量
+func (r *LightRAG) queryEmbedding(ctx context.Context, query string, param QueryParam) ([]float64, error) {
+	if !param.UseHyDE || r.llm == nil {
+		return r.embedder.Embed(ctx, query)
+	}
+	hypothetical, err := r.hypotheticalAnswer(ctx, query)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to generate hypothetical answer, using query embedding")
+		return r.embedder.Embed(ctx, query)
+	}
+	emb, err := r.embedder.Embed(ctx, hypothetical)
+	if err != nil {
+		return nil, err
+	}
+	if !param.HyDEFuseQuery {
+		return emb, nil
+	}
+	queryEmb, err := r.embedder.Embed(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return fuseEmbeddings(emb, queryEmb), nil
+}
+
+// hypotheticalAnswer 让 LLM 为查询写一段假设的回答
+func (r *LightRAG) hypotheticalAnswer(ctx context.Context, query string) (string, error) {
+	prompt, err := GetHyDEPrompt(ctx, query)
+	if err != nil {
+		return "", fmt.Errorf("failed to get HyDE prompt: %w", err)
+	}
+	answer, err := r.llm.Complete(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate hypothetical answer: %w", err)
+	}
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return "", fmt.Errorf("empty hypothetical answer")
+	}
+	logrus.WithFields(logrus.Fields{
+		"query":        query,
+		"hypothetical": answer,
+	}).Debug("Generated hypothetical answer")
+	return answer, nil
+}
+
+// fuseEmbeddings 把两个向量归一化后取平均，维度不同时返回 a
+func fuseEmbeddings(a, b []float64) []float64 {
+	if len(a) != len(b) {
+		return a
+	}
+	na, nb := vectorNorm(a), vectorNorm(b)
+	fused := make([]float64, len(a))
+	for i := range a {
+		if na > 0 {
+			fused[i] += a[i] / na / 2
+		}
+		if nb > 0 {
+			fused[i] += b[i] / nb / 2
+		}
+	}
+	return fused
+}
+
+func vectorNorm(v []float64) float64 {
+	var sum float64
+	for _, x := range v {
+		sum += x * x
+	}
+	return math.Sqrt(sum)
+}
@@ -0,0 +1,56 @@
+package lightrag
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestQueryEmbedding(t *testing.T) {
+	ctx := context.Background()
+	var prompts []string
+	llm := &FlexibleLLM{ResponseFunc: func(prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		if strings.Contains(prompt, "broken") {
+			return "", fmt.Errorf("llm unavailable")
+		}
+		return "  Concurrency in go uses goroutines.  ", nil
+	}}
+	rag := &LightRAG{llm: llm, embedder: keywordEmbedder{}}
+
+	// 不开启 HyDE 时直接嵌入查询，不调用 LLM
+	emb, err := rag.queryEmbedding(ctx, "what is concurrency", QueryParam{})
+	if err != nil || fmt.Sprint(emb) != "[0 1]" || len(prompts) != 0 {
+		t.Errorf("expected query embedding, got %v, %v, %d prompts", emb, err, len(prompts))
+	}
+
+	// 假设回答包含 "go"，向量与查询不同
+	emb, err = rag.queryEmbedding(ctx, "what is concurrency", QueryParam{UseHyDE: true})
+	if err != nil || fmt.Sprint(emb) != "[1 0]" {
+		t.Errorf("expected hypothetical answer embedding, got %v, %v", emb, err)
+	}
+	if len(prompts) != 1 || !strings.Contains(prompts[0], "what is concurrency") {
+		t.Errorf("unexpected prompts: %q", prompts)
+	}
+
+	emb, err = rag.queryEmbedding(ctx, "what is concurrency", QueryParam{UseHyDE: true, HyDEFuseQuery: true})
+	if err != nil || fmt.Sprint(emb) != "[0.5 0.5]" {
+		t.Errorf("expected fused embedding, got %v, %v", emb, err)
+	}
+
+	// 生成失败时回退到查询的向量
+	emb, err = rag.queryEmbedding(ctx, "broken", QueryParam{UseHyDE: true})
+	if err != nil || fmt.Sprint(emb) != "[0 1]" {
+		t.Errorf("expected fallback to query embedding, got %v, %v", emb, err)
+	}
+}
+
+func TestFuseEmbeddings(t *testing.T) {
+	if got := fuseEmbeddings([]float64{3, 0}, []float64{0, 2}); fmt.Sprint(got) != "[0.5 0.5]" {
+		t.Errorf("fuseEmbeddings = %v", got)
+	}
+	if got := fuseEmbeddings([]float64{1, 0}, []float64{1}); fmt.Sprint(got) != "[1 0]" {
+		t.Errorf("expected first embedding on dimension mismatch, got %v", got)
+	}
+}
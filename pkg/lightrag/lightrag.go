@@ -2,27 +2,28 @@ package lightrag
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/sego"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
 )
 
 // ExtractionStats 知识图谱提取统计信息
 type ExtractionStats struct {
-	TotalExtractions   int       // 总提取任务数
-	SuccessCount       int       // 成功提取数
-	FailureCount       int       // 失败提取数
-	TotalEntities      int       // 提取的实体总数
-	TotalRelationships int       // 提取的关系总数
-	StartTime          time.Time // 开始时间
-	EndTime            time.Time // 结束时间
-	MaxConcurrency     int       // 最大并发数
+	TotalExtractions     int       // 总提取任务数
+	SuccessCount         int       // 成功提取数
+	FailureCount         int       // 失败提取数
+	TotalEntities        int       // 提取的实体总数
+	TotalRelationships   int       // 提取的关系总数
+	StartTime            time.Time // 开始时间
+	EndTime              time.Time // 结束时间
+	MaxConcurrency       int       // 最大并发数
+	DescriptionSummaries int       // 合并实体描述的次数
 }
 
 // LightRAG 基于新的driver实现的 LightRAG
@@ -31,6 +32,34 @@ type LightRAG struct {
 	workingDir string
 	embedder   Embedder
 	llm        LLM
+	// llmModel LLM 的模型名称，是提取版本的一部分
+	llmModel string
+
+	skipMigrations bool
+
+	extractionLogConfig *ExtractionLogConfig
+	extractionLog       *extractionLogger
+
+	// summarizeAt 实体描述达到该数量时在提取后合并，summarizing 记录正在合并的实体
+	summarizeAt int
+	summarizing sync.Map
+
+	// vectorMetric 向量检索使用的相似度
+	vectorMetric VectorMetric
+
+	// documentColumns 文档表的类型化列
+	documentColumns []Column
+	// fulltextAnalyzer 全文检索的分词器，为空时沿用集合设置
+	fulltextAnalyzer Analyzer
+
+	// queryCache 查询结果缓存，为 nil 时不缓存
+	queryCache *queryCache
+
+	// profiles 按查询模式覆盖的检索参数
+	profiles map[QueryMode]RetrievalProfile
+
+	// events 生命周期事件，订阅者包括 Options.Webhooks 和 Subscribe 添加的处理函数
+	events *eventBus
 
 	// 集合
 	docs Collection
@@ -47,6 +76,7 @@ type LightRAG struct {
 	// 统计信息
 	stats      ExtractionStats
 	statsMutex sync.RWMutex // 保护统计信息的读写
+	usage      *usageMeter
 }
 
 // Options LightRAG 配置选项
@@ -55,6 +85,37 @@ type Options struct {
 	Embedder         Embedder
 	LLM              LLM
 	MaxConcurrentLLM int // 最大并发 LLM 请求数，默认为 10
+	// SkipMigrations InitializeStorages 时不自动执行结构升级，只检查数据库是否由更新版本的包创建
+	// 需要先确认升级内容（如 sqlite-ai db migrate -dry-run）时使用，之后调用 Migrate 执行
+	SkipMigrations bool
+	// CallPolicy LLM 和 Embedder 调用的超时、重试和熔断策略（如 DefaultCallPolicy()），为 nil 时直接调用
+	// 服务暂时不可用（429、5xx）时重试，避免文档的图谱提取和向量生成因此失败
+	CallPolicy *CallPolicy
+	// ExtractionLog 开启后把图谱提取的提示词和响应保存到 lightrag_extraction_log 表，通过 GetExtractionLogs 按文档查询
+	ExtractionLog *ExtractionLogConfig
+	// SummarizeDescriptionsAt 实体的 DESCRIPTION 边达到该数量时，提取后用 LLM 合并为一条，0 表示不自动合并
+	// 也可以定期调用 SummarizeEntityDescriptions
+	SummarizeDescriptionsAt int
+	// Pricing 模型价格，用于在 GetUsageStats 中估算费用，为 nil 时只统计 token 数
+	Pricing *Pricing
+	// VectorMetric 向量检索的相似度（MetricCosine、MetricDotProduct、MetricL2），为空时使用余弦相似度
+	// 应与 embedding 模型训练时使用的相似度一致
+	VectorMetric VectorMetric
+	// RetrievalProfiles 按查询模式覆盖检索参数（各阶段候选数、图遍历深度、向量分数下限、全文权重、重排），
+	// 未配置的模式和值为 0 的字段使用 DefaultRetrievalProfile
+	RetrievalProfiles map[QueryMode]RetrievalProfile
+	// DocumentColumns 文档表的类型化列（如 source VARCHAR、page INTEGER），从文档的同名元数据字段填充，
+	// 按这些字段过滤时直接比较列而不是解析 metadata，也可以为其建立索引
+	DocumentColumns []Column
+	// FulltextAnalyzer 全文检索的分词器：AnalyzerSego（默认，中文分词）、AnalyzerSimple（英文，小写、去停用词和词干提取）
+	// 或 AnalyzerICU（按 Unicode 文字类别切分，中日韩文字按 bigram）；保存在集合设置中，为空时沿用上次的设置，
+	// 更换分词器时重新分词所有文档
+	FulltextAnalyzer Analyzer
+	// QueryCache 开启查询结果缓存：相同的查询（不区分大小写和空白）、模式和过滤条件直接返回上次的检索结果或回答，
+	// 文档集合或图谱有写入时失效；为 nil 时不缓存
+	QueryCache *QueryCacheConfig
+	// Webhooks 接收文档导入、向量生成、图谱提取和维护任务完成等事件的地址，也可以用 Subscribe 在进程内订阅
+	Webhooks []WebhookConfig
 }
 
 // New 创建 LightRAG 实例
@@ -62,11 +123,32 @@ func New(opts Options) *LightRAG {
 	if opts.MaxConcurrentLLM <= 0 {
 		opts.MaxConcurrentLLM = 100
 	}
+	if opts.CallPolicy != nil {
+		opts.LLM = WrapLLM(opts.LLM, opts.CallPolicy.Middlewares()...)
+		opts.Embedder = WrapEmbedder(opts.Embedder, opts.CallPolicy.Middlewares()...)
+	}
+	// 用量统计在最外层，重试的每次请求都计入
+	usage := newUsageMeter(opts.Pricing)
+	events := newEventBus()
+	for _, webhook := range opts.Webhooks {
+		events.subscribe(webhookHandler(webhook), webhook.Events)
+	}
 	return &LightRAG{
-		workingDir: opts.WorkingDir,
-		embedder:   opts.Embedder,
-		llm:        opts.LLM,
-		llmSem:     make(chan struct{}, opts.MaxConcurrentLLM),
+		workingDir:          opts.WorkingDir,
+		embedder:            usage.wrapEmbedder(opts.Embedder),
+		llm:                 usage.wrapLLM(opts.LLM),
+		llmModel:            llmModelName(opts.LLM),
+		usage:               usage,
+		skipMigrations:      opts.SkipMigrations,
+		extractionLogConfig: opts.ExtractionLog,
+		summarizeAt:         opts.SummarizeDescriptionsAt,
+		profiles:            opts.RetrievalProfiles,
+		vectorMetric:        opts.VectorMetric,
+		documentColumns:     opts.DocumentColumns,
+		fulltextAnalyzer:    opts.FulltextAnalyzer,
+		queryCache:          newQueryCache(opts.QueryCache),
+		events:              events,
+		llmSem:              make(chan struct{}, opts.MaxConcurrentLLM),
 		stats: ExtractionStats{
 			MaxConcurrency: opts.MaxConcurrentLLM,
 			StartTime:      time.Now(),
@@ -90,6 +172,7 @@ func (r *LightRAG) InitializeStorages(ctx context.Context) error {
 			Enabled: true,
 			Backend: "cayley",
 		},
+		SkipMigrations: r.skipMigrations,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create database: %w", err)
@@ -101,12 +184,27 @@ func (r *LightRAG) InitializeStorages(ctx context.Context) error {
 	docSchema := Schema{
 		PrimaryKey: "id",
 		RevField:   "_rev",
+		Columns:    r.documentColumns,
 	}
 	docs, err := db.Collection(ctx, "lightrag_documents", docSchema)
 	if err != nil {
 		return fmt.Errorf("failed to create documents collection: %w", err)
 	}
 	r.docs = docs
+	if c, ok := docs.(*duckdbCollection); ok {
+		c.events = r.events
+	}
+
+	if r.extractionLogConfig != nil {
+		c, ok := docs.(*duckdbCollection)
+		if !ok {
+			return fmt.Errorf("extraction log requires a duckdb collection")
+		}
+		r.extractionLog, err = newExtractionLogger(ctx, c.db, *r.extractionLogConfig)
+		if err != nil {
+			return err
+		}
+	}
 
 	// 使用 errgroup 并行初始化搜索索引
 	g, _ := errgroup.WithContext(ctx)
@@ -115,6 +213,7 @@ func (r *LightRAG) InitializeStorages(ctx context.Context) error {
 	g.Go(func() error {
 		fulltext, err := AddFulltextSearch(docs, FulltextSearchConfig{
 			Identifier: "docs_fulltext",
+			Analyzer:   r.fulltextAnalyzer,
 			DocToString: func(doc map[string]any) string {
 				content, _ := doc["content"].(string)
 				return content
@@ -134,11 +233,13 @@ func (r *LightRAG) InitializeStorages(ctx context.Context) error {
 				Identifier: "docs_vector",
 				DocToEmbedding: func(doc map[string]any) ([]float64, error) {
 					content, _ := doc["content"].(string)
+					id, _ := doc["id"].(string)
 					// 使用 context.Background() 避免 context canceled 错误
 					// 后台 worker 处理时，原始的 context 可能已被取消
-					return r.embedder.Embed(context.Background(), content)
+					return r.embedder.Embed(withDocumentUsage(context.Background(), id), content)
 				},
 				Dimensions: r.embedder.Dimensions(),
+				Metric:     r.vectorMetric,
 			})
 			if err != nil {
 				return fmt.Errorf("failed to add vector search: %w", err)
@@ -187,6 +288,7 @@ func (r *LightRAG) Insert(ctx context.Context, text string) error {
 	if err != nil {
 		return fmt.Errorf("failed to insert document: %w", err)
 	}
+	r.events.publish(EventDocumentsIngested, map[string]any{"ids": []string{doc["id"].(string)}, "count": 1})
 
 	// 提取并存储实体与关系
 	if r.llm != nil && r.graph != nil {
@@ -257,43 +359,100 @@ func (r *LightRAG) DeleteDocument(ctx context.Context, id string) error {
 	return r.docs.Delete(ctx, id)
 }
 
+// ArchiveDocuments 归档文档：文档不再参与检索，但保留内容、向量和图谱数据，可以用 UnarchiveDocuments 恢复
+func (r *LightRAG) ArchiveDocuments(ctx context.Context, ids ...string) (int, error) {
+	if err := r.checkDocs(); err != nil {
+		return 0, err
+	}
+	return r.docs.Archive(ctx, ids...)
+}
+
+// UnarchiveDocuments 把归档或软删除的文档恢复为 active
+func (r *LightRAG) UnarchiveDocuments(ctx context.Context, ids ...string) (int, error) {
+	if err := r.checkDocs(); err != nil {
+		return 0, err
+	}
+	return r.docs.Unarchive(ctx, ids...)
+}
+
+// SoftDeleteDocuments 软删除文档：与归档一样保留数据，但默认的文档列表中也不再显示
+func (r *LightRAG) SoftDeleteDocuments(ctx context.Context, ids ...string) (int, error) {
+	if err := r.checkDocs(); err != nil {
+		return 0, err
+	}
+	return r.docs.SoftDelete(ctx, ids...)
+}
+
+// SearchFulltext 直接全文搜索文档，不经过图谱和向量检索
+// opts.Syntax 为 true 时支持短语、AND / OR / NOT、前缀和字段限定，见 FulltextSearchOptions
+func (r *LightRAG) SearchFulltext(ctx context.Context, query string, opts FulltextSearchOptions) ([]FulltextSearchResult, error) {
+	if err := r.checkDocs(); err != nil {
+		return nil, err
+	}
+	if r.fulltext == nil {
+		return nil, fmt.Errorf("fulltext search is not initialized")
+	}
+	return r.fulltext.FindWithScores(ctx, query, opts)
+}
+
+// checkDocs 检查文档集合是否可用
+func (r *LightRAG) checkDocs() error {
+	if r == nil {
+		return fmt.Errorf("LightRAG instance is nil")
+	}
+	if !r.initialized {
+		return fmt.Errorf("storages not initialized")
+	}
+	if r.docs == nil {
+		return fmt.Errorf("documents collection is not initialized")
+	}
+	return nil
+}
+
 func (r *LightRAG) extractQueryKeywords(ctx context.Context, query string) (*QueryKeywords, error) {
 	if r == nil {
 		return nil, fmt.Errorf("LightRAG instance is nil")
 	}
 	if r.llm == nil {
-		return &QueryKeywords{}, nil
+		// 未配置 LLM 时使用本地关键词提取：TF-IDF 偏向具体实体，TextRank 偏向概括性主题
+		return extractLocalQueryKeywords(query), nil
 	}
 
 	promptStr, err := GetQueryEntityPrompt(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get query entity prompt: %w", err)
 	}
-	response, err := r.llm.Complete(ctx, promptStr)
+	response, err := completeJSON(ctx, r.llm, promptStr, queryKeywordsSchema)
 	if err != nil {
 		return nil, err
 	}
 
 	logrus.WithField("raw_response", response).Debug("LLM response for query keywords")
 
-	jsonStr := response
-	idxStart := strings.Index(jsonStr, "{")
-	idxEnd := strings.LastIndex(jsonStr, "}")
-	if idxStart == -1 || idxEnd == -1 || idxEnd < idxStart {
-		return nil, fmt.Errorf("no JSON object found in response: %s", response)
-	}
-	jsonStr = jsonStr[idxStart : idxEnd+1]
-
 	var keywords QueryKeywords
-	if err := json.Unmarshal([]byte(jsonStr), &keywords); err != nil {
-		logrus.WithField("jsonStr", jsonStr).Error("Failed to parse query keywords")
+	if err := decodeJSONResponse(response, &keywords); err != nil {
+		logrus.WithField("response", response).Error("Failed to parse query keywords")
 		return nil, fmt.Errorf("failed to parse query keywords: %w", err)
 	}
 
 	return &keywords, nil
 }
 
-func (r *LightRAG) extractAndStore(ctx context.Context, text string, docID string) error {
+// localKeywordTopK 本地关键词提取时每一层级返回的关键词数量
+const localKeywordTopK = 5
+
+// extractLocalQueryKeywords 不经过 LLM，使用分词器从查询中提取低层级和高层级关键词
+func extractLocalQueryKeywords(query string) *QueryKeywords {
+	keywords := &QueryKeywords{
+		LowLevel: sego.ExtractKeywords(query, localKeywordTopK),
+	}
+	for _, kw := range sego.ExtractKeywordsWithWeights(query, localKeywordTopK, sego.KeywordTextRank) {
+		keywords.HighLevel = append(keywords.HighLevel, kw.Word)
+	}
+	return keywords
+}
+
+func (r *LightRAG) extractAndStore(ctx context.Context, text string, docID string) (err error) {
 	// 安全检查：防止 nil 指针
 	if r == nil {
 		return fmt.Errorf("LightRAG instance is nil")
@@ -309,6 +468,20 @@ func (r *LightRAG) extractAndStore(ctx context.Context, text string, docID strin
 	r.statsMutex.Lock()
 	r.stats.TotalExtractions++
 	r.statsMutex.Unlock()
+	ctx = withDocumentUsage(ctx, docID)
+
+	var result ExtractionResult
+	defer func() {
+		if err != nil {
+			r.events.publish(EventExtractionFailed, map[string]any{"doc_id": docID, "error": err.Error()})
+		} else {
+			r.events.publish(EventExtractionCompleted, map[string]any{
+				"doc_id":        docID,
+				"entities":      len(result.Entities),
+				"relationships": len(result.Relationships),
+			})
+		}
+	}()
 
 	promptStr, err := GetExtractionPrompt(ctx, text)
 	if err != nil {
@@ -317,38 +490,29 @@ func (r *LightRAG) extractAndStore(ctx context.Context, text string, docID strin
 		r.statsMutex.Unlock()
 		return fmt.Errorf("failed to get extraction prompt: %w", err)
 	}
-	response, err := r.llm.Complete(ctx, promptStr)
+	start := time.Now()
+	response, err := completeJSON(ctx, r.llm, promptStr, extractionSchema)
+	logEntry := &ExtractionLogEntry{DocID: docID, Prompt: promptStr, Response: response, DurationMs: time.Since(start).Milliseconds()}
 	if err != nil {
 		r.statsMutex.Lock()
 		r.stats.FailureCount++
 		r.statsMutex.Unlock()
+		logEntry.Error = err.Error()
+		r.extractionLog.record(ctx, logEntry)
 		return err
 	}
 
-	// 尝试解析 JSON，增强健壮性
-	jsonStr := response
-	idxStart := strings.Index(jsonStr, "{")
-	idxEnd := strings.LastIndex(jsonStr, "}")
-	if idxStart == -1 || idxEnd == -1 || idxEnd < idxStart {
-		// 尝试检查是否是纯数组（有些 LLM 可能只返回数组）
-		idxStart = strings.Index(jsonStr, "[")
-		idxEnd = strings.LastIndex(jsonStr, "]")
-		if idxStart == -1 || idxEnd == -1 || idxEnd < idxStart {
-			r.statsMutex.Lock()
-			r.stats.FailureCount++
-			r.statsMutex.Unlock()
-			return fmt.Errorf("no JSON object or array found in response: %s", response)
-		}
-	}
-	jsonStr = jsonStr[idxStart : idxEnd+1]
-
-	var result ExtractionResult
-	if err := json.Unmarshal([]byte(jsonStr), &result); err != nil {
+	if err := decodeJSONResponse(response, &result); err != nil {
 		r.statsMutex.Lock()
 		r.stats.FailureCount++
 		r.statsMutex.Unlock()
-		return fmt.Errorf("failed to parse extraction result: %w, response: %s", err, response)
+		logEntry.Error = fmt.Sprintf("failed to parse extraction result: %v", err)
+		r.extractionLog.record(ctx, logEntry)
+		return fmt.Errorf("failed to parse extraction result: %w", err)
 	}
+	logEntry.Entities = len(result.Entities)
+	logEntry.Relationships = len(result.Relationships)
+	r.extractionLog.record(ctx, logEntry)
 
 	logrus.WithFields(logrus.Fields{
 		"doc_id":              docID,
@@ -356,6 +520,9 @@ func (r *LightRAG) extractAndStore(ctx context.Context, text string, docID strin
 		"relationships_count": len(result.Relationships),
 	}).Info("Extracted graph data from document")
 
+	// 人工锁定的实体类型、描述和人工删除的关系不会被提取结果覆盖
+	curation := r.loadExtractionCuration(ctx, &result)
+
 	// 批量存储实体链接和关系（如果 driver 支持批量操作，这里可以进一步优化）
 	// 目前 driver 接口是单条操作
 	for _, entity := range result.Entities {
@@ -369,17 +536,17 @@ func (r *LightRAG) extractAndStore(ctx context.Context, text string, docID strin
 		}
 
 		// 存储实体类型和描述
-		if entity.Type != "" {
+		if entity.Type != "" && !curation.isLocked(entity.Name, "TYPE") {
 			_ = r.graph.Link(ctx, entity.Name, "TYPE", entity.Type)
 		}
-		if entity.Description != "" {
+		if entity.Description != "" && !curation.isLocked(entity.Name, "DESCRIPTION") {
 			_ = r.graph.Link(ctx, entity.Name, "DESCRIPTION", entity.Description)
 		}
 	}
 
 	// 存储关系
 	for _, rel := range result.Relationships {
-		if rel.Source == "" || rel.Target == "" {
+		if rel.Source == "" || rel.Target == "" || curation.isDeleted(rel.Source, rel.Relation, rel.Target) {
 			continue
 		}
 		err := r.graph.Link(ctx, rel.Source, rel.Relation, rel.Target)
@@ -388,6 +555,10 @@ func (r *LightRAG) extractAndStore(ctx context.Context, text string, docID strin
 		}
 	}
 
+	if r.summarizeAt > 0 {
+		r.summarizeDescriptionsAfterExtraction(ctx, result.Entities)
+	}
+
 	// 更新统计：成功提取
 	r.statsMutex.Lock()
 	r.stats.SuccessCount++
@@ -395,6 +566,7 @@ func (r *LightRAG) extractAndStore(ctx context.Context, text string, docID strin
 	r.stats.TotalRelationships += len(result.Relationships)
 	r.statsMutex.Unlock()
 
+	r.recordExtractionVersion(ctx, docID)
 	return nil
 }
 
@@ -411,6 +583,9 @@ func (r *LightRAG) InsertBatch(ctx context.Context, documents []map[string]any)
 	}
 
 	for i := range documents {
+		if err := normalizeDocumentACL(documents[i]); err != nil {
+			return nil, fmt.Errorf("document at index %d: %w", i, err)
+		}
 		if id, ok := documents[i]["id"]; !ok || id == "" {
 			documents[i]["id"] = fmt.Sprintf("%d-%d", time.Now().UnixNano(), i)
 		}
@@ -437,6 +612,12 @@ func (r *LightRAG) InsertBatch(ctx context.Context, documents []map[string]any)
 	ids := make([]string, 0, len(res))
 	for _, doc := range res {
 		ids = append(ids, doc.ID())
+	}
+	if len(ids) > 0 {
+		r.events.publish(EventDocumentsIngested, map[string]any{"ids": ids, "count": len(ids)})
+	}
+
+	for _, doc := range res {
 		// 批量插入时也进行图谱提取，使用信号量控制并发
 		if r.llm != nil && r.graph != nil {
 			content, _ := doc.Data()["content"].(string)
@@ -461,11 +642,32 @@ func (r *LightRAG) InsertBatch(ctx context.Context, documents []map[string]any)
 	return ids, nil
 }
 
-// Query 执行查询
+// Query 执行查询，开启 Options.QueryCache 时相同的查询直接返回缓存的回答
 func (r *LightRAG) Query(ctx context.Context, query string, param QueryParam) (string, error) {
 	if r == nil {
 		return "", fmt.Errorf("LightRAG instance is nil")
 	}
+	ctx = r.usage.startQuery(ctx)
+
+	key := queryCacheKey("query", query, param)
+	version, cacheable := r.cacheVersion()
+	if cacheable {
+		if entry, ok := r.queryCache.get(key, version); ok {
+			return entry.answer, nil
+		}
+	}
+	answer, err := r.answer(ctx, query, param)
+	if err != nil {
+		return "", err
+	}
+	if cacheable {
+		r.queryCache.put(key, version, nil, answer)
+	}
+	return answer, nil
+}
+
+// answer 检索并用 LLM 生成回答，未配置 LLM 时返回拼接的上下文
+func (r *LightRAG) answer(ctx context.Context, query string, param QueryParam) (string, error) {
 	results, err := r.Retrieve(ctx, query, param)
 	if err != nil {
 		return "", err
@@ -474,6 +676,9 @@ func (r *LightRAG) Query(ctx context.Context, query string, param QueryParam) (s
 	if len(results) == 0 {
 		return "No relevant information found.", nil
 	}
+	if param.ExpandNeighbors > 0 {
+		results = r.expandNeighbors(ctx, results, param.ExpandNeighbors, param.AllowedLabels)
+	}
 
 	// 简单的上下文拼接
 	contextText := ""
@@ -513,7 +718,7 @@ func (r *LightRAG) Query(ctx context.Context, query string, param QueryParam) (s
 	return contextText, nil
 }
 
-// Retrieve 执行检索
+// Retrieve 执行检索，开启 Options.QueryCache 时相同的检索直接返回缓存的结果
 func (r *LightRAG) Retrieve(ctx context.Context, query string, param QueryParam) ([]SearchResult, error) {
 	if r == nil {
 		return nil, fmt.Errorf("LightRAG instance is nil")
@@ -521,15 +726,46 @@ func (r *LightRAG) Retrieve(ctx context.Context, query string, param QueryParam)
 	if !r.initialized {
 		return nil, fmt.Errorf("storages not initialized")
 	}
+	ctx = r.usage.startQuery(ctx)
 
+	profile := r.retrievalProfile(param.Mode)
 	if param.Limit <= 0 {
-		param.Limit = 5
+		param.Limit = profile.TopK
+	}
+
+	key := queryCacheKey("retrieve", query, param)
+	version, cacheable := r.cacheVersion()
+	if cacheable {
+		if entry, ok := r.queryCache.get(key, version); ok {
+			return append([]SearchResult(nil), entry.results...), nil
+		}
 	}
 
+	results, err := r.retrieve(ctx, query, param, profile)
+	if err != nil {
+		return nil, err
+	}
+	if profile.Rerank {
+		results = r.rerankResults(ctx, query, results)
+	}
+	if len(results) > param.Limit {
+		results = results[:param.Limit]
+	}
+	if cacheable {
+		r.queryCache.put(key, version, append([]SearchResult(nil), results...), "")
+	}
+	return results, nil
+}
+
+// retrieve 按查询模式检索，param.Limit 已经设置
+func (r *LightRAG) retrieve(ctx context.Context, query string, param QueryParam, profile RetrievalProfile) ([]SearchResult, error) {
 	var rawResults []FulltextSearchResult
 	var recalledTriples []Relationship
 	var err error
 
+	// 访问标签作为过滤条件下推到所有检索路径
+	param.Filters = withAllowedLabels(param.Filters, param.AllowedLabels)
+
 	switch param.Mode {
 	case ModeVector, ModeNaive:
 		if r.vector == nil {
@@ -538,12 +774,12 @@ func (r *LightRAG) Retrieve(ctx context.Context, query string, param QueryParam)
 		if r.embedder == nil {
 			return nil, fmt.Errorf("embedder is not available")
 		}
-		emb, err := r.embedder.Embed(ctx, query)
+		emb, err := r.queryEmbedding(ctx, query, param)
 		if err != nil {
 			return nil, err
 		}
 		vecResults, err := r.vector.Search(ctx, emb, VectorSearchOptions{
-			Limit:    param.Limit,
+			Limit:    profile.vectorLimit(param.Limit),
 			Selector: param.Filters,
 		})
 		if err != nil {
@@ -562,8 +798,9 @@ func (r *LightRAG) Retrieve(ctx context.Context, query string, param QueryParam)
 			return nil, fmt.Errorf("fulltext search not available")
 		}
 		rawResults, err = r.fulltext.FindWithScores(ctx, query, FulltextSearchOptions{
-			Limit:    param.Limit,
-			Selector: param.Filters,
+			Limit:          profile.fulltextLimit(param.Limit),
+			Selector:       param.Filters,
+			ExpandSynonyms: param.ExpandSynonyms,
 		})
 		if err != nil {
 			logrus.WithError(err).Warn("Fulltext search failed")
@@ -574,10 +811,13 @@ func (r *LightRAG) Retrieve(ctx context.Context, query string, param QueryParam)
 		if r.graph == nil {
 			return nil, fmt.Errorf("graph search not available")
 		}
-		keywords, err := r.extractQueryKeywords(ctx, query)
+		keywords, err := r.queryKeywords(ctx, query, param)
 		if err != nil {
 			logrus.WithError(err).Warn("Failed to extract query keywords, falling back to hybrid")
-			return r.Retrieve(ctx, query, QueryParam{Mode: ModeHybrid, Limit: param.Limit})
+			// 保留过滤条件和访问标签，只更换模式
+			fallback := param
+			fallback.Mode = ModeHybrid
+			return r.Retrieve(ctx, query, fallback)
 		}
 
 		// 根据 LightRAG 论文，Local search 使用 low-level keywords（具体实体）
@@ -588,7 +828,7 @@ func (r *LightRAG) Retrieve(ctx context.Context, query string, param QueryParam)
 			"high_level": keywords.HighLevel, // 同时显示 high_level 以便调试和理解分类
 		}).Info("Performing local search")
 
-		return r.retrieveByKeywords(ctx, keywords.LowLevel, param)
+		return r.retrieveByKeywords(ctx, keywords.LowLevel, param, profile)
 
 	case ModeGraph:
 		if r.graph == nil {
@@ -597,7 +837,7 @@ func (r *LightRAG) Retrieve(ctx context.Context, query string, param QueryParam)
 
 		// Graph 模式：纯知识图谱查询，不使用向量或全文搜索
 		// 1. 获取关键词
-		keywords, err := r.extractQueryKeywords(ctx, query)
+		keywords, err := r.queryKeywords(ctx, query, param)
 		if err != nil {
 			return nil, fmt.Errorf("failed to extract keywords: %w", err)
 		}
@@ -614,7 +854,7 @@ func (r *LightRAG) Retrieve(ctx context.Context, query string, param QueryParam)
 		relMap := make(map[string]bool)
 
 		for _, k := range allKeywords {
-			subgraph, _ := r.GetSubgraph(ctx, k, 2)
+			subgraph, _ := r.GetSubgraph(ctx, k, profile.GraphDepth)
 			if subgraph != nil {
 				for _, e := range subgraph.Entities {
 					if !entityMap[e.Name] {
@@ -632,7 +872,7 @@ func (r *LightRAG) Retrieve(ctx context.Context, query string, param QueryParam)
 			}
 		}
 
-		recalledTriples = graphData.Relationships
+		recalledTriples = r.visibleTriples(ctx, graphData.Relationships, param.Filters)
 
 		// 3. 根据召回的实体找到关联的文档
 		docIDMap := make(map[string]bool)
@@ -653,7 +893,7 @@ func (r *LightRAG) Retrieve(ctx context.Context, query string, param QueryParam)
 
 		count := 0
 		for id := range docIDMap {
-			if count >= param.Limit*2 {
+			if count >= profile.graphDocLimit(param.Limit) {
 				break
 			}
 			docID := id
@@ -687,10 +927,13 @@ func (r *LightRAG) Retrieve(ctx context.Context, query string, param QueryParam)
 		if r.graph == nil {
 			return nil, fmt.Errorf("graph search not available")
 		}
-		keywords, err := r.extractQueryKeywords(ctx, query)
+		keywords, err := r.queryKeywords(ctx, query, param)
 		if err != nil {
 			logrus.WithError(err).Warn("Failed to extract query keywords, falling back to hybrid")
-			return r.Retrieve(ctx, query, QueryParam{Mode: ModeHybrid, Limit: param.Limit})
+			// 保留过滤条件和访问标签，只更换模式
+			fallback := param
+			fallback.Mode = ModeHybrid
+			return r.Retrieve(ctx, query, fallback)
 		}
 
 		// 根据 LightRAG 论文，Global search 使用 high-level keywords（抽象主题）
@@ -701,19 +944,19 @@ func (r *LightRAG) Retrieve(ctx context.Context, query string, param QueryParam)
 			"high_level": keywords.HighLevel, // Global search 使用 high_level keywords
 		}).Info("Performing global search")
 
-		return r.retrieveByKeywords(ctx, keywords.HighLevel, param)
+		return r.retrieveByKeywords(ctx, keywords.HighLevel, param, profile)
 	case ModeHybrid:
 		// 论文中的 Hybrid：结合 Local 和 Global
-		keywords, err := r.extractQueryKeywords(ctx, query)
+		keywords, err := r.queryKeywords(ctx, query, param)
 		if err != nil {
 			// 回退到朴素混合搜索（向量 + 全文）
-			return r.retrieveNaiveHybrid(ctx, query, param)
+			return r.retrieveNaiveHybrid(ctx, query, param, profile)
 		}
 
 		// 如果关键词列表都为空，也回退到朴素混合搜索
 		if len(keywords.LowLevel) == 0 && len(keywords.HighLevel) == 0 {
 			logrus.Warn("No keywords extracted, falling back to naive hybrid search")
-			return r.retrieveNaiveHybrid(ctx, query, param)
+			return r.retrieveNaiveHybrid(ctx, query, param, profile)
 		}
 
 		logrus.WithFields(logrus.Fields{
@@ -723,35 +966,35 @@ func (r *LightRAG) Retrieve(ctx context.Context, query string, param QueryParam)
 		}).Info("Performing hybrid search (local + global)")
 
 		// 分别进行 local 和 global 检索并合并结果
-		localResults, _ := r.retrieveByKeywords(ctx, keywords.LowLevel, param)
-		globalResults, _ := r.retrieveByKeywords(ctx, keywords.HighLevel, param)
+		localResults, _ := r.retrieveByKeywords(ctx, keywords.LowLevel, param, profile)
+		globalResults, _ := r.retrieveByKeywords(ctx, keywords.HighLevel, param, profile)
 
 		// 如果两个结果都为空，回退到朴素混合搜索
 		if len(localResults) == 0 && len(globalResults) == 0 {
 			logrus.Warn("No results from keyword-based search, falling back to naive hybrid search")
-			return r.retrieveNaiveHybrid(ctx, query, param)
+			return r.retrieveNaiveHybrid(ctx, query, param, profile)
 		}
 
 		// 合并结果
-		return r.mergeSearchResults(localResults, globalResults, param.Limit), nil
+		return r.mergeSearchResults(localResults, globalResults, param.Limit, profile.MaxRedundancy), nil
 	case ModeMix:
 		// Mix 模式：结合知识图谱和向量检索
 		// 根据 Python 版本，mix 模式整合知识图谱和向量检索
 		if r.graph == nil {
 			return nil, fmt.Errorf("graph search not available")
 		}
-		keywords, err := r.extractQueryKeywords(ctx, query)
+		keywords, err := r.queryKeywords(ctx, query, param)
 		if err != nil {
 			// 如果提取关键词失败，回退到向量搜索
 			if r.vector == nil || r.embedder == nil {
 				return nil, fmt.Errorf("vector search not available")
 			}
-			emb, err := r.embedder.Embed(ctx, query)
+			emb, err := r.queryEmbedding(ctx, query, param)
 			if err != nil {
 				return nil, err
 			}
 			vecResults, err := r.vector.Search(ctx, emb, VectorSearchOptions{
-				Limit:    param.Limit,
+				Limit:    profile.vectorLimit(param.Limit),
 				Selector: param.Filters,
 			})
 			if err != nil {
@@ -777,12 +1020,12 @@ func (r *LightRAG) Retrieve(ctx context.Context, query string, param QueryParam)
 			if r.vector == nil || r.embedder == nil {
 				return nil, fmt.Errorf("vector search not available")
 			}
-			emb, err := r.embedder.Embed(ctx, query)
+			emb, err := r.queryEmbedding(ctx, query, param)
 			if err != nil {
 				return nil, err
 			}
 			vecResults, err := r.vector.Search(ctx, emb, VectorSearchOptions{
-				Limit:    param.Limit,
+				Limit:    profile.vectorLimit(param.Limit),
 				Selector: param.Filters,
 			})
 			if err != nil {
@@ -809,7 +1052,7 @@ func (r *LightRAG) Retrieve(ctx context.Context, query string, param QueryParam)
 
 		// Mix 模式使用所有关键词（low-level + high-level）进行检索
 		// retrieveByKeywords 方法已经实现了图谱 + 向量的组合检索
-		results, err := r.retrieveByKeywords(ctx, allKeywords, param)
+		results, err := r.retrieveByKeywords(ctx, allKeywords, param, profile)
 		if err != nil {
 			return nil, err
 		}
@@ -819,12 +1062,12 @@ func (r *LightRAG) Retrieve(ctx context.Context, query string, param QueryParam)
 			if r.vector == nil || r.embedder == nil {
 				return results, nil // 返回空结果而不是错误
 			}
-			emb, err := r.embedder.Embed(ctx, query)
+			emb, err := r.queryEmbedding(ctx, query, param)
 			if err != nil {
 				return results, nil // 返回空结果而不是错误
 			}
 			vecResults, err := r.vector.Search(ctx, emb, VectorSearchOptions{
-				Limit:    param.Limit,
+				Limit:    profile.vectorLimit(param.Limit),
 				Selector: param.Filters,
 			})
 			if err != nil {
@@ -845,7 +1088,7 @@ func (r *LightRAG) Retrieve(ctx context.Context, query string, param QueryParam)
 		if r.fulltext == nil {
 			return nil, fmt.Errorf("fulltext search not available")
 		}
-		rawResults, err = r.fulltext.FindWithScores(ctx, query, FulltextSearchOptions{Limit: param.Limit})
+		rawResults, err = r.fulltext.FindWithScores(ctx, query, FulltextSearchOptions{Limit: profile.fulltextLimit(param.Limit), Selector: param.Filters, ExpandSynonyms: param.ExpandSynonyms})
 		if err != nil {
 			return nil, err
 		}
@@ -973,12 +1216,55 @@ func (r *LightRAG) ExportGraph(ctx context.Context, docID string) (*GraphData, e
 	return result, nil
 }
 
+// ImportGraph 导入 ExportGraph 导出的知识图谱，写入实体的类型、描述和实体间的关系
+// 导出数据不包含实体到文档的 APPEARS_IN 链接，导入的实体不会关联到文档
+func (r *LightRAG) ImportGraph(ctx context.Context, data *GraphData) error {
+	if r == nil {
+		return fmt.Errorf("LightRAG instance is nil")
+	}
+	if !r.initialized {
+		return fmt.Errorf("storages not initialized")
+	}
+	if r.graph == nil {
+		return fmt.Errorf("graph database not available")
+	}
+	if data == nil {
+		return nil
+	}
+
+	for _, entity := range data.Entities {
+		if entity.Name == "" {
+			continue
+		}
+		if entity.Type != "" {
+			if err := r.graph.Link(ctx, entity.Name, "TYPE", entity.Type); err != nil {
+				return fmt.Errorf("failed to import entity %s: %w", entity.Name, err)
+			}
+		}
+		if entity.Description != "" {
+			if err := r.graph.Link(ctx, entity.Name, "DESCRIPTION", entity.Description); err != nil {
+				return fmt.Errorf("failed to import entity %s: %w", entity.Name, err)
+			}
+		}
+	}
+
+	for _, rel := range data.Relationships {
+		if rel.Source == "" || rel.Target == "" || rel.Relation == "" {
+			continue
+		}
+		if err := r.graph.Link(ctx, rel.Source, rel.Relation, rel.Target); err != nil {
+			return fmt.Errorf("failed to import relationship %s -[%s]-> %s: %w", rel.Source, rel.Relation, rel.Target, err)
+		}
+	}
+	return nil
+}
+
 // SearchGraph 仅从图谱检索实体和关系
 func (r *LightRAG) SearchGraph(ctx context.Context, query string) (*GraphData, error) {
 	return r.SearchGraphWithDepth(ctx, query, 1)
 }
 
-// SearchGraphWithDepth 从图谱检索实体和关系，支持指定搜索深度
+// SearchGraphWithDepth 从图谱检索实体和关系，支持指定搜索深度，depth <= 0 时使用检索参数中的 GraphDepth
 func (r *LightRAG) SearchGraphWithDepth(ctx context.Context, query string, depth int) (*GraphData, error) {
 	if r == nil {
 		return nil, fmt.Errorf("LightRAG instance is nil")
@@ -1012,6 +1298,10 @@ func (r *LightRAG) SearchGraphWithDepth(ctx context.Context, query string, depth
 	g, gCtx := errgroup.WithContext(ctx)
 
 	mode, _ := ctx.Value("rag_mode").(QueryMode)
+	profile := r.retrievalProfile(mode)
+	if depth <= 0 {
+		depth = profile.GraphDepth
+	}
 
 	for _, e := range entities {
 		entityName := e
@@ -1051,14 +1341,14 @@ func (r *LightRAG) SearchGraphWithDepth(ctx context.Context, query string, depth
 				mu.Lock()
 				allEntities[entityName] = true
 				mu.Unlock()
-			} else if mode != ModeGraph && r.vector != nil && r.embedder != nil {
+			} else if profile.ExpansionTopK > 0 && r.vector != nil && r.embedder != nil {
 				// 如果没找到直接关联，通过向量搜索寻找最相关的文档，从而发现相关实体
 				emb, err := r.embedder.Embed(gCtx, entityName)
 				if err == nil {
-					vecResults, err := r.vector.Search(gCtx, emb, VectorSearchOptions{Limit: 3})
+					vecResults, err := r.vector.Search(gCtx, emb, VectorSearchOptions{Limit: profile.ExpansionTopK})
 					if err == nil {
 						for _, res := range vecResults {
-							if res.Score < 0.75 {
+							if res.Score < profile.VectorScoreFloor {
 								continue
 							}
 
@@ -1254,7 +1544,8 @@ func (r *LightRAG) Wait() {
 }
 
 // WaitForEmbeddings 等待所有向量嵌入完成（最多等待 maxWait 时间）
-// embedding worker 每 2 秒检查一次，每次最多处理 100 个文档，速率限制是每秒 5 次
+// 每 500ms 检查一次文档表中 pending 和 processing 状态的文档数，没有时立即返回；
+// 超时不返回错误，可以用 GetEmbeddingStatus 查看剩余和失败的文档
 func (r *LightRAG) WaitForEmbeddings(ctx context.Context, maxWait time.Duration) error {
 	if r == nil || !r.initialized || r.docs == nil {
 		return nil
@@ -1262,66 +1553,34 @@ func (r *LightRAG) WaitForEmbeddings(ctx context.Context, maxWait time.Duration)
 	if r.vector == nil || r.embedder == nil {
 		return nil // 没有向量搜索，不需要等待
 	}
-
-	// 使用类型断言访问底层的 duckdbCollection
-	type pendingCounter interface {
-		countPendingEmbeddings(ctx context.Context) (int, error)
-	}
-
-	collection, ok := r.docs.(pendingCounter)
-	if !ok {
-		// 如果不是 duckdbCollection，使用简单的超时等待
-		logrus.Warn("Cannot check embedding status, using timeout-based wait")
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(maxWait):
-			return nil
-		}
+	c, err := r.maintenanceCollection()
+	if err != nil {
+		return err
 	}
 
 	deadline := time.Now().Add(maxWait)
 	ticker := time.NewTicker(500 * time.Millisecond)
 	defer ticker.Stop()
 
-	// 首先等待至少 2 秒，让 worker 有机会运行一次
-	initialWait := time.NewTimer(2 * time.Second)
-	select {
-	case <-ctx.Done():
-		initialWait.Stop()
-		return ctx.Err()
-	case <-initialWait.C:
-		// 继续
-	}
-
-	// 然后轮询检查，直到没有 pending 文档或超时
 	for {
+		pendingCount, err := c.countPendingEmbeddings(ctx)
+		if err != nil {
+			logrus.WithError(err).Debug("Failed to check pending embeddings, continuing to wait")
+		} else if pendingCount == 0 {
+			logrus.Debug("All embeddings completed")
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			logrus.WithField("pending_count", pendingCount).Warn("WaitForEmbeddings timed out, some embeddings are still pending")
+			return nil // 超时了，返回 nil（不是错误）
+		}
+		logrus.WithField("pending_count", pendingCount).Debug("Still waiting for embeddings to complete")
+
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-ticker.C:
-			// 检查是否超时
-			if time.Now().After(deadline) {
-				pendingCount, _ := collection.countPendingEmbeddings(ctx)
-				if pendingCount > 0 {
-					logrus.WithField("pending_count", pendingCount).Warn("WaitForEmbeddings timed out, some embeddings are still pending")
-				}
-				return nil // 超时了，返回 nil（不是错误）
-			}
-
-			// 检查是否还有 pending 的嵌入
-			pendingCount, err := collection.countPendingEmbeddings(ctx)
-			if err != nil {
-				logrus.WithError(err).Debug("Failed to check pending embeddings, continuing to wait")
-				continue
-			}
-
-			if pendingCount == 0 {
-				logrus.Debug("All embeddings completed")
-				return nil
-			}
-
-			logrus.WithField("pending_count", pendingCount).Debug("Still waiting for embeddings to complete")
 		}
 	}
 }
@@ -1332,17 +1591,29 @@ func (r *LightRAG) GetExtractionStats() ExtractionStats {
 	defer r.statsMutex.RUnlock()
 	// 返回副本以避免竞态条件
 	return ExtractionStats{
-		TotalExtractions:   r.stats.TotalExtractions,
-		SuccessCount:       r.stats.SuccessCount,
-		FailureCount:       r.stats.FailureCount,
-		TotalEntities:      r.stats.TotalEntities,
-		TotalRelationships: r.stats.TotalRelationships,
-		StartTime:          r.stats.StartTime,
-		EndTime:            r.stats.EndTime,
-		MaxConcurrency:     r.stats.MaxConcurrency,
+		TotalExtractions:     r.stats.TotalExtractions,
+		SuccessCount:         r.stats.SuccessCount,
+		FailureCount:         r.stats.FailureCount,
+		TotalEntities:        r.stats.TotalEntities,
+		TotalRelationships:   r.stats.TotalRelationships,
+		StartTime:            r.stats.StartTime,
+		EndTime:              r.stats.EndTime,
+		MaxConcurrency:       r.stats.MaxConcurrency,
+		DescriptionSummaries: r.stats.DescriptionSummaries,
 	}
 }
 
+// GetUsageStats 获取 LLM 和 Embedder 调用的 token 用量和估算费用，按导入和查询分类汇总
+// 单次查询的用量可以通过 WithUsageTracker 获取
+func (r *LightRAG) GetUsageStats() UsageStats {
+	return r.usage.snapshot()
+}
+
+// GetDocumentUsage 获取文档导入（向量生成和图谱提取）的用量，本实例没有处理过该文档时返回 false
+func (r *LightRAG) GetDocumentUsage(docID string) (TokenUsage, bool) {
+	return r.usage.document(docID)
+}
+
 // CountAppearsInLinks 统计 APPEARS_IN 链接的数量（实体到文档的链接）
 func (r *LightRAG) CountAppearsInLinks(ctx context.Context) (int, error) {
 	if r == nil {
@@ -1374,6 +1645,8 @@ func (r *LightRAG) CountAppearsInLinks(ctx context.Context) (int, error) {
 func (r *LightRAG) FinalizeStorages(ctx context.Context) error {
 	// 等待所有后台任务完成（包括实体提取任务）
 	r.wg.Wait()
+	// 数据库关闭（embedding worker 停止）后再把剩余的事件交给订阅者
+	defer r.events.close(eventFlushTimeout)
 
 	// 等待一小段时间，确保 embedding worker 有机会完成当前正在处理的文档
 	// 注意：embedding worker 会在数据库关闭时自动停止
@@ -1395,7 +1668,7 @@ func (r *LightRAG) FinalizeStorages(ctx context.Context) error {
 	return nil
 }
 
-func (r *LightRAG) retrieveByKeywords(ctx context.Context, keywords []string, param QueryParam) ([]SearchResult, error) {
+func (r *LightRAG) retrieveByKeywords(ctx context.Context, keywords []string, param QueryParam, profile RetrievalProfile) ([]SearchResult, error) {
 	if r == nil {
 		return nil, fmt.Errorf("LightRAG instance is nil")
 	}
@@ -1415,7 +1688,7 @@ func (r *LightRAG) retrieveByKeywords(ctx context.Context, keywords []string, pa
 		keyword := kw
 		g.Go(func() error {
 			// 1. 图谱检索：查找实体及其邻居
-			subgraph, _ := r.GetSubgraph(gCtx, keyword, 1)
+			subgraph, _ := r.GetSubgraph(gCtx, keyword, profile.GraphDepth)
 			if subgraph != nil {
 				mu.Lock()
 				recalledTriples = append(recalledTriples, subgraph.Relationships...)
@@ -1439,7 +1712,7 @@ func (r *LightRAG) retrieveByKeywords(ctx context.Context, keywords []string, pa
 				emb, err := r.embedder.Embed(gCtx, keyword)
 				if err == nil {
 					vecResults, err := r.vector.Search(gCtx, emb, VectorSearchOptions{
-						Limit:    param.Limit,
+						Limit:    profile.vectorLimit(param.Limit),
 						Selector: param.Filters,
 					})
 					if err == nil {
@@ -1455,6 +1728,7 @@ func (r *LightRAG) retrieveByKeywords(ctx context.Context, keywords []string, pa
 		})
 	}
 	_ = g.Wait()
+	recalledTriples = r.visibleTriples(ctx, recalledTriples, param.Filters)
 
 	// 排序并获取文档
 	type scoredDoc struct {
@@ -1481,7 +1755,7 @@ func (r *LightRAG) retrieveByKeywords(ctx context.Context, keywords []string, pa
 	}
 	for _, sd := range sortedDocs {
 		doc, err := r.docs.FindByID(ctx, sd.id)
-		if err == nil && doc != nil {
+		if err == nil && doc != nil && matchesFilters(doc.Data(), param.Filters) {
 			content, _ := doc.Data()["content"].(string)
 			results = append(results, SearchResult{
 				ID:              sd.id,
@@ -1496,7 +1770,7 @@ func (r *LightRAG) retrieveByKeywords(ctx context.Context, keywords []string, pa
 	return results, nil
 }
 
-func (r *LightRAG) retrieveNaiveHybrid(ctx context.Context, query string, param QueryParam) ([]SearchResult, error) {
+func (r *LightRAG) retrieveNaiveHybrid(ctx context.Context, query string, param QueryParam, profile RetrievalProfile) ([]SearchResult, error) {
 	if r == nil {
 		return nil, fmt.Errorf("LightRAG instance is nil")
 	}
@@ -1513,8 +1787,9 @@ func (r *LightRAG) retrieveNaiveHybrid(ctx context.Context, query string, param
 	g.Go(func() error {
 		var err error
 		ftResults, err = r.fulltext.FindWithScores(gCtx, query, FulltextSearchOptions{
-			Limit:    param.Limit,
-			Selector: param.Filters,
+			Limit:          profile.fulltextLimit(param.Limit),
+			Selector:       param.Filters,
+			ExpandSynonyms: param.ExpandSynonyms,
 		})
 		return err
 	})
@@ -1522,13 +1797,13 @@ func (r *LightRAG) retrieveNaiveHybrid(ctx context.Context, query string, param
 	// 2. 向量搜索
 	if r.vector != nil && r.embedder != nil {
 		g.Go(func() error {
-			emb, err := r.embedder.Embed(gCtx, query)
+			emb, err := r.queryEmbedding(gCtx, query, param)
 			if err != nil {
 				return nil
 			}
 			var err2 error
 			vecResults, err2 = r.vector.Search(gCtx, emb, VectorSearchOptions{
-				Limit:    param.Limit,
+				Limit:    profile.vectorLimit(param.Limit),
 				Selector: param.Filters,
 			})
 			return err2
@@ -1545,7 +1820,7 @@ func (r *LightRAG) retrieveNaiveHybrid(ctx context.Context, query string, param
 		if res.Document == nil {
 			continue
 		}
-		score := 1.0 / float64(i+60)
+		score := profile.FulltextWeight / float64(i+60)
 		docScores[res.Document.ID()] += score
 		docMap[res.Document.ID()] = res.Document
 	}
@@ -1585,7 +1860,8 @@ func (r *LightRAG) retrieveNaiveHybrid(ctx context.Context, query string, param
 	return results, nil
 }
 
-func (r *LightRAG) mergeSearchResults(r1, r2 []SearchResult, limit int) []SearchResult {
+// mergeSearchResults 按 ID 合并去重，maxRedundancy > 0 时再去掉内容近似重复的结果
+func (r *LightRAG) mergeSearchResults(r1, r2 []SearchResult, limit int, maxRedundancy float64) []SearchResult {
 	seen := make(map[string]bool)
 	var merged []SearchResult
 
@@ -1615,6 +1891,7 @@ func (r *LightRAG) mergeSearchResults(r1, r2 []SearchResult, limit int) []Search
 	sort.Slice(merged, func(i, j int) bool {
 		return merged[i].Score > merged[j].Score
 	})
+	merged = suppressRedundant(merged, maxRedundancy)
 
 	if len(merged) > limit {
 		merged = merged[:limit]
@@ -1628,9 +1905,64 @@ func matchesFilters(docData map[string]any, filters map[string]any) bool {
 	}
 	for k, v := range filters {
 		actual, ok := docData[k]
-		if !ok || actual != v {
+		if ops, isOps := v.(map[string]any); isOps {
+			if !matchesFilterOps(actual, ok, ops) {
+				return false
+			}
+			continue
+		}
+		if !ok || !filterValueEqual(actual, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesFilterOps 在 Go 中判断字段是否满足操作符条件，语义与 selectorClause 相同
+func matchesFilterOps(actual any, exists bool, ops map[string]any) bool {
+	for op, v := range ops {
+		switch op {
+		case "$eq":
+			if !exists || !filterValueEqual(actual, v) {
+				return false
+			}
+		case "$ne":
+			if exists && filterValueEqual(actual, v) {
+				return false
+			}
+		case "$in":
+			values, _ := v.([]any)
+			found := false
+			for _, value := range values {
+				if exists && filterValueEqual(actual, value) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		case "$acl":
+			values, _ := v.([]any)
+			if !matchesACL(actual, values) {
+				return false
+			}
+		default:
 			return false
 		}
 	}
 	return true
 }
+
+// filterValueEqual 比较元数据值和过滤值，不可比较的值（数组、对象）视为不相等
+func filterValueEqual(actual, v any) bool {
+	switch actual.(type) {
+	case map[string]any, []any, []string:
+		return false
+	}
+	switch v.(type) {
+	case map[string]any, []any, []string:
+		return false
+	}
+	return actual == v
+}
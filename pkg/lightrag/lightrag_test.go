@@ -556,6 +556,124 @@ func TestLightRAG_GraphSearchAndSubgraph(t *testing.T) {
 	}
 }
 
+func TestLightRAG_ImportGraph(t *testing.T) {
+	ctx := context.Background()
+	workingDir := "./testdata/test_rag_import_graph"
+	defer os.RemoveAll(workingDir)
+
+	rag := New(Options{WorkingDir: workingDir})
+	if err := rag.InitializeStorages(ctx); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	defer rag.FinalizeStorages(ctx)
+
+	err := rag.ImportGraph(ctx, &GraphData{
+		Entities: []Entity{
+			{Name: "SQLiteAI", Type: "PROJECT", Description: "An AI driver"},
+			{Name: "Golang", Type: "LANGUAGE"},
+		},
+		Relationships: []Relationship{
+			{Source: "SQLiteAI", Target: "Golang", Relation: "BUILT_FOR"},
+			{Source: "", Target: "Golang", Relation: "IGNORED"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ImportGraph failed: %v", err)
+	}
+
+	graph, err := rag.ExportFullGraph(ctx)
+	if err != nil {
+		t.Fatalf("ExportFullGraph failed: %v", err)
+	}
+	entities := make(map[string]Entity)
+	for _, e := range graph.Entities {
+		entities[e.Name] = e
+	}
+	if e := entities["SQLiteAI"]; e.Type != "PROJECT" || e.Description != "An AI driver" {
+		t.Errorf("unexpected SQLiteAI entity: %+v", e)
+	}
+	if e := entities["Golang"]; e.Type != "LANGUAGE" {
+		t.Errorf("unexpected Golang entity: %+v", e)
+	}
+	if len(graph.Relationships) != 1 || graph.Relationships[0].Relation != "BUILT_FOR" {
+		t.Errorf("unexpected relationships: %+v", graph.Relationships)
+	}
+}
+
+func TestLightRAG_Maintenance(t *testing.T) {
+	ctx := context.Background()
+	workingDir := "./testdata/test_rag_maintenance"
+	defer os.RemoveAll(workingDir)
+
+	rag := New(Options{
+		WorkingDir: workingDir,
+		Embedder:   NewSimpleEmbedder(8),
+	})
+	if err := rag.InitializeStorages(ctx); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	defer rag.FinalizeStorages(ctx)
+
+	if _, err := rag.InsertBatch(ctx, []map[string]any{
+		{"id": "maint-1", "content": "Maintenance document about DuckDB"},
+		{"id": "maint-2", "content": "Another maintenance document"},
+	}); err != nil {
+		t.Fatalf("InsertBatch failed: %v", err)
+	}
+
+	applied, err := rag.Migrate(ctx)
+	if err != nil {
+		t.Fatalf("Migrate failed: %v", err)
+	}
+	pending, err := rag.PendingMigrations(ctx)
+	if err != nil {
+		t.Fatalf("PendingMigrations failed: %v", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("expected no pending migrations after Migrate (applied %d), got %v", len(applied), pending)
+	}
+	again, err := rag.Migrate(ctx)
+	if err != nil || len(again) != 0 {
+		t.Errorf("second Migrate should be a no-op, got %v, %v", again, err)
+	}
+
+	if n, err := rag.RebuildFulltextIndex(ctx); err != nil || n < 2 {
+		t.Errorf("RebuildFulltextIndex: got %d, %v", n, err)
+	}
+	if err := rag.Vacuum(ctx); err != nil {
+		t.Errorf("Vacuum failed: %v", err)
+	}
+	if _, err := rag.Reembed(ctx, false); err != nil {
+		t.Errorf("Reembed failed: %v", err)
+	}
+	if err := rag.WaitForEmbeddings(ctx, 30*time.Second); err != nil {
+		t.Errorf("WaitForEmbeddings failed: %v", err)
+	}
+
+	info, err := rag.Inspect(ctx)
+	if err != nil {
+		t.Fatalf("Inspect failed: %v", err)
+	}
+	if info.SchemaVersion != info.LatestSchemaVersion {
+		t.Errorf("expected schema version %d, got %d", info.LatestSchemaVersion, info.SchemaVersion)
+	}
+	if !info.Fulltext.Indexed || info.Fulltext.Untokenized != 0 {
+		t.Errorf("unexpected fulltext info: %+v", info.Fulltext)
+	}
+	if info.Embeddings.Total < 2 || info.Embeddings.Completed != info.Embeddings.Total {
+		t.Errorf("unexpected embedding coverage: %+v", info.Embeddings)
+	}
+	found := false
+	for _, table := range info.Tables {
+		if table.Name == "lightrag_documents" {
+			found = table.Rows >= 2
+		}
+	}
+	if !found {
+		t.Errorf("expected lightrag_documents in tables, got %+v", info.Tables)
+	}
+}
+
 func TestLightRAG_Retrieve_Modes_Extra(t *testing.T) {
 	ctx := context.Background()
 	workingDir := "./testdata/test_rag_modes_extra"
@@ -746,3 +864,14 @@ func TestLightRAG_Insert_NotInitialized(t *testing.T) {
 		t.Errorf("expected error for uninitialized insert, got: %v", err)
 	}
 }
+
+func TestLightRAG_ExtractQueryKeywords_NoLLM(t *testing.T) {
+	rag := &LightRAG{}
+	keywords, err := rag.extractQueryKeywords(context.Background(), "知识图谱中的实体关系抽取与检索增强生成")
+	if err != nil {
+		t.Fatalf("extract keywords failed: %v", err)
+	}
+	if len(keywords.LowLevel) == 0 || len(keywords.HighLevel) == 0 {
+		t.Errorf("expected local keywords without LLM, got: %+v", keywords)
+	}
+}
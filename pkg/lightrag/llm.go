@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -141,11 +142,30 @@ func (l *SimpleLLM) Complete(ctx context.Context, prompt string) (string, error)
 	return "Simple LLM response", nil
 }
 
+// CompleteStructured 模拟实现的响应本身就是 JSON，直接返回 Complete 的结果
+func (l *SimpleLLM) CompleteStructured(ctx context.Context, prompt string, schema *JSONSchema) (string, error) {
+	return l.Complete(ctx, prompt)
+}
+
 // OpenAIConfig OpenAI 配置
 type OpenAIConfig struct {
 	APIKey  string
 	BaseURL string
 	Model   string
+	// StrictSchema 结构化输出使用 json_schema 严格模式；默认使用兼容性更好的 json_object 模式
+	// （DashScope 等 OpenAI 兼容服务只支持后者），此时 schema 以文本形式附加在提示词末尾
+	StrictSchema bool
+}
+
+// APIError 模型服务返回的错误状态，429 和 5xx 会被 WithRetry 重试
+type APIError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // 响应头 Retry-After 要求的等待时间
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("openai API error: status %d, body: %s", e.StatusCode, e.Body)
 }
 
 // OpenAILLM OpenAI LLM 实现
@@ -170,33 +190,132 @@ func NewOpenAILLM(config *OpenAIConfig) *OpenAILLM {
 	}
 }
 
+// openAIMessage chat completions 接口的消息格式
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
 // Complete 完成提示词并返回响应
 func (l *OpenAILLM) Complete(ctx context.Context, prompt string) (string, error) {
-	url := fmt.Sprintf("%s/chat/completions", l.config.BaseURL)
+	msg, err := l.chat(ctx, map[string]interface{}{
+		"messages":    []openAIMessage{{Role: RoleUser, Content: prompt}},
+		"temperature": 0.7,
+	})
+	if err != nil {
+		return "", err
+	}
+	return msg.Content, nil
+}
 
-	reqBody := map[string]interface{}{
-		"model": l.config.Model,
-		"messages": []map[string]string{
-			{
-				"role":    "user",
-				"content": prompt,
+// CompleteStructured 使用 JSON 模式完成提示词，返回的内容是 JSON 对象
+func (l *OpenAILLM) CompleteStructured(ctx context.Context, prompt string, schema *JSONSchema) (string, error) {
+	responseFormat := map[string]interface{}{"type": "json_object"}
+	if schema != nil && l.config.StrictSchema {
+		responseFormat = map[string]interface{}{
+			"type": "json_schema",
+			"json_schema": map[string]interface{}{
+				"name":        schema.Name,
+				"description": schema.Description,
+				"schema":      schema.Schema,
+				"strict":      true,
 			},
-		},
-		"stream":      false,
+		}
+	} else if schema != nil {
+		schemaJSON, err := json.Marshal(schema.Schema)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal schema: %w", err)
+		}
+		prompt += "\n\nRespond with a single JSON object that conforms to this JSON Schema:\n" + string(schemaJSON)
+	}
+
+	msg, err := l.chat(ctx, map[string]interface{}{
+		"messages":        []openAIMessage{{Role: RoleUser, Content: prompt}},
+		"temperature":     0,
+		"response_format": responseFormat,
+	})
+	if err != nil {
+		return "", err
+	}
+	return msg.Content, nil
+}
+
+// CompleteWithTools 发送对话和可用的工具，返回模型的 assistant 消息
+func (l *OpenAILLM) CompleteWithTools(ctx context.Context, messages []Message, tools []Tool) (*Message, error) {
+	reqMessages := make([]openAIMessage, 0, len(messages))
+	for _, m := range messages {
+		msg := openAIMessage{Role: m.Role, Content: m.Content, ToolCallID: m.ToolCallID}
+		for _, call := range m.ToolCalls {
+			tc := openAIToolCall{ID: call.ID, Type: "function"}
+			tc.Function.Name = call.Name
+			tc.Function.Arguments = call.Arguments
+			msg.ToolCalls = append(msg.ToolCalls, tc)
+		}
+		reqMessages = append(reqMessages, msg)
+	}
+	reqBody := map[string]interface{}{
+		"messages":    reqMessages,
 		"temperature": 0.7,
-		"extra_body": map[string]interface{}{
-			"enable_thinking": false,
-		},
+	}
+	if len(tools) > 0 {
+		reqTools := make([]map[string]interface{}, 0, len(tools))
+		for _, tool := range tools {
+			reqTools = append(reqTools, map[string]interface{}{
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":        tool.Name,
+					"description": tool.Description,
+					"parameters":  tool.Parameters,
+				},
+			})
+		}
+		reqBody["tools"] = reqTools
+	}
+
+	msg, err := l.chat(ctx, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	result := &Message{Role: RoleAssistant, Content: msg.Content}
+	for _, call := range msg.ToolCalls {
+		result.ToolCalls = append(result.ToolCalls, ToolCall{
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: call.Function.Arguments,
+		})
+	}
+	return result, nil
+}
+
+// chat 调用 chat completions 接口，reqBody 中的 model、stream 等公共字段由此处填入
+func (l *OpenAILLM) chat(ctx context.Context, reqBody map[string]interface{}) (*openAIMessage, error) {
+	url := fmt.Sprintf("%s/chat/completions", l.config.BaseURL)
+
+	reqBody["model"] = l.config.Model
+	reqBody["stream"] = false
+	reqBody["extra_body"] = map[string]interface{}{
+		"enable_thinking": false,
 	}
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -207,30 +326,39 @@ func (l *OpenAILLM) Complete(ctx context.Context, prompt string) (string, error)
 	}).Info("Sending request to OpenAI")
 	resp, err := l.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("openai API error: status %d, body: %s", resp.StatusCode, string(body))
+		apiErr := &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			apiErr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return nil, apiErr
 	}
 
 	var result struct {
 		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
+			Message openAIMessage `json:"message"`
 		} `json:"choices"`
+		Usage *struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if result.Usage != nil {
+		ReportUsage(ctx, result.Usage.PromptTokens, result.Usage.CompletionTokens)
 	}
 
 	if len(result.Choices) == 0 {
-		return "", fmt.Errorf("no choices in response")
+		return nil, fmt.Errorf("no choices in response")
 	}
 
-	return result.Choices[0].Message.Content, nil
+	return &result.Choices[0].Message, nil
 }
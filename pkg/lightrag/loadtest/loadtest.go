@@ -0,0 +1,483 @@
+// Package loadtest 存储层的压测和性能剖析工具
+//
+// Run 生成 N 个合成文档，依次测量：分批导入的吞吐、导入结束到向量全部生成（异步 embedding 队列排空）的时间、
+// 各检索模式的查询延迟（P50 / P99）以及过程中的内存占用，输出的 Report 可以保存为 JSON，
+// 用 WriteComparison 对比不同后端（LightRAG 的 DuckDB 存储和 SQLite 基线）或不同配置（分词器、相似度、批大小等）
+package loadtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/lightrag"
+)
+
+// Target 被压测的存储后端
+type Target interface {
+	// Name 后端名称，用于报告
+	Name() string
+	// Modes 支持的检索模式
+	Modes() []lightrag.QueryMode
+	// Ingest 导入一批文档
+	Ingest(ctx context.Context, docs []map[string]any) error
+	// WaitIndexed 等待异步的索引工作（向量生成、全文索引重建）完成，返回超时后仍未完成索引的文档数
+	WaitIndexed(ctx context.Context) (int, error)
+	// Retrieve 执行一次检索
+	Retrieve(ctx context.Context, query string, param lightrag.QueryParam) ([]lightrag.SearchResult, error)
+	// Close 释放资源
+	Close() error
+}
+
+// Config 压测参数
+type Config struct {
+	// Documents 合成文档数，默认 1000
+	Documents int `json:"documents"`
+	// Words 每个文档的词数，默认 200
+	Words int `json:"words"`
+	// BatchSize 每批导入的文档数，默认 100
+	BatchSize int `json:"batch_size"`
+	// Queries 每个检索模式的查询数，默认 100
+	Queries int `json:"queries"`
+	// Concurrency 并发执行查询的数量，默认 1
+	Concurrency int `json:"concurrency"`
+	// Limit 每次检索的结果数，默认 10
+	Limit int `json:"limit"`
+	// Modes 测量的检索模式，为空时测量后端支持的所有模式
+	Modes []lightrag.QueryMode `json:"modes,omitempty"`
+	// Seed 生成文档和查询的随机种子，相同的种子生成相同的数据，便于对比
+	Seed int64 `json:"seed"`
+}
+
+func (c *Config) setDefaults() {
+	if c.Documents <= 0 {
+		c.Documents = 1000
+	}
+	if c.Words <= 0 {
+		c.Words = 200
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 100
+	}
+	if c.Queries <= 0 {
+		c.Queries = 100
+	}
+	if c.Concurrency <= 0 {
+		c.Concurrency = 1
+	}
+	if c.Limit <= 0 {
+		c.Limit = 10
+	}
+}
+
+// Report 压测报告，耗时的单位都是毫秒
+type Report struct {
+	Target string `json:"target"`
+	Config Config `json:"config"`
+	// Tuning 调用方记录的其他配置（如分词器、相似度），只用于对比时展示
+	Tuning map[string]string `json:"tuning,omitempty"`
+	Ingest IngestStats       `json:"ingest"`
+	// IndexWaitMs 导入结束到异步索引完成（embedding 队列排空）的时间
+	IndexWaitMs float64 `json:"index_wait_ms"`
+	// Unindexed 等待超时后仍未生成向量的文档数，不为 0 时 IndexWaitMs 只是等待的上限
+	Unindexed int           `json:"unindexed,omitempty"`
+	Queries   []QueryStats  `json:"queries"`
+	Memory    MemoryStats   `json:"memory"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration_ns"`
+}
+
+// IngestStats 导入的吞吐
+type IngestStats struct {
+	Documents     int     `json:"documents"`
+	DurationMs    float64 `json:"duration_ms"`
+	DocsPerSecond float64 `json:"docs_per_second"`
+	BatchP50Ms    float64 `json:"batch_p50_ms"`
+	BatchP99Ms    float64 `json:"batch_p99_ms"`
+}
+
+// QueryStats 一个检索模式的查询延迟
+type QueryStats struct {
+	Mode   lightrag.QueryMode `json:"mode"`
+	Count  int                `json:"count"`
+	Errors int                `json:"errors"`
+	P50Ms  float64            `json:"p50_ms"`
+	P99Ms  float64            `json:"p99_ms"`
+	MeanMs float64            `json:"mean_ms"`
+	QPS    float64            `json:"qps"`
+	// FirstError 第一次检索失败的原因
+	FirstError string `json:"first_error,omitempty"`
+}
+
+// MemoryStats 压测过程中 Go 堆的占用，不包括 DuckDB 等 C 库分配的内存
+type MemoryStats struct {
+	PeakHeapMB   float64 `json:"peak_heap_mb"`
+	TotalAllocMB float64 `json:"total_alloc_mb"`
+	NumGC        uint32  `json:"num_gc"`
+}
+
+// Run 对 target 执行一次完整的压测：导入、等待索引、按模式查询
+func Run(ctx context.Context, target Target, cfg Config) (*Report, error) {
+	cfg.setDefaults()
+	report := &Report{Target: target.Name(), Config: cfg, StartedAt: time.Now()}
+
+	sampler := startMemorySampler(50 * time.Millisecond)
+	defer func() { report.Memory = sampler.stop() }()
+
+	// 导入
+	docs := GenerateDocuments(cfg.Documents, cfg.Words, cfg.Seed)
+	var batches []time.Duration
+	start := time.Now()
+	for i := 0; i < len(docs); i += cfg.BatchSize {
+		batch := docs[i:min(i+cfg.BatchSize, len(docs))]
+		batchStart := time.Now()
+		if err := target.Ingest(ctx, batch); err != nil {
+			return nil, fmt.Errorf("failed to ingest batch at %d: %w", i, err)
+		}
+		batches = append(batches, time.Since(batchStart))
+	}
+	elapsed := time.Since(start)
+	report.Ingest = IngestStats{
+		Documents:     len(docs),
+		DurationMs:    millis(elapsed),
+		DocsPerSecond: float64(len(docs)) / elapsed.Seconds(),
+		BatchP50Ms:    millis(percentile(batches, 50)),
+		BatchP99Ms:    millis(percentile(batches, 99)),
+	}
+
+	// 异步索引
+	start = time.Now()
+	unindexed, err := target.WaitIndexed(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wait for indexing: %w", err)
+	}
+	report.Unindexed = unindexed
+	report.IndexWaitMs = millis(time.Since(start))
+
+	// 查询
+	modes := cfg.Modes
+	if len(modes) == 0 {
+		modes = target.Modes()
+	}
+	queries := GenerateQueries(cfg.Queries, cfg.Seed)
+	for _, mode := range modes {
+		stats, err := runQueries(ctx, target, mode, queries, cfg)
+		if err != nil {
+			return nil, err
+		}
+		report.Queries = append(report.Queries, stats)
+	}
+
+	report.Duration = time.Since(report.StartedAt)
+	return report, nil
+}
+
+// runQueries 用 cfg.Concurrency 个 goroutine 执行一个模式的所有查询
+func runQueries(ctx context.Context, target Target, mode lightrag.QueryMode, queries []string, cfg Config) (QueryStats, error) {
+	stats := QueryStats{Mode: mode, Count: len(queries)}
+	latencies := make([]time.Duration, len(queries))
+	failed := make([]bool, len(queries))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	next := make(chan int)
+	start := time.Now()
+	for w := 0; w < cfg.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				queryStart := time.Now()
+				_, err := target.Retrieve(ctx, queries[i], lightrag.QueryParam{Mode: mode, Limit: cfg.Limit})
+				latencies[i] = time.Since(queryStart)
+				if err != nil {
+					failed[i] = true
+					mu.Lock()
+					if stats.FirstError == "" {
+						stats.FirstError = err.Error()
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range queries {
+		if ctx.Err() != nil {
+			break
+		}
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return stats, err
+	}
+	elapsed := time.Since(start)
+
+	var ok []time.Duration
+	var total time.Duration
+	for i, d := range latencies {
+		if failed[i] {
+			stats.Errors++
+			continue
+		}
+		ok = append(ok, d)
+		total += d
+	}
+	if len(ok) > 0 {
+		stats.P50Ms = millis(percentile(ok, 50))
+		stats.P99Ms = millis(percentile(ok, 99))
+		stats.MeanMs = millis(total / time.Duration(len(ok)))
+		stats.QPS = float64(len(ok)) / elapsed.Seconds()
+	}
+	return stats, nil
+}
+
+// percentile 返回第 p 百分位的值（最近秩法），values 会被排序
+func percentile(values []time.Duration, p float64) time.Duration {
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	rank := int(float64(len(values))*p/100+0.999999) - 1
+	return values[max(0, min(rank, len(values)-1))]
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// memorySampler 定期读取 Go 堆的占用，记录峰值
+type memorySampler struct {
+	before runtime.MemStats
+	peak   uint64
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+func startMemorySampler(interval time.Duration) *memorySampler {
+	s := &memorySampler{done: make(chan struct{})}
+	runtime.ReadMemStats(&s.before)
+	s.peak = s.before.HeapAlloc
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		var m runtime.MemStats
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+				runtime.ReadMemStats(&m)
+				s.peak = max(s.peak, m.HeapAlloc)
+			}
+		}
+	}()
+	return s
+}
+
+func (s *memorySampler) stop() MemoryStats {
+	close(s.done)
+	s.wg.Wait()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	s.peak = max(s.peak, m.HeapAlloc)
+	const mb = 1 << 20
+	return MemoryStats{
+		PeakHeapMB:   float64(s.peak) / mb,
+		TotalAllocMB: float64(m.TotalAlloc-s.before.TotalAlloc) / mb,
+		NumGC:        m.NumGC - s.before.NumGC,
+	}
+}
+
+// syllables 合成词的音节，组合出的词不会被当作停用词，不同分词器的切分结果也一致
+var syllables = []string{"ka", "lo", "mi", "ne", "ru", "ta", "ve", "zo", "pi", "sa", "du", "fe", "go", "hu", "ji", "be"}
+
+// vocabularySize 合成词表的大小
+const vocabularySize = 2000
+
+// word 返回词表中的第 i 个词
+func word(i int) string {
+	var b strings.Builder
+	for n := i + len(syllables); n > 0; n /= len(syllables) {
+		b.WriteString(syllables[n%len(syllables)])
+	}
+	return b.String()
+}
+
+// GenerateDocuments 生成 n 个合成文档，每个文档 words 个词；词频服从 Zipf 分布，与真实语料类似，少数词非常常见
+func GenerateDocuments(n, words int, seed int64) []map[string]any {
+	rng := rand.New(rand.NewSource(seed))
+	zipf := rand.NewZipf(rng, 1.1, 1, vocabularySize-1)
+	categories := []string{"news", "wiki", "blog", "paper", "manual"}
+	docs := make([]map[string]any, n)
+	for i := range docs {
+		var b strings.Builder
+		for j := 0; j < words; j++ {
+			if j > 0 {
+				if j%12 == 0 {
+					b.WriteString(". ")
+				} else {
+					b.WriteByte(' ')
+				}
+			}
+			b.WriteString(word(int(zipf.Uint64())))
+		}
+		b.WriteByte('.')
+		docs[i] = map[string]any{
+			"id":       fmt.Sprintf("loadtest-%06d", i),
+			"content":  b.String(),
+			"category": categories[rng.Intn(len(categories))],
+		}
+	}
+	return docs
+}
+
+// GenerateQueries 生成 n 个两到三个词的查询，词从 Zipf 分布的中间部分选取，既不是停用词一样常见也不至于没有结果
+func GenerateQueries(n int, seed int64) []string {
+	rng := rand.New(rand.NewSource(seed + 1))
+	queries := make([]string, n)
+	for i := range queries {
+		words := make([]string, 2+rng.Intn(2))
+		for j := range words {
+			words[j] = word(5 + rng.Intn(200))
+		}
+		queries[i] = strings.Join(words, " ")
+	}
+	return queries
+}
+
+// WriteComparison 以表格输出一个或多个报告，每个报告一列
+func WriteComparison(w io.Writer, reports ...*Report) error {
+	if len(reports) == 0 {
+		return nil
+	}
+	var rows [][]string
+	add := func(label string, value func(r *Report) string) {
+		row := []string{label}
+		for _, r := range reports {
+			row = append(row, value(r))
+		}
+		rows = append(rows, row)
+	}
+
+	add("target", func(r *Report) string { return r.Target })
+	var tuningKeys []string
+	seen := make(map[string]bool)
+	for _, r := range reports {
+		for k := range r.Tuning {
+			if !seen[k] {
+				seen[k] = true
+				tuningKeys = append(tuningKeys, k)
+			}
+		}
+	}
+	sort.Strings(tuningKeys)
+	for _, k := range tuningKeys {
+		add(k, func(r *Report) string { return r.Tuning[k] })
+	}
+	add("documents", func(r *Report) string { return fmt.Sprintf("%d × %d words", r.Ingest.Documents, r.Config.Words) })
+	add("ingest docs/s", func(r *Report) string { return fmt.Sprintf("%.1f", r.Ingest.DocsPerSecond) })
+	add("ingest batch p50/p99", func(r *Report) string {
+		return fmt.Sprintf("%.1f / %.1f ms", r.Ingest.BatchP50Ms, r.Ingest.BatchP99Ms)
+	})
+	add("index wait", func(r *Report) string {
+		if r.Unindexed > 0 {
+			return fmt.Sprintf("> %.0f ms (%d unindexed)", r.IndexWaitMs, r.Unindexed)
+		}
+		return fmt.Sprintf("%.0f ms", r.IndexWaitMs)
+	})
+
+	var modes []lightrag.QueryMode
+	seenMode := make(map[lightrag.QueryMode]bool)
+	for _, r := range reports {
+		for _, q := range r.Queries {
+			if !seenMode[q.Mode] {
+				seenMode[q.Mode] = true
+				modes = append(modes, q.Mode)
+			}
+		}
+	}
+	for _, mode := range modes {
+		add(string(mode)+" p50/p99", func(r *Report) string {
+			for _, q := range r.Queries {
+				if q.Mode != mode {
+					continue
+				}
+				if q.Errors == q.Count {
+					return "failed"
+				}
+				s := fmt.Sprintf("%.1f / %.1f ms", q.P50Ms, q.P99Ms)
+				if q.Errors > 0 {
+					s += fmt.Sprintf(" (%d errors)", q.Errors)
+				}
+				return s
+			}
+			return "-"
+		})
+	}
+	add("peak heap", func(r *Report) string { return fmt.Sprintf("%.1f MB", r.Memory.PeakHeapMB) })
+	add("total alloc", func(r *Report) string { return fmt.Sprintf("%.1f MB", r.Memory.TotalAllocMB) })
+	add("gc cycles", func(r *Report) string { return fmt.Sprintf("%d", r.Memory.NumGC) })
+
+	widths := make([]int, len(reports)+1)
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+	var b strings.Builder
+	for _, row := range rows {
+		for i, cell := range row {
+			if i > 0 {
+				b.WriteString("  ")
+			}
+			fmt.Fprintf(&b, "%-*s", widths[i], cell)
+		}
+		b.WriteString("\n")
+	}
+	for _, r := range reports {
+		for _, q := range r.Queries {
+			if q.FirstError != "" {
+				fmt.Fprintf(&b, "%s %s: %d/%d 次查询失败，例如: %s\n", r.Target, q.Mode, q.Errors, q.Count, q.FirstError)
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// LoadReport 读取 JSON 格式的报告
+func LoadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report: %w", err)
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse report: %w", err)
+	}
+	return &report, nil
+}
+
+// SaveReport 以 JSON 保存报告
+func SaveReport(path string, report *Report) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/lightrag"
+)
+
+// fakeTarget 记录导入的文档，向量模式的检索总是失败
+type fakeTarget struct {
+	mu      sync.Mutex
+	batches []int
+	queries int
+}
+
+func (t *fakeTarget) Name() string { return "fake" }
+
+func (t *fakeTarget) Modes() []lightrag.QueryMode {
+	return []lightrag.QueryMode{lightrag.ModeFulltext, lightrag.ModeVector}
+}
+
+func (t *fakeTarget) Ingest(ctx context.Context, docs []map[string]any) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.batches = append(t.batches, len(docs))
+	return nil
+}
+
+func (t *fakeTarget) WaitIndexed(ctx context.Context) (int, error) { return 3, nil }
+
+func (t *fakeTarget) Retrieve(ctx context.Context, query string, param lightrag.QueryParam) ([]lightrag.SearchResult, error) {
+	t.mu.Lock()
+	t.queries++
+	t.mu.Unlock()
+	if param.Mode == lightrag.ModeVector {
+		return nil, errors.New("no vectors")
+	}
+	return []lightrag.SearchResult{{ID: "loadtest-000000"}}, nil
+}
+
+func (t *fakeTarget) Close() error { return nil }
+
+func TestRun(t *testing.T) {
+	target := &fakeTarget{}
+	report, err := Run(context.Background(), target, Config{Documents: 25, Words: 20, BatchSize: 10, Queries: 8, Concurrency: 3})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if !reflect.DeepEqual(target.batches, []int{10, 10, 5}) {
+		t.Errorf("unexpected batches: %v", target.batches)
+	}
+	if target.queries != 16 {
+		t.Errorf("expected 16 queries, got %d", target.queries)
+	}
+	if report.Ingest.Documents != 25 || report.Unindexed != 3 || len(report.Queries) != 2 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if q := report.Queries[0]; q.Mode != lightrag.ModeFulltext || q.Count != 8 || q.Errors != 0 || q.P99Ms < q.P50Ms {
+		t.Errorf("unexpected fulltext stats: %+v", q)
+	}
+	if q := report.Queries[1]; q.Errors != 8 || q.FirstError != "no vectors" {
+		t.Errorf("unexpected vector stats: %+v", q)
+	}
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := SaveReport(path, report); err != nil {
+		t.Fatalf("SaveReport failed: %v", err)
+	}
+	loaded, err := LoadReport(path)
+	if err != nil {
+		t.Fatalf("LoadReport failed: %v", err)
+	}
+	loaded.Target = "fake-2"
+	loaded.Tuning = map[string]string{"analyzer": "simple"}
+
+	var buf bytes.Buffer
+	if err := WriteComparison(&buf, report, loaded); err != nil {
+		t.Fatalf("WriteComparison failed: %v", err)
+	}
+	for _, want := range []string{"fake-2", "analyzer", "fulltext p50/p99", "failed", "3 unindexed", "no vectors"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("comparison missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var values []time.Duration
+	for i := 100; i >= 1; i-- {
+		values = append(values, time.Duration(i))
+	}
+	for p, want := range map[float64]time.Duration{50: 50, 99: 99, 100: 100, 1: 1} {
+		if got := percentile(values, p); got != want {
+			t.Errorf("p%v = %v, want %v", p, got, want)
+		}
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("expected 0 for empty values, got %v", got)
+	}
+}
+
+func TestGenerateDocuments(t *testing.T) {
+	a := GenerateDocuments(5, 30, 42)
+	b := GenerateDocuments(5, 30, 42)
+	if !reflect.DeepEqual(a, b) {
+		t.Error("expected the same seed to generate the same documents")
+	}
+	if words := strings.Fields(a[0]["content"].(string)); len(words) != 30 {
+		t.Errorf("expected 30 words, got %d", len(words))
+	}
+	if reflect.DeepEqual(a, GenerateDocuments(5, 30, 43)) {
+		t.Error("expected a different seed to generate different documents")
+	}
+	if q := GenerateQueries(3, 42); len(q) != 3 || q[0] == "" {
+		t.Errorf("unexpected queries: %v", q)
+	}
+}
+
+func TestSQLiteTarget(t *testing.T) {
+	ctx := context.Background()
+	target, err := NewSQLiteTarget(ctx, t.TempDir(), 32)
+	if err != nil {
+		t.Fatalf("NewSQLiteTarget failed: %v", err)
+	}
+	defer target.Close()
+
+	report, err := Run(ctx, target, Config{Documents: 50, Words: 40, BatchSize: 20, Queries: 10, Limit: 5})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for _, q := range report.Queries {
+		if q.Errors > 0 {
+			t.Errorf("%s: %d errors, first: %s", q.Mode, q.Errors, q.FirstError)
+		}
+	}
+
+	results, err := target.Retrieve(ctx, word(0), lightrag.QueryParam{Mode: lightrag.ModeHybrid, Limit: 5})
+	if err != nil {
+		t.Fatalf("Retrieve failed: %v", err)
+	}
+	if len(results) != 5 {
+		t.Errorf("expected 5 results for the most common word, got %d", len(results))
+	}
+}
+
+// newBenchTarget 创建 LightRAG 目标，DuckDB 扩展无法加载时跳过
+func newBenchTarget(b *testing.B) Target {
+	target, err := NewLightRAGTarget(context.Background(), LightRAGOptions{WorkingDir: b.TempDir(), Dims: 64})
+	if err != nil {
+		b.Skipf("LightRAG not available: %v", err)
+	}
+	b.Cleanup(func() { target.Close() })
+	return target
+}
+
+func BenchmarkIngest(b *testing.B) {
+	ctx := context.Background()
+	for _, backend := range []string{"duckdb", "sqlite"} {
+		b.Run(backend, func(b *testing.B) {
+			var target Target
+			if backend == "duckdb" {
+				target = newBenchTarget(b)
+			} else {
+				var err error
+				if target, err = NewSQLiteTarget(ctx, b.TempDir(), 64); err != nil {
+					b.Fatal(err)
+				}
+				b.Cleanup(func() { target.Close() })
+			}
+			docs := GenerateDocuments(b.N, 200, 1)
+			b.ResetTimer()
+			for i := 0; i < len(docs); i += 100 {
+				if err := target.Ingest(ctx, docs[i:min(i+100, len(docs))]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkRetrieve(b *testing.B) {
+	ctx := context.Background()
+	target, err := NewSQLiteTarget(ctx, b.TempDir(), 64)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer target.Close()
+	if err := target.Ingest(ctx, GenerateDocuments(1000, 200, 1)); err != nil {
+		b.Fatal(err)
+	}
+	queries := GenerateQueries(100, 1)
+	for _, mode := range target.Modes() {
+		b.Run(string(mode), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := target.Retrieve(ctx, queries[i%len(queries)], lightrag.QueryParam{Mode: mode, Limit: 10}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package loadtest
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mozhou-tech/sqlite-ai-driver/pkg/lightrag"
+	_ "modernc.org/sqlite"
+)
+
+// LightRAGOptions LightRAG 目标的配置
+type LightRAGOptions struct {
+	// WorkingDir 工作目录，应为空目录
+	WorkingDir string
+	// Dims 合成向量的维度，默认 256
+	Dims int
+	// VectorMetric 向量相似度
+	VectorMetric lightrag.VectorMetric
+	// FulltextAnalyzer 全文检索的分词器
+	FulltextAnalyzer lightrag.Analyzer
+	// WaitTimeout 等待 embedding 队列排空的最长时间，默认 10 分钟
+	WaitTimeout time.Duration
+}
+
+// lightragTarget 使用 DuckDB 存储的 LightRAG，不配置 LLM，向量由 SimpleEmbedder 生成
+type lightragTarget struct {
+	rag         *lightrag.LightRAG
+	waitTimeout time.Duration
+}
+
+// NewLightRAGTarget 在 opts.WorkingDir 中创建并初始化 LightRAG
+func NewLightRAGTarget(ctx context.Context, opts LightRAGOptions) (Target, error) {
+	if opts.Dims <= 0 {
+		opts.Dims = 256
+	}
+	if opts.WaitTimeout <= 0 {
+		opts.WaitTimeout = 10 * time.Minute
+	}
+	rag := lightrag.New(lightrag.Options{
+		WorkingDir:       opts.WorkingDir,
+		Embedder:         lightrag.NewSimpleEmbedder(opts.Dims),
+		VectorMetric:     opts.VectorMetric,
+		FulltextAnalyzer: opts.FulltextAnalyzer,
+	})
+	if err := rag.InitializeStorages(ctx); err != nil {
+		return nil, fmt.Errorf("failed to initialize storages: %w", err)
+	}
+	return &lightragTarget{rag: rag, waitTimeout: opts.WaitTimeout}, nil
+}
+
+func (t *lightragTarget) Name() string { return "duckdb" }
+
+func (t *lightragTarget) Modes() []lightrag.QueryMode {
+	return []lightrag.QueryMode{lightrag.ModeFulltext, lightrag.ModeVector, lightrag.ModeHybrid, lightrag.ModeNaive}
+}
+
+func (t *lightragTarget) Ingest(ctx context.Context, docs []map[string]any) error {
+	_, err := t.rag.InsertBatch(ctx, docs)
+	return err
+}
+
+// WaitIndexed 等待 embedding 队列排空，然后重建全文索引（DuckDB 的 FTS 索引不会随插入更新）
+func (t *lightragTarget) WaitIndexed(ctx context.Context) (int, error) {
+	if err := t.rag.WaitForEmbeddings(ctx, t.waitTimeout); err != nil {
+		return 0, err
+	}
+	if _, err := t.rag.RebuildFulltextIndex(ctx); err != nil {
+		return 0, err
+	}
+	status, err := t.rag.GetEmbeddingStatus(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return status.Pending + status.Processing, nil
+}
+
+func (t *lightragTarget) Retrieve(ctx context.Context, query string, param lightrag.QueryParam) ([]lightrag.SearchResult, error) {
+	return t.rag.Retrieve(ctx, query, param)
+}
+
+func (t *lightragTarget) Close() error {
+	return t.rag.FinalizeStorages(context.Background())
+}
+
+// sqliteTarget 基于 SQLite 的基线：FTS5 全文索引，向量以 float32 BLOB 保存并暴力计算余弦相似度
+// 向量在导入时同步生成，没有异步队列，用于和 LightRAG 的 DuckDB 存储对比
+type sqliteTarget struct {
+	db       *sql.DB
+	embedder *lightrag.SimpleEmbedder
+}
+
+// NewSQLiteTarget 在 dir 中创建 SQLite 数据库
+func NewSQLiteTarget(ctx context.Context, dir string, dims int) (Target, error) {
+	if dims <= 0 {
+		dims = 256
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+	db, err := sql.Open("sqlite", filepath.Join(dir, "loadtest.db")+"?_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+	for _, stmt := range []string{
+		`CREATE TABLE IF NOT EXISTS documents (id TEXT PRIMARY KEY, content TEXT, category TEXT, embedding BLOB)`,
+		`CREATE VIRTUAL TABLE IF NOT EXISTS documents_fts USING fts5(id UNINDEXED, content)`,
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to create schema: %w", err)
+		}
+	}
+	return &sqliteTarget{db: db, embedder: lightrag.NewSimpleEmbedder(dims)}, nil
+}
+
+func (t *sqliteTarget) Name() string { return "sqlite" }
+
+func (t *sqliteTarget) Modes() []lightrag.QueryMode {
+	return []lightrag.QueryMode{lightrag.ModeFulltext, lightrag.ModeVector, lightrag.ModeHybrid}
+}
+
+func (t *sqliteTarget) Ingest(ctx context.Context, docs []map[string]any) error {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	for _, doc := range docs {
+		id, _ := doc["id"].(string)
+		content, _ := doc["content"].(string)
+		category, _ := doc["category"].(string)
+		embedding, err := t.embedder.Embed(ctx, content)
+		if err != nil {
+			return fmt.Errorf("failed to embed document: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT OR REPLACE INTO documents (id, content, category, embedding) VALUES (?, ?, ?, ?)`,
+			id, content, category, encodeVector(embedding)); err != nil {
+			return fmt.Errorf("failed to insert document: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO documents_fts (id, content) VALUES (?, ?)`, id, content); err != nil {
+			return fmt.Errorf("failed to index document: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// WaitIndexed SQLite 基线在导入时同步建立索引，不需要等待
+func (t *sqliteTarget) WaitIndexed(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func (t *sqliteTarget) Retrieve(ctx context.Context, query string, param lightrag.QueryParam) ([]lightrag.SearchResult, error) {
+	limit := param.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	switch param.Mode {
+	case lightrag.ModeFulltext:
+		return t.searchFulltext(ctx, query, limit)
+	case lightrag.ModeVector:
+		return t.searchVector(ctx, query, limit)
+	case lightrag.ModeHybrid:
+		fulltext, err := t.searchFulltext(ctx, query, limit)
+		if err != nil {
+			return nil, err
+		}
+		vector, err := t.searchVector(ctx, query, limit)
+		if err != nil {
+			return nil, err
+		}
+		return fuseRanks(limit, fulltext, vector), nil
+	default:
+		return nil, fmt.Errorf("unsupported mode: %s", param.Mode)
+	}
+}
+
+// searchFulltext 以 OR 连接查询词，按 bm25 排序
+func (t *sqliteTarget) searchFulltext(ctx context.Context, query string, limit int) ([]lightrag.SearchResult, error) {
+	var terms []string
+	for _, term := range strings.Fields(query) {
+		terms = append(terms, `"`+strings.ReplaceAll(term, `"`, `""`)+`"`)
+	}
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	rows, err := t.db.QueryContext(ctx, `SELECT id, content, bm25(documents_fts) AS score FROM documents_fts
+		WHERE documents_fts MATCH ? ORDER BY score LIMIT ?`, strings.Join(terms, " OR "), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search fulltext: %w", err)
+	}
+	defer rows.Close()
+	var results []lightrag.SearchResult
+	for rows.Next() {
+		var r lightrag.SearchResult
+		var score float64
+		if err := rows.Scan(&r.ID, &r.Content, &score); err != nil {
+			return nil, fmt.Errorf("failed to scan fulltext result: %w", err)
+		}
+		r.Score = -score // bm25 越小越相关
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// searchVector 读取所有向量，暴力计算余弦相似度
+func (t *sqliteTarget) searchVector(ctx context.Context, query string, limit int) ([]lightrag.SearchResult, error) {
+	q, err := t.embedder.Embed(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+
+	rows, err := t.db.QueryContext(ctx, `SELECT id, content, embedding FROM documents`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search vectors: %w", err)
+	}
+	defer rows.Close()
+	var results []lightrag.SearchResult
+	for rows.Next() {
+		var r lightrag.SearchResult
+		var blob []byte
+		if err := rows.Scan(&r.ID, &r.Content, &blob); err != nil {
+			return nil, fmt.Errorf("failed to scan vector: %w", err)
+		}
+		r.Score = cosine(q, decodeVector(blob))
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// fuseRanks 倒数排名融合（k = 60）
+func fuseRanks(limit int, lists ...[]lightrag.SearchResult) []lightrag.SearchResult {
+	scores := make(map[string]float64)
+	byID := make(map[string]lightrag.SearchResult)
+	for _, list := range lists {
+		for rank, r := range list {
+			scores[r.ID] += 1 / float64(60+rank+1)
+			byID[r.ID] = r
+		}
+	}
+	results := make([]lightrag.SearchResult, 0, len(byID))
+	for id, r := range byID {
+		r.Score = scores[id]
+		results = append(results, r)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+func (t *sqliteTarget) Close() error {
+	return t.db.Close()
+}
+
+func encodeVector(v []float64) []byte {
+	buf := make([]byte, 4*len(v))
+	for i, x := range v {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(float32(x)))
+	}
+	return buf
+}
+
+func decodeVector(buf []byte) []float64 {
+	v := make([]float64, len(buf)/4)
+	for i := range v {
+		v[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:])))
+	}
+	return v
+}
+
+func cosine(a, b []float64) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		if i >= len(b) {
+			break
+		}
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,320 @@
+package lightrag
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	duckdb_driver "github.com/mozhou-tech/sqlite-ai-driver/pkg/duckdb-driver"
+	"github.com/sirupsen/logrus"
+)
+
+// StorageInfo 工作目录的存储状态
+type StorageInfo struct {
+	Tables              []TableInfo       `json:"tables"`
+	DatabaseSize        string            `json:"database_size"`
+	WALSize             string            `json:"wal_size"`
+	Fulltext            FulltextIndexInfo `json:"fulltext"`
+	Embeddings          EmbeddingCoverage `json:"embeddings"`
+	GraphTriples        int               `json:"graph_triples"`
+	SchemaVersion       int               `json:"schema_version"`
+	LatestSchemaVersion int               `json:"latest_schema_version"`
+}
+
+// TableInfo 数据表及其行数
+type TableInfo struct {
+	Name string `json:"name"`
+	Rows int64  `json:"rows"`
+}
+
+// FulltextIndexInfo 全文索引状态
+type FulltextIndexInfo struct {
+	Indexed     bool     `json:"indexed"`     // 是否已创建 FTS 索引
+	Analyzer    Analyzer `json:"analyzer"`    // 集合使用的分词器
+	Tokenized   int      `json:"tokenized"`   // 已有分词结果的文档数
+	Untokenized int      `json:"untokenized"` // 缺少分词结果的文档数
+}
+
+// EmbeddingCoverage 向量生成进度
+type EmbeddingCoverage struct {
+	Total      int `json:"total"`
+	Completed  int `json:"completed"`
+	Pending    int `json:"pending"`
+	Processing int `json:"processing"`
+	Failed     int `json:"failed"`
+	Vectors    int `json:"vectors"` // 实际存有向量的文档数
+}
+
+// maxFailedEmbeddings GetEmbeddingStatus 最多返回的失败文档数
+const maxFailedEmbeddings = 100
+
+// EmbeddingStatus 向量生成状态：各状态的文档数和最近失败的文档
+type EmbeddingStatus struct {
+	EmbeddingCoverage
+	// FailedDocuments 失败的文档及最近一次失败的原因，最多 100 个，总数见 Failed
+	FailedDocuments []FailedEmbedding `json:"failed_documents"`
+}
+
+// FailedEmbedding 生成向量失败的文档
+type FailedEmbedding struct {
+	ID    string `json:"id"`
+	Error string `json:"error,omitempty"`
+}
+
+// maintenanceCollection 返回底层的 DuckDB 文档集合
+func (r *LightRAG) maintenanceCollection() (*duckdbCollection, error) {
+	if r == nil {
+		return nil, fmt.Errorf("LightRAG instance is nil")
+	}
+	if !r.initialized {
+		return nil, fmt.Errorf("storages not initialized")
+	}
+	c, ok := r.docs.(*duckdbCollection)
+	if !ok {
+		return nil, fmt.Errorf("documents collection is not a duckdb collection")
+	}
+	return c, nil
+}
+
+// Inspect 返回数据表、全文索引、向量生成进度、知识图谱和结构版本等存储状态
+func (r *LightRAG) Inspect(ctx context.Context) (*StorageInfo, error) {
+	c, err := r.maintenanceCollection()
+	if err != nil {
+		return nil, err
+	}
+	info := &StorageInfo{LatestSchemaVersion: latestSchemaVersion()}
+
+	tables, err := c.listTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range tables {
+		var rows int64
+		if err := c.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM "%s"`, name)).Scan(&rows); err != nil {
+			return nil, fmt.Errorf("failed to count rows in %s: %w", name, err)
+		}
+		info.Tables = append(info.Tables, TableInfo{Name: name, Rows: rows})
+	}
+
+	err = c.db.QueryRowContext(ctx, `
+		SELECT database_size, wal_size FROM pragma_database_size()
+		WHERE database_name = current_database()
+	`).Scan(&info.DatabaseSize, &info.WALSize)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get database size: %w", err)
+	}
+
+	info.Fulltext.Indexed, err = duckdb_driver.FTSIndexExists(ctx, c.db, c.tableName)
+	if err != nil {
+		return nil, err
+	}
+	info.Fulltext.Analyzer = c.getAnalyzer()
+	err = c.db.QueryRowContext(ctx, fmt.Sprintf(`
+		SELECT
+			COUNT(*) FILTER (WHERE content_tokens IS NOT NULL AND content_tokens <> ''),
+			COUNT(*) FILTER (WHERE content_tokens IS NULL OR content_tokens = '')
+		FROM %s
+	`, c.tableName)).Scan(&info.Fulltext.Tokenized, &info.Fulltext.Untokenized)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count tokenized documents: %w", err)
+	}
+
+	if info.Embeddings, err = c.embeddingCoverage(ctx); err != nil {
+		return nil, err
+	}
+
+	if r.graph != nil {
+		triples, err := r.graph.AllTriples(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count graph triples: %w", err)
+		}
+		info.GraphTriples = len(triples)
+	}
+
+	if info.SchemaVersion, err = schemaVersion(ctx, c.db, c.tableName); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// Vacuum 更新统计信息并执行 checkpoint，把 WAL 合并进数据库文件、回收删除数据占用的空间
+func (r *LightRAG) Vacuum(ctx context.Context) error {
+	c, err := r.maintenanceCollection()
+	if err != nil {
+		return err
+	}
+	if _, err := c.db.ExecContext(ctx, `VACUUM ANALYZE`); err != nil {
+		return fmt.Errorf("failed to vacuum: %w", err)
+	}
+	if _, err := c.db.ExecContext(ctx, `CHECKPOINT`); err != nil {
+		return fmt.Errorf("failed to checkpoint: %w", err)
+	}
+	r.events.publish(EventMaintenanceCompleted, map[string]any{"task": "vacuum"})
+	return nil
+}
+
+// RebuildFulltextIndex 重新分词所有文档并重建全文索引，返回重新分词的文档数
+// DuckDB 的 FTS 索引不会随插入自动更新，导入新文档或更新 sego 词典后需要重建；分词使用集合设置中的分词器
+func (r *LightRAG) RebuildFulltextIndex(ctx context.Context) (int, error) {
+	c, err := r.maintenanceCollection()
+	if err != nil {
+		return 0, err
+	}
+	n, err := duckdb_driver.RebuildFTSIndexWithTokenizer(ctx, c.db, c.tableName, "id", "content", "content_tokens", c.getAnalyzer().tokenize)
+	if err != nil {
+		return 0, err
+	}
+	c.markWritten()
+	logrus.WithField("documents", n).Info("Fulltext index rebuilt")
+	r.events.publish(EventMaintenanceCompleted, map[string]any{"task": "rebuild_fulltext", "documents": n})
+	return n, nil
+}
+
+// Reembed 清空向量并把文档重新标记为 pending，由后台 worker 重新生成，返回标记的文档数
+// failedOnly 为 true 时只处理生成失败的文档；更换 embedding 模型或维度后应处理所有文档
+// 需要配置 Embedder，调用方可以用 WaitForEmbeddings 等待完成
+func (r *LightRAG) Reembed(ctx context.Context, failedOnly bool) (int, error) {
+	c, err := r.maintenanceCollection()
+	if err != nil {
+		return 0, err
+	}
+	vectorSearches := c.getVectorSearches()
+	if r.vector == nil || len(vectorSearches) == 0 {
+		return 0, fmt.Errorf("vector search not available, an embedder is required")
+	}
+
+	sets := []string{"embedding_status = 'pending'"}
+	for _, vs := range vectorSearches {
+		sets = append(sets, fmt.Sprintf("vector_%s = NULL", vs.config.Identifier))
+	}
+	updateSQL := fmt.Sprintf(`UPDATE %s SET %s`, c.tableName, strings.Join(sets, ", "))
+	if failedOnly {
+		updateSQL += ` WHERE embedding_status = 'failed'`
+	}
+	result, err := c.db.ExecContext(ctx, updateSQL)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reset embeddings: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	c.markWritten()
+
+	c.startEmbeddingWorker(ctx)
+	r.events.publish(EventMaintenanceCompleted, map[string]any{"task": "reembed", "documents": int(n), "failed_only": failedOnly})
+	return int(n), nil
+}
+
+// listTables 列出数据库中的所有数据表（共享数据库中也包含其他模块的表）
+func (c *duckdbCollection) listTables(ctx context.Context) ([]string, error) {
+	rows, err := c.db.QueryContext(ctx, `
+		SELECT table_name
+		FROM information_schema.tables
+		WHERE table_catalog = current_database() AND table_schema = 'main' AND table_type = 'BASE TABLE'
+		ORDER BY table_name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan table name: %w", err)
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
+
+// GetEmbeddingStatus 返回 pending、processing、completed、failed 各状态的文档数，以及失败的文档 ID 和失败原因
+func (r *LightRAG) GetEmbeddingStatus(ctx context.Context) (*EmbeddingStatus, error) {
+	c, err := r.maintenanceCollection()
+	if err != nil {
+		return nil, err
+	}
+	status := &EmbeddingStatus{FailedDocuments: []FailedEmbedding{}}
+	if status.EmbeddingCoverage, err = c.embeddingCoverage(ctx); err != nil {
+		return nil, err
+	}
+	if status.Failed == 0 {
+		return status, nil
+	}
+
+	rows, err := c.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT id, COALESCE(embedding_error, '')
+		FROM %s
+		WHERE embedding_status = 'failed'
+		ORDER BY id
+		LIMIT ?
+	`, c.tableName), maxFailedEmbeddings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list failed embeddings: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var failed FailedEmbedding
+		if err := rows.Scan(&failed.ID, &failed.Error); err != nil {
+			return nil, fmt.Errorf("failed to scan failed embedding: %w", err)
+		}
+		status.FailedDocuments = append(status.FailedDocuments, failed)
+	}
+	return status, rows.Err()
+}
+
+// embeddingCoverage 按 embedding_status 统计文档数
+func (c *duckdbCollection) embeddingCoverage(ctx context.Context) (EmbeddingCoverage, error) {
+	var coverage EmbeddingCoverage
+	rows, err := c.db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT COALESCE(embedding_status, 'pending'), COUNT(*)
+		FROM %s
+		GROUP BY 1
+	`, c.tableName))
+	if err != nil {
+		return coverage, fmt.Errorf("failed to count embedding status: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return coverage, fmt.Errorf("failed to scan embedding status: %w", err)
+		}
+		coverage.Total += count
+		switch status {
+		case "completed":
+			coverage.Completed += count
+		case "processing":
+			coverage.Processing += count
+		case "failed":
+			coverage.Failed += count
+		default:
+			coverage.Pending += count
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return coverage, err
+	}
+
+	// 向量列只在配置过 Embedder 时创建，存在多个时统计第一个
+	var vectorColumn string
+	err = c.db.QueryRowContext(ctx, `
+		SELECT column_name
+		FROM information_schema.columns
+		WHERE table_name = ? AND column_name LIKE 'vector\_%' ESCAPE '\'
+		ORDER BY column_name
+		LIMIT 1
+	`, c.tableName).Scan(&vectorColumn)
+	if err == sql.ErrNoRows {
+		return coverage, nil
+	}
+	if err != nil {
+		return coverage, fmt.Errorf("failed to find vector column: %w", err)
+	}
+	countSQL := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE %s IS NOT NULL`, c.tableName, vectorColumn)
+	if err := c.db.QueryRowContext(ctx, countSQL).Scan(&coverage.Vectors); err != nil {
+		return coverage, fmt.Errorf("failed to count vectors: %w", err)
+	}
+	return coverage, nil
+}
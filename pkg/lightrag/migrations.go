@@ -0,0 +1,268 @@
+package lightrag
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	duckdb_driver "github.com/mozhou-tech/sqlite-ai-driver/pkg/duckdb-driver"
+	"github.com/sirupsen/logrus"
+)
+
+// schemaVersionTable 记录每个集合已执行的结构升级
+const schemaVersionTable = "lightrag_schema_version"
+
+// Migration 集合表的一次结构升级
+type Migration struct {
+	Version     int    `json:"version"`
+	Description string `json:"description"`
+}
+
+// sqlExecutor *sql.DB 和 *sql.Tx 共有的方法，结构升级在事务中执行
+type sqlExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// schemaMigration 按版本号依次执行的结构升级
+// 新增列或索引时在末尾追加一步，不要修改已发布的步骤；每一步都应当幂等，
+// 这样没有版本记录的旧数据库从版本 0 开始升级也能得到相同的结果
+type schemaMigration struct {
+	Migration
+	apply func(ctx context.Context, tx sqlExecutor, tableName string) error
+}
+
+var schemaMigrations = []schemaMigration{
+	{
+		// content_tokens 用于 FTS，embedding_status 跟踪向量生成状态（pending、processing、completed、failed），
+		// chunk_length 记录分块的字符数
+		Migration: Migration{Version: 1, Description: "add content_tokens, embedding_status and chunk_length columns"},
+		apply: func(ctx context.Context, tx sqlExecutor, tableName string) error {
+			for _, column := range []string{
+				"content_tokens TEXT",
+				"embedding_status VARCHAR DEFAULT 'pending'",
+				"chunk_length INTEGER",
+			} {
+				alterSQL := fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s`, tableName, column)
+				if _, err := tx.ExecContext(ctx, alterSQL); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Migration: Migration{Version: 2, Description: "reset missing and interrupted embedding_status to pending"},
+		apply: func(ctx context.Context, tx sqlExecutor, tableName string) error {
+			updateSQL := fmt.Sprintf(`
+				UPDATE %s SET embedding_status = 'pending'
+				WHERE embedding_status IS NULL OR embedding_status = 'processing'
+			`, tableName)
+			_, err := tx.ExecContext(ctx, updateSQL)
+			return err
+		},
+	},
+	{
+		Migration: Migration{Version: 3, Description: "backfill chunk_length"},
+		apply: func(ctx context.Context, tx sqlExecutor, tableName string) error {
+			// DuckDB 的 length 按字符计数，与 Insert 中的 len([]rune(content)) 一致
+			updateSQL := fmt.Sprintf(`
+				UPDATE %s SET chunk_length = length(content)
+				WHERE chunk_length IS NULL AND content IS NOT NULL
+			`, tableName)
+			_, err := tx.ExecContext(ctx, updateSQL)
+			return err
+		},
+	},
+	{
+		Migration: Migration{Version: 4, Description: "backfill content_tokens with sego"},
+		apply: func(ctx context.Context, tx sqlExecutor, tableName string) error {
+			_, err := tokenizeMissing(ctx, tx, tableName)
+			return err
+		},
+	},
+	{
+		// state 为 active、archived 或 deleted，只有 active 的文档参与检索
+		Migration: Migration{Version: 5, Description: "add state column for archived and soft-deleted documents"},
+		apply: func(ctx context.Context, tx sqlExecutor, tableName string) error {
+			alterSQL := fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS state VARCHAR DEFAULT 'active'`, tableName)
+			if _, err := tx.ExecContext(ctx, alterSQL); err != nil {
+				return err
+			}
+			updateSQL := fmt.Sprintf(`UPDATE %s SET state = 'active' WHERE state IS NULL`, tableName)
+			_, err := tx.ExecContext(ctx, updateSQL)
+			return err
+		},
+	},
+	{
+		// embedding_error 记录最近一次生成向量失败的原因，成功后清空
+		Migration: Migration{Version: 6, Description: "add embedding_error column"},
+		apply: func(ctx context.Context, tx sqlExecutor, tableName string) error {
+			alterSQL := fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS embedding_error VARCHAR`, tableName)
+			_, err := tx.ExecContext(ctx, alterSQL)
+			return err
+		},
+	},
+}
+
+// latestSchemaVersion 当前包支持的最高结构版本
+func latestSchemaVersion() int {
+	return schemaMigrations[len(schemaMigrations)-1].Version
+}
+
+// migrateCollection 检查集合表的结构版本并执行尚未执行的升级
+// 数据库由更新版本的包创建（结构版本高于当前包支持的版本）时返回错误，避免旧代码写坏新结构
+// apply 为 false 时只做检查
+func migrateCollection(ctx context.Context, db *sql.DB, tableName string, apply bool) ([]Migration, error) {
+	if err := ensureSchemaVersionTable(ctx, db); err != nil {
+		return nil, err
+	}
+	version, err := schemaVersion(ctx, db, tableName)
+	if err != nil {
+		return nil, err
+	}
+	if version > latestSchemaVersion() {
+		return nil, fmt.Errorf("schema version %d of table %s is newer than supported version %d, please upgrade lightrag",
+			version, tableName, latestSchemaVersion())
+	}
+	if !apply {
+		return nil, nil
+	}
+
+	var applied []Migration
+	for _, m := range schemaMigrations {
+		if m.Version <= version {
+			continue
+		}
+		if err := applyMigration(ctx, db, tableName, m); err != nil {
+			return applied, fmt.Errorf("failed to apply migration %d (%s) to %s: %w", m.Version, m.Description, tableName, err)
+		}
+		logrus.WithFields(logrus.Fields{
+			"table":       tableName,
+			"version":     m.Version,
+			"description": m.Description,
+		}).Info("Schema migration applied")
+		applied = append(applied, m.Migration)
+	}
+	return applied, nil
+}
+
+// applyMigration 在一个事务中执行升级并记录版本，失败时回滚
+func applyMigration(ctx context.Context, db *sql.DB, tableName string, m schemaMigration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := m.apply(ctx, tx, tableName); err != nil {
+		return err
+	}
+	insertSQL := fmt.Sprintf(`INSERT INTO %s (table_name, version, description) VALUES (?, ?, ?)`, schemaVersionTable)
+	if _, err := tx.ExecContext(ctx, insertSQL, tableName, m.Version, m.Description); err != nil {
+		return fmt.Errorf("failed to record schema version: %w", err)
+	}
+	return tx.Commit()
+}
+
+// pendingMigrations 返回集合表尚未执行的升级
+func pendingMigrations(ctx context.Context, db *sql.DB, tableName string) ([]Migration, error) {
+	version, err := schemaVersion(ctx, db, tableName)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, m := range schemaMigrations {
+		if m.Version > version {
+			pending = append(pending, m.Migration)
+		}
+	}
+	return pending, nil
+}
+
+func ensureSchemaVersionTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			table_name VARCHAR,
+			version INTEGER,
+			description VARCHAR,
+			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (table_name, version)
+		)
+	`, schemaVersionTable))
+	if err != nil {
+		return fmt.Errorf("failed to create schema version table: %w", err)
+	}
+	return nil
+}
+
+// schemaVersion 返回集合表已执行的最高升级版本，从未执行过升级时为 0
+func schemaVersion(ctx context.Context, db *sql.DB, tableName string) (int, error) {
+	var exists int
+	err := db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM information_schema.tables WHERE table_name = ?
+	`, schemaVersionTable).Scan(&exists)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check schema version table: %w", err)
+	}
+	if exists == 0 {
+		return 0, nil
+	}
+	var version int
+	selectSQL := fmt.Sprintf(`SELECT COALESCE(MAX(version), 0) FROM %s WHERE table_name = ?`, schemaVersionTable)
+	if err := db.QueryRowContext(ctx, selectSQL, tableName).Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+	return version, nil
+}
+
+// tokenizeMissing 为缺少分词结果的文档补全 content_tokens，返回补全的文档数
+func tokenizeMissing(ctx context.Context, tx sqlExecutor, tableName string) (int, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`
+		SELECT id, content FROM %s
+		WHERE content IS NOT NULL AND (content_tokens IS NULL OR content_tokens = '')
+	`, tableName))
+	if err != nil {
+		return 0, fmt.Errorf("failed to query untokenized documents: %w", err)
+	}
+	docs := make(map[string]string)
+	for rows.Next() {
+		var id, content string
+		if err := rows.Scan(&id, &content); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan document: %w", err)
+		}
+		docs[id] = content
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	updateSQL := fmt.Sprintf(`UPDATE %s SET content_tokens = ? WHERE id = ?`, tableName)
+	for id, content := range docs {
+		if _, err := tx.ExecContext(ctx, updateSQL, duckdb_driver.TokenizeWithSego(content), id); err != nil {
+			return 0, fmt.Errorf("failed to update content_tokens: %w", err)
+		}
+	}
+	return len(docs), nil
+}
+
+// Migrate 执行文档集合尚未执行的结构升级，返回本次执行的升级
+// InitializeStorages 默认已经自动执行，只有设置了 Options.SkipMigrations 时才需要手动调用
+func (r *LightRAG) Migrate(ctx context.Context) ([]Migration, error) {
+	c, err := r.maintenanceCollection()
+	if err != nil {
+		return nil, err
+	}
+	return migrateCollection(ctx, c.db, c.tableName, true)
+}
+
+// PendingMigrations 返回文档集合尚未执行的结构升级
+func (r *LightRAG) PendingMigrations(ctx context.Context) ([]Migration, error) {
+	c, err := r.maintenanceCollection()
+	if err != nil {
+		return nil, err
+	}
+	return pendingMigrations(ctx, c.db, c.tableName)
+}
@@ -0,0 +1,143 @@
+package lightrag
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+
+	duckdb_driver "github.com/mozhou-tech/sqlite-ai-driver/pkg/duckdb-driver"
+)
+
+// openMigrationTestDB 打开共享的 DuckDB 数据库，并用旧版本的表结构（没有 content_tokens 等列）创建 tableName
+func openMigrationTestDB(t *testing.T, tableName string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("duckdb", duckdb_driver.INDEX_DB_FILE)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	cleanup := func() {
+		db.Exec(`DROP TABLE IF EXISTS ` + tableName)
+		db.Exec(`DELETE FROM `+schemaVersionTable+` WHERE table_name = ?`, tableName)
+	}
+	if err := ensureSchemaVersionTable(context.Background(), db); err != nil {
+		t.Fatalf("failed to create schema version table: %v", err)
+	}
+	cleanup()
+	t.Cleanup(func() {
+		cleanup()
+		db.Close()
+	})
+
+	_, err = db.Exec(`
+		CREATE TABLE ` + tableName + ` (
+			id VARCHAR PRIMARY KEY,
+			content TEXT,
+			metadata JSON,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			_rev INTEGER DEFAULT 1
+		)
+	`)
+	if err != nil {
+		t.Fatalf("failed to create legacy table: %v", err)
+	}
+	_, err = db.Exec(`INSERT INTO `+tableName+` (id, content) VALUES ('doc1', ?)`, "自然语言处理是人工智能的重要分支")
+	if err != nil {
+		t.Fatalf("failed to insert legacy document: %v", err)
+	}
+	return db
+}
+
+func TestMigrations_UpgradeLegacyTable(t *testing.T) {
+	ctx := context.Background()
+	db := openMigrationTestDB(t, "migration_test_legacy")
+	d := &duckdbDatabase{db: db}
+
+	if _, err := d.Collection(ctx, "migration_test_legacy", Schema{PrimaryKey: "id"}); err != nil {
+		t.Fatalf("Collection failed: %v", err)
+	}
+
+	version, err := schemaVersion(ctx, db, "migration_test_legacy")
+	if err != nil {
+		t.Fatalf("schemaVersion failed: %v", err)
+	}
+	if version != latestSchemaVersion() {
+		t.Errorf("expected version %d, got %d", latestSchemaVersion(), version)
+	}
+
+	var status, tokens string
+	var length int
+	err = db.QueryRow(`SELECT embedding_status, chunk_length, content_tokens FROM migration_test_legacy WHERE id = 'doc1'`).
+		Scan(&status, &length, &tokens)
+	if err != nil {
+		t.Fatalf("failed to query upgraded document: %v", err)
+	}
+	if status != "pending" {
+		t.Errorf("expected embedding_status pending, got %s", status)
+	}
+	if length != len([]rune("自然语言处理是人工智能的重要分支")) {
+		t.Errorf("unexpected chunk_length %d", length)
+	}
+	if tokens == "" {
+		t.Error("content_tokens should be backfilled")
+	}
+
+	// 再次打开不会重复执行
+	applied, err := migrateCollection(ctx, db, "migration_test_legacy", true)
+	if err != nil || len(applied) != 0 {
+		t.Errorf("expected no migrations on second run, got %v, %v", applied, err)
+	}
+}
+
+func TestMigrations_Skip(t *testing.T) {
+	ctx := context.Background()
+	db := openMigrationTestDB(t, "migration_test_skip")
+	d := &duckdbDatabase{db: db, skipMigrations: true}
+
+	if _, err := d.Collection(ctx, "migration_test_skip", Schema{PrimaryKey: "id"}); err != nil {
+		t.Fatalf("Collection failed: %v", err)
+	}
+	pending, err := pendingMigrations(ctx, db, "migration_test_skip")
+	if err != nil {
+		t.Fatalf("pendingMigrations failed: %v", err)
+	}
+	if len(pending) != len(schemaMigrations) {
+		t.Errorf("expected %d pending migrations, got %d", len(schemaMigrations), len(pending))
+	}
+
+	applied, err := migrateCollection(ctx, db, "migration_test_skip", true)
+	if err != nil {
+		t.Fatalf("migrateCollection failed: %v", err)
+	}
+	if len(applied) != len(schemaMigrations) {
+		t.Errorf("expected %d applied migrations, got %d", len(schemaMigrations), len(applied))
+	}
+}
+
+func TestMigrations_NewerSchemaRejected(t *testing.T) {
+	ctx := context.Background()
+	db := openMigrationTestDB(t, "migration_test_newer")
+	d := &duckdbDatabase{db: db}
+
+	_, err := db.Exec(`INSERT INTO `+schemaVersionTable+` (table_name, version, description) VALUES (?, ?, 'from the future')`,
+		"migration_test_newer", latestSchemaVersion()+1)
+	if err != nil {
+		t.Fatalf("failed to record future version: %v", err)
+	}
+
+	_, err = d.Collection(ctx, "migration_test_newer", Schema{PrimaryKey: "id"})
+	if err == nil || !strings.Contains(err.Error(), "newer than supported") {
+		t.Errorf("expected newer schema error, got %v", err)
+	}
+}
+
+func TestMigrations_Ordered(t *testing.T) {
+	for i, m := range schemaMigrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %d has version %d, versions must be consecutive starting at 1", i, m.Version)
+		}
+		if m.Description == "" || m.apply == nil {
+			t.Errorf("migration %d is incomplete", m.Version)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package lightrag
+
+// selectMMR 用 MMR（maximal marginal relevance）从按分数降序的候选中选出 limit 个结果：
+// 每次选择 lambda*相关性 - (1-lambda)*与已选结果的最大相似度 最大的候选，相关性和相似度均为余弦相似度
+// 返回的结果按选中的顺序排列，保留原始分数
+func selectMMR(query []float64, candidates []VectorSearchResult, vectors [][]float64, limit int, lambda float64) []VectorSearchResult {
+	if len(candidates) <= 1 || len(vectors) != len(candidates) {
+		if len(candidates) > limit {
+			return candidates[:limit]
+		}
+		return candidates
+	}
+
+	relevance := make([]float64, len(candidates))
+	for i, vec := range vectors {
+		relevance[i] = cosineSimilarity(query, vec)
+	}
+	// maxSim[i] 候选 i 与已选结果的最大相似度
+	maxSim := make([]float64, len(candidates))
+	for i := range maxSim {
+		maxSim[i] = -1
+	}
+	used := make([]bool, len(candidates))
+
+	selected := make([]VectorSearchResult, 0, limit)
+	for len(selected) < limit && len(selected) < len(candidates) {
+		best := -1
+		bestScore := 0.0
+		for i := range candidates {
+			if used[i] {
+				continue
+			}
+			score := lambda * relevance[i]
+			if len(selected) > 0 {
+				score -= (1 - lambda) * maxSim[i]
+			}
+			if best < 0 || score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		used[best] = true
+		selected = append(selected, candidates[best])
+		for i := range candidates {
+			if !used[i] {
+				if sim := cosineSimilarity(vectors[i], vectors[best]); sim > maxSim[i] {
+					maxSim[i] = sim
+				}
+			}
+		}
+	}
+	return selected
+}
+
+// toFloat64Slice 把 DuckDB FLOAT[] 列扫描出的值转换为 []float64
+func toFloat64Slice(v any) []float64 {
+	switch vals := v.(type) {
+	case []float64:
+		return vals
+	case []float32:
+		out := make([]float64, len(vals))
+		for i, f := range vals {
+			out[i] = float64(f)
+		}
+		return out
+	case []any:
+		out := make([]float64, 0, len(vals))
+		for _, item := range vals {
+			switch f := item.(type) {
+			case float32:
+				out = append(out, float64(f))
+			case float64:
+				out = append(out, f)
+			default:
+				return nil
+			}
+		}
+		return out
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package lightrag
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ChunkParentField、ChunkIndexField 分块的顺序元数据：分块所属原文档的 ID 和分块在原文档中的序号（从 0 开始），
+// 与 tfidf 分块器写入的元数据一致；导入时设置后 QueryParam.ExpandNeighbors 据此找到相邻的分块
+const (
+	ChunkParentField = "parent_id"
+	ChunkIndexField  = "chunk_index"
+)
+
+// maxExpandNeighbors QueryParam.ExpandNeighbors 的上限，避免上下文过长
+const maxExpandNeighbors = 5
+
+// chunkPosition 返回分块的原文档 ID 和序号，没有顺序元数据时返回 false
+func chunkPosition(metadata map[string]any) (string, int, bool) {
+	parent, _ := metadata[ChunkParentField].(string)
+	if parent == "" {
+		return "", 0, false
+	}
+	switch v := metadata[ChunkIndexField].(type) {
+	case int:
+		return parent, v, true
+	case int64:
+		return parent, int(v), true
+	case float64:
+		return parent, int(v), true
+	case json.Number:
+		n, err := v.Int64()
+		return parent, int(n), err == nil
+	}
+	return "", 0, false
+}
+
+// expandNeighbors 把检索到的分块与同一原文档中前后各 n 个分块按顺序拼接，回答不会因为截断在分块边界而缺少上下文
+// 每个分块只出现一次：分块已经包含在排名更靠前的结果中时去掉该结果；没有顺序元数据的结果保持不变。
+// 相邻分块同样受 AllowedLabels 限制；读取失败时记录日志并使用原来的结果
+func (r *LightRAG) expandNeighbors(ctx context.Context, results []SearchResult, n int, allowedLabels []string) []SearchResult {
+	if n > maxExpandNeighbors {
+		n = maxExpandNeighbors
+	}
+	if n <= 0 || r.docs == nil {
+		return results
+	}
+
+	// 按原文档收集需要读取的序号
+	wanted := make(map[string]map[int]bool)
+	for _, res := range results {
+		parent, index, ok := chunkPosition(res.Metadata)
+		if !ok {
+			continue
+		}
+		if wanted[parent] == nil {
+			wanted[parent] = make(map[int]bool)
+		}
+		for i := index - n; i <= index+n; i++ {
+			if i >= 0 && i != index {
+				wanted[parent][i] = true
+			}
+		}
+	}
+	chunks := make(map[string]map[int]string) // 原文档 ID -> 序号 -> 内容
+	for parent, indexes := range wanted {
+		values := make([]any, 0, len(indexes))
+		for i := range indexes {
+			values = append(values, i)
+		}
+		selector := withAllowedLabels(map[string]any{
+			ChunkParentField: parent,
+			ChunkIndexField:  map[string]any{"$in": values},
+		}, allowedLabels)
+		docs, err := r.docs.Find(ctx, FindOptions{Selector: selector, Limit: len(values)})
+		if err != nil {
+			logrus.WithError(err).WithField("parent_id", parent).Warn("Failed to load neighbor chunks")
+			return results
+		}
+		chunks[parent] = make(map[int]string, len(docs))
+		for _, doc := range docs {
+			data := doc.Data()
+			if _, index, ok := chunkPosition(data); ok {
+				content, _ := data["content"].(string)
+				chunks[parent][index] = content
+			}
+		}
+	}
+
+	used := make(map[string]map[int]bool)
+	expanded := make([]SearchResult, 0, len(results))
+	for _, res := range results {
+		parent, index, ok := chunkPosition(res.Metadata)
+		if !ok {
+			expanded = append(expanded, res)
+			continue
+		}
+		if used[parent] == nil {
+			used[parent] = make(map[int]bool)
+		}
+		if used[parent][index] {
+			continue
+		}
+		parts := map[int]string{index: res.Content}
+		for i, content := range chunks[parent] {
+			if i >= index-n && i <= index+n && !used[parent][i] {
+				parts[i] = content
+			}
+		}
+		indexes := make([]int, 0, len(parts))
+		for i := range parts {
+			indexes = append(indexes, i)
+			used[parent][i] = true
+		}
+		sort.Ints(indexes)
+		texts := make([]string, len(indexes))
+		for i, idx := range indexes {
+			texts[i] = parts[idx]
+		}
+		res.Content = strings.Join(texts, "\n")
+		expanded = append(expanded, res)
+	}
+	return expanded
+}
@@ -0,0 +1,74 @@
+package lightrag
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	duckdb_driver "github.com/mozhou-tech/sqlite-ai-driver/pkg/duckdb-driver"
+)
+
+func TestExpandNeighbors(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("duckdb", duckdb_driver.INDEX_DB_FILE)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	const table = "lightrag_neighbors_test"
+	db.Exec(`DROP TABLE IF EXISTS ` + table)
+	t.Cleanup(func() {
+		db.Exec(`DROP TABLE IF EXISTS ` + table)
+		db.Close()
+	})
+	if _, err := db.Exec(`CREATE TABLE ` + table + ` (id VARCHAR PRIMARY KEY, content TEXT, metadata JSON, state VARCHAR DEFAULT 'active', created_at BIGINT DEFAULT 0)`); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	insert := func(parent string, index int, metadata string) {
+		id := fmt.Sprintf("%s_chunk_%d", parent, index)
+		if metadata == "" {
+			metadata = fmt.Sprintf(`{"parent_id": %q, "chunk_index": %d}`, parent, index)
+		}
+		if _, err := db.Exec(`INSERT INTO `+table+` (id, content, metadata) VALUES (?, ?, ?::JSON)`, id, id, metadata); err != nil {
+			t.Fatalf("failed to insert chunk: %v", err)
+		}
+	}
+	for i := 0; i < 6; i++ {
+		insert("a.md", i, "")
+	}
+	insert("b.md", 0, "")
+	insert("b.md", 1, `{"parent_id": "b.md", "chunk_index": 1, "acl_labels": ["group:hr"]}`)
+
+	rag := &LightRAG{initialized: true, docs: &duckdbCollection{db: db, tableName: table}}
+	hit := func(parent string, index int) SearchResult {
+		id := fmt.Sprintf("%s_chunk_%d", parent, index)
+		// 检索结果的元数据来自 JSON，序号为 float64
+		return SearchResult{ID: id, Content: id, Metadata: map[string]any{ChunkParentField: parent, ChunkIndexField: float64(index)}}
+	}
+	results := []SearchResult{
+		hit("a.md", 2),
+		hit("a.md", 3), // 已包含在上一个结果中
+		hit("b.md", 0),
+		{ID: "plain", Content: "plain"},
+		hit("a.md", 5),
+	}
+
+	expanded := rag.expandNeighbors(ctx, results, 1, []string{"group:eng"})
+	var contents []string
+	for _, res := range expanded {
+		contents = append(contents, res.Content)
+	}
+	want := []string{
+		"a.md_chunk_1\na.md_chunk_2\na.md_chunk_3",
+		"b.md_chunk_0", // 相邻分块的访问标签不匹配
+		"plain",
+		"a.md_chunk_4\na.md_chunk_5",
+	}
+	if fmt.Sprint(contents) != fmt.Sprint(want) {
+		t.Errorf("expanded = %q, want %q", contents, want)
+	}
+
+	if expanded := rag.expandNeighbors(ctx, results, 0, nil); len(expanded) != len(results) {
+		t.Errorf("expected results to be unchanged without expansion, got %d", len(expanded))
+	}
+}
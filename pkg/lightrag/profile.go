@@ -0,0 +1,156 @@
+package lightrag
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RetrievalProfile 一种查询模式的检索参数，通过 Options.RetrievalProfiles 按模式覆盖
+// 值为 0 的字段使用该模式的默认值（DefaultRetrievalProfile）
+type RetrievalProfile struct {
+	// TopK QueryParam.Limit 未设置时返回的结果数
+	TopK int
+	// VectorTopK 每次向量检索的候选数，0 表示与返回结果数相同
+	VectorTopK int
+	// FulltextTopK 全文检索的候选数，0 表示与返回结果数相同
+	FulltextTopK int
+	// GraphDocTopK graph 模式最多读取的关联文档数，0 表示返回结果数的 2 倍
+	GraphDocTopK int
+	// GraphDepth 从关键词实体出发遍历子图的深度
+	GraphDepth int
+	// ExpansionTopK 关键词实体在图中没有关系时，通过向量检索扩展实体所用的候选文档数，小于 0 表示不扩展
+	ExpansionTopK int
+	// VectorScoreFloor 扩展实体时候选文档的最低向量分数
+	VectorScoreFloor float64
+	// FulltextWeight 朴素混合检索（向量 + 全文）RRF 融合时全文结果的权重，向量结果的权重为 1
+	FulltextWeight float64
+	// Rerank 按查询与结果内容的向量相似度重新排序，需要 Embedder，每个结果多一次 Embed 调用
+	Rerank bool
+	// MaxRedundancy 合并 local 和 global 结果时，两个结果内容的 SimHash 相似度（0-1）超过该值视为重复，
+	// 只保留分数较高的一个，用于去掉分块重叠产生的近似重复内容；0 表示只按 ID 去重，建议 0.9 左右
+	MaxRedundancy float64
+}
+
+// DefaultRetrievalProfile 返回查询模式的默认检索参数
+func DefaultRetrievalProfile(mode QueryMode) RetrievalProfile {
+	p := RetrievalProfile{
+		TopK:             5,
+		GraphDepth:       1,
+		ExpansionTopK:    3,
+		VectorScoreFloor: 0.75,
+		FulltextWeight:   1,
+	}
+	if mode == ModeGraph {
+		// 纯图谱查询：遍历更深，不借助向量检索扩展实体
+		p.GraphDepth = 2
+		p.ExpansionTopK = -1
+	}
+	return p
+}
+
+// retrievalProfile 合并 Options.RetrievalProfiles 中的配置和模式的默认值
+func (r *LightRAG) retrievalProfile(mode QueryMode) RetrievalProfile {
+	p := DefaultRetrievalProfile(mode)
+	custom, ok := r.profiles[mode]
+	if !ok {
+		return p
+	}
+	if custom.TopK > 0 {
+		p.TopK = custom.TopK
+	}
+	if custom.VectorTopK > 0 {
+		p.VectorTopK = custom.VectorTopK
+	}
+	if custom.FulltextTopK > 0 {
+		p.FulltextTopK = custom.FulltextTopK
+	}
+	if custom.GraphDocTopK > 0 {
+		p.GraphDocTopK = custom.GraphDocTopK
+	}
+	if custom.GraphDepth > 0 {
+		p.GraphDepth = custom.GraphDepth
+	}
+	if custom.ExpansionTopK != 0 {
+		p.ExpansionTopK = custom.ExpansionTopK
+	}
+	if custom.VectorScoreFloor > 0 {
+		p.VectorScoreFloor = custom.VectorScoreFloor
+	}
+	if custom.FulltextWeight > 0 {
+		p.FulltextWeight = custom.FulltextWeight
+	}
+	if custom.MaxRedundancy > 0 {
+		p.MaxRedundancy = custom.MaxRedundancy
+	}
+	p.Rerank = custom.Rerank
+	return p
+}
+
+// vectorLimit 向量检索的候选数
+func (p RetrievalProfile) vectorLimit(limit int) int {
+	if p.VectorTopK > 0 {
+		return p.VectorTopK
+	}
+	return limit
+}
+
+// fulltextLimit 全文检索的候选数
+func (p RetrievalProfile) fulltextLimit(limit int) int {
+	if p.FulltextTopK > 0 {
+		return p.FulltextTopK
+	}
+	return limit
+}
+
+// graphDocLimit graph 模式读取的关联文档数
+func (p RetrievalProfile) graphDocLimit(limit int) int {
+	if p.GraphDocTopK > 0 {
+		return p.GraphDocTopK
+	}
+	return limit * 2
+}
+
+// rerankResults 按查询与结果内容的向量相似度重新排序，Embedder 不可用或调用失败时保持原顺序
+func (r *LightRAG) rerankResults(ctx context.Context, query string, results []SearchResult) []SearchResult {
+	if r.embedder == nil || len(results) < 2 {
+		return results
+	}
+	queryEmb, err := r.embedder.Embed(ctx, query)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to embed query for rerank, keeping original order")
+		return results
+	}
+	reranked := make([]SearchResult, len(results))
+	for i, res := range results {
+		emb, err := r.embedder.Embed(ctx, res.Content)
+		if err != nil {
+			logrus.WithError(err).WithField("id", res.ID).Warn("Failed to embed result for rerank, keeping original order")
+			return results
+		}
+		reranked[i] = res
+		reranked[i].Score = cosineSimilarity(queryEmb, emb)
+	}
+	sort.SliceStable(reranked, func(i, j int) bool {
+		return reranked[i].Score > reranked[j].Score
+	})
+	return reranked
+}
+
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
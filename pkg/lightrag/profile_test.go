@@ -0,0 +1,62 @@
+package lightrag
+
+import (
+	"context"
+	"testing"
+)
+
+// keywordEmbedder 按文本中是否包含 "go" 生成二维向量
+type keywordEmbedder struct{}
+
+func (keywordEmbedder) Embed(ctx context.Context, text string) ([]float64, error) {
+	for i := 0; i+1 < len(text); i++ {
+		if text[i:i+2] == "go" {
+			return []float64{1, 0}, nil
+		}
+	}
+	return []float64{0, 1}, nil
+}
+
+func (keywordEmbedder) Dimensions() int { return 2 }
+
+func TestRetrievalProfile(t *testing.T) {
+	rag := New(Options{RetrievalProfiles: map[QueryMode]RetrievalProfile{
+		ModeGraph: {TopK: 8, GraphDepth: 3, Rerank: true},
+		ModeLocal: {ExpansionTopK: -1, FulltextWeight: 0.5},
+	}})
+
+	if p := rag.retrievalProfile(ModeHybrid); p != DefaultRetrievalProfile(ModeHybrid) {
+		t.Errorf("unconfigured mode should use defaults, got %+v", p)
+	}
+	graph := rag.retrievalProfile(ModeGraph)
+	if graph.TopK != 8 || graph.GraphDepth != 3 || !graph.Rerank || graph.ExpansionTopK != -1 || graph.VectorScoreFloor != 0.75 {
+		t.Errorf("unexpected graph profile: %+v", graph)
+	}
+	if graph.graphDocLimit(4) != 8 || graph.vectorLimit(4) != 4 {
+		t.Errorf("unexpected stage limits: %d, %d", graph.graphDocLimit(4), graph.vectorLimit(4))
+	}
+	local := rag.retrievalProfile(ModeLocal)
+	if local.ExpansionTopK != -1 || local.FulltextWeight != 0.5 || local.TopK != 5 || local.GraphDepth != 1 {
+		t.Errorf("unexpected local profile: %+v", local)
+	}
+}
+
+func TestRerankResults(t *testing.T) {
+	rag := &LightRAG{embedder: keywordEmbedder{}}
+	results := []SearchResult{
+		{ID: "a", Content: "rust ownership", Score: 0.9},
+		{ID: "b", Content: "go channels", Score: 0.1},
+	}
+	reranked := rag.rerankResults(context.Background(), "go", results)
+	if reranked[0].ID != "b" || reranked[0].Score != 1 || reranked[1].Score != 0 {
+		t.Errorf("unexpected rerank order: %+v", reranked)
+	}
+	if results[0].ID != "a" {
+		t.Error("rerank should not modify the input slice")
+	}
+
+	// 没有 Embedder 时保持原顺序
+	if kept := (&LightRAG{}).rerankResults(context.Background(), "go", results); kept[0].ID != "a" {
+		t.Errorf("expected original order without embedder, got %+v", kept)
+	}
+}
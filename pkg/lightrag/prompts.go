@@ -3,6 +3,7 @@ package lightrag
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/cloudwego/eino/components/prompt"
 	"github.com/cloudwego/eino/schema"
@@ -72,13 +73,35 @@ Context:
 Question: {query}
 
 Answer the question based on the context.
+`
+
+	HyDEPromptTemplate = `
+-Goal-
+Write a short passage that answers the question below, as it might appear in a document of the knowledge base.
+Use specific terms and facts that such a document would likely contain, and write in the same language as the question.
+If you are not sure about the facts, write a plausible answer anyway. Output only the passage.
+
+-Question-
+{query}
+`
+
+	DescriptionSummaryPromptTemplate = `
+-Goal-
+The following descriptions of the entity "{entity}" were extracted from different parts of the documents.
+Combine them into a single comprehensive description. Keep every distinct fact, remove repetition, resolve
+contradictions where possible and write in the same language as the descriptions. Output only the description text.
+
+-Descriptions-
+{descriptions}
 `
 )
 
 var (
-	entityExtractionTemplate prompt.ChatTemplate
-	queryEntityTemplate      prompt.ChatTemplate
-	ragAnswerTemplate        prompt.ChatTemplate
+	entityExtractionTemplate   prompt.ChatTemplate
+	queryEntityTemplate        prompt.ChatTemplate
+	ragAnswerTemplate          prompt.ChatTemplate
+	descriptionSummaryTemplate prompt.ChatTemplate
+	hydeTemplate               prompt.ChatTemplate
 )
 
 func init() {
@@ -93,6 +116,14 @@ func init() {
 	ragAnswerTemplate = prompt.FromMessages(schema.FString,
 		schema.UserMessage(RAGAnswerPromptTemplate),
 	)
+
+	descriptionSummaryTemplate = prompt.FromMessages(schema.FString,
+		schema.UserMessage(DescriptionSummaryPromptTemplate),
+	)
+
+	hydeTemplate = prompt.FromMessages(schema.FString,
+		schema.UserMessage(HyDEPromptTemplate),
+	)
 }
 
 func GetExtractionPrompt(ctx context.Context, text string) (string, error) {
@@ -130,3 +161,89 @@ func GetRAGAnswerPrompt(ctx context.Context, contextText, query string) (string,
 	}
 	return msgs[0].Content, nil
 }
+
+func GetDescriptionSummaryPrompt(ctx context.Context, entity string, descriptions []string) (string, error) {
+	var sb strings.Builder
+	for _, d := range descriptions {
+		sb.WriteString("- ")
+		sb.WriteString(d)
+		sb.WriteString("\n")
+	}
+	msgs, err := descriptionSummaryTemplate.Format(ctx, map[string]any{
+		"entity":       entity,
+		"descriptions": sb.String(),
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(msgs) == 0 {
+		return "", fmt.Errorf("no messages generated for description summary prompt")
+	}
+	return msgs[0].Content, nil
+}
+
+func GetHyDEPrompt(ctx context.Context, query string) (string, error) {
+	msgs, err := hydeTemplate.Format(ctx, map[string]any{"query": query})
+	if err != nil {
+		return "", err
+	}
+	if len(msgs) == 0 {
+		return "", fmt.Errorf("no messages generated for HyDE prompt")
+	}
+	return msgs[0].Content, nil
+}
+
+// extractionSchema 图谱提取结果的 JSON Schema，与 ExtractionResult 对应
+var extractionSchema = &JSONSchema{
+	Name:        "graph_extraction",
+	Description: "Entities and relationships extracted from the text",
+	Schema: map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"entities": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"name":        map[string]any{"type": "string"},
+						"type":        map[string]any{"type": "string"},
+						"description": map[string]any{"type": "string"},
+					},
+					"required":             []string{"name", "type", "description"},
+					"additionalProperties": false,
+				},
+			},
+			"relationships": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"source":      map[string]any{"type": "string"},
+						"target":      map[string]any{"type": "string"},
+						"relation":    map[string]any{"type": "string"},
+						"description": map[string]any{"type": "string"},
+					},
+					"required":             []string{"source", "target", "relation", "description"},
+					"additionalProperties": false,
+				},
+			},
+		},
+		"required":             []string{"entities", "relationships"},
+		"additionalProperties": false,
+	},
+}
+
+// queryKeywordsSchema 查询关键词的 JSON Schema，与 QueryKeywords 对应
+var queryKeywordsSchema = &JSONSchema{
+	Name:        "query_keywords",
+	Description: "Low-level and high-level keywords of the query",
+	Schema: map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"low_level":  map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			"high_level": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		},
+		"required":             []string{"low_level", "high_level"},
+		"additionalProperties": false,
+	},
+}
@@ -0,0 +1,375 @@
+package lightrag
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+// extractionVersionTable 记录每个文档最近一次成功提取时的提取版本（提示词、输出结构和模型的摘要）
+const extractionVersionTable = "lightrag_extraction_versions"
+
+// ReextractOptions ReextractGraph 的参数
+type ReextractOptions struct {
+	// Filter 只处理元数据匹配的文档（写法与 ListDocumentsOptions.Filters 相同），为空时处理所有 active 和 archived 的文档
+	Filter map[string]any
+	// Force 为 false 时跳过已经用当前提示词和模型提取过的文档，中断后再次调用会从未完成的文档继续；
+	// 为 true 时处理所有匹配的文档
+	Force bool
+	// RequestsPerSecond 每秒最多发起的提取请求数，0 表示不限制
+	RequestsPerSecond float64
+	// Concurrency 同时提取的文档数，默认 1；同时受 Options.MaxConcurrentLLM 限制
+	Concurrency int
+	// Progress 每提取完一个文档（无论成功与否）调用一次，可以为 nil
+	Progress func(done, total int, docID string, err error)
+}
+
+// ReextractResult ReextractGraph 的结果
+type ReextractResult struct {
+	Documents       int `json:"documents"`        // 重新提取的文档数
+	Skipped         int `json:"skipped"`          // 已是当前提取版本而跳过的文档数
+	Failed          int `json:"failed"`           // 提取失败的文档数
+	RemovedEntities int `json:"removed_entities"` // 只出现在这些文档中、被整体清除的实体数
+}
+
+// ModelName 返回模型名称，用于判断更换模型后是否需要重新提取
+func (l *OpenAILLM) ModelName() string {
+	return l.config.Model
+}
+
+// ModelName 返回被包装的 LLM 的模型名称
+func (l *resilientLLM) ModelName() string {
+	return llmModelName(l.llm)
+}
+
+// llmModelName 返回 LLM 的模型名称，不提供 ModelName 方法的 LLM 返回空字符串
+func llmModelName(llm LLM) string {
+	if named, ok := llm.(interface{ ModelName() string }); ok {
+		return named.ModelName()
+	}
+	return ""
+}
+
+// extractionVersion 当前的提取版本：提取提示词、输出结构和模型名称的摘要，任一变化时版本随之变化
+func (r *LightRAG) extractionVersion(ctx context.Context) string {
+	promptStr, _ := GetExtractionPrompt(ctx, "")
+	schema, _ := json.Marshal(extractionSchema)
+	sum := sha256.Sum256([]byte(promptStr + "\x00" + string(schema) + "\x00" + r.llmModel))
+	return hex.EncodeToString(sum[:8])
+}
+
+func ensureExtractionVersionTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			doc_id VARCHAR PRIMARY KEY,
+			version VARCHAR,
+			extracted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`, extractionVersionTable))
+	if err != nil {
+		return fmt.Errorf("failed to create extraction version table: %w", err)
+	}
+	return nil
+}
+
+// recordExtractionVersion 记录文档提取成功时的提取版本，失败只记录日志，不影响提取本身
+func (r *LightRAG) recordExtractionVersion(ctx context.Context, docID string) {
+	c, ok := r.docs.(*duckdbCollection)
+	if !ok || c.db == nil {
+		return
+	}
+	err := ensureExtractionVersionTable(ctx, c.db)
+	if err == nil {
+		_, err = c.db.ExecContext(ctx, fmt.Sprintf(`
+			INSERT OR REPLACE INTO %s (doc_id, version, extracted_at) VALUES (?, ?, ?)
+		`, extractionVersionTable), docID, r.extractionVersion(ctx), time.Now())
+	}
+	if err != nil {
+		logrus.WithError(err).WithField("doc_id", docID).Warn("Failed to record extraction version")
+	}
+}
+
+// ReextractGraph 清除匹配文档提取出的图谱数据，并用当前的提示词和 LLM 重新提取实体和关系，
+// 用于升级提取提示词或更换模型之后。清除时去掉实体到这些文档的 APPEARS_IN 链接，只出现在这些文档中的实体
+// 连同其类型、描述和关系整体删除；同时出现在其他文档中的实体保留已有数据，重新提取的描述会追加。
+// 人工维护过的实体（AddEntity、UpdateEntityDescription 等）不会被删除。
+// 先清除所有文档再逐个提取，中断后以 Force = false 再次调用即可补齐；单个文档提取失败不影响其他文档，所有错误合并返回
+func (r *LightRAG) ReextractGraph(ctx context.Context, opts ReextractOptions) (*ReextractResult, error) {
+	c, err := r.maintenanceCollection()
+	if err != nil {
+		return nil, err
+	}
+	if r.graph == nil {
+		return nil, fmt.Errorf("graph database not available")
+	}
+	if r.llm == nil {
+		return nil, fmt.Errorf("LLM is not available")
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+
+	docs, err := r.reextractCandidates(ctx, c, opts.Filter)
+	if err != nil {
+		return nil, err
+	}
+	result := &ReextractResult{}
+	if !opts.Force {
+		versions, err := loadExtractionVersions(ctx, c.db)
+		if err != nil {
+			return nil, err
+		}
+		current := r.extractionVersion(ctx)
+		pending := docs[:0]
+		for _, doc := range docs {
+			if versions[doc.id] == current {
+				result.Skipped++
+				continue
+			}
+			pending = append(pending, doc)
+		}
+		docs = pending
+	}
+	if len(docs) == 0 {
+		return result, nil
+	}
+
+	ids := make([]string, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.id
+	}
+	if result.RemovedEntities, err = r.clearDocumentGraph(ctx, c.db, ids); err != nil {
+		return result, err
+	}
+
+	var limiter *rate.Limiter
+	if opts.RequestsPerSecond > 0 {
+		limiter = rate.NewLimiter(rate.Limit(opts.RequestsPerSecond), 1)
+	}
+	var mu sync.Mutex
+	var errs []error
+	done := 0
+	queue := make(chan reextractCandidate)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for doc := range queue {
+				err := r.reextractDocument(ctx, limiter, doc)
+				mu.Lock()
+				done++
+				if err != nil {
+					result.Failed++
+					errs = append(errs, fmt.Errorf("failed to extract %s: %w", doc.id, err))
+				} else {
+					result.Documents++
+				}
+				if opts.Progress != nil {
+					opts.Progress(done, len(docs), doc.id, err)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, doc := range docs {
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case queue <- doc:
+		case <-ctx.Done():
+		}
+	}
+	close(queue)
+	wg.Wait()
+	if ctx.Err() != nil {
+		errs = append(errs, ctx.Err())
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"documents":        result.Documents,
+		"skipped":          result.Skipped,
+		"failed":           result.Failed,
+		"removed_entities": result.RemovedEntities,
+	}).Info("Graph re-extracted")
+	r.events.publish(EventMaintenanceCompleted, map[string]any{
+		"task":             "reextract",
+		"documents":        result.Documents,
+		"skipped":          result.Skipped,
+		"failed":           result.Failed,
+		"removed_entities": result.RemovedEntities,
+	})
+	return result, errors.Join(errs...)
+}
+
+// reextractCandidate 需要重新提取的文档
+type reextractCandidate struct {
+	id      string
+	content string
+}
+
+// reextractCandidates 返回元数据匹配 filter 的 active 和 archived 文档，按 ID 排序
+func (r *LightRAG) reextractCandidates(ctx context.Context, c *duckdbCollection, filter map[string]any) ([]reextractCandidate, error) {
+	stateFilter, args, err := stateClause([]DocumentState{StateActive, StateArchived})
+	if err != nil {
+		return nil, err
+	}
+	where := stateFilter
+	clause, filterArgs, err := selectorClauseWithColumns(filter, c.columnTypes())
+	if err != nil {
+		return nil, err
+	}
+	if clause != "" {
+		where += " AND " + clause
+		args = append(args, filterArgs...)
+	}
+	rows, err := c.db.QueryContext(ctx, fmt.Sprintf(`SELECT id, content FROM %s WHERE %s ORDER BY id`, c.tableName, where), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find documents: %w", err)
+	}
+	defer rows.Close()
+	var docs []reextractCandidate
+	for rows.Next() {
+		var doc reextractCandidate
+		if err := rows.Scan(&doc.id, &doc.content); err != nil {
+			return nil, fmt.Errorf("failed to scan document: %w", err)
+		}
+		docs = append(docs, doc)
+	}
+	return docs, rows.Err()
+}
+
+// loadExtractionVersions 读取文档的提取版本，表不存在（从未记录过）时返回空 map
+func loadExtractionVersions(ctx context.Context, db *sql.DB) (map[string]string, error) {
+	if err := ensureExtractionVersionTable(ctx, db); err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`SELECT doc_id, version FROM %s`, extractionVersionTable))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load extraction versions: %w", err)
+	}
+	defer rows.Close()
+	versions := make(map[string]string)
+	for rows.Next() {
+		var id, version string
+		if err := rows.Scan(&id, &version); err != nil {
+			return nil, fmt.Errorf("failed to scan extraction version: %w", err)
+		}
+		versions[id] = version
+	}
+	return versions, rows.Err()
+}
+
+// clearDocumentGraph 去掉实体到文档的 APPEARS_IN 链接，删除不再出现在任何文档中且没有人工维护过的实体，
+// 并清除文档的提取版本（中断后再次调用时会重新提取），返回删除的实体数
+func (r *LightRAG) clearDocumentGraph(ctx context.Context, db *sql.DB, ids []string) (int, error) {
+	affected := make(map[string]bool)
+	for _, id := range ids {
+		entities, err := r.graph.GetInNeighbors(ctx, id, "APPEARS_IN")
+		if err != nil {
+			return 0, fmt.Errorf("failed to get entities of %s: %w", id, err)
+		}
+		for _, entity := range entities {
+			if err := r.graph.Unlink(ctx, entity, "APPEARS_IN", id); err != nil {
+				return 0, fmt.Errorf("failed to unlink %s from %s: %w", entity, id, err)
+			}
+			affected[entity] = true
+		}
+	}
+	if err := ensureExtractionVersionTable(ctx, db); err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		if _, err := db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE doc_id = ?`, extractionVersionTable), id); err != nil {
+			return 0, fmt.Errorf("failed to clear extraction version: %w", err)
+		}
+	}
+
+	entities := make([]string, 0, len(affected))
+	for entity := range affected {
+		entities = append(entities, entity)
+	}
+	sort.Strings(entities)
+	curated, err := curatedSubjects(ctx, db, entities)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, entity := range entities {
+		if curated[entity] {
+			continue
+		}
+		docs, err := r.graph.GetNeighbors(ctx, entity, "APPEARS_IN")
+		if err != nil {
+			return removed, fmt.Errorf("failed to get documents of %s: %w", entity, err)
+		}
+		if len(docs) > 0 {
+			continue
+		}
+		if _, err := r.graph.DeleteNode(ctx, entity); err != nil {
+			return removed, fmt.Errorf("failed to delete entity %s: %w", entity, err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// curatedSubjects 返回有人工维护记录（锁定的类型、描述或删除的关系）的实体
+func curatedSubjects(ctx context.Context, db *sql.DB, entities []string) (map[string]bool, error) {
+	curated := make(map[string]bool)
+	if len(entities) == 0 {
+		return curated, nil
+	}
+	if err := ensureGraphCurationTable(ctx, db); err != nil {
+		return nil, err
+	}
+	args := make([]any, len(entities))
+	for i, entity := range entities {
+		args[i] = entity
+	}
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT DISTINCT subject FROM %s WHERE subject IN (?%s)
+	`, graphCurationTable, strings.Repeat(", ?", len(entities)-1)), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load graph curation: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var subject string
+		if err := rows.Scan(&subject); err != nil {
+			return nil, fmt.Errorf("failed to scan graph curation: %w", err)
+		}
+		curated[subject] = true
+	}
+	return curated, rows.Err()
+}
+
+// reextractDocument 等待速率限制和 LLM 并发名额后提取一个文档
+func (r *LightRAG) reextractDocument(ctx context.Context, limiter *rate.Limiter, doc reextractCandidate) error {
+	if limiter != nil {
+		if err := limiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	if r.llmSem != nil {
+		select {
+		case r.llmSem <- struct{}{}:
+			defer func() { <-r.llmSem }()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return r.extractAndStore(ctx, doc.content, doc.id)
+}
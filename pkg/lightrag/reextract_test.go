@@ -0,0 +1,111 @@
+package lightrag
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+	"testing"
+
+	cayley_driver "github.com/mozhou-tech/sqlite-ai-driver/pkg/cayley-driver"
+	duckdb_driver "github.com/mozhou-tech/sqlite-ai-driver/pkg/duckdb-driver"
+)
+
+func TestReextractGraph(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("duckdb", duckdb_driver.INDEX_DB_FILE)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	const table = "lightrag_reextract_test"
+	drop := func() {
+		for _, name := range []string{table, graphCurationTable, extractionVersionTable} {
+			db.Exec(`DROP TABLE IF EXISTS ` + name)
+		}
+	}
+	drop()
+	t.Cleanup(func() {
+		drop()
+		db.Close()
+	})
+	if _, err := db.Exec(`CREATE TABLE ` + table + ` (id VARCHAR PRIMARY KEY, content TEXT, metadata JSON, state VARCHAR DEFAULT 'active')`); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	for _, row := range [][3]string{
+		{"a", `{"source_file": "a.md"}`, "active"},
+		{"b", `{"source_file": "b.md"}`, "active"},
+		{"c", `{"source_file": "a.md"}`, "deleted"},
+	} {
+		if _, err := db.Exec(`INSERT INTO `+table+` (id, content, metadata, state) VALUES (?, 'content', ?::JSON, ?)`, row[0], row[1], row[2]); err != nil {
+			t.Fatalf("failed to insert document: %v", err)
+		}
+	}
+	graph, err := cayley_driver.NewGraphWithNamespace(t.TempDir(), cayley_driver.GRAPH_DB_FILE, "reextract_test_")
+	if err != nil {
+		t.Fatalf("failed to create graph: %v", err)
+	}
+	t.Cleanup(func() { graph.Close() })
+
+	var calls atomic.Int32
+	llm := &FlexibleLLM{ResponseFunc: func(prompt string) (string, error) {
+		calls.Add(1)
+		return `{"entities": [{"name": "New", "type": "Concept"}], "relationships": []}`, nil
+	}}
+	rag := &LightRAG{initialized: true, llm: llm, llmModel: "model-a", docs: &duckdbCollection{db: db, tableName: table}, graph: &duckdbGraphDatabase{graph: graph}}
+
+	// 旧的提取结果：Old 只出现在 a 中，Shared 同时出现在 a、b 中，Curated 有人工修改
+	rag.graph.Link(ctx, "Old", "APPEARS_IN", "a")
+	rag.graph.Link(ctx, "Old", "TYPE", "Thing")
+	rag.graph.Link(ctx, "Old", "RELATED_TO", "Shared")
+	rag.graph.Link(ctx, "Shared", "APPEARS_IN", "a")
+	rag.graph.Link(ctx, "Shared", "APPEARS_IN", "b")
+	rag.graph.Link(ctx, "Curated", "APPEARS_IN", "a")
+	rag.graph.Link(ctx, "Curated", "TYPE", "Person")
+	if err := recordGraphCuration(ctx, db, curationLocked, "Curated", "TYPE", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	var progress []string
+	result, err := rag.ReextractGraph(ctx, ReextractOptions{
+		Filter:            map[string]any{"source_file": "a.md"},
+		RequestsPerSecond: 100,
+		Progress: func(done, total int, docID string, err error) {
+			progress = append(progress, docID)
+		},
+	})
+	if err != nil {
+		t.Fatalf("ReextractGraph failed: %v", err)
+	}
+	if result.Documents != 1 || result.Skipped != 0 || result.Failed != 0 || result.RemovedEntities != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if len(progress) != 1 || progress[0] != "a" {
+		t.Errorf("unexpected progress: %v", progress)
+	}
+	if triples, _ := rag.graph.GetNeighbors(ctx, "Old", "TYPE"); len(triples) != 0 {
+		t.Errorf("expected entity only in the document to be removed, got %v", triples)
+	}
+	if docs, _ := rag.graph.GetNeighbors(ctx, "Shared", "APPEARS_IN"); len(docs) != 1 || docs[0] != "b" {
+		t.Errorf("expected shared entity to keep other documents, got %v", docs)
+	}
+	if types, _ := rag.graph.GetNeighbors(ctx, "Curated", "TYPE"); len(types) != 1 {
+		t.Errorf("expected curated entity to be kept, got %v", types)
+	}
+	if entities, _ := rag.graph.GetInNeighbors(ctx, "a", "APPEARS_IN"); len(entities) != 1 || entities[0] != "New" {
+		t.Errorf("expected re-extracted entities, got %v", entities)
+	}
+
+	// 提取版本未变时跳过，更换模型或 Force 时重新提取
+	result, err = rag.ReextractGraph(ctx, ReextractOptions{Filter: map[string]any{"source_file": "a.md"}})
+	if err != nil || result.Documents != 0 || result.Skipped != 1 || calls.Load() != 1 {
+		t.Errorf("expected document to be skipped, got %+v, %v, %d calls", result, err, calls.Load())
+	}
+	rag.llmModel = "model-b"
+	result, err = rag.ReextractGraph(ctx, ReextractOptions{})
+	if err != nil || result.Documents != 2 || result.Skipped != 0 {
+		t.Errorf("expected documents to be re-extracted after changing model, got %+v, %v", result, err)
+	}
+	result, err = rag.ReextractGraph(ctx, ReextractOptions{Force: true, Concurrency: 2})
+	if err != nil || result.Documents != 2 || calls.Load() != 5 {
+		t.Errorf("expected forced re-extraction, got %+v, %v, %d calls", result, err, calls.Load())
+	}
+}
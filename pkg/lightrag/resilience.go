@@ -0,0 +1,375 @@
+package lightrag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrCircuitOpen 熔断器打开期间直接返回的错误
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CallFunc 一次 LLM 或 Embedder 调用
+type CallFunc func(ctx context.Context) error
+
+// CallMiddleware 包装调用以添加超时、重试、熔断等策略，可以任意组合，排在前面的在外层
+type CallMiddleware func(next CallFunc) CallFunc
+
+// CallPolicy LLM 和 Embedder 调用的超时、重试和熔断策略，零值字段表示不启用对应功能
+// 通过 Options.CallPolicy 配置后，LightRAG 使用的 LLM 和 Embedder 会被分别包装（各自拥有独立的熔断器）
+type CallPolicy struct {
+	// Timeout 单次调用（每次重试单独计时）的超时
+	Timeout time.Duration
+	// Retry 可重试错误（429、5xx、网络错误和单次调用超时）的重试策略
+	Retry RetryPolicy
+	// BreakerThreshold 连续多少次调用失败（重试之后仍失败）后熔断
+	BreakerThreshold int
+	// BreakerCooldown 熔断持续的时间，之后放行一次试探调用，默认 30 秒
+	BreakerCooldown time.Duration
+}
+
+// RetryPolicy 重试策略，退避时间从 InitialBackoff 开始逐次翻倍并加入随机抖动，不超过 MaxBackoff
+// 服务返回 Retry-After 时至少等待该时间
+type RetryPolicy struct {
+	// MaxRetries 失败后最多重试的次数，0 表示不重试
+	MaxRetries int
+	// InitialBackoff 第一次重试前的等待时间，默认 500 毫秒
+	InitialBackoff time.Duration
+	// MaxBackoff 最长等待时间，默认 30 秒
+	MaxBackoff time.Duration
+	// Retryable 判断错误是否可以重试，默认为 IsRetryable
+	Retryable func(error) bool
+}
+
+// DefaultCallPolicy 适合调用在线服务的默认策略：单次 60 秒超时，最多重试 3 次，连续 5 次失败后熔断 30 秒
+func DefaultCallPolicy() *CallPolicy {
+	return &CallPolicy{
+		Timeout: 60 * time.Second,
+		Retry: RetryPolicy{
+			MaxRetries:     3,
+			InitialBackoff: 500 * time.Millisecond,
+			MaxBackoff:     30 * time.Second,
+		},
+		BreakerThreshold: 5,
+		BreakerCooldown:  30 * time.Second,
+	}
+}
+
+// Middlewares 按熔断、重试、超时的顺序返回策略对应的中间件，每次调用都会创建新的熔断器
+func (p *CallPolicy) Middlewares() []CallMiddleware {
+	if p == nil {
+		return nil
+	}
+	var mws []CallMiddleware
+	if p.BreakerThreshold > 0 {
+		mws = append(mws, WithCircuitBreaker(NewCircuitBreaker(p.BreakerThreshold, p.BreakerCooldown)))
+	}
+	if p.Retry.MaxRetries > 0 {
+		mws = append(mws, WithRetry(p.Retry))
+	}
+	if p.Timeout > 0 {
+		mws = append(mws, WithTimeout(p.Timeout))
+	}
+	return mws
+}
+
+// chainCall 用中间件包装调用
+func chainCall(mws []CallMiddleware, call CallFunc) CallFunc {
+	for i := len(mws) - 1; i >= 0; i-- {
+		call = mws[i](call)
+	}
+	return call
+}
+
+// WithTimeout 为每次调用设置超时，d <= 0 时不做处理
+func WithTimeout(d time.Duration) CallMiddleware {
+	return func(next CallFunc) CallFunc {
+		if d <= 0 {
+			return next
+		}
+		return func(ctx context.Context) error {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			return next(ctx)
+		}
+	}
+}
+
+// WithRetry 对可重试的错误按指数退避重试，ctx 结束时停止
+func WithRetry(policy RetryPolicy) CallMiddleware {
+	if policy.InitialBackoff <= 0 {
+		policy.InitialBackoff = 500 * time.Millisecond
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = 30 * time.Second
+	}
+	if policy.Retryable == nil {
+		policy.Retryable = IsRetryable
+	}
+	return func(next CallFunc) CallFunc {
+		return func(ctx context.Context) error {
+			backoff := policy.InitialBackoff
+			for attempt := 1; ; attempt++ {
+				err := next(ctx)
+				if err == nil {
+					return nil
+				}
+				if attempt > policy.MaxRetries || ctx.Err() != nil || !policy.Retryable(err) {
+					if attempt > 1 {
+						return fmt.Errorf("failed after %d attempts: %w", attempt, err)
+					}
+					return err
+				}
+
+				// 全抖动：在 [backoff/2, backoff] 之间随机等待，避免大量文档同时重试
+				wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+				if retryAfter := retryAfterOf(err); retryAfter > wait {
+					wait = retryAfter
+				}
+				if wait > policy.MaxBackoff {
+					wait = policy.MaxBackoff
+				}
+				logrus.WithError(err).WithFields(logrus.Fields{
+					"attempt": attempt,
+					"wait":    wait,
+				}).Warn("Retrying model call")
+
+				timer := time.NewTimer(wait)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return err
+				case <-timer.C:
+				}
+				backoff *= 2
+				if backoff > policy.MaxBackoff {
+					backoff = policy.MaxBackoff
+				}
+			}
+		}
+	}
+}
+
+// WithCircuitBreaker 使用熔断器保护调用：熔断期间直接返回 ErrCircuitOpen，不再请求已经不可用的服务
+func WithCircuitBreaker(b *CircuitBreaker) CallMiddleware {
+	return func(next CallFunc) CallFunc {
+		return func(ctx context.Context) error {
+			if err := b.allow(); err != nil {
+				return err
+			}
+			err := next(ctx)
+			b.record(err)
+			return err
+		}
+	}
+}
+
+// 熔断器状态
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// CircuitBreaker 连续失败达到阈值后打开，冷却时间过后放行一次试探调用：成功则关闭，失败则重新打开
+// 只有可重试的错误（服务不可用、限流、超时等）计为失败，请求本身有误（如 400）不影响熔断状态
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	probing   bool
+}
+
+// NewCircuitBreaker 创建熔断器，cooldown <= 0 时为 30 秒
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		threshold = 1
+	}
+	if cooldown <= 0 {
+		cooldown = 30 * time.Second
+	}
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// State 返回熔断器当前状态
+func (b *CircuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case b.failures < b.threshold:
+		return BreakerClosed
+	case b.probing || time.Since(b.openedAt) >= b.cooldown:
+		return BreakerHalfOpen
+	default:
+		return BreakerOpen
+	}
+}
+
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return nil
+	}
+	if b.probing || time.Since(b.openedAt) < b.cooldown {
+		return ErrCircuitOpen
+	}
+	b.probing = true
+	return nil
+}
+
+func (b *CircuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	wasProbing := b.probing
+	b.probing = false
+	if err == nil || !IsRetryable(err) {
+		if err == nil || wasProbing {
+			b.failures = 0
+		}
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		if b.failures == b.threshold || wasProbing {
+			logrus.WithError(err).WithField("cooldown", b.cooldown).Warn("Circuit breaker opened")
+		}
+		b.openedAt = time.Now()
+	}
+}
+
+// statusCodePattern 从错误信息中识别 HTTP 状态码（如 "status 429"、"status code: 503"）
+var statusCodePattern = regexp.MustCompile(`status(?: code)?:? (\d{3})`)
+
+// IsRetryable 判断错误是否为临时错误：429、5xx、网络错误和单次调用超时
+// 调用方取消（context.Canceled）和熔断不重试
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, ErrCircuitOpen) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return retryableStatus(apiErr.StatusCode)
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	if m := statusCodePattern.FindStringSubmatch(msg); m != nil {
+		code, _ := strconv.Atoi(m[1])
+		return retryableStatus(code)
+	}
+	for _, s := range []string{"rate limit", "timeout", "connection reset", "connection refused", "unexpected eof"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func retryableStatus(code int) bool {
+	return code == 429 || code >= 500
+}
+
+// retryAfterOf 返回服务要求的重试等待时间
+func retryAfterOf(err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.RetryAfter
+	}
+	return 0
+}
+
+// WrapLLM 用中间件包装 LLM，保留 JSON 模式和工具调用能力
+func WrapLLM(llm LLM, mws ...CallMiddleware) LLM {
+	if llm == nil || len(mws) == 0 {
+		return llm
+	}
+	wrapped := &resilientLLM{llm: llm, mws: mws}
+	if _, ok := llm.(ToolCallingLLM); ok {
+		return &resilientToolCallingLLM{wrapped}
+	}
+	return wrapped
+}
+
+type resilientLLM struct {
+	llm LLM
+	mws []CallMiddleware
+}
+
+func (l *resilientLLM) Complete(ctx context.Context, prompt string) (string, error) {
+	var response string
+	err := chainCall(l.mws, func(ctx context.Context) error {
+		var err error
+		response, err = l.llm.Complete(ctx, prompt)
+		return err
+	})(ctx)
+	return response, err
+}
+
+// CompleteStructured 被包装的 LLM 不支持 JSON 模式时退回到 Complete
+func (l *resilientLLM) CompleteStructured(ctx context.Context, prompt string, schema *JSONSchema) (string, error) {
+	var response string
+	err := chainCall(l.mws, func(ctx context.Context) error {
+		var err error
+		response, err = completeJSON(ctx, l.llm, prompt, schema)
+		return err
+	})(ctx)
+	return response, err
+}
+
+type resilientToolCallingLLM struct {
+	*resilientLLM
+}
+
+func (l *resilientToolCallingLLM) CompleteWithTools(ctx context.Context, messages []Message, tools []Tool) (*Message, error) {
+	var reply *Message
+	err := chainCall(l.mws, func(ctx context.Context) error {
+		var err error
+		reply, err = l.llm.(ToolCallingLLM).CompleteWithTools(ctx, messages, tools)
+		return err
+	})(ctx)
+	return reply, err
+}
+
+// WrapEmbedder 用中间件包装 Embedder
+func WrapEmbedder(embedder Embedder, mws ...CallMiddleware) Embedder {
+	if embedder == nil || len(mws) == 0 {
+		return embedder
+	}
+	return &resilientEmbedder{embedder: embedder, mws: mws}
+}
+
+type resilientEmbedder struct {
+	embedder Embedder
+	mws      []CallMiddleware
+}
+
+func (e *resilientEmbedder) Embed(ctx context.Context, text string) ([]float64, error) {
+	var embedding []float64
+	err := chainCall(e.mws, func(ctx context.Context) error {
+		var err error
+		embedding, err = e.embedder.Embed(ctx, text)
+		return err
+	})(ctx)
+	return embedding, err
+}
+
+func (e *resilientEmbedder) Dimensions() int {
+	return e.embedder.Dimensions()
+}
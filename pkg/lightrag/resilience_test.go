@@ -0,0 +1,163 @@
+package lightrag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyEmbedder 前 failures 次调用返回 err
+type flakyEmbedder struct {
+	calls    int
+	failures int
+	err      error
+}
+
+func (e *flakyEmbedder) Embed(ctx context.Context, text string) ([]float64, error) {
+	e.calls++
+	if e.calls <= e.failures {
+		return nil, e.err
+	}
+	return []float64{1, 2, 3}, nil
+}
+
+func (e *flakyEmbedder) Dimensions() int { return 3 }
+
+var fastRetry = RetryPolicy{MaxRetries: 3, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+
+func TestWithRetry(t *testing.T) {
+	ctx := context.Background()
+
+	// 429 和 5xx 重试后成功
+	emb := &flakyEmbedder{failures: 2, err: &APIError{StatusCode: 429}}
+	wrapped := WrapEmbedder(emb, WithRetry(fastRetry))
+	if _, err := wrapped.Embed(ctx, "text"); err != nil {
+		t.Fatalf("expected success after retries, got %v", err)
+	}
+	if emb.calls != 3 {
+		t.Errorf("expected 3 calls, got %d", emb.calls)
+	}
+	if wrapped.Dimensions() != 3 {
+		t.Errorf("Dimensions should be forwarded")
+	}
+
+	// 超过重试次数
+	emb = &flakyEmbedder{failures: 10, err: fmt.Errorf("API request failed with status 503: busy")}
+	_, err := WrapEmbedder(emb, WithRetry(fastRetry)).Embed(ctx, "text")
+	if err == nil || emb.calls != 4 {
+		t.Errorf("expected failure after 4 calls, got %d calls, %v", emb.calls, err)
+	}
+
+	// 请求错误不重试
+	emb = &flakyEmbedder{failures: 10, err: &APIError{StatusCode: 400}}
+	if _, err := WrapEmbedder(emb, WithRetry(fastRetry)).Embed(ctx, "text"); err == nil || emb.calls != 1 {
+		t.Errorf("expected a single call for 400, got %d calls, %v", emb.calls, err)
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	var attempts int32
+	slow := CallFunc(func(ctx context.Context) error {
+		atomic.AddInt32(&attempts, 1)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	call := chainCall([]CallMiddleware{WithRetry(RetryPolicy{MaxRetries: 1, InitialBackoff: time.Millisecond}), WithTimeout(10 * time.Millisecond)}, slow)
+	err := call(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	// 单次调用超时可以重试
+	if atomic.LoadInt32(&attempts) != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	breaker := NewCircuitBreaker(2, 20*time.Millisecond)
+	emb := &flakyEmbedder{failures: 2, err: &APIError{StatusCode: 502}}
+	wrapped := WrapEmbedder(emb, WithCircuitBreaker(breaker))
+
+	wrapped.Embed(ctx, "a")
+	wrapped.Embed(ctx, "b")
+	if breaker.State() != BreakerOpen {
+		t.Fatalf("expected open breaker, got %s", breaker.State())
+	}
+	if _, err := wrapped.Embed(ctx, "c"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+	if emb.calls != 2 {
+		t.Errorf("open breaker should not call the embedder, got %d calls", emb.calls)
+	}
+
+	// 冷却后试探调用成功，熔断器关闭
+	time.Sleep(25 * time.Millisecond)
+	if breaker.State() != BreakerHalfOpen {
+		t.Errorf("expected half-open breaker, got %s", breaker.State())
+	}
+	if _, err := wrapped.Embed(ctx, "d"); err != nil {
+		t.Fatalf("probe call failed: %v", err)
+	}
+	if breaker.State() != BreakerClosed {
+		t.Errorf("expected closed breaker, got %s", breaker.State())
+	}
+}
+
+func TestWrapLLM_RetriesOpenAI(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "rate limited", http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "{\"entities\": []}"}}]}`))
+	}))
+	defer server.Close()
+
+	policy := &CallPolicy{Timeout: time.Second, Retry: fastRetry, BreakerThreshold: 5}
+	llm := WrapLLM(NewOpenAILLM(&OpenAIConfig{BaseURL: server.URL}), policy.Middlewares()...)
+	if _, ok := llm.(ToolCallingLLM); !ok {
+		t.Error("wrapped OpenAILLM should keep tool calling support")
+	}
+	if _, ok := WrapLLM(&FlexibleLLM{}, policy.Middlewares()...).(ToolCallingLLM); ok {
+		t.Error("wrapped LLM without tool calling should not implement ToolCallingLLM")
+	}
+
+	response, err := completeJSON(context.Background(), llm, "extract", extractionSchema)
+	if err != nil {
+		t.Fatalf("expected success after retry, got %v", err)
+	}
+	if response != `{"entities": []}` || atomic.LoadInt32(&requests) != 2 {
+		t.Errorf("unexpected response %q after %d requests", response, requests)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{&APIError{StatusCode: 429}, true},
+		{&APIError{StatusCode: 500}, true},
+		{&APIError{StatusCode: 401}, false},
+		{fmt.Errorf("wrapped: %w", &APIError{StatusCode: 503}), true},
+		{errors.New("error, status code: 429, message: too many requests"), true},
+		{errors.New("API request failed with status 400: bad input"), false},
+		{context.DeadlineExceeded, true},
+		{context.Canceled, false},
+		{ErrCircuitOpen, false},
+		{errors.New("invalid prompt"), false},
+	}
+	for _, c := range cases {
+		if got := IsRetryable(c.err); got != c.want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
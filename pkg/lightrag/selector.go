@@ -0,0 +1,160 @@
+package lightrag
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// selectorClause 把 Selector 转换为 SQL 条件，便于在查询中直接过滤
+// 支持字段值相等（{"source": "wiki"}）以及 $eq、$ne、$in 操作符（{"page": {"$in": [1, 2]}}），
+// $acl 匹配没有访问标签或标签与给定值有交集的文档（{"acl_labels": {"$acl": ["group:eng"]}}），
+// id 和 content 对应同名的列，其他字段从 metadata 中读取；selector 为空时返回空字符串
+func selectorClause(selector map[string]any) (string, []any, error) {
+	return selectorClauseWithColumns(selector, nil)
+}
+
+// selectorClauseWithColumns 与 selectorClause 相同，columns（列名 -> 类型）中的字段直接比较 Schema.Columns 声明的类型化列
+func selectorClauseWithColumns(selector map[string]any, columns map[string]string) (string, []any, error) {
+	keys := make([]string, 0, len(selector))
+	for key := range selector {
+		keys = append(keys, key)
+	}
+	// 固定顺序，便于调试和复用查询计划
+	sort.Strings(keys)
+
+	var conditions []string
+	var args []any
+	for _, key := range keys {
+		if key == "" || strings.HasPrefix(key, "$") {
+			return "", nil, fmt.Errorf("unsupported selector field: %q", key)
+		}
+		column, columnArgs := selectorColumn(key, columns)
+
+		ops, ok := selector[key].(map[string]any)
+		if !ok {
+			ops = map[string]any{"$eq": selector[key]}
+		}
+		opNames := make([]string, 0, len(ops))
+		for op := range ops {
+			opNames = append(opNames, op)
+		}
+		sort.Strings(opNames)
+
+		for _, op := range opNames {
+			switch op {
+			case "$eq", "$ne":
+				value, placeholder, err := selectorValue(key, ops[op], columns)
+				if err != nil {
+					return "", nil, err
+				}
+				if op == "$eq" {
+					conditions = append(conditions, fmt.Sprintf("%s = %s", column, placeholder))
+					args = append(args, columnArgs...)
+				} else {
+					// 字段不存在时也满足 $ne
+					conditions = append(conditions, fmt.Sprintf("(%s IS NULL OR %s <> %s)", column, column, placeholder))
+					args = append(append(args, columnArgs...), columnArgs...)
+				}
+				args = append(args, value)
+			case "$in":
+				values, ok := ops[op].([]any)
+				if !ok || len(values) == 0 {
+					return "", nil, fmt.Errorf("selector %s: $in requires a non-empty array", key)
+				}
+				placeholders := make([]string, len(values))
+				args = append(args, columnArgs...)
+				for i, v := range values {
+					value, placeholder, err := selectorValue(key, v, columns)
+					if err != nil {
+						return "", nil, err
+					}
+					placeholders[i] = placeholder
+					args = append(args, value)
+				}
+				conditions = append(conditions, fmt.Sprintf("%s IN (%s)", column, strings.Join(placeholders, ", ")))
+			case "$acl":
+				// 访问标签（见 ACLLabelsField）：字段不存在，或数组中至少有一个值在 values 中
+				values, ok := ops[op].([]any)
+				if !ok {
+					return "", nil, fmt.Errorf("selector %s: $acl requires an array", key)
+				}
+				if len(values) == 0 {
+					conditions = append(conditions, fmt.Sprintf("%s IS NULL", column))
+					args = append(args, columnArgs...)
+					continue
+				}
+				placeholders := make([]string, len(values))
+				args = append(append(args, columnArgs...), columnArgs...)
+				for i, v := range values {
+					s, ok := v.(string)
+					if !ok {
+						return "", nil, fmt.Errorf("selector %s: $acl requires strings, got %T", key, v)
+					}
+					placeholders[i] = "CAST(? AS VARCHAR)"
+					args = append(args, s)
+				}
+				conditions = append(conditions, fmt.Sprintf("(%s IS NULL OR list_has_any(CAST(%s AS VARCHAR[]), [%s]))", column, column, strings.Join(placeholders, ", ")))
+			default:
+				return "", nil, fmt.Errorf("selector %s: unsupported operator %s", key, op)
+			}
+		}
+	}
+	return strings.Join(conditions, " AND "), args, nil
+}
+
+// selectorColumn 返回字段对应的 SQL 表达式及其参数
+func selectorColumn(key string, columns map[string]string) (string, []any) {
+	if _, ok := columns[key]; ok {
+		return key, nil
+	}
+	switch key {
+	case "id", "content":
+		return key, nil
+	default:
+		return "json_extract(metadata, ?)", []any{jsonPointer(key)}
+	}
+}
+
+// selectorValue 返回比较值的参数和占位符：id 和 content 直接比较字符串，类型化列转换为列的类型比较，
+// 元数据字段按 JSON 比较
+func selectorValue(key string, value any, columns map[string]string) (any, string, error) {
+	if key == "id" || key == "content" {
+		s, ok := value.(string)
+		if !ok {
+			return nil, "", fmt.Errorf("selector %s: expected a string, got %T", key, value)
+		}
+		return s, "?", nil
+	}
+	switch value.(type) {
+	case map[string]any, []any:
+		return nil, "", fmt.Errorf("selector %s: only scalar values are supported", key)
+	}
+	if columnType, ok := columns[key]; ok {
+		return value, fmt.Sprintf("CAST(? AS %s)", columnType), nil
+	}
+	literal, err := jsonLiteral(value)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode selector %s: %w", key, err)
+	}
+	return literal, "?::JSON", nil
+}
+
+// jsonLiteral 按 DuckDB json_extract 的格式编码值（不转义 HTML 字符）
+func jsonLiteral(value any) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// jsonPointer 把元数据字段名转换为 JSON Pointer（RFC 6901），字段名中可以包含 . 和 /
+func jsonPointer(key string) string {
+	key = strings.ReplaceAll(key, "~", "~0")
+	return "/" + strings.ReplaceAll(key, "/", "~1")
+}
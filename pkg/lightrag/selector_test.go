@@ -0,0 +1,251 @@
+package lightrag
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	cayley_driver "github.com/mozhou-tech/sqlite-ai-driver/pkg/cayley-driver"
+	duckdb_driver "github.com/mozhou-tech/sqlite-ai-driver/pkg/duckdb-driver"
+)
+
+func TestSelectorClause(t *testing.T) {
+	clause, args, err := selectorClause(map[string]any{
+		"source": "a<b>",
+		"id":     map[string]any{"$in": []any{"x", "y"}},
+		"page":   map[string]any{"$ne": 2},
+	})
+	if err != nil {
+		t.Fatalf("selectorClause failed: %v", err)
+	}
+	want := "id IN (?, ?) AND (json_extract(metadata, ?) IS NULL OR json_extract(metadata, ?) <> ?::JSON) AND json_extract(metadata, ?) = ?::JSON"
+	if clause != want {
+		t.Errorf("clause = %s\nwant %s", clause, want)
+	}
+	if len(args) != 7 || args[2] != "/page" || args[4] != "2" || args[6] != `"a<b>"` {
+		t.Errorf("unexpected args: %v", args)
+	}
+
+	if clause, args, err := selectorClause(nil); clause != "" || len(args) != 0 || err != nil {
+		t.Errorf("empty selector should produce no clause, got %q, %v, %v", clause, args, err)
+	}
+	for _, selector := range []map[string]any{
+		{"$or": []any{}},
+		{"page": map[string]any{"$gt": 1}},
+		{"tags": []any{"a"}},
+		{"id": 1},
+		{"id": map[string]any{"$in": []any{}}},
+	} {
+		if _, _, err := selectorClause(selector); err == nil {
+			t.Errorf("expected error for selector %v", selector)
+		}
+	}
+}
+
+func TestSelectorPushdown(t *testing.T) {
+	ctx := context.Background()
+	// 分数最高的 4 个结果来源为 a，在 Go 中过滤时会只剩下不到 limit 个结果
+	search := newTestVectorSearch(t, "lightrag_selector_test", map[string]string{
+		"a1": "[1.0, 0.0]",
+		"a2": "[1.0, 0.1]",
+		"a3": "[1.0, 0.2]",
+		"a4": "[1.0, 0.3]",
+		"b1": "[1.0, 0.4]",
+		"b2": "[1.0, 0.5]",
+	})
+	if _, err := search.db.Exec(`UPDATE lightrag_selector_test SET metadata = json_object('source', substr(id, 1, 1), 'page', CAST(substr(id, 2) AS INTEGER))`); err != nil {
+		t.Fatalf("failed to set metadata: %v", err)
+	}
+
+	results, err := search.Search(ctx, []float64{1, 0}, VectorSearchOptions{Limit: 2, Selector: map[string]any{"source": "b"}})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 2 || results[0].Document.ID() != "b1" || results[1].Document.ID() != "b2" {
+		t.Errorf("expected both b documents, got %+v", results)
+	}
+
+	collection := &duckdbCollection{db: search.db, tableName: "lightrag_selector_test"}
+	docs, err := collection.Find(ctx, FindOptions{Selector: map[string]any{"page": map[string]any{"$in": []any{1, 2}}}})
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	var ids []string
+	for _, doc := range docs {
+		ids = append(ids, doc.ID())
+	}
+	if len(ids) != 4 || !strings.Contains(strings.Join(ids, ","), "b2") {
+		t.Errorf("unexpected Find results: %v", ids)
+	}
+}
+
+func TestAccessLabels(t *testing.T) {
+	ctx := context.Background()
+	search := newTestVectorSearch(t, "lightrag_acl_test", map[string]string{
+		"alice":  "[1.0, 0.0]",
+		"eng":    "[1.0, 0.1]",
+		"public": "[1.0, 0.2]",
+		"other":  "[1.0, 0.3]",
+	})
+	for id, labels := range map[string]any{"alice": "user:alice", "eng": []any{"group:eng", "tenant:acme"}, "other": []string{"tenant:other"}} {
+		doc := map[string]any{ACLLabelsField: labels}
+		if err := normalizeDocumentACL(doc); err != nil {
+			t.Fatalf("normalizeDocumentACL failed: %v", err)
+		}
+		metadata, _ := json.Marshal(doc)
+		if _, err := search.db.Exec(`UPDATE lightrag_acl_test SET metadata = ? WHERE id = ?`, string(metadata), id); err != nil {
+			t.Fatalf("failed to set metadata: %v", err)
+		}
+	}
+
+	collection := &duckdbCollection{db: search.db, tableName: "lightrag_acl_test"}
+	for _, tc := range []struct {
+		allowed []string
+		want    string
+	}{
+		{nil, "alice,eng,other,public"},
+		{[]string{}, "public"},
+		{[]string{"user:alice", "tenant:acme"}, "alice,eng,public"},
+	} {
+		selector := withAllowedLabels(map[string]any{ACLLabelsField: "ignored"}, tc.allowed)
+		if tc.allowed == nil {
+			selector = nil
+		}
+		results, err := search.Search(ctx, []float64{1, 0}, VectorSearchOptions{Limit: 10, Selector: selector})
+		if err != nil {
+			t.Fatalf("Search failed: %v", err)
+		}
+		var ids []string
+		for _, r := range results {
+			ids = append(ids, r.Document.ID())
+		}
+		sort.Strings(ids)
+		if got := strings.Join(ids, ","); got != tc.want {
+			t.Errorf("allowed %v: vector search got %s, want %s", tc.allowed, got, tc.want)
+		}
+
+		docs, err := collection.Find(ctx, FindOptions{Selector: selector})
+		if err != nil {
+			t.Fatalf("Find failed: %v", err)
+		}
+		ids = ids[:0]
+		for _, doc := range docs {
+			// 图谱扩展在 Go 中过滤，结果应与 SQL 一致
+			if !matchesFilters(doc.Data(), selector) {
+				t.Errorf("allowed %v: %s found by SQL but rejected by matchesFilters", tc.allowed, doc.ID())
+			}
+			ids = append(ids, doc.ID())
+		}
+		sort.Strings(ids)
+		if got := strings.Join(ids, ","); got != tc.want {
+			t.Errorf("allowed %v: Find got %s, want %s", tc.allowed, got, tc.want)
+		}
+	}
+
+	if matchesFilters(map[string]any{ACLLabelsField: []any{"tenant:other"}}, withAllowedLabels(nil, []string{"user:alice"})) {
+		t.Error("expected document with other labels to be rejected")
+	}
+	if err := normalizeDocumentACL(map[string]any{ACLLabelsField: 1}); err == nil {
+		t.Error("expected error for invalid labels")
+	}
+	doc := map[string]any{ACLLabelsField: " , "}
+	if err := normalizeDocumentACL(doc); err != nil || doc[ACLLabelsField] != nil {
+		t.Errorf("expected empty labels to be removed, got %v, %v", doc, err)
+	}
+}
+
+// selectorFulltext 忽略查询文本，返回所有满足过滤条件的文档，用于不依赖 FTS 扩展的检索测试
+type selectorFulltext struct {
+	docs *duckdbCollection
+}
+
+func (f selectorFulltext) FindWithScores(ctx context.Context, query string, opts FulltextSearchOptions) ([]FulltextSearchResult, error) {
+	docs, err := f.docs.Find(ctx, FindOptions{Selector: opts.Selector, Limit: opts.Limit})
+	if err != nil {
+		return nil, err
+	}
+	results := make([]FulltextSearchResult, len(docs))
+	for i, doc := range docs {
+		results[i] = FulltextSearchResult{Document: doc, Score: 1}
+	}
+	return results, nil
+}
+
+func (f selectorFulltext) Close() error { return nil }
+
+func TestAccessLabelsGraphRetrieval(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("duckdb", duckdb_driver.INDEX_DB_FILE)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	const table = "lightrag_acl_graph_test"
+	db.Exec(`DROP TABLE IF EXISTS ` + table)
+	t.Cleanup(func() {
+		db.Exec(`DROP TABLE IF EXISTS ` + table)
+		db.Close()
+	})
+	if _, err := db.Exec(`CREATE TABLE ` + table + ` (id VARCHAR PRIMARY KEY, content TEXT, metadata JSON, state VARCHAR DEFAULT 'active', created_at BIGINT DEFAULT 0)`); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	for id, metadata := range map[string]string{
+		"public": `{}`,
+		"secret": `{"acl_labels": ["group:hr"]}`,
+	} {
+		if _, err := db.Exec(`INSERT INTO `+table+` (id, content, metadata) VALUES (?, ?, ?::JSON)`, id, "fox "+id, metadata); err != nil {
+			t.Fatalf("failed to insert document: %v", err)
+		}
+	}
+	docs := &duckdbCollection{db: db, tableName: table}
+	graph, err := cayley_driver.NewGraphWithNamespace(t.TempDir(), cayley_driver.GRAPH_DB_FILE, "acl_graph_test_")
+	if err != nil {
+		t.Fatalf("failed to create graph: %v", err)
+	}
+	t.Cleanup(func() { graph.Close() })
+
+	keywordsFail := true
+	llm := &FlexibleLLM{ResponseFunc: func(prompt string) (string, error) {
+		if keywordsFail {
+			return "", fmt.Errorf("llm unavailable")
+		}
+		return `{"low_level": ["Alice"], "high_level": []}`, nil
+	}}
+	rag := &LightRAG{initialized: true, llm: llm, docs: docs, fulltext: selectorFulltext{docs}, graph: &duckdbGraphDatabase{graph: graph}}
+
+	// 关键词提取失败时回退到混合检索，访问标签仍然生效
+	for _, mode := range []QueryMode{ModeLocal, ModeGlobal} {
+		results, err := rag.Retrieve(ctx, "fox", QueryParam{Mode: mode, AllowedLabels: []string{}})
+		if err != nil {
+			t.Fatalf("%s: Retrieve failed: %v", mode, err)
+		}
+		if len(results) != 1 || results[0].ID != "public" {
+			t.Errorf("%s: expected only the public document, got %+v", mode, results)
+		}
+	}
+
+	// 召回的三元组只来自调用方可见的文档
+	keywordsFail = false
+	rag.graph.Link(ctx, "Alice", "APPEARS_IN", "public")
+	rag.graph.Link(ctx, "Bob", "APPEARS_IN", "public")
+	rag.graph.Link(ctx, "Alice", "KNOWS", "Bob")
+	rag.graph.Link(ctx, "Alice", "APPEARS_IN", "secret")
+	rag.graph.Link(ctx, "Carol", "APPEARS_IN", "secret")
+	rag.graph.Link(ctx, "Alice", "REPORTS_TO", "Carol")
+	for _, mode := range []QueryMode{ModeGraph, ModeLocal} {
+		results, err := rag.Retrieve(ctx, "who does alice know", QueryParam{Mode: mode, AllowedLabels: []string{}})
+		if err != nil {
+			t.Fatalf("%s: Retrieve failed: %v", mode, err)
+		}
+		if len(results) != 1 || results[0].ID != "public" {
+			t.Fatalf("%s: expected only the public document, got %+v", mode, results)
+		}
+		triples := results[0].RecalledTriples
+		if len(triples) != 1 || triples[0].Relation != "KNOWS" {
+			t.Errorf("%s: expected only triples from visible documents, got %+v", mode, triples)
+		}
+	}
+}
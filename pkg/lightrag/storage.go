@@ -7,14 +7,13 @@ import (
 	"fmt"
 	"strings"
 	"sync"
-	"time"
+	"sync/atomic"
 
 	cayley_driver "github.com/mozhou-tech/sqlite-ai-driver/pkg/cayley-driver"
 	_ "github.com/mozhou-tech/sqlite-ai-driver/pkg/duckdb-driver"
 	duckdb_driver "github.com/mozhou-tech/sqlite-ai-driver/pkg/duckdb-driver"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/sync/errgroup"
-	"golang.org/x/time/rate"
 )
 
 // --- Interfaces ---
@@ -25,6 +24,8 @@ type Database interface {
 	Collection(ctx context.Context, name string, schema Schema) (Collection, error)
 	// Graph 获取图数据库实例
 	Graph() GraphDatabase
+	// BeginTx 开始事务，通过返回的 Tx 获取的集合在事务中读写
+	BeginTx(ctx context.Context) (Tx, error)
 	// Close 关闭数据库连接
 	Close(ctx context.Context) error
 }
@@ -33,6 +34,8 @@ type Database interface {
 type Schema struct {
 	PrimaryKey string
 	RevField   string
+	// Columns 类型化列，用于按字段快速过滤和建立索引，见 Column
+	Columns []Column
 }
 
 // Collection 定义文档集合接口
@@ -47,13 +50,36 @@ type Collection interface {
 	Delete(ctx context.Context, id string) error
 	// BulkUpsert 批量插入或更新文档
 	BulkUpsert(ctx context.Context, docs []map[string]any) ([]Document, error)
+	// Archive 归档文档，归档后不参与检索，但保留内容和向量，返回状态发生变化的文档数
+	Archive(ctx context.Context, ids ...string) (int, error)
+	// Unarchive 把归档或软删除的文档恢复为 active，返回状态发生变化的文档数
+	Unarchive(ctx context.Context, ids ...string) (int, error)
+	// SoftDelete 软删除文档，保留数据但不参与检索和列表，返回状态发生变化的文档数
+	SoftDelete(ctx context.Context, ids ...string) (int, error)
 }
 
+// DocumentState 文档状态
+type DocumentState string
+
+const (
+	// StateActive 正常参与检索的文档
+	StateActive DocumentState = "active"
+	// StateArchived 已归档的文档，不参与检索，可以恢复
+	StateArchived DocumentState = "archived"
+	// StateDeleted 已软删除的文档，不参与检索，可以恢复
+	StateDeleted DocumentState = "deleted"
+)
+
+// StateField 列表结果中表示文档状态的字段
+const StateField = "_state"
+
 // FindOptions 查找选项
 type FindOptions struct {
 	Limit    int
 	Offset   int
 	Selector map[string]any
+	// States 只返回这些状态的文档，为空时只返回 active 的文档
+	States []DocumentState
 }
 
 // Document 定义文档接口
@@ -76,6 +102,11 @@ type FulltextSearch interface {
 type FulltextSearchOptions struct {
 	Limit    int
 	Selector map[string]any
+	// Syntax 为 true 时按查询语法解析 query：短语（"..."）、AND / OR / NOT（或 -词）、括号、
+	// 前缀（词*）和字段限定（field:词），语法见 fulltext_query.go；默认把 query 作为一组词按 BM25 搜索
+	Syntax bool
+	// ExpandSynonyms 为 true 时用集合的同义词扩展查询：按一组词搜索时追加同义词，按语法搜索时把词替换为词与同义词的 OR
+	ExpandSynonyms bool
 }
 
 // FulltextSearchResult 全文搜索结果
@@ -96,6 +127,38 @@ type VectorSearch interface {
 type VectorSearchOptions struct {
 	Limit    int
 	Selector map[string]any
+	// Metric 本次搜索使用的相似度，为空时使用 VectorSearchConfig.Metric
+	Metric VectorMetric
+	// MinScore 分数低于该值的结果被丢弃，0 表示不限制（L2 的分数始终大于 0）
+	MinScore float64
+	// MMRLambda 大于 0 时用 MMR（maximal marginal relevance）从候选中选出既相关又不重复的结果，
+	// 取值 (0, 1]，越大越看重与查询的相关性，1 等同于不做多样化；相关性和结果间的相似度均按余弦相似度计算
+	MMRLambda float64
+	// MMRCandidates MMR 的候选数，默认为 Limit 的 4 倍
+	MMRCandidates int
+}
+
+// VectorMetric 向量相似度的计算方式
+type VectorMetric string
+
+const (
+	MetricCosine     VectorMetric = "cosine" // 余弦相似度（默认），分数范围 [-1, 1]
+	MetricDotProduct VectorMetric = "dot"    // 内积，适合按内积训练的 embedding 模型，分数越大越相似
+	MetricL2         VectorMetric = "l2"     // 欧氏距离，分数为 1 / (1 + 距离)
+)
+
+// scoreExpr 返回计算 column 与查询向量（? 参数）相似度分数的 SQL 表达式，分数越大越相似
+func (m VectorMetric) scoreExpr(column string) (string, error) {
+	switch m {
+	case "", MetricCosine:
+		return fmt.Sprintf("list_cosine_similarity(%s, ?::FLOAT[])", column), nil
+	case MetricDotProduct:
+		return fmt.Sprintf("list_inner_product(%s, ?::FLOAT[])", column), nil
+	case MetricL2:
+		return fmt.Sprintf("1.0 / (1.0 + list_distance(%s, ?::FLOAT[]))", column), nil
+	default:
+		return "", fmt.Errorf("unsupported vector metric: %s", m)
+	}
 }
 
 // VectorSearchResult 向量搜索结果
@@ -108,6 +171,14 @@ type VectorSearchResult struct {
 type GraphDatabase interface {
 	// Link 创建一条从 subject 到 object 的边，边的类型为 predicate
 	Link(ctx context.Context, subject, predicate, object string) error
+	// Unlink 删除一条从 subject 到 object 的 predicate 边，边不存在时不报错
+	Unlink(ctx context.Context, subject, predicate, object string) error
+	// DeleteByPredicate 删除所有类型为 predicate 的边，返回删除的边数
+	DeleteByPredicate(ctx context.Context, predicate string) (int, error)
+	// DeleteNode 删除 node 的所有出边和入边，返回删除的边数
+	DeleteNode(ctx context.Context, node string) (int, error)
+	// UpdateLinks 在一个事务中删除 subject 到 remove 中各节点的 predicate 边，并添加到 add 中各节点的边
+	UpdateLinks(ctx context.Context, subject, predicate string, remove, add []string) error
 	// GetNeighbors 获取从 node 出发的邻居节点 (Out-neighbors)
 	GetNeighbors(ctx context.Context, node, predicate string) ([]string, error)
 	// GetInNeighbors 获取指向 node 的邻居节点 (In-neighbors)
@@ -144,6 +215,8 @@ type DatabaseOptions struct {
 	Name         string
 	WorkingDir   string // 工作目录，作为基础目录
 	GraphOptions *GraphOptions
+	// SkipMigrations 创建集合时只检查结构版本，不自动执行结构升级
+	SkipMigrations bool
 }
 
 // GraphOptions 图数据库选项
@@ -156,6 +229,9 @@ type GraphOptions struct {
 type FulltextSearchConfig struct {
 	Identifier  string
 	DocToString func(doc map[string]any) string
+	// Analyzer 分词器（AnalyzerSego、AnalyzerSimple、AnalyzerICU），保存在集合设置中，插入文档和查询时使用同一个分词器
+	// 为空时沿用集合已保存的分词器（默认 sego）；与已保存的不同时重新分词所有文档并重建索引
+	Analyzer Analyzer
 }
 
 // VectorSearchConfig 向量搜索配置
@@ -163,16 +239,24 @@ type VectorSearchConfig struct {
 	Identifier     string
 	DocToEmbedding func(doc map[string]any) ([]float64, error)
 	Dimensions     int
+	// Metric 默认的相似度，为空时使用余弦相似度
+	Metric VectorMetric
 }
 
 // --- DuckDB Implementation ---
 
 // duckdbDatabase 基于DuckDB的数据库实现
 type duckdbDatabase struct {
-	db          *sql.DB
-	graph       cayley_driver.Graph
-	collections []*duckdbCollection // 跟踪所有创建的集合，以便在关闭时停止它们的 worker
-	mu          sync.Mutex          // 保护 collections 的并发访问
+	db             *sql.DB
+	graph          cayley_driver.Graph
+	worker         *embeddingWorker // 所有集合共享的后台 embedding worker 和速率限制器
+	skipMigrations bool
+
+	mu          sync.Mutex
+	collections map[string]*duckdbCollection // 已打开的集合，事务中按名称获取
+
+	// writes 集合和图谱的写入次数，查询结果缓存据此判断缓存是否失效
+	writes atomic.Uint64
 }
 
 // CreateDatabase 创建数据库实例
@@ -183,7 +267,7 @@ type duckdbDatabase struct {
 // - 如果数据库文件已存在：会打开现有数据库，保留所有现有数据和表结构
 // - 如果数据库文件不存在：DuckDB 会自动创建新的数据库文件
 // - 表创建：使用 CREATE TABLE IF NOT EXISTS，如果表已存在则不会重新创建
-// - 列添加：如果表存在但缺少某些列（如 content_tokens、embedding_status），会自动添加（向后兼容）
+// - 结构升级：lightrag_schema_version 记录每个集合的结构版本，Collection 按顺序执行尚未执行的升级（见 migrations.go）
 func CreateDatabase(ctx context.Context, opts DatabaseOptions) (Database, error) {
 
 	// 打开DuckDB数据库
@@ -213,8 +297,10 @@ func CreateDatabase(ctx context.Context, opts DatabaseOptions) (Database, error)
 	}
 
 	return &duckdbDatabase{
-		db:    db,
-		graph: graph,
+		db:             db,
+		graph:          graph,
+		worker:         newEmbeddingWorker(),
+		skipMigrations: opts.SkipMigrations,
 	}, nil
 }
 
@@ -236,85 +322,87 @@ func (d *duckdbDatabase) Collection(ctx context.Context, name string, schema Sch
 		return nil, fmt.Errorf("failed to create table: %w", err)
 	}
 
-	// 创建FTS索引（如果不存在）
-	// 注意：这里先不创建，等AddFulltextSearch时再创建
-	// 创建tokens列用于FTS
-	tokensColumn := "content_tokens"
-	// 使用 DuckDB 原生的 information_schema 查询列信息，避免触发 sqlite 扩展的 catalog 错误
-	checkColumnSQL := fmt.Sprintf(`
-		SELECT COUNT(*) 
-		FROM information_schema.columns 
-		WHERE table_name = '%s' AND column_name = ?
-	`, tableName)
-
-	var count int
-	err = d.db.QueryRowContext(ctx, checkColumnSQL, tokensColumn).Scan(&count)
-	if err == nil && count == 0 {
-		alterTableSQL := fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s TEXT`, tableName, tokensColumn)
-		_, _ = d.db.ExecContext(ctx, alterTableSQL)
+	// 按版本执行结构升级（添加 content_tokens、embedding_status、chunk_length 等列），
+	// 由更新版本的包创建的数据库会在这里报错
+	if _, err := migrateCollection(ctx, d.db, tableName, !d.skipMigrations); err != nil {
+		return nil, err
 	}
 
-	// 创建 embedding_status 列用于跟踪 embedding 状态
-	// 状态值: 'pending' (待处理), 'processing' (处理中), 'completed' (已完成), 'failed' (失败)
-	statusColumn := "embedding_status"
-	err = d.db.QueryRowContext(ctx, checkColumnSQL, statusColumn).Scan(&count)
-	if err == nil && count == 0 {
-		alterTableSQL := fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s VARCHAR DEFAULT 'pending'`, tableName, statusColumn)
-		_, _ = d.db.ExecContext(ctx, alterTableSQL)
+	// 创建 Schema 中声明的类型化列
+	if err := ensureColumns(ctx, d.db, tableName, schema.Columns); err != nil {
+		return nil, fmt.Errorf("failed to create columns for %s: %w", tableName, err)
 	}
 
-	// 创建 chunk_length 列用于存储 chunk 长度
-	chunkLengthColumn := "chunk_length"
-	err = d.db.QueryRowContext(ctx, checkColumnSQL, chunkLengthColumn).Scan(&count)
-	if err == nil && count == 0 {
-		alterTableSQL := fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s INTEGER`, tableName, chunkLengthColumn)
-		_, _ = d.db.ExecContext(ctx, alterTableSQL)
+	// 集合设置中保存的全文分词器
+	analyzer, err := loadAnalyzer(ctx, d.db, tableName)
+	if err != nil {
+		return nil, err
 	}
 
 	collection := &duckdbCollection{
 		db:        d.db,
 		tableName: tableName,
 		schema:    schema,
+		worker:    d.worker,
+		analyzer:  analyzer,
+		writes:    &d.writes,
 	}
 
-	// 将集合添加到数据库的跟踪列表中
 	d.mu.Lock()
-	d.collections = append(d.collections, collection)
+	if d.collections == nil {
+		d.collections = make(map[string]*duckdbCollection)
+	}
+	d.collections[name] = collection
 	d.mu.Unlock()
 
 	return collection, nil
 }
 
-// getEmbeddingLimiter 获取或初始化 embedding 速率限制器（每秒5次）
-func (c *duckdbCollection) getEmbeddingLimiter() *rate.Limiter {
-	c.limiterOnce.Do(func() {
-		// 每秒5次，burst 为1（严格限制，不允许突发）
-		// rate.Limit(5) 表示每秒5次 = 每200ms一次
-		// burst 1 表示令牌桶中最多有1个令牌，每次请求消耗1个令牌
-		// 这样确保严格按每秒5次的速率执行，不允许突发
-		c.embeddingLimiter = rate.NewLimiter(rate.Limit(5), 1)
-		logrus.WithFields(logrus.Fields{
-			"rate":  "5 per second",
-			"burst": 1,
-		}).Info("Embedding rate limiter initialized")
+// getEmbeddingWorker 返回集合使用的 embedding worker
+// 通过 duckdbDatabase 创建的集合共享数据库的 worker，其他集合使用自己的 worker
+func (c *duckdbCollection) getEmbeddingWorker() *embeddingWorker {
+	c.workerOnce.Do(func() {
+		if c.worker == nil {
+			c.worker = newEmbeddingWorker()
+		}
 	})
-	return c.embeddingLimiter
+	return c.worker
+}
+
+// conn 返回执行 SQL 的连接，事务中的集合返回事务
+func (c *duckdbCollection) conn() sqlConn {
+	if c.tx != nil {
+		return c.tx
+	}
+	return c.db
+}
+
+// markWritten 记录一次写入，使查询结果缓存失效
+func (c *duckdbCollection) markWritten() {
+	if c.writes != nil {
+		c.writes.Add(1)
+	}
+}
+
+// getVectorSearches 返回已注册的向量搜索，可以与 AddVectorSearch 并发调用
+func (c *duckdbCollection) getVectorSearches() []*duckdbVectorSearch {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.vectorSearches
 }
 
 func (d *duckdbDatabase) Graph() GraphDatabase {
 	if d.graph == nil {
 		return nil
 	}
-	return &duckdbGraphDatabase{graph: d.graph}
+	return &duckdbGraphDatabase{graph: d.graph, writes: &d.writes}
 }
 
 func (d *duckdbDatabase) Close(ctx context.Context) error {
-	// 在关闭数据库之前，停止所有集合的后台 worker
-	d.mu.Lock()
-	for _, collection := range d.collections {
-		collection.stopEmbeddingWorker()
+	// 在关闭数据库之前，停止所有集合共享的后台 worker
+	if d.worker != nil {
+		d.worker.stop()
 	}
-	d.mu.Unlock()
 
 	var errs []error
 	if d.db != nil {
@@ -335,18 +423,19 @@ func (d *duckdbDatabase) Close(ctx context.Context) error {
 
 // duckdbCollection 基于DuckDB的集合实现
 type duckdbCollection struct {
-	db               *sql.DB
-	tableName        string
-	schema           Schema
-	vectorSearches   []*duckdbVectorSearch // 存储所有注册的向量搜索配置
-	embeddingLimiter *rate.Limiter         // Embedding API 速率限制器（每秒5次）
-	limiterOnce      sync.Once             // 确保 limiter 只初始化一次
-
-	// 后台 embedding worker 相关字段
-	embeddingWorkerCtx    context.Context
-	embeddingWorkerCancel context.CancelFunc
-	embeddingWorkerWg     sync.WaitGroup
-	embeddingWorkerOnce   sync.Once
+	db             *sql.DB
+	tableName      string
+	schema         Schema
+	mu             sync.RWMutex          // 保护 vectorSearches 和 analyzer 的并发访问
+	vectorSearches []*duckdbVectorSearch // 存储所有注册的向量搜索配置
+	analyzer       Analyzer              // 生成 content_tokens 的全文分词器
+	tx             *sql.Tx               // 不为 nil 时集合的读写都在该事务中执行
+	writes         *atomic.Uint64        // 数据库的写入计数，为 nil 时不记录
+	events         *eventBus             // 向量生成完成时发送事件，为 nil 时不发送
+
+	// 后台 embedding worker，为 nil 时在第一次使用时创建
+	worker     *embeddingWorker
+	workerOnce sync.Once
 }
 
 func (c *duckdbCollection) Insert(ctx context.Context, doc map[string]any) (Document, error) {
@@ -376,39 +465,31 @@ func (c *duckdbCollection) Insert(ctx context.Context, doc map[string]any) (Docu
 	}
 	metadataJSON, _ := json.Marshal(metadata)
 
-	insertSQL := fmt.Sprintf(`
-		INSERT INTO %s (id, content, metadata, _rev, embedding_status, chunk_length)
-		VALUES (?, ?, ?::JSON, 1, 'pending', ?)
-		ON CONFLICT (id) DO UPDATE SET
-			content = EXCLUDED.content,
-			metadata = EXCLUDED.metadata,
-			_rev = %s._rev + 1,
-			embedding_status = 'pending',
-			chunk_length = EXCLUDED.chunk_length
-	`, c.tableName, c.tableName)
-
-	_, err := c.db.ExecContext(ctx, insertSQL, id, content, string(metadataJSON), chunkLength)
+	_, err := c.conn().ExecContext(ctx, c.upsertSQL(), c.upsertArgs(doc, id, content, string(metadataJSON), chunkLength)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to insert document: %w", err)
 	}
+	defer c.markWritten()
 
 	// 更新tokens列
 	if content != "" {
-		tokens := duckdb_driver.TokenizeWithSego(content)
+		tokens := c.getAnalyzer().tokenize(content)
 		logrus.WithFields(logrus.Fields{
 			"id":     id,
 			"tokens": tokens,
 		}).Debug("Updating content_tokens for document")
 		updateSQL := fmt.Sprintf(`UPDATE %s SET content_tokens = ? WHERE id = ?`, c.tableName)
-		_, err = c.db.ExecContext(ctx, updateSQL, tokens, id)
+		_, err = c.conn().ExecContext(ctx, updateSQL, tokens, id)
 		if err != nil {
 			// 记录错误但不中断插入流程
 			logrus.WithError(err).Warnf("Failed to update content_tokens for document %s", id)
 		}
 	}
 
-	// 启动后台 embedding worker（如果还没有启动）
-	c.startEmbeddingWorker(ctx)
+	// 启动后台 embedding worker（如果还没有启动），事务中的集合在提交时启动
+	if c.tx == nil {
+		c.startEmbeddingWorker(ctx)
+	}
 
 	// 不再立即处理 embedding，而是标记为 pending，由后台 worker 异步处理
 
@@ -423,12 +504,12 @@ func (c *duckdbCollection) FindByID(ctx context.Context, id string) (Document, e
 	selectSQL := fmt.Sprintf(`
 		SELECT id, content, metadata
 		FROM %s
-		WHERE id = ?
+		WHERE id = ? AND state = 'active'
 	`, c.tableName)
 
 	var docID, content string
 	var metadataVal any
-	err := c.db.QueryRowContext(ctx, selectSQL, id).Scan(&docID, &content, &metadataVal)
+	err := c.conn().QueryRowContext(ctx, selectSQL, id).Scan(&docID, &content, &metadataVal)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -483,11 +564,25 @@ func (c *duckdbCollection) Find(ctx context.Context, opts FindOptions) ([]Docume
 		FROM %s
 	`, c.tableName)
 
-	// TODO: 实现 Selector 过滤
+	filter, args, err := selectorClauseWithColumns(opts.Selector, c.columnTypes())
+	if err != nil {
+		return nil, err
+	}
+	stateFilter, stateArgs, err := stateClause(opts.States)
+	if err != nil {
+		return nil, err
+	}
+	if filter != "" {
+		filter = stateFilter + " AND " + filter
+	} else {
+		filter = stateFilter
+	}
+	args = append(stateArgs, args...)
+	selectSQL += " WHERE " + filter
 
 	selectSQL += fmt.Sprintf(" ORDER BY created_at DESC LIMIT %d OFFSET %d", limit, offset)
 
-	rows, err := c.db.QueryContext(ctx, selectSQL)
+	rows, err := c.conn().QueryContext(ctx, selectSQL, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find documents: %w", err)
 	}
@@ -539,12 +634,72 @@ func (c *duckdbCollection) Find(ctx context.Context, opts FindOptions) ([]Docume
 	return results, nil
 }
 
+// stateClause 返回只保留给定状态文档的 SQL 条件，states 为空时只保留 active 的文档
+func stateClause(states []DocumentState) (string, []any, error) {
+	if len(states) == 0 {
+		return "state = 'active'", nil, nil
+	}
+	placeholders := make([]string, len(states))
+	args := make([]any, len(states))
+	for i, state := range states {
+		switch state {
+		case StateActive, StateArchived, StateDeleted:
+		default:
+			return "", nil, fmt.Errorf("unsupported document state: %q", state)
+		}
+		placeholders[i] = "?"
+		args[i] = string(state)
+	}
+	return fmt.Sprintf("state IN (%s)", strings.Join(placeholders, ", ")), args, nil
+}
+
+func (c *duckdbCollection) Archive(ctx context.Context, ids ...string) (int, error) {
+	return c.setState(ctx, StateArchived, []DocumentState{StateActive}, ids)
+}
+
+func (c *duckdbCollection) Unarchive(ctx context.Context, ids ...string) (int, error) {
+	return c.setState(ctx, StateActive, []DocumentState{StateArchived, StateDeleted}, ids)
+}
+
+func (c *duckdbCollection) SoftDelete(ctx context.Context, ids ...string) (int, error) {
+	return c.setState(ctx, StateDeleted, []DocumentState{StateActive, StateArchived}, ids)
+}
+
+// setState 把状态为 from 之一的文档改为 to，返回状态发生变化的文档数
+// 只修改 state 列，不影响内容、向量和 embedding 状态
+func (c *duckdbCollection) setState(ctx context.Context, to DocumentState, from []DocumentState, ids []string) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	fromClause, args, err := stateClause(from)
+	if err != nil {
+		return 0, err
+	}
+	placeholders := make([]string, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args = append(args, id)
+	}
+	updateSQL := fmt.Sprintf(`UPDATE %s SET state = ? WHERE %s AND id IN (%s)`, c.tableName, fromClause, strings.Join(placeholders, ", "))
+	res, err := c.conn().ExecContext(ctx, updateSQL, append([]any{string(to)}, args...)...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to set document state to %s: %w", to, err)
+	}
+	c.markWritten()
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to set document state to %s: %w", to, err)
+	}
+	return int(n), nil
+}
+
 func (c *duckdbCollection) Delete(ctx context.Context, id string) error {
 	deleteSQL := fmt.Sprintf("DELETE FROM %s WHERE id = ?", c.tableName)
-	_, err := c.db.ExecContext(ctx, deleteSQL, id)
+	_, err := c.conn().ExecContext(ctx, deleteSQL, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete document: %w", err)
 	}
+	c.markWritten()
 	return nil
 }
 
@@ -553,12 +708,18 @@ func (c *duckdbCollection) BulkUpsert(ctx context.Context, docs []map[string]any
 		return []Document{}, nil
 	}
 
-	tx, err := c.db.BeginTx(ctx, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	// 事务中的集合直接使用外层事务，由调用方提交或回滚
+	tx := c.tx
+	if tx == nil {
+		var err error
+		tx, err = c.db.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback()
 	}
-	defer tx.Rollback()
 
+	analyzer := c.getAnalyzer()
 	var results []Document
 	for _, doc := range docs {
 		id, ok := doc["id"].(string)
@@ -587,25 +748,14 @@ func (c *duckdbCollection) BulkUpsert(ctx context.Context, docs []map[string]any
 		metadataJSON, _ := json.Marshal(metadata)
 
 		// 不使用 PrepareContext，直接使用 ExecContext
-		insertSQL := fmt.Sprintf(`
-			INSERT INTO %s (id, content, metadata, _rev, embedding_status, chunk_length)
-			VALUES (?, ?, ?::JSON, 1, 'pending', ?)
-			ON CONFLICT (id) DO UPDATE SET
-				content = EXCLUDED.content,
-				metadata = EXCLUDED.metadata,
-				_rev = %s._rev + 1,
-				embedding_status = 'pending',
-				chunk_length = EXCLUDED.chunk_length
-		`, c.tableName, c.tableName)
-
-		_, err := tx.ExecContext(ctx, insertSQL, id, content, string(metadataJSON), chunkLength)
+		_, err := tx.ExecContext(ctx, c.upsertSQL(), c.upsertArgs(doc, id, content, string(metadataJSON), chunkLength)...)
 		if err != nil {
 			return nil, fmt.Errorf("failed to upsert document: %w", err)
 		}
 
 		// 更新tokens列
 		if content != "" {
-			tokens := duckdb_driver.TokenizeWithSego(content)
+			tokens := analyzer.tokenize(content)
 			updateSQL := fmt.Sprintf(`UPDATE %s SET content_tokens = ? WHERE id = ?`, c.tableName)
 			_, _ = tx.ExecContext(ctx, updateSQL, tokens, id)
 		}
@@ -619,9 +769,14 @@ func (c *duckdbCollection) BulkUpsert(ctx context.Context, docs []map[string]any
 		})
 	}
 
+	if c.tx != nil {
+		// 提交之前 worker 看不到这些文档，由 Tx.Commit 唤醒 worker
+		return results, nil
+	}
 	if err := tx.Commit(); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	c.markWritten()
 
 	// 启动后台 embedding worker（如果还没有启动）
 	c.startEmbeddingWorker(ctx)
@@ -652,6 +807,8 @@ func (d *duckdbDocument) Data() map[string]any {
 type duckdbFulltextSearch struct {
 	db        *sql.DB
 	tableName string
+	columns   map[string]string // 集合的类型化列，Selector 中的同名字段直接比较该列
+	analyzer  Analyzer          // 查询的分词器，与集合生成 content_tokens 的分词器一致
 	config    FulltextSearchConfig
 }
 
@@ -661,9 +818,42 @@ func AddFulltextSearch(collection Collection, config FulltextSearchConfig) (Full
 	if !ok {
 		return nil, fmt.Errorf("collection is not a duckdb collection")
 	}
+	// 建索引和重新分词不在事务中执行，搜索也只能看到已提交的文档
+	if duckdbColl.tx != nil {
+		return nil, fmt.Errorf("cannot add fulltext search to a collection in a transaction, use the collection from Database.Collection")
+	}
+
+	analyzer, err := parseAnalyzer(string(config.Analyzer))
+	if err != nil {
+		return nil, err
+	}
+	current := duckdbColl.getAnalyzer()
+	if analyzer == "" {
+		analyzer = current
+	}
+	if analyzer != current {
+		// 已有文档的分词结果由原来的分词器生成，全部重新分词并重建索引，之后再保存，失败时下次仍会重试
+		n, err := duckdb_driver.RebuildFTSIndexWithTokenizer(context.Background(), duckdbColl.db, duckdbColl.tableName, "id", "content", "content_tokens", analyzer.tokenize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retokenize documents with analyzer %s: %w", analyzer, err)
+		}
+		duckdbColl.markWritten()
+		logrus.WithFields(logrus.Fields{
+			"table":     duckdbColl.tableName,
+			"from":      current,
+			"to":        analyzer,
+			"documents": n,
+		}).Info("Fulltext analyzer changed, documents retokenized")
+	}
+	if config.Analyzer != "" {
+		if err := saveAnalyzer(context.Background(), duckdbColl.db, duckdbColl.tableName, analyzer); err != nil {
+			return nil, err
+		}
+	}
+	duckdbColl.setAnalyzer(analyzer)
 
 	// 创建FTS索引
-	err := duckdb_driver.CreateFTSIndexWithSego(
+	err = duckdb_driver.CreateFTSIndexWithSego(
 		context.Background(),
 		duckdbColl.db,
 		duckdbColl.tableName,
@@ -693,7 +883,7 @@ func AddFulltextSearch(collection Collection, config FulltextSearchConfig) (Full
 		for rows.Next() {
 			var id, content string
 			if err := rows.Scan(&id, &content); err == nil && content != "" {
-				tokens := duckdb_driver.TokenizeWithSego(content)
+				tokens := analyzer.tokenize(content)
 				updateSQL := fmt.Sprintf(`UPDATE %s SET content_tokens = ? WHERE id = ?`, duckdbColl.tableName)
 				_, _ = duckdbColl.db.ExecContext(context.Background(), updateSQL, tokens, id)
 			}
@@ -703,6 +893,8 @@ func AddFulltextSearch(collection Collection, config FulltextSearchConfig) (Full
 	return &duckdbFulltextSearch{
 		db:        duckdbColl.db,
 		tableName: duckdbColl.tableName,
+		columns:   duckdbColl.columnTypes(),
+		analyzer:  analyzer,
 		config:    config,
 	}, nil
 }
@@ -713,8 +905,32 @@ func (f *duckdbFulltextSearch) FindWithScores(ctx context.Context, query string,
 		limit = 10
 	}
 
-	// 使用sego分词搜索
-	ids, err := duckdb_driver.SearchWithSego(ctx, f.db, f.tableName, query, "content", "content_tokens", limit*2) // 获取更多结果以便过滤
+	// Selector 转换为 SQL 条件，在搜索时直接过滤，只搜索 active 的文档
+	filter, filterArgs, err := selectorClauseWithColumns(opts.Selector, f.columns)
+	if err != nil {
+		return nil, err
+	}
+	if filter != "" {
+		filter = "state = 'active' AND " + filter
+	} else {
+		filter = "state = 'active'"
+	}
+
+	var synonyms synonymIndex
+	if opts.ExpandSynonyms {
+		if synonyms, err = loadSynonymIndex(ctx, f.db, f.tableName); err != nil {
+			return nil, err
+		}
+	}
+
+	var ids []string
+	if opts.Syntax {
+		ids, err = f.searchWithSyntax(ctx, query, synonyms, limit*2, filter, filterArgs)
+	} else {
+		query = synonyms.expandQuery(query)
+		// 使用集合的分词器对查询分词后搜索
+		ids, err = duckdb_driver.SearchWithTokenizerFilter(ctx, f.db, f.tableName, query, "content", "content_tokens", f.analyzer.tokenize, limit*2, filter, filterArgs...) // 获取更多结果，跳过读取失败的文档
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to search: %w", err)
 	}
@@ -758,26 +974,6 @@ func (f *duckdbFulltextSearch) FindWithScores(ctx context.Context, query string,
 			}
 		}
 
-		// 应用 Selector 过滤器
-		if opts.Selector != nil && len(opts.Selector) > 0 {
-			matched := true
-			for key, expectedValue := range opts.Selector {
-				// 检查 metadata 中的值
-				actualValue, exists := doc[key]
-				if !exists {
-					// 如果 metadata 中没有，检查是否在顶层 doc 中
-					actualValue, exists = doc[key]
-				}
-				if !exists || actualValue != expectedValue {
-					matched = false
-					break
-				}
-			}
-			if !matched {
-				continue
-			}
-		}
-
 		// 简单的分数计算（基于位置，越靠前分数越高）
 		score := 1.0 / float64(i+1)
 
@@ -799,6 +995,51 @@ func (f *duckdbFulltextSearch) FindWithScores(ctx context.Context, query string,
 	return results, nil
 }
 
+// searchWithSyntax 按查询语法搜索，返回匹配的文档 ID
+// 有 FTS 索引时按 BM25 排序，否则（如无法加载 fts 扩展）按创建时间排序
+func (f *duckdbFulltextSearch) searchWithSyntax(ctx context.Context, query string, synonyms synonymIndex, limit int, filter string, filterArgs []any) ([]string, error) {
+	node, err := parseFulltextQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	node = synonyms.expandNode(node)
+	match, args := node.sql(f.columns)
+	if filter != "" {
+		match += " AND " + filter
+		args = append(args, filterArgs...)
+	}
+
+	rankText := f.analyzer.tokenize(strings.Join(rankTerms(node), " "))
+	var rows *sql.Rows
+	if rankText != "" {
+		rankedSQL := fmt.Sprintf(`
+			SELECT id FROM %s
+			WHERE %s
+			ORDER BY fts_main_%s.match_bm25(id, ?) DESC NULLS LAST, created_at DESC
+			LIMIT ?
+		`, f.tableName, match, f.tableName)
+		rows, err = f.db.QueryContext(ctx, rankedSQL, append(append(args, rankText), limit)...)
+	}
+	if rows == nil {
+		plainSQL := fmt.Sprintf(`SELECT id FROM %s WHERE %s ORDER BY created_at DESC LIMIT ?`, f.tableName, match)
+		rows, err = f.db.QueryContext(ctx, plainSQL, append(args, limit)...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
 func (f *duckdbFulltextSearch) Close() error {
 	// DuckDB的FTS索引不需要显式关闭
 	return nil
@@ -808,6 +1049,7 @@ func (f *duckdbFulltextSearch) Close() error {
 type duckdbVectorSearch struct {
 	db        *sql.DB
 	tableName string
+	columns   map[string]string // 集合的类型化列，Selector 中的同名字段直接比较该列
 	config    VectorSearchConfig
 }
 
@@ -816,6 +1058,13 @@ func AddVectorSearch(collection Collection, config VectorSearchConfig) (VectorSe
 	if !ok {
 		return nil, fmt.Errorf("collection is not a duckdb collection")
 	}
+	// 新增向量列不在事务中执行，搜索也只能看到已提交的文档
+	if duckdbColl.tx != nil {
+		return nil, fmt.Errorf("cannot add vector search to a collection in a transaction, use the collection from Database.Collection")
+	}
+	if _, err := config.Metric.scoreExpr(""); err != nil {
+		return nil, err
+	}
 
 	// 检查并创建vector列
 	vectorColumn := "vector_" + config.Identifier
@@ -840,11 +1089,14 @@ func AddVectorSearch(collection Collection, config VectorSearchConfig) (VectorSe
 	vectorSearch := &duckdbVectorSearch{
 		db:        duckdbColl.db,
 		tableName: duckdbColl.tableName,
+		columns:   duckdbColl.columnTypes(),
 		config:    config,
 	}
 
 	// 注册向量搜索到集合中，以便在插入时自动计算向量
+	duckdbColl.mu.Lock()
 	duckdbColl.vectorSearches = append(duckdbColl.vectorSearches, vectorSearch)
+	duckdbColl.mu.Unlock()
 
 	// 启动后台 embedding worker（如果还没有启动）
 	duckdbColl.startEmbeddingWorker(context.Background())
@@ -880,27 +1132,66 @@ func (v *duckdbVectorSearch) Search(ctx context.Context, embedding []float64, op
 		vectorArg = vectorStr
 	}
 
-	// 使用DuckDB的list_cosine_similarity进行向量搜索
-	// 只查询 embedding_status = 'completed' 的文档，确保只返回已成功生成 embedding 的文档
+	metric := opts.Metric
+	if metric == "" {
+		metric = v.config.Metric
+	}
+	scoreExpr, err := metric.scoreExpr(vectorColumn)
+	if err != nil {
+		return nil, err
+	}
+	if opts.MMRLambda < 0 || opts.MMRLambda > 1 {
+		return nil, fmt.Errorf("MMR lambda must be between 0 and 1, got %g", opts.MMRLambda)
+	}
+	useMMR := opts.MMRLambda > 0
+	// Selector 转换为 SQL 条件，过滤后仍能返回 limit 个结果
+	filter, filterArgs, err := selectorClauseWithColumns(opts.Selector, v.columns)
+	if err != nil {
+		return nil, err
+	}
+	if filter != "" {
+		filter = " AND " + filter
+	}
+	// 需要候选的数量，MMR 时从更多的候选中挑选
+	candidates := limit
+	if useMMR {
+		candidates = opts.MMRCandidates
+		if candidates <= 0 {
+			candidates = limit * 4
+		} else if candidates < limit {
+			candidates = limit
+		}
+	}
+	// MMR 需要读取候选的向量
+	extraColumns := ""
+	if useMMR {
+		extraColumns = ", " + vectorColumn
+	}
+
+	// 按 metric 对应的 DuckDB 函数（list_cosine_similarity、list_inner_product、list_distance）计算分数
+	// 只查询 embedding_status = 'completed' 的文档，确保只返回已成功生成 embedding 的文档；归档和软删除的文档不参与检索
 	sqlQuery := fmt.Sprintf(`
 		SELECT 
 			id,
 			content,
 			metadata,
-			1 - list_cosine_similarity(%s, ?::FLOAT[]) as distance
+			%s as score%s
 		FROM %s
-		WHERE %s IS NOT NULL AND embedding_status = 'completed'
-		ORDER BY list_cosine_similarity(%s, ?::FLOAT[]) DESC
+		WHERE %s IS NOT NULL AND embedding_status = 'completed' AND state = 'active'%s
+		ORDER BY score DESC
 		LIMIT ?
-	`, vectorColumn, v.tableName, vectorColumn, vectorColumn)
+	`, scoreExpr, extraColumns, v.tableName, vectorColumn, filter)
 
 	logrus.WithFields(logrus.Fields{
 		"table_name":    v.tableName,
 		"vector_column": vectorColumn,
-		"limit":         limit * 2,
+		"metric":        metric,
+		"limit":         candidates,
+		"mmr":           useMMR,
 	}).Debug("Executing vector search query")
 
-	rows, err := v.db.QueryContext(ctx, sqlQuery, vectorArg, vectorArg, limit*2) // 获取更多结果以便过滤
+	args := append([]any{vectorArg}, filterArgs...)
+	rows, err := v.db.QueryContext(ctx, sqlQuery, append(args, candidates)...)
 	if err != nil {
 		logrus.WithError(err).WithFields(logrus.Fields{
 			"table_name":    v.tableName,
@@ -911,17 +1202,26 @@ func (v *duckdbVectorSearch) Search(ctx context.Context, embedding []float64, op
 	defer rows.Close()
 
 	var results []VectorSearchResult
+	var vectors [][]float64
 	resultCount := 0
 	for rows.Next() {
 		resultCount++
 		var id, content string
-		var metadataVal any
-		var distance float64
+		var metadataVal, vectorVal any
+		var score float64
 
-		err := rows.Scan(&id, &content, &metadataVal, &distance)
+		dest := []any{&id, &content, &metadataVal, &score}
+		if useMMR {
+			dest = append(dest, &vectorVal)
+		}
+		err := rows.Scan(dest...)
 		if err != nil {
 			continue
 		}
+		// 结果按分数降序，之后的结果分数更低
+		if opts.MinScore != 0 && score < opts.MinScore {
+			break
+		}
 
 		doc := map[string]any{
 			"id":      id,
@@ -951,24 +1251,6 @@ func (v *duckdbVectorSearch) Search(ctx context.Context, embedding []float64, op
 			}
 		}
 
-		// 应用 Selector 过滤器
-		if opts.Selector != nil && len(opts.Selector) > 0 {
-			matched := true
-			for key, expectedValue := range opts.Selector {
-				actualValue, exists := doc[key]
-				if !exists || actualValue != expectedValue {
-					matched = false
-					break
-				}
-			}
-			if !matched {
-				continue
-			}
-		}
-
-		// 将distance转换为similarity score
-		score := 1.0 - distance
-
 		results = append(results, VectorSearchResult{
 			Document: &duckdbDocument{
 				id:      id,
@@ -977,11 +1259,17 @@ func (v *duckdbVectorSearch) Search(ctx context.Context, embedding []float64, op
 			},
 			Score: score,
 		})
+		if useMMR {
+			vectors = append(vectors, toFloat64Slice(vectorVal))
+		}
 
-		if len(results) >= limit {
+		if len(results) >= candidates {
 			break
 		}
 	}
+	if useMMR {
+		results = selectMMR(embedding, results, vectors, limit, opts.MMRLambda)
+	}
 
 	logrus.WithFields(logrus.Fields{
 		"table_name":       v.tableName,
@@ -1000,13 +1288,98 @@ func (v *duckdbVectorSearch) Close() error {
 
 // duckdbGraphDatabase 图数据库实现
 type duckdbGraphDatabase struct {
-	graph cayley_driver.Graph
+	graph  cayley_driver.Graph
+	writes *atomic.Uint64 // 数据库的写入计数，为 nil 时不记录
+}
+
+// markWritten 记录一次写入，使查询结果缓存失效
+func (g *duckdbGraphDatabase) markWritten() {
+	if g.writes != nil {
+		g.writes.Add(1)
+	}
 }
 
 func (g *duckdbGraphDatabase) Link(ctx context.Context, subject, predicate, object string) error {
+	defer g.markWritten()
 	return g.graph.Link(ctx, subject, predicate, object)
 }
 
+func (g *duckdbGraphDatabase) Unlink(ctx context.Context, subject, predicate, object string) error {
+	defer g.markWritten()
+	return g.graph.Unlink(ctx, subject, predicate, object)
+}
+
+func (g *duckdbGraphDatabase) DeleteByPredicate(ctx context.Context, predicate string) (int, error) {
+	if predicate == "" {
+		return 0, fmt.Errorf("predicate is required")
+	}
+	return g.deleteTriples(ctx, cayley_driver.TripleFilter{Predicates: []string{predicate}})
+}
+
+func (g *duckdbGraphDatabase) DeleteNode(ctx context.Context, node string) (int, error) {
+	if node == "" {
+		return 0, fmt.Errorf("node is required")
+	}
+	return g.deleteTriples(ctx, cayley_driver.TripleFilter{Subject: node}, cayley_driver.TripleFilter{Object: node})
+}
+
+// deleteTriples 在一个事务中删除满足任一 filter 的边
+func (g *duckdbGraphDatabase) deleteTriples(ctx context.Context, filters ...cayley_driver.TripleFilter) (int, error) {
+	seen := make(map[cayley_driver.Triple]bool)
+	var triples []cayley_driver.Triple
+	for _, filter := range filters {
+		err := g.graph.TriplesIter(ctx, filter, func(t cayley_driver.Triple) error {
+			if !seen[t] {
+				seen[t] = true
+				triples = append(triples, t)
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to scan triples: %w", err)
+		}
+	}
+	if len(triples) == 0 {
+		return 0, nil
+	}
+	defer g.markWritten()
+
+	tx, err := g.graph.BeginTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	for _, t := range triples {
+		if err := tx.Unlink(ctx, t.Subject, t.Predicate, t.Object); err != nil {
+			return 0, fmt.Errorf("failed to unlink %s -[%s]-> %s: %w", t.Subject, t.Predicate, t.Object, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(triples), nil
+}
+
+func (g *duckdbGraphDatabase) UpdateLinks(ctx context.Context, subject, predicate string, remove, add []string) error {
+	defer g.markWritten()
+	tx, err := g.graph.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, object := range remove {
+		if err := tx.Unlink(ctx, subject, predicate, object); err != nil {
+			return fmt.Errorf("failed to unlink %s -[%s]-> %s: %w", subject, predicate, object, err)
+		}
+	}
+	for _, object := range add {
+		if err := tx.Link(ctx, subject, predicate, object); err != nil {
+			return fmt.Errorf("failed to link %s -[%s]-> %s: %w", subject, predicate, object, err)
+		}
+	}
+	return tx.Commit()
+}
+
 func (g *duckdbGraphDatabase) GetNeighbors(ctx context.Context, node, predicate string) ([]string, error) {
 	return g.graph.GetNeighbors(ctx, node, predicate)
 }
@@ -1161,50 +1534,27 @@ func (q *duckdbGraphQuery) All(ctx context.Context) ([]GraphQueryResult, error)
 	return results, nil
 }
 
-// startEmbeddingWorker 启动后台 embedding worker（只启动一次）
+// startEmbeddingWorker 把集合加入 embedding worker 的处理队列，启动 worker（如果还没有启动）并唤醒它
 func (c *duckdbCollection) startEmbeddingWorker(ctx context.Context) {
-	c.embeddingWorkerOnce.Do(func() {
-		workerCtx, cancel := context.WithCancel(context.Background())
-		c.embeddingWorkerCtx = workerCtx
-		c.embeddingWorkerCancel = cancel
-
-		c.embeddingWorkerWg.Add(1)
-		go c.embeddingWorker(workerCtx)
-		logrus.Info("Background embedding worker started")
-	})
+	w := c.getEmbeddingWorker()
+	w.register(c)
+	w.start()
+	w.notify()
 }
 
-// stopEmbeddingWorker 停止后台 embedding worker
+// stopEmbeddingWorker 停止后台 embedding worker，共享的 worker 会停止处理所有集合
 func (c *duckdbCollection) stopEmbeddingWorker() {
-	if c.embeddingWorkerCancel != nil {
-		c.embeddingWorkerCancel()
-		c.embeddingWorkerWg.Wait()
-		logrus.Info("Background embedding worker stopped")
-	}
-}
-
-// embeddingWorker 后台 worker，定期检查并处理 pending 状态的 embedding
-func (c *duckdbCollection) embeddingWorker(ctx context.Context) {
-	defer c.embeddingWorkerWg.Done()
-
-	ticker := time.NewTicker(2 * time.Second) // 每2秒检查一次
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			c.processPendingEmbeddings(ctx)
-		}
-	}
+	c.getEmbeddingWorker().stop()
 }
 
 // processPendingEmbeddings 处理所有 pending 状态的 embedding
+// 同一个数据库的集合由共享的 worker 依次调用，共用一个速率限制器
 func (c *duckdbCollection) processPendingEmbeddings(ctx context.Context) {
-	if len(c.vectorSearches) == 0 {
+	vectorSearches := c.getVectorSearches()
+	if len(vectorSearches) == 0 {
 		return
 	}
+	limiter := c.getEmbeddingWorker().limiter
 
 	// 使用独立的 context，避免使用可能被取消的请求 context
 	processCtx := context.Background()
@@ -1231,18 +1581,21 @@ func (c *duckdbCollection) processPendingEmbeddings(ctx context.Context) {
 	var pendingDocs []struct {
 		id       string
 		content  string
-		metadata string
+		metadata map[string]any
 	}
 
 	for rows.Next() {
-		var id, content, metadata string
-		if err := rows.Scan(&id, &content, &metadata); err != nil {
+		var id, content string
+		// JSON 列由驱动解析为 map，不能直接扫描到 string
+		var metadataVal any
+		if err := rows.Scan(&id, &content, &metadataVal); err != nil {
 			continue
 		}
+		metadata := decodeMetadata(metadataVal)
 		pendingDocs = append(pendingDocs, struct {
 			id       string
 			content  string
-			metadata string
+			metadata map[string]any
 		}{id: id, content: content, metadata: metadata})
 	}
 
@@ -1259,6 +1612,11 @@ func (c *duckdbCollection) processPendingEmbeddings(ctx context.Context) {
 	// 使用 semaphore 模式控制并发数
 	sem := make(chan struct{}, 100) // 最多100个并发
 
+	// 本批处理的结果，处理完后作为 embeddings.completed 事件发送
+	var resultMu sync.Mutex
+	completed := make([]string, 0, len(pendingDocs))
+	failed := make([]map[string]any, 0)
+
 	for _, doc := range pendingDocs {
 		doc := doc // 避免闭包问题
 
@@ -1299,7 +1657,7 @@ func (c *duckdbCollection) processPendingEmbeddings(ctx context.Context) {
 					"content_len": len([]rune(doc.content)),
 				}).Debug("Skipping embedding for chunk that is too short (<=10 characters)")
 				// 直接标记为 completed，跳过嵌入
-				updateStatusSQL = fmt.Sprintf(`UPDATE %s SET embedding_status = 'completed' WHERE id = ?`, c.tableName)
+				updateStatusSQL = fmt.Sprintf(`UPDATE %s SET embedding_status = 'completed', embedding_error = NULL WHERE id = ?`, c.tableName)
 				_, err = c.db.ExecContext(processCtx, updateStatusSQL, doc.id)
 				if err != nil {
 					logrus.WithError(err).WithField("doc_id", doc.id).Error("Failed to update embedding status to completed")
@@ -1307,13 +1665,8 @@ func (c *duckdbCollection) processPendingEmbeddings(ctx context.Context) {
 				return nil
 			}
 
-			// 解析 metadata
-			var metadataMap map[string]any
-			if doc.metadata != "" {
-				if err := json.Unmarshal([]byte(doc.metadata), &metadataMap); err != nil {
-					metadataMap = make(map[string]any)
-				}
-			} else {
+			metadataMap := doc.metadata
+			if metadataMap == nil {
 				metadataMap = make(map[string]any)
 			}
 
@@ -1327,21 +1680,22 @@ func (c *duckdbCollection) processPendingEmbeddings(ctx context.Context) {
 				docMap[k] = v
 			}
 
-			// 为每个向量搜索配置生成 embedding
+			// 为每个向量搜索配置生成 embedding，lastErr 记录最后一次失败的原因
 			allSuccess := true
-			for _, vs := range c.vectorSearches {
+			var lastErr string
+			for _, vs := range vectorSearches {
 				if vs.config.DocToEmbedding == nil {
 					continue
 				}
 
-				// 等待速率限制器允许（每秒最多5次）
-				limiter := c.getEmbeddingLimiter()
+				// 等待速率限制器允许（同一个数据库的所有集合合计每秒最多5次）
 				if err := limiter.Wait(processCtx); err != nil {
 					logrus.WithError(err).WithFields(logrus.Fields{
 						"doc_id":      doc.id,
 						"content_len": len(doc.content),
 					}).Error("Rate limiter wait failed")
 					allSuccess = false
+					lastErr = err.Error()
 					continue
 				}
 
@@ -1363,6 +1717,7 @@ func (c *duckdbCollection) processPendingEmbeddings(ctx context.Context) {
 						}).Error("Failed to generate embedding in background worker")
 					}
 					allSuccess = false
+					lastErr = err.Error()
 					continue
 				}
 
@@ -1385,6 +1740,7 @@ func (c *duckdbCollection) processPendingEmbeddings(ctx context.Context) {
 							"vector_column": vectorColumn,
 						}).Error("Failed to update vector column")
 						allSuccess = false
+						lastErr = fmt.Sprintf("failed to update vector column: %v", err)
 					} else {
 						logrus.WithFields(logrus.Fields{
 							"doc_id":      doc.id,
@@ -1395,6 +1751,7 @@ func (c *duckdbCollection) processPendingEmbeddings(ctx context.Context) {
 				} else {
 					logrus.WithField("doc_id", doc.id).Warn("Empty embedding vector generated")
 					allSuccess = false
+					lastErr = "empty embedding vector generated"
 				}
 			}
 
@@ -1403,11 +1760,20 @@ func (c *duckdbCollection) processPendingEmbeddings(ctx context.Context) {
 			if !allSuccess {
 				status = "failed"
 			}
-			updateStatusSQL = fmt.Sprintf(`UPDATE %s SET embedding_status = ? WHERE id = ?`, c.tableName)
-			_, err = c.db.ExecContext(processCtx, updateStatusSQL, status, doc.id)
+			updateStatusSQL = fmt.Sprintf(`UPDATE %s SET embedding_status = ?, embedding_error = ? WHERE id = ?`, c.tableName)
+			_, err = c.db.ExecContext(processCtx, updateStatusSQL, status, sql.NullString{String: lastErr, Valid: lastErr != ""}, doc.id)
 			if err != nil {
 				logrus.WithError(err).WithField("doc_id", doc.id).Error("Failed to update embedding status")
 			}
+			resultMu.Lock()
+			if allSuccess {
+				completed = append(completed, doc.id)
+			} else {
+				failed = append(failed, map[string]any{"id": doc.id, "error": lastErr})
+			}
+			resultMu.Unlock()
+			// 新的向量会改变向量检索的结果
+			c.markWritten()
 			return nil
 		})
 	}
@@ -1416,11 +1782,14 @@ func (c *duckdbCollection) processPendingEmbeddings(ctx context.Context) {
 	if err := g.Wait(); err != nil {
 		logrus.WithError(err).Error("Error processing pending embeddings")
 	}
+	if len(completed) > 0 || len(failed) > 0 {
+		c.events.publish(EventEmbeddingsCompleted, map[string]any{"completed": completed, "failed": failed})
+	}
 }
 
 // countPendingEmbeddings 统计 pending 或 processing 状态的嵌入数量
 func (c *duckdbCollection) countPendingEmbeddings(ctx context.Context) (int, error) {
-	if len(c.vectorSearches) == 0 {
+	if len(c.getVectorSearches()) == 0 {
 		return 0, nil
 	}
 
@@ -0,0 +1,361 @@
+package lightrag
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	duckdb_driver "github.com/mozhou-tech/sqlite-ai-driver/pkg/duckdb-driver"
+)
+
+func TestGraphDatabase_UnlinkAndDelete(t *testing.T) {
+	ctx := context.Background()
+	graph := newSummaryTestRAG(t, nil).graph
+	for _, triple := range [][3]string{
+		{"Go", "TYPE", "Language"},
+		{"Go", "CREATED_BY", "Google"},
+		{"Go", "APPEARS_IN", "doc-1"},
+		{"Rust", "APPEARS_IN", "doc-1"},
+		{"Gopher", "MASCOT_OF", "Go"},
+	} {
+		if err := graph.Link(ctx, triple[0], triple[1], triple[2]); err != nil {
+			t.Fatalf("Link failed: %v", err)
+		}
+	}
+
+	if err := graph.Unlink(ctx, "Go", "TYPE", "Language"); err != nil {
+		t.Fatalf("Unlink failed: %v", err)
+	}
+	if types, _ := graph.GetNeighbors(ctx, "Go", "TYPE"); len(types) != 0 {
+		t.Errorf("expected TYPE edge to be removed, got %v", types)
+	}
+	if err := graph.Unlink(ctx, "Go", "TYPE", "Language"); err != nil {
+		t.Errorf("unlinking a missing edge should not fail: %v", err)
+	}
+
+	n, err := graph.DeleteByPredicate(ctx, "APPEARS_IN")
+	if err != nil || n != 2 {
+		t.Fatalf("DeleteByPredicate = %d, %v, want 2", n, err)
+	}
+	if docs, _ := graph.GetInNeighbors(ctx, "doc-1", "APPEARS_IN"); len(docs) != 0 {
+		t.Errorf("expected APPEARS_IN edges to be removed, got %v", docs)
+	}
+
+	n, err = graph.DeleteNode(ctx, "Go")
+	if err != nil || n != 2 {
+		t.Fatalf("DeleteNode = %d, %v, want 2", n, err)
+	}
+	if triples, _ := graph.AllTriples(ctx); len(triples) != 0 {
+		t.Errorf("expected no triples left, got %+v", triples)
+	}
+
+	if _, err := graph.DeleteByPredicate(ctx, ""); err == nil {
+		t.Error("expected error for empty predicate")
+	}
+}
+
+// newWorkerTestDatabase 创建带 embedding worker 的数据库，测试结束时删除给定的表
+func newWorkerTestDatabase(t *testing.T, tables ...string) *duckdbDatabase {
+	t.Helper()
+	db, err := sql.Open("duckdb", duckdb_driver.INDEX_DB_FILE)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	d := &duckdbDatabase{db: db, worker: newEmbeddingWorker()}
+	cleanup := func() {
+		for _, table := range tables {
+			db.Exec(`DROP TABLE IF EXISTS ` + table)
+			db.Exec(`DELETE FROM `+schemaVersionTable+` WHERE table_name = ?`, table)
+			db.Exec(`DELETE FROM `+collectionSettingsTable+` WHERE table_name = ?`, table)
+		}
+	}
+	cleanup()
+	t.Cleanup(func() {
+		d.worker.stop()
+		cleanup()
+		db.Close()
+	})
+	return d
+}
+
+func TestEmbeddingWorkerSharedAcrossCollections(t *testing.T) {
+	ctx := context.Background()
+	tables := []string{"lightrag_worker_test_a", "lightrag_worker_test_b"}
+	d := newWorkerTestDatabase(t, tables...)
+
+	var calls atomic.Int32
+	var collections []*duckdbCollection
+	for _, table := range tables {
+		c, err := d.Collection(ctx, table, Schema{PrimaryKey: "id"})
+		if err != nil {
+			t.Fatalf("Collection failed: %v", err)
+		}
+		if _, err := AddVectorSearch(c, VectorSearchConfig{
+			Identifier: "test",
+			DocToEmbedding: func(doc map[string]any) ([]float64, error) {
+				calls.Add(1)
+				return []float64{1, 0}, nil
+			},
+		}); err != nil {
+			t.Fatalf("AddVectorSearch failed: %v", err)
+		}
+		if _, err := c.Insert(ctx, map[string]any{"id": "doc-1", "content": "shared embedding worker"}); err != nil {
+			t.Fatalf("Insert failed: %v", err)
+		}
+		collections = append(collections, c.(*duckdbCollection))
+	}
+
+	if collections[0].getEmbeddingWorker() != collections[1].getEmbeddingWorker() {
+		t.Fatal("expected collections of one database to share the embedding worker")
+	}
+	if n := len(d.worker.collections); n != 2 {
+		t.Errorf("expected 2 registered collections, got %d", n)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for _, c := range collections {
+		for {
+			pending, err := c.countPendingEmbeddings(ctx)
+			if err != nil {
+				t.Fatalf("countPendingEmbeddings failed: %v", err)
+			}
+			if pending == 0 {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("embeddings of %s still pending", c.tableName)
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("expected 2 embedding calls, got %d", n)
+	}
+
+	// 不是通过数据库创建的集合使用自己的 worker
+	standalone := &duckdbCollection{db: d.db, tableName: tables[0]}
+	if standalone.getEmbeddingWorker() == d.worker {
+		t.Error("expected a standalone collection to use its own worker")
+	}
+}
+
+func TestGetEmbeddingStatus(t *testing.T) {
+	ctx := context.Background()
+	const table = "lightrag_embedding_status_test"
+	d := newWorkerTestDatabase(t, table)
+	c, err := d.Collection(ctx, table, Schema{PrimaryKey: "id"})
+	if err != nil {
+		t.Fatalf("Collection failed: %v", err)
+	}
+	vector, err := AddVectorSearch(c, VectorSearchConfig{
+		Identifier: "test",
+		DocToEmbedding: func(doc map[string]any) ([]float64, error) {
+			if doc["id"] == "bad" {
+				return nil, fmt.Errorf("embedding service unavailable")
+			}
+			return []float64{1, 0}, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("AddVectorSearch failed: %v", err)
+	}
+	if _, err := c.BulkUpsert(ctx, []map[string]any{
+		{"id": "good", "content": "embedding succeeds here"},
+		{"id": "bad", "content": "embedding fails for this one"},
+	}); err != nil {
+		t.Fatalf("BulkUpsert failed: %v", err)
+	}
+	rag := &LightRAG{initialized: true, docs: c, vector: vector, embedder: NewSimpleEmbedder(2)}
+
+	start := time.Now()
+	if err := rag.WaitForEmbeddings(ctx, 10*time.Second); err != nil {
+		t.Fatalf("WaitForEmbeddings failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("WaitForEmbeddings should return once the table has no pending documents, took %v", elapsed)
+	}
+
+	status, err := rag.GetEmbeddingStatus(ctx)
+	if err != nil {
+		t.Fatalf("GetEmbeddingStatus failed: %v", err)
+	}
+	if status.Completed != 1 || status.Failed != 1 || status.Pending != 0 || status.Processing != 0 {
+		t.Errorf("unexpected counts: %+v", status.EmbeddingCoverage)
+	}
+	if len(status.FailedDocuments) != 1 || status.FailedDocuments[0].ID != "bad" || status.FailedDocuments[0].Error != "embedding service unavailable" {
+		t.Errorf("unexpected failed documents: %+v", status.FailedDocuments)
+	}
+}
+
+func TestDatabaseBeginTx(t *testing.T) {
+	ctx := context.Background()
+	const table = "lightrag_tx_test"
+	d := newWorkerTestDatabase(t, table)
+	c, err := d.Collection(ctx, table, Schema{PrimaryKey: "id"})
+	if err != nil {
+		t.Fatalf("Collection failed: %v", err)
+	}
+	docs := []map[string]any{
+		{"id": "chunk-1", "content": "first chunk of the document"},
+		{"id": "chunk-2", "content": "second chunk of the document"},
+	}
+
+	// 图谱链接失败时回滚，整批分块都不会写入
+	tx, err := d.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("BeginTx failed: %v", err)
+	}
+	txc, err := tx.Collection(table)
+	if err != nil {
+		t.Fatalf("Tx.Collection failed: %v", err)
+	}
+	if _, err := txc.BulkUpsert(ctx, docs); err != nil {
+		t.Fatalf("BulkUpsert failed: %v", err)
+	}
+	if doc, _ := txc.FindByID(ctx, "chunk-1"); doc == nil {
+		t.Error("expected the document to be visible inside the transaction")
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if doc, err := c.FindByID(ctx, "chunk-1"); err != nil || doc != nil {
+		t.Errorf("expected rolled back document to be absent, got %v, %v", doc, err)
+	}
+
+	tx, err = d.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("BeginTx failed: %v", err)
+	}
+	txc, _ = tx.Collection(table)
+	if _, err := txc.BulkUpsert(ctx, docs); err != nil {
+		t.Fatalf("BulkUpsert failed: %v", err)
+	}
+	if _, err := txc.Insert(ctx, map[string]any{"id": "chunk-3", "content": "third chunk of the document"}); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Errorf("Rollback after Commit should be a no-op, got %v", err)
+	}
+	found, err := c.Find(ctx, FindOptions{})
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if len(found) != 3 {
+		t.Errorf("expected 3 committed documents, got %d", len(found))
+	}
+
+	if _, err := tx.Collection("lightrag_tx_missing"); err == nil {
+		t.Error("expected error for a collection that is not opened")
+	}
+
+	// 事务中的集合带有已注册的向量搜索配置，但不能再添加搜索
+	if _, err := AddVectorSearch(c, VectorSearchConfig{Identifier: "content"}); err != nil {
+		t.Fatalf("AddVectorSearch failed: %v", err)
+	}
+	tx, err = d.BeginTx(ctx)
+	if err != nil {
+		t.Fatalf("BeginTx failed: %v", err)
+	}
+	defer tx.Rollback()
+	txc, _ = tx.Collection(table)
+	if n := len(txc.(*duckdbCollection).getVectorSearches()); n != 1 {
+		t.Errorf("expected the vector search to be copied, got %d", n)
+	}
+	if _, err := AddVectorSearch(txc, VectorSearchConfig{Identifier: "title"}); err == nil {
+		t.Error("expected error adding a vector search inside a transaction")
+	}
+	if _, err := AddFulltextSearch(txc, FulltextSearchConfig{}); err == nil {
+		t.Error("expected error adding a fulltext search inside a transaction")
+	}
+}
+
+func TestTypedColumns(t *testing.T) {
+	ctx := context.Background()
+	const table = "lightrag_typed_columns_test"
+	d := newWorkerTestDatabase(t, table)
+
+	// 声明列之前写入的文档在新增列时从 metadata 回填
+	c, err := d.Collection(ctx, table, Schema{PrimaryKey: "id"})
+	if err != nil {
+		t.Fatalf("Collection failed: %v", err)
+	}
+	if _, err := c.Insert(ctx, map[string]any{"id": "old", "content": "written before the columns", "source": "wiki", "page": 7}); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	schema := Schema{PrimaryKey: "id", Columns: []Column{
+		{Name: "source", Type: "varchar", Index: true},
+		{Name: "page", Type: "INT"},
+		{Name: "published_at", Type: "TIMESTAMP"},
+	}}
+	c, err = d.Collection(ctx, table, schema)
+	if err != nil {
+		t.Fatalf("Collection with columns failed: %v", err)
+	}
+	if _, err := c.BulkUpsert(ctx, []map[string]any{
+		{"id": "a", "content": "typed column document a", "source": "blog", "page": float64(1), "published_at": "2026-01-02 03:04:05"},
+		{"id": "b", "content": "typed column document b", "source": "wiki", "page": float64(2)},
+	}); err != nil {
+		t.Fatalf("BulkUpsert failed: %v", err)
+	}
+	// 更新带索引的列
+	if _, err := c.Insert(ctx, map[string]any{"id": "a", "content": "typed column document a", "source": "news", "page": 3}); err != nil {
+		t.Fatalf("Insert update failed: %v", err)
+	}
+
+	var source string
+	var page int
+	var publishedAt sql.NullTime
+	if err := d.db.QueryRow(`SELECT source, page, published_at FROM `+table+` WHERE id = 'a'`).Scan(&source, &page, &publishedAt); err != nil {
+		t.Fatalf("failed to read typed columns: %v", err)
+	}
+	if source != "news" || page != 3 || publishedAt.Valid {
+		t.Errorf("unexpected typed columns: %s, %d, %v", source, page, publishedAt)
+	}
+	if err := d.db.QueryRow(`SELECT source, page FROM `+table+` WHERE id = 'old'`).Scan(&source, &page); err != nil || source != "wiki" || page != 7 {
+		t.Errorf("expected backfilled columns, got %s, %d, %v", source, page, err)
+	}
+
+	// 无法转换的值与回填一致写入 NULL，不会让整批写入失败，原值保留在 metadata 中
+	if _, err := c.BulkUpsert(ctx, []map[string]any{
+		{"id": "roman", "content": "typed column document with a roman page", "source": "book", "page": "iv"},
+	}); err != nil {
+		t.Fatalf("BulkUpsert with an uncastable value failed: %v", err)
+	}
+	var romanPage sql.NullInt64
+	var rawPage string
+	if err := d.db.QueryRow(`SELECT page, json_extract_string(metadata, '$.page') FROM `+table+` WHERE id = 'roman'`).Scan(&romanPage, &rawPage); err != nil {
+		t.Fatalf("failed to read uncastable column: %v", err)
+	}
+	if romanPage.Valid || rawPage != "iv" {
+		t.Errorf("expected NULL page and raw metadata, got %v, %q", romanPage, rawPage)
+	}
+
+	docs, err := c.Find(ctx, FindOptions{Selector: map[string]any{"source": "wiki", "page": map[string]any{"$in": []any{2, 7}}}})
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if len(docs) != 2 || docs[0].Data()["source"] != "wiki" {
+		t.Errorf("expected both wiki documents with metadata, got %d", len(docs))
+	}
+	if clause, _, _ := selectorClauseWithColumns(map[string]any{"page": 2}, c.(*duckdbCollection).columnTypes()); clause != "page = CAST(? AS INTEGER)" {
+		t.Errorf("expected the typed column to be compared directly, got %s", clause)
+	}
+
+	for _, columns := range [][]Column{
+		{{Name: "content", Type: "VARCHAR"}},
+		{{Name: "Source", Type: "VARCHAR"}},
+		{{Name: "page", Type: "BLOB"}},
+		{{Name: "page", Type: "INT"}, {Name: "page", Type: "INT"}},
+	} {
+		if _, err := d.Collection(ctx, table, Schema{PrimaryKey: "id", Columns: columns}); err == nil {
+			t.Errorf("expected error for columns %+v", columns)
+		}
+	}
+}
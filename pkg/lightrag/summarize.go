@@ -0,0 +1,153 @@
+package lightrag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxDescriptionSummaryInput 一次合并的描述总字符数上限，超出的描述留到下一次合并
+const maxDescriptionSummaryInput = 12000
+
+// SummarizeEntityDescriptions 把描述数不少于 minDescriptions（小于 2 时为 2）的实体的多条 DESCRIPTION 边
+// 用 LLM 合并为一条，返回合并的实体数。同一实体从多个分块中提取时描述会不断累积，可以定期调用；
+// 设置 Options.SummarizeDescriptionsAt 时提取后自动合并。单个实体合并失败不影响其他实体，所有错误合并返回
+func (r *LightRAG) SummarizeEntityDescriptions(ctx context.Context, minDescriptions int) (int, error) {
+	if r == nil {
+		return 0, fmt.Errorf("LightRAG instance is nil")
+	}
+	if !r.initialized {
+		return 0, fmt.Errorf("storages not initialized")
+	}
+	if r.graph == nil {
+		return 0, fmt.Errorf("graph database not available")
+	}
+	if r.llm == nil {
+		return 0, fmt.Errorf("LLM is not available")
+	}
+	if minDescriptions < 2 {
+		minDescriptions = 2
+	}
+
+	triples, err := r.graph.AllTriples(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get all triples: %w", err)
+	}
+	descriptions := make(map[string][]string)
+	for _, t := range triples {
+		if t.Predicate == "DESCRIPTION" {
+			descriptions[t.Subject] = append(descriptions[t.Subject], t.Object)
+		}
+	}
+	entities := make([]string, 0, len(descriptions))
+	for entity, descs := range descriptions {
+		if len(descs) >= minDescriptions {
+			entities = append(entities, entity)
+		}
+	}
+	sort.Strings(entities)
+
+	var errs []error
+	summarized := 0
+	for _, entity := range entities {
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+		ok, err := r.summarizeEntity(ctx, entity, descriptions[entity])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to summarize descriptions of %s: %w", entity, err))
+			continue
+		}
+		if ok {
+			summarized++
+		}
+	}
+	r.events.publish(EventMaintenanceCompleted, map[string]any{"task": "summarize_descriptions", "entities": summarized, "errors": len(errs)})
+	return summarized, errors.Join(errs...)
+}
+
+// summarizeDescriptionsAfterExtraction 提取后检查涉及的实体，描述数达到 SummarizeDescriptionsAt 时合并
+func (r *LightRAG) summarizeDescriptionsAfterExtraction(ctx context.Context, entities []Entity) {
+	seen := make(map[string]bool)
+	for _, entity := range entities {
+		if entity.Name == "" || entity.Description == "" || seen[entity.Name] {
+			continue
+		}
+		seen[entity.Name] = true
+		descriptions, err := r.graph.GetNeighbors(ctx, entity.Name, "DESCRIPTION")
+		if err != nil || len(descriptions) < r.summarizeAt {
+			continue
+		}
+		if _, err := r.summarizeEntity(ctx, entity.Name, descriptions); err != nil {
+			logrus.WithError(err).WithField("entity", entity.Name).Warn("Failed to summarize entity descriptions")
+		}
+	}
+}
+
+// summarizeEntity 用 LLM 合并实体的描述，在一个事务中删除参与合并的描述并写入合并结果
+// 只删除参与合并的描述，合并期间新提取的描述会保留到下一次合并；同一实体正在合并时直接返回 false
+func (r *LightRAG) summarizeEntity(ctx context.Context, entity string, descriptions []string) (bool, error) {
+	if _, busy := r.summarizing.LoadOrStore(entity, struct{}{}); busy {
+		return false, nil
+	}
+	defer r.summarizing.Delete(entity)
+	if r.curationFor(ctx, []string{entity}).isLocked(entity, "DESCRIPTION") {
+		return false, nil // 人工设置的描述不参与合并
+	}
+
+	// 去重并限制输入长度，重复的描述也需要删除
+	var selected, unique []string
+	seen := make(map[string]bool)
+	size := 0
+	for _, d := range descriptions {
+		if seen[d] {
+			selected = append(selected, d)
+			continue
+		}
+		n := len([]rune(d))
+		if len(unique) > 0 && size+n > maxDescriptionSummaryInput {
+			continue
+		}
+		seen[d] = true
+		size += n
+		selected = append(selected, d)
+		unique = append(unique, d)
+	}
+	if len(selected) < 2 {
+		return false, nil
+	}
+
+	summary := unique[0]
+	if len(unique) > 1 {
+		promptStr, err := GetDescriptionSummaryPrompt(ctx, entity, unique)
+		if err != nil {
+			return false, fmt.Errorf("failed to get description summary prompt: %w", err)
+		}
+		response, err := r.llm.Complete(ctx, promptStr)
+		if err != nil {
+			return false, err
+		}
+		summary = strings.TrimSpace(response)
+		if summary == "" {
+			return false, fmt.Errorf("empty summary")
+		}
+	}
+
+	if err := r.graph.UpdateLinks(ctx, entity, "DESCRIPTION", selected, []string{summary}); err != nil {
+		return false, err
+	}
+
+	r.statsMutex.Lock()
+	r.stats.DescriptionSummaries++
+	r.statsMutex.Unlock()
+	logrus.WithFields(logrus.Fields{
+		"entity":       entity,
+		"descriptions": len(selected),
+	}).Debug("Summarized entity descriptions")
+	return true, nil
+}
@@ -0,0 +1,92 @@
+package lightrag
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	cayley_driver "github.com/mozhou-tech/sqlite-ai-driver/pkg/cayley-driver"
+)
+
+// summaryLLM 返回固定的合并结果并记录提示词
+type summaryLLM struct {
+	prompts []string
+}
+
+func (l *summaryLLM) Complete(ctx context.Context, prompt string) (string, error) {
+	l.prompts = append(l.prompts, prompt)
+	return "  Go is a statically typed language created at Google.\n", nil
+}
+
+func newSummaryTestRAG(t *testing.T, llm LLM) *LightRAG {
+	t.Helper()
+	graph, err := cayley_driver.NewGraphWithNamespace(t.TempDir(), cayley_driver.GRAPH_DB_FILE, "summarize_test_")
+	if err != nil {
+		t.Fatalf("failed to create graph: %v", err)
+	}
+	t.Cleanup(func() { graph.Close() })
+	return &LightRAG{initialized: true, llm: llm, graph: &duckdbGraphDatabase{graph: graph}}
+}
+
+func TestSummarizeEntityDescriptions(t *testing.T) {
+	ctx := context.Background()
+	llm := &summaryLLM{}
+	rag := newSummaryTestRAG(t, llm)
+
+	for _, d := range []string{"A programming language", "Created at Google", "Statically typed"} {
+		rag.graph.Link(ctx, "Go", "DESCRIPTION", d)
+	}
+	rag.graph.Link(ctx, "Go", "TYPE", "Language")
+	rag.graph.Link(ctx, "Rust", "DESCRIPTION", "A systems language")
+
+	n, err := rag.SummarizeEntityDescriptions(ctx, 2)
+	if err != nil {
+		t.Fatalf("SummarizeEntityDescriptions failed: %v", err)
+	}
+	if n != 1 || len(llm.prompts) != 1 {
+		t.Fatalf("expected 1 summarized entity, got %d (%d prompts)", n, len(llm.prompts))
+	}
+	if !strings.Contains(llm.prompts[0], `"Go"`) || !strings.Contains(llm.prompts[0], "- Created at Google") {
+		t.Errorf("unexpected prompt: %s", llm.prompts[0])
+	}
+
+	descriptions, _ := rag.graph.GetNeighbors(ctx, "Go", "DESCRIPTION")
+	if len(descriptions) != 1 || descriptions[0] != "Go is a statically typed language created at Google." {
+		t.Errorf("unexpected descriptions after summary: %v", descriptions)
+	}
+	if types, _ := rag.graph.GetNeighbors(ctx, "Go", "TYPE"); len(types) != 1 {
+		t.Errorf("TYPE edge should be kept, got %v", types)
+	}
+	if descriptions, _ := rag.graph.GetNeighbors(ctx, "Rust", "DESCRIPTION"); len(descriptions) != 1 || descriptions[0] != "A systems language" {
+		t.Errorf("entity with a single description should be untouched, got %v", descriptions)
+	}
+	if stats := rag.GetExtractionStats(); stats.DescriptionSummaries != 1 {
+		t.Errorf("expected 1 description summary in stats, got %d", stats.DescriptionSummaries)
+	}
+}
+
+func TestSummarizeEntity_OnlyRemovesSummarizedDescriptions(t *testing.T) {
+	ctx := context.Background()
+	rag := newSummaryTestRAG(t, &summaryLLM{})
+	for _, d := range []string{"first", "second", "added during summary"} {
+		rag.graph.Link(ctx, "Go", "DESCRIPTION", d)
+	}
+
+	// 只传入前两条描述，模拟合并期间新提取了第三条
+	ok, err := rag.summarizeEntity(ctx, "Go", []string{"first", "second"})
+	if err != nil || !ok {
+		t.Fatalf("summarizeEntity failed: %v, %v", ok, err)
+	}
+	descriptions, _ := rag.graph.GetNeighbors(ctx, "Go", "DESCRIPTION")
+	sort.Strings(descriptions)
+	if len(descriptions) != 2 || descriptions[1] != "added during summary" {
+		t.Errorf("unexpected descriptions: %v", descriptions)
+	}
+
+	// 正在合并的实体直接跳过
+	rag.summarizing.Store("Go", struct{}{})
+	if ok, err := rag.summarizeEntity(ctx, "Go", descriptions); ok || err != nil {
+		t.Errorf("expected busy entity to be skipped, got %v, %v", ok, err)
+	}
+}
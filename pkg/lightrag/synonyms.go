@@ -0,0 +1,295 @@
+package lightrag
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// synonymsTable 用户维护的同义词，按集合区分
+// 每一行是一对同义词，查询扩展时双向生效：k8s -> kubernetes 也会把 kubernetes 扩展为 k8s
+const synonymsTable = "lightrag_synonyms"
+
+func ensureSynonymsTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			table_name VARCHAR,
+			term VARCHAR,
+			synonym VARCHAR,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (table_name, term, synonym)
+		)
+	`, synonymsTable))
+	if err != nil {
+		return fmt.Errorf("failed to create synonyms table: %w", err)
+	}
+	return nil
+}
+
+// addSynonyms 为 term 添加同义词，已存在的忽略，返回新增的数量
+func addSynonyms(ctx context.Context, db *sql.DB, tableName, term string, synonyms []string) (int, error) {
+	term = strings.TrimSpace(term)
+	if term == "" {
+		return 0, fmt.Errorf("term is required")
+	}
+	if err := ensureSynonymsTable(ctx, db); err != nil {
+		return 0, err
+	}
+	insertSQL := fmt.Sprintf(`INSERT INTO %s (table_name, term, synonym) VALUES (?, ?, ?) ON CONFLICT DO NOTHING`, synonymsTable)
+	added := 0
+	for _, synonym := range synonyms {
+		synonym = strings.TrimSpace(synonym)
+		if synonym == "" || strings.EqualFold(synonym, term) {
+			continue
+		}
+		result, err := db.ExecContext(ctx, insertSQL, tableName, term, synonym)
+		if err != nil {
+			return added, fmt.Errorf("failed to add synonym: %w", err)
+		}
+		n, _ := result.RowsAffected()
+		added += int(n)
+	}
+	return added, nil
+}
+
+// removeSynonyms 删除 term 的同义词，synonyms 为空时删除 term 的所有同义词，返回删除的数量
+func removeSynonyms(ctx context.Context, db *sql.DB, tableName, term string, synonyms []string) (int, error) {
+	if err := ensureSynonymsTable(ctx, db); err != nil {
+		return 0, err
+	}
+	deleteSQL := fmt.Sprintf(`DELETE FROM %s WHERE table_name = ? AND term = ?`, synonymsTable)
+	args := []any{tableName, strings.TrimSpace(term)}
+	if len(synonyms) > 0 {
+		deleteSQL += " AND synonym IN (?" + strings.Repeat(", ?", len(synonyms)-1) + ")"
+		for _, synonym := range synonyms {
+			args = append(args, strings.TrimSpace(synonym))
+		}
+	}
+	result, err := db.ExecContext(ctx, deleteSQL, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to remove synonyms: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return int(n), nil
+}
+
+// listSynonyms 返回集合的同义词（词 -> 同义词），与添加时的方向一致
+func listSynonyms(ctx context.Context, db *sql.DB, tableName string) (map[string][]string, error) {
+	if err := ensureSynonymsTable(ctx, db); err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(`
+		SELECT term, synonym FROM %s WHERE table_name = ? ORDER BY term, synonym
+	`, synonymsTable), tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query synonyms: %w", err)
+	}
+	defer rows.Close()
+
+	synonyms := make(map[string][]string)
+	for rows.Next() {
+		var term, synonym string
+		if err := rows.Scan(&term, &synonym); err != nil {
+			return nil, fmt.Errorf("failed to scan synonym: %w", err)
+		}
+		synonyms[term] = append(synonyms[term], synonym)
+	}
+	return synonyms, rows.Err()
+}
+
+// synonymIndex 查询扩展使用的同义词索引，键为小写的词，值包含两个方向的同义词
+type synonymIndex map[string][]string
+
+// loadSynonymIndex 读取集合的同义词并建立双向索引
+func loadSynonymIndex(ctx context.Context, db *sql.DB, tableName string) (synonymIndex, error) {
+	synonyms, err := listSynonyms(ctx, db, tableName)
+	if err != nil {
+		return nil, err
+	}
+	idx := make(synonymIndex)
+	add := func(from, to string) {
+		key := strings.ToLower(from)
+		for _, existing := range idx[key] {
+			if strings.EqualFold(existing, to) {
+				return
+			}
+		}
+		idx[key] = append(idx[key], to)
+	}
+	for term, list := range synonyms {
+		for _, synonym := range list {
+			add(term, synonym)
+			add(synonym, term)
+		}
+	}
+	return idx, nil
+}
+
+// lookup 返回词的同义词，不区分大小写
+func (idx synonymIndex) lookup(term string) []string {
+	return idx[strings.ToLower(strings.TrimSpace(term))]
+}
+
+// expandKeywords 在关键词之后追加它们的同义词，已有的关键词不重复添加
+func (idx synonymIndex) expandKeywords(keywords []string) []string {
+	if len(idx) == 0 {
+		return keywords
+	}
+	seen := make(map[string]bool, len(keywords))
+	for _, kw := range keywords {
+		seen[strings.ToLower(kw)] = true
+	}
+	expanded := append([]string(nil), keywords...)
+	for _, kw := range keywords {
+		for _, synonym := range idx.lookup(kw) {
+			if !seen[strings.ToLower(synonym)] {
+				seen[strings.ToLower(synonym)] = true
+				expanded = append(expanded, synonym)
+			}
+		}
+	}
+	return expanded
+}
+
+// expandQuery 在查询文本之后追加其中出现的词的同义词，用于按一组词搜索的全文检索
+// 英文词按单词边界匹配，中文按子串匹配，都不区分大小写
+func (idx synonymIndex) expandQuery(query string) string {
+	if len(idx) == 0 {
+		return query
+	}
+	terms := make([]string, 0, len(idx))
+	for term := range idx {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+
+	lower := strings.ToLower(query)
+	var extra []string
+	seen := make(map[string]bool)
+	for _, term := range terms {
+		if !containsTerm(lower, term) {
+			continue
+		}
+		for _, synonym := range idx[term] {
+			key := strings.ToLower(synonym)
+			if !seen[key] && !containsTerm(lower, key) {
+				seen[key] = true
+				extra = append(extra, synonym)
+			}
+		}
+	}
+	if len(extra) == 0 {
+		return query
+	}
+	return query + " " + strings.Join(extra, " ")
+}
+
+// containsTerm 检查小写的文本中是否出现小写的词
+func containsTerm(text, term string) bool {
+	pattern, ok := ftsTerm{text: term}.wordPattern()
+	if !ok {
+		return strings.Contains(text, term)
+	}
+	matched, err := regexp.MatchString(pattern, text)
+	return err == nil && matched
+}
+
+// expandNode 把查询语法树中在 content 中匹配的词（不含前缀）替换为词与同义词的 OR
+func (idx synonymIndex) expandNode(node ftsNode) ftsNode {
+	if len(idx) == 0 {
+		return node
+	}
+	switch n := node.(type) {
+	case ftsAnd:
+		children := make([]ftsNode, len(n.children))
+		for i, child := range n.children {
+			children[i] = idx.expandNode(child)
+		}
+		return ftsAnd{children: children}
+	case ftsOr:
+		children := make([]ftsNode, len(n.children))
+		for i, child := range n.children {
+			children[i] = idx.expandNode(child)
+		}
+		return ftsOr{children: children}
+	case ftsNot:
+		return ftsNot{child: idx.expandNode(n.child)}
+	case ftsTerm:
+		if n.prefix || (n.field != "" && n.field != "content") {
+			return n
+		}
+		synonyms := idx.lookup(n.text)
+		if len(synonyms) == 0 {
+			return n
+		}
+		children := []ftsNode{n}
+		for _, synonym := range synonyms {
+			children = append(children, ftsTerm{field: n.field, text: synonym})
+		}
+		return ftsOr{children: children}
+	}
+	return node
+}
+
+// AddSynonyms 为文档集合添加同义词（如缩写和领域术语），返回新增的数量
+// 同义词双向生效，查询时设置 QueryParam.ExpandSynonyms 或 FulltextSearchOptions.ExpandSynonyms 扩展查询
+func (r *LightRAG) AddSynonyms(ctx context.Context, term string, synonyms ...string) (int, error) {
+	c, err := r.maintenanceCollection()
+	if err != nil {
+		return 0, err
+	}
+	n, err := addSynonyms(ctx, c.db, c.tableName, term, synonyms)
+	if n > 0 {
+		c.markWritten()
+	}
+	return n, err
+}
+
+// RemoveSynonyms 删除 term 的同义词，不指定 synonyms 时删除 term 的所有同义词，返回删除的数量
+func (r *LightRAG) RemoveSynonyms(ctx context.Context, term string, synonyms ...string) (int, error) {
+	c, err := r.maintenanceCollection()
+	if err != nil {
+		return 0, err
+	}
+	n, err := removeSynonyms(ctx, c.db, c.tableName, term, synonyms)
+	if n > 0 {
+		c.markWritten()
+	}
+	return n, err
+}
+
+// ListSynonyms 返回文档集合的同义词（词 -> 同义词）
+func (r *LightRAG) ListSynonyms(ctx context.Context) (map[string][]string, error) {
+	c, err := r.maintenanceCollection()
+	if err != nil {
+		return nil, err
+	}
+	return listSynonyms(ctx, c.db, c.tableName)
+}
+
+// queryKeywords 提取查询关键词，param.ExpandSynonyms 为 true 时追加关键词的同义词
+// 读取同义词失败时只记录日志，使用原来的关键词
+func (r *LightRAG) queryKeywords(ctx context.Context, query string, param QueryParam) (*QueryKeywords, error) {
+	keywords, err := r.extractQueryKeywords(ctx, query)
+	if err != nil || !param.ExpandSynonyms {
+		return keywords, err
+	}
+	c, err := r.maintenanceCollection()
+	if err != nil {
+		return keywords, nil
+	}
+	idx, err := loadSynonymIndex(ctx, c.db, c.tableName)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to load synonyms, keywords are not expanded")
+		return keywords, nil
+	}
+	return &QueryKeywords{
+		LowLevel:  idx.expandKeywords(keywords.LowLevel),
+		HighLevel: idx.expandKeywords(keywords.HighLevel),
+	}, nil
+}
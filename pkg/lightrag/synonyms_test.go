@@ -0,0 +1,134 @@
+package lightrag
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"testing"
+
+	duckdb_driver "github.com/mozhou-tech/sqlite-ai-driver/pkg/duckdb-driver"
+)
+
+func TestSynonymIndex(t *testing.T) {
+	idx := synonymIndex{
+		"k8s":        {"Kubernetes"},
+		"kubernetes": {"k8s"},
+		"rag":        {"retrieval augmented generation"},
+		"检索增强生成":     {"RAG"},
+	}
+
+	keywords := idx.expandKeywords([]string{"K8s", "Docker", "kubernetes"})
+	if len(keywords) != 3 {
+		t.Errorf("expected no duplicate keywords, got %v", keywords)
+	}
+	keywords = idx.expandKeywords([]string{"RAG"})
+	if len(keywords) != 2 || keywords[1] != "retrieval augmented generation" {
+		t.Errorf("unexpected expanded keywords: %v", keywords)
+	}
+
+	if got := idx.expandQuery("deploy on k8s cluster"); got != "deploy on k8s cluster Kubernetes" {
+		t.Errorf("expandQuery = %q", got)
+	}
+	// 单词边界：drag 中的 rag 不扩展
+	if got := idx.expandQuery("drag and drop"); got != "drag and drop" {
+		t.Errorf("expandQuery = %q", got)
+	}
+	if got := idx.expandQuery("什么是检索增强生成"); got != "什么是检索增强生成 RAG" {
+		t.Errorf("expandQuery = %q", got)
+	}
+	if got := synonymIndex(nil).expandQuery("k8s"); got != "k8s" {
+		t.Errorf("nil index should not expand, got %q", got)
+	}
+
+	node, err := parseFulltextQuery(`k8s -rag title:k8s k8s*`)
+	if err != nil {
+		t.Fatalf("parseFulltextQuery failed: %v", err)
+	}
+	clause, args := idx.expandNode(node).sql(nil)
+	want := `((COALESCE(regexp_matches(content, ?), false) OR COALESCE(regexp_matches(content, ?), false)) AND ` +
+		`NOT (COALESCE(regexp_matches(content, ?), false) OR COALESCE(regexp_matches(content, ?), false)) AND ` +
+		`COALESCE(regexp_matches(json_extract_string(metadata, ?), ?), false) AND COALESCE(regexp_matches(content, ?), false))`
+	if clause != want {
+		t.Errorf("clause = %s\nwant %s", clause, want)
+	}
+	if len(args) != 7 || args[1] != `(?i)\bKubernetes\b` || args[3] != `(?i)\bretrieval\s+augmented\s+generation\b` {
+		t.Errorf("unexpected args: %v", args)
+	}
+}
+
+func TestSynonymExpansionSearch(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("duckdb", duckdb_driver.INDEX_DB_FILE)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	const table = "lightrag_synonyms_test"
+	cleanup := func() {
+		db.Exec(`DROP TABLE IF EXISTS ` + table)
+		db.Exec(`DELETE FROM `+synonymsTable+` WHERE table_name = ?`, table)
+	}
+	cleanup()
+	t.Cleanup(func() {
+		cleanup()
+		db.Close()
+	})
+	if _, err := db.Exec(`CREATE TABLE ` + table + ` (id VARCHAR PRIMARY KEY, content TEXT, metadata JSON, content_tokens TEXT, state VARCHAR DEFAULT 'active', created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP)`); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	for id, content := range map[string]string{
+		"a": "Kubernetes schedules pods across nodes",
+		"b": "Docker builds container images",
+	} {
+		if _, err := db.Exec(`INSERT INTO `+table+` (id, content, metadata) VALUES (?, ?, '{}')`, id, content); err != nil {
+			t.Fatalf("failed to insert document: %v", err)
+		}
+	}
+
+	n, err := addSynonyms(ctx, db, table, "k8s", []string{"kubernetes", "kube", "K8S", ""})
+	if err != nil || n != 2 {
+		t.Fatalf("addSynonyms = %d, %v; want 2 added", n, err)
+	}
+	if n, _ := addSynonyms(ctx, db, table, "k8s", []string{"kubernetes"}); n != 0 {
+		t.Errorf("expected existing synonym to be ignored, added %d", n)
+	}
+	synonyms, err := listSynonyms(ctx, db, table)
+	if err != nil {
+		t.Fatalf("listSynonyms failed: %v", err)
+	}
+	if got := synonyms["k8s"]; len(got) != 2 || got[0] != "kube" || got[1] != "kubernetes" {
+		t.Errorf("unexpected synonyms: %v", synonyms)
+	}
+
+	search := &duckdbFulltextSearch{db: db, tableName: table}
+	for _, syntax := range []bool{false, true} {
+		results, err := search.FindWithScores(ctx, "k8s", FulltextSearchOptions{Limit: 10, Syntax: syntax})
+		if err != nil {
+			t.Fatalf("FindWithScores failed: %v", err)
+		}
+		if len(results) != 0 {
+			t.Errorf("syntax=%v: expected no results without expansion, got %d", syntax, len(results))
+		}
+		results, err = search.FindWithScores(ctx, "k8s", FulltextSearchOptions{Limit: 10, Syntax: syntax, ExpandSynonyms: true})
+		if err != nil {
+			t.Fatalf("FindWithScores failed: %v", err)
+		}
+		var ids []string
+		for _, r := range results {
+			ids = append(ids, r.Document.ID())
+		}
+		sort.Strings(ids)
+		if len(ids) != 1 || ids[0] != "a" {
+			t.Errorf("syntax=%v: expected the kubernetes document, got %v", syntax, ids)
+		}
+	}
+
+	if n, err := removeSynonyms(ctx, db, table, "k8s", []string{"kube"}); err != nil || n != 1 {
+		t.Errorf("removeSynonyms = %d, %v; want 1", n, err)
+	}
+	if n, err := removeSynonyms(ctx, db, table, "k8s", nil); err != nil || n != 1 {
+		t.Errorf("removeSynonyms(all) = %d, %v; want 1", n, err)
+	}
+	if synonyms, _ := listSynonyms(ctx, db, table); len(synonyms) != 0 {
+		t.Errorf("expected no synonyms left, got %v", synonyms)
+	}
+}
@@ -0,0 +1,70 @@
+package lightrag
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// defaultMaxToolSteps RunTools 默认最多调用模型的次数
+const defaultMaxToolSteps = 8
+
+// ToolHandler 执行一次工具调用并返回结果文本，返回的错误会作为结果告诉模型，由模型决定如何继续
+type ToolHandler func(ctx context.Context, call ToolCall) (string, error)
+
+// RunTools 循环调用模型并执行其请求的工具，直到模型给出不含工具调用的回答
+// 返回完整的对话（包括工具调用和结果），最后一条为模型的回答；maxSteps <= 0 时使用默认值
+func RunTools(ctx context.Context, llm ToolCallingLLM, messages []Message, tools []Tool, handler ToolHandler, maxSteps int) ([]Message, error) {
+	if llm == nil {
+		return messages, fmt.Errorf("LLM is not available")
+	}
+	if maxSteps <= 0 {
+		maxSteps = defaultMaxToolSteps
+	}
+	conversation := append([]Message(nil), messages...)
+	for step := 0; step < maxSteps; step++ {
+		reply, err := llm.CompleteWithTools(ctx, conversation, tools)
+		if err != nil {
+			return conversation, fmt.Errorf("failed to complete with tools: %w", err)
+		}
+		conversation = append(conversation, *reply)
+		if len(reply.ToolCalls) == 0 {
+			return conversation, nil
+		}
+		for _, call := range reply.ToolCalls {
+			result, err := handler(ctx, call)
+			if err != nil {
+				result = fmt.Sprintf("error: %v", err)
+			}
+			conversation = append(conversation, Message{Role: RoleTool, Content: result, ToolCallID: call.ID})
+		}
+	}
+	return conversation, fmt.Errorf("no final answer after %d steps", maxSteps)
+}
+
+// completeJSON 请求 JSON 格式的回答：LLM 支持 JSON 模式时使用 CompleteStructured，否则退回到 Complete
+func completeJSON(ctx context.Context, llm LLM, prompt string, schema *JSONSchema) (string, error) {
+	if structured, ok := llm.(StructuredLLM); ok {
+		return structured.CompleteStructured(ctx, prompt, schema)
+	}
+	return llm.Complete(ctx, prompt)
+}
+
+// decodeJSONResponse 解析模型返回的 JSON
+// JSON 模式下响应本身就是 JSON；不支持 JSON 模式的模型可能在 JSON 前后附带说明或代码块，此时截取第一个 { 到最后一个 } 之间的内容
+func decodeJSONResponse(response string, v any) error {
+	trimmed := strings.TrimSpace(response)
+	if err := json.Unmarshal([]byte(trimmed), v); err == nil {
+		return nil
+	}
+	start := strings.Index(trimmed, "{")
+	end := strings.LastIndex(trimmed, "}")
+	if start == -1 || end < start {
+		return fmt.Errorf("no JSON object found in response: %s", response)
+	}
+	if err := json.Unmarshal([]byte(trimmed[start:end+1]), v); err != nil {
+		return fmt.Errorf("invalid JSON in response: %w", err)
+	}
+	return nil
+}
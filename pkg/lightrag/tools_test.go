@@ -0,0 +1,140 @@
+package lightrag
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newTestOpenAIServer 启动模拟的 chat completions 接口，handle 接收请求体并返回 assistant 消息
+func newTestOpenAIServer(t *testing.T, handle func(req map[string]any) map[string]any) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []any{map[string]any{"message": handle(req)}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOpenAILLM_CompleteStructured(t *testing.T) {
+	var formats []any
+	var prompts []string
+	server := newTestOpenAIServer(t, func(req map[string]any) map[string]any {
+		formats = append(formats, req["response_format"])
+		msg := req["messages"].([]any)[0].(map[string]any)
+		prompts = append(prompts, msg["content"].(string))
+		return map[string]any{"role": "assistant", "content": `{"low_level": ["Go"], "high_level": []}`}
+	})
+
+	ctx := context.Background()
+	llm := NewOpenAILLM(&OpenAIConfig{BaseURL: server.URL})
+	response, err := llm.CompleteStructured(ctx, "keywords", queryKeywordsSchema)
+	if err != nil {
+		t.Fatalf("CompleteStructured failed: %v", err)
+	}
+	var keywords QueryKeywords
+	if err := decodeJSONResponse(response, &keywords); err != nil || len(keywords.LowLevel) != 1 {
+		t.Errorf("unexpected keywords %+v, %v", keywords, err)
+	}
+	// 默认使用 json_object 模式，schema 附加在提示词中
+	if formats[0].(map[string]any)["type"] != "json_object" {
+		t.Errorf("expected json_object response format, got %v", formats[0])
+	}
+	if !strings.Contains(prompts[0], `"low_level"`) {
+		t.Errorf("schema should be appended to the prompt: %s", prompts[0])
+	}
+
+	strict := NewOpenAILLM(&OpenAIConfig{BaseURL: server.URL, StrictSchema: true})
+	if _, err := strict.CompleteStructured(ctx, "keywords", queryKeywordsSchema); err != nil {
+		t.Fatalf("CompleteStructured failed: %v", err)
+	}
+	format := formats[1].(map[string]any)
+	if format["type"] != "json_schema" || format["json_schema"].(map[string]any)["name"] != "query_keywords" {
+		t.Errorf("expected json_schema response format, got %v", format)
+	}
+	if prompts[1] != "keywords" {
+		t.Errorf("strict mode should not modify the prompt: %s", prompts[1])
+	}
+}
+
+func TestRunTools(t *testing.T) {
+	server := newTestOpenAIServer(t, func(req map[string]any) map[string]any {
+		messages := req["messages"].([]any)
+		last := messages[len(messages)-1].(map[string]any)
+		if last["role"] == RoleTool {
+			return map[string]any{"role": "assistant", "content": "It is " + last["content"].(string)}
+		}
+		if tools := req["tools"].([]any); len(tools) != 1 {
+			t.Errorf("expected 1 tool, got %d", len(tools))
+		}
+		return map[string]any{
+			"role": "assistant",
+			"tool_calls": []any{map[string]any{
+				"id":       "call_1",
+				"type":     "function",
+				"function": map[string]any{"name": "weather", "arguments": `{"city":"Beijing"}`},
+			}},
+		}
+	})
+
+	llm := NewOpenAILLM(&OpenAIConfig{BaseURL: server.URL})
+	tools := []Tool{{
+		Name:        "weather",
+		Description: "Get the weather of a city",
+		Parameters: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"city": map[string]any{"type": "string"}},
+		},
+	}}
+	var calls []ToolCall
+	handler := func(ctx context.Context, call ToolCall) (string, error) {
+		calls = append(calls, call)
+		var args struct{ City string }
+		if err := json.Unmarshal([]byte(call.Arguments), &args); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("sunny in %s", args.City), nil
+	}
+
+	conversation, err := RunTools(context.Background(), llm, []Message{{Role: RoleUser, Content: "weather?"}}, tools, handler, 0)
+	if err != nil {
+		t.Fatalf("RunTools failed: %v", err)
+	}
+	if len(calls) != 1 || calls[0].Name != "weather" || calls[0].ID != "call_1" {
+		t.Errorf("unexpected tool calls: %+v", calls)
+	}
+	if len(conversation) != 4 {
+		t.Fatalf("expected 4 messages, got %d: %+v", len(conversation), conversation)
+	}
+	if conversation[2].Role != RoleTool || conversation[2].ToolCallID != "call_1" {
+		t.Errorf("unexpected tool message: %+v", conversation[2])
+	}
+	if answer := conversation[3].Content; answer != "It is sunny in Beijing" {
+		t.Errorf("unexpected answer: %s", answer)
+	}
+}
+
+func TestDecodeJSONResponse(t *testing.T) {
+	var result ExtractionResult
+	if err := decodeJSONResponse(`{"entities": [{"name": "Go"}], "relationships": []}`, &result); err != nil || len(result.Entities) != 1 {
+		t.Errorf("failed to decode plain JSON: %+v, %v", result, err)
+	}
+	// 不支持 JSON 模式的模型可能返回代码块
+	result = ExtractionResult{}
+	if err := decodeJSONResponse("Here you go:\n```json\n{\"entities\": [{\"name\": \"Go\"}]}\n```", &result); err != nil || len(result.Entities) != 1 {
+		t.Errorf("failed to decode fenced JSON: %+v, %v", result, err)
+	}
+	if err := decodeJSONResponse("no json here", &result); err == nil {
+		t.Error("expected error for response without JSON")
+	}
+}
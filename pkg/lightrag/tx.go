@@ -0,0 +1,92 @@
+package lightrag
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+)
+
+// Tx 数据库事务
+// 通过 Collection 获取的集合在事务中读写，Commit 之前其他连接看不到这些修改；
+// 可以先写入一批分块和元数据，图谱链接失败时 Rollback 撤销整批写入
+type Tx interface {
+	// Collection 获取事务中的集合，集合需要先通过 Database.Collection 打开
+	Collection(name string) (Collection, error)
+	// Commit 提交事务，并为事务中写入的文档生成向量
+	Commit() error
+	// Rollback 回滚事务，提交之后调用没有影响
+	Rollback() error
+}
+
+// sqlConn duckdbCollection 执行 SQL 的连接，*sql.DB 和 *sql.Tx 都满足
+type sqlConn interface {
+	sqlExecutor
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// duckdbTx 基于 DuckDB 的事务
+type duckdbTx struct {
+	ctx context.Context
+	db  *duckdbDatabase
+	tx  *sql.Tx
+
+	mu   sync.Mutex
+	used []*duckdbCollection // 事务中用过的集合，提交后唤醒 embedding worker
+}
+
+func (d *duckdbDatabase) BeginTx(ctx context.Context) (Tx, error) {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	return &duckdbTx{ctx: ctx, db: d, tx: tx}, nil
+}
+
+func (t *duckdbTx) Collection(name string) (Collection, error) {
+	t.db.mu.Lock()
+	base, ok := t.db.collections[name]
+	t.db.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("collection %s is not opened", name)
+	}
+
+	t.mu.Lock()
+	t.used = append(t.used, base)
+	t.mu.Unlock()
+
+	// 复制已注册的向量搜索配置；事务中的集合不能再添加向量或全文搜索
+	return &duckdbCollection{
+		db:             base.db,
+		tx:             t.tx,
+		tableName:      base.tableName,
+		schema:         base.schema,
+		vectorSearches: base.getVectorSearches(),
+		worker:         base.getEmbeddingWorker(),
+		analyzer:       base.getAnalyzer(),
+		writes:         base.writes,
+	}, nil
+}
+
+func (t *duckdbTx) Commit() error {
+	if err := t.tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	t.db.writes.Add(1)
+
+	// 写入的文档标记为 pending，提交后才对 worker 可见
+	t.mu.Lock()
+	used := t.used
+	t.mu.Unlock()
+	for _, c := range used {
+		c.startEmbeddingWorker(t.ctx)
+	}
+	return nil
+}
+
+func (t *duckdbTx) Rollback() error {
+	if err := t.tx.Rollback(); err != nil && err != sql.ErrTxDone {
+		return fmt.Errorf("failed to rollback transaction: %w", err)
+	}
+	return nil
+}
@@ -24,6 +24,19 @@ type QueryParam struct {
 	Limit     int            `json:"limit"`
 	Threshold float64        `json:"threshold"` // 分数阈值
 	Filters   map[string]any `json:"filters"`   // 元数据过滤器 (Mango Selector)
+	// ExpandSynonyms 用集合的同义词（AddSynonyms）扩展全文检索的查询和图谱检索的关键词
+	ExpandSynonyms bool `json:"expand_synonyms"`
+	// AllowedLabels 查询者拥有的访问标签，不为 nil 时只检索没有标签（ACLLabelsField）或标签与其有交集的文档；
+	// 空数组表示只能检索没有标签的文档
+	AllowedLabels []string `json:"allowed_labels,omitempty"`
+	// ExpandNeighbors 生成回答前把每个检索到的分块与同一原文档中前后各 n 个分块拼接（最多 5），
+	// 需要导入时保存分块的顺序元数据（ChunkParentField、ChunkIndexField）；0 表示不扩展，只影响 Query
+	ExpandNeighbors int `json:"expand_neighbors,omitempty"`
+	// UseHyDE 先让 LLM 为查询写一段假设的回答，用回答的向量代替查询的向量做向量检索，
+	// 改善描述不充分的查询的召回；未配置 LLM 或生成失败时使用查询的向量
+	UseHyDE bool `json:"use_hyde,omitempty"`
+	// HyDEFuseQuery 开启 UseHyDE 时把假设回答的向量与查询的向量归一化后取平均，兼顾查询中的原始用词
+	HyDEFuseQuery bool `json:"hyde_fuse_query,omitempty"`
 }
 
 // SearchResult 搜索结果
@@ -77,3 +90,54 @@ type Embedder interface {
 type LLM interface {
 	Complete(ctx context.Context, prompt string) (string, error)
 }
+
+// JSONSchema 结构化输出的 JSON Schema，Name 只能包含字母、数字、下划线和连字符
+type JSONSchema struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Schema      map[string]any `json:"schema"`
+}
+
+// StructuredLLM 支持 JSON 模式的语言模型，返回的内容是一个完整的 JSON 值，不包含 Markdown 代码块等多余文本
+// schema 为 nil 时只要求返回 JSON 对象；LLM 实现了该接口时，图谱提取和查询关键词提取会使用 JSON 模式
+type StructuredLLM interface {
+	LLM
+	CompleteStructured(ctx context.Context, prompt string, schema *JSONSchema) (string, error)
+}
+
+// 对话消息的角色
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
+// Message 对话消息
+type Message struct {
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`   // assistant 消息中模型请求的工具调用
+	ToolCallID string     `json:"tool_call_id,omitempty"` // tool 消息对应的调用 ID
+}
+
+// Tool 可供模型调用的工具，Parameters 为参数的 JSON Schema
+type Tool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Parameters  map[string]any `json:"parameters"`
+}
+
+// ToolCall 模型请求的一次工具调用，Arguments 为 JSON 编码的参数
+type ToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// ToolCallingLLM 支持工具调用的语言模型
+// 返回的 assistant 消息包含 ToolCalls 时，调用方执行工具后把结果作为 tool 消息追加到对话中再次调用
+type ToolCallingLLM interface {
+	LLM
+	CompleteWithTools(ctx context.Context, messages []Message, tools []Tool) (*Message, error)
+}
@@ -0,0 +1,343 @@
+package lightrag
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Pricing 模型价格，单位为每百万 token 的费用（币种由使用方约定），用于估算费用
+type Pricing struct {
+	PromptPerMillion     float64 // LLM 输入
+	CompletionPerMillion float64 // LLM 输出
+	EmbeddingPerMillion  float64 // 向量生成
+}
+
+// TokenUsage 一组模型调用的 token 用量和估算费用
+type TokenUsage struct {
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	EmbeddingTokens  int     `json:"embedding_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	Cost             float64 `json:"cost"`
+	// EstimatedCalls 服务没有返回用量、按文本长度估算 token 数的调用次数
+	EstimatedCalls int `json:"estimated_calls"`
+}
+
+func (u *TokenUsage) add(other TokenUsage) {
+	u.Calls += other.Calls
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.EmbeddingTokens += other.EmbeddingTokens
+	u.TotalTokens += other.TotalTokens
+	u.Cost += other.Cost
+	u.EstimatedCalls += other.EstimatedCalls
+}
+
+// UsageStats LightRAG 实例创建以来的模型调用用量
+type UsageStats struct {
+	Total     TokenUsage `json:"total"`
+	LLM       TokenUsage `json:"llm"`
+	Embedding TokenUsage `json:"embedding"`
+	// Ingestion 文档导入（向量生成和图谱提取）的用量，Documents 为涉及的文档数
+	Ingestion TokenUsage `json:"ingestion"`
+	Documents int        `json:"documents"`
+	// Queries Query 和 Retrieve 的用量，QueryCount 为查询次数
+	Queries    TokenUsage `json:"queries"`
+	QueryCount int        `json:"query_count"`
+	StartTime  time.Time  `json:"start_time"`
+}
+
+// UsageTracker 累计一段调用的用量，见 WithUsageTracker
+type UsageTracker struct {
+	mu    sync.Mutex
+	usage TokenUsage
+}
+
+// Usage 返回目前累计的用量
+func (t *UsageTracker) Usage() TokenUsage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.usage
+}
+
+func (t *UsageTracker) add(u TokenUsage) {
+	t.mu.Lock()
+	t.usage.add(u)
+	t.mu.Unlock()
+}
+
+// usageScope 调用的归属：所属文档或查询，以及调用方的 UsageTracker
+type usageScope struct {
+	docID    string
+	query    bool
+	trackers []*UsageTracker
+}
+
+type usageScopeKey struct{}
+
+// reportedUsageKey 单次调用中由模型实现通过 ReportUsage 上报的用量
+type reportedUsageKey struct{}
+
+type reportedUsage struct {
+	mu               sync.Mutex
+	reported         bool
+	promptTokens     int
+	completionTokens int
+}
+
+func scopeFrom(ctx context.Context) usageScope {
+	scope, _ := ctx.Value(usageScopeKey{}).(usageScope)
+	return scope
+}
+
+// WithUsageTracker 返回的 ctx 中发起的 LLM 和 Embedder 调用（如一次 Query）都会累计到 tracker
+func WithUsageTracker(ctx context.Context) (context.Context, *UsageTracker) {
+	tracker := &UsageTracker{}
+	scope := scopeFrom(ctx)
+	scope.trackers = append(scope.trackers[:len(scope.trackers):len(scope.trackers)], tracker)
+	return context.WithValue(ctx, usageScopeKey{}, scope), tracker
+}
+
+// withDocumentUsage 把调用计入文档 docID 的导入用量
+func withDocumentUsage(ctx context.Context, docID string) context.Context {
+	scope := scopeFrom(ctx)
+	scope.docID = docID
+	return context.WithValue(ctx, usageScopeKey{}, scope)
+}
+
+// ReportUsage 由 LLM 或 Embedder 实现调用，上报服务返回的实际 token 数（Embedder 只填 promptTokens）
+// 没有上报的调用按文本长度估算
+func ReportUsage(ctx context.Context, promptTokens, completionTokens int) {
+	if r, ok := ctx.Value(reportedUsageKey{}).(*reportedUsage); ok {
+		r.mu.Lock()
+		r.reported = true
+		r.promptTokens += promptTokens
+		r.completionTokens += completionTokens
+		r.mu.Unlock()
+	}
+}
+
+// estimateTokens 粗略估算文本的 token 数：中日韩等非 ASCII 字符按每字 1 个，其余按每 4 个字符 1 个
+func estimateTokens(text string) int {
+	ascii, other := 0, 0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return other + (ascii+3)/4
+}
+
+// usageMeter 统计 LightRAG 的模型调用用量，nil 表示不统计（直接构造的 LightRAG）
+type usageMeter struct {
+	pricing Pricing
+
+	mu        sync.Mutex
+	stats     UsageStats
+	documents map[string]TokenUsage
+}
+
+func newUsageMeter(pricing *Pricing) *usageMeter {
+	m := &usageMeter{
+		documents: make(map[string]TokenUsage),
+		stats:     UsageStats{StartTime: time.Now()},
+	}
+	if pricing != nil {
+		m.pricing = *pricing
+	}
+	return m
+}
+
+// startQuery 把 ctx 中的调用计入查询用量，嵌套调用（Query 内部的 Retrieve）只计一次查询
+func (m *usageMeter) startQuery(ctx context.Context) context.Context {
+	scope := scopeFrom(ctx)
+	if m == nil || scope.query {
+		return ctx
+	}
+	m.mu.Lock()
+	m.stats.QueryCount++
+	m.mu.Unlock()
+	scope.query = true
+	return context.WithValue(ctx, usageScopeKey{}, scope)
+}
+
+// meter 执行一次调用并记录用量：优先使用模型实现上报的 token 数，否则调用成功时按 prompt 和 completion 估算
+func (m *usageMeter) meter(ctx context.Context, embedding bool, prompt func() string, call func(ctx context.Context) (completion func() string, err error)) error {
+	reported := &reportedUsage{}
+	completion, err := call(context.WithValue(ctx, reportedUsageKey{}, reported))
+
+	reported.mu.Lock()
+	u := TokenUsage{Calls: 1, PromptTokens: reported.promptTokens, CompletionTokens: reported.completionTokens}
+	wasReported := reported.reported
+	reported.mu.Unlock()
+	if !wasReported {
+		if err != nil {
+			// 失败且没有上报用量的调用不计费
+			return err
+		}
+		u.EstimatedCalls = 1
+		u.PromptTokens = estimateTokens(prompt())
+		if completion != nil {
+			u.CompletionTokens = estimateTokens(completion())
+		}
+	}
+
+	if embedding {
+		u.EmbeddingTokens = u.PromptTokens + u.CompletionTokens
+		u.PromptTokens, u.CompletionTokens = 0, 0
+		u.Cost = float64(u.EmbeddingTokens) * m.pricing.EmbeddingPerMillion / 1e6
+	} else {
+		u.Cost = (float64(u.PromptTokens)*m.pricing.PromptPerMillion + float64(u.CompletionTokens)*m.pricing.CompletionPerMillion) / 1e6
+	}
+	u.TotalTokens = u.PromptTokens + u.CompletionTokens + u.EmbeddingTokens
+	m.record(scopeFrom(ctx), embedding, u)
+	return err
+}
+
+func (m *usageMeter) record(scope usageScope, embedding bool, u TokenUsage) {
+	m.mu.Lock()
+	m.stats.Total.add(u)
+	if embedding {
+		m.stats.Embedding.add(u)
+	} else {
+		m.stats.LLM.add(u)
+	}
+	switch {
+	case scope.docID != "":
+		m.stats.Ingestion.add(u)
+		doc := m.documents[scope.docID]
+		doc.add(u)
+		m.documents[scope.docID] = doc
+	case scope.query:
+		m.stats.Queries.add(u)
+	}
+	m.mu.Unlock()
+
+	for _, tracker := range scope.trackers {
+		tracker.add(u)
+	}
+}
+
+func (m *usageMeter) snapshot() UsageStats {
+	if m == nil {
+		return UsageStats{}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := m.stats
+	stats.Documents = len(m.documents)
+	return stats
+}
+
+func (m *usageMeter) document(docID string) (TokenUsage, bool) {
+	if m == nil {
+		return TokenUsage{}, false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, ok := m.documents[docID]
+	return u, ok
+}
+
+// wrapLLM 统计 LLM 调用的用量，保留 JSON 模式和工具调用能力
+func (m *usageMeter) wrapLLM(llm LLM) LLM {
+	if llm == nil {
+		return nil
+	}
+	metered := &meteredLLM{llm: llm, meter: m}
+	if _, ok := llm.(ToolCallingLLM); ok {
+		return &meteredToolCallingLLM{metered}
+	}
+	return metered
+}
+
+type meteredLLM struct {
+	llm   LLM
+	meter *usageMeter
+}
+
+func (l *meteredLLM) Complete(ctx context.Context, prompt string) (string, error) {
+	var response string
+	err := l.meter.meter(ctx, false, func() string { return prompt }, func(ctx context.Context) (func() string, error) {
+		var err error
+		response, err = l.llm.Complete(ctx, prompt)
+		return func() string { return response }, err
+	})
+	return response, err
+}
+
+func (l *meteredLLM) CompleteStructured(ctx context.Context, prompt string, schema *JSONSchema) (string, error) {
+	var response string
+	err := l.meter.meter(ctx, false, func() string { return prompt }, func(ctx context.Context) (func() string, error) {
+		var err error
+		response, err = completeJSON(ctx, l.llm, prompt, schema)
+		return func() string { return response }, err
+	})
+	return response, err
+}
+
+type meteredToolCallingLLM struct {
+	*meteredLLM
+}
+
+func (l *meteredToolCallingLLM) CompleteWithTools(ctx context.Context, messages []Message, tools []Tool) (*Message, error) {
+	var reply *Message
+	prompt := func() string {
+		var sb strings.Builder
+		for _, m := range messages {
+			sb.WriteString(m.Content)
+			for _, call := range m.ToolCalls {
+				sb.WriteString(call.Arguments)
+			}
+		}
+		return sb.String()
+	}
+	err := l.meter.meter(ctx, false, prompt, func(ctx context.Context) (func() string, error) {
+		var err error
+		reply, err = l.llm.(ToolCallingLLM).CompleteWithTools(ctx, messages, tools)
+		return func() string {
+			if reply == nil {
+				return ""
+			}
+			text := reply.Content
+			for _, call := range reply.ToolCalls {
+				text += call.Name + call.Arguments
+			}
+			return text
+		}, err
+	})
+	return reply, err
+}
+
+// wrapEmbedder 统计 Embedder 调用的用量
+func (m *usageMeter) wrapEmbedder(embedder Embedder) Embedder {
+	if embedder == nil {
+		return nil
+	}
+	return &meteredEmbedder{embedder: embedder, meter: m}
+}
+
+type meteredEmbedder struct {
+	embedder Embedder
+	meter    *usageMeter
+}
+
+func (e *meteredEmbedder) Embed(ctx context.Context, text string) ([]float64, error) {
+	var embedding []float64
+	err := e.meter.meter(ctx, true, func() string { return text }, func(ctx context.Context) (func() string, error) {
+		var err error
+		embedding, err = e.embedder.Embed(ctx, text)
+		return nil, err
+	})
+	return embedding, err
+}
+
+func (e *meteredEmbedder) Dimensions() int {
+	return e.embedder.Dimensions()
+}
@@ -0,0 +1,95 @@
+package lightrag
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestOpenAIUsageServer 模拟返回 usage 的 chat completions 接口
+func newTestOpenAIUsageServer(t *testing.T, promptTokens, completionTokens int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []any{map[string]any{"message": map[string]any{"role": "assistant", "content": `{"entities": []}`}}},
+			"usage":   map[string]any{"prompt_tokens": promptTokens, "completion_tokens": completionTokens},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestUsageAccounting(t *testing.T) {
+	server := newTestOpenAIServer(t, func(req map[string]any) map[string]any {
+		return map[string]any{"role": "assistant", "content": `{"entities": []}`}
+	})
+	usageServer := newTestOpenAIUsageServer(t, 100, 20)
+
+	rag := New(Options{
+		LLM:      NewOpenAILLM(&OpenAIConfig{BaseURL: usageServer.URL}),
+		Embedder: NewSimpleEmbedder(4),
+		Pricing:  &Pricing{PromptPerMillion: 1, CompletionPerMillion: 4, EmbeddingPerMillion: 0.5},
+	})
+
+	// 导入：服务返回的用量 + 按长度估算的 embedding
+	docCtx := withDocumentUsage(context.Background(), "doc-1")
+	if _, err := completeJSON(docCtx, rag.llm, "extract", extractionSchema); err != nil {
+		t.Fatalf("completeJSON failed: %v", err)
+	}
+	if _, err := rag.embedder.Embed(docCtx, "abcdefgh中文"); err != nil {
+		t.Fatalf("Embed failed: %v", err)
+	}
+
+	// 查询：Query 中嵌套的 Retrieve 只计一次查询
+	queryCtx, tracker := WithUsageTracker(context.Background())
+	queryCtx = rag.usage.startQuery(rag.usage.startQuery(queryCtx))
+	if _, err := rag.llm.Complete(queryCtx, "answer"); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+
+	doc, ok := rag.GetDocumentUsage("doc-1")
+	if !ok {
+		t.Fatal("expected usage for doc-1")
+	}
+	// estimateTokens("abcdefgh中文") = 2 + 2
+	if doc.Calls != 2 || doc.PromptTokens != 100 || doc.CompletionTokens != 20 || doc.EmbeddingTokens != 4 || doc.EstimatedCalls != 1 {
+		t.Errorf("unexpected document usage: %+v", doc)
+	}
+	if want := (100*1 + 20*4 + 4*0.5) / 1e6; math.Abs(doc.Cost-want) > 1e-12 {
+		t.Errorf("document cost = %v, want %v", doc.Cost, want)
+	}
+
+	if u := tracker.Usage(); u.Calls != 1 || u.TotalTokens != 120 {
+		t.Errorf("unexpected tracked query usage: %+v", u)
+	}
+
+	stats := rag.GetUsageStats()
+	if stats.Total.Calls != 3 || stats.LLM.Calls != 2 || stats.Embedding.Calls != 1 {
+		t.Errorf("unexpected totals: %+v", stats)
+	}
+	if stats.Ingestion.Calls != 2 || stats.Documents != 1 || stats.Queries.Calls != 1 || stats.QueryCount != 1 {
+		t.Errorf("unexpected breakdown: %+v", stats)
+	}
+
+	// 不返回 usage 的服务按文本长度估算
+	estimated := New(Options{LLM: NewOpenAILLM(&OpenAIConfig{BaseURL: server.URL})})
+	if _, err := estimated.llm.Complete(context.Background(), "12345678"); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if u := estimated.GetUsageStats().LLM; u.EstimatedCalls != 1 || u.PromptTokens != 2 || u.CompletionTokens != estimateTokens(`{"entities": []}`) || u.Cost != 0 {
+		t.Errorf("unexpected estimated usage: %+v", u)
+	}
+}
+
+func TestUsageMeterPreservesToolCalling(t *testing.T) {
+	rag := New(Options{LLM: NewOpenAILLM(&OpenAIConfig{}), CallPolicy: DefaultCallPolicy()})
+	if _, ok := rag.llm.(ToolCallingLLM); !ok {
+		t.Error("metered OpenAILLM should keep tool calling support")
+	}
+	if rag := New(Options{}); rag.llm != nil || rag.embedder != nil {
+		t.Error("nil LLM and Embedder should stay nil")
+	}
+}
@@ -0,0 +1,174 @@
+package lightrag
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"testing"
+
+	duckdb_driver "github.com/mozhou-tech/sqlite-ai-driver/pkg/duckdb-driver"
+)
+
+// newTestVectorSearch 创建包含给定向量（id -> 向量字面量）的表，返回其上的向量搜索
+func newTestVectorSearch(t *testing.T, table string, vectors map[string]string) *duckdbVectorSearch {
+	t.Helper()
+	db, err := sql.Open("duckdb", duckdb_driver.INDEX_DB_FILE)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	db.Exec(`DROP TABLE IF EXISTS ` + table)
+	t.Cleanup(func() {
+		db.Exec(`DROP TABLE IF EXISTS ` + table)
+		db.Close()
+	})
+	if _, err := db.Exec(`CREATE TABLE ` + table + ` (id VARCHAR PRIMARY KEY, content TEXT, metadata JSON, embedding_status VARCHAR, vector_test FLOAT[], created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, state VARCHAR DEFAULT 'active')`); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	for id, vector := range vectors {
+		if _, err := db.Exec(`INSERT INTO `+table+` (id, content, metadata, embedding_status, vector_test) VALUES (?, ?, '{}', 'completed', ?::FLOAT[])`, id, id, vector); err != nil {
+			t.Fatalf("failed to insert document: %v", err)
+		}
+	}
+	return &duckdbVectorSearch{db: db, tableName: table, config: VectorSearchConfig{Identifier: "test"}}
+}
+
+func TestVectorSearchMetrics(t *testing.T) {
+	ctx := context.Background()
+	// small 与查询方向相同但长度小，large 方向略有偏差但长度大
+	search := newTestVectorSearch(t, "lightrag_vector_metric_test", map[string]string{
+		"small": "[1.0, 0.0]",
+		"large": "[3.0, 1.0]",
+	})
+	query := []float64{1, 0}
+
+	for _, tc := range []struct {
+		metric VectorMetric
+		first  string
+		score  float64
+	}{
+		{"", "small", 1},
+		{MetricCosine, "small", 1},
+		{MetricDotProduct, "large", 3},
+		{MetricL2, "small", 1},
+	} {
+		results, err := search.Search(ctx, query, VectorSearchOptions{Limit: 2, Metric: tc.metric})
+		if err != nil {
+			t.Fatalf("Search(%q) failed: %v", tc.metric, err)
+		}
+		if len(results) != 2 || results[0].Document.ID() != tc.first || math.Abs(results[0].Score-tc.score) > 1e-6 {
+			t.Errorf("Search(%q): unexpected results %v, %v", tc.metric, results[0].Document.ID(), results[0].Score)
+		}
+	}
+
+	// 配置中的 metric 作为默认值
+	search.config.Metric = MetricDotProduct
+	if results, _ := search.Search(ctx, query, VectorSearchOptions{Limit: 1}); len(results) != 1 || results[0].Document.ID() != "large" {
+		t.Errorf("expected config metric to be used, got %+v", results)
+	}
+	if _, err := search.Search(ctx, query, VectorSearchOptions{Metric: "manhattan"}); err == nil {
+		t.Error("expected error for unsupported metric")
+	}
+}
+
+func TestVectorSearchMinScoreAndMMR(t *testing.T) {
+	ctx := context.Background()
+	// a 和 a2 几乎相同，b 与查询的相关性较低但内容不同
+	search := newTestVectorSearch(t, "lightrag_vector_mmr_test", map[string]string{
+		"a":  "[1.0, 0.2]",
+		"a2": "[1.0, 0.22]",
+		"b":  "[0.6, 1.0]",
+	})
+	query := []float64{1, 0.3}
+	ids := func(results []VectorSearchResult) []string {
+		out := make([]string, len(results))
+		for i, r := range results {
+			out[i] = r.Document.ID()
+		}
+		return out
+	}
+
+	results, err := search.Search(ctx, query, VectorSearchOptions{Limit: 2})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if got := ids(results); len(got) != 2 || got[0] != "a2" || got[1] != "a" {
+		t.Errorf("expected the two most similar results, got %v", got)
+	}
+
+	results, err = search.Search(ctx, query, VectorSearchOptions{Limit: 2, MMRLambda: 0.5})
+	if err != nil {
+		t.Fatalf("Search with MMR failed: %v", err)
+	}
+	if got := ids(results); len(got) != 2 || got[0] != "a2" || got[1] != "b" {
+		t.Errorf("expected MMR to skip the near duplicate, got %v", got)
+	}
+	if results[1].Score > 0.8 {
+		t.Errorf("MMR should keep the original score, got %v", results[1].Score)
+	}
+
+	results, err = search.Search(ctx, query, VectorSearchOptions{Limit: 3, MinScore: 0.9})
+	if err != nil {
+		t.Fatalf("Search with MinScore failed: %v", err)
+	}
+	if got := ids(results); len(got) != 2 {
+		t.Errorf("expected results below MinScore to be dropped, got %v", got)
+	}
+
+	if _, err := search.Search(ctx, query, VectorSearchOptions{MMRLambda: 1.5}); err == nil {
+		t.Error("expected error for MMR lambda out of range")
+	}
+}
+
+func TestDocumentStates(t *testing.T) {
+	ctx := context.Background()
+	search := newTestVectorSearch(t, "lightrag_document_state_test", map[string]string{
+		"a": "[1.0, 0.0]",
+		"b": "[1.0, 0.5]",
+		"c": "[0.0, 1.0]",
+	})
+	collection := &duckdbCollection{db: search.db, tableName: "lightrag_document_state_test"}
+	ids := func(results []VectorSearchResult) []string {
+		out := make([]string, len(results))
+		for i, r := range results {
+			out[i] = r.Document.ID()
+		}
+		return out
+	}
+
+	if n, err := collection.Archive(ctx, "a"); err != nil || n != 1 {
+		t.Fatalf("Archive = %d, %v", n, err)
+	}
+	if n, err := collection.SoftDelete(ctx, "b"); err != nil || n != 1 {
+		t.Fatalf("SoftDelete = %d, %v", n, err)
+	}
+	// 已归档的文档不能再次归档
+	if n, _ := collection.Archive(ctx, "a", "b"); n != 0 {
+		t.Errorf("expected no state change, got %d", n)
+	}
+
+	results, err := search.Search(ctx, []float64{1, 0}, VectorSearchOptions{Limit: 3})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if got := ids(results); len(got) != 1 || got[0] != "c" {
+		t.Errorf("expected only the active document, got %v", got)
+	}
+	if doc, err := collection.FindByID(ctx, "a"); err != nil || doc != nil {
+		t.Errorf("expected archived document to be hidden from FindByID, got %v, %v", doc, err)
+	}
+	docs, err := collection.Find(ctx, FindOptions{States: []DocumentState{StateArchived, StateDeleted}})
+	if err != nil {
+		t.Fatalf("Find failed: %v", err)
+	}
+	if len(docs) != 2 {
+		t.Errorf("expected archived and deleted documents, got %d", len(docs))
+	}
+
+	// 恢复后重新参与检索，向量没有丢失
+	if n, err := collection.Unarchive(ctx, "a", "b", "c"); err != nil || n != 2 {
+		t.Fatalf("Unarchive = %d, %v", n, err)
+	}
+	if results, _ := search.Search(ctx, []float64{1, 0}, VectorSearchOptions{Limit: 3}); len(results) != 3 || results[0].Document.ID() != "a" {
+		t.Errorf("expected restored documents to be searchable, got %v", ids(results))
+	}
+}
@@ -0,0 +1,212 @@
+package sego
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// KeywordMethod 关键词提取算法
+type KeywordMethod int
+
+const (
+	// KeywordTFIDF 基于词频和词典 IDF 的 TF-IDF 算法，适合提取具体的实体、术语
+	KeywordTFIDF KeywordMethod = iota
+	// KeywordTextRank 基于词共现图的 TextRank 算法，适合提取概括性的主题词
+	KeywordTextRank
+)
+
+// textRankWindow TextRank 共现窗口大小
+const textRankWindow = 5
+
+// Keyword 关键词及其权重
+type Keyword struct {
+	Word   string
+	Weight float64
+}
+
+// ExtractKeywords 使用 TF-IDF 从文本中提取权重最高的 topK 个关键词
+func (s *Segmenter) ExtractKeywords(text string, topK int) []string {
+	keywords := s.ExtractKeywordsWithWeights(text, topK, KeywordTFIDF)
+	words := make([]string, len(keywords))
+	for i, kw := range keywords {
+		words[i] = kw.Word
+	}
+	return words
+}
+
+// ExtractKeywordsWithWeights 使用指定算法从文本中提取关键词，按权重降序返回；topK <= 0 时返回全部
+// 候选词经过规范化并过滤停用词、标点、单字和纯数字
+func (s *Segmenter) ExtractKeywordsWithWeights(text string, topK int, method KeywordMethod) []Keyword {
+	candidates, idf := s.keywordCandidates(text)
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	var weights map[string]float64
+	switch method {
+	case KeywordTextRank:
+		weights = textRankWeights(candidates)
+	default:
+		weights = tfidfWeights(candidates, idf)
+	}
+
+	keywords := make([]Keyword, 0, len(weights))
+	for word, weight := range weights {
+		keywords = append(keywords, Keyword{Word: word, Weight: weight})
+	}
+	sort.Slice(keywords, func(i, j int) bool {
+		if keywords[i].Weight != keywords[j].Weight {
+			return keywords[i].Weight > keywords[j].Weight
+		}
+		return keywords[i].Word < keywords[j].Word
+	})
+
+	if topK > 0 && len(keywords) > topK {
+		keywords = keywords[:topK]
+	}
+	return keywords
+}
+
+// ExtractKeywords 使用全局分词器和 TF-IDF 从文本中提取权重最高的 topK 个关键词
+func ExtractKeywords(text string, topK int) []string {
+	return defaultSegmenter.ExtractKeywords(text, topK)
+}
+
+// ExtractKeywordsWithWeights 使用全局分词器按指定算法从文本中提取关键词，见 Segmenter.ExtractKeywordsWithWeights
+func ExtractKeywordsWithWeights(text string, topK int, method KeywordMethod) []Keyword {
+	return defaultSegmenter.ExtractKeywordsWithWeights(text, topK, method)
+}
+
+// keywordCandidates 分词并返回按原文顺序排列的候选词，以及每个候选词的 IDF
+// IDF 使用词典词频近似估计：log(词典总词频 / 词频)，词典未收录的词视为低频词
+func (s *Segmenter) keywordCandidates(text string) ([]string, map[string]float64) {
+	if text == "" {
+		return nil, nil
+	}
+
+	segmenter, err := s.Sego()
+	if err != nil {
+		return nil, nil
+	}
+
+	opts := DefaultTokenizeOptions()
+	text = Normalize(text, opts.Normalize)
+	totalFreq := float64(segmenter.Dictionary().TotalFrequency())
+	maxIDF := math.Log(totalFreq)
+
+	var candidates []string
+	idf := make(map[string]float64)
+	for _, seg := range segmenter.Segment([]byte(text)) {
+		token := seg.Token()
+		word := strings.ToLower(strings.TrimSpace(token.Text()))
+		if !isKeywordCandidate(word) {
+			continue
+		}
+		candidates = append(candidates, word)
+		if _, ok := idf[word]; !ok {
+			freq := float64(token.Frequency())
+			if freq <= 1 {
+				idf[word] = maxIDF
+			} else {
+				idf[word] = math.Log(totalFreq / freq)
+			}
+		}
+	}
+	return candidates, idf
+}
+
+// isKeywordCandidate 判断词是否可以作为关键词
+func isKeywordCandidate(word string) bool {
+	if utf8.RuneCountInString(word) < 2 {
+		return false
+	}
+	if isPunctuation(word) || IsStopword(word) {
+		return false
+	}
+	hasLetter := false
+	for _, r := range word {
+		if unicode.IsLetter(r) {
+			hasLetter = true
+			break
+		}
+	}
+	return hasLetter
+}
+
+// tfidfWeights 计算 TF-IDF 权重
+func tfidfWeights(candidates []string, idf map[string]float64) map[string]float64 {
+	tf := make(map[string]float64)
+	for _, w := range candidates {
+		tf[w]++
+	}
+	total := float64(len(candidates))
+	weights := make(map[string]float64, len(tf))
+	for w, count := range tf {
+		weights[w] = count / total * idf[w]
+	}
+	return weights
+}
+
+// textRankWeights 在候选词序列上构建共现图并迭代计算 TextRank 权重
+func textRankWeights(candidates []string) map[string]float64 {
+	edges := make(map[string]map[string]float64)
+	addEdge := func(a, b string) {
+		if edges[a] == nil {
+			edges[a] = make(map[string]float64)
+		}
+		edges[a][b]++
+	}
+	for i, w := range candidates {
+		if edges[w] == nil {
+			edges[w] = make(map[string]float64)
+		}
+		for j := i + 1; j < len(candidates) && j < i+textRankWindow; j++ {
+			if candidates[j] == w {
+				continue
+			}
+			addEdge(w, candidates[j])
+			addEdge(candidates[j], w)
+		}
+	}
+
+	outSum := make(map[string]float64, len(edges))
+	for w, neighbors := range edges {
+		for _, weight := range neighbors {
+			outSum[w] += weight
+		}
+	}
+
+	const damping = 0.85
+	scores := make(map[string]float64, len(edges))
+	for w := range edges {
+		scores[w] = 1.0
+	}
+	for iter := 0; iter < 10; iter++ {
+		next := make(map[string]float64, len(edges))
+		for w, neighbors := range edges {
+			sum := 0.0
+			for n, weight := range neighbors {
+				if outSum[n] > 0 {
+					sum += weight / outSum[n] * scores[n]
+				}
+			}
+			next[w] = (1 - damping) + damping*sum
+		}
+		scores = next
+	}
+
+	// 归一化到 (0, 1]
+	maxScore := 0.0
+	for _, s := range scores {
+		maxScore = math.Max(maxScore, s)
+	}
+	if maxScore > 0 {
+		for w := range scores {
+			scores[w] /= maxScore
+		}
+	}
+	return scores
+}
@@ -0,0 +1,135 @@
+package sego
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+//go:embed dictionary/t2s.txt
+var t2sData []byte
+
+// ChineseConversion 简繁转换方式
+type ChineseConversion int
+
+const (
+	// ConversionNone 不做简繁转换
+	ConversionNone ChineseConversion = iota
+	// ConversionToSimplified 繁体转简体
+	ConversionToSimplified
+	// ConversionToTraditional 简体转繁体
+	ConversionToTraditional
+)
+
+// NormalizeOptions 文本规范化选项
+type NormalizeOptions struct {
+	// Lowercase 将字母转为小写
+	Lowercase bool
+	// FullWidthToHalfWidth 将全角字符（全角字母、数字、标点和空格）转为半角
+	FullWidthToHalfWidth bool
+	// Conversion 简繁转换方式，基于内嵌的常用字映射表逐字转换
+	Conversion ChineseConversion
+}
+
+var (
+	normalizeMu      sync.RWMutex
+	normalizeOptions NormalizeOptions
+
+	conversionOnce sync.Once
+	t2sTable       map[rune]rune
+	s2tTable       map[rune]rune
+)
+
+// SetNormalizeOptions 设置 Tokenize 使用的全局规范化选项，默认不做任何规范化
+func SetNormalizeOptions(opts NormalizeOptions) {
+	normalizeMu.Lock()
+	defer normalizeMu.Unlock()
+	normalizeOptions = opts
+}
+
+// GetNormalizeOptions 返回 Tokenize 当前使用的全局规范化选项
+func GetNormalizeOptions() NormalizeOptions {
+	normalizeMu.RLock()
+	defer normalizeMu.RUnlock()
+	return normalizeOptions
+}
+
+// Normalize 按选项对文本进行规范化
+func Normalize(text string, opts NormalizeOptions) string {
+	if text == "" || opts == (NormalizeOptions{}) {
+		return text
+	}
+
+	var table map[rune]rune
+	switch opts.Conversion {
+	case ConversionToSimplified:
+		loadConversionTables()
+		table = t2sTable
+	case ConversionToTraditional:
+		loadConversionTables()
+		table = s2tTable
+	}
+
+	return strings.Map(func(r rune) rune {
+		if opts.FullWidthToHalfWidth {
+			r = toHalfWidth(r)
+		}
+		if table != nil {
+			if mapped, ok := table[r]; ok {
+				r = mapped
+			}
+		}
+		if opts.Lowercase {
+			r = unicode.ToLower(r)
+		}
+		return r
+	}, text)
+}
+
+// toHalfWidth 将全角字符转为半角
+func toHalfWidth(r rune) rune {
+	switch {
+	case r == 0x3000:
+		return ' '
+	case r >= 0xFF01 && r <= 0xFF5E:
+		return r - 0xFEE0
+	default:
+		return r
+	}
+}
+
+// loadConversionTables 加载内嵌的简繁映射表
+func loadConversionTables() {
+	conversionOnce.Do(func() {
+		t2sTable = make(map[rune]rune)
+		s2tTable = make(map[rune]rune)
+
+		scanner := bufio.NewScanner(bytes.NewReader(t2sData))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				continue
+			}
+			t, s := []rune(fields[0]), []rune(fields[1])
+			if len(t) != 1 || len(s) != 1 {
+				continue
+			}
+			t2sTable[t[0]] = s[0]
+
+			// 单向映射或已有更常用的繁体字时不写入简转繁表
+			if len(fields) >= 3 && fields[2] == "t2s" {
+				continue
+			}
+			if _, ok := s2tTable[s[0]]; !ok {
+				s2tTable[s[0]] = t[0]
+			}
+		}
+	})
+}
@@ -0,0 +1,180 @@
+package sego
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+//go:embed dictionary/pinyin.txt
+var pinyinData []byte
+
+var (
+	pinyinOnce  sync.Once
+	pinyinTable map[rune]string
+)
+
+// loadPinyinTable 加载内嵌的拼音表
+func loadPinyinTable() {
+	pinyinOnce.Do(func() {
+		pinyinTable = make(map[rune]string)
+
+		scanner := bufio.NewScanner(bytes.NewReader(pinyinData))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				continue
+			}
+			for _, r := range fields[1] {
+				pinyinTable[r] = fields[0]
+			}
+		}
+	})
+}
+
+// runePinyin 返回单个汉字的拼音，繁体字按对应的简体字查找
+func runePinyin(r rune) (string, bool) {
+	loadPinyinTable()
+	if py, ok := pinyinTable[r]; ok {
+		return py, true
+	}
+	loadConversionTables()
+	if s, ok := t2sTable[r]; ok {
+		py, ok := pinyinTable[s]
+		return py, ok
+	}
+	return "", false
+}
+
+// Pinyin 将文本逐字转换为无声调拼音（ü 写作 v），返回音节列表
+// 未收录的汉字以及连续的字母数字按原样（小写）作为一个元素输出，空白和标点被丢弃
+// 多音字只取一个最常用的读音，因此结果用于检索匹配而不保证读音准确
+func Pinyin(text string) []string {
+	var syllables []string
+	var other strings.Builder
+	flush := func() {
+		if other.Len() > 0 {
+			syllables = append(syllables, other.String())
+			other.Reset()
+		}
+	}
+
+	for _, r := range text {
+		if py, ok := runePinyin(r); ok {
+			flush()
+			syllables = append(syllables, py)
+			continue
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			other.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		flush()
+	}
+	flush()
+	return syllables
+}
+
+// PinyinString 返回文本的全拼（音节之间不加分隔），如 "中国" -> "zhongguo"
+func PinyinString(text string) string {
+	return strings.Join(Pinyin(text), "")
+}
+
+// PinyinInitials 返回文本的拼音首字母，如 "中国" -> "zg"
+func PinyinInitials(text string) string {
+	var b strings.Builder
+	for _, syllable := range Pinyin(text) {
+		b.WriteByte(syllable[0])
+	}
+	return b.String()
+}
+
+// fuzzyPinyinReplacer 模糊音规则：平翘舌不分（zh/z、ch/c、sh/s）、前后鼻音不分（ang/an、eng/en、ing/in）、n/l 不分
+var fuzzyPinyinReplacer = strings.NewReplacer("zh", "z", "ch", "c", "sh", "s", "ng", "n", "l", "n")
+
+// FuzzyPinyin 将拼音按模糊音规则归一化，索引和查询两侧都归一化后，常见的同音、近音错字也能匹配
+func FuzzyPinyin(pinyin string) string {
+	return fuzzyPinyinReplacer.Replace(strings.ToLower(pinyin))
+}
+
+// PinyinShadow 生成拼音影子列的内容，用于让全文检索支持拼音输入
+// 文本按 TokenizeFiltered 分词，每个含汉字的词输出全拼和（多字词的）首字母，以空格分隔；
+// fuzzy 为 true 时按 FuzzyPinyin 归一化。查询时使用 PinyinQuery 生成检索词，两侧 fuzzy 设置需一致
+func PinyinShadow(text string, fuzzy bool) string {
+	return pinyinTerms(TokenizeWithOptions(text, DefaultTokenizeOptions()), fuzzy, true)
+}
+
+// PinyinQuery 将查询转换为拼音检索词，用于检索 PinyinShadow 生成的影子列
+// 查询可以是中文（转换为全拼）或拼音输入（如 "zhongguo"、"zhong guo"、"zg"）；
+// 拼音输入含多个片段时，额外输出拼接后的整体，以匹配多音节词的全拼
+func PinyinQuery(query string, fuzzy bool) string {
+	// 拼音音节（如 an、he）可能与英文停用词相同，这里只对中文词过滤停用词
+	opts := DefaultTokenizeOptions()
+	opts.RemoveStopwords = false
+
+	var tokens, latin []string
+	for _, token := range TokenizeWithOptions(query, opts) {
+		if !containsHan(token) {
+			latin = append(latin, token)
+		} else if IsStopword(token) {
+			continue
+		}
+		tokens = append(tokens, token)
+	}
+	if len(latin) > 1 {
+		tokens = append(tokens, strings.Join(latin, ""))
+	}
+	return pinyinTerms(tokens, fuzzy, false)
+}
+
+// pinyinTerms 将词转换为拼音检索词并去重
+func pinyinTerms(tokens []string, fuzzy, withInitials bool) string {
+	seen := make(map[string]bool)
+	var terms []string
+	add := func(term string) {
+		if fuzzy {
+			term = FuzzyPinyin(term)
+		}
+		if term != "" && !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+
+	for _, token := range tokens {
+		if !containsHan(token) {
+			// 影子列只收录中文词的拼音，查询侧保留拼音输入
+			if !withInitials {
+				add(token)
+			}
+			continue
+		}
+		syllables := Pinyin(token)
+		add(strings.Join(syllables, ""))
+		if withInitials && len(syllables) > 1 {
+			var initials strings.Builder
+			for _, syllable := range syllables {
+				initials.WriteByte(syllable[0])
+			}
+			add(initials.String())
+		}
+	}
+	return strings.Join(terms, " ")
+}
+
+// containsHan 判断字符串是否包含汉字
+func containsHan(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
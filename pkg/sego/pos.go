@@ -0,0 +1,117 @@
+package sego
+
+import (
+	"strings"
+
+	huichensego "github.com/huichen/sego"
+)
+
+// TaggedToken 带词性标注的词
+// 词性使用 sego 词典中的 ICTCLAS 标注（n 名词、nr 人名、ns 地名、nt 机构名、nz 其他专名、v 动词、p 介词等），
+// 词典未收录的词（包括英文、数字）词性为 "x"
+type TaggedToken struct {
+	Text string
+	Pos  string
+}
+
+// TokenizeWithPOS 对文本分词，返回带词性的词列表
+// 与 Tokenize 一样会应用 SetNormalizeOptions 设置的全局规范化选项
+func (s *Segmenter) TokenizeWithPOS(text string) []TaggedToken {
+	if text == "" {
+		return nil
+	}
+
+	segmenter, err := s.Sego()
+	if err != nil {
+		return nil
+	}
+
+	opts := GetNormalizeOptions()
+	tokens := segmentTaggedTokens(segmenter, Normalize(text, opts), false)
+	if opts.Lowercase {
+		for i := range tokens {
+			tokens[i].Text = strings.ToLower(tokens[i].Text)
+		}
+	}
+	return tokens
+}
+
+// Nouns 返回文本中的名词（含人名、地名、机构名等专有名词），用于生成实体候选
+// 词典未收录的多字符词（通常是英文术语或新词）也会保留
+func (s *Segmenter) Nouns(text string) []string {
+	var nouns []string
+	for _, token := range s.TokenizeWithPOS(text) {
+		if IsNounPOS(token.Pos) || (token.Pos == "x" && len([]rune(token.Text)) > 1 && !isPunctuation(token.Text)) {
+			nouns = append(nouns, token.Text)
+		}
+	}
+	return nouns
+}
+
+// TokenizeWithPOS 使用全局分词器对文本分词，返回带词性的词列表
+func TokenizeWithPOS(text string) []TaggedToken {
+	return defaultSegmenter.TokenizeWithPOS(text)
+}
+
+// Nouns 使用全局分词器返回文本中的名词，见 Segmenter.Nouns
+func Nouns(text string) []string {
+	return defaultSegmenter.Nouns(text)
+}
+
+// IsNounPOS 判断词性是否为名词类（n、nr、ns、nt、nz、nrt、nrfg、ng 等）
+func IsNounPOS(pos string) bool {
+	return strings.HasPrefix(pos, "n")
+}
+
+// IsFunctionWordPOS 判断词性是否为虚词：介词 p、连词 c、助词 u、叹词 e、语气词 y、拟声词 o
+func IsFunctionWordPOS(pos string) bool {
+	if pos == "" {
+		return false
+	}
+	switch pos[0] {
+	case 'p', 'c', 'u', 'e', 'y', 'o':
+		return true
+	}
+	return false
+}
+
+// segmentTaggedTokens 对文本分词，返回去除空白后的非空词及其词性
+// searchMode 为 true 时同时输出长词中包含的短词，短词使用其自身在词典中的词性
+func segmentTaggedTokens(segmenter *huichensego.Segmenter, text string, searchMode bool) []TaggedToken {
+	var tokens []TaggedToken
+	for _, seg := range segmenter.Segment([]byte(text)) {
+		if searchMode {
+			tokens = appendSearchModeTokens(tokens, seg.Token())
+			continue
+		}
+		if token := taggedToken(seg.Token()); token.Text != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// appendSearchModeTokens 按 sego 搜索模式的规则递归展开词，与 huichensego.SegmentsToSlice(segs, true) 的输出一致
+func appendSearchModeTokens(tokens []TaggedToken, token *huichensego.Token) []TaggedToken {
+	subs := token.Segments()
+	hasOnlyTerminalToken := true
+	for _, s := range subs {
+		if len(s.Token().Segments()) > 1 {
+			hasOnlyTerminalToken = false
+		}
+	}
+	if !hasOnlyTerminalToken {
+		for _, s := range subs {
+			tokens = appendSearchModeTokens(tokens, s.Token())
+		}
+	}
+	if t := taggedToken(token); t.Text != "" {
+		tokens = append(tokens, t)
+	}
+	return tokens
+}
+
+// taggedToken 将 sego 的词转换为 TaggedToken，去除首尾空白
+func taggedToken(token *huichensego.Token) TaggedToken {
+	return TaggedToken{Text: strings.TrimSpace(token.Text()), Pos: token.Pos()}
+}
@@ -2,9 +2,11 @@ package sego
 
 import (
 	_ "embed"
+	"fmt"
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	huichensego "github.com/huichen/sego"
 )
@@ -12,56 +14,166 @@ import (
 //go:embed dictionary/dictionary.txt
 var dictionaryData []byte
 
-var (
-	globalSegmenter huichensego.Segmenter
-	once            sync.Once
-	initErr         error
-)
+// Options 分词器选项
+type Options struct {
+	// DictionaryFiles 额外加载的词典文件（sego 词典格式，每行 "词 词频 [词性]"），与内嵌词典合并；
+	// 同一个词以先出现的词条为准，内嵌词典排在这些文件之前
+	DictionaryFiles []string
+	// WithoutEmbeddedDictionary 不加载内嵌词典，此时 DictionaryFiles 不能为空
+	WithoutEmbeddedDictionary bool
+	// UserDictFiles 创建时加载的用户词典文件，格式见 LoadUserDict
+	UserDictFiles []string
+}
 
-// GetSegmenter 返回全局 sego 分词器，并在需要时初始化。
-// 它会自动处理内嵌词典的加载和临时文件的管理。
-func GetSegmenter() (*huichensego.Segmenter, error) {
-	once.Do(func() {
-		tmpFile, err := os.CreateTemp("", "sego-dict-*.txt")
-		if err != nil {
-			initErr = err
-			return
-		}
-		// 词典加载完后即可删除临时文件
-		defer os.Remove(tmpFile.Name())
+// Segmenter 分词器实例，持有独立的词典（内嵌词典、额外词典文件和用户词）
+// 底层 sego 分词器在第一次分词时才加载词典，词典变更后在下一次分词时重建；所有方法都可以并发调用
+// 停用词表和 SetNormalizeOptions 设置的规范化选项为包级配置，由所有实例共享
+type Segmenter struct {
+	opts Options
+
+	// current 当前生效的 sego 分词器，词典变更后会整体替换，已取得旧分词器的调用方不受影响
+	current atomic.Pointer[huichensego.Segmenter]
+	// dirty 标记词典已变更，下次获取分词器时需要重建
+	dirty atomic.Bool
+	// mu 保护用户词典配置以及分词器的构建过程
+	mu sync.Mutex
+	// userWords 用户词典（LoadUserDict 和 AddWord 注册的词），优先级高于其他词典
+	userWords map[string]userWord
+	// removedWords 被 RemoveWord 移除的词，构建词典时会同时从其他词典中剔除
+	removedWords map[string]bool
+}
 
-		if _, err := tmpFile.Write(dictionaryData); err != nil {
-			initErr = err
-			return
+// defaultSegmenter 包级函数使用的默认分词器，仅使用内嵌词典
+var defaultSegmenter = newSegmenter(Options{})
+
+// New 创建一个独立的分词器实例，词典在第一次分词时加载
+func New(opts Options) (*Segmenter, error) {
+	if opts.WithoutEmbeddedDictionary && len(opts.DictionaryFiles) == 0 {
+		return nil, fmt.Errorf("no dictionary: DictionaryFiles is required when WithoutEmbeddedDictionary is set")
+	}
+	// sego 在词典文件不存在时会直接退出进程，这里提前检查
+	for _, path := range opts.DictionaryFiles {
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("dictionary file %s: %w", path, err)
 		}
+	}
 
-		if err := tmpFile.Close(); err != nil {
-			initErr = err
-			return
+	s := newSegmenter(opts)
+	for _, path := range opts.UserDictFiles {
+		if err := s.LoadUserDict(path); err != nil {
+			return nil, err
 		}
+	}
+	return s, nil
+}
 
-		globalSegmenter.LoadDictionary(tmpFile.Name())
-	})
-	return &globalSegmenter, initErr
+func newSegmenter(opts Options) *Segmenter {
+	return &Segmenter{
+		opts:         opts,
+		userWords:    make(map[string]userWord),
+		removedWords: make(map[string]bool),
+	}
+}
+
+// Sego 返回底层的 sego 分词器，并在需要时初始化或按变更后的词典重建
+func (s *Segmenter) Sego() (*huichensego.Segmenter, error) {
+	if seg := s.current.Load(); seg != nil && !s.dirty.Load() {
+		return seg, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 双重检查：可能已被其他 goroutine 构建完成
+	if seg := s.current.Load(); seg != nil && !s.dirty.Load() {
+		return seg, nil
+	}
+
+	seg, err := s.build()
+	if err != nil {
+		return nil, err
+	}
+	s.current.Store(seg)
+	s.dirty.Store(false)
+	return seg, nil
 }
 
 // Init 显式初始化分词器，用于在程序启动时预加载词典
-func Init() error {
-	_, err := GetSegmenter()
+func (s *Segmenter) Init() error {
+	_, err := s.Sego()
 	return err
 }
 
-// Tokenize 使用 sego 对文本进行中文分词，返回用空格分隔的词
-func Tokenize(text string) string {
+// build 合并所有词典构建新的 sego 分词器，调用方需持有 mu
+func (s *Segmenter) build() (*huichensego.Segmenter, error) {
+	tmpFile, err := os.CreateTemp("", "sego-dict-*.txt")
+	if err != nil {
+		return nil, err
+	}
+	// 词典加载完后即可删除临时文件
+	defer os.Remove(tmpFile.Name())
+
+	if err := s.writeDictionary(tmpFile); err != nil {
+		tmpFile.Close()
+		return nil, err
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return nil, err
+	}
+
+	seg := &huichensego.Segmenter{}
+	seg.LoadDictionary(tmpFile.Name())
+	return seg, nil
+}
+
+// Tokenize 对文本进行中文分词，返回用空格分隔的词
+// 分词前会应用 SetNormalizeOptions 设置的全局规范化选项
+func (s *Segmenter) Tokenize(text string) string {
 	if text == "" {
 		return ""
 	}
 
-	segmenter, err := GetSegmenter()
+	segmenter, err := s.Sego()
 	if err != nil {
 		return text
 	}
 
+	opts := GetNormalizeOptions()
+	tokens := segmentTokens(segmenter, Normalize(text, opts))
+	if opts.Lowercase {
+		for i, token := range tokens {
+			tokens[i] = strings.ToLower(token)
+		}
+	}
+
+	if len(tokens) == 0 {
+		return text // 如果分词结果为空，返回原文
+	}
+
+	return strings.Join(tokens, " ")
+}
+
+// GetSegmenter 返回全局 sego 分词器，并在需要时初始化。
+// 它会自动处理内嵌词典的加载和临时文件的管理。
+// 如果通过 LoadUserDict/AddWord/RemoveWord 修改过词典，会在这里重新构建分词器。
+func GetSegmenter() (*huichensego.Segmenter, error) {
+	return defaultSegmenter.Sego()
+}
+
+// Init 显式初始化全局分词器，用于在程序启动时预加载词典
+func Init() error {
+	return defaultSegmenter.Init()
+}
+
+// Tokenize 使用全局分词器对文本进行中文分词，返回用空格分隔的词
+// 分词前会应用 SetNormalizeOptions 设置的全局规范化选项
+func Tokenize(text string) string {
+	return defaultSegmenter.Tokenize(text)
+}
+
+// segmentTokens 对文本分词，返回去除空白后的非空词
+func segmentTokens(segmenter *huichensego.Segmenter, text string) []string {
 	segments := segmenter.Segment([]byte(text))
 	var tokens []string
 	for _, seg := range segments {
@@ -72,10 +184,5 @@ func Tokenize(text string) string {
 			tokens = append(tokens, token)
 		}
 	}
-
-	if len(tokens) == 0 {
-		return text // 如果分词结果为空，返回原文
-	}
-
-	return strings.Join(tokens, " ")
+	return tokens
 }
@@ -0,0 +1,319 @@
+package sego
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	huichensego "github.com/huichen/sego"
+)
+
+func containsToken(tokens, token string) bool {
+	for _, t := range strings.Fields(tokens) {
+		if t == token {
+			return true
+		}
+	}
+	return false
+}
+
+func TestUserDictionary(t *testing.T) {
+	text := "我们正在开发墨舟知识库引擎"
+	if containsToken(Tokenize(text), "墨舟知识库") {
+		t.Fatalf("墨舟知识库 should not be a single token before registration")
+	}
+
+	// AddWord 注册的词作为单个词输出
+	if err := AddWord("墨舟知识库", 0, "nz"); err != nil {
+		t.Fatalf("AddWord failed: %v", err)
+	}
+	if tokens := Tokenize(text); !containsToken(tokens, "墨舟知识库") {
+		t.Errorf("Expected 墨舟知识库 as a single token, got %q", tokens)
+	}
+
+	// RemoveWord 之后不再作为整体输出
+	RemoveWord("墨舟知识库")
+	if tokens := Tokenize(text); containsToken(tokens, "墨舟知识库") {
+		t.Errorf("Expected 墨舟知识库 to be split after removal, got %q", tokens)
+	}
+
+	// LoadUserDict 从文件加载
+	dictPath := filepath.Join(t.TempDir(), "user_dict.txt")
+	content := "# 领域词典\n墨舟知识库 20000 nz\nSKU-XJ\n"
+	if err := os.WriteFile(dictPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write user dictionary: %v", err)
+	}
+	if err := LoadUserDict(dictPath); err != nil {
+		t.Fatalf("LoadUserDict failed: %v", err)
+	}
+	if tokens := Tokenize(text); !containsToken(tokens, "墨舟知识库") {
+		t.Errorf("Expected 墨舟知识库 as a single token after LoadUserDict, got %q", tokens)
+	}
+	RemoveWord("墨舟知识库")
+
+	if err := LoadUserDict(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("Expected error for missing user dictionary")
+	}
+	if err := AddWord("two words", 0, ""); err == nil {
+		t.Error("Expected error for word containing whitespace")
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	opts := NormalizeOptions{Lowercase: true, FullWidthToHalfWidth: true, Conversion: ConversionToSimplified}
+	if got := Normalize("ＡＢＣ１２３　資料庫與網絡", opts); got != "abc123 资料库与网络" {
+		t.Errorf("Unexpected normalization result %q", got)
+	}
+
+	// 简体字本身也是常用繁体字时，简转繁保持不变
+	if got := Normalize("后台数据", NormalizeOptions{Conversion: ConversionToTraditional}); got != "后台數據" {
+		t.Errorf("Unexpected s2t result %q", got)
+	}
+
+	if got := Normalize("ABC", NormalizeOptions{}); got != "ABC" {
+		t.Errorf("Expected zero options to keep text unchanged, got %q", got)
+	}
+}
+
+func TestTokenizeFiltered(t *testing.T) {
+	tokens := TokenizeFiltered("这是一个關於機器學習的测试！")
+	for _, stop := range []string{"这", "是", "一个", "的", "！"} {
+		if containsToken(tokens, stop) {
+			t.Errorf("Expected %q to be filtered, got %q", stop, tokens)
+		}
+	}
+	if !containsToken(tokens, "机器") && !containsToken(tokens, "机器学习") {
+		t.Errorf("Expected traditional text to be converted, got %q", tokens)
+	}
+
+	if tokens := TokenizeFiltered("The quick brown fox"); containsToken(tokens, "the") || !containsToken(tokens, "quick") {
+		t.Errorf("Unexpected English tokens %q", tokens)
+	}
+
+	// 自定义停用词
+	AddStopwords("测试")
+	if tokens := TokenizeFiltered("功能测试"); containsToken(tokens, "测试") {
+		t.Errorf("Expected custom stopword to be filtered, got %q", tokens)
+	}
+	RemoveStopwords("测试")
+	if !IsStopword("THE") || IsStopword("测试") {
+		t.Error("Unexpected stopword state")
+	}
+
+	if tokens := TokenizeFiltered("的了吗"); tokens != "" {
+		t.Errorf("Expected empty result for stopwords only, got %q", tokens)
+	}
+}
+
+func TestTokenizeSearchModeAndBigram(t *testing.T) {
+	tokens := TokenizeWithOptions("中华人民共和国成立", TokenizeOptions{SearchMode: true})
+	joined := strings.Join(tokens, " ")
+	for _, want := range []string{"中华人民共和国", "人民", "共和国"} {
+		if !containsToken(joined, want) {
+			t.Errorf("Expected %q in search mode tokens, got %v", want, tokens)
+		}
+	}
+
+	// 普通模式只输出长词
+	if joined := strings.Join(TokenizeWithOptions("中华人民共和国成立", TokenizeOptions{}), " "); containsToken(joined, "人民") {
+		t.Errorf("Expected no sub-words in normal mode, got %q", joined)
+	}
+
+	tokens = TokenizeWithOptions("墨舟科技的产品", TokenizeOptions{BigramFallback: true, RemoveStopwords: true})
+	if !containsToken(strings.Join(tokens, " "), "墨舟") {
+		t.Errorf("Expected bigram for OOV characters, got %v", tokens)
+	}
+}
+
+func TestExtractKeywords(t *testing.T) {
+	text := "知识图谱是一种结构化的语义知识库。知识图谱由实体和关系组成，实体之间通过关系连接，知识图谱广泛用于搜索引擎和问答系统。"
+
+	keywords := ExtractKeywords(text, 3)
+	if len(keywords) != 3 {
+		t.Fatalf("Expected 3 keywords, got %v", keywords)
+	}
+	found := false
+	for _, kw := range keywords {
+		if kw == "知识" || kw == "图谱" || kw == "知识图谱" {
+			found = true
+		}
+		if IsStopword(kw) {
+			t.Errorf("Unexpected stopword keyword %q", kw)
+		}
+	}
+	if !found {
+		t.Errorf("Expected knowledge graph related keyword, got %v", keywords)
+	}
+
+	ranked := ExtractKeywordsWithWeights(text, 0, KeywordTextRank)
+	if len(ranked) == 0 {
+		t.Fatal("Expected TextRank keywords")
+	}
+	if ranked[0].Weight != 1.0 {
+		t.Errorf("Expected normalized top weight 1.0, got %f", ranked[0].Weight)
+	}
+	for i := 1; i < len(ranked); i++ {
+		if ranked[i].Weight > ranked[i-1].Weight {
+			t.Errorf("Expected keywords sorted by weight, got %v", ranked)
+			break
+		}
+	}
+
+	if keywords := ExtractKeywords("的了吗", 5); len(keywords) != 0 {
+		t.Errorf("Expected no keywords for stopwords only, got %v", keywords)
+	}
+}
+
+func TestTokenizeWithPOS(t *testing.T) {
+	tokens := TokenizeWithPOS("张三在北京大学学习自然语言处理")
+	if len(tokens) == 0 {
+		t.Fatal("Expected tagged tokens")
+	}
+	for _, token := range tokens {
+		if token.Text == "" || token.Pos == "" {
+			t.Errorf("Unexpected empty token or pos: %+v", token)
+		}
+		if token.Text == "在" && !IsFunctionWordPOS(token.Pos) {
+			t.Errorf("Expected 在 to be a function word, got pos %q", token.Pos)
+		}
+	}
+
+	nouns := strings.Join(Nouns("我们在北京讨论知识图谱和API设计"), " ")
+	for _, want := range []string{"北京", "api"} {
+		if !containsToken(nouns, want) && !containsToken(nouns, strings.ToUpper(want)) {
+			t.Errorf("Expected nouns to contain %q, got %v", want, nouns)
+		}
+	}
+	if containsToken(nouns, "在") || containsToken(nouns, "讨论") {
+		t.Errorf("Expected nouns to exclude non-nouns, got %v", nouns)
+	}
+
+	opts := TokenizeOptions{RemoveFunctionWords: true}
+	filtered := strings.Join(TokenizeWithOptions("我在北京和上海工作", opts), " ")
+	if containsToken(filtered, "在") || containsToken(filtered, "和") {
+		t.Errorf("Expected function words removed, got %v", filtered)
+	}
+	if !containsToken(filtered, "北京") {
+		t.Errorf("Expected 北京 kept, got %v", filtered)
+	}
+}
+
+func TestSegmenterInstances(t *testing.T) {
+	text := "我们正在开发墨舟知识库引擎"
+
+	a, err := New(Options{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	b, err := New(Options{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	// 实例之间以及与全局分词器之间的词典互不影响
+	if err := a.AddWord("墨舟知识库", 0, "nz"); err != nil {
+		t.Fatalf("AddWord failed: %v", err)
+	}
+	if tokens := a.Tokenize(text); !containsToken(tokens, "墨舟知识库") {
+		t.Errorf("Expected 墨舟知识库 as a single token, got %q", tokens)
+	}
+	if tokens := b.Tokenize(text); containsToken(tokens, "墨舟知识库") {
+		t.Errorf("Expected other instance unaffected, got %q", tokens)
+	}
+	if tokens := Tokenize(text); containsToken(tokens, "墨舟知识库") {
+		t.Errorf("Expected global segmenter unaffected, got %q", tokens)
+	}
+
+	// 额外词典文件与创建时加载的用户词典
+	dir := t.TempDir()
+	dictPath := filepath.Join(dir, "extra_dict.txt")
+	if err := os.WriteFile(dictPath, []byte("知识库引擎 100000 n"), 0644); err != nil {
+		t.Fatalf("Failed to write dictionary: %v", err)
+	}
+	userDictPath := filepath.Join(dir, "user_dict.txt")
+	if err := os.WriteFile(userDictPath, []byte("开发墨舟\n"), 0644); err != nil {
+		t.Fatalf("Failed to write user dictionary: %v", err)
+	}
+	c, err := New(Options{DictionaryFiles: []string{dictPath}, UserDictFiles: []string{userDictPath}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if tokens := c.Tokenize(text); !containsToken(tokens, "开发墨舟") || !containsToken(tokens, "知识库引擎") {
+		t.Errorf("Expected words from user dictionary and extra dictionary, got %q", tokens)
+	}
+
+	// 并发的首次分词只构建一次分词器
+	d, err := New(Options{})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	var wg sync.WaitGroup
+	results := make([]*huichensego.Segmenter, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = d.Sego()
+		}(i)
+	}
+	wg.Wait()
+	for _, seg := range results {
+		if seg == nil || seg != results[0] {
+			t.Fatal("Expected all goroutines to share one lazily built segmenter")
+		}
+	}
+
+	if _, err := New(Options{WithoutEmbeddedDictionary: true}); err == nil {
+		t.Error("Expected error when no dictionary is configured")
+	}
+	if _, err := New(Options{DictionaryFiles: []string{filepath.Join(dir, "missing.txt")}}); err == nil {
+		t.Error("Expected error for missing dictionary file")
+	}
+}
+
+func TestPinyin(t *testing.T) {
+	if got := PinyinString("中国人民"); got != "zhongguorenmin" {
+		t.Errorf("Unexpected pinyin %q", got)
+	}
+	if got := PinyinInitials("知识图谱"); got != "zstp" {
+		t.Errorf("Unexpected initials %q", got)
+	}
+	// 繁体字按简体字查找，非汉字片段原样输出
+	if got := Pinyin("學習Go語言"); strings.Join(got, " ") != "xue xi go yu yan" {
+		t.Errorf("Unexpected pinyin %v", got)
+	}
+	if FuzzyPinyin("zhangsan") != FuzzyPinyin("zansang") {
+		t.Error("Expected fuzzy pinyin to ignore zh/z and ang/an")
+	}
+
+	shadow := PinyinShadow("这是知识图谱的介绍", false)
+	for _, want := range []string{"zhishi", "tupu", "jieshao"} {
+		if !containsToken(shadow, want) {
+			t.Errorf("Expected shadow to contain %q, got %q", want, shadow)
+		}
+	}
+	if containsToken(shadow, "de") {
+		t.Errorf("Expected stopwords excluded from shadow, got %q", shadow)
+	}
+
+	// 中文和拼音输入生成的检索词都能命中影子列
+	for _, query := range []string{"图谱", "tupu", "tu pu"} {
+		matched := false
+		for _, term := range strings.Fields(PinyinQuery(query, false)) {
+			if containsToken(shadow, term) {
+				matched = true
+			}
+		}
+		if !matched {
+			t.Errorf("Expected query %q to match shadow %q", query, shadow)
+		}
+	}
+
+	// 模糊音：平翘舌混淆的拼音输入
+	fuzzyShadow := PinyinShadow("知识", true)
+	if !containsToken(fuzzyShadow, PinyinQuery("zisi", true)) {
+		t.Errorf("Expected fuzzy query to match %q", fuzzyShadow)
+	}
+}
@@ -0,0 +1,94 @@
+package sego
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+//go:embed dictionary/stopwords.txt
+var stopwordsData []byte
+
+var (
+	stopwordsOnce sync.Once
+	stopwordsMu   sync.RWMutex
+	stopwords     map[string]bool
+)
+
+// loadDefaultStopwords 加载内嵌的默认停用词表
+func loadDefaultStopwords() {
+	stopwordsOnce.Do(func() {
+		words := make(map[string]bool)
+		scanner := bufio.NewScanner(bytes.NewReader(stopwordsData))
+		for scanner.Scan() {
+			word := strings.TrimSpace(scanner.Text())
+			if word == "" || strings.HasPrefix(word, "#") {
+				continue
+			}
+			words[strings.ToLower(word)] = true
+		}
+
+		stopwordsMu.Lock()
+		stopwords = words
+		stopwordsMu.Unlock()
+	})
+}
+
+// IsStopword 判断是否为停用词（不区分大小写）
+func IsStopword(word string) bool {
+	loadDefaultStopwords()
+	stopwordsMu.RLock()
+	defer stopwordsMu.RUnlock()
+	return stopwords[strings.ToLower(word)]
+}
+
+// AddStopwords 添加停用词
+func AddStopwords(words ...string) {
+	loadDefaultStopwords()
+	stopwordsMu.Lock()
+	defer stopwordsMu.Unlock()
+	for _, w := range words {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			stopwords[w] = true
+		}
+	}
+}
+
+// RemoveStopwords 从停用词表中移除指定的词（包括默认停用词）
+func RemoveStopwords(words ...string) {
+	loadDefaultStopwords()
+	stopwordsMu.Lock()
+	defer stopwordsMu.Unlock()
+	for _, w := range words {
+		delete(stopwords, strings.ToLower(strings.TrimSpace(w)))
+	}
+}
+
+// LoadStopwords 从文件加载停用词并追加到停用词表，每行一个词，以 # 开头的行为注释
+func LoadStopwords(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open stopwords file: %w", err)
+	}
+	defer f.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		word := strings.TrimSpace(scanner.Text())
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		words = append(words, word)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read stopwords file: %w", err)
+	}
+
+	AddStopwords(words...)
+	return nil
+}
@@ -0,0 +1,148 @@
+package sego
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	huichensego "github.com/huichen/sego"
+)
+
+// TokenizeOptions 分词选项
+type TokenizeOptions struct {
+	// Normalize 规范化选项，在分词前作用于整段文本
+	Normalize NormalizeOptions
+	// RemoveStopwords 过滤停用词
+	RemoveStopwords bool
+	// RemovePunctuation 过滤仅由标点、符号组成的词
+	RemovePunctuation bool
+	// SearchMode 搜索模式：在输出长词的同时输出其中包含的短词（如 "中华人民共和国" 还会输出 "中华"、"人民"、"共和国"），
+	// 用于索引侧，使较短的查询词也能命中之前索引的长复合词
+	SearchMode bool
+	// BigramFallback 对连续的单字（通常是词典未收录的人名、品牌名等）额外输出相邻两字组成的二元组
+	BigramFallback bool
+	// RemoveFunctionWords 按词性过滤虚词（介词、连词、助词、叹词、语气词、拟声词），见 IsFunctionWordPOS
+	RemoveFunctionWords bool
+}
+
+// DefaultTokenizeOptions 返回全文检索使用的默认分词选项：
+// 小写化、全角转半角、繁体转简体，并过滤停用词和标点
+func DefaultTokenizeOptions() TokenizeOptions {
+	return TokenizeOptions{
+		Normalize: NormalizeOptions{
+			Lowercase:            true,
+			FullWidthToHalfWidth: true,
+			Conversion:           ConversionToSimplified,
+		},
+		RemoveStopwords:   true,
+		RemovePunctuation: true,
+	}
+}
+
+// isPunctuation 判断词是否仅由标点、符号或空白组成
+func isPunctuation(token string) bool {
+	for _, r := range token {
+		if !unicode.IsPunct(r) && !unicode.IsSymbol(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// isSingleHan 判断词是否为单个汉字
+func isSingleHan(token string) bool {
+	r, size := utf8.DecodeRuneInString(token)
+	return size == len(token) && unicode.Is(unicode.Han, r)
+}
+
+// TokenizeWithOptions 按选项分词，返回词列表
+func (s *Segmenter) TokenizeWithOptions(text string, opts TokenizeOptions) []string {
+	if text == "" {
+		return nil
+	}
+
+	text = Normalize(text, opts.Normalize)
+	segmenter, err := s.Sego()
+	if err != nil {
+		return nil
+	}
+
+	var tokens []string
+	for _, tagged := range segmentWithOptions(segmenter, text, opts) {
+		token := tagged.Text
+		if opts.RemoveFunctionWords && IsFunctionWordPOS(tagged.Pos) {
+			continue
+		}
+		if opts.Normalize.Lowercase {
+			token = strings.ToLower(token)
+		}
+		if opts.RemovePunctuation && isPunctuation(token) {
+			continue
+		}
+		if opts.RemoveStopwords && IsStopword(token) {
+			continue
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+// segmentWithOptions 按搜索模式和二元组回退选项分词，返回去除空白后的非空词及其词性
+// 二元组的词性为 "x"
+func segmentWithOptions(segmenter *huichensego.Segmenter, text string, opts TokenizeOptions) []TaggedToken {
+	if !opts.BigramFallback {
+		return segmentTaggedTokens(segmenter, text, opts.SearchMode)
+	}
+
+	var tokens []TaggedToken
+	// run 记录当前连续的单字序列，遇到多字词、非汉字、停用词或虚词时结束
+	var run []string
+	flush := func() {
+		for i := 0; i+1 < len(run); i++ {
+			tokens = append(tokens, TaggedToken{Text: run[i] + run[i+1], Pos: "x"})
+		}
+		run = run[:0]
+	}
+
+	for _, seg := range segmenter.Segment([]byte(text)) {
+		tagged := taggedToken(seg.Token())
+		if tagged.Text == "" {
+			flush()
+			continue
+		}
+
+		if opts.SearchMode {
+			tokens = appendSearchModeTokens(tokens, seg.Token())
+		} else {
+			tokens = append(tokens, tagged)
+		}
+
+		if isSingleHan(tagged.Text) &&
+			!(opts.RemoveStopwords && IsStopword(tagged.Text)) &&
+			!(opts.RemoveFunctionWords && IsFunctionWordPOS(tagged.Pos)) {
+			run = append(run, tagged.Text)
+		} else {
+			flush()
+		}
+	}
+	flush()
+
+	return tokens
+}
+
+// TokenizeFiltered 使用 DefaultTokenizeOptions 分词，返回用空格分隔的词，供全文检索索引和查询使用
+// 与 Tokenize 不同，文本全部由停用词或标点组成时返回空字符串
+func (s *Segmenter) TokenizeFiltered(text string) string {
+	return strings.Join(s.TokenizeWithOptions(text, DefaultTokenizeOptions()), " ")
+}
+
+// TokenizeWithOptions 使用全局分词器按选项分词，返回词列表
+func TokenizeWithOptions(text string, opts TokenizeOptions) []string {
+	return defaultSegmenter.TokenizeWithOptions(text, opts)
+}
+
+// TokenizeFiltered 使用全局分词器和 DefaultTokenizeOptions 分词，返回用空格分隔的词，供全文检索索引和查询使用
+// 与 Tokenize 不同，文本全部由停用词或标点组成时返回空字符串
+func TokenizeFiltered(text string) string {
+	return defaultSegmenter.TokenizeFiltered(text)
+}
@@ -0,0 +1,192 @@
+package sego
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// DefaultUserWordFreq 用户词未指定词频时使用的默认词频
+// 该值足以让用户词优先于由常见词拼接而成的切分结果，从而作为一个整体输出
+const DefaultUserWordFreq = 10000
+
+// userWord 用户注册的词条
+type userWord struct {
+	freq int
+	pos  string
+}
+
+// LoadUserDict 加载用户词典文件，用于让领域术语（项目名、产品型号等）作为单个词输出
+// 文件每行格式为 "词 [词频] [词性]"，字段以空白分隔；空行和以 # 开头的行会被忽略
+// 词频缺省时使用 DefaultUserWordFreq。词典变更在下次分词时生效
+func (s *Segmenter) LoadUserDict(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open user dictionary: %w", err)
+	}
+	defer f.Close()
+
+	entries := make(map[string]userWord)
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		entry := userWord{freq: DefaultUserWordFreq}
+		if len(fields) >= 2 {
+			freq, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return fmt.Errorf("invalid frequency at line %d: %q", lineNo, fields[1])
+			}
+			if freq > 0 {
+				entry.freq = freq
+			}
+		}
+		if len(fields) >= 3 {
+			entry.pos = fields[2]
+		}
+		entries[normalizeDictWord(fields[0])] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read user dictionary: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for word, entry := range entries {
+		s.userWords[word] = entry
+		delete(s.removedWords, word)
+	}
+	s.dirty.Store(true)
+	return nil
+}
+
+// AddWord 注册一个自定义词，freq <= 0 时使用 DefaultUserWordFreq，pos 为词性（可为空）
+// 词不能包含空白字符。词典变更在下次分词时生效
+func (s *Segmenter) AddWord(word string, freq int, pos string) error {
+	word = normalizeDictWord(word)
+	if word == "" {
+		return fmt.Errorf("word is empty")
+	}
+	if strings.IndexFunc(word, unicode.IsSpace) >= 0 {
+		return fmt.Errorf("word %q contains whitespace", word)
+	}
+	if strings.IndexFunc(pos, unicode.IsSpace) >= 0 {
+		return fmt.Errorf("pos %q contains whitespace", pos)
+	}
+	if freq <= 0 {
+		freq = DefaultUserWordFreq
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.userWords[word] = userWord{freq: freq, pos: pos}
+	delete(s.removedWords, word)
+	s.dirty.Store(true)
+	return nil
+}
+
+// RemoveWord 从词典中移除一个词（包括内嵌词典和额外词典文件中的词），使其不再作为整体输出
+// 词典变更在下次分词时生效
+func (s *Segmenter) RemoveWord(word string) {
+	word = normalizeDictWord(word)
+	if word == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.userWords, word)
+	s.removedWords[word] = true
+	s.dirty.Store(true)
+}
+
+// LoadUserDict 为全局分词器加载用户词典文件，见 Segmenter.LoadUserDict
+func LoadUserDict(path string) error {
+	return defaultSegmenter.LoadUserDict(path)
+}
+
+// AddWord 为全局分词器注册一个自定义词，见 Segmenter.AddWord
+func AddWord(word string, freq int, pos string) error {
+	return defaultSegmenter.AddWord(word, freq, pos)
+}
+
+// RemoveWord 从全局分词器的词典中移除一个词，见 Segmenter.RemoveWord
+func RemoveWord(word string) {
+	defaultSegmenter.RemoveWord(word)
+}
+
+// normalizeDictWord 规范化词典中的词
+// sego 会把连续的字母数字转为小写后再查词典，这里保持一致，以便大小写不同的输入都能匹配
+func normalizeDictWord(word string) string {
+	return strings.ToLower(strings.TrimSpace(word))
+}
+
+// writeDictionary 写出合并后的词典，调用方需持有 mu
+// 用户词写在最前面：sego 加载词典时同一个词只保留第一次出现的词条，因此用户词频会覆盖其他词典
+func (s *Segmenter) writeDictionary(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for word, entry := range s.userWords {
+		if _, err := fmt.Fprintf(bw, "%s %d %s\n", word, entry.freq, entry.pos); err != nil {
+			return err
+		}
+	}
+
+	if !s.opts.WithoutEmbeddedDictionary {
+		if err := s.writeDictionaryData(bw, dictionaryData); err != nil {
+			return err
+		}
+	}
+	for _, path := range s.opts.DictionaryFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read dictionary %s: %w", path, err)
+		}
+		if err := s.writeDictionaryData(bw, data); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// writeDictionaryData 写出一份词典数据，跳过被 RemoveWord 移除的词
+func (s *Segmenter) writeDictionaryData(bw *bufio.Writer, data []byte) error {
+	if len(s.removedWords) == 0 {
+		if _, err := bw.Write(data); err != nil {
+			return err
+		}
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			return bw.WriteByte('\n')
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		word := line
+		if i := bytes.IndexAny(line, " \t"); i >= 0 {
+			word = line[:i]
+		}
+		if s.removedWords[strings.ToLower(string(word))] {
+			continue
+		}
+		if _, err := bw.Write(line); err != nil {
+			return err
+		}
+		if err := bw.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
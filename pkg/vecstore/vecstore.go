@@ -22,11 +22,18 @@ type Embedder interface {
 // Options VecStore配置选项
 type Options struct {
 	Embedder Embedder
+	// TableName 存储文档的表名，默认为 vecstore_documents
+	// 同一数据库中的多个 VecStore 应使用不同的表名
+	TableName string
+	// DB 可选，复用已打开的数据库连接（如与其他 VecStore 共享），为空时由 Initialize 打开
+	// 复用的连接不会被 Close 关闭
+	DB *sql.DB
 }
 
 // VecStore 基于DuckDB的纯文本向量搜索存储
 type VecStore struct {
 	db          *sql.DB
+	ownsDB      bool
 	tableName   string
 	embedder    Embedder
 	initialized bool
@@ -37,9 +44,14 @@ type VecStore struct {
 
 // New 创建VecStore实例
 func New(opts Options) *VecStore {
+	tableName := opts.TableName
+	if tableName == "" {
+		tableName = "vecstore_documents"
+	}
 	return &VecStore{
+		db:        opts.DB,
 		embedder:  opts.Embedder,
-		tableName: "vecstore_documents",
+		tableName: tableName,
 	}
 }
 
@@ -55,11 +67,14 @@ func (v *VecStore) Initialize(ctx context.Context) error {
 
 	// 打开DuckDB数据库连接
 	// duckdb-driver 会自动将所有路径映射到共享数据库文件 ./data/indexing/index.db
-	db, err := sql.Open("duckdb", "vecstore.db")
-	if err != nil {
-		return fmt.Errorf("failed to open database: %w", err)
+	if v.db == nil {
+		db, err := sql.Open("duckdb", "vecstore.db")
+		if err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+		v.db = db
+		v.ownsDB = true
 	}
-	v.db = db
 
 	// 创建表（如果不存在）
 	createTableSQL := fmt.Sprintf(`
@@ -74,7 +89,7 @@ func (v *VecStore) Initialize(ctx context.Context) error {
 		)
 	`, v.tableName)
 
-	_, err = v.db.ExecContext(ctx, createTableSQL)
+	_, err := v.db.ExecContext(ctx, createTableSQL)
 	if err != nil {
 		return fmt.Errorf("failed to create table: %w", err)
 	}
@@ -366,7 +381,7 @@ func (v *VecStore) processPendingEmbeddings(ctx context.Context) {
 
 // Close 关闭VecStore
 func (v *VecStore) Close() error {
-	if v.db != nil {
+	if v.db != nil && v.ownsDB {
 		return v.db.Close()
 	}
 	return nil
@@ -84,6 +84,47 @@ func TestInitialize(t *testing.T) {
 	store.Close()
 }
 
+func TestSharedDB(t *testing.T) {
+	ctx := context.Background()
+	store := New(Options{Embedder: newMockEmbedder(128)})
+	if err := store.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer store.Close()
+
+	other := New(Options{
+		Embedder:  newMockEmbedder(128),
+		TableName: "vecstore_test_shared",
+		DB:        store.GetDB(),
+	})
+	if other.GetTableName() != "vecstore_test_shared" {
+		t.Errorf("expected table name 'vecstore_test_shared', got '%s'", other.GetTableName())
+	}
+	if err := other.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	defer store.GetDB().Exec("DROP TABLE IF EXISTS vecstore_test_shared")
+
+	if err := other.Insert(ctx, "shared table document", nil); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	var count int
+	if err := store.GetDB().QueryRow("SELECT COUNT(*) FROM vecstore_test_shared").Scan(&count); err != nil {
+		t.Fatalf("count failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 document in shared table, got %d", count)
+	}
+
+	// 复用的连接不会被关闭
+	if err := other.Close(); err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+	if err := store.GetDB().Ping(); err != nil {
+		t.Errorf("shared db should stay open after Close: %v", err)
+	}
+}
+
 func TestInsert_WithoutInitialization(t *testing.T) {
 	embedder := newMockEmbedder(128)
 	store := New(Options{